@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/mergestat/mergestat-lite/cmd/changelog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	changelogFrom          string
+	changelogTo            string
+	changelogGitHubRepo    string
+	changelogLookupAuthors bool
+)
+
+func init() {
+	changelogCmd.Flags().StringVar(&changelogFrom, "from", "", "revision to generate the changelog from (exclusive), such as the previous release tag. Defaults to the start of history")
+	changelogCmd.Flags().StringVar(&changelogTo, "to", "", "revision to generate the changelog up to (inclusive). Defaults to HEAD (or --default-ref)")
+	changelogCmd.Flags().StringVar(&changelogGitHubRepo, "github-repo", "", "owner/name of the GitHub repository to link pull requests, issues and commits to. Detected from the origin remote if not supplied")
+	changelogCmd.Flags().BoolVar(&changelogLookupAuthors, "github-authors", false, "credit the authors of pull requests, looked up with the GitHub API (requires GITHUB_TOKEN)")
+}
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Generate a Markdown changelog for a revision range",
+	Long: `Prints a Markdown changelog of the commits in the default repository (either the current directory or supplied by --repo)
+between two revisions. Commits are grouped by their Conventional Commit type (https://www.conventionalcommits.org),
+with breaking changes listed first. Pull request numbers are read from the "(#123)" suffix of squashed commits,
+and closed issues from keywords such as "fixes #45". Merge commits are not listed themselves.
+
+Example:
+  mergestat changelog --from v0.5.0 --to v0.6.0 > CHANGELOG.md
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		githubRepo := changelogGitHubRepo
+		if githubRepo == "" {
+			githubRepo = detectGitHubRepo()
+		}
+
+		if changelogLookupAuthors && githubToken == "" && !offline {
+			handleExitError(fmt.Errorf("--github-authors requires a GITHUB_TOKEN"))
+		}
+
+		var db *sql.DB
+		var err error
+		if db, err = sql.Open("sqlite3", ":memory:"); err != nil {
+			handleExitError(fmt.Errorf("failed to initialize database connection: %v", err))
+		}
+		defer db.Close()
+
+		var out string
+		if out, err = changelog.Generate(db, &changelog.Options{
+			From:          changelogFrom,
+			To:            changelogTo,
+			GitHubRepo:    githubRepo,
+			LookupAuthors: changelogLookupAuthors,
+		}); err != nil {
+			handleExitError(err)
+		}
+
+		fmt.Print(out)
+	},
+}
+
+// detectGitHubRepo returns the owner/name of the GitHub repository the default repository (--repo,
+// or the current directory) was cloned from, or an empty string if it isn't hosted on GitHub
+func detectGitHubRepo() string {
+	path := repo
+	if path == "" {
+		path = "."
+	}
+	return changelog.DetectGitHubRepo(path)
+}
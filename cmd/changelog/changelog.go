@@ -0,0 +1,313 @@
+package changelog
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// rangeCommitsSQL selects the (non-merge) commits reachable from $to but not from $from, newest first.
+// Merge commits are skipped, as the changes they bring in are listed through the merged commits themselves.
+const rangeCommitsSQL = `
+SELECT hash, message FROM commits('', $to)
+WHERE parents < 2 AND hash NOT IN (SELECT hash FROM commits('', $from))
+ORDER BY committer_when DESC
+`
+
+// allCommitsSQL selects all of the (non-merge) commits reachable from $to, newest first
+const allCommitsSQL = `
+SELECT hash, message FROM commits('', $to) WHERE parents < 2 ORDER BY committer_when DESC
+`
+
+// mergedPullRequestsSQL selects the merged pull requests of a GitHub repository
+const mergedPullRequestsSQL = `
+SELECT number, author_login FROM github_repo_pull_requests($repo) WHERE merged
+`
+
+// Options configure the generation of a changelog
+type Options struct {
+	From, To   string // the revision range to generate the changelog for
+	GitHubRepo string // owner/name of the GitHub repository, used to link pull requests, issues and commits
+	// LookupAuthors, if set, looks up the authors of pull requests using the github tables (and so requires a token)
+	LookupAuthors bool
+}
+
+// Entry is a single change listed in a changelog
+type Entry struct {
+	Hash        string
+	Type        string // the Conventional Commit type, or empty if the message doesn't follow the convention
+	Scope       string
+	Description string
+	Breaking    bool
+	PullRequest int   // number of the pull request the change was merged in, if known
+	Issues      []int // numbers of the issues the change closes
+	Author      string
+}
+
+// sections lists the headings of the changelog, in the order they are rendered.
+// Types not listed here (and non-conventional messages) end up under "Other Changes".
+var sections = []struct{ typ, heading string }{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance Improvements"},
+	{"refactor", "Code Refactoring"},
+	{"revert", "Reverts"},
+	{"docs", "Documentation"},
+	{"test", "Tests"},
+	{"build", "Build System"},
+	{"ci", "Continuous Integration"},
+	{"style", "Styles"},
+	{"chore", "Chores"},
+}
+
+var (
+	conventionalHeader = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+	breakingFooter     = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:`)
+	squashedPR         = regexp.MustCompile(`\s*\(#(\d+)\)$`)
+	closesIssue        = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?)\s+#(\d+)`)
+)
+
+// Generate renders the Markdown changelog of the commits in the range described by opt,
+// querying the commits (and github) tables through db
+func Generate(db *sql.DB, opt *Options) (string, error) {
+	var rows *sql.Rows
+	var err error
+	if opt.From != "" {
+		rows, err = db.Query(rangeCommitsSQL, sql.Named("from", opt.From), sql.Named("to", opt.To))
+	} else {
+		rows, err = db.Query(allCommitsSQL, sql.Named("to", opt.To))
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query commits: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []*Entry
+	for rows.Next() {
+		var hash, message string
+		if err = rows.Scan(&hash, &message); err != nil {
+			return "", fmt.Errorf("failed to scan commit: %v", err)
+		}
+		entries = append(entries, Parse(hash, message))
+	}
+	if err = rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to query commits: %v", err)
+	}
+
+	if opt.LookupAuthors && opt.GitHubRepo != "" {
+		if err = lookupAuthors(db, opt.GitHubRepo, entries); err != nil {
+			return "", err
+		}
+	}
+
+	title := opt.To
+	if title == "" {
+		title = "HEAD"
+	}
+	if opt.From != "" {
+		title = opt.From + "..." + title
+	}
+
+	return Render(title, opt.GitHubRepo, entries), nil
+}
+
+// lookupAuthors sets the author of entries merged through a pull request, to the GitHub login of whoever opened it
+func lookupAuthors(db *sql.DB, repo string, entries []*Entry) error {
+	var wanted = make(map[int][]*Entry)
+	for _, entry := range entries {
+		if entry.PullRequest != 0 {
+			wanted[entry.PullRequest] = append(wanted[entry.PullRequest], entry)
+		}
+	}
+	if len(wanted) == 0 {
+		return nil
+	}
+
+	rows, err := db.Query(mergedPullRequestsSQL, sql.Named("repo", repo))
+	if err != nil {
+		return fmt.Errorf("failed to query pull requests: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var number int
+		var author sql.NullString
+		if err = rows.Scan(&number, &author); err != nil {
+			return fmt.Errorf("failed to scan pull request: %v", err)
+		}
+		for _, entry := range wanted[number] {
+			entry.Author = author.String
+		}
+	}
+
+	return rows.Err()
+}
+
+// Parse extracts a changelog entry from a commit message, following the Conventional Commits specification
+// (https://www.conventionalcommits.org). The number of the pull request is read from the "(#123)" suffix
+// GitHub appends to the subject of squashed commits, and issues from keywords such as "fixes #45".
+func Parse(hash, message string) *Entry {
+	subject, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	subject = strings.TrimSpace(subject)
+
+	var entry = &Entry{Hash: hash}
+	if m := squashedPR.FindStringSubmatch(subject); m != nil {
+		entry.PullRequest, _ = strconv.Atoi(m[1])
+		subject = strings.TrimSuffix(subject, m[0])
+	}
+
+	if m := conventionalHeader.FindStringSubmatch(subject); m != nil {
+		entry.Type, entry.Scope, entry.Breaking, entry.Description = strings.ToLower(m[1]), m[2], m[3] == "!", m[4]
+	} else {
+		entry.Description = subject
+	}
+
+	if breakingFooter.MatchString(body) {
+		entry.Breaking = true
+	}
+
+	var seen = make(map[int]bool)
+	for _, m := range closesIssue.FindAllStringSubmatch(message, -1) {
+		if n, _ := strconv.Atoi(m[1]); !seen[n] && n != entry.PullRequest {
+			entry.Issues = append(entry.Issues, n)
+			seen[n] = true
+		}
+	}
+
+	return entry
+}
+
+// Render renders entries as a Markdown changelog, with a section per type of change (and one for breaking changes).
+// If githubRepo (owner/name) is set, pull requests, issues and commits are linked to.
+func Render(title, githubRepo string, entries []*Entry) string {
+	var groups = make(map[string][]*Entry)
+	var breaking []*Entry
+	for _, entry := range entries {
+		if entry.Breaking {
+			breaking = append(breaking, entry)
+		}
+		groups[sectionOf(entry.Type)] = append(groups[sectionOf(entry.Type)], entry)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Changelog (%s)\n", title)
+
+	if len(entries) == 0 {
+		b.WriteString("\nNo changes.\n")
+		return b.String()
+	}
+
+	var section = func(heading string, entries []*Entry) {
+		if len(entries) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", heading)
+		for _, entry := range entries {
+			b.WriteString(renderEntry(githubRepo, entry))
+		}
+	}
+
+	section("⚠ Breaking Changes", breaking)
+	for _, s := range sections {
+		section(s.heading, groups[s.typ])
+	}
+	section("Other Changes", groups[""])
+
+	return b.String()
+}
+
+// sectionOf returns the type an entry of type typ is grouped under
+func sectionOf(typ string) string {
+	for _, s := range sections {
+		if s.typ == typ {
+			return typ
+		}
+	}
+	return ""
+}
+
+func renderEntry(githubRepo string, entry *Entry) string {
+	var b strings.Builder
+	b.WriteString("- ")
+	if entry.Scope != "" {
+		fmt.Fprintf(&b, "**%s:** ", entry.Scope)
+	}
+	b.WriteString(entry.Description)
+
+	if entry.PullRequest != 0 {
+		fmt.Fprintf(&b, " (%s)", link(githubRepo, "pull", entry.PullRequest))
+	}
+
+	if entry.Author != "" {
+		fmt.Fprintf(&b, " by @%s", entry.Author)
+	}
+
+	if len(entry.Issues) > 0 {
+		var issues []string
+		for _, n := range entry.Issues {
+			issues = append(issues, link(githubRepo, "issues", n))
+		}
+		fmt.Fprintf(&b, ", closes %s", strings.Join(issues, ", "))
+	}
+
+	short := entry.Hash
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	if githubRepo != "" {
+		fmt.Fprintf(&b, " ([%s](https://github.com/%s/commit/%s))", short, githubRepo, entry.Hash)
+	} else {
+		fmt.Fprintf(&b, " (%s)", short)
+	}
+
+	b.WriteString("\n")
+	return b.String()
+}
+
+// link renders a reference to a pull request or issue, as a Markdown link if githubRepo is set
+func link(githubRepo, kind string, n int) string {
+	if githubRepo == "" {
+		return fmt.Sprintf("#%d", n)
+	}
+	return fmt.Sprintf("[#%d](https://github.com/%s/%s/%d)", n, githubRepo, kind, n)
+}
+
+// DetectGitHubRepo returns the owner/name of the GitHub repository the origin remote
+// of the repository at path points to, or an empty string if there's none
+func DetectGitHubRepo(path string) string {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return ""
+	}
+
+	remote, err := repo.Remote("origin")
+	if err != nil || len(remote.Config().URLs) == 0 {
+		return ""
+	}
+
+	return gitHubRepoFromURL(remote.Config().URLs[0])
+}
+
+// gitHubRepoFromURL parses the owner/name out of the URL of a GitHub remote,
+// in either the https (https://github.com/owner/name.git) or scp-like (git@github.com:owner/name.git) form
+func gitHubRepoFromURL(remote string) string {
+	var p string
+	if strings.HasPrefix(remote, "git@github.com:") {
+		p = strings.TrimPrefix(remote, "git@github.com:")
+	} else if u, err := url.Parse(remote); err == nil && u.Host == "github.com" {
+		p = strings.TrimPrefix(u.Path, "/")
+	} else {
+		return ""
+	}
+
+	p = strings.TrimSuffix(strings.TrimSuffix(p, "/"), ".git")
+	if parts := strings.Split(p, "/"); len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+		return p
+	}
+	return ""
+}
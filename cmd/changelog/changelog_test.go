@@ -0,0 +1,77 @@
+package changelog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected *Entry
+	}{
+		{"feat(git): add commit_graph function (#42)", &Entry{Type: "feat", Scope: "git", Description: "add commit_graph function", PullRequest: 42}},
+		{"fix: handle empty repos\n\nFixes #7 and closes #8", &Entry{Type: "fix", Description: "handle empty repos", Issues: []int{7, 8}}},
+		{"refactor!: drop the legacy backend", &Entry{Type: "refactor", Description: "drop the legacy backend", Breaking: true}},
+		{"feat: new flag\n\nBREAKING CHANGE: the old flag is gone", &Entry{Type: "feat", Description: "new flag", Breaking: true}},
+		{"Update README.md", &Entry{Description: "Update README.md"}},
+	}
+
+	for _, test := range tests {
+		entry := Parse("abc", test.message)
+		test.expected.Hash = "abc"
+		if !reflect.DeepEqual(entry, test.expected) {
+			t.Fatalf("parsing %q: expected %+v, got %+v", test.message, test.expected, entry)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	entries := []*Entry{
+		Parse("1111111111", "feat(git): add commit_graph function (#42)"),
+		Parse("2222222222", "fix: handle empty repos\n\nfixes #7"),
+		Parse("3333333333", "refactor!: drop the legacy backend"),
+		Parse("4444444444", "Update README.md"),
+	}
+
+	out := Render("v1.0.0...HEAD", "mergestat/mergestat-lite", entries)
+
+	for _, expected := range []string{
+		"# Changelog (v1.0.0...HEAD)",
+		"## ⚠ Breaking Changes\n\n- drop the legacy backend",
+		"## Features\n\n- **git:** add commit_graph function ([#42](https://github.com/mergestat/mergestat-lite/pull/42)) ([1111111](https://github.com/mergestat/mergestat-lite/commit/1111111111))",
+		"## Bug Fixes\n\n- handle empty repos, closes [#7](https://github.com/mergestat/mergestat-lite/issues/7)",
+		"## Other Changes\n\n- Update README.md",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+
+	// sections are rendered in a fixed order
+	if strings.Index(out, "## Features") > strings.Index(out, "## Bug Fixes") {
+		t.Fatalf("expected features to be listed before bug fixes, got:\n%s", out)
+	}
+
+	// without a GitHub repository, references aren't linked
+	if out := Render("HEAD", "", entries[:1]); !strings.Contains(out, "- **git:** add commit_graph function (#42) (1111111)") {
+		t.Fatalf("expected unlinked references, got:\n%s", out)
+	}
+}
+
+func TestGitHubRepoFromURL(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/mergestat/mergestat-lite.git": "mergestat/mergestat-lite",
+		"https://github.com/mergestat/mergestat-lite":     "mergestat/mergestat-lite",
+		"git@github.com:mergestat/mergestat-lite.git":     "mergestat/mergestat-lite",
+		"https://gitlab.com/mergestat/mergestat-lite.git": "",
+		"/some/local/path": "",
+	}
+
+	for remote, expected := range tests {
+		if repo := gitHubRepoFromURL(remote); repo != expected {
+			t.Fatalf("expected %q for %q, got %q", expected, remote, repo)
+		}
+	}
+}
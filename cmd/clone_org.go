@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/mergestat/mergestat-lite/cmd/cloneorg"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cloneOrgDest            string
+	cloneOrgConcurrency     int
+	cloneOrgIncludeArchived bool
+	cloneOrgIncludeForks    bool
+)
+
+func init() {
+	cloneOrgCmd.Flags().StringVar(&cloneOrgDest, "dest", "./repos", "directory to keep the repositories in, as <dest>/<org>/<name>.git")
+	cloneOrgCmd.Flags().IntVar(&cloneOrgConcurrency, "concurrency", 4, "number of repositories to clone or fetch in parallel")
+	cloneOrgCmd.Flags().BoolVar(&cloneOrgIncludeArchived, "include-archived", false, "also sync archived repositories")
+	cloneOrgCmd.Flags().BoolVar(&cloneOrgIncludeForks, "include-forks", false, "also sync forks")
+}
+
+var cloneOrgCmd = &cobra.Command{
+	Use:   "clone-org <org>",
+	Short: "Clone or update all repositories of a GitHub organization",
+	Long: `Lists the repositories of a GitHub organization (or user) with the GitHub API (requires GITHUB_TOKEN), and clones them
+as bare repositories into --dest, or fetches them if they were cloned already. Repositories are synced in parallel.
+
+A manifest of the synced repositories is written to the repos table of <dest>/manifest.db, which can be mounted
+to run queries across all of them. For instance:
+
+  mergestat clone-org mergestat --dest ./repos
+  mergestat --db ./repos/manifest.db "SELECT repos.name, count(*) FROM repos, commits(repos.path) GROUP BY repos.name"
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if githubToken == "" {
+			handleExitError(fmt.Errorf("clone-org requires a GITHUB_TOKEN"))
+		}
+
+		var db *sql.DB
+		var err error
+		if db, err = sql.Open("sqlite3", ":memory:"); err != nil {
+			handleExitError(fmt.Errorf("failed to initialize database connection: %v", err))
+		}
+		defer db.Close()
+
+		var repos []*cloneorg.Repo
+		if repos, err = cloneorg.ListRepos(db, args[0], &cloneorg.ListOptions{Archived: cloneOrgIncludeArchived, Forks: cloneOrgIncludeForks}); err != nil {
+			handleExitError(err)
+		}
+
+		if err = os.MkdirAll(cloneOrgDest, 0755); err != nil {
+			handleExitError(fmt.Errorf("failed to create %s: %v", cloneOrgDest, err))
+		}
+
+		var done int
+		results := cloneorg.Sync(context.Background(), cloneOrgDest, repos, &cloneorg.SyncOptions{
+			Concurrency:     cloneOrgConcurrency,
+			Auth:            &http.BasicAuth{Username: githubToken},
+			InsecureSkipTLS: gitSSLNoVerify != "",
+			Progress: func(result *cloneorg.Result) {
+				done++
+				if result.Err != nil {
+					logger.Error().Err(result.Err).Msgf("[%d/%d] failed to sync %s", done, len(repos), result.Repo.FullName)
+				} else {
+					logger.Info().Bool("cloned", result.Cloned).Msgf("[%d/%d] synced %s", done, len(repos), result.Repo.FullName)
+				}
+			},
+		})
+
+		var failed int
+		for _, result := range results {
+			if result.Err != nil {
+				failed++
+			}
+		}
+
+		manifest := filepath.Join(cloneOrgDest, "manifest.db")
+		if err = cloneorg.WriteManifest(manifest, results); err != nil {
+			handleExitError(err)
+		}
+
+		fmt.Printf("synced %d of %d repositories into %s (manifest: %s)\n", len(results)-failed, len(results), cloneOrgDest, manifest)
+	},
+}
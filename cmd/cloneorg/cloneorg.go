@@ -0,0 +1,231 @@
+// Package cloneorg keeps a local cache of the repositories of a GitHub organization up to date,
+// so that queries can be run across all of them without cloning on-demand
+package cloneorg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// orgReposSQL lists the repositories of a GitHub organization (or user), using the github tables
+const orgReposSQL = `
+SELECT name, default_branch_ref_name, is_archived, is_fork, pushed_at
+FROM github_org_repos($org)
+WHERE ($archived OR NOT is_archived) AND ($forks OR NOT is_fork)
+ORDER BY name
+`
+
+// Repo is a repository to keep in the cache
+type Repo struct {
+	Name          string
+	FullName      string // owner/name
+	URL           string
+	DefaultBranch string
+	Archived      bool
+	Fork          bool
+	PushedAt      string
+}
+
+// ListOptions configure which repositories of an organization are listed
+type ListOptions struct {
+	Archived bool // include archived repositories
+	Forks    bool // include forks
+}
+
+// ListRepos lists the repositories of the GitHub organization org, querying the github tables through db
+func ListRepos(db *sql.DB, org string, opt *ListOptions) ([]*Repo, error) {
+	rows, err := db.Query(orgReposSQL, sql.Named("org", org), sql.Named("archived", opt.Archived), sql.Named("forks", opt.Forks))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories of %s: %v", org, err)
+	}
+	defer rows.Close()
+
+	var repos []*Repo
+	for rows.Next() {
+		var repo = &Repo{}
+		var defaultBranch, pushedAt sql.NullString
+		if err = rows.Scan(&repo.Name, &defaultBranch, &repo.Archived, &repo.Fork, &pushedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan repository: %v", err)
+		}
+
+		repo.FullName = org + "/" + repo.Name
+		repo.URL = "https://github.com/" + repo.FullName
+		repo.DefaultBranch, repo.PushedAt = defaultBranch.String, pushedAt.String
+		repos = append(repos, repo)
+	}
+
+	return repos, rows.Err()
+}
+
+// SyncOptions configure how repositories are synced into the cache
+type SyncOptions struct {
+	Concurrency     int
+	Auth            transport.AuthMethod
+	InsecureSkipTLS bool
+	// Progress, if set, is called as each repository is done syncing. Calls are serialized.
+	Progress func(*Result)
+}
+
+// Result is the outcome of syncing a single repository
+type Result struct {
+	Repo     *Repo
+	Path     string
+	Cloned   bool // whether the repository was cloned, rather than fetched
+	SyncedAt time.Time
+	Err      error
+}
+
+// Path returns where repo is kept in a cache rooted at dest, following the layout dest/<owner>/<name>.git
+func Path(dest string, repo *Repo) string {
+	return filepath.Join(dest, filepath.FromSlash(repo.FullName)+".git")
+}
+
+// Sync clones (as bare repositories) or fetches all of repos into the cache rooted at dest, in parallel.
+// All branches and tags are mirrored, and HEAD points at the default branch of each repository.
+// A repository failing to sync doesn't stop the others, its error is reported in its result instead.
+func Sync(ctx context.Context, dest string, repos []*Repo, opt *SyncOptions) []*Result {
+	concurrency := opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var results = make([]*Result, len(repos))
+	var indexes = make(chan int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = syncRepo(ctx, dest, repos[i], opt)
+				if opt.Progress != nil {
+					mu.Lock()
+					opt.Progress(results[i])
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range repos {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+func syncRepo(ctx context.Context, dest string, r *Repo, opt *SyncOptions) *Result {
+	var result = &Result{Repo: r, Path: Path(dest, r)}
+
+	repo, err := git.PlainOpen(result.Path)
+	if err == git.ErrRepositoryNotExists {
+		result.Cloned = true
+		if err = os.MkdirAll(result.Path, 0755); err == nil {
+			repo, err = git.PlainInit(result.Path, true)
+		}
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if _, err = repo.Remote("origin"); err == git.ErrRemoteNotFound {
+		_, err = repo.CreateRemote(&config.RemoteConfig{
+			Name:  "origin",
+			URLs:  []string{r.URL},
+			Fetch: []config.RefSpec{"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"},
+		})
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	err = repo.FetchContext(ctx, &git.FetchOptions{RemoteName: "origin", Auth: opt.Auth, InsecureSkipTLS: opt.InsecureSkipTLS, Force: true})
+	if err != nil && err != git.NoErrAlreadyUpToDate && err != transport.ErrEmptyRemoteRepository {
+		result.Err = err
+		return result
+	}
+
+	if r.DefaultBranch != "" {
+		head := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(r.DefaultBranch))
+		if err = repo.Storer.SetReference(head); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+
+	result.SyncedAt = time.Now()
+	return result
+}
+
+// WriteManifest (re)creates the repos table of the manifest database at path, listing the outcome of a sync.
+// The manifest can be mounted with --db, to run queries across all repositories (e.g. with commits(repos.path)).
+func WriteManifest(path string, results []*Result) error {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return fmt.Errorf("failed to open manifest: %v", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	const schema = `
+		DROP TABLE IF EXISTS repos;
+		CREATE TABLE repos (
+			full_name		TEXT PRIMARY KEY,
+			name			TEXT,
+			url				TEXT,
+			path			TEXT,
+			default_branch	TEXT,
+			is_archived		BOOLEAN,
+			is_fork			BOOLEAN,
+			pushed_at		DATETIME,
+			synced_at		DATETIME,
+			error			TEXT
+		)`
+	if _, err = tx.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create manifest: %v", err)
+	}
+
+	for _, result := range results {
+		var syncedAt, syncErr interface{}
+		if !result.SyncedAt.IsZero() {
+			syncedAt = result.SyncedAt.Format(time.RFC3339)
+		}
+		if result.Err != nil {
+			syncErr = result.Err.Error()
+		}
+
+		path, err := filepath.Abs(result.Path)
+		if err != nil {
+			return err
+		}
+
+		r := result.Repo
+		_, err = tx.Exec("INSERT INTO repos VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			r.FullName, r.Name, r.URL, path, r.DefaultBranch, r.Archived, r.Fork, r.PushedAt, syncedAt, syncErr)
+		if err != nil {
+			return fmt.Errorf("failed to write manifest: %v", err)
+		}
+	}
+
+	return tx.Commit()
+}
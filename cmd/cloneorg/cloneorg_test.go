@@ -0,0 +1,101 @@
+package cloneorg
+
+import (
+	"context"
+	"database/sql"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSync(t *testing.T) {
+	src, dest := t.TempDir(), t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-C", src, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	run("init", "-b", "trunk")
+	run("commit", "--allow-empty", "-m", "initial")
+	run("tag", "v1.0.0")
+
+	repos := []*Repo{
+		{Name: "widgets", FullName: "acme/widgets", URL: src, DefaultBranch: "trunk"},
+		{Name: "missing", FullName: "acme/missing", URL: filepath.Join(src, "does-not-exist")},
+	}
+
+	results := Sync(context.Background(), dest, repos, &SyncOptions{Concurrency: 2})
+	if !results[0].Cloned || results[0].Err != nil {
+		t.Fatalf("expected widgets to be cloned, got: %+v", results[0])
+	}
+
+	if results[1].Err == nil {
+		t.Fatalf("expected syncing a missing repository to fail")
+	}
+
+	// new commits are fetched on the next sync
+	run("commit", "--allow-empty", "-m", "second")
+	results = Sync(context.Background(), dest, repos[:1], &SyncOptions{Concurrency: 1})
+	if results[0].Cloned || results[0].Err != nil {
+		t.Fatalf("expected widgets to be fetched, got: %+v", results[0])
+	}
+
+	repo, err := git.PlainOpen(filepath.Join(dest, "acme", "widgets.git"))
+	if err != nil {
+		t.Fatalf("failed to open synced repository: %v", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil || head.Name() != plumbing.NewBranchReferenceName("trunk") {
+		t.Fatalf("expected HEAD to point at trunk, got: %v (%v)", head, err)
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil || strings.TrimSpace(commit.Message) != "second" {
+		t.Fatalf("expected HEAD to be fetched, got: %v (%v)", commit, err)
+	}
+
+	if _, err = repo.Tag("v1.0.0"); err != nil {
+		t.Fatalf("expected tags to be fetched: %v", err)
+	}
+}
+
+func TestWriteManifest(t *testing.T) {
+	dest := t.TempDir()
+	manifest := filepath.Join(dest, "manifest.db")
+
+	results := Sync(context.Background(), dest, []*Repo{{Name: "missing", FullName: "acme/missing", URL: filepath.Join(dest, "nope")}}, &SyncOptions{})
+	if err := WriteManifest(manifest, results); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+
+	// writing the manifest again replaces its contents
+	if err := WriteManifest(manifest, results); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var count int
+	var path string
+	var syncErr sql.NullString
+	if err = db.QueryRow("SELECT count(*), path, error FROM repos").Scan(&count, &path, &syncErr); err != nil {
+		t.Fatalf("failed to query manifest: %v", err)
+	}
+
+	if count != 1 || path != filepath.Join(dest, "acme", "missing.git") || !syncErr.Valid {
+		t.Fatalf("unexpected manifest contents: count=%d path=%s error=%v", count, path, syncErr)
+	}
+}
@@ -1,6 +1,6 @@
 -- The following is SQLite SQL.
 -- Table valued function commits, columns = [hash, message, author_name, author_email, author_when, committer_name, committer_email, committer_when, parents]
--- Table valued function refs, columns = [name, type, remotate, full_name, hash, target]
+-- Table valued function refs, columns = [name, type, remotate, full_name, hash, target, symbolic]
 -- Table valued function stats, columns = [file_path, additions, deletions]
 -- Table valued function files, columns = [path, executable, contents]
 -- Table valued function blame, columns = [line_no, commit_hash]
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mergestat/mergestat-lite/cmd/fleet"
+	"github.com/mergestat/mergestat-lite/pkg/display"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fleetRoot        string
+	fleetMaxDepth    int
+	fleetConcurrency int
+	fleetQueries     []string
+	fleetColumns     string
+	fleetFormat      string
+)
+
+func init() {
+	fleetStatusCmd.Flags().StringVar(&fleetRoot, "root", ".", "directory to discover the repositories under")
+	fleetStatusCmd.Flags().IntVar(&fleetMaxDepth, "max-depth", -1, "how many directories deep to look for repositories under --root (unlimited if negative)")
+	fleetStatusCmd.Flags().IntVar(&fleetConcurrency, "concurrency", 4, "number of repositories to query in parallel")
+	fleetStatusCmd.Flags().StringArrayVar(&fleetQueries, "query", nil, "add a column to the dashboard, as name=SQL, with the path of each repository bound to $repo (replaces the default column of the same name). Can be repeated.")
+	fleetStatusCmd.Flags().StringVar(&fleetColumns, "columns", "", "comma separated list of the columns to show, in order (defaults to all of them)")
+	fleetStatusCmd.Flags().StringVarP(&fleetFormat, "format", "f", "table", "specify the output format. Options are 'csv' 'csv-noheader' 'tsv' 'tsv-noheader' 'table' 'single' 'ndjson' and 'json'")
+
+	fleetCmd.AddCommand(fleetStatusCmd)
+}
+
+var fleetCmd = &cobra.Command{
+	Use:   "fleet [command]",
+	Short: "Report on all of the repositories under a directory",
+}
+
+var fleetStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show a dashboard of the status of all of the repositories under a directory",
+	Long: `Discovers the repositories under --root, and shows a dashboard with a row for each of them, holding the results
+of a set of queries run against each repository, in parallel. By default, these are the branch checked out,
+the number of files with uncommitted changes, the number of commits ahead and behind the same branch on origin
+(empty without one), and the age of the last commit.
+
+Columns are added (or replaced) with --query name=SQL, where the path of the repository is bound to $repo, and
+the first column of the first row is shown. Columns are selected with --columns. For instance:
+
+  mergestat fleet status --root ~/src
+  mergestat fleet status --root ~/src --query "stashes=SELECT count(*) FROM refs(\$repo) WHERE full_name = 'refs/stash'"
+  mergestat fleet status --root ~/src --columns branch,dirty --format json
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var extra = make([]*fleet.Query, len(fleetQueries))
+		for i, q := range fleetQueries {
+			var err error
+			if extra[i], err = fleet.ParseQuery(q); err != nil {
+				handleExitError(err)
+			}
+		}
+
+		var columns []string
+		if fleetColumns != "" {
+			columns = strings.Split(fleetColumns, ",")
+		}
+
+		queries, err := fleet.SelectQueries(extra, columns)
+		if err != nil {
+			handleExitError(err)
+		}
+
+		var db *sql.DB
+		if db, err = sql.Open("sqlite3", ":memory:"); err != nil {
+			handleExitError(fmt.Errorf("failed to initialize database connection: %v", err))
+		}
+		defer db.Close()
+		db.SetMaxOpenConns(fleetConcurrency)
+
+		root := fleetRoot
+		if strings.HasPrefix(root, "~") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				handleExitError(fmt.Errorf("failed to expand home directory: %v", err))
+			}
+			root = filepath.Join(home, root[1:])
+		}
+		if root, err = filepath.Abs(root); err != nil {
+			handleExitError(err)
+		}
+
+		repos, err := fleet.Discover(db, root, fleetMaxDepth)
+		if err != nil {
+			handleExitError(err)
+		}
+
+		statuses := fleet.Run(queryCtx, db, repos, queries, fleetConcurrency)
+
+		var failed int
+		for _, status := range statuses {
+			for _, err := range status.Errs {
+				if err != nil {
+					failed++
+					logger.Error().Err(err).Msgf("failed to query %s", status.Repo)
+				}
+			}
+
+			// repositories are shown relative to the root they were discovered under
+			if rel, err := filepath.Rel(root, status.Repo); err == nil {
+				status.Repo = rel
+			}
+		}
+
+		renderMetricsReport("SELECT * FROM fleet_status", func(db *sql.DB) error {
+			return fleet.CreateStatusTable(db, queries, statuses)
+		}, func(rows *sql.Rows) error {
+			return display.WriteTo(rows, os.Stdout, fleetFormat, false)
+		})
+
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, "%d queries failed (run with --verbose for details)\n", failed)
+		}
+	},
+}
@@ -0,0 +1,187 @@
+// Package fleet reports on many repositories at once, such as all of the repositories checked out under a directory,
+// by running a set of per-repository queries against each of them, in parallel
+package fleet
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// discoverSQL lists the repositories found under a directory, using the repos_in table
+const discoverSQL = `SELECT path FROM repos_in($root, $max_depth) ORDER BY path`
+
+// Query is a per-repository query, the first column of the first row of which is reported for each repository.
+// The path of the repository is bound to $repo.
+type Query struct {
+	Name string
+	SQL  string
+}
+
+// DefaultQueries are the queries run against each repository by default: the branch checked out, the number of files
+// with uncommitted changes, the number of commits ahead and behind the same branch on origin, and the age of the last commit
+var DefaultQueries = []*Query{
+	{Name: "branch", SQL: `SELECT iif(detached, '(detached)', name) FROM head($repo)`},
+	{Name: "dirty", SQL: `SELECT count(*) FROM status($repo)`},
+	{Name: "ahead", SQL: `
+		SELECT commits_ahead($repo, 'HEAD', r.full_name)
+		FROM head($repo) h JOIN refs($repo) r ON r.full_name = 'refs/remotes/origin/' || h.name`},
+	{Name: "behind", SQL: `
+		SELECT commits_ahead($repo, r.full_name, 'HEAD')
+		FROM head($repo) h JOIN refs($repo) r ON r.full_name = 'refs/remotes/origin/' || h.name`},
+	{Name: "last_commit", SQL: `
+		SELECT CASE
+			WHEN age < 1.0 / 24 THEN CAST(age * 24 * 60 AS INT) || 'm'
+			WHEN age < 1 THEN CAST(age * 24 AS INT) || 'h'
+			ELSE CAST(age AS INT) || 'd'
+		END
+		FROM (SELECT julianday('now') - julianday(committer_when) AS age FROM commits($repo) LIMIT 1)`},
+}
+
+// ParseQuery parses a query given as name=SQL
+func ParseQuery(s string) (*Query, error) {
+	name, query, ok := strings.Cut(s, "=")
+	name, query = strings.TrimSpace(name), strings.TrimSpace(query)
+	if !ok || name == "" || query == "" {
+		return nil, fmt.Errorf("invalid query %q: expected name=SQL", s)
+	}
+	return &Query{Name: name, SQL: query}, nil
+}
+
+// SelectQueries returns the queries to run: the default ones, replaced by the extra ones of the same name, followed by
+// the other extra ones. If columns isn't empty, only the queries it names are returned, in its order.
+func SelectQueries(extra []*Query, columns []string) ([]*Query, error) {
+	var queries []*Query
+	var byName = make(map[string]*Query)
+	for _, q := range append(append([]*Query{}, DefaultQueries...), extra...) {
+		if _, ok := byName[q.Name]; !ok {
+			queries = append(queries, q)
+		} else {
+			for i := range queries {
+				if queries[i].Name == q.Name {
+					queries[i] = q
+				}
+			}
+		}
+		byName[q.Name] = q
+	}
+
+	if len(columns) == 0 {
+		return queries, nil
+	}
+
+	var selected []*Query
+	for _, name := range columns {
+		q, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		selected = append(selected, q)
+	}
+	return selected, nil
+}
+
+// Discover returns the paths of the repositories found under root, up to maxDepth directories deep (unlimited if negative)
+func Discover(db *sql.DB, root string, maxDepth int) ([]string, error) {
+	rows, err := db.Query(discoverSQL, sql.Named("root", root), sql.Named("max_depth", maxDepth))
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover repositories in %s: %v", root, err)
+	}
+	defer rows.Close()
+
+	var repos []string
+	for rows.Next() {
+		var path string
+		if err = rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("failed to scan repository: %v", err)
+		}
+		repos = append(repos, path)
+	}
+	return repos, rows.Err()
+}
+
+// Status is the outcome of running the queries against a single repository
+type Status struct {
+	Repo   string
+	Values []sql.NullString // the value reported by each query (NULL if it returned no row, or failed)
+	Errs   []error          // the error of each query, if it failed
+}
+
+// Run runs queries against each of repos, with up to concurrency repositories queried in parallel.
+// A query failing doesn't stop the others, its error is reported in the status of the repository instead.
+func Run(ctx context.Context, db *sql.DB, repos []string, queries []*Query, concurrency int) []*Status {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var statuses = make([]*Status, len(repos))
+	var indexes = make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				statuses[i] = run(ctx, db, repos[i], queries)
+			}
+		}()
+	}
+
+	for i := range repos {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return statuses
+}
+
+func run(ctx context.Context, db *sql.DB, repo string, queries []*Query) *Status {
+	var status = &Status{Repo: repo, Values: make([]sql.NullString, len(queries)), Errs: make([]error, len(queries))}
+	for i, q := range queries {
+		err := db.QueryRowContext(ctx, q.SQL, sql.Named("repo", repo)).Scan(&status.Values[i])
+		if err != nil && err != sql.ErrNoRows {
+			status.Errs[i] = fmt.Errorf("%s: %v", q.Name, err)
+		}
+	}
+	return status
+}
+
+// CreateStatusTable creates the fleet_status temporary table, holding the dashboard of statuses: a row for each repository,
+// with a column for each of queries (holding 'error' if it failed). Temporary tables only exist on the connection
+// that created them, so db must be limited to a single connection.
+func CreateStatusTable(db *sql.DB, queries []*Query, statuses []*Status) error {
+	var columns = []string{"repository TEXT"}
+	var params = []string{"?"}
+	for _, q := range queries {
+		columns = append(columns, `"`+strings.ReplaceAll(q.Name, `"`, `""`)+`"`)
+		params = append(params, "?")
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("CREATE TEMP TABLE fleet_status (%s)", strings.Join(columns, ", "))); err != nil {
+		return fmt.Errorf("failed to create fleet_status table: %v", err)
+	}
+
+	insert := fmt.Sprintf("INSERT INTO fleet_status VALUES (%s)", strings.Join(params, ", "))
+	for _, status := range statuses {
+		var values = make([]interface{}, 1, len(queries)+1)
+		values[0] = status.Repo
+		for i := range queries {
+			switch {
+			case status.Errs[i] != nil:
+				values = append(values, "error")
+			case status.Values[i].Valid:
+				values = append(values, status.Values[i].String)
+			default:
+				values = append(values, nil)
+			}
+		}
+
+		if _, err := db.Exec(insert, values...); err != nil {
+			return fmt.Errorf("failed to record the status of %s: %v", status.Repo, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package fleet
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/mergestat/mergestat-lite/extensions"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/pkg/locator"
+	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
+	"go.riyazali.net/sqlite"
+)
+
+func init() {
+	// register sqlite extension when this package is loaded
+	sqlite.Register(extensions.RegisterFn(options.WithExtraFunctions(), options.WithRepoLocator(locator.DiskLocator())))
+}
+
+func TestMain(m *testing.M) { os.Exit(m.Run()) }
+
+func TestRun(t *testing.T) {
+	upstream, root := t.TempDir(), t.TempDir()
+	run := func(dir string, args ...string) {
+		t.Helper()
+		args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	// a is a clone one commit ahead and one commit behind its upstream, b is a dirty repository without a remote
+	run(upstream, "init", "-b", "main")
+	run(upstream, "commit", "--allow-empty", "-m", "initial")
+	run(root, "clone", upstream, "a")
+	run(filepath.Join(root, "a"), "commit", "--allow-empty", "-m", "local")
+	run(upstream, "commit", "--allow-empty", "-m", "upstream")
+	run(filepath.Join(root, "a"), "fetch")
+
+	run(root, "init", "-b", "trunk", "b")
+	run(filepath.Join(root, "b"), "commit", "--allow-empty", "-m", "initial")
+	if err := os.WriteFile(filepath.Join(root, "b", "notes.txt"), []byte("wip\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open connection: %v", err.Error())
+	}
+	defer db.Close()
+
+	repos, err := Discover(db, root, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 2 || filepath.Base(repos[0]) != "a" || filepath.Base(repos[1]) != "b" {
+		t.Fatalf("expected repositories a and b, got: %v", repos)
+	}
+
+	queries, err := SelectQueries([]*Query{{Name: "broken", SQL: "SELECT * FROM no_such_table"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := Run(context.Background(), db, repos, queries, 2)
+	expected := [][]string{
+		{"main", "0", "1", "1", "0m", "error"},
+		{"trunk", "1", "NULL", "NULL", "0m", "error"},
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			var got = "NULL"
+			switch {
+			case statuses[r].Errs[c] != nil:
+				got = "error"
+			case statuses[r].Values[c].Valid:
+				got = statuses[r].Values[c].String
+			}
+			if got != value {
+				t.Fatalf("expected %q for %s of %s, got: %q (%v)", value, queries[c].Name, repos[r], got, statuses[r].Errs[c])
+			}
+		}
+	}
+
+	// the dashboard only exists on a single connection
+	db.SetMaxOpenConns(1)
+	if err = CreateStatusTable(db, queries, statuses); err != nil {
+		t.Fatal(err)
+	}
+
+	var branch, broken string
+	if err = db.QueryRow("SELECT branch, broken FROM fleet_status WHERE repository = ?", repos[1]).Scan(&branch, &broken); err != nil {
+		t.Fatal(err)
+	}
+	if branch != "trunk" || broken != "error" {
+		t.Fatalf("unexpected dashboard row: %s, %s", branch, broken)
+	}
+}
+
+func TestSelectQueries(t *testing.T) {
+	dirty, err := ParseQuery("dirty = SELECT count(*) FROM status($repo) WHERE NOT untracked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dirty.Name != "dirty" || dirty.SQL != "SELECT count(*) FROM status($repo) WHERE NOT untracked" {
+		t.Fatalf("unexpected query: %+v", dirty)
+	}
+
+	for _, invalid := range []string{"dirty", "=SELECT 1", "dirty="} {
+		if _, err := ParseQuery(invalid); err == nil {
+			t.Fatalf("expected %q to be invalid", invalid)
+		}
+	}
+
+	queries, err := SelectQueries([]*Query{dirty, {Name: "tags", SQL: "SELECT 1"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, q := range queries {
+		names = append(names, q.Name)
+	}
+	if strings.Join(names, ",") != "branch,dirty,ahead,behind,last_commit,tags" || queries[1] != dirty {
+		t.Fatalf("expected the extra queries to replace or follow the default ones, got: %v", names)
+	}
+
+	if queries, err = SelectQueries([]*Query{dirty}, []string{"dirty", " branch"}); err != nil || len(queries) != 2 || queries[0] != dirty || queries[1].Name != "branch" {
+		t.Fatalf("expected the selected columns, got: %v (%v)", queries, err)
+	}
+
+	if _, err = SelectQueries(nil, []string{"stashes"}); err == nil {
+		t.Fatal("expected an unknown column to fail")
+	}
+}
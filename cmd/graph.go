@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var graphFormat string // output format of the graph subcommand (dot or json)
+
+func init() {
+	graphCmd.Flags().StringVarP(&graphFormat, "format", "f", "dot", "specify the output format. Options are 'dot' (Graphviz) and 'json' (nodes and edges)")
+}
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [revision range]",
+	Short: "Export the commit graph of a revision range",
+	Long: `Prints the commit DAG of a revision range in the default repository (either the current directory or supplied by --repo),
+as a Graphviz digraph or as JSON nodes and edges. The range may be of the form 'v1.0.0..HEAD', or a single revision
+to export all of its history. Defaults to HEAD (or --default-ref).
+
+The same output is available in queries, with the commit_graph(repository, range, format) function.
+
+Example:
+  mergestat graph v0.5.0..v0.6.0 | dot -Tsvg > graph.svg
+`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var revRange string
+		if len(args) > 0 {
+			revRange = args[0]
+		}
+
+		var db *sql.DB
+		var err error
+		if db, err = sql.Open("sqlite3", ":memory:"); err != nil {
+			handleExitError(fmt.Errorf("failed to initialize database connection: %v", err))
+		}
+		defer db.Close()
+
+		var graph string
+		if err = db.QueryRow("SELECT commit_graph('', ?, ?)", revRange, graphFormat).Scan(&graph); err != nil {
+			handleExitError(fmt.Errorf("failed to export commit graph: %v", err))
+		}
+
+		fmt.Println(strings.TrimSuffix(graph, "\n"))
+	},
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// memoryGuard aborts query execution once the heap grows beyond a configured limit,
+// so that runaway queries fail cleanly with an error instead of getting the process OOM killed.
+type memoryGuard struct {
+	limit    uint64
+	exceeded atomic.Bool
+}
+
+// newMemoryGuard parses a human friendly size (such as "512MB" or "2GB") into a memoryGuard.
+// An empty size returns a nil guard, which never aborts.
+func newMemoryGuard(size string) (*memoryGuard, error) {
+	if size == "" {
+		return nil, nil
+	}
+
+	limit, err := parseByteSize(size)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryGuard{limit: limit}, nil
+}
+
+// Watch returns a context that is cancelled once the heap in use exceeds the limit.
+// It also sets a soft memory limit on the runtime so the garbage collector works harder before we give up.
+func (g *memoryGuard) Watch(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	if g == nil {
+		return ctx, cancel
+	}
+
+	debug.SetMemoryLimit(int64(g.limit))
+
+	go func() {
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+
+		var stats runtime.MemStats
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runtime.ReadMemStats(&stats)
+				if stats.HeapInuse > g.limit {
+					g.exceeded.Store(true)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// Err wraps err to explain that the query was aborted if the limit was exceeded
+func (g *memoryGuard) Err(err error) error {
+	if g != nil && g.exceeded.Load() {
+		return fmt.Errorf("query aborted after exceeding --max-memory of %d bytes: %v", g.limit, err)
+	}
+	return err
+}
+
+// setupMemoryGuard watches memory usage for as long as the process runs, cancelling queryCtx once --max-memory is exceeded.
+// The subcommands don't all run their queries with queryCtx, so they exit with an error right away instead.
+func setupMemoryGuard(cmd *cobra.Command) {
+	var err error
+	if guard, err = newMemoryGuard(maxMemory); err != nil {
+		handleExitError(fmt.Errorf("invalid --max-memory: %v", err))
+	}
+	if guard == nil {
+		return
+	}
+
+	ctx, _ := guard.Watch(queryCtx)
+	queryCtx = ctx
+
+	if cmd != rootCmd {
+		go func() {
+			<-ctx.Done()
+			handleExitError(guard.Err(ctx.Err()))
+		}()
+	}
+}
+
+// parseByteSize parses sizes such as "1024", "512KB", "512MB" or "1.5GB" (using powers of 1024)
+func parseByteSize(size string) (uint64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	s = strings.TrimSuffix(s, "B")
+
+	var multiplier float64 = 1
+	for suffix, m := range map[string]float64{"K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40} {
+		if strings.HasSuffix(s, suffix) {
+			s, multiplier = strings.TrimSuffix(s, suffix), m
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+
+	return uint64(value * multiplier), nil
+}
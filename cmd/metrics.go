@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mergestat/mergestat-lite/cmd/metrics"
+	"github.com/mergestat/mergestat-lite/pkg/display"
+	"github.com/spf13/cobra"
+)
+
+var (
+	metricsGitHubRepo string
+	metricsOutputJSON bool
+	metricsPrintSQL   bool
+	doraEnvironment   string
+	doraSince         string
+	doraWorkflow      string
+
+	reviewLatencySince  string
+	reviewLatencyRoster string
+
+	busFactorRev          string
+	busFactorThreshold    float64
+	busFactorMinLines     int
+	busFactorInactiveDays int
+)
+
+func init() {
+	metricsCmd.PersistentFlags().BoolVar(&metricsOutputJSON, "json", false, "output as JSON")
+	metricsCmd.PersistentFlags().BoolVar(&metricsPrintSQL, "print-sql", false, "print the SQL query behind the report instead of executing it")
+
+	doraCmd.Flags().StringVar(&metricsGitHubRepo, "github-repo", "", "owner/name of the GitHub repository to report on. Detected from the origin remote if not supplied")
+	doraCmd.Flags().StringVar(&doraEnvironment, "environment", "production", "the deployment environment to report on")
+	doraCmd.Flags().StringVar(&doraSince, "since", "-90 days", "the reporting window, as a SQLite date modifier relative to 'now'")
+	doraCmd.Flags().StringVar(&doraWorkflow, "workflow", "", "the GitHub Actions workflow deploying to the environment (such as deploy.yml), whose completed runs are counted as deployments too")
+
+	reviewLatencyCmd.Flags().StringVar(&metricsGitHubRepo, "github-repo", "", "owner/name of the GitHub repository to report on. Detected from the origin remote if not supplied")
+	reviewLatencyCmd.Flags().StringVar(&reviewLatencySince, "since", "-90 days", "the reporting window (by pull request creation), as a SQLite date modifier relative to 'now'")
+	reviewLatencyCmd.Flags().StringVar(&reviewLatencyRoster, "roster", "", "path to a CSV file of login,team records, to group pull requests by the team of their author")
+
+	busFactorCmd.Flags().StringVar(&busFactorRev, "rev", "", "the revision to blame. Defaults to HEAD (or --default-ref)")
+	busFactorCmd.Flags().Float64Var(&busFactorThreshold, "threshold", 0.8, "the share (from 0 to 1) of a directory's lines a single author must own for it to be flagged")
+	busFactorCmd.Flags().IntVar(&busFactorMinLines, "min-lines", 100, "the minimum number of lines in a directory for it to be considered")
+	busFactorCmd.Flags().IntVar(&busFactorInactiveDays, "inactive-days", 180, "the number of days without commits after which an author is considered inactive")
+
+	metricsCmd.AddCommand(doraCmd, reviewLatencyCmd, busFactorCmd)
+}
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics [command]",
+	Short: "Compute engineering metrics reports",
+}
+
+var doraCmd = &cobra.Command{
+	Use:   "dora",
+	Short: "Report the DORA metrics of a GitHub repository",
+	Long: `Reports the four DORA metrics of a GitHub repository: deployment frequency, median lead time for changes,
+change failure rate and mean time to restore service. Deployments are read from the GitHub deployments API
+(requires GITHUB_TOKEN), and from the runs of the GitHub Actions workflow supplied by --workflow, if any. Lead times are read
+from the history of the default repository (either the current directory or supplied by --repo), which must contain the deployed commits.
+
+Use --print-sql to inspect (and adapt) the query behind the report.
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if metricsPrintSQL {
+			fmt.Print(metrics.DoraSQL)
+			return
+		}
+
+		runMetricsReport(metrics.DoraSQL, nil,
+			sql.Named("repo", metricsGitHubRepoOrDetect()),
+			sql.Named("environment", doraEnvironment),
+			sql.Named("since", doraSince),
+			sql.Named("workflow", doraWorkflow),
+		)
+	},
+}
+
+var reviewLatencyCmd = &cobra.Command{
+	Use:   "review-latency",
+	Short: "Report the review latency of a GitHub repository's pull requests",
+	Long: `Reports percentiles (p50, p75 and p90) of the time to first review (the first review or comment by someone other than
+the author) and the time to merge of the pull requests of a GitHub repository (requires GITHUB_TOKEN).
+Supply a CSV file of login,team records with --roster to break the report down by the team of each pull request's author.
+
+Use --print-sql to inspect (and adapt) the query behind the report, which reads teams from a roster(login, team) table.
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if metricsPrintSQL {
+			fmt.Print(metrics.ReviewLatencySQL)
+			return
+		}
+
+		var roster io.Reader
+		if reviewLatencyRoster != "" {
+			f, err := os.Open(reviewLatencyRoster)
+			if err != nil {
+				handleExitError(fmt.Errorf("failed to open roster: %v", err))
+			}
+			defer f.Close()
+			roster = f
+		}
+
+		runMetricsReport(metrics.ReviewLatencySQL, func(db *sql.DB) error { return metrics.CreateRoster(db, roster) },
+			sql.Named("repo", metricsGitHubRepoOrDetect()),
+			sql.Named("since", reviewLatencySince),
+		)
+	},
+}
+
+var busFactorCmd = &cobra.Command{
+	Use:   "bus-factor [file pattern]",
+	Short: "Flag directories where a single author owns most of the code",
+	Long: `Reports the directories of the default repository (either the current directory or supplied by --repo) where a single author
+last modified most of the surviving lines, according to git blame, and whether that author has been active recently.
+These are the areas of the code base most at risk of losing their knowledge holder.
+Specify a glob (such as 'src/**' or '*.go') as an argument to only consider the files matching it.
+
+Use --print-sql to inspect (and adapt) the query behind the report.
+`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if metricsPrintSQL {
+			fmt.Print(metrics.BusFactorSQL)
+			return
+		}
+
+		var pathGlob string
+		if len(args) > 0 {
+			pathGlob = args[0]
+		}
+
+		runMetricsReport(metrics.BusFactorSQL, nil,
+			sql.Named("rev", busFactorRev),
+			sql.Named("path_glob", pathGlob),
+			sql.Named("threshold", busFactorThreshold),
+			sql.Named("min_lines", busFactorMinLines),
+			sql.Named("inactive_days", busFactorInactiveDays),
+		)
+	},
+}
+
+// metricsGitHubRepoOrDetect returns the GitHub repository supplied with --github-repo, or the one detected from the origin remote
+func metricsGitHubRepoOrDetect() string {
+	githubRepo := metricsGitHubRepo
+	if githubRepo == "" {
+		if githubRepo = detectGitHubRepo(); githubRepo == "" {
+			handleExitError(fmt.Errorf("could not detect the GitHub repository, supply one with --github-repo"))
+		}
+	}
+	return githubRepo
+}
+
+// runMetricsReport executes the query of a metrics report, writing the results to stdout.
+// If set, setup is called (to create any tables the query expects) before executing the query.
+func runMetricsReport(query string, setup func(*sql.DB) error, args ...interface{}) {
+	renderMetricsReport(query, setup, func(rows *sql.Rows) error {
+		var format = "table"
+		if metricsOutputJSON {
+			format = "json"
+		}
+		return display.WriteTo(rows, os.Stdout, format, false)
+	}, args...)
+}
+
+// renderMetricsReport executes the query of a metrics report, and calls render to write the results to stdout,
+// for reports with an output of their own rather than a table. Setup is called as in runMetricsReport.
+func renderMetricsReport(query string, setup func(*sql.DB) error, render func(*sql.Rows) error, args ...interface{}) {
+	var db *sql.DB
+	var err error
+	if db, err = sql.Open("sqlite3", ":memory:"); err != nil {
+		handleExitError(fmt.Errorf("failed to initialize database connection: %v", err))
+	}
+	defer db.Close()
+
+	// tables created by setup only exist on the connection that created them
+	db.SetMaxOpenConns(1)
+
+	if setup != nil {
+		if err = setup(db); err != nil {
+			handleExitError(err)
+		}
+	}
+
+	var rows *sql.Rows
+	if rows, err = db.Query(query, args...); err != nil {
+		handleExitError(fmt.Errorf("query execution failed: %v", err))
+	}
+	defer rows.Close()
+
+	if err = render(rows); err != nil {
+		handleExitError(fmt.Errorf("failed to output resultset: %v", err))
+	}
+}
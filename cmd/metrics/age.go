@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var age = regexp.MustCompile(`^(\d+)\s*([dwmy])$`)
+
+// AgeModifier converts an age such as 90d (days), 12w (weeks), 6m (months) or 1y (years)
+// into a SQLite date modifier reaching that far into the past, such as '-90 days'
+func AgeModifier(s string) (string, error) {
+	m := age.FindStringSubmatch(s)
+	if m == nil {
+		return "", fmt.Errorf("invalid age %q, expected a number of days (d), weeks (w), months (m) or years (y), such as 90d", s)
+	}
+
+	n, _ := strconv.Atoi(m[1])
+	switch m[2] {
+	case "w":
+		return fmt.Sprintf("-%d days", n*7), nil
+	case "m":
+		return fmt.Sprintf("-%d months", n), nil
+	case "y":
+		return fmt.Sprintf("-%d years", n), nil
+	default:
+		return fmt.Sprintf("-%d days", n), nil
+	}
+}
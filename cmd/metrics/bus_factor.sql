@@ -0,0 +1,37 @@
+-- Bus factor (knowledge concentration) of the directories of the default repository (--repo), flagging the directories
+-- where a single author last modified most of the surviving lines.
+--
+--   $rev            the revision to blame, or '' for HEAD (or --default-ref)
+--   $path_glob      a glob restricting the files considered, or '' for all files
+--   $threshold      the share (from 0 to 1) of a directory's lines its top author must own for it to be flagged
+--   $min_lines      the minimum number of lines in a directory for it to be considered
+--   $inactive_days  the number of days without commits after which the top author is considered inactive
+WITH ownership AS (
+    -- rtrim(path, replace(path, '/', '')) strips the file name from path, leaving its directory (with a trailing slash)
+    SELECT rtrim(path, replace(path, '/', '')) AS directory, author_name, author_email, sum(lines) AS lines
+    FROM blame_summary('', $rev, $path_glob)
+    GROUP BY directory, author_email
+),
+directories AS (
+    SELECT directory, sum(lines) AS lines, count(*) AS authors FROM ownership GROUP BY directory
+),
+top_authors AS (
+    SELECT *, row_number() OVER (PARTITION BY directory ORDER BY lines DESC) AS rank FROM ownership
+),
+activity AS (
+    SELECT author_email, max(author_when) AS last_commit FROM commits('', $rev) GROUP BY author_email
+)
+SELECT
+    coalesce(nullif(rtrim(d.directory, '/'), ''), '.') AS directory,
+    d.lines,
+    d.authors,
+    t.author_name AS top_author,
+    t.author_email AS top_author_email,
+    round(1.0 * t.lines / d.lines, 3) AS top_author_share,
+    a.last_commit AS top_author_last_commit,
+    coalesce(a.last_commit < datetime('now', '-' || $inactive_days || ' days'), 1) AS top_author_inactive
+FROM directories d
+JOIN top_authors t ON t.directory = d.directory AND t.rank = 1
+LEFT JOIN activity a ON a.author_email = t.author_email
+WHERE d.lines >= $min_lines AND 1.0 * t.lines / d.lines >= $threshold
+ORDER BY top_author_share DESC, d.lines DESC
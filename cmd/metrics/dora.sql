@@ -0,0 +1,51 @@
+-- DORA metrics (https://dora.dev) for the deployments of a GitHub repository to an environment.
+--
+--   $repo         owner/name of the GitHub repository
+--   $environment  the deployment environment to report on, such as 'production'
+--   $since        a SQLite date modifier bounding the reporting window, such as '-90 days'
+--   $workflow     the GitHub Actions workflow deploying to the environment (by file name, such as 'deploy.yml'), if any
+--
+-- Lead times are computed from the history of the default repository (--repo), which must contain the deployed commits.
+WITH deployments AS (
+    -- deployments recorded through the GitHub deployments API, which succeed when they first get a SUCCESS status
+    -- (their state turns INACTIVE once superseded, whether they succeeded or not)
+    SELECT commit_hash, created_at, succeeded_at, state IN ('FAILURE', 'ERROR') AS failed
+    FROM github_repo_deployments($repo)
+    WHERE environment = $environment AND julianday(created_at) >= julianday('now', $since)
+    UNION ALL
+    -- the completed runs of the deployment workflow, for repositories deploying with GitHub Actions
+    SELECT head_sha, created_at, CASE WHEN conclusion = 'success' THEN updated_at END, conclusion IN ('failure', 'timed_out')
+    FROM github_workflow_runs($repo)
+    WHERE $workflow <> '' AND workflow = $workflow AND status = 'completed'
+      AND julianday(created_at) >= julianday('now', $since)
+),
+successful AS (
+    SELECT *, lag(commit_hash) OVER (ORDER BY julianday(succeeded_at)) AS previous_hash
+    FROM deployments WHERE succeeded_at IS NOT NULL
+),
+failed AS (
+    SELECT * FROM deployments WHERE failed
+),
+-- the time between authoring each commit and its deployment, for the commits shipped by each (but the first) deployment
+lead_times AS (
+    SELECT (julianday(s.succeeded_at) - julianday(c.author_when)) * 24 AS hours
+    FROM successful s, commits('', s.commit_hash) c
+    WHERE s.previous_hash IS NOT NULL
+      AND c.hash NOT IN (SELECT hash FROM commits('', s.previous_hash))
+),
+-- the time between a failed deployment and the next successful one
+restore_times AS (
+    SELECT ((SELECT min(julianday(s.succeeded_at)) FROM successful s WHERE julianday(s.created_at) > julianday(f.created_at)) - julianday(f.created_at)) * 24 AS hours
+    FROM failed f
+)
+SELECT
+    $environment AS environment,
+    (SELECT count(*) FROM successful) AS deployments,
+    round((SELECT count(*) FROM successful) / (julianday('now') - julianday('now', $since)), 3) AS deployments_per_day,
+    (SELECT round(avg(hours), 2) FROM (
+        SELECT hours FROM lead_times ORDER BY hours
+        LIMIT 2 - (SELECT count(*) FROM lead_times) % 2
+        OFFSET ((SELECT count(*) FROM lead_times) - 1) / 2
+    )) AS median_lead_time_hours,
+    round(1.0 * (SELECT count(*) FROM failed) / nullif((SELECT count(*) FROM successful) + (SELECT count(*) FROM failed), 0), 3) AS change_failure_rate,
+    (SELECT round(avg(hours), 2) FROM restore_times WHERE hours IS NOT NULL) AS mean_time_to_restore_hours
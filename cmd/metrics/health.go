@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HealthChecks are the checks the health score of a repository is made of (see HealthSQL)
+var HealthChecks = []string{"license", "readme", "codeowners", "ci", "activity", "bus_factor", "stale_prs", "stale_issues"}
+
+// DefaultHealthWeights are the weights of the health checks, unless overridden
+var DefaultHealthWeights = map[string]float64{
+	"license": 1, "readme": 1, "codeowners": 1, "ci": 1,
+	"activity": 2, "bus_factor": 2, "stale_prs": 1, "stale_issues": 1,
+}
+
+// ParseHealthWeights parses a list of check=weight pairs, such as "activity=3,codeowners=0", overriding the default
+// weights of those checks. A weight of 0 leaves a check out of the score.
+func ParseHealthWeights(s string) (map[string]float64, error) {
+	var weights = make(map[string]float64, len(DefaultHealthWeights))
+	for check, weight := range DefaultHealthWeights {
+		weights[check] = weight
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		check, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid weight %q, expected check=weight", pair)
+		}
+
+		check = strings.TrimSpace(check)
+		if _, known := DefaultHealthWeights[check]; !known {
+			return nil, fmt.Errorf("unknown check %q, expected one of %s", check, strings.Join(HealthChecks, ", "))
+		}
+
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight %q of check %q, expected a non-negative number", value, check)
+		}
+		weights[check] = weight
+	}
+
+	return weights, nil
+}
+
+// HealthArgs returns the named arguments of HealthSQL: the weights (as $w_<check>), and the
+// $active_within and $stale_after SQLite date modifiers (see AgeModifier)
+func HealthArgs(weights map[string]float64, activeWithin, staleAfter string) []interface{} {
+	var checks = make([]string, 0, len(weights))
+	for check := range weights {
+		checks = append(checks, check)
+	}
+	sort.Strings(checks)
+
+	var args = []interface{}{sql.Named("active_within", activeWithin), sql.Named("stale_after", staleAfter)}
+	for _, check := range checks {
+		args = append(args, sql.Named("w_"+check, weights[check]))
+	}
+	return args
+}
+
+// HealthRepo is a repository to report the health of
+type HealthRepo struct {
+	Path       string // the path of the repository on disk
+	GitHubRepo string // owner/name of the repository on GitHub, to check the staleness of its pull requests and issues, if set
+}
+
+// CreateHealthRepos creates the (temporary) health_repos and health_open_items tables HealthSQL reports on, and fills
+// them with repos, along with the open pull requests and issues of those with a GitHub repository, if checkGitHub is set.
+// db should be limited to a single connection, so that later queries see the tables.
+func CreateHealthRepos(db *sql.DB, repos []HealthRepo, checkGitHub bool) error {
+	const schema = `
+		CREATE TEMP TABLE IF NOT EXISTS health_repos (repository TEXT PRIMARY KEY, github_repo TEXT);
+		CREATE TEMP TABLE IF NOT EXISTS health_open_items (repository TEXT, kind TEXT, number INT, updated_at DATETIME, PRIMARY KEY (repository, kind, number));`
+	if _, err := db.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create health tables: %v", err)
+	}
+
+	for _, repo := range repos {
+		// the staleness checks are left NULL, unless the repository's pull requests and issues are loaded
+		var githubRepo interface{}
+		if checkGitHub && repo.GitHubRepo != "" {
+			githubRepo = repo.GitHubRepo
+		}
+
+		if _, err := db.Exec("INSERT OR REPLACE INTO health_repos (repository, github_repo) VALUES (?, ?)", repo.Path, githubRepo); err != nil {
+			return fmt.Errorf("failed to insert repository: %v", err)
+		}
+
+		if githubRepo == nil {
+			continue
+		}
+
+		const insert = `INSERT OR REPLACE INTO health_open_items (repository, kind, number, updated_at)
+			SELECT ?, 'pr', number, updated_at FROM github_repo_pull_requests(?) WHERE state = 'OPEN'
+			UNION ALL
+			SELECT ?, 'issue', number, updated_at FROM github_repo_issues(?) WHERE state = 'OPEN'`
+		if _, err := db.Exec(insert, repo.Path, repo.GitHubRepo, repo.Path, repo.GitHubRepo); err != nil {
+			return fmt.Errorf("failed to load open pull requests and issues of %s: %v", repo.GitHubRepo, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+-- Health score of repositories, combining the presence of key files, recent activity, bus factor and the staleness
+-- of open pull requests and issues into a weighted score from 0 to 100.
+--
+--   $active_within  a SQLite date modifier, repositories with commits since which are considered active, such as '-90 days'
+--   $stale_after    a SQLite date modifier, open pull requests and issues not updated since which are stale, such as '-30 days'
+--   $w_<check>      the weight of each check (license, readme, codeowners, ci, activity, bus_factor, stale_prs and stale_issues)
+--
+-- Repositories are read from a health_repos(repository, github_repo) table, and their open pull requests and issues
+-- from a health_open_items(repository, kind, number, updated_at) table, where kind is 'pr' or 'issue'. Checks that
+-- couldn't be made (the staleness checks of repositories without a github_repo) are NULL, and left out of the score.
+--
+-- Each check scores from 0 to 1: the file checks score 1 if the file is present (LICENSE, README and CI configuration
+-- at the root of the repository, CODEOWNERS where GitHub and GitLab look for it), activity scores 1 if there are
+-- commits within $active_within, bus_factor (the number of authors who last modified half of the surviving lines)
+-- scores 0 for a single author, 0.5 for two and 1 for three or more, and the staleness checks score the share of
+-- open pull requests (or issues) which aren't stale.
+WITH presence AS (
+    SELECT
+        r.repository,
+        max(instr(f.path, '/') = 0 AND (lower(f.path) GLOB 'licen[cs]e*' OR lower(f.path) GLOB 'copying*')) AS has_license,
+        max(instr(f.path, '/') = 0 AND lower(f.path) GLOB 'readme*') AS has_readme,
+        max(f.path IN ('CODEOWNERS', '.github/CODEOWNERS', '.gitlab/CODEOWNERS', 'docs/CODEOWNERS')) AS has_codeowners,
+        max(f.path GLOB '.github/workflows/*.y*ml' OR f.path IN ('.gitlab-ci.yml', '.circleci/config.yml', '.travis.yml', 'Jenkinsfile', 'azure-pipelines.yml', 'bitbucket-pipelines.yml')) AS has_ci
+    FROM health_repos r, files(r.repository) f
+    GROUP BY r.repository
+),
+activity AS (
+    SELECT
+        r.repository,
+        max(c.committer_when) AS last_commit_at,
+        sum(julianday(c.committer_when) >= julianday('now', $active_within)) AS recent_commits
+    FROM health_repos r, commits(r.repository) c
+    GROUP BY r.repository
+),
+ownership AS (
+    SELECT r.repository, b.author_email, sum(b.lines) AS lines
+    FROM health_repos r, blame_summary(r.repository, '', '') b
+    GROUP BY r.repository, b.author_email
+),
+running_ownership AS (
+    SELECT
+        repository,
+        lines,
+        sum(lines) OVER (PARTITION BY repository ORDER BY lines DESC ROWS UNBOUNDED PRECEDING) AS running_lines,
+        sum(lines) OVER (PARTITION BY repository) AS total_lines
+    FROM ownership
+),
+bus_factor AS (
+    -- the authors needed (from the top owner down) to reach half of the lines
+    SELECT repository, count(*) AS bus_factor FROM running_ownership WHERE running_lines - lines < total_lines / 2.0 GROUP BY repository
+),
+open_items AS (
+    SELECT
+        repository,
+        sum(kind = 'pr') AS open_prs,
+        sum(kind = 'pr' AND julianday(updated_at) < julianday('now', $stale_after)) AS stale_prs,
+        sum(kind = 'issue') AS open_issues,
+        sum(kind = 'issue' AND julianday(updated_at) < julianday('now', $stale_after)) AS stale_issues
+    FROM health_open_items
+    GROUP BY repository
+),
+report AS (
+    SELECT
+        r.repository,
+        coalesce(p.has_license, 0) AS has_license,
+        coalesce(p.has_readme, 0) AS has_readme,
+        coalesce(p.has_codeowners, 0) AS has_codeowners,
+        coalesce(p.has_ci, 0) AS has_ci,
+        a.last_commit_at,
+        coalesce(a.recent_commits, 0) AS recent_commits,
+        coalesce(bf.bus_factor, 0) AS bus_factor,
+        iif(r.github_repo IS NULL, NULL, coalesce(o.open_prs, 0)) AS open_prs,
+        iif(r.github_repo IS NULL, NULL, coalesce(o.stale_prs, 0)) AS stale_prs,
+        iif(r.github_repo IS NULL, NULL, coalesce(o.open_issues, 0)) AS open_issues,
+        iif(r.github_repo IS NULL, NULL, coalesce(o.stale_issues, 0)) AS stale_issues
+    FROM health_repos r
+    LEFT JOIN presence p ON p.repository = r.repository
+    LEFT JOIN activity a ON a.repository = r.repository
+    LEFT JOIN bus_factor bf ON bf.repository = r.repository
+    LEFT JOIN open_items o ON o.repository = r.repository
+),
+scores AS (
+    SELECT
+        *,
+        min(max(bus_factor - 1, 0), 2) / 2.0 AS bus_factor_score,
+        1 - 1.0 * stale_prs / max(open_prs, 1) AS stale_prs_score,
+        1 - 1.0 * stale_issues / max(open_issues, 1) AS stale_issues_score
+    FROM report
+)
+SELECT
+    repository,
+    round(100.0 * (
+        $w_license * has_license + $w_readme * has_readme + $w_codeowners * has_codeowners + $w_ci * has_ci +
+        $w_activity * (recent_commits > 0) + $w_bus_factor * bus_factor_score +
+        coalesce($w_stale_prs * stale_prs_score, 0) + coalesce($w_stale_issues * stale_issues_score, 0)
+    ) / nullif(
+        $w_license + $w_readme + $w_codeowners + $w_ci + $w_activity + $w_bus_factor +
+        iif(stale_prs_score IS NULL, 0, $w_stale_prs) + iif(stale_issues_score IS NULL, 0, $w_stale_issues), 0
+    ), 1) AS score,
+    has_license,
+    has_readme,
+    has_codeowners,
+    has_ci,
+    last_commit_at,
+    recent_commits,
+    bus_factor,
+    open_prs,
+    stale_prs,
+    open_issues,
+    stale_issues
+FROM scores
+ORDER BY score ASC, repository
@@ -0,0 +1,48 @@
+// Package metrics holds the SQL behind the built-in metrics reports, so that it can be inspected and adapted by users
+package metrics
+
+import (
+	_ "embed"
+)
+
+// DoraSQL computes the four DORA metrics (deployment frequency, lead time for changes,
+// change failure rate and time to restore service) of a GitHub repository's deployments
+//
+//go:embed dora.sql
+var DoraSQL string
+
+// BusFactorSQL flags the directories of a repository where a single author owns most of the surviving
+// lines (according to git blame), along with whether that author is still active
+//
+//go:embed bus_factor.sql
+var BusFactorSQL string
+
+// ReviewLatencySQL computes percentiles of the time to first review and time to merge of
+// the pull requests of a GitHub repository, per team (as listed in the roster table)
+//
+//go:embed review_latency.sql
+var ReviewLatencySQL string
+
+// StaleBranchesSQL lists the branches of a repository whose last commit is older than a cut off, along with
+// how far ahead and behind a base branch they are and whether they have an open pull request
+//
+//go:embed stale_branches.sql
+var StaleBranchesSQL string
+
+// PunchcardSQL counts the commits of a repository by day of the week and hour of the day they were authored,
+// in the timezone of their author (see Punchcard)
+//
+//go:embed punchcard.sql
+var PunchcardSQL string
+
+// HealthSQL scores the health of repositories, from the presence of key files (such as a LICENSE or CI configuration),
+// recent activity, bus factor and the staleness of open pull requests and issues, weighted by check
+//
+//go:embed health.sql
+var HealthSQL string
+
+// OwnersSQL ranks the owners of a subtree of a repository by the files they own according to CODEOWNERS,
+// the lines they last modified according to git blame, and their recent churn
+//
+//go:embed owners.sql
+var OwnersSQL string
@@ -0,0 +1,492 @@
+package metrics_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/mergestat/mergestat-lite/cmd/metrics"
+	"github.com/mergestat/mergestat-lite/extensions"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/pkg/locator"
+	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
+	"go.riyazali.net/sqlite"
+)
+
+func init() {
+	// register sqlite extension when this package is loaded
+	sqlite.Register(extensions.RegisterFn(
+		options.WithExtraFunctions(), options.WithRepoLocator(locator.CachedLocator(locator.MultiLocator(nil))),
+		options.WithGitHub(),
+	))
+}
+
+// tests' entrypoint that registers the extension
+// automatically with all loaded database connections
+func TestMain(m *testing.M) { os.Exit(m.Run()) }
+
+func connect(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open connection: %v", err.Error())
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestDoraSQL(t *testing.T) {
+	// the report needs the GitHub API to run, so only make sure the query compiles
+	stmt, err := connect(t).Prepare(metrics.DoraSQL)
+	if err != nil {
+		t.Fatalf("failed to prepare query: %v", err)
+	}
+	_ = stmt.Close()
+}
+
+func TestBusFactorSQL(t *testing.T) {
+	dir := t.TempDir()
+	commit := func(author, file string, lines int) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := os.OpenFile(filepath.Join(dir, file), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < lines; i++ {
+			_, _ = fmt.Fprintf(f, "%s line %d\n", author, i)
+		}
+		_ = f.Close()
+
+		for _, args := range [][]string{{"add", "-A"}, {"commit", "-m", "update " + file}} {
+			args = append([]string{"-C", dir, "-c", "user.name=" + author, "-c", "user.email=" + author + "@example.com"}, args...)
+			if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+				t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+			}
+		}
+	}
+
+	if out, err := exec.Command("git", "init", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v: %s", err, out)
+	}
+
+	commit("alice", "lib/core.go", 150)
+	commit("bob", "lib/util.go", 10)
+	commit("bob", "docs/guide.md", 60)
+	commit("alice", "shared/a.txt", 30)
+	commit("bob", "shared/b.txt", 30)
+
+	t.Setenv("MERGESTAT_DEFAULT_REPO", dir)
+
+	rows, err := connect(t).Query(metrics.BusFactorSQL,
+		sql.Named("rev", ""),
+		sql.Named("path_glob", ""),
+		sql.Named("threshold", 0.8),
+		sql.Named("min_lines", 50),
+		sql.Named("inactive_days", 180),
+	)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err)
+	}
+	defer rows.Close()
+
+	var flagged = make(map[string]string)
+	for rows.Next() {
+		var directory, author, email string
+		var lines, authors, inactive int
+		var share float64
+		var lastCommit sql.NullString
+		if err = rows.Scan(&directory, &lines, &authors, &author, &email, &share, &lastCommit, &inactive); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+
+		if inactive != 0 {
+			t.Fatalf("expected %s to be active, last commit: %s", author, lastCommit.String)
+		}
+		flagged[directory] = author
+	}
+
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err)
+	}
+
+	// shared/ is evenly split between authors, so only lib/ and docs/ are flagged
+	expected := map[string]string{"lib": "alice", "docs": "bob"}
+	if fmt.Sprint(flagged) != fmt.Sprint(expected) {
+		t.Fatalf("expected %v to be flagged, got %v", expected, flagged)
+	}
+}
+
+func TestReviewLatencySQL(t *testing.T) {
+	db := connect(t)
+	db.SetMaxOpenConns(1)
+
+	roster := "login,team\n@alice, platform\nbob,web\n"
+	if err := metrics.CreateRoster(db, strings.NewReader(roster)); err != nil {
+		t.Fatalf("failed to create roster: %v", err)
+	}
+
+	var teams = make(map[string]string)
+	rows, err := db.Query("SELECT login, team FROM roster")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var login, team string
+		if err = rows.Scan(&login, &team); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+		teams[login] = team
+	}
+
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err)
+	}
+
+	if expected := map[string]string{"alice": "platform", "bob": "web"}; fmt.Sprint(teams) != fmt.Sprint(expected) {
+		t.Fatalf("expected roster %v, got %v", expected, teams)
+	}
+
+	// the report needs the GitHub API to run, so only make sure the query compiles against the roster
+	stmt, err := db.Prepare(metrics.ReviewLatencySQL)
+	if err != nil {
+		t.Fatalf("failed to prepare query: %v", err)
+	}
+	_ = stmt.Close()
+}
+
+func TestAgeModifier(t *testing.T) {
+	tests := map[string]string{"90d": "-90 days", "2w": "-14 days", "6m": "-6 months", "1y": "-1 years"}
+	for age, expected := range tests {
+		if modifier, err := metrics.AgeModifier(age); err != nil || modifier != expected {
+			t.Fatalf("expected %q for %q, got %q (%v)", expected, age, modifier, err)
+		}
+	}
+
+	if _, err := metrics.AgeModifier("90"); err == nil {
+		t.Fatalf("expected an error for an age without a unit")
+	}
+}
+
+func TestStaleBranchesSQL(t *testing.T) {
+	dir := t.TempDir()
+	git := func(env []string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Env = append(os.Environ(), env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	old := []string{"GIT_AUTHOR_DATE=2020-01-01T00:00:00Z", "GIT_COMMITTER_DATE=2020-01-01T00:00:00Z"}
+
+	git(nil, "init", "-b", "main")
+	git(old, "commit", "--allow-empty", "-m", "initial")
+
+	// merged is fully merged into main, abandoned has a commit of its own, and recent is still being worked on
+	git(old, "branch", "merged")
+	git(old, "checkout", "-b", "abandoned")
+	git(old, "commit", "--allow-empty", "-m", "abandoned work")
+	git(nil, "checkout", "-b", "recent", "main")
+	git(nil, "commit", "--allow-empty", "-m", "recent work")
+	git(nil, "checkout", "main")
+	git(nil, "commit", "--allow-empty", "-m", "more work on main")
+
+	t.Setenv("MERGESTAT_DEFAULT_REPO", dir)
+
+	db := connect(t)
+	db.SetMaxOpenConns(1)
+
+	if err := metrics.CreateOpenPullRequests(db, ""); err != nil {
+		t.Fatalf("failed to create open_pull_requests: %v", err)
+	}
+
+	if _, err := db.Exec("INSERT INTO open_pull_requests VALUES (12, 'abandoned')"); err != nil {
+		t.Fatalf("failed to insert pull request: %v", err)
+	}
+
+	rows, err := db.Query(metrics.StaleBranchesSQL, sql.Named("base", "main"), sql.Named("older_than", "-90 days"))
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err)
+	}
+	defer rows.Close()
+
+	var branches []string
+	for rows.Next() {
+		var name, lastAuthor, lastCommitAt string
+		var remote sql.NullString
+		var ageDays, ahead, behind int
+		var openPR sql.NullInt64
+		if err = rows.Scan(&name, &remote, &lastAuthor, &lastCommitAt, &ageDays, &ahead, &behind, &openPR); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+		branches = append(branches, fmt.Sprintf("%s ahead=%d behind=%d pr=%d", name, ahead, behind, openPR.Int64))
+	}
+
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err)
+	}
+
+	// merged branches are listed first
+	expected := []string{"merged ahead=0 behind=1 pr=0", "abandoned ahead=1 behind=1 pr=12"}
+	if fmt.Sprint(branches) != fmt.Sprint(expected) {
+		t.Fatalf("expected %v, got %v", expected, branches)
+	}
+}
+
+func TestPunchcardSQL(t *testing.T) {
+	dir := t.TempDir()
+	commit := func(author, date string) {
+		t.Helper()
+		cmd := exec.Command("git", "-C", dir, "-c", "user.name="+author, "-c", "user.email="+author+"@example.com",
+			"commit", "--allow-empty", "-m", "commit by "+author)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git commit failed: %v: %s", err, out)
+		}
+	}
+
+	if out, err := exec.Command("git", "init", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v: %s", err, out)
+	}
+
+	// Monday 9am in the timezone of the author, which is Monday 4am in UTC
+	commit("alice", "2023-01-02T09:30:00+05:00")
+	commit("alice", "2023-01-02T09:45:00+05:00")
+	// Saturday 11pm in the timezone of the author, which is Sunday in UTC
+	commit("bob", "2023-01-07T23:00:00-08:00")
+
+	t.Setenv("MERGESTAT_DEFAULT_REPO", dir)
+
+	punchcard := func(author string) *metrics.Punchcard {
+		t.Helper()
+		rows, err := connect(t).Query(metrics.PunchcardSQL, sql.Named("since", "-100 years"), sql.Named("author", author))
+		if err != nil {
+			t.Fatalf("failed to execute query: %v", err)
+		}
+		defer rows.Close()
+
+		p, err := metrics.ReadPunchcard(rows)
+		if err != nil {
+			t.Fatalf("failed to read punch card: %v", err)
+		}
+		return p
+	}
+
+	p := punchcard("")
+	if p[1][9] != 2 || p[6][23] != 1 || p.Max() != 2 {
+		t.Fatalf("expected commits on Monday at 9 and Saturday at 23, got: %v", p)
+	}
+
+	if p = punchcard("BOB@"); p[1][9] != 0 || p[6][23] != 1 {
+		t.Fatalf("expected only the commits of bob, got: %v", p)
+	}
+
+	var out strings.Builder
+	if err := punchcard("").Render(&out); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(out.String(), "\n"); len(lines) < 8 || !strings.HasPrefix(lines[2], "Mon") || !strings.Contains(lines[2], "██") {
+		t.Fatalf("expected Monday to hold the busiest hour, got:\n%s", out.String())
+	}
+
+	js, err := json.Marshal(punchcard(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(js), `[{"day":"Sunday","hours":[0,`) || !strings.Contains(string(js), `"day":"Monday","hours":[0,0,0,0,0,0,0,0,0,2,`) {
+		t.Fatalf("unexpected JSON: %s", js)
+	}
+}
+
+func TestHealthSQL(t *testing.T) {
+	dir := t.TempDir()
+	commit := func(author, file string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		var contents strings.Builder
+		for i := 0; i < 10; i++ {
+			_, _ = fmt.Fprintf(&contents, "%s line %d\n", author, i)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(contents.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, args := range [][]string{{"add", "-A"}, {"commit", "-m", "add " + file}} {
+			args = append([]string{"-C", dir, "-c", "user.name=" + author, "-c", "user.email=" + author + "@example.com"}, args...)
+			if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+				t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+			}
+		}
+	}
+
+	if out, err := exec.Command("git", "init", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v: %s", err, out)
+	}
+
+	// no CODEOWNERS, and the lines are evenly split between three authors, so two of them own half
+	commit("alice", "LICENSE")
+	commit("bob", "README.md")
+	commit("carol", ".github/workflows/ci.yml")
+
+	score := func(weights string) (float64, int) {
+		t.Helper()
+		db := connect(t)
+		db.SetMaxOpenConns(1)
+
+		if err := metrics.CreateHealthRepos(db, []metrics.HealthRepo{{Path: dir, GitHubRepo: "mergestat/mergestat-lite"}}, false); err != nil {
+			t.Fatalf("failed to create health_repos: %v", err)
+		}
+
+		w, err := metrics.ParseHealthWeights(weights)
+		if err != nil {
+			t.Fatalf("failed to parse weights: %v", err)
+		}
+
+		var repository string
+		var score float64
+		var license, readme, codeowners, ci, recentCommits, busFactor int
+		var lastCommitAt string
+		var openPRs, stalePRs, openIssues, staleIssues sql.NullInt64
+		err = db.QueryRow(metrics.HealthSQL, metrics.HealthArgs(w, "-90 days", "-30 days")...).
+			Scan(&repository, &score, &license, &readme, &codeowners, &ci, &lastCommitAt, &recentCommits, &busFactor, &openPRs, &stalePRs, &openIssues, &staleIssues)
+		if err != nil {
+			t.Fatalf("failed to execute query: %v", err)
+		}
+
+		if license != 1 || readme != 1 || codeowners != 0 || ci != 1 || recentCommits != 3 {
+			t.Fatalf("unexpected checks: license=%d readme=%d codeowners=%d ci=%d recent_commits=%d", license, readme, codeowners, ci, recentCommits)
+		}
+		// pull requests and issues aren't checked without checkGitHub
+		if openPRs.Valid || openIssues.Valid {
+			t.Fatalf("expected no pull requests or issues to be checked, got %v and %v", openPRs, openIssues)
+		}
+		return score, busFactor
+	}
+
+	// 3 of 4 files, activity and half the bus factor score, out of a total weight of 10
+	if s, busFactor := score(""); s != 60 || busFactor != 2 {
+		t.Fatalf("expected a score of 60 and a bus factor of 2, got %v and %d", s, busFactor)
+	}
+
+	if s, _ := score("codeowners=0, bus_factor=0"); s != 100 {
+		t.Fatalf("expected a score of 100 without the codeowners and bus factor checks, got %v", s)
+	}
+}
+
+func TestParseHealthWeights(t *testing.T) {
+	weights, err := metrics.ParseHealthWeights("activity=3,ci=0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if weights["activity"] != 3 || weights["ci"] != 0.5 || weights["license"] != metrics.DefaultHealthWeights["license"] {
+		t.Fatalf("unexpected weights: %v", weights)
+	}
+
+	for _, invalid := range []string{"activity", "uptime=1", "ci=-1", "ci=high"} {
+		if _, err := metrics.ParseHealthWeights(invalid); err == nil {
+			t.Fatalf("expected an error parsing %q", invalid)
+		}
+	}
+}
+
+func TestSubtreePath(t *testing.T) {
+	tests := map[string]string{"": "", ".": "", "/": "", "services": "services/", "./services/": "services/", "/services/api": "services/api/"}
+	for dir, expected := range tests {
+		if p := metrics.SubtreePath(dir); p != expected {
+			t.Fatalf("expected %q for %q, got %q", expected, dir, p)
+		}
+	}
+}
+
+func TestOwnersSQL(t *testing.T) {
+	dir := t.TempDir()
+	commit := func(email, file string, lines int) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		var contents strings.Builder
+		for i := 0; i < lines; i++ {
+			_, _ = fmt.Fprintf(&contents, "%s line %d\n", email, i)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(contents.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, args := range [][]string{{"add", "-A"}, {"commit", "-m", "add " + file}} {
+			args = append([]string{"-C", dir, "-c", "user.name=" + email, "-c", "user.email=" + email}, args...)
+			if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+				t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+			}
+		}
+	}
+
+	if out, err := exec.Command("git", "init", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v: %s", err, out)
+	}
+
+	// bob's README (committed along with CODEOWNERS) is outside of the subtree, and alice commits with her GitHub noreply address
+	if err := os.MkdirAll(filepath.Join(dir, ".github"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".github", "CODEOWNERS"), []byte("/services/ @Alice\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	commit("bob@example.com", "README.md", 50)
+	commit("1+alice@users.noreply.github.com", "services/api/main.go", 10)
+	commit("bob@example.com", "services/api/util.go", 30)
+
+	t.Setenv("MERGESTAT_DEFAULT_REPO", dir)
+
+	rows, err := connect(t).Query(metrics.OwnersSQL,
+		sql.Named("rev", ""),
+		sql.Named("path", "services/"),
+		sql.Named("since", "-90 days"),
+		sql.Named("limit", 10),
+	)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err)
+	}
+	defer rows.Close()
+
+	var owners []string
+	for rows.Next() {
+		var owner string
+		var name sql.NullString
+		var files, lines, commits, churn int
+		var coverage, blameShare, churnShare float64
+		if err = rows.Scan(&owner, &name, &files, &coverage, &lines, &blameShare, &commits, &churn, &churnShare); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+		owners = append(owners, fmt.Sprintf("%s files=%d/%v lines=%d/%v commits=%d churn=%d/%v", owner, files, coverage, lines, blameShare, commits, churn, churnShare))
+	}
+
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err)
+	}
+
+	expected := []string{
+		"@alice files=2/1 lines=10/0.25 commits=1 churn=10/0.25",
+		"bob@example.com files=0/0 lines=30/0.75 commits=1 churn=30/0.75",
+	}
+	if fmt.Sprint(owners) != fmt.Sprint(expected) {
+		t.Fatalf("expected %v, got %v", expected, owners)
+	}
+}
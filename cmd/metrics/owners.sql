@@ -0,0 +1,70 @@
+-- Top owners of a subtree of the default repository (--repo), merging the files they own according to CODEOWNERS,
+-- the surviving lines they last modified according to git blame, and their recent churn (lines added and deleted).
+--
+--   $rev        the revision to report on, or '' for HEAD (or --default-ref)
+--   $path       the subtree to report on, with a trailing slash (such as 'services/'), or '' for the whole repository
+--   $since      a SQLite date modifier, commits authored since which make up the recent churn, such as '-90 days'
+--   $limit      the number of owners to list
+--
+-- Authors are identified by their email address, except for those committing with their GitHub noreply address
+-- (login@users.noreply.github.com), identified as @login to match the owners listed in CODEOWNERS. Each owner is
+-- ranked by the sum of their share of the files, lines and churn of the subtree.
+WITH codeowned AS (
+    SELECT path, lower(owner) AS owner FROM codeowners('', $rev) WHERE substr(path, 1, length($path)) = $path
+),
+codeowned_files AS (
+    SELECT owner, count(DISTINCT path) AS files FROM codeowned WHERE owner IS NOT NULL GROUP BY owner
+),
+blamed AS (
+    SELECT lower(author_email) AS author_email, author_name, lines
+    FROM blame_summary('', $rev, iif($path = '', '', $path || '**'))
+),
+recent_stats AS (
+    SELECT lower(c.author_email) AS author_email, c.author_name, c.hash, s.additions + s.deletions AS churn
+    FROM commits('', $rev) c, stats('', c.hash) s
+    WHERE julianday(c.author_when) >= julianday('now', $since) AND substr(s.file_path, 1, length($path)) = $path
+),
+authors AS (
+    SELECT
+        author_email,
+        iif(author_email LIKE '%@users.noreply.github.com',
+            '@' || substr(author_email, instr(author_email, '+') + 1, instr(author_email, '@') - instr(author_email, '+') - 1),
+            author_email) AS owner,
+        max(author_name) AS name
+    FROM (SELECT author_email, author_name FROM blamed UNION SELECT author_email, author_name FROM recent_stats)
+    GROUP BY author_email
+),
+blame_lines AS (
+    SELECT a.owner, sum(b.lines) AS lines FROM blamed b JOIN authors a ON a.author_email = b.author_email GROUP BY a.owner
+),
+churn AS (
+    SELECT a.owner, count(DISTINCT r.hash) AS commits, sum(r.churn) AS churn
+    FROM recent_stats r JOIN authors a ON a.author_email = r.author_email
+    GROUP BY a.owner
+),
+totals AS (
+    SELECT
+        (SELECT count(DISTINCT path) FROM codeowned) AS files,
+        (SELECT sum(lines) FROM blamed) AS lines,
+        (SELECT sum(churn) FROM recent_stats) AS churn
+),
+ranked AS (
+    SELECT
+        o.owner,
+        (SELECT max(name) FROM authors a WHERE a.owner = o.owner) AS name,
+        coalesce(f.files, 0) AS codeowned_files,
+        round(1.0 * coalesce(f.files, 0) / max(t.files, 1), 3) AS codeowners_coverage,
+        coalesce(b.lines, 0) AS blame_lines,
+        round(1.0 * coalesce(b.lines, 0) / max(t.lines, 1), 3) AS blame_share,
+        coalesce(c.commits, 0) AS recent_commits,
+        coalesce(c.churn, 0) AS recent_churn,
+        round(1.0 * coalesce(c.churn, 0) / max(t.churn, 1), 3) AS churn_share
+    FROM (SELECT owner FROM codeowned_files UNION SELECT owner FROM authors) o
+    CROSS JOIN totals t
+    LEFT JOIN codeowned_files f ON f.owner = o.owner
+    LEFT JOIN blame_lines b ON b.owner = o.owner
+    LEFT JOIN churn c ON c.owner = o.owner
+)
+SELECT * FROM ranked
+ORDER BY codeowners_coverage + blame_share + churn_share DESC, owner
+LIMIT $limit
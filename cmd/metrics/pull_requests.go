@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// CreateOpenPullRequests creates the (temporary) open_pull_requests table reports use to tell whether a branch
+// is still under review, and fills it with the open pull requests of githubRepo (owner/name), if it's set.
+// db should be limited to a single connection, so that later queries see the table.
+func CreateOpenPullRequests(db *sql.DB, githubRepo string) error {
+	if _, err := db.Exec("CREATE TEMP TABLE IF NOT EXISTS open_pull_requests (number INT PRIMARY KEY, head_ref_name TEXT)"); err != nil {
+		return fmt.Errorf("failed to create open_pull_requests table: %v", err)
+	}
+
+	if githubRepo == "" {
+		return nil
+	}
+
+	const insert = `INSERT OR REPLACE INTO open_pull_requests (number, head_ref_name)
+		SELECT number, head_ref_name FROM github_repo_pull_requests(?) WHERE state = 'OPEN'`
+	if _, err := db.Exec(insert, githubRepo); err != nil {
+		return fmt.Errorf("failed to load open pull requests: %v", err)
+	}
+
+	return nil
+}
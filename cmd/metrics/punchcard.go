@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Punchcard holds the number of commits by day of the week (from Sunday) and hour of the day
+type Punchcard [7][24]int
+
+// ReadPunchcard reads the day, hour and commits columns returned by PunchcardSQL into a punch card
+func ReadPunchcard(rows *sql.Rows) (*Punchcard, error) {
+	var p Punchcard
+	for rows.Next() {
+		var day, hour, commits int
+		if err := rows.Scan(&day, &hour, &commits); err != nil {
+			return nil, err
+		}
+		if day < 0 || day > 6 || hour < 0 || hour > 23 {
+			return nil, fmt.Errorf("invalid punch card cell: day %d, hour %d", day, hour)
+		}
+		p[day][hour] += commits
+	}
+	return &p, rows.Err()
+}
+
+// Max returns the highest number of commits of any hour of the week
+func (p *Punchcard) Max() int {
+	var max int
+	for _, hours := range p {
+		for _, commits := range hours {
+			if commits > max {
+				max = commits
+			}
+		}
+	}
+	return max
+}
+
+// punchcardShades are the blocks hours are drawn with, from no commits to the busiest hour of the week
+var punchcardShades = []string{"·", "░", "▒", "▓", "█"}
+
+// Render draws the punch card as a heatmap of blocks, with a row per day and a column per hour,
+// shaded relative to the busiest hour of the week
+func (p *Punchcard) Render(w io.Writer) error {
+	var b strings.Builder
+	max := p.Max()
+
+	b.WriteString("    ")
+	for hour := 0; hour < 24; hour++ {
+		fmt.Fprintf(&b, " %02d", hour)
+	}
+	b.WriteString("  total\n")
+
+	for day, hours := range p {
+		var total int
+		fmt.Fprintf(&b, "%-4s", time.Weekday(day).String()[:3])
+		for _, commits := range hours {
+			shade := punchcardShades[0]
+			if commits > 0 {
+				// spread the non-empty hours over the remaining shades, so that any commit shows up
+				shade = punchcardShades[1+(commits*(len(punchcardShades)-1)-1)/max]
+			}
+			fmt.Fprintf(&b, " %s%s", shade, shade)
+			total += commits
+		}
+		fmt.Fprintf(&b, "  %d\n", total)
+	}
+
+	fmt.Fprintf(&b, "\n%s no commits, %s busiest hour (%d commits)\n", punchcardShades[0], punchcardShades[len(punchcardShades)-1], max)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// punchcardDay is the JSON representation of a day of a punch card
+type punchcardDay struct {
+	Day   string `json:"day"`
+	Hours []int  `json:"hours"`
+	Total int    `json:"total"`
+}
+
+// MarshalJSON returns the punch card as an array of days (from Sunday), each with the commits of each of its hours
+func (p *Punchcard) MarshalJSON() ([]byte, error) {
+	var days = make([]punchcardDay, 0, len(p))
+	for day, hours := range p {
+		var d = punchcardDay{Day: time.Weekday(day).String(), Hours: make([]int, len(hours))}
+		for hour, commits := range hours {
+			d.Hours[hour] = commits
+			d.Total += commits
+		}
+		days = append(days, d)
+	}
+	return json.Marshal(days)
+}
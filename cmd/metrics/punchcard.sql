@@ -0,0 +1,17 @@
+-- Commits of the default repository (--repo) by day of the week and hour of the day they were authored,
+-- in the timezone of their author, for a punch card of when work happens.
+--
+--   $since   a SQLite date modifier, only commits authored after which are counted, such as '-1 years'
+--   $author  only count the commits of authors whose name or email contains this (case-insensitive), or all of them if empty
+--
+-- author_when holds the local time of the author followed by their UTC offset (as recorded in the commit), which is
+-- left out so that SQLite doesn't convert the time to UTC. day is 0 for Sunday through 6 for Saturday.
+SELECT
+    CAST(strftime('%w', substr(author_when, 1, 19)) AS INT) AS day,
+    CAST(substr(author_when, 12, 2) AS INT) AS hour,
+    count(*) AS commits
+FROM commits('')
+WHERE author_when > strftime('%Y-%m-%dT%H:%M:%SZ', 'now', $since)
+  AND ($author = '' OR instr(lower(author_name), lower($author)) > 0 OR instr(lower(author_email), lower($author)) > 0)
+GROUP BY day, hour
+ORDER BY day, hour
@@ -0,0 +1,57 @@
+-- Review latency of the pull requests of a GitHub repository: the time from opening a pull request to its first review
+-- (or comment) by someone other than its author, and the time to merge it, as percentiles per team.
+--
+--   $repo   owner/name of the GitHub repository
+--   $since  a SQLite date modifier bounding when pull requests were opened, such as '-90 days'
+--
+-- Teams are read from a roster(login, team) table, which may be empty (see --roster), in which case only the
+-- totals (team 'all') are reported. Reviews and comments are fetched per pull request, which can take a while.
+WITH prs AS MATERIALIZED (
+    SELECT number, author_login, created_at, merged, merged_at
+    FROM github_repo_pull_requests($repo)
+    WHERE created_at >= datetime('now', $since) AND NOT is_draft
+),
+responses AS MATERIALIZED (
+    SELECT p.number, min(r.submitted_at) AS responded_at
+    FROM prs p, github_repo_pr_reviews($repo, p.number) r
+    WHERE r.author_login != p.author_login AND r.submitted_at IS NOT NULL
+    GROUP BY p.number
+    UNION ALL
+    SELECT p.number, min(c.created_at) AS responded_at
+    FROM prs p, github_repo_pr_comments($repo, p.number) c
+    WHERE c.author_login != p.author_login
+    GROUP BY p.number
+),
+latencies AS (
+    SELECT p.author_login, 'time_to_first_review' AS metric,
+        (julianday((SELECT min(responded_at) FROM responses r WHERE r.number = p.number)) - julianday(p.created_at)) * 24 AS hours
+    FROM prs p
+    UNION ALL
+    SELECT p.author_login, 'time_to_merge' AS metric, (julianday(p.merged_at) - julianday(p.created_at)) * 24 AS hours
+    FROM prs p WHERE p.merged
+),
+grouped AS (
+    SELECT coalesce(roster.team, 'unassigned') AS team, metric, hours
+    FROM latencies LEFT JOIN roster ON roster.login = latencies.author_login
+    WHERE hours IS NOT NULL AND EXISTS (SELECT 1 FROM roster)
+    UNION ALL
+    SELECT 'all' AS team, metric, hours FROM latencies WHERE hours IS NOT NULL
+),
+ranked AS (
+    SELECT team, metric, hours,
+        row_number() OVER (PARTITION BY team, metric ORDER BY hours) AS rn,
+        count(*) OVER (PARTITION BY team, metric) AS n
+    FROM grouped
+)
+-- percentiles use the nearest-rank method
+SELECT
+    team,
+    metric,
+    n AS pull_requests,
+    round(min(CASE WHEN rn >= 0.50 * n THEN hours END), 2) AS p50_hours,
+    round(min(CASE WHEN rn >= 0.75 * n THEN hours END), 2) AS p75_hours,
+    round(min(CASE WHEN rn >= 0.90 * n THEN hours END), 2) AS p90_hours,
+    round(avg(hours), 2) AS avg_hours
+FROM ranked
+GROUP BY team, metric
+ORDER BY team = 'all' DESC, team, metric
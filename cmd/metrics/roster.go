@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CreateRoster creates the (temporary) roster table reports use to group people into teams,
+// and fills it with the login,team records read from r, if it's not nil. A header row is skipped.
+// db should be limited to a single connection, so that later queries see the table.
+func CreateRoster(db *sql.DB, r io.Reader) error {
+	if _, err := db.Exec("CREATE TEMP TABLE IF NOT EXISTS roster (login TEXT PRIMARY KEY, team TEXT NOT NULL)"); err != nil {
+		return fmt.Errorf("failed to create roster table: %v", err)
+	}
+
+	if r == nil {
+		return nil
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to read roster: %v", err)
+	}
+
+	for i, record := range records {
+		login, team := strings.TrimPrefix(strings.TrimSpace(record[0]), "@"), strings.TrimSpace(record[1])
+		if i == 0 && strings.EqualFold(login, "login") && strings.EqualFold(team, "team") {
+			continue
+		}
+
+		if _, err = db.Exec("INSERT OR REPLACE INTO roster (login, team) VALUES (?, ?)", login, team); err != nil {
+			return fmt.Errorf("failed to load roster: %v", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+-- Branches of the default repository (--repo) without commits for a while, which are candidates for deletion.
+--
+--   $base        the branch that branches are compared against, such as 'main'
+--   $older_than  a SQLite date modifier, branches whose last commit is older than which are reported, such as '-90 days'
+--
+-- ahead counts the commits of a branch that aren't in $base (if 0, the branch was merged), and behind the commits of
+-- $base that aren't in the branch. Open pull requests are read from an open_pull_requests(number, head_ref_name)
+-- table, which may be empty (see --check-prs), so that branches still under review can be kept.
+WITH branches AS (
+    SELECT name, remote, hash FROM refs('')
+    WHERE type = 'branch' AND hash IS NOT NULL AND name != $base AND name NOT LIKE '%/' || $base
+)
+SELECT
+    b.name,
+    b.remote,
+    c.author_name AS last_author,
+    c.committer_when AS last_commit_at,
+    CAST(julianday('now') - julianday(c.committer_when) AS INT) AS age_days,
+    commits_ahead('', b.hash, $base) AS ahead,
+    commits_ahead('', $base, b.hash) AS behind,
+    (SELECT min(number) FROM open_pull_requests p WHERE p.head_ref_name = b.name OR b.remote || '/' || p.head_ref_name = b.name) AS open_pr
+FROM branches b
+JOIN commits c ON c.hash = b.hash
+WHERE c.committer_when < datetime('now', $older_than)
+ORDER BY ahead = 0 DESC, c.committer_when ASC
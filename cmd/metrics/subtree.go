@@ -0,0 +1,16 @@
+package metrics
+
+import (
+	"path"
+	"strings"
+)
+
+// SubtreePath normalizes the path of a directory of a repository (such as ./services or /services/) into the
+// prefix of the paths of the files in it (services/), as reports expect. The root of the repository is "".
+func SubtreePath(dir string) string {
+	dir = strings.Trim(path.Clean("/"+strings.TrimSpace(dir)), "/")
+	if dir == "" {
+		return ""
+	}
+	return dir + "/"
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/mergestat/mergestat-lite/cmd/changelog"
+	"github.com/mergestat/mergestat-lite/cmd/metrics"
+	"github.com/spf13/cobra"
+)
+
+var (
+	staleBranchesOlderThan string
+	staleBranchesBase      string
+	staleBranchesCheckPRs  bool
+
+	punchcardSince  string
+	punchcardAuthor string
+
+	healthWeights      string
+	healthActiveWithin string
+	healthStaleAfter   string
+	healthCheckGitHub  bool
+
+	ownersPath  string
+	ownersRev   string
+	ownersSince string
+	ownersLimit int
+)
+
+func init() {
+	reportCmd.PersistentFlags().BoolVar(&metricsOutputJSON, "json", false, "output as JSON")
+	reportCmd.PersistentFlags().BoolVar(&metricsPrintSQL, "print-sql", false, "print the SQL query behind the report instead of executing it")
+
+	staleBranchesCmd.Flags().StringVar(&staleBranchesOlderThan, "older-than", "90d", "report branches whose last commit is older than this, in days (d), weeks (w), months (m) or years (y)")
+	staleBranchesCmd.Flags().StringVar(&staleBranchesBase, "base", "", "the branch others are compared against. Defaults to --default-ref, or HEAD")
+	staleBranchesCmd.Flags().BoolVar(&staleBranchesCheckPRs, "check-prs", false, "look up open pull requests with the GitHub API (requires GITHUB_TOKEN), to tell which branches are still under review")
+	staleBranchesCmd.Flags().StringVar(&metricsGitHubRepo, "github-repo", "", "owner/name of the GitHub repository to look up pull requests in. Detected from the origin remote if not supplied")
+
+	punchcardCmd.Flags().StringVar(&punchcardSince, "since", "1y", "count the commits authored since this long ago, in days (d), weeks (w), months (m) or years (y)")
+	punchcardCmd.Flags().StringVar(&punchcardAuthor, "author", "", "only count the commits of authors whose name or email contains this")
+
+	healthCmd.Flags().StringVar(&healthWeights, "weights", "", "override the weights of checks, as a list of check=weight pairs such as 'activity=3,codeowners=0'")
+	healthCmd.Flags().StringVar(&healthActiveWithin, "active-within", "90d", "consider repositories with commits since this long ago active, in days (d), weeks (w), months (m) or years (y)")
+	healthCmd.Flags().StringVar(&healthStaleAfter, "stale-after", "30d", "consider open pull requests and issues not updated for this long stale, in days (d), weeks (w), months (m) or years (y)")
+	healthCmd.Flags().BoolVar(&healthCheckGitHub, "check-github", false, "look up open pull requests and issues with the GitHub API (requires GITHUB_TOKEN), for repositories with a GitHub origin remote")
+
+	ownersCmd.Flags().StringVar(&ownersPath, "path", "", "the subtree (directory) to report on, such as 'services/'. Defaults to the whole repository")
+	ownersCmd.Flags().StringVar(&ownersRev, "rev", "", "the revision to report on. Defaults to HEAD (or --default-ref)")
+	ownersCmd.Flags().StringVar(&ownersSince, "since", "90d", "count the churn of commits authored since this long ago, in days (d), weeks (w), months (m) or years (y)")
+	ownersCmd.Flags().IntVar(&ownersLimit, "limit", 10, "the number of owners to list")
+
+	reportCmd.AddCommand(staleBranchesCmd, punchcardCmd, healthCmd, ownersCmd)
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report [command]",
+	Short: "Generate repository maintenance reports",
+}
+
+var staleBranchesCmd = &cobra.Command{
+	Use:   "stale-branches",
+	Short: "List branches that are candidates for deletion",
+	Long: `Lists the branches of the default repository (either the current directory or supplied by --repo) without any commits
+for a while, along with how many commits they are ahead and behind the base branch. Branches that are not ahead
+have been merged, and are listed first. Use --check-prs to also report the open pull request of each branch, if any.
+
+Use --print-sql to inspect (and adapt) the query behind the report, which reads pull requests from an open_pull_requests(number, head_ref_name) table.
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if metricsPrintSQL {
+			fmt.Print(metrics.StaleBranchesSQL)
+			return
+		}
+
+		olderThan, err := metrics.AgeModifier(staleBranchesOlderThan)
+		if err != nil {
+			handleExitError(err)
+		}
+
+		base := staleBranchesBase
+		if base == "" {
+			if base = defaultRef; base == "" {
+				base = "HEAD"
+			}
+		}
+
+		var githubRepo string
+		if staleBranchesCheckPRs {
+			githubRepo = metricsGitHubRepoOrDetect()
+		}
+
+		runMetricsReport(metrics.StaleBranchesSQL, func(db *sql.DB) error { return metrics.CreateOpenPullRequests(db, githubRepo) },
+			sql.Named("base", base),
+			sql.Named("older_than", olderThan),
+		)
+	},
+}
+
+var punchcardCmd = &cobra.Command{
+	Use:   "punchcard",
+	Short: "Draw a heatmap of commits by hour of the day and day of the week",
+	Long: `Draws a heatmap of the commits of the default repository (either the current directory or supplied by --repo)
+by hour of the day and day of the week they were authored, in the timezone of their author (as recorded in each commit),
+showing when work happens. Use --json to output the number of commits of each hour instead.
+
+Use --print-sql to inspect (and adapt) the query behind the report.
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if metricsPrintSQL {
+			fmt.Print(metrics.PunchcardSQL)
+			return
+		}
+
+		since, err := metrics.AgeModifier(punchcardSince)
+		if err != nil {
+			handleExitError(err)
+		}
+
+		renderMetricsReport(metrics.PunchcardSQL, nil, func(rows *sql.Rows) error {
+			punchcard, err := metrics.ReadPunchcard(rows)
+			if err != nil {
+				return err
+			}
+
+			if metricsOutputJSON {
+				return json.NewEncoder(os.Stdout).Encode(punchcard)
+			}
+			return punchcard.Render(os.Stdout)
+		},
+			sql.Named("since", since),
+			sql.Named("author", punchcardAuthor),
+		)
+	},
+}
+
+var healthCmd = &cobra.Command{
+	Use:   "health [repo paths...]",
+	Short: "Score the health of repositories",
+	Long: `Scores the health of repositories (those supplied as arguments, or the default repository) from 0 to 100,
+combining the following checks, weighted by --weights:
+
+  license, readme, codeowners, ci  whether the repository has a LICENSE, README, CODEOWNERS and CI configuration (weight 1 each)
+  activity                         whether there are commits within --active-within (weight 2)
+  bus_factor                       how many authors last modified half of the surviving lines, scoring 0 for one, 0.5 for two and 1 for three or more (weight 2)
+  stale_prs, stale_issues          the share of open pull requests and issues updated within --stale-after (weight 1 each)
+
+The staleness checks require --check-github, and are left out of the score of repositories without a GitHub origin remote.
+Repositories are listed from the least healthy.
+
+Use --print-sql to inspect (and adapt) the query behind the report, which reads repositories from a health_repos(repository, github_repo)
+table, and open pull requests and issues from a health_open_items(repository, kind, number, updated_at) table.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if metricsPrintSQL {
+			fmt.Print(metrics.HealthSQL)
+			return
+		}
+
+		weights, err := metrics.ParseHealthWeights(healthWeights)
+		if err != nil {
+			handleExitError(err)
+		}
+
+		activeWithin, err := metrics.AgeModifier(healthActiveWithin)
+		if err != nil {
+			handleExitError(err)
+		}
+
+		staleAfter, err := metrics.AgeModifier(healthStaleAfter)
+		if err != nil {
+			handleExitError(err)
+		}
+
+		paths := args
+		if len(paths) == 0 {
+			if paths = []string{repo}; repo == "" {
+				paths = []string{"."}
+			}
+		}
+
+		var repos = make([]metrics.HealthRepo, len(paths))
+		for i, path := range paths {
+			repos[i] = metrics.HealthRepo{Path: path, GitHubRepo: changelog.DetectGitHubRepo(path)}
+		}
+
+		runMetricsReport(metrics.HealthSQL, func(db *sql.DB) error { return metrics.CreateHealthRepos(db, repos, healthCheckGitHub) },
+			metrics.HealthArgs(weights, activeWithin, staleAfter)...,
+		)
+	},
+}
+
+var ownersCmd = &cobra.Command{
+	Use:   "owners",
+	Short: "List the top owners of a subtree",
+	Long: `Lists the top owners of a subtree (supplied by --path) of the default repository (either the current directory or supplied by --repo),
+merging three views of ownership: the files each owner is listed for in CODEOWNERS, the surviving lines each author last
+modified (according to git blame), and the lines each author recently added or deleted. Each view is also given as the
+owner's share of the subtree, and owners are ranked by the sum of those shares. This helps maintainers of monorepos
+find who to involve when reorganizing a part of the code base.
+
+Authors committing with their GitHub noreply address are matched to their @login in CODEOWNERS. Teams and other
+authors are listed as they appear in CODEOWNERS and by email address, respectively.
+
+Use --print-sql to inspect (and adapt) the query behind the report.
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if metricsPrintSQL {
+			fmt.Print(metrics.OwnersSQL)
+			return
+		}
+
+		since, err := metrics.AgeModifier(ownersSince)
+		if err != nil {
+			handleExitError(err)
+		}
+
+		runMetricsReport(metrics.OwnersSQL, nil,
+			sql.Named("rev", ownersRev),
+			sql.Named("path", metrics.SubtreePath(ownersPath)),
+			sql.Named("since", since),
+			sql.Named("limit", ownersLimit),
+		)
+	},
+}
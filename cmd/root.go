@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/mergestat/mergestat-lite/pkg/asof"
 	"github.com/mergestat/mergestat-lite/pkg/display"
 	. "github.com/mergestat/mergestat-lite/pkg/query"
 	"github.com/rs/zerolog"
@@ -16,37 +19,69 @@ import (
 )
 
 var format string                                     // output format flag
+var output string                                     // path to write the output to, instead of stdout
+var chartType, chartX, chartY string                  // how to chart the results, in the chart format
 var presetQuery string                                // named / preset query flag
 var dbPath string                                     // path to sqlite db file on disk to mount on
 var repo string                                       // path to repo on disk
 var cloneDir string                                   // path to directory to clone repos in
 var skipMailmap bool                                  // whether to skip usage of the .mailmap file when querying commit history
+var noReplaceObjects bool                             // whether to ignore replace refs when querying commit history
+var gitBackend string                                 // which implementation to walk commit history with (go-git, libgit2 or cli)
+var defaultRef string                                 // ref the git tables default to when none is supplied, instead of HEAD
+var onError string                                    // how the git tables handle repositories that can't be read (fail, skip or null)
 var gitSSLNoVerify = os.Getenv("GIT_SSL_NO_VERIFY")   // if set to anything, will not verify SSL when cloning
 var githubToken = os.Getenv("GITHUB_TOKEN")           // GitHub auth token for GitHub tables
 var sourcegraphToken = os.Getenv("SOURCEGRAPH_TOKEN") // Sourcegraph auth token for Sourcegraph queries
+var slackToken = os.Getenv("SLACK_TOKEN")             // Slack auth token for Slack tables
+var pagerdutyToken = os.Getenv("PAGERDUTY_TOKEN")     // PagerDuty REST API key for PagerDuty tables
 var verbose bool                                      // whether or not to print logs to stderr
 var codex bool                                        // whether or not to use codex for query execution
+var maxMemory string                                  // abort query execution once heap usage exceeds this size
+var statsPrefetchMemory string                        // how much memory the stats table holds stats computed ahead of time in
+var githubCache string                                // directory to cache GitHub API responses in
+var offline bool                                      // whether to answer GitHub queries from the cache only
+var allowHTTP bool                                    // whether to register the http_get functions
+var orgMapping string                                 // path to the file mapping emails to organizations, for org_of
 var logger = zerolog.Nop()                            // By default use a NOOP logger
+var queryCtx = context.Background()                   // context of the query being run, cancelled on interrupt
+var guard *memoryGuard                                // cancels queryCtx once memory usage exceeds --max-memory, if set
 
 func init() {
 	// local (root command only) flags
-	rootCmd.Flags().StringVarP(&format, "format", "f", "table", "specify the output format. Options are 'csv' 'csv-noheader' 'tsv' 'tsv-noheader' 'table' 'single' 'ndjson' and 'json'")
+	rootCmd.Flags().StringVarP(&format, "format", "f", "table", "specify the output format. Options are 'csv' 'csv-noheader' 'tsv' 'tsv-noheader' 'table' 'single' 'ndjson' 'json' 'xlsx' (an Excel workbook, with a sheet for each statement of the query returning rows) and 'chart' (see --chart-type)")
+	rootCmd.Flags().StringVar(&chartType, "chart-type", display.ChartBar, "in the chart format, the type of chart to render. Options are 'bar' and 'line'. Rendered in the terminal, or as an SVG image if --output ends in .svg")
+	rootCmd.Flags().StringVar(&chartX, "x", "", "in the chart format, the column labelling the bars or points (defaults to the first column)")
+	rootCmd.Flags().StringVar(&chartY, "y", "", "in the chart format, the column holding the values to chart (defaults to the second column)")
+	rootCmd.Flags().StringVarP(&output, "output", "o", "", "write the output to this file, instead of stdout")
 	rootCmd.Flags().StringVarP(&presetQuery, "preset", "p", "", "used to pick a preset query")
 	rootCmd.PersistentFlags().StringVarP(&dbPath, "db", "d", "", "specify a db file on disk to mount when executing queries")
-	rootCmd.PersistentFlags().StringVarP(&repo, "repo", "r", ".", "specify a path to a default repo on disk. This will be used if no repo is supplied as an argument to a git table")
+	rootCmd.PersistentFlags().StringVarP(&repo, "repo", "r", "", "specify a path to a default repo on disk. This will be used if no repo is supplied as an argument to a git table. Defaults to $GIT_DIR, $MERGESTAT_DEFAULT_REPO or the repo enclosing the current directory")
 	rootCmd.PersistentFlags().StringVarP(&cloneDir, "clone-dir", "c", "", "specify a path to a directory on disk to use when cloning repos, instead of a tmp dir. Should be empty to avoid path conflicts.")
 	rootCmd.PersistentFlags().BoolVar(&skipMailmap, "skip-mailmap", false, "skip usage of .mailmap file when querying commit history.")
+	rootCmd.PersistentFlags().BoolVar(&noReplaceObjects, "no-replace-objects", false, "ignore replace refs (refs/replace/*) when querying commit history, as with git --no-replace-objects. Grafts and shallow clone boundaries are still honored.")
+	rootCmd.PersistentFlags().StringVar(&gitBackend, "git-backend", "go-git", "specify the backend the commits table walks commit history with. Options are 'go-git', 'libgit2' (faster on very large repos, but walks histories altered by replace refs or grafts with go-git) and 'cli' (shells out to the system git). The stats, files and diff tables always use libgit2")
+	rootCmd.PersistentFlags().StringVar(&defaultRef, "default-ref", "", "specify a ref (such as 'main') that git tables default to when none is supplied, instead of HEAD. Useful with bare mirrors where HEAD points somewhere unhelpful")
+	rootCmd.PersistentFlags().StringVar(&onError, "on-error", "fail", "specify how the commits and refs tables handle repositories that can't be read, when no on_error argument is supplied. Options are 'fail' (fail the query), 'skip' (return no row for the repository) and 'null' (return a single row with the error in the error column)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "whether or not to print query execution logs to stderr")
 	rootCmd.PersistentFlags().BoolVarP(&codex, "codex", "x", false, "whether or not to use codex for query execution")
+	rootCmd.PersistentFlags().StringVar(&githubCache, "github-cache", "", "cache GitHub API responses in this directory (or in the user cache directory if no directory is given), so that queries can be answered again with --offline")
+	rootCmd.PersistentFlags().Lookup("github-cache").NoOptDefVal = defaultGitHubCacheDir()
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "answer queries of the GitHub tables exclusively from the --github-cache directory, failing on responses that weren't cached by an earlier run. No token is needed.")
+	rootCmd.PersistentFlags().StringVar(&statsPrefetchMemory, "stats-prefetch-memory", "0", "compute the stats of upcoming commits ahead of time, with a small pool of workers, holding them in up to this much memory (e.g. '32MB'). Speeds up querying the stats of a whole history. Disabled (0) by default.")
+	rootCmd.PersistentFlags().BoolVar(&allowHTTP, "allow-http", false, "register the http_get(url) function and http_get_json(url, json_path) table, which make GET requests to any url from within queries (such as to enrich results with internal APIs). Responses are cached for the duration of the process, and limited to $HTTP_GET_MAX_BYTES (10MB by default).")
+	rootCmd.PersistentFlags().StringVar(&orgMapping, "org-mapping", "", "path to a YAML (or JSON) file mapping email domains to organizations (under 'domains'), and single emails (under 'overrides'), for the org_of(email) function")
+	rootCmd.PersistentFlags().StringVar(&maxMemory, "max-memory", "", "abort the query cleanly once memory usage exceeds this size (e.g. '512MB' or '2GB'). Results are streamed (the table format a page of rows at a time), but tables aggregating their rows (such as blame_summary) still hold them all, and are bounded by this limit alone")
 
 	// register the sqlite extension ahead of any command
 	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
 		setupLogger()
+		setupMemoryGuard(cmd)
 		registerExt()
 	}
 
 	// add sub commands
-	rootCmd.AddCommand(exportCmd, serveCmd, summarizeCmd)
+	rootCmd.AddCommand(exportCmd, serveCmd, summarizeCmd, graphCmd, changelogCmd, metricsCmd, reportCmd, cloneOrgCmd, fleetCmd)
 
 	// conditionally add the pgsync sub command
 	// TODO(patrickdevivo) "conditional" for now until the behavior stabilizes
@@ -85,7 +120,11 @@ var rootCmd = &cobra.Command{
 	Use:  `mergestat "SELECT * FROM commits"`,
 	Args: cobra.MaximumNArgs(2),
 	Long: `mergestat is a CLI for querying git repositories with SQL, using SQLite virtual tables.
-Example queries can be found in the GitHub repo: https://github.com/mergestat/mergestat`,
+Example queries can be found in the GitHub repo: https://github.com/mergestat/mergestat
+
+Add an AS OF '<ref>' clause after a table (or at the end of the query) to query the git tables as of a ref,
+rather than HEAD (or --default-ref), as in: SELECT count(*) FROM files AS OF 'v1.0.0'
+The ref applies to all the git tables of the query, but the ones supplied a ref explicitly.`,
 	Short: `Query git repositories with SQL`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
@@ -136,20 +175,103 @@ Example queries can be found in the GitHub repo: https://github.com/mergestat/me
 			query = generatedSQL
 		}
 
+		// an AS OF '<ref>' clause sets the ref the git tables default to, overriding --default-ref
+		var asOfRef string
+		if query, asOfRef, err = asof.Rewrite(query); err != nil {
+			handleExitError(err)
+		}
+		if asOfRef != "" {
+			extensionContext["defaultRef"] = asOfRef
+		}
+
+		// an interrupt (Ctrl-C) cancels the query, along with any API requests it's making
+		ctx, stop := signal.NotifyContext(queryCtx, os.Interrupt)
+		defer stop()
+		queryCtx = ctx
+
+		var out io.Writer = os.Stdout
+		if output != "" {
+			var f *os.File
+			if f, err = os.Create(output); err != nil {
+				handleExitError(fmt.Errorf("failed to create output file: %v", err))
+			}
+			defer f.Close()
+			out = f
+		} else if format == "xlsx" {
+			if info, err = os.Stdout.Stat(); err == nil && !isPiped(info) {
+				handleExitError(fmt.Errorf("refusing to write a workbook to the terminal: use --output or redirect stdout"))
+			}
+		}
+
+		// a workbook holds the resultsets of all of the statements of the query, rather than of the last one
+		if format == "xlsx" {
+			if err = writeWorkbook(ctx, db, query, out); err != nil {
+				handleExitError(guard.Err(err))
+			}
+			return
+		}
+
 		var rows *sql.Rows
-		if rows, err = db.Query(query); err != nil {
-			handleExitError(fmt.Errorf("query execution failed: %v", err))
+		if rows, err = db.QueryContext(ctx, query); err != nil {
+			handleExitError(fmt.Errorf("query execution failed: %v", guard.Err(err)))
 		}
 		defer rows.Close()
 
-		if err = display.WriteTo(rows, os.Stdout, format, false); err != nil {
-			handleExitError(fmt.Errorf("failed to output resultset: %v", err))
+		if format == "chart" {
+			opts := display.ChartOptions{Type: chartType, X: chartX, Y: chartY, SVG: strings.HasSuffix(strings.ToLower(output), ".svg")}
+			if err = display.WriteChart(rows, out, opts); err != nil {
+				handleExitError(fmt.Errorf("failed to chart resultset: %v", guard.Err(err)))
+			}
+			return
+		}
+
+		if err = display.WriteTo(rows, out, format, false); err != nil {
+			handleExitError(fmt.Errorf("failed to output resultset: %v", guard.Err(err)))
 		}
 	},
 }
 
 func isPiped(info os.FileInfo) bool { return info.Mode()&os.ModeCharDevice == 0 }
 
+// writeWorkbook runs each statement of query in turn, on the same connection (so that temporary tables created by
+// a statement can be queried by the next ones), writing the resultset of each one returning rows as a sheet of a workbook
+func writeWorkbook(ctx context.Context, db *sql.DB, query string, w io.Writer) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open connection: %v", err)
+	}
+	defer conn.Close()
+
+	var workbook = display.NewXLSXWriter(w)
+	for _, statement := range Split(query) {
+		rows, err := conn.QueryContext(ctx, statement)
+		if err != nil {
+			return fmt.Errorf("query execution failed: %v", err)
+		}
+
+		var columns []string
+		if columns, err = rows.Columns(); err == nil {
+			if len(columns) > 0 {
+				err = workbook.AddSheet("", rows)
+			} else {
+				// statements returning no rows (such as CREATE TEMP TABLE) only run once stepped through
+				for rows.Next() {
+				}
+				err = rows.Err()
+			}
+		}
+		_ = rows.Close()
+		if err != nil {
+			return fmt.Errorf("failed to output resultset: %v", err)
+		}
+	}
+
+	if err = workbook.Close(); err != nil {
+		return fmt.Errorf("failed to output workbook: %v", err)
+	}
+	return nil
+}
+
 // Execute executes the root command
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
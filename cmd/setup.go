@@ -1,19 +1,29 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	nethttp "net/http"
 	"os"
+	"strconv"
 
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/mergestat/mergestat-lite/extensions"
 	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/extensions/services"
+	"github.com/mergestat/mergestat-lite/pkg/httpcache"
 	"github.com/mergestat/mergestat-lite/pkg/locator"
 	"go.riyazali.net/sqlite"
+	"golang.org/x/oauth2"
 
 	// bring in sqlite 🙌
 	_ "github.com/mattn/go-sqlite3"
 	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
 )
 
+// extensionContext is the context of the registered extensions, kept to set the default ref of a query (see asof.Rewrite)
+var extensionContext services.Context
+
 func registerExt() {
 	multiLocOpt := &locator.MultiLocatorOptions{
 		CloneDir:        cloneDir,
@@ -28,6 +38,31 @@ func registerExt() {
 		skipMailmapCtx = "true"
 	}
 
+	var noReplaceObjectsCtx string
+	if noReplaceObjects {
+		noReplaceObjectsCtx = "true"
+	}
+
+	var statsPrefetchMemoryCtx = "0"
+	if statsPrefetchMemory != "0" {
+		size, err := parseByteSize(statsPrefetchMemory)
+		if err != nil {
+			handleExitError(fmt.Errorf("invalid --stats-prefetch-memory: %v", err))
+		}
+		statsPrefetchMemoryCtx = strconv.FormatUint(size, 10)
+	}
+
+	var githubRateLimit = os.Getenv("GITHUB_RATE_LIMIT")
+	if offline {
+		// cached responses don't count against any rate limit
+		githubRateLimit = "1000"
+	}
+
+	var githubClient func() *nethttp.Client // the default client, unless responses are cached
+	if githubCache != "" || offline {
+		githubClient = githubCacheClient
+	}
+
 	sqlite.Register(
 		extensions.RegisterFn(
 			options.WithExtraFunctions(),
@@ -37,14 +72,71 @@ func registerExt() {
 			))),
 			options.WithContextValue("defaultRepoPath", repo),
 			options.WithContextValue("skipMailmap", skipMailmapCtx),
+			options.WithContextValue("noReplaceObjects", noReplaceObjectsCtx),
+			options.WithContextValue("gitBackend", gitBackend),
+			options.WithContextValue("defaultRef", defaultRef),
+			options.WithContextValue("onError", onError),
+			options.WithContextValue("statsPrefetchMemory", statsPrefetchMemoryCtx),
+			options.WithContextValue("botAuthors", os.Getenv("BOT_AUTHORS")),
+			options.WithContextValue("orgMapping", orgMapping),
 			options.WithGitHub(),
+			options.WithGitHubHTTPClientGetter(githubClient),
 			options.WithContextValue("githubToken", githubToken),
 			options.WithContextValue("githubPerPage", os.Getenv("GITHUB_PER_PAGE")),
-			options.WithContextValue("githubRateLimit", os.Getenv("GITHUB_RATE_LIMIT")),
+			options.WithContextValue("githubRateLimit", githubRateLimit),
 			options.WithSourcegraph(),
 			options.WithContextValue("sourcegraphToken", sourcegraphToken),
 			options.WithNPM(),
+			options.WithSlack(),
+			options.WithContextValue("slackToken", slackToken),
+			options.WithPagerDuty(),
+			options.WithContextValue("pagerdutyToken", pagerdutyToken),
+			options.WithObjectStorage(),
+			options.WithOCI(),
+			options.WithKubernetes(),
+			options.WithAllowHTTP(allowHTTP),
+			options.WithContextValue("httpGetMaxBytes", os.Getenv("HTTP_GET_MAX_BYTES")),
+			options.WithContextValue("httpRetries", os.Getenv("HTTP_RETRIES")),
+			options.WithContextValue("httpRetryBackoff", os.Getenv("HTTP_RETRY_BACKOFF")),
+			options.WithContextValue("httpRetryStatuses", os.Getenv("HTTP_RETRY_STATUSES")),
+			options.WithQueryContext(func() context.Context { return queryCtx }),
 			options.WithLogger(&logger),
+			func(o *options.Options) { extensionContext = o.Context },
 		),
 	)
 }
+
+// defaultGitHubCacheDir returns the directory GitHub API responses are cached in, when none is given
+func defaultGitHubCacheDir() string {
+	dir, err := httpcache.DefaultDir("github")
+	if err != nil {
+		return ".mergestat-github-cache"
+	}
+	return dir
+}
+
+// githubCacheClient returns a client of the GitHub APIs caching responses in (or, when offline, answering from) the --github-cache directory
+func githubCacheClient() *nethttp.Client {
+	dir := githubCache
+	if dir == "" {
+		dir = defaultGitHubCacheDir()
+	}
+
+	// retry requests the same way the default client does
+	retryOpts := &options.Options{Logger: &logger}
+	for _, fn := range []options.OptionFn{
+		options.WithContextValue("httpRetries", os.Getenv("HTTP_RETRIES")),
+		options.WithContextValue("httpRetryBackoff", os.Getenv("HTTP_RETRY_BACKOFF")),
+		options.WithContextValue("httpRetryStatuses", os.Getenv("HTTP_RETRY_STATUSES")),
+	} {
+		fn(retryOpts)
+	}
+
+	var transport nethttp.RoundTripper = &oauth2.Transport{
+		Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: githubToken}),
+		Base:   retryOpts.RetryTransport(nil),
+	}
+	transport = &httpcache.Transport{Dir: dir, Offline: offline, Transport: transport}
+
+	return &nethttp.Client{Transport: transport}
+}
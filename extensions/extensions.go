@@ -4,14 +4,23 @@
 package extensions
 
 import (
+	"github.com/mergestat/mergestat-lite/extensions/internal/classify"
 	"github.com/mergestat/mergestat-lite/extensions/internal/enry"
 	"github.com/mergestat/mergestat-lite/extensions/internal/git"
 	"github.com/mergestat/mergestat-lite/extensions/internal/github"
 	"github.com/mergestat/mergestat-lite/extensions/internal/golang"
 	"github.com/mergestat/mergestat-lite/extensions/internal/helpers"
+	"github.com/mergestat/mergestat-lite/extensions/internal/httpget"
+	"github.com/mergestat/mergestat-lite/extensions/internal/kubernetes"
 	"github.com/mergestat/mergestat-lite/extensions/internal/npm"
+	"github.com/mergestat/mergestat-lite/extensions/internal/objectstore"
+	"github.com/mergestat/mergestat-lite/extensions/internal/oci"
+	"github.com/mergestat/mergestat-lite/extensions/internal/pagerduty"
+	"github.com/mergestat/mergestat-lite/extensions/internal/schema"
+	"github.com/mergestat/mergestat-lite/extensions/internal/slack"
 	"github.com/mergestat/mergestat-lite/extensions/internal/sourcegraph"
 	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/extensions/services"
 	"go.riyazali.net/sqlite"
 )
 
@@ -21,8 +30,18 @@ func RegisterFn(fns ...options.OptionFn) func(ext *sqlite.ExtensionApi) (_ sqlit
 		fn(opt)
 	}
 
+	if opt.Modules == nil {
+		opt.Modules = &services.ModuleRegistry{}
+	}
+
 	// return an extension function that register modules with sqlite when this package is loaded
 	return func(ext *sqlite.ExtensionApi) (_ sqlite.ErrorCode, err error) {
+		// the function runs for every connection SQLite opens, and the last query is that of the connection,
+		// so each one collects the statistics of its queries on its own
+		var conn = *opt
+		conn.Stats = &services.QueryStats{}
+		opt := &conn
+
 		if !opt.ExcludeGit {
 			// register the git tables
 			if sqliteErr, err := git.Register(ext, opt); err != nil {
@@ -64,6 +83,55 @@ func RegisterFn(fns ...options.OptionFn) func(ext *sqlite.ExtensionApi) (_ sqlit
 			}
 		}
 
+		if opt.Slack {
+			if sqliteErr, err := slack.Register(ext, opt); err != nil {
+				return sqliteErr, err
+			}
+		}
+
+		if opt.PagerDuty {
+			if sqliteErr, err := pagerduty.Register(ext, opt); err != nil {
+				return sqliteErr, err
+			}
+		}
+
+		if opt.ObjectStorage {
+			if sqliteErr, err := objectstore.Register(ext, opt); err != nil {
+				return sqliteErr, err
+			}
+		}
+
+		if opt.OCI {
+			if sqliteErr, err := oci.Register(ext, opt); err != nil {
+				return sqliteErr, err
+			}
+		}
+
+		if opt.Kubernetes {
+			if sqliteErr, err := kubernetes.Register(ext, opt); err != nil {
+				return sqliteErr, err
+			}
+		}
+
+		// only register the functions making arbitrary requests when explicitly allowed
+		if opt.AllowHTTP {
+			if sqliteErr, err := httpget.Register(ext, opt); err != nil {
+				return sqliteErr, err
+			}
+		}
+
+		// register the classifiers of the embedder last, so that they can override the functions above
+		if len(opt.Classifiers) > 0 {
+			if sqliteErr, err := classify.Register(ext, opt); err != nil {
+				return sqliteErr, err
+			}
+		}
+
+		// register the tables describing all of the modules registered above
+		if sqliteErr, err := schema.Register(ext, opt); err != nil {
+			return sqliteErr, err
+		}
+
 		return sqlite.SQLITE_OK, nil
 	}
 }
@@ -0,0 +1,46 @@
+// Package classify registers the classifiers supplied by embedders (such as to label bot commits, or vendor syncs)
+// as SQL functions, so that they run inline, as the rows of a query are scanned.
+package classify
+
+import (
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// Register registers each of the classifiers of opt as a SQL function of the same name
+func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
+	for name, classifier := range opt.Classifiers {
+		if err = ext.CreateFunction(name, &Classify{Classifier: classifier}); err != nil {
+			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register classifier %q", name)
+		}
+	}
+	return sqlite.SQLITE_OK, nil
+}
+
+// Classify implements a sql function calling a classifier with its arguments (as text, NULL being an empty string),
+// such as classify_commit(hash, message). It returns the label of the classifier, or NULL if it's empty.
+type Classify struct {
+	Classifier options.Classifier
+}
+
+func (*Classify) Args() int           { return -1 }
+func (*Classify) Deterministic() bool { return false }
+func (fn *Classify) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	var args = make([]string, len(values))
+	for i, value := range values {
+		args[i] = value.Text()
+	}
+
+	label, err := fn.Classifier(args...)
+	if err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	if label == "" {
+		c.ResultNull()
+	} else {
+		c.ResultText(label)
+	}
+}
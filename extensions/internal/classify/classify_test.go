@@ -0,0 +1,73 @@
+package classify
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
+	"go.riyazali.net/sqlite"
+)
+
+// FixtureDatabase represents the database connection to run the test against
+var FixtureDatabase *sql.DB
+
+func init() {
+	var opt = &options.Options{}
+	options.WithClassifier("classify_commit", func(args ...string) (string, error) {
+		if len(args) != 2 {
+			return "", errors.New("expected a hash and a message")
+		}
+		switch {
+		case strings.HasPrefix(args[1], "chore(deps)"):
+			return "dependencies", nil
+		case strings.HasPrefix(args[1], "vendor:"):
+			return "vendor-sync", nil
+		}
+		return "", nil
+	})(opt)
+
+	// register sqlite extension when this package is loaded
+	sqlite.Register(func(ext *sqlite.ExtensionApi) (_ sqlite.ErrorCode, err error) {
+		return Register(ext, opt)
+	})
+}
+
+func TestMain(m *testing.M) {
+	var err error
+	if FixtureDatabase, err = sql.Open("sqlite3", "file:testing.db?mode=memory"); err != nil {
+		log.Fatalf("failed to open database connection: %v", err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestClassify(t *testing.T) {
+	var tests = []struct {
+		message  string
+		expected sql.NullString
+	}{
+		{"chore(deps): bump golang.org/x/mod", sql.NullString{String: "dependencies", Valid: true}},
+		{"vendor: sync with upstream", sql.NullString{String: "vendor-sync", Valid: true}},
+		{"fix a bug", sql.NullString{}},
+	}
+
+	for _, test := range tests {
+		var label sql.NullString
+		if err := FixtureDatabase.QueryRow("SELECT classify_commit('abc123', ?)", test.message).Scan(&label); err != nil {
+			t.Fatalf("failed to execute query: %v", err.Error())
+		}
+		if label != test.expected {
+			t.Fatalf("expected %v for %q, got: %v", test.expected, test.message, label)
+		}
+	}
+
+	var label sql.NullString
+	if err := FixtureDatabase.QueryRow("SELECT classify_commit('abc123')").Scan(&label); err == nil || !strings.Contains(err.Error(), "expected a hash and a message") {
+		t.Fatalf("expected the error of the classifier, got: %v", err)
+	}
+}
@@ -7,7 +7,10 @@ import (
 )
 
 // Register registers enry related functionality as a SQLite extension
-func Register(ext *sqlite.ExtensionApi, _ *options.Options) (_ sqlite.ErrorCode, err error) {
+func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
+	// overrides from .gitattributes are shared between functions (and the languages table), so they're only read once per commit
+	var overrides = newLinguistOverrides(opt)
+
 	var fns = map[string]sqlite.Function{
 		"enry_detect_language":  &EnryDetectLanguage{},
 		"enry_is_binary":        &EnryIsBinary{},
@@ -20,10 +23,32 @@ func Register(ext *sqlite.ExtensionApi, _ *options.Options) (_ sqlite.ErrorCode,
 		"enry_is_vendor":        &EnryIsVendor{},
 	}
 
-	for name, fn := range fns {
-		if err = ext.CreateFunction(name, fn); err != nil {
-			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q function", name)
+	// functions taking a repository (and rev) read its .gitattributes, which change as its refs move, so they're registered
+	// once more under the same name, as not deterministic and taking any number of arguments. SQLite calls the deterministic
+	// variants above (without overrides) when they take exactly the number of arguments passed, that is without a repository.
+	var repoFns = map[string]sqlite.Function{
+		"enry_detect_language": &EnryDetectLanguage{overrides: overrides},
+		"enry_is_generated":    &EnryIsGenerated{overrides: overrides},
+		"enry_is_vendor":       &EnryIsVendor{overrides: overrides},
+	}
+
+	for _, group := range []map[string]sqlite.Function{fns, repoFns} {
+		for name, fn := range group {
+			if err = ext.CreateFunction(name, fn); err != nil {
+				return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q function", name)
+			}
+		}
+	}
+
+	var modules = map[string]sqlite.Module{
+		"languages": NewLanguagesModule(overrides),
+	}
+
+	for name, mod := range modules {
+		if err = ext.CreateModule(name, opt.Stats.Module(name, mod)); err != nil {
+			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q module", name)
 		}
+		opt.Modules.Add("enry", name, mod)
 	}
 
 	return sqlite.SQLITE_OK, nil
@@ -1,16 +1,52 @@
 package enry
 
 import (
+	"fmt"
+
 	"github.com/go-enry/go-enry/v2"
 	"go.riyazali.net/sqlite"
 )
 
-type EnryDetectLanguage struct{}
+// EnryDetectLanguage implements enry_detect_language(path, contents [, repository [, rev]]). If a repository is supplied,
+// the linguist-language attribute set in its .gitattributes files (at rev) takes precedence. Contents may be NULL,
+// in which case the language is detected from the path alone, unless its extension is ambiguous (such as .h or .m)
+// and a repository is supplied, in which case the start of the file is read from the repository and sampled instead.
+type EnryDetectLanguage struct {
+	overrides *linguistOverrides
+}
+
+func (f *EnryDetectLanguage) Args() int {
+	if f.overrides == nil {
+		return 2
+	}
+	return -1
+}
 
-func (f *EnryDetectLanguage) Args() int           { return 2 }
-func (f *EnryDetectLanguage) Deterministic() bool { return true }
+func (f *EnryDetectLanguage) Deterministic() bool { return f.overrides == nil }
 func (f *EnryDetectLanguage) Apply(context *sqlite.Context, value ...sqlite.Value) {
-	if lang := enry.GetLanguage(value[0].Text(), value[1].Blob()); lang == "" {
+	if len(value) < 2 || len(value) > 4 {
+		context.ResultError(fmt.Errorf("enry_detect_language expects a path and contents, and optionally a repository and rev"))
+		return
+	}
+
+	if lang, err := f.overrides.language(value[0].Text(), value[2:]); err != nil {
+		context.ResultError(err)
+		return
+	} else if lang != "" {
+		context.ResultText(lang)
+		return
+	}
+
+	var contents = value[1].Blob()
+	if value[1].IsNil() && len(value) > 2 && isAmbiguous(value[0].Text()) {
+		var err error
+		if contents, err = f.overrides.sample(value[0].Text(), value[2:]); err != nil {
+			context.ResultError(err)
+			return
+		}
+	}
+
+	if lang := enry.GetLanguage(value[0].Text(), contents); lang == "" {
 		context.ResultNull()
 		return
 	} else {
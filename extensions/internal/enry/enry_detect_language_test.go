@@ -27,3 +27,18 @@ func TestEnryDetectLanguage(t *testing.T) {
 		t.Fatalf("expected string: %s, got %s", "Go", contents[0][0])
 	}
 }
+
+func TestEnryDetectLanguageSamplesAmbiguousExtensions(t *testing.T) {
+	dir := initRepo(t, map[string]string{
+		"include/widget.h": "#import <Foundation/Foundation.h>\n\n@interface Widget : NSObject\n@property (nonatomic) NSString *name;\n@end\n",
+	})
+
+	var lang string
+	if err := FixtureDatabase.QueryRow("SELECT enry_detect_language(?, NULL, ?)", "include/widget.h", dir).Scan(&lang); err != nil {
+		t.Fatal(err)
+	}
+
+	if lang != "Objective-C" {
+		t.Fatalf("expected string: %s, got %s", "Objective-C", lang)
+	}
+}
@@ -1,15 +1,41 @@
 package enry
 
 import (
+	"fmt"
+
 	"github.com/go-enry/go-enry/v2"
+	"github.com/mergestat/mergestat-lite/extensions/internal/sqlutil"
 	"go.riyazali.net/sqlite"
 )
 
-type EnryIsGenerated struct{}
+// EnryIsGenerated implements enry_is_generated(path, contents [, repository [, rev]]). If a repository is supplied,
+// the linguist-generated attribute set in its .gitattributes files (at rev) takes precedence.
+type EnryIsGenerated struct {
+	overrides *linguistOverrides
+}
+
+func (f *EnryIsGenerated) Args() int {
+	if f.overrides == nil {
+		return 2
+	}
+	return -1
+}
 
-func (f *EnryIsGenerated) Args() int           { return 2 }
-func (f *EnryIsGenerated) Deterministic() bool { return true }
+func (f *EnryIsGenerated) Deterministic() bool { return f.overrides == nil }
 func (f *EnryIsGenerated) Apply(context *sqlite.Context, value ...sqlite.Value) {
+	if len(value) < 2 || len(value) > 4 {
+		context.ResultError(fmt.Errorf("enry_is_generated expects a path and contents, and optionally a repository and rev"))
+		return
+	}
+
+	if generated, ok, err := f.overrides.flag(value[0].Text(), "linguist-generated", value[2:]); err != nil {
+		context.ResultError(err)
+		return
+	} else if ok {
+		context.ResultInt(sqlutil.BoolToInt(generated))
+		return
+	}
+
 	if enry.IsGenerated(value[0].Text(), value[1].Blob()) {
 		context.ResultInt(1)
 	} else {
@@ -1,15 +1,41 @@
 package enry
 
 import (
+	"fmt"
+
 	"github.com/go-enry/go-enry/v2"
+	"github.com/mergestat/mergestat-lite/extensions/internal/sqlutil"
 	"go.riyazali.net/sqlite"
 )
 
-type EnryIsVendor struct{}
+// EnryIsVendor implements enry_is_vendor(path [, repository [, rev]]). If a repository is supplied,
+// the linguist-vendored attribute set in its .gitattributes files (at rev) takes precedence.
+type EnryIsVendor struct {
+	overrides *linguistOverrides
+}
+
+func (f *EnryIsVendor) Args() int {
+	if f.overrides == nil {
+		return 1
+	}
+	return -1
+}
 
-func (f *EnryIsVendor) Args() int           { return 1 }
-func (f *EnryIsVendor) Deterministic() bool { return true }
+func (f *EnryIsVendor) Deterministic() bool { return f.overrides == nil }
 func (f *EnryIsVendor) Apply(context *sqlite.Context, value ...sqlite.Value) {
+	if len(value) < 1 || len(value) > 3 {
+		context.ResultError(fmt.Errorf("enry_is_vendor expects a path, and optionally a repository and rev"))
+		return
+	}
+
+	if vendored, ok, err := f.overrides.flag(value[0].Text(), "linguist-vendored", value[1:]); err != nil {
+		context.ResultError(err)
+		return
+	} else if ok {
+		context.ResultInt(sqlutil.BoolToInt(vendored))
+		return
+	}
+
 	if enry.IsVendor(value[0].Text()) {
 		context.ResultInt(1)
 	} else {
@@ -6,6 +6,8 @@ import (
 	"os"
 	"testing"
 
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/pkg/locator"
 	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
 	"go.riyazali.net/sqlite"
 )
@@ -16,7 +18,7 @@ var FixtureDatabase *sql.DB
 func init() {
 	// register sqlite extension when this package is loaded
 	sqlite.Register(func(ext *sqlite.ExtensionApi) (_ sqlite.ErrorCode, err error) {
-		return Register(ext, nil)
+		return Register(ext, &options.Options{Locator: locator.CachedLocator(locator.MultiLocator(nil))})
 	})
 }
 
@@ -0,0 +1,288 @@
+package enry
+
+import (
+	"context"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-enry/go-enry/v2"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// linguistOverrides looks up the linguist-vendored, linguist-generated and linguist-language attributes
+// set in the .gitattributes files of a repository, so that the enry functions can match what GitHub displays.
+// See https://github.com/github-linguist/linguist/blob/master/docs/overrides.md
+// It also provides access to samples of the contents of files, for functions which need them.
+type linguistOverrides struct {
+	opt *options.Options
+
+	mu       sync.Mutex
+	matchers map[string]gitattributes.Matcher // keyed by repository path and commit hash
+	order    []string                         // keys of the cached matchers, oldest first
+}
+
+// matcherCacheSize is the number of matchers held by linguistOverrides, past which the oldest ones are evicted
+const matcherCacheSize = 64
+
+func newLinguistOverrides(opt *options.Options) *linguistOverrides {
+	return &linguistOverrides{opt: opt, matchers: make(map[string]gitattributes.Matcher)}
+}
+
+// lookup returns the value of the named attribute for the file at filePath, in the repository
+// and at the revision supplied as the trailing (optional) arguments of an enry function.
+// If no repository is supplied, or the attribute isn't specified for the file, nil is returned.
+func (l *linguistOverrides) lookup(filePath, name string, args []sqlite.Value) (gitattributes.Attribute, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	var repoPath, rev = args[0].Text(), ""
+	if len(args) > 1 {
+		rev = args[1].Text()
+	}
+
+	m, err := l.matcher(repoPath, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	return matchAttribute(m, filePath, name), nil
+}
+
+// matchAttribute returns the value of the named attribute for the file at filePath, or nil if it isn't specified
+func matchAttribute(m gitattributes.Matcher, filePath, name string) gitattributes.Attribute {
+	attrs, _ := m.Match(strings.Split(path.Clean(filePath), "/"), []string{name})
+	if attr, ok := attrs[name]; ok && !attr.IsUnspecified() {
+		return attr
+	}
+	return nil
+}
+
+// flag looks up a boolean attribute, returning the value of the attribute and whether it was specified at all.
+// Linguist accepts both the set / unset form (linguist-vendored, -linguist-vendored) and an explicit true / false value.
+func (l *linguistOverrides) flag(filePath, name string, args []sqlite.Value) (value, ok bool, err error) {
+	attr, err := l.lookup(filePath, name, args)
+	if err != nil || attr == nil {
+		return false, false, err
+	}
+	return flagValue(attr), true, nil
+}
+
+// flagValue returns the value of a boolean attribute
+func flagValue(attr gitattributes.Attribute) bool {
+	switch {
+	case attr.IsSet():
+		return true
+	case attr.IsUnset():
+		return false
+	default:
+		return attr.Value() != "false"
+	}
+}
+
+// language looks up the linguist-language attribute, returning the canonical name of the language it's set to
+func (l *linguistOverrides) language(filePath string, args []sqlite.Value) (string, error) {
+	attr, err := l.lookup(filePath, "linguist-language", args)
+	if err != nil || attr == nil {
+		return "", err
+	}
+	return languageValue(attr), nil
+}
+
+// languageValue returns the canonical name of the language a linguist-language attribute is set to, if any
+func languageValue(attr gitattributes.Attribute) string {
+	if !attr.IsValueSet() {
+		return ""
+	}
+
+	// the value may be an alias (e.g. linguist-language=js), which enry resolves for us.
+	// Linguist also allows hyphens in place of spaces (as in linguist-language=Protocol-Buffer)
+	for _, alias := range []string{attr.Value(), strings.ReplaceAll(attr.Value(), "-", " ")} {
+		if lang, ok := enry.GetLanguageByAlias(alias); ok {
+			return lang
+		}
+	}
+	return attr.Value()
+}
+
+// matcher returns a matcher over the attributes defined in all of the .gitattributes files found in the tree of the
+// commit rev resolves to (HEAD, or the configured default ref, if empty). The most recently read matchers are cached by commit.
+func (l *linguistOverrides) matcher(repoPath, rev string) (_ gitattributes.Matcher, err error) {
+	repo, commit, repoPath, err := l.resolve(repoPath, rev)
+	if err != nil {
+		return nil, err
+	}
+	return l.commitMatcher(repo, commit, repoPath)
+}
+
+// commitMatcher returns a matcher over the attributes defined in all of the .gitattributes files found in the tree of commit
+func (l *linguistOverrides) commitMatcher(repo *git.Repository, commit *object.Commit, repoPath string) (gitattributes.Matcher, error) {
+	var key = repoPath + "@" + commit.Hash.String()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if m, ok := l.matchers[key]; ok {
+		return m, nil
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not lookup tree")
+	}
+
+	patterns, err := readAttributes(repo, tree)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read .gitattributes")
+	}
+
+	for len(l.order) >= matcherCacheSize {
+		delete(l.matchers, l.order[0])
+		l.order = l.order[1:]
+	}
+
+	m := gitattributes.NewMatcher(patterns)
+	l.matchers[key] = m
+	l.order = append(l.order, key)
+	return m, nil
+}
+
+// sampleSize is the number of bytes of a file sampled to detect its language, as Linguist's classifier only considers those
+const sampleSize = 50 * 1024
+
+// sample returns the first sampleSize bytes of the file at filePath, in the repository and at the revision
+// supplied as the trailing arguments of an enry function
+func (l *linguistOverrides) sample(filePath string, args []sqlite.Value) ([]byte, error) {
+	var repoPath, rev = args[0].Text(), ""
+	if len(args) > 1 {
+		rev = args[1].Text()
+	}
+
+	_, commit, _, err := l.resolve(repoPath, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := commit.File(path.Clean(filePath))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not lookup %q", filePath)
+	}
+
+	return sampleFile(file)
+}
+
+// sampleFile returns the first sampleSize bytes of f, without reading the rest of it
+func sampleFile(f *object.File) ([]byte, error) {
+	r, err := f.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(io.LimitReader(r, sampleSize))
+}
+
+// isAmbiguous reports whether the extension of filePath is shared by more than one language (such as .h or .m),
+// in which case the language can only be told from the file's contents
+func isAmbiguous(filePath string) bool {
+	return len(enry.GetLanguagesByExtension(filePath, nil, nil)) > 1
+}
+
+// resolve opens the repository at repoPath (or the default repository, if empty) and looks up the commit
+// rev resolves to (HEAD, or the configured default ref, if empty). It also returns the resolved repository path.
+func (l *linguistOverrides) resolve(repoPath, rev string) (_ *git.Repository, _ *object.Commit, _ string, err error) {
+	if l.opt == nil || l.opt.Locator == nil {
+		return nil, nil, "", errors.New("no repository locator configured")
+	}
+
+	if repoPath == "" {
+		if repoPath, err = utils.GetDefaultRepoFromCtx(l.opt.Context); err != nil {
+			return nil, nil, "", err
+		}
+	}
+
+	if rev == "" {
+		if rev = utils.GetDefaultRefFromCtx(l.opt.Context); rev == "" {
+			rev = "HEAD"
+		}
+	}
+
+	repo, err := l.opt.Locator.Open(context.Background(), repoPath)
+	if err != nil {
+		return nil, nil, "", errors.Wrapf(err, "failed to open %q", repoPath)
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, nil, "", errors.Wrapf(err, "failed to resolve %q", rev)
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, nil, "", errors.Wrapf(err, "could not lookup commit")
+	}
+
+	return repo, commit, repoPath, nil
+}
+
+// readAttributes reads the patterns of all .gitattributes files in tree, in order of increasing priority
+// (that is, files closer to the root of the tree come first)
+func readAttributes(repo *git.Repository, tree *object.Tree) ([]gitattributes.MatchAttribute, error) {
+	type file struct {
+		domain []string
+		entry  object.TreeEntry
+	}
+
+	var files []file
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if path.Base(name) == ".gitattributes" && entry.Mode.IsFile() {
+			var domain []string
+			if dir := path.Dir(name); dir != "." {
+				domain = strings.Split(dir, "/")
+			}
+			files = append(files, file{domain: domain, entry: entry})
+		}
+	}
+
+	sort.SliceStable(files, func(i, j int) bool { return len(files[i].domain) < len(files[j].domain) })
+
+	var patterns []gitattributes.MatchAttribute
+	for _, f := range files {
+		blob, err := repo.BlobObject(f.entry.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		r, err := blob.Reader()
+		if err != nil {
+			return nil, err
+		}
+
+		attrs, err := gitattributes.ReadAttributes(r, f.domain, len(f.domain) == 0)
+		_ = r.Close()
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, attrs...)
+	}
+
+	return patterns, nil
+}
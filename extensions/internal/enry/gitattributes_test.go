@@ -0,0 +1,105 @@
+package enry
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// initRepo creates a git repository in a temporary directory, committing the given files to it
+func initRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	for name, contents := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "initial commit"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("failed to run git %v: %v: %s", args, err, out)
+		}
+	}
+
+	return dir
+}
+
+func TestLinguistOverrides(t *testing.T) {
+	dir := initRepo(t, map[string]string{
+		".gitattributes":              "libs/** linguist-vendored\n*.pb.go linguist-generated=false\n*.rb linguist-language=Java\n",
+		"node_modules/.gitattributes": "* -linguist-vendored\n",
+	})
+
+	var tests = []struct {
+		query    string
+		args     []interface{}
+		expected string
+	}{
+		{"SELECT enry_is_vendor(?)", []interface{}{"libs/lib/lib.c"}, "0"},
+		{"SELECT enry_is_vendor(?, ?)", []interface{}{"libs/lib/lib.c", dir}, "1"},
+		{"SELECT enry_is_vendor(?, ?, 'HEAD')", []interface{}{"node_modules/data", dir}, "0"},
+		{"SELECT enry_is_vendor(?, ?)", []interface{}{"src/main.c", dir}, "0"},
+		{"SELECT enry_is_generated(?, ?, ?)", []interface{}{"api.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.", dir}, "0"},
+		{"SELECT enry_is_generated(?, ?)", []interface{}{"api.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT."}, "1"},
+		{"SELECT enry_detect_language(?, ?, ?)", []interface{}{"script.rb", "puts 'hello'", dir}, "Java"},
+		{"SELECT enry_detect_language(?, ?)", []interface{}{"script.rb", "puts 'hello'"}, "Ruby"},
+	}
+
+	for _, test := range tests {
+		var got string
+		if err := FixtureDatabase.QueryRow(test.query, test.args...).Scan(&got); err != nil {
+			t.Fatalf("failed to execute %q: %v", test.query, err)
+		}
+
+		if got != test.expected {
+			t.Fatalf("expected %q from %q with %v, got %q", test.expected, test.query, test.args, got)
+		}
+	}
+}
+
+func TestLinguistOverridesCacheIsBounded(t *testing.T) {
+	dir := initRepo(t, map[string]string{".gitattributes": "*.rb linguist-language=Java\n"})
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// matchers are cached by repository path (and commit), so each path adds one
+	var overrides = newLinguistOverrides(nil)
+	for i := 0; i < 2*matcherCacheSize; i++ {
+		if _, err := overrides.commitMatcher(repo, commit, fmt.Sprintf("%s-%d", dir, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(overrides.matchers) != matcherCacheSize || len(overrides.order) != matcherCacheSize {
+		t.Fatalf("expected %d cached matchers, got: %d", matcherCacheSize, len(overrides.matchers))
+	}
+
+	if _, ok := overrides.matchers[fmt.Sprintf("%s-%d@%s", dir, 2*matcherCacheSize-1, commit.Hash)]; !ok {
+		t.Fatalf("expected the most recent matcher to be cached")
+	}
+}
@@ -0,0 +1,175 @@
+package enry
+
+import (
+	"io"
+	"sort"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-enry/go-enry/v2"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var languagesCols = []vtab.Column{
+	{Name: "language", Type: "TEXT"},
+	{Name: "files", Type: "INT"},
+	{Name: "bytes", Type: "INT"},
+	{Name: "percentage", Type: "REAL"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "rev", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// languageStats are the number and total size of the files of a language
+type languageStats struct {
+	language string
+	files    int
+	bytes    int64
+}
+
+type languagesIter struct {
+	languages []*languageStats
+	total     int64
+	index     int
+}
+
+func (i *languagesIter) Column(ctx vtab.Context, c int) error {
+	current := i.languages[i.index]
+	switch languagesCols[c].Name {
+	case "language":
+		ctx.ResultText(current.language)
+	case "files":
+		ctx.ResultInt(current.files)
+	case "bytes":
+		ctx.ResultInt64(current.bytes)
+	case "percentage":
+		ctx.ResultFloat(float64(current.bytes) * 100 / float64(i.total))
+	}
+	return nil
+}
+
+func (i *languagesIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.languages) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewLanguagesModule returns the implementation of a table-valued-function with the breakdown of the languages of the
+// files in the tree of rev (HEAD, or the configured default ref, if not supplied), by size, largest first. As on GitHub,
+// vendored, generated and documentation files are left out, as are languages which aren't programming or markup languages,
+// and the linguist-vendored, linguist-generated and linguist-language attributes set in .gitattributes take precedence.
+func NewLanguagesModule(overrides *linguistOverrides) sqlite.Module {
+	return vtab.NewTableFunc("languages", languagesCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, rev string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch languagesCols[constraint.ColIndex].Name {
+				case "repository":
+					repoPath = constraint.Value.Text()
+				case "rev":
+					rev = constraint.Value.Text()
+				}
+			}
+		}
+
+		return newLanguagesIter(overrides, repoPath, rev)
+	})
+}
+
+func newLanguagesIter(overrides *linguistOverrides, repoPath, rev string) (*languagesIter, error) {
+	repo, commit, repoPath, err := overrides.resolve(repoPath, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := overrides.commitMatcher(repo, commit, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not lookup tree")
+	}
+
+	var iter = &languagesIter{index: -1}
+	var stats = make(map[string]*languageStats)
+	err = tree.Files().ForEach(func(f *object.File) error {
+		lang, err := fileLanguage(m, f)
+		if err != nil || lang == "" {
+			return err
+		}
+
+		s, ok := stats[lang]
+		if !ok {
+			s = &languageStats{language: lang}
+			stats[lang] = s
+			iter.languages = append(iter.languages, s)
+		}
+		s.files += 1
+		s.bytes += f.Size
+		iter.total += f.Size
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not list files")
+	}
+
+	sort.Slice(iter.languages, func(i, j int) bool {
+		if iter.languages[i].bytes != iter.languages[j].bytes {
+			return iter.languages[i].bytes > iter.languages[j].bytes
+		}
+		return iter.languages[i].language < iter.languages[j].language
+	})
+
+	return iter, nil
+}
+
+// fileLanguage returns the language f counts towards in the breakdown of languages, or "" if it doesn't count towards any.
+// Files with an ambiguous extension (such as .h or .m) are told apart by sampling their contents.
+// See https://github.com/github-linguist/linguist/blob/master/docs/how-linguist-works.md
+func fileLanguage(m gitattributes.Matcher, f *object.File) (string, error) {
+	if f.Mode == filemode.Symlink || enry.IsDocumentation(f.Name) {
+		return "", nil
+	}
+
+	var vendored = enry.IsVendor(f.Name)
+	if attr := matchAttribute(m, f.Name, "linguist-vendored"); attr != nil {
+		vendored = flagValue(attr)
+	}
+
+	var generated = enry.IsGenerated(f.Name, nil)
+	if attr := matchAttribute(m, f.Name, "linguist-generated"); attr != nil {
+		generated = flagValue(attr)
+	}
+
+	if vendored || generated {
+		return "", nil
+	}
+
+	var lang string
+	if attr := matchAttribute(m, f.Name, "linguist-language"); attr != nil {
+		lang = languageValue(attr)
+	}
+	if lang == "" {
+		var contents []byte
+		if isAmbiguous(f.Name) {
+			var err error
+			if contents, err = sampleFile(f); err != nil {
+				return "", errors.Wrapf(err, "could not sample %q", f.Name)
+			}
+		}
+		lang = enry.GetLanguage(f.Name, contents)
+	}
+
+	switch enry.GetLanguageType(lang) {
+	case enry.Programming, enry.Markup:
+		return lang, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,91 @@
+package enry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestLanguages(t *testing.T) {
+	var goSource = "package main\n\nfunc main() {}\n"
+	dir := initRepo(t, map[string]string{
+		".gitattributes":  "libs/** linguist-vendored\nvendor/** -linguist-vendored\ngen/** linguist-generated\n*.rb linguist-language=Java\n",
+		"main.go":         goSource,
+		"vendor/keep.go":  goSource,
+		"libs/lib.c":      "int lib(void) { return 0; }\n",
+		"gen/api.go":      goSource,
+		"script.rb":       "puts 'hello'\n",
+		"README.md":       "# Hello\n",
+		"data/cfg.json":   "{}\n",
+		"docs/example.py": "print('hello')\n",
+	})
+
+	rows, err := FixtureDatabase.Query("SELECT language, files, bytes, percentage FROM languages(?)", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	type language struct {
+		name       string
+		files      int
+		bytes      int
+		percentage float64
+	}
+
+	var got []language
+	for rows.Next() {
+		var l language
+		if err := rows.Scan(&l.name, &l.files, &l.bytes, &l.percentage); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, l)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// vendor/ is vendored by default, but not as set in .gitattributes, while libs/ is the other way around.
+	// Generated files, documentation and languages which aren't programming or markup languages (such as JSON) are left out
+	var goBytes, javaBytes = 2 * len(goSource), len("puts 'hello'\n")
+	var total = float64(goBytes + javaBytes)
+	var expected = []language{
+		{"Go", 2, goBytes, float64(goBytes) * 100 / total},
+		{"Java", 1, javaBytes, float64(javaBytes) * 100 / total},
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got: %v", expected, got)
+	}
+
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %v, got: %v", expected[i], got[i])
+		}
+	}
+}
+
+func TestLanguagesSamplesAmbiguousExtensions(t *testing.T) {
+	// only the start of files is sampled, which is enough to tell the Objective-C header apart from the C one
+	var header = "#import <Foundation/Foundation.h>\n\n@interface Widget : NSObject\n@property (nonatomic) NSString *name;\n@end\n"
+	dir := initRepo(t, map[string]string{
+		"include/widget.h": header + strings.Repeat("// padding\n", sampleSize/10),
+		"include/lib.h":    "#ifndef LIB_H\n#define LIB_H\n\nint lib(void);\n\n#endif\n",
+	})
+
+	rows, err := FixtureDatabase.Query("SELECT language FROM languages(?) ORDER BY language", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(contents) != 2 || contents[0][0] != "C" || contents[1][0] != "Objective-C" {
+		t.Fatalf("expected C and Objective-C, got: %v", contents)
+	}
+}
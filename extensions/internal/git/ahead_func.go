@@ -0,0 +1,123 @@
+package git
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// CommitsAheadFn implements the COMMITS_AHEAD(repository, rev, base) sql function, which counts the commits reachable
+// from rev but not from base (as in git rev-list --count base..rev). Swapping rev and base counts the commits rev is behind base.
+type CommitsAheadFn struct {
+	Options *utils.ModuleOptions
+
+	// the ancestors of the last base commits were counted against, as the function
+	// is typically called with the same base for every row of a query (such as every branch)
+	mu        sync.Mutex
+	base      string // repository path and hash of the base commit
+	ancestors map[plumbing.Hash]struct{}
+}
+
+// NewCommitsAheadFn returns a new CommitsAheadFn implementation
+func NewCommitsAheadFn(opt *utils.ModuleOptions) *CommitsAheadFn {
+	return &CommitsAheadFn{Options: opt}
+}
+
+func (*CommitsAheadFn) Deterministic() bool { return false }
+func (*CommitsAheadFn) Args() int           { return 3 }
+func (fn *CommitsAheadFn) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	path := values[0].Text()
+
+	var err error
+	if path == "" {
+		if path, err = fn.Options.GetRepoPath(); err != nil {
+			c.ResultError(err)
+			return
+		}
+	}
+
+	var repo *git.Repository
+	if repo, err = fn.Options.Locator.Open(context.Background(), path); err != nil {
+		c.ResultError(errors.Wrapf(err, "failed to open %q", path))
+		return
+	}
+
+	var rev, base *plumbing.Hash
+	if rev, err = repo.ResolveRevision(plumbing.Revision(values[1].Text())); err != nil {
+		c.ResultError(errors.Wrapf(err, "failed to resolve %q", values[1].Text()))
+		return
+	}
+
+	if base, err = repo.ResolveRevision(plumbing.Revision(values[2].Text())); err != nil {
+		c.ResultError(errors.Wrapf(err, "failed to resolve %q", values[2].Text()))
+		return
+	}
+
+	fn.mu.Lock()
+	defer fn.mu.Unlock()
+
+	if key := path + "@" + base.String(); fn.base != key {
+		if fn.ancestors, err = ancestors(repo, *base); err != nil {
+			c.ResultError(err)
+			return
+		}
+		fn.base = key
+	}
+
+	var count int
+	if count, err = countUnreachable(repo, *rev, fn.ancestors); err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	c.ResultInt(count)
+}
+
+// ancestors returns the set of all commits reachable from (and including) from
+func ancestors(repo *git.Repository, from plumbing.Hash) (map[plumbing.Hash]struct{}, error) {
+	iter, err := repo.Log(&git.LogOptions{From: from})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create iterator")
+	}
+	defer iter.Close()
+
+	var set = make(map[plumbing.Hash]struct{})
+	err = iter.ForEach(func(c *object.Commit) error { set[c.Hash] = struct{}{}; return nil })
+	return set, err
+}
+
+// countUnreachable counts the commits reachable from from, that aren't in exclude.
+// The walk stops at excluded commits, as all of their parents are excluded too.
+func countUnreachable(repo *git.Repository, from plumbing.Hash, exclude map[plumbing.Hash]struct{}) (int, error) {
+	var count int
+	var seen = make(map[plumbing.Hash]struct{})
+	var queue = []plumbing.Hash{from}
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+
+		if _, ok := seen[hash]; ok {
+			continue
+		}
+		seen[hash] = struct{}{}
+
+		if _, ok := exclude[hash]; ok {
+			continue
+		}
+
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return 0, errors.Wrapf(err, "could not lookup commit %s", hash)
+		}
+
+		count++
+		queue = append(queue, commit.ParentHashes...)
+	}
+	return count, nil
+}
@@ -0,0 +1,28 @@
+package git_test
+
+import (
+	"testing"
+)
+
+func TestCommitsAheadFn(t *testing.T) {
+	db := Connect(t, Memory)
+	repo, hash := "https://github.com/mergestat/mergestat-lite", "2359c9a9ba0ba8aa694601ff12538c4e74b82cd5"
+
+	var ahead, behind int
+	if err := db.QueryRow("SELECT commits_ahead(?, ?, ?), commits_ahead(?, ?, ?)", repo, hash, hash+"~3", repo, hash+"~3", hash).Scan(&ahead, &behind); err != nil {
+		t.Fatalf("failed to execute query: %v", err)
+	}
+
+	var expected int
+	if err := db.QueryRow("SELECT count(*) FROM commits(?, ?) WHERE hash NOT IN (SELECT hash FROM commits(?, ?))", repo, hash, repo, hash+"~3").Scan(&expected); err != nil {
+		t.Fatalf("failed to execute query: %v", err)
+	}
+
+	if ahead != expected {
+		t.Fatalf("expected %s to be %d commits ahead of its 3rd ancestor, got %d", hash, expected, ahead)
+	}
+
+	if behind != 0 {
+		t.Fatalf("expected an ancestor not to be ahead of its descendant, got %d", behind)
+	}
+}
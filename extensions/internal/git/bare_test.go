@@ -0,0 +1,52 @@
+package git_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// cloneFixtures clones the test repository into a worktree and then into a bare repository,
+// returning the paths to the worktree's .git directory and the bare repository
+func cloneFixtures(t *testing.T) (dotGit, bare string) {
+	t.Helper()
+
+	worktree := t.TempDir()
+	if _, err := git.PlainClone(worktree, false, &git.CloneOptions{URL: "https://github.com/mergestat/mergestat-lite"}); err != nil {
+		t.Fatalf("failed to clone repository: %v", err)
+	}
+
+	bare = t.TempDir()
+	if _, err := git.PlainClone(bare, true, &git.CloneOptions{URL: worktree}); err != nil {
+		t.Fatalf("failed to create bare clone: %v", err)
+	}
+
+	return filepath.Join(worktree, ".git"), bare
+}
+
+func TestBareAndGitDirRepositories(t *testing.T) {
+	db := Connect(t, Memory)
+	dotGit, bare := cloneFixtures(t)
+
+	var queries = map[string]string{
+		"commits": "SELECT count(*) FROM commits(?)",
+		"refs":    "SELECT count(*) FROM refs(?)",
+		"stats":   "SELECT count(*) FROM stats(?, '2359c9a9ba0ba8aa694601ff12538c4e74b82cd5')",
+		"files":   "SELECT count(*) FROM files(?)",
+		"blame":   "SELECT count(*) FROM blame(?, '', 'README.md')",
+	}
+
+	for _, repo := range []string{dotGit, bare} {
+		for table, query := range queries {
+			var count int
+			if err := db.QueryRow(query, repo).Scan(&count); err != nil {
+				t.Fatalf("failed to query %s in %q: %v", table, repo, err)
+			}
+
+			if count == 0 {
+				t.Fatalf("expected %s to return rows for %q", table, repo)
+			}
+		}
+	}
+}
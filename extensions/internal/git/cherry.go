@@ -0,0 +1,289 @@
+package git
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var cherryCols = []vtab.Column{
+	{Name: "hash", Type: "TEXT"},
+	{Name: "message", Type: "TEXT"},
+	{Name: "author_name", Type: "TEXT"},
+	{Name: "author_email", Type: "TEXT"},
+	{Name: "author_when", Type: "DATETIME"},
+	{Name: "equivalent", Type: "BOOLEAN"},
+	{Name: "upstream_hash", Type: "TEXT"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "upstream", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "head", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+type cherryIter struct {
+	commits []*object.Commit
+	index   int
+
+	// the upstream commits that aren't in head, by patch id
+	upstream map[string]plumbing.Hash
+	// the upstream commit equivalent to the current one, if any
+	equivalent plumbing.Hash
+}
+
+func (i *cherryIter) Column(ctx vtab.Context, c int) error {
+	current := i.commits[i.index]
+	switch cherryCols[c].Name {
+	case "hash":
+		ctx.ResultText(current.Hash.String())
+	case "message":
+		ctx.ResultText(current.Message)
+	case "author_name":
+		ctx.ResultText(current.Author.Name)
+	case "author_email":
+		ctx.ResultText(current.Author.Email)
+	case "author_when":
+		ctx.ResultText(current.Author.When.Format(time.RFC3339))
+	case "equivalent":
+		if !i.equivalent.IsZero() {
+			ctx.ResultInt(1)
+		} else {
+			ctx.ResultInt(0)
+		}
+	case "upstream_hash":
+		if !i.equivalent.IsZero() {
+			ctx.ResultText(i.equivalent.String())
+		}
+	}
+	return nil
+}
+
+func (i *cherryIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.commits) {
+		return nil, io.EOF
+	}
+
+	id, err := patchID(i.commits[i.index])
+	if err != nil {
+		return nil, err
+	}
+	i.equivalent = i.upstream[id]
+
+	return i, nil
+}
+
+// NewCherryModule returns the implementation of a table-valued-function mirroring git cherry, listing the commits
+// of head (HEAD if not supplied) that aren't in upstream, oldest first. Commits are flagged as equivalent when
+// upstream has a commit with the same patch id (i.e. introducing the same changes, such as a cherry-pick of it),
+// with upstream_hash holding the equivalent commit. As in git cherry, merge commits are left out.
+func NewCherryModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("cherry", cherryCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, upstream, head string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 7:
+					repoPath = constraint.Value.Text()
+				case 8:
+					upstream = constraint.Value.Text()
+				case 9:
+					head = constraint.Value.Text()
+				}
+			}
+		}
+
+		if upstream == "" {
+			return nil, errors.New("upstream must be supplied")
+		}
+
+		if head == "" {
+			head = "HEAD"
+		}
+
+		var err error
+		if repoPath == "" {
+			if repoPath, err = options.GetRepoPath(); err != nil {
+				return nil, err
+			}
+		}
+
+		var repo *git.Repository
+		if repo, err = options.Locator.Open(context.Background(), repoPath); err != nil {
+			return nil, errors.Wrapf(err, "failed to open %q", repoPath)
+		}
+
+		return newCherryIter(repo, upstream, head)
+	})
+}
+
+func newCherryIter(repo *git.Repository, upstream, head string) (*cherryIter, error) {
+	var upstreamHash, headHash *plumbing.Hash
+	var err error
+	if upstreamHash, err = repo.ResolveRevision(plumbing.Revision(upstream)); err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve %q", upstream)
+	}
+	if headHash, err = repo.ResolveRevision(plumbing.Revision(head)); err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve %q", head)
+	}
+
+	var upstreamAncestors, headAncestors map[plumbing.Hash]struct{}
+	if upstreamAncestors, err = ancestors(repo, *upstreamHash); err != nil {
+		return nil, err
+	}
+	if headAncestors, err = ancestors(repo, *headHash); err != nil {
+		return nil, err
+	}
+
+	var iter = &cherryIter{index: -1, upstream: make(map[string]plumbing.Hash)}
+	if iter.commits, err = unreachableCommits(repo, *headHash, upstreamAncestors); err != nil {
+		return nil, err
+	}
+
+	// only the upstream commits that aren't in head can be equivalent to the commits of head
+	var candidates []*object.Commit
+	if candidates, err = unreachableCommits(repo, *upstreamHash, headAncestors); err != nil {
+		return nil, err
+	}
+	for _, commit := range candidates {
+		var id string
+		if id, err = patchID(commit); err != nil {
+			return nil, err
+		}
+		iter.upstream[id] = commit.Hash
+	}
+
+	return iter, nil
+}
+
+// unreachableCommits returns the non-merge commits reachable from from, that aren't in exclude, ordered by committer date
+// (oldest first). The walk stops at excluded commits, as all of their parents are excluded too.
+func unreachableCommits(repo *git.Repository, from plumbing.Hash, exclude map[plumbing.Hash]struct{}) ([]*object.Commit, error) {
+	var commits []*object.Commit
+	var seen = make(map[plumbing.Hash]struct{})
+	var queue = []plumbing.Hash{from}
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+
+		if _, ok := seen[hash]; ok {
+			continue
+		}
+		seen[hash] = struct{}{}
+
+		if _, ok := exclude[hash]; ok {
+			continue
+		}
+
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not lookup commit %s", hash)
+		}
+
+		if commit.NumParents() <= 1 {
+			commits = append(commits, commit)
+		}
+		queue = append(queue, commit.ParentHashes...)
+	}
+
+	sort.SliceStable(commits, func(i, j int) bool { return commits[i].Committer.When.Before(commits[j].Committer.When) })
+	return commits, nil
+}
+
+// patchID returns an identifier of the changes a (non-merge) commit introduces, similar to git patch-id: the hash of the
+// added and removed lines of each file (ignoring whitespace), so that it's the same for commits applying the same changes
+// on top of different parents. Binary files are identified by their contents before and after the change.
+func patchID(commit *object.Commit) (string, error) {
+	var from *object.Tree
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return "", errors.Wrapf(err, "could not lookup parent of %s", commit.Hash)
+		}
+		if from, err = parent.Tree(); err != nil {
+			return "", errors.Wrapf(err, "could not lookup tree of %s", parent.Hash)
+		}
+	}
+
+	to, err := commit.Tree()
+	if err != nil {
+		return "", errors.Wrapf(err, "could not lookup tree of %s", commit.Hash)
+	}
+
+	changes, err := object.DiffTree(from, to)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not diff %s", commit.Hash)
+	}
+
+	patch, err := changes.Patch()
+	if err != nil {
+		return "", errors.Wrapf(err, "could not diff %s", commit.Hash)
+	}
+
+	var h = sha1.New()
+	for _, file := range patch.FilePatches() {
+		fromFile, toFile := file.Files()
+		_, _ = io.WriteString(h, "diff "+filePath(fromFile)+" "+filePath(toFile)+"\n")
+
+		if file.IsBinary() {
+			_, _ = io.WriteString(h, fileHash(fromFile)+" "+fileHash(toFile)+"\n")
+			continue
+		}
+
+		for _, chunk := range file.Chunks() {
+			var prefix string
+			switch chunk.Type() {
+			case fdiff.Add:
+				prefix = "+"
+			case fdiff.Delete:
+				prefix = "-"
+			default:
+				continue // unchanged lines depend on the parent, rather than on the changes
+			}
+
+			for _, line := range strings.SplitAfter(chunk.Content(), "\n") {
+				if line = stripWhitespace(line); line != "" {
+					_, _ = io.WriteString(h, prefix+line+"\n")
+				}
+			}
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func filePath(f fdiff.File) string {
+	if f == nil {
+		return "/dev/null"
+	}
+	return f.Path()
+}
+
+func fileHash(f fdiff.File) string {
+	if f == nil {
+		return plumbing.ZeroHash.String()
+	}
+	return f.Hash().String()
+}
+
+func stripWhitespace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
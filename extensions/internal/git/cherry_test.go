@@ -0,0 +1,76 @@
+package git_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestCherry(t *testing.T) {
+	db := Connect(t, Memory)
+
+	repo := tools.NewRepo(t)
+	dir := repo.Dir
+
+	checkout := func(branch string, create bool) {
+		t.Helper()
+		if err := repo.Worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(branch), Create: create}); err != nil {
+			t.Fatalf("failed to checkout %s: %v", branch, err)
+		}
+	}
+
+	repo.Commit("initial commit", map[string]string{"README.md": "hello\n"})
+
+	checkout("upstream", true)
+	fix := repo.Commit("fix a bug", map[string]string{"fix.txt": "a fix\n"})
+
+	checkout("master", false)
+	checkout("topic", true)
+	backport := repo.Commit("backport the fix", map[string]string{"fix.txt": "a  fix\n"})
+	feature := repo.Commit("add a feature", map[string]string{"feature.txt": "a feature\n"})
+
+	rows, err := db.Query("SELECT hash, equivalent, upstream_hash FROM cherry(?, 'upstream', 'topic')", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	type result struct {
+		hash         string
+		equivalent   bool
+		upstreamHash sql.NullString
+	}
+	var results []result
+	for rows.Next() {
+		var r result
+		if err = rows.Scan(&r.hash, &r.equivalent, &r.upstreamHash); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+		results = append(results, r)
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err.Error())
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 commits in topic but not upstream, got: %+v", results)
+	}
+	if r := results[0]; r.hash != backport.String() || !r.equivalent || r.upstreamHash.String != fix.String() {
+		t.Fatalf("expected the backport to be equivalent to the fix, got: %+v", r)
+	}
+	if r := results[1]; r.hash != feature.String() || r.equivalent || r.upstreamHash.Valid {
+		t.Fatalf("expected the feature not to be in upstream, got: %+v", r)
+	}
+
+	// HEAD is the default head
+	var count int
+	if err = db.QueryRow("SELECT count(*) FROM cherry(?, 'upstream')", dir).Scan(&count); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 commits in HEAD but not upstream, got %d", count)
+	}
+}
@@ -0,0 +1,167 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/pkg/errors"
+)
+
+// cliLogFormat is the --format passed to git log. Fields are separated by the ASCII unit separator
+// and commits by a NUL byte (using -z), neither of which should appear in a commit message.
+const cliLogFormat = "%H%x1f%P%x1f%T%x1f%an%x1f%ae%x1f%aI%x1f%cn%x1f%ce%x1f%cI%x1f%B"
+
+// newCLICommitIter returns an iterator over the history reachable from opts.From, produced by
+// shelling out to the system's git executable. With a commit-graph, native git is still the fastest
+// option for walking the history of very large monorepos, and sidesteps go-git's edge cases.
+// Replace refs and grafts are honored by git itself, with replace refs ignored if noReplace is set.
+func newCLICommitIter(repo *git.Repository, opts *git.LogOptions, noReplace bool) (object.CommitIter, error) {
+	bin, err := exec.LookPath("git")
+	if err != nil {
+		return nil, errors.Wrap(err, "git executable not found")
+	}
+
+	fsStorer, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return nil, errors.New("cli backend only supported on filesystem backed git repos")
+	}
+
+	var args = []string{
+		"--git-dir", fsStorer.Filesystem().Root(), "--no-pager", "-c", "log.showSignature=false",
+	}
+	if noReplace {
+		args = append(args, "--no-replace-objects")
+	}
+	args = append(args, "log", "-z", "--format="+cliLogFormat)
+	if opts.Since != nil {
+		args = append(args, "--since="+opts.Since.Format(time.RFC3339))
+	}
+	if opts.Until != nil {
+		args = append(args, "--until="+opts.Until.Format(time.RFC3339))
+	}
+	args = append(args, opts.From.String(), "--")
+
+	cmd := exec.Command(bin, args...)
+	cmd.Stderr = new(bytes.Buffer)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "failed to start git log")
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, 0); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+
+	return &cliCommitIter{cmd: cmd, scanner: scanner}, nil
+}
+
+type cliCommitIter struct {
+	cmd     *exec.Cmd
+	scanner *bufio.Scanner
+}
+
+func (iter *cliCommitIter) Next() (*object.Commit, error) {
+	if !iter.scanner.Scan() {
+		if err := iter.scanner.Err(); err != nil {
+			return nil, err
+		}
+
+		if err := iter.wait(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+
+	return parseCLICommit(iter.scanner.Text())
+}
+
+func (iter *cliCommitIter) ForEach(fn func(*object.Commit) error) error {
+	defer iter.Close()
+	for {
+		c, err := iter.Next()
+		if eof(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err = fn(c); err == storer.ErrStop {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (iter *cliCommitIter) Close() {
+	if iter.cmd != nil && iter.cmd.ProcessState == nil {
+		_ = iter.cmd.Process.Kill()
+		_ = iter.cmd.Wait()
+	}
+}
+
+// wait reaps the git process, returning its stderr output as an error if it failed
+func (iter *cliCommitIter) wait() error {
+	if iter.cmd.ProcessState != nil {
+		return nil
+	}
+
+	if err := iter.cmd.Wait(); err != nil {
+		return errors.Wrapf(err, "git log failed: %s", strings.TrimSpace(iter.cmd.Stderr.(*bytes.Buffer).String()))
+	}
+	return nil
+}
+
+// parseCLICommit parses a single record produced by git log using cliLogFormat
+func parseCLICommit(record string) (*object.Commit, error) {
+	fields := strings.SplitN(strings.TrimPrefix(record, "\n"), "\x1f", 10)
+	if len(fields) != 10 {
+		return nil, errors.Errorf("unexpected git log output: %q", record)
+	}
+
+	var parents []plumbing.Hash
+	for _, parent := range strings.Fields(fields[1]) {
+		parents = append(parents, plumbing.NewHash(parent))
+	}
+
+	authorWhen, err := time.Parse(time.RFC3339, fields[5])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse author date")
+	}
+
+	committerWhen, err := time.Parse(time.RFC3339, fields[8])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse committer date")
+	}
+
+	return &object.Commit{
+		Hash:         plumbing.NewHash(fields[0]),
+		Author:       object.Signature{Name: fields[3], Email: fields[4], When: authorWhen},
+		Committer:    object.Signature{Name: fields[6], Email: fields[7], When: committerWhen},
+		Message:      fields[9],
+		TreeHash:     plumbing.NewHash(fields[2]),
+		ParentHashes: parents,
+	}, nil
+}
@@ -45,6 +45,7 @@ func (fn *CloneFn) Apply(c *sqlite.Context, values ...sqlite.Value) {
 	fsStorer, ok := repo.Storer.(*filesystem.Storage)
 	if !ok {
 		c.ResultError(fmt.Errorf("clone scalar function can only open filesystem backed git repos"))
+		return
 	}
 
 	c.ResultText(fsStorer.Filesystem().Root())
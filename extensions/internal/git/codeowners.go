@@ -0,0 +1,126 @@
+package git
+
+import (
+	"io"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/pkg/owners"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var codeownersCols = []vtab.Column{
+	{Name: "path", Type: "TEXT"},
+	{Name: "owner", Type: "TEXT"},
+	{Name: "line", Type: "INT"},
+	{Name: "pattern", Type: "TEXT"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// codeowner is an owner of the file at path, set by rule (or without either, if the file has no owners)
+type codeowner struct {
+	path  string
+	owner string
+	rule  *owners.CodeownersRule
+}
+
+type codeownersIter struct {
+	owners []*codeowner
+	index  int
+}
+
+func (i *codeownersIter) Column(ctx vtab.Context, c int) error {
+	current := i.owners[i.index]
+	switch codeownersCols[c].Name {
+	case "path":
+		ctx.ResultText(current.path)
+	case "owner":
+		if current.owner != "" {
+			ctx.ResultText(current.owner)
+		}
+	case "line":
+		if current.rule != nil {
+			ctx.ResultInt(current.rule.Line)
+		}
+	case "pattern":
+		if current.rule != nil {
+			ctx.ResultText(current.rule.Pattern)
+		}
+	}
+	return nil
+}
+
+func (i *codeownersIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.owners) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewCodeownersModule returns the implementation of a table-valued-function listing the owners of each of the files
+// at ref (the default ref, or HEAD, if not supplied) according to the CODEOWNERS file of the repository (looked up
+// in .github/, the root, docs/ and .gitlab/, in that order), along with the line and pattern of the rule setting them.
+// Files without owners (not matching any rule, or matching a rule without owners) are listed with a NULL owner.
+func NewCodeownersModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("codeowners", codeownersCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 4:
+					repoPath = constraint.Value.Text()
+				case 5:
+					ref = constraint.Value.Text()
+				}
+			}
+		}
+
+		tree, err := options.LookupTree(repoPath, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		var rules []*owners.CodeownersRule
+		for _, name := range owners.CodeownersFiles {
+			f, err := tree.File(name)
+			if err == object.ErrFileNotFound {
+				continue
+			} else if err != nil {
+				return nil, errors.Wrapf(err, "could not lookup %s", name)
+			}
+
+			contents, err := f.Contents()
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not retrieve contents of %s", name)
+			}
+			if rules, err = owners.ParseCodeowners(contents); err != nil {
+				return nil, errors.Wrapf(err, "could not parse %s", name)
+			}
+			break
+		}
+
+		var listed []*codeowner
+		err = tree.Files().ForEach(func(f *object.File) error {
+			rule := owners.MatchCodeowners(rules, f.Name)
+			if rule == nil || len(rule.Owners) == 0 {
+				listed = append(listed, &codeowner{path: f.Name, rule: rule})
+				return nil
+			}
+
+			for _, owner := range rule.Owners {
+				listed = append(listed, &codeowner{path: f.Name, owner: owner, rule: rule})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &codeownersIter{owners: listed, index: -1}, nil
+	})
+}
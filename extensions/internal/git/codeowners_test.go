@@ -0,0 +1,48 @@
+package git_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestCodeowners(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := commitFiles(t, map[string]string{
+		".github/CODEOWNERS":          "* @acme/core\n/services/ @alice @bob\n/services/legacy/\n",
+		"README.md":                   "# codeowners\n",
+		"services/api/main.go":        "package main\n",
+		"services/legacy/old.go":      "package legacy\n",
+		"services/billing/invoice.go": "package billing\n",
+	})
+
+	rows, err := db.Query("SELECT path, owner, line, pattern FROM codeowners(?) WHERE path GLOB 'services/*' OR path = 'README.md' ORDER BY path, owner", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{"README.md", "@acme/core", "1", "*"},
+		{"services/api/main.go", "@alice", "2", "/services/"},
+		{"services/api/main.go", "@bob", "2", "/services/"},
+		{"services/billing/invoice.go", "@alice", "2", "/services/"},
+		{"services/billing/invoice.go", "@bob", "2", "/services/"},
+		{"services/legacy/old.go", "NULL", "3", "/services/legacy/"},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d rows, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+}
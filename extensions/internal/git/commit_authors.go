@@ -0,0 +1,184 @@
+package git
+
+import (
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/pkg/mailmap"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var commitAuthorsCols = []vtab.Column{
+	{Name: "hash", Type: "TEXT"},
+	{Name: "role", Type: "TEXT"},
+	{Name: "name", Type: "TEXT"},
+	{Name: "email", Type: "TEXT"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "skip_mailmap", Type: "BOOLEAN", Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// roles of the people credited in a commit
+const (
+	roleAuthor    = "author"
+	roleCommitter = "committer"
+	roleCoAuthor  = "co-author"
+)
+
+// coAuthorTrailer is the (case-insensitive) key of the trailers crediting co-authors of a commit
+const coAuthorTrailer = "Co-authored-by"
+
+// commitAuthor is a person credited in a commit, in a given role
+type commitAuthor struct {
+	role     string
+	identity mailmap.NameAndEmail
+}
+
+type commitAuthorsIter struct {
+	commits object.CommitIter
+	mm      mailmap.MailMap
+
+	commit  *object.Commit  // the current commit
+	authors []*commitAuthor // the people credited in the current commit
+	index   int
+}
+
+func (i *commitAuthorsIter) Column(ctx vtab.Context, c int) error {
+	current := i.authors[i.index]
+	switch commitAuthorsCols[c].Name {
+	case "hash":
+		ctx.ResultText(i.commit.Hash.String())
+	case "role":
+		ctx.ResultText(current.role)
+	case "name":
+		ctx.ResultText(current.identity.Name)
+	case "email":
+		if current.identity.Email != "" {
+			ctx.ResultText(current.identity.Email)
+		}
+	}
+	return nil
+}
+
+func (i *commitAuthorsIter) Next() (vtab.Row, error) {
+	i.index += 1
+	for i.commit == nil || i.index >= len(i.authors) {
+		var err error
+		if i.commit, err = i.commits.Next(); err != nil {
+			return nil, err // io.EOF once all commits have been visited
+		}
+		i.authors, i.index = commitAuthors(i.commit, i.mm), 0
+	}
+	return i, nil
+}
+
+// NewCommitAuthorsModule returns the implementation of a table-valued-function listing the people credited in each commit
+// reachable from ref (the default ref, or HEAD, if not supplied): its author, its committer and the co-authors credited
+// in Co-authored-by trailers, each in a row of its own along with their role. Identities are resolved using the .mailmap
+// file at ref, as in the commits table, unless skip_mailmap is true (it defaults to the skipMailmap context value).
+func NewCommitAuthorsModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("commit_authors", commitAuthorsCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		var skipMailmap = utils.GetSkipMailmapFromCtx(options.Context)
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 4:
+					repoPath = constraint.Value.Text()
+				case 5:
+					ref = constraint.Value.Text()
+				case 6:
+					if !constraint.Value.IsNil() {
+						skipMailmap = constraint.Value.Int() != 0
+					}
+				}
+			}
+		}
+
+		if ref == "" {
+			if ref = utils.GetDefaultRefFromCtx(options.Context); ref == "" {
+				ref = "HEAD"
+			}
+		}
+
+		_, repo, err := openFnRepo(options, repoPath)
+		if err != nil {
+			return nil, err
+		}
+
+		commit, err := resolveCommitObject(repo, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		var iter = &commitAuthorsIter{index: -1}
+		if !skipMailmap {
+			if iter.mm, err = readMailmap(options.Mailmap, repo, commit.Hash); err != nil {
+				return nil, err
+			}
+		}
+
+		if iter.commits, err = repo.Log(&git.LogOptions{From: commit.Hash}); err != nil {
+			return nil, errors.Wrapf(err, "failed to walk the history of %q", ref)
+		}
+
+		return iter, nil
+	})
+}
+
+// commitAuthors returns the people credited in commit, as mapped by mm: its author and committer,
+// followed by its co-authors (in the order of the trailers, leaving out repeated ones)
+func commitAuthors(commit *object.Commit, mm mailmap.MailMap) []*commitAuthor {
+	var authors = []*commitAuthor{
+		{role: roleAuthor, identity: mm.Lookup(mailmap.NameAndEmail{Name: commit.Author.Name, Email: commit.Author.Email})},
+		{role: roleCommitter, identity: mm.Lookup(mailmap.NameAndEmail{Name: commit.Committer.Name, Email: commit.Committer.Email})},
+	}
+
+	var seen = make(map[mailmap.NameAndEmail]struct{})
+	for _, coAuthor := range coAuthors(commit.Message) {
+		identity := mm.Lookup(coAuthor)
+		if _, ok := seen[identity]; !ok {
+			seen[identity] = struct{}{}
+			authors = append(authors, &commitAuthor{role: roleCoAuthor, identity: identity})
+		}
+	}
+
+	return authors
+}
+
+// coAuthors parses the Co-authored-by trailers of a commit message. As in git, trailers are only looked up
+// in the last paragraph of the message, and values are expected to be of the form: Name <email>
+func coAuthors(message string) []mailmap.NameAndEmail {
+	var paragraphs = strings.Split(strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n")), "\n\n")
+	if len(paragraphs) < 2 {
+		return nil // a message made of a single paragraph is only a subject
+	}
+
+	var identities []mailmap.NameAndEmail
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), coAuthorTrailer) {
+			continue
+		}
+
+		var identity mailmap.NameAndEmail
+		value = strings.TrimSpace(value)
+		if start, end := strings.Index(value, "<"), strings.LastIndex(value, ">"); start >= 0 && end > start {
+			identity.Name = strings.TrimSpace(value[:start])
+			identity.Email = strings.TrimSpace(value[start+1 : end])
+		} else {
+			identity.Name = value
+		}
+
+		if identity.Name != "" || identity.Email != "" {
+			identities = append(identities, identity)
+		}
+	}
+
+	return identities
+}
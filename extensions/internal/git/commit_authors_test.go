@@ -0,0 +1,84 @@
+package git_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestCommitAuthors(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to initialize repository: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".mailmap"), []byte("Bob <bob@example.com> <bob@old.example.com>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Add(".mailmap"); err != nil {
+		t.Fatal(err)
+	}
+
+	var message = "Pair on the parser\n\n" +
+		"Co-authored-by: Mentions in the body are not trailers <nope@example.com>\n\n" +
+		"Signed-off-by: Alice <alice@example.com>\n" +
+		"Co-authored-by: Bob <bob@old.example.com>\n" +
+		"co-authored-by: Carol <carol@example.com>\n" +
+		"Co-authored-by: Bob <bob@example.com>\n"
+	if _, err = worktree.Commit(message, &git.CommitOptions{
+		Author:    &object.Signature{Name: "Alice", Email: "alice@example.com", When: time.Now()},
+		Committer: &object.Signature{Name: "Dave", Email: "dave@example.com", When: time.Now()},
+	}); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	rows, err := db.Query("SELECT role, name, email FROM commit_authors(?)", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	// co-authors are resolved through the .mailmap, and only credited once
+	expected := [][]string{
+		{"author", "Alice", "alice@example.com"},
+		{"committer", "Dave", "dave@example.com"},
+		{"co-author", "Bob", "bob@example.com"},
+		{"co-author", "Carol", "carol@example.com"},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d rows, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+
+	// skipping the .mailmap leaves identities as recorded in the trailers
+	var raw int
+	if err := db.QueryRow("SELECT count(*) FROM commit_authors(?, 'HEAD', 1) WHERE role = 'co-author'", dir).Scan(&raw); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if raw != 3 {
+		t.Fatalf("expected 3 co-authors without the .mailmap, got %d", raw)
+	}
+}
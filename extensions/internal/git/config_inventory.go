@@ -0,0 +1,386 @@
+package git
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/augmentable-dev/vtab"
+	"github.com/ghodss/yaml"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var configInventoryCols = []vtab.Column{
+	{Name: "path", Type: "TEXT"},
+	{Name: "tool", Type: "TEXT"},
+	{Name: "format", Type: "TEXT"},
+	{Name: "summary", Type: "JSON"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// configFile is a kind of configuration file detected by the inventory
+type configFile struct {
+	tool, format string
+	dirs         []string // the only directories the tool reads the file from, if it's not read from any
+}
+
+// configFiles are the configuration files detected by the inventory, by name
+var configFiles = map[string]configFile{
+	".editorconfig": {tool: "editorconfig", format: "ini"},
+
+	".golangci.yml":  {tool: "golangci-lint", format: "yaml"},
+	".golangci.yaml": {tool: "golangci-lint", format: "yaml"},
+	".golangci.toml": {tool: "golangci-lint", format: "toml"},
+	".golangci.json": {tool: "golangci-lint", format: "json"},
+
+	".eslintrc":         {tool: "eslint", format: "yaml"}, // the legacy (and deprecated) format, either JSON or YAML
+	".eslintrc.json":    {tool: "eslint", format: "json"},
+	".eslintrc.yml":     {tool: "eslint", format: "yaml"},
+	".eslintrc.yaml":    {tool: "eslint", format: "yaml"},
+	".eslintrc.js":      {tool: "eslint", format: "javascript"},
+	".eslintrc.cjs":     {tool: "eslint", format: "javascript"},
+	"eslint.config.js":  {tool: "eslint", format: "javascript"},
+	"eslint.config.mjs": {tool: "eslint", format: "javascript"},
+	"eslint.config.cjs": {tool: "eslint", format: "javascript"},
+
+	"renovate.json":     {tool: "renovate", format: "json", dirs: []string{".", ".github", ".gitlab"}},
+	"renovate.json5":    {tool: "renovate", format: "json5", dirs: []string{".", ".github", ".gitlab"}},
+	".renovaterc":       {tool: "renovate", format: "json", dirs: []string{"."}},
+	".renovaterc.json":  {tool: "renovate", format: "json", dirs: []string{"."}},
+	".renovaterc.json5": {tool: "renovate", format: "json5", dirs: []string{"."}},
+
+	"dependabot.yml":  {tool: "dependabot", format: "yaml", dirs: []string{".github"}},
+	"dependabot.yaml": {tool: "dependabot", format: "yaml", dirs: []string{".github"}},
+}
+
+// lookupConfigFile returns the kind of configuration file at filePath, if the inventory detects it.
+// Files of dependencies (vendored, or installed in node_modules) aren't part of a repository's configuration.
+func lookupConfigFile(filePath string) (configFile, bool) {
+	config, ok := configFiles[path.Base(filePath)]
+	if !ok || strings.Contains("/"+filePath, "/node_modules/") || strings.Contains("/"+filePath, "/vendor/") {
+		return configFile{}, false
+	}
+
+	if config.dirs == nil {
+		return config, true
+	}
+	for _, dir := range config.dirs {
+		if path.Dir(filePath) == dir {
+			return config, true
+		}
+	}
+	return configFile{}, false
+}
+
+// configToJSON converts the contents of a configuration file in format to JSON. Configurations which are code
+// (or in formats which aren't supported) convert to nil.
+func configToJSON(format, contents string) ([]byte, error) {
+	switch format {
+	case "yaml":
+		return yaml.YAMLToJSON([]byte(contents))
+	case "json":
+		return []byte(contents), nil
+	case "toml":
+		var config interface{}
+		if _, err := toml.Decode(contents, &config); err != nil {
+			return nil, err
+		}
+		return json.Marshal(config)
+	}
+	return nil, nil
+}
+
+// stringOrList unmarshals a value that's either a string or a list of them
+type stringOrList []string
+
+func (s *stringOrList) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*s = []string{str}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(s))
+}
+
+// summarizeEditorConfig summarizes an .editorconfig file: whether it's the root one, the sections (globs) it
+// configures and the properties set for all files (in the [*] section)
+func summarizeEditorConfig(contents string) interface{} {
+	var summary = struct {
+		Root       bool              `json:"root"`
+		Sections   []string          `json:"sections"`
+		Properties map[string]string `json:"properties"`
+	}{Sections: []string{}, Properties: map[string]string{}}
+
+	var section string
+	var preamble = true
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			section, preamble = line[1:len(line)-1], false
+			summary.Sections = append(summary.Sections, section)
+		default:
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+			if preamble && key == "root" {
+				summary.Root = strings.EqualFold(value, "true")
+			} else if section == "*" {
+				summary.Properties[key] = value
+			}
+		}
+	}
+	return summary
+}
+
+// summarizeGolangCI summarizes a golangci-lint configuration: its version (2 for the configurations of golangci-lint v2),
+// the default set of linters enabled (as in linters.default of v2, derived from linters.enable-all / linters.disable-all for v1)
+// and the linters enabled or disabled on top of it
+func summarizeGolangCI(js []byte) (interface{}, error) {
+	var config struct {
+		Version json.RawMessage `json:"version"` // "2", or 2
+		Linters struct {
+			Default    string   `json:"default"`
+			EnableAll  bool     `json:"enable-all"`
+			DisableAll bool     `json:"disable-all"`
+			Enable     []string `json:"enable"`
+			Disable    []string `json:"disable"`
+		} `json:"linters"`
+	}
+	if err := json.Unmarshal(js, &config); err != nil {
+		return nil, err
+	}
+
+	var summary = struct {
+		Version string   `json:"version"`
+		Default string   `json:"default"`
+		Enable  []string `json:"enable"`
+		Disable []string `json:"disable"`
+	}{Version: "1", Default: "standard", Enable: []string{}, Disable: []string{}}
+
+	if len(config.Version) > 0 {
+		summary.Version = strings.Trim(string(config.Version), `"`)
+	}
+	switch {
+	case config.Linters.Default != "":
+		summary.Default = config.Linters.Default
+	case config.Linters.EnableAll:
+		summary.Default = "all"
+	case config.Linters.DisableAll:
+		summary.Default = "none"
+	}
+	if config.Linters.Enable != nil {
+		summary.Enable = config.Linters.Enable
+	}
+	if config.Linters.Disable != nil {
+		summary.Disable = config.Linters.Disable
+	}
+	return summary, nil
+}
+
+// summarizeESLint summarizes an ESLint configuration (in the legacy format): the shared configurations it extends
+// and the plugins it loads
+func summarizeESLint(js []byte) (interface{}, error) {
+	var config struct {
+		Root    bool         `json:"root"`
+		Extends stringOrList `json:"extends"`
+		Plugins []string     `json:"plugins"`
+	}
+	if err := json.Unmarshal(js, &config); err != nil {
+		return nil, err
+	}
+
+	var summary = struct {
+		Root    bool     `json:"root"`
+		Extends []string `json:"extends"`
+		Plugins []string `json:"plugins"`
+	}{Root: config.Root, Extends: []string{}, Plugins: []string{}}
+	if config.Extends != nil {
+		summary.Extends = config.Extends
+	}
+	if config.Plugins != nil {
+		summary.Plugins = config.Plugins
+	}
+	return summary, nil
+}
+
+// summarizeRenovate summarizes a Renovate configuration: the presets it extends and its schedule
+func summarizeRenovate(js []byte) (interface{}, error) {
+	var config struct {
+		Extends  []string     `json:"extends"`
+		Schedule stringOrList `json:"schedule"`
+	}
+	if err := json.Unmarshal(js, &config); err != nil {
+		return nil, err
+	}
+
+	var summary = struct {
+		Extends  []string `json:"extends"`
+		Schedule []string `json:"schedule"`
+	}{Extends: []string{}, Schedule: []string{}}
+	if config.Extends != nil {
+		summary.Extends = config.Extends
+	}
+	if config.Schedule != nil {
+		summary.Schedule = config.Schedule
+	}
+	return summary, nil
+}
+
+// summarizeDependabot summarizes a Dependabot configuration: the package ecosystems (and directories) it updates, and how often
+func summarizeDependabot(js []byte) (interface{}, error) {
+	var config struct {
+		Updates []struct {
+			PackageEcosystem string `json:"package-ecosystem"`
+			Directory        string `json:"directory"`
+			Schedule         struct {
+				Interval string `json:"interval"`
+			} `json:"schedule"`
+		} `json:"updates"`
+	}
+	if err := json.Unmarshal(js, &config); err != nil {
+		return nil, err
+	}
+
+	type update struct {
+		Ecosystem string `json:"ecosystem"`
+		Directory string `json:"directory"`
+		Interval  string `json:"interval"`
+	}
+	var summary = struct {
+		Updates []update `json:"updates"`
+	}{Updates: []update{}}
+	for _, u := range config.Updates {
+		summary.Updates = append(summary.Updates, update{Ecosystem: u.PackageEcosystem, Directory: u.Directory, Interval: u.Schedule.Interval})
+	}
+	return summary, nil
+}
+
+// summarizeConfig returns the summary (as JSON) of the configuration file of tool, in format. The summary is empty
+// for configurations that can't be read, because they're code or in an unsupported format, or are invalid.
+func summarizeConfig(tool, format, contents string) string {
+	var summary interface{}
+	if tool == "editorconfig" {
+		summary = summarizeEditorConfig(contents)
+	} else {
+		js, err := configToJSON(format, contents)
+		if err != nil || js == nil {
+			return ""
+		}
+
+		switch tool {
+		case "golangci-lint":
+			summary, err = summarizeGolangCI(js)
+		case "eslint":
+			summary, err = summarizeESLint(js)
+		case "renovate":
+			summary, err = summarizeRenovate(js)
+		case "dependabot":
+			summary, err = summarizeDependabot(js)
+		}
+		if err != nil {
+			return ""
+		}
+	}
+
+	js, err := json.Marshal(summary)
+	if err != nil {
+		return ""
+	}
+	return string(js)
+}
+
+// inventoriedConfig is a configuration file detected by the inventory
+type inventoriedConfig struct {
+	path, tool, format, summary string
+}
+
+type configInventoryIter struct {
+	configs []*inventoriedConfig
+	index   int
+}
+
+func (i *configInventoryIter) Column(ctx vtab.Context, c int) error {
+	current := i.configs[i.index]
+	switch configInventoryCols[c].Name {
+	case "path":
+		ctx.ResultText(current.path)
+	case "tool":
+		ctx.ResultText(current.tool)
+	case "format":
+		ctx.ResultText(current.format)
+	case "summary":
+		if current.summary != "" {
+			ctx.ResultText(current.summary)
+		}
+	}
+	return nil
+}
+
+func (i *configInventoryIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.configs) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewConfigInventoryModule returns the implementation of a table-valued-function listing the configuration files of common
+// development tools (EditorConfig, golangci-lint, ESLint, Renovate and Dependabot) found at ref (the default ref, or HEAD,
+// if not supplied), each with a summary (as JSON) of its main settings, to measure the adoption of standards across repositories.
+// The summary is NULL for configurations that are code (such as eslint.config.js), in JSON5, or that can't be parsed.
+func NewConfigInventoryModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("config_inventory", configInventoryCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 4:
+					repoPath = constraint.Value.Text()
+				case 5:
+					ref = constraint.Value.Text()
+				}
+			}
+		}
+
+		tree, err := options.LookupTree(repoPath, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		var configs []*inventoriedConfig
+		err = tree.Files().ForEach(func(f *object.File) error {
+			config, ok := lookupConfigFile(f.Name)
+			if !ok {
+				return nil
+			}
+
+			contents, err := f.Contents()
+			if err != nil {
+				return errors.Wrapf(err, "could not retrieve contents of %s", f.Name)
+			}
+
+			configs = append(configs, &inventoriedConfig{
+				path: f.Name, tool: config.tool, format: config.format, summary: summarizeConfig(config.tool, config.format, contents),
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &configInventoryIter{configs: configs, index: -1}, nil
+	})
+}
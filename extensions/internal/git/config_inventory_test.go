@@ -0,0 +1,57 @@
+package git_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestConfigInventory(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := commitFiles(t, map[string]string{
+		".editorconfig": "root = true\n\n[*]\nindent_style = space\nindent_size = 2\n\n[*.go]\nindent_style = tab\n",
+		".golangci.yml": "linters:\n  disable-all: true\n  enable:\n    - govet\n    - errcheck\n",
+		".github/dependabot.yml": `version: 2
+updates:
+  - package-ecosystem: gomod
+    directory: /
+    schedule:
+      interval: weekly
+`,
+		"renovate.json":                     `{"extends": ["config:recommended"], "schedule": "before 6am on monday"}`,
+		"web/.eslintrc.json":                `{"root": true, "extends": "eslint:recommended", "plugins": ["react"]}`,
+		"web/eslint.config.js":              "export default [];\n",
+		"web/node_modules/x/.eslintrc.json": `{"extends": "airbnb"}`,
+		"docs/renovate.json":                `{}`,
+	})
+
+	rows, err := db.Query("SELECT path, tool, format, summary FROM config_inventory(?)", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{".editorconfig", "editorconfig", "ini", `{"root":true,"sections":["*","*.go"],"properties":{"indent_size":"2","indent_style":"space"}}`},
+		{".github/dependabot.yml", "dependabot", "yaml", `{"updates":[{"ecosystem":"gomod","directory":"/","interval":"weekly"}]}`},
+		{".golangci.yml", "golangci-lint", "yaml", `{"version":"1","default":"none","enable":["govet","errcheck"],"disable":[]}`},
+		{"renovate.json", "renovate", "json", `{"extends":["config:recommended"],"schedule":["before 6am on monday"]}`},
+		{"web/.eslintrc.json", "eslint", "json", `{"root":true,"extends":["eslint:recommended"],"plugins":["react"]}`},
+		{"web/eslint.config.js", "eslint", "javascript", "NULL"},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d configuration files, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package git
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// CommitDepthFn implements the COMMIT_DEPTH(repository, hash) sql function, which returns the distance of a commit
+// from the root of the history: the number of commits on the longest path from it to a root commit (which has a depth of 0).
+type CommitDepthFn struct {
+	Options *utils.ModuleOptions
+
+	// the depths computed so far in the last repository, as the function
+	// is typically called for every commit of the same repository
+	mu     sync.Mutex
+	path   string
+	walker *dagWalker
+}
+
+// NewCommitDepthFn returns a new CommitDepthFn implementation
+func NewCommitDepthFn(opt *utils.ModuleOptions) *CommitDepthFn {
+	return &CommitDepthFn{Options: opt}
+}
+
+func (*CommitDepthFn) Deterministic() bool { return false }
+func (*CommitDepthFn) Args() int           { return 2 }
+func (fn *CommitDepthFn) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	path, repo, err := openFnRepo(fn.Options, values[0].Text())
+	if err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	var hash *plumbing.Hash
+	if hash, err = repo.ResolveRevision(plumbing.Revision(values[1].Text())); err != nil {
+		c.ResultError(errors.Wrapf(err, "failed to resolve %q", values[1].Text()))
+		return
+	}
+
+	fn.mu.Lock()
+	defer fn.mu.Unlock()
+
+	if fn.path != path {
+		fn.path, fn.walker = path, newDAGWalker(repo)
+	}
+
+	var depth int
+	if depth, err = fn.walker.depth(*hash); err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	c.ResultInt(depth)
+}
+
+// BranchPointFn implements the BRANCH_POINT(repository, rev_a, rev_b) sql function, which returns the hash of
+// the commit rev_a and rev_b diverged from (their best common ancestor, as in git merge-base rev_a rev_b),
+// or NULL if they don't share any history.
+type BranchPointFn struct {
+	Options *utils.ModuleOptions
+}
+
+// NewBranchPointFn returns a new BranchPointFn implementation
+func NewBranchPointFn(opt *utils.ModuleOptions) *BranchPointFn {
+	return &BranchPointFn{Options: opt}
+}
+
+func (*BranchPointFn) Deterministic() bool { return false }
+func (*BranchPointFn) Args() int           { return 3 }
+func (fn *BranchPointFn) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	_, repo, err := openFnRepo(fn.Options, values[0].Text())
+	if err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	var a, b *object.Commit
+	if a, err = resolveCommitObject(repo, values[1].Text()); err != nil {
+		c.ResultError(err)
+		return
+	}
+	if b, err = resolveCommitObject(repo, values[2].Text()); err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	var bases []*object.Commit
+	if bases, err = a.MergeBase(b); err != nil {
+		c.ResultError(errors.Wrapf(err, "failed to find the branch point of %q and %q", values[1].Text(), values[2].Text()))
+		return
+	}
+
+	if len(bases) > 0 {
+		c.ResultText(bases[0].Hash.String())
+	}
+}
+
+// openFnRepo opens the repository at path (the default repository if empty) for a sql function,
+// returning the path of the repository along with it
+func openFnRepo(options *utils.ModuleOptions, path string) (string, *git.Repository, error) {
+	var err error
+	if path == "" {
+		if path, err = options.GetRepoPath(); err != nil {
+			return "", nil, err
+		}
+	}
+
+	var repo *git.Repository
+	if repo, err = options.Locator.Open(context.Background(), path); err != nil {
+		return "", nil, errors.Wrapf(err, "failed to open %q", path)
+	}
+	return path, repo, nil
+}
+
+// resolveCommitObject returns the commit rev resolves to in repo
+func resolveCommitObject(repo *git.Repository, rev string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve %q", rev)
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not lookup commit %s", hash)
+	}
+	return commit, nil
+}
+
+// dagWalker computes the depths of the commits of a repository, remembering the parents and depths
+// of the commits it walked through
+type dagWalker struct {
+	repo    *git.Repository
+	parents map[plumbing.Hash][]plumbing.Hash
+	depths  map[plumbing.Hash]int
+}
+
+func newDAGWalker(repo *git.Repository) *dagWalker {
+	return &dagWalker{repo: repo, parents: make(map[plumbing.Hash][]plumbing.Hash), depths: make(map[plumbing.Hash]int)}
+}
+
+// depth returns the number of commits on the longest path from hash to a root commit. The history is walked
+// without recursion, as it may be deep enough for a recursive walk to exhaust the stack.
+func (w *dagWalker) depth(hash plumbing.Hash) (int, error) {
+	var stack = []plumbing.Hash{hash}
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		if _, ok := w.depths[top]; ok {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		parents, ok := w.parents[top]
+		if !ok {
+			commit, err := w.repo.CommitObject(top)
+			if err != nil {
+				return 0, errors.Wrapf(err, "could not lookup commit %s", top)
+			}
+			parents = commit.ParentHashes
+			w.parents[top] = parents
+		}
+
+		// the depth of a commit is known once the depths of all of its parents are
+		var depth, pending = 0, false
+		for _, parent := range parents {
+			if d, ok := w.depths[parent]; ok {
+				if d+1 > depth {
+					depth = d + 1
+				}
+			} else {
+				stack = append(stack, parent)
+				pending = true
+			}
+		}
+
+		if !pending {
+			w.depths[top] = depth
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	return w.depths[hash], nil
+}
@@ -0,0 +1,72 @@
+package git_test
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestCommitDAGFns(t *testing.T) {
+	db := Connect(t, Memory)
+
+	repo := tools.NewRepo(t)
+	dir := repo.Dir
+
+	// A - B - C ------- M
+	//      \           /
+	//       F1 - F2 --
+	repo.Commit("A", map[string]string{"a.txt": "A"})
+	b := repo.Commit("B", map[string]string{"b.txt": "B"})
+	c := repo.Commit("C", map[string]string{"c.txt": "C"})
+	if err := repo.Worktree.Checkout(&git.CheckoutOptions{Hash: b}); err != nil {
+		t.Fatal(err)
+	}
+	repo.Commit("F1", map[string]string{"f.txt": "F1"}, b)
+	f2 := repo.Commit("F2", map[string]string{"f.txt": "F2"})
+	m := repo.Commit("M", map[string]string{"m.txt": "M"}, c, f2)
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.Master, m)); err != nil {
+		t.Fatal(err)
+	}
+
+	var depthB, depthC, depthM int
+	var branchPoint string
+	err := db.QueryRow("SELECT commit_depth(?, ?), commit_depth(?, ?), commit_depth(?, 'master'), branch_point(?, ?, ?)",
+		dir, b.String(), dir, c.String(), dir, dir, c.String(), f2.String()).
+		Scan(&depthB, &depthC, &depthM, &branchPoint)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err)
+	}
+
+	// the depth of the merge follows the longest path, through the branch
+	if depthB != 1 || depthC != 2 || depthM != 4 {
+		t.Fatalf("unexpected depths: B=%d C=%d M=%d", depthB, depthC, depthM)
+	}
+
+	if branchPoint != b.String() {
+		t.Fatalf("expected the branch point of C and F2 to be %s, got %s", b, branchPoint)
+	}
+
+	var commits, merges, roots, maxDepth, maxWidth int
+	var mergeRatio float64
+	err = db.QueryRow("SELECT commits, merges, roots, merge_ratio, max_depth, max_width FROM dag_stats(?, 'master')", dir).
+		Scan(&commits, &merges, &roots, &mergeRatio, &maxDepth, &maxWidth)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err)
+	}
+
+	// C and F1 are both at depth 2
+	if commits != 6 || merges != 1 || roots != 1 || mergeRatio != 1.0/6 || maxDepth != 4 || maxWidth != 2 {
+		t.Fatalf("unexpected dag stats: commits=%d merges=%d roots=%d merge_ratio=%f max_depth=%d max_width=%d",
+			commits, merges, roots, mergeRatio, maxDepth, maxWidth)
+	}
+
+	// without a ref, the DAG reachable from HEAD (left at M by the last commit) is summarized
+	if err = db.QueryRow("SELECT commits, max_depth FROM dag_stats(?)", dir).Scan(&commits, &maxDepth); err != nil {
+		t.Fatalf("failed to execute query: %v", err)
+	}
+	if commits != 6 || maxDepth != 4 {
+		t.Fatalf("unexpected dag stats of HEAD: commits=%d max_depth=%d", commits, maxDepth)
+	}
+}
@@ -0,0 +1,121 @@
+package git
+
+import (
+	"io"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var dagStatsCols = []vtab.Column{
+	{Name: "commits", Type: "INT"},
+	{Name: "merges", Type: "INT"},
+	{Name: "roots", Type: "INT"},
+	{Name: "merge_ratio", Type: "REAL"},
+	{Name: "max_depth", Type: "INT"},
+	{Name: "max_width", Type: "INT"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// dagStats summarizes the topology of the commits reachable from a ref
+type dagStats struct {
+	commits, merges, roots int
+	maxDepth, maxWidth     int
+}
+
+type dagStatsIter struct {
+	stats *dagStats
+	done  bool
+}
+
+func (i *dagStatsIter) Column(ctx vtab.Context, c int) error {
+	switch dagStatsCols[c].Name {
+	case "commits":
+		ctx.ResultInt(i.stats.commits)
+	case "merges":
+		ctx.ResultInt(i.stats.merges)
+	case "roots":
+		ctx.ResultInt(i.stats.roots)
+	case "merge_ratio":
+		if i.stats.commits > 0 {
+			ctx.ResultFloat(float64(i.stats.merges) / float64(i.stats.commits))
+		}
+	case "max_depth":
+		ctx.ResultInt(i.stats.maxDepth)
+	case "max_width":
+		ctx.ResultInt(i.stats.maxWidth)
+	}
+	return nil
+}
+
+func (i *dagStatsIter) Next() (vtab.Row, error) {
+	if i.done {
+		return nil, io.EOF
+	}
+	i.done = true
+	return i, nil
+}
+
+// NewDAGStatsModule returns the implementation of a table-valued-function returning a single row summarizing the shape
+// of the commit DAG reachable from ref (HEAD if not supplied): the number of commits, merges and root commits,
+// the ratio of merges to commits, the depth of ref (as in commit_depth) and the width of the DAG, that is the most
+// commits found at the same depth (i.e. lines of development running in parallel).
+func NewDAGStatsModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("dag_stats", dagStatsCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 6:
+					repoPath = constraint.Value.Text()
+				case 7:
+					ref = constraint.Value.Text()
+				}
+			}
+		}
+
+		if ref == "" {
+			if ref = utils.GetDefaultRefFromCtx(options.Context); ref == "" {
+				ref = "HEAD"
+			}
+		}
+
+		_, repo, err := openFnRepo(options, repoPath)
+		if err != nil {
+			return nil, err
+		}
+
+		var hash *plumbing.Hash
+		if hash, err = repo.ResolveRevision(plumbing.Revision(ref)); err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve %q", ref)
+		}
+
+		var w = newDAGWalker(repo)
+		var stats = &dagStats{}
+		if stats.maxDepth, err = w.depth(*hash); err != nil {
+			return nil, err
+		}
+
+		// the walker went through every commit reachable from ref, to find its depth
+		var widths = make(map[int]int)
+		for commit, depth := range w.depths {
+			stats.commits++
+			if parents := len(w.parents[commit]); parents == 0 {
+				stats.roots++
+			} else if parents > 1 {
+				stats.merges++
+			}
+
+			if widths[depth]++; widths[depth] > stats.maxWidth {
+				stats.maxWidth = widths[depth]
+			}
+		}
+
+		return &dagStatsIter{stats: stats}, nil
+	})
+}
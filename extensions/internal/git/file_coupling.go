@@ -0,0 +1,223 @@
+package git
+
+import (
+	"io"
+	"sort"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/extensions/services"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var fileCouplingCols = []vtab.Column{
+	{Name: "file_a", Type: "TEXT"},
+	{Name: "file_b", Type: "TEXT"},
+	{Name: "co_changes", Type: "INT"},
+	{Name: "changes_a", Type: "INT"},
+	{Name: "changes_b", Type: "INT"},
+	{Name: "coupling", Type: "REAL"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "since", Type: "DATETIME", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// maxCouplingChangeset is the most files a commit may change to count towards file coupling. Larger commits (such as
+// imports, mass renames or reformatting) change files together without them being related, and would make for
+// a quadratic number of pairs.
+const maxCouplingChangeset = 50
+
+// filePair is a pair of files changed together, with a sorting before b
+type filePair struct{ a, b string }
+
+type fileCoupling struct {
+	filePair
+	together int
+}
+
+type fileCouplingIter struct {
+	pairs   []*fileCoupling
+	changes map[string]int // the number of commits changing each file
+	index   int
+}
+
+func (i *fileCouplingIter) Column(ctx vtab.Context, c int) error {
+	current := i.pairs[i.index]
+	switch fileCouplingCols[c].Name {
+	case "file_a":
+		ctx.ResultText(current.a)
+	case "file_b":
+		ctx.ResultText(current.b)
+	case "co_changes":
+		ctx.ResultInt(current.together)
+	case "changes_a":
+		ctx.ResultInt(i.changes[current.a])
+	case "changes_b":
+		ctx.ResultInt(i.changes[current.b])
+	case "coupling":
+		// the share of the commits changing either file that change both of them
+		ctx.ResultFloat(float64(current.together) / float64(i.changes[current.a]+i.changes[current.b]-current.together))
+	}
+	return nil
+}
+
+func (i *fileCouplingIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.pairs) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewFileCouplingModule returns the implementation of a table-valued-function listing the pairs of files changed
+// together in the history of ref (HEAD if not supplied), optionally limited to the commits made since a date, with
+// the number of commits changing both of them and each of them, most coupled first. It's computed in a single pass over
+// the history, rather than by joining stats with itself. Merge commits, and commits changing more than 50 files, are left out.
+func NewFileCouplingModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("file_coupling", fileCouplingCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref, since string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 6:
+					repoPath = constraint.Value.Text()
+				case 7:
+					ref = constraint.Value.Text()
+				case 8:
+					since = constraint.Value.Text()
+				}
+			}
+		}
+
+		if ref == "" {
+			if ref = utils.GetDefaultRefFromCtx(options.Context); ref == "" {
+				ref = "HEAD"
+			}
+		}
+
+		var opts = &git.LogOptions{}
+		if since != "" {
+			t, err := parseCouplingSince(since)
+			if err != nil {
+				return nil, err
+			}
+			opts.Since = &t
+		}
+
+		_, repo, err := openFnRepo(options, repoPath)
+		if err != nil {
+			return nil, err
+		}
+
+		commit, err := resolveCommitObject(repo, ref)
+		if err != nil {
+			return nil, err
+		}
+		opts.From = commit.Hash
+
+		return newFileCouplingIter(repo, opts, options.Stats)
+	})
+}
+
+// parseCouplingSince parses since, in the formats SQLite datetimes are usually in
+func parseCouplingSince(since string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, since); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("invalid since %q: expected a date, such as 2006-01-02", since)
+}
+
+func newFileCouplingIter(repo *git.Repository, opts *git.LogOptions, stats *services.QueryStats) (*fileCouplingIter, error) {
+	commits, err := repo.Log(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to walk history")
+	}
+	defer commits.Close()
+
+	var iter = &fileCouplingIter{changes: make(map[string]int), index: -1}
+	var together = make(map[filePair]*fileCoupling)
+	err = commits.ForEach(func(commit *object.Commit) error {
+		stats.Count(services.CommitsWalked, 1)
+		if commit.NumParents() > 1 {
+			return nil
+		}
+
+		files, err := changedFiles(commit)
+		if err != nil {
+			return err
+		}
+		if len(files) > maxCouplingChangeset {
+			return nil
+		}
+
+		for x, a := range files {
+			iter.changes[a]++
+			for _, b := range files[x+1:] {
+				var pair = filePair{a, b}
+				if _, ok := together[pair]; !ok {
+					together[pair] = &fileCoupling{filePair: pair}
+					iter.pairs = append(iter.pairs, together[pair])
+				}
+				together[pair].together++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(iter.pairs, func(i, j int) bool {
+		if iter.pairs[i].together != iter.pairs[j].together {
+			return iter.pairs[i].together > iter.pairs[j].together
+		}
+		if iter.pairs[i].a != iter.pairs[j].a {
+			return iter.pairs[i].a < iter.pairs[j].a
+		}
+		return iter.pairs[i].b < iter.pairs[j].b
+	})
+	return iter, nil
+}
+
+// changedFiles returns the sorted paths of the files commit changes, against its first parent (if any)
+func changedFiles(commit *object.Commit) ([]string, error) {
+	var from *object.Tree
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not lookup parent of %s", commit.Hash)
+		}
+		if from, err = parent.Tree(); err != nil {
+			return nil, errors.Wrapf(err, "could not lookup tree of %s", parent.Hash)
+		}
+	}
+
+	to, err := commit.Tree()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not lookup tree of %s", commit.Hash)
+	}
+
+	changes, err := object.DiffTree(from, to)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not diff %s", commit.Hash)
+	}
+
+	var files = make([]string, 0, len(changes))
+	for _, change := range changes {
+		// renames aren't detected, so a change is either to the same path or an addition or removal
+		if change.To.Name != "" {
+			files = append(files, change.To.Name)
+		} else {
+			files = append(files, change.From.Name)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,66 @@
+package git_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestFileCoupling(t *testing.T) {
+	db := Connect(t, Memory)
+
+	repo := tools.NewRepo(t)
+	dir := repo.Dir
+
+	// one commit a month, from February on
+	for month, files := range [][]string{{"a.go", "b.go"}, {"a.go", "b.go", "c.go"}, {"a.go"}, {"c.go"}} {
+		var contents = make(map[string]string)
+		for _, file := range files {
+			contents[file] = fmt.Sprint(month)
+		}
+		repo.When = time.Date(2022, time.Month(month+2), 1, 0, 0, 0, 0, time.UTC)
+		repo.Commit("change", contents)
+	}
+
+	rows, err := db.Query("SELECT file_a, file_b, co_changes, changes_a, changes_b, round(coupling, 2) FROM file_coupling(?)", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{"a.go", "b.go", "2", "3", "2", "0.67"},
+		{"a.go", "c.go", "1", "3", "2", "0.25"},
+		{"b.go", "c.go", "1", "2", "2", "0.33"},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d rows, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+
+	// only the last three commits are made since the start of March
+	var together, changes int
+	if err = db.QueryRow("SELECT co_changes, changes_a FROM file_coupling(?, 'HEAD', '2022-03-01') WHERE file_a = 'a.go' AND file_b = 'b.go'", dir).Scan(&together, &changes); err != nil {
+		t.Fatal(err)
+	}
+	if together != 1 || changes != 2 {
+		t.Fatalf("expected a.go and b.go to change together once since March, got: %d (of %d)", together, changes)
+	}
+
+	if _, err = db.Query("SELECT * FROM file_coupling(?, 'HEAD', 'last year')", dir); err == nil {
+		t.Fatal("expected an invalid since to fail")
+	}
+}
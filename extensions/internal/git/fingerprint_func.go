@@ -0,0 +1,111 @@
+package git
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// fingerprintLength is the number of commits of the early history of the main line a fingerprint is computed from.
+// Repositories with a shorter main line are identified by all of it, and only share their fingerprint with exact copies.
+const fingerprintLength = 8
+
+// RepoFingerprintFn implements the REPO_FINGERPRINT(repository) sql function, which returns a hash identifying
+// the history of a repository: forks and mirrors of a repository share their root commits and the early history of
+// their main line, and so their fingerprint, regardless of what was committed (or which branches were added) since.
+// It's meant to group duplicates when aggregating metrics across many repositories.
+type RepoFingerprintFn struct {
+	Options *utils.ModuleOptions
+}
+
+// NewRepoFingerprintFn returns a new RepoFingerprintFn implementation
+func NewRepoFingerprintFn(opt *utils.ModuleOptions) *RepoFingerprintFn {
+	return &RepoFingerprintFn{Options: opt}
+}
+
+func (*RepoFingerprintFn) Deterministic() bool { return false }
+func (*RepoFingerprintFn) Args() int           { return 1 }
+func (fn *RepoFingerprintFn) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	_, repo, err := openFnRepo(fn.Options, values[0].Text())
+	if err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	var ref string
+	if ref = utils.GetDefaultRefFromCtx(fn.Options.Context); ref == "" {
+		ref = "HEAD"
+	}
+
+	var hash *plumbing.Hash
+	if hash, err = repo.ResolveRevision(plumbing.Revision(ref)); err != nil {
+		c.ResultError(errors.Wrapf(err, "failed to resolve %q", ref))
+		return
+	}
+
+	var fingerprint string
+	if fingerprint, err = repoFingerprint(repo, *hash); err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	c.ResultText(fingerprint)
+}
+
+// repoFingerprint returns the hash of the (sorted) root commits reachable from head,
+// followed by the first fingerprintLength commits of the first-parent history of head, oldest first
+func repoFingerprint(repo *git.Repository, head plumbing.Hash) (string, error) {
+	iter, err := repo.Log(&git.LogOptions{From: head})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create iterator")
+	}
+	defer iter.Close()
+
+	var roots []string
+	err = iter.ForEach(func(c *object.Commit) error {
+		if c.NumParents() == 0 {
+			roots = append(roots, c.Hash.String())
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(roots)
+
+	// the main line is walked from head, keeping the last (i.e. earliest) commits
+	var mainLine []plumbing.Hash
+	for hash := head; ; {
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return "", errors.Wrapf(err, "could not lookup commit %s", hash)
+		}
+
+		if mainLine = append(mainLine, hash); len(mainLine) > fingerprintLength {
+			mainLine = mainLine[1:]
+		}
+
+		if commit.NumParents() == 0 {
+			break
+		}
+		hash = commit.ParentHashes[0]
+	}
+
+	var h = sha1.New()
+	for _, root := range roots {
+		_, _ = io.WriteString(h, "root "+root+"\n")
+	}
+	for i := len(mainLine) - 1; i >= 0; i-- {
+		_, _ = io.WriteString(h, "commit "+mainLine[i].String()+"\n")
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,40 @@
+package git_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestRepoFingerprintFn(t *testing.T) {
+	db := Connect(t, Memory)
+
+	upstream := tools.NewRepo(t)
+	for _, content := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		upstream.Commit("update file.txt", map[string]string{"file.txt": content})
+	}
+
+	// the fork moves on from upstream
+	fork := upstream.Clone()
+	fork.Commit("update fork.txt", map[string]string{"fork.txt": "k"})
+
+	// an unrelated repository with the same file, committed at another time
+	other := tools.NewRepo(t)
+	other.When = fork.When
+	other.Commit("update file.txt", map[string]string{"file.txt": "a"})
+
+	var upstreamFingerprint, forkFingerprint, otherFingerprint string
+	err := db.QueryRow("SELECT repo_fingerprint(?), repo_fingerprint(?), repo_fingerprint(?)", upstream.Dir, fork.Dir, other.Dir).
+		Scan(&upstreamFingerprint, &forkFingerprint, &otherFingerprint)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err)
+	}
+
+	if upstreamFingerprint != forkFingerprint {
+		t.Fatalf("expected the fork to share the fingerprint of upstream, got %s and %s", forkFingerprint, upstreamFingerprint)
+	}
+
+	if otherFingerprint == upstreamFingerprint {
+		t.Fatalf("expected an unrelated repository to have a different fingerprint")
+	}
+}
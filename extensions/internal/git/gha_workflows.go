@@ -0,0 +1,123 @@
+package git
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/pkg/ghactions"
+	"go.riyazali.net/sqlite"
+)
+
+var ghaWorkflowsCols = []vtab.Column{
+	{Name: "path", Type: "TEXT"},
+	{Name: "workflow", Type: "TEXT"},
+	{Name: "triggers", Type: "JSON"},
+	{Name: "job", Type: "TEXT"},
+	{Name: "job_name", Type: "TEXT"},
+	{Name: "runs_on", Type: "TEXT"},
+	{Name: "step", Type: "INT"},
+	{Name: "step_name", Type: "TEXT"},
+	{Name: "uses", Type: "TEXT"},
+	{Name: "action", Type: "TEXT"},
+	{Name: "action_version", Type: "TEXT"},
+	{Name: "run", Type: "TEXT"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+type ghaWorkflowsIter struct {
+	steps []*ghactions.Step
+	index int
+}
+
+func (i *ghaWorkflowsIter) Column(ctx vtab.Context, c int) error {
+	current := i.steps[i.index]
+	action, version := current.Action()
+
+	var value string
+	switch ghaWorkflowsCols[c].Name {
+	case "path":
+		value = current.Path
+	case "workflow":
+		value = current.Workflow
+	case "triggers":
+		triggers := current.Triggers
+		if triggers == nil {
+			triggers = []string{}
+		}
+		js, err := json.Marshal(triggers)
+		if err != nil {
+			return err
+		}
+		value = string(js)
+	case "job":
+		value = current.Job
+	case "job_name":
+		value = current.JobName
+	case "runs_on":
+		value = current.RunsOn
+	case "step":
+		if current.Step >= 0 {
+			ctx.ResultInt(current.Step)
+		}
+		return nil
+	case "step_name":
+		value = current.StepName
+	case "uses":
+		value = current.Uses
+	case "action":
+		value = action
+	case "action_version":
+		value = version
+	case "run":
+		value = current.Run
+	}
+
+	// fields left out of the workflow are NULL
+	if value != "" {
+		ctx.ResultText(value)
+	}
+	return nil
+}
+
+func (i *ghaWorkflowsIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.steps) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewGHAWorkflowsModule returns the implementation of a table-valued-function listing the steps of the jobs of the GitHub Actions
+// workflows (in .github/workflows) at ref (the default ref, or HEAD, if not supplied), along with the action each uses
+// and the version it's pinned to. Jobs calling a reusable workflow are listed as a single row, without a step.
+func NewGHAWorkflowsModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("gha_workflows", ghaWorkflowsCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 12:
+					repoPath = constraint.Value.Text()
+				case 13:
+					ref = constraint.Value.Text()
+				}
+			}
+		}
+
+		tree, err := options.LookupTree(repoPath, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		steps, err := ghactions.Read(tree)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ghaWorkflowsIter{steps: steps, index: -1}, nil
+	})
+}
@@ -0,0 +1,124 @@
+package git_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestGHAWorkflows(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := commitFiles(t, map[string]string{
+		".github/workflows/ci.yml": `name: CI
+on:
+  push:
+    branches: [main]
+  pull_request:
+jobs:
+  test:
+    name: Test
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11 # v4.1.1
+      - name: Set up Go
+        uses: actions/setup-go@v5
+      - run: go test ./...
+  release:
+    uses: mergestat/workflows/.github/workflows/release.yml@main
+`,
+		".github/workflows/nightly.yaml": `on: schedule
+jobs:
+  scan:
+    runs-on: [self-hosted, linux]
+    steps:
+      - uses: ./.github/actions/scan
+`,
+		".github/workflows/README.md": "not a workflow",
+	})
+
+	rows, err := db.Query("SELECT path, workflow, triggers, job, job_name, runs_on, step, step_name, action, action_version, run FROM gha_workflows(?)", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{".github/workflows/ci.yml", "CI", `["pull_request","push"]`, "release", "NULL", "NULL", "NULL", "NULL", "mergestat/workflows/.github/workflows/release.yml", "main", "NULL"},
+		{".github/workflows/ci.yml", "CI", `["pull_request","push"]`, "test", "Test", "ubuntu-latest", "0", "NULL", "actions/checkout", "b4ffde65f46336ab88eb53be808477a3936bae11", "NULL"},
+		{".github/workflows/ci.yml", "CI", `["pull_request","push"]`, "test", "Test", "ubuntu-latest", "1", "Set up Go", "actions/setup-go", "v5", "NULL"},
+		{".github/workflows/ci.yml", "CI", `["pull_request","push"]`, "test", "Test", "ubuntu-latest", "2", "NULL", "NULL", "NULL", "go test ./..."},
+		{".github/workflows/nightly.yaml", "NULL", `["schedule"]`, "scan", "NULL", `["self-hosted","linux"]`, "0", "NULL", "./.github/actions/scan", "NULL", "NULL"},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d steps, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+}
+
+func TestGitLabCIJobs(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := commitFiles(t, map[string]string{
+		".gitlab-ci.yml": `default:
+  image: golang:1.22
+stages: [build, deploy]
+.deploy:
+  stage: deploy
+  script:
+    - ./deploy.sh
+build:
+  stage: build
+  script: go build ./...
+lint:
+  image:
+    name: golangci/golangci-lint:v1.57
+  script:
+    - golangci-lint run
+production:
+  extends: .deploy
+  needs: [build, {job: lint, artifacts: false}]
+downstream:
+  stage: deploy
+  trigger: mergestat/deployments
+`,
+	})
+
+	rows, err := db.Query("SELECT job, hidden, stage, image, extends, needs, script, trigger FROM gitlab_ci_jobs(?, 'HEAD')", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{".deploy", "1", "deploy", "golang:1.22", "[]", "[]", `["./deploy.sh"]`, "NULL"},
+		{"build", "0", "build", "golang:1.22", "[]", "[]", `["go build ./..."]`, "NULL"},
+		{"downstream", "0", "deploy", "golang:1.22", "[]", "[]", "[]", "mergestat/deployments"},
+		{"lint", "0", "test", "golangci/golangci-lint:v1.57", "[]", "[]", `["golangci-lint run"]`, "NULL"},
+		{"production", "0", "deploy", "golang:1.22", `[".deploy"]`, `["build","lint"]`, `["./deploy.sh"]`, "NULL"},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d jobs, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+}
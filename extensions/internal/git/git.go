@@ -9,12 +9,17 @@ import (
 	"go.riyazali.net/sqlite"
 )
 
-// Register registers git related functionality as a SQLite extension
+// Register registers git related functionality as a SQLite extension. It runs for every connection SQLite opens,
+// so that the state of the modules scoped to a connection (such as the statement context) isn't shared between them.
 func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
 	moduleOpts := &utils.ModuleOptions{
 		Locator: opt.Locator,
 		Context: opt.Context,
 		Logger:  opt.Logger,
+
+		Statement: utils.NewStatementContext(),
+		Mailmap:   &utils.MailmapCache{Stats: opt.Stats},
+		Stats:     opt.Stats,
 	}
 
 	// by default use a NOOP logger so we don't need nil checks within the modules
@@ -25,22 +30,60 @@ func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCod
 
 	// register virtual table modules
 	var modules = map[string]sqlite.Module{
-		"commits": NewLogModule(moduleOpts),
-		"refs":    NewRefModule(moduleOpts),
-		"stats":   native.NewStatsModule(moduleOpts),
-		"files":   native.NewFilesModule(moduleOpts),
-		"blame":   native.NewBlameModule(moduleOpts),
+		"commits":          NewLogModule(moduleOpts),
+		"refs":             NewRefModule(moduleOpts),
+		"head":             NewHeadModule(moduleOpts),
+		"releases":         NewReleasesModule(moduleOpts),
+		"cherry":           NewCherryModule(moduleOpts),
+		"mailmap_entries":  NewMailmapEntriesModule(moduleOpts),
+		"commit_authors":   NewCommitAuthorsModule(moduleOpts),
+		"dag_stats":        NewDAGStatsModule(moduleOpts),
+		"stats":            native.NewStatsModule(moduleOpts),
+		"diff_hunks":       native.NewDiffHunksModule(moduleOpts),
+		"diff_lines":       native.NewDiffLinesModule(moduleOpts),
+		"dir_stats":        native.NewDirStatsModule(moduleOpts),
+		"tree_diff":        native.NewTreeDiffModule(moduleOpts),
+		"files":            native.NewFilesModule(moduleOpts),
+		"blame":            native.NewBlameModule(moduleOpts),
+		"repos_in":         NewReposInModule(moduleOpts),
+		"blame_summary":    native.NewBlameSummaryModule(moduleOpts),
+		"ancestry_path":    native.NewAncestryPathModule(moduleOpts),
+		"reachable_from":   native.NewReachableFromModule(moduleOpts),
+		"status":           native.NewStatusModule(moduleOpts),
+		"secret_findings":  native.NewSecretFindingsModule(moduleOpts),
+		"large_files":      native.NewLargeFilesModule(moduleOpts),
+		"tf_modules":       NewTerraformModulesModule(moduleOpts),
+		"tf_providers":     NewTerraformProvidersModule(moduleOpts),
+		"gha_workflows":    NewGHAWorkflowsModule(moduleOpts),
+		"gitlab_ci_jobs":   NewGitLabCIJobsModule(moduleOpts),
+		"owners":           NewOwnersModule(moduleOpts),
+		"maintainers":      NewMaintainersModule(moduleOpts),
+		"codeowners":       NewCodeownersModule(moduleOpts),
+		"config_inventory": NewConfigInventoryModule(moduleOpts),
+		"ref_policy_check": NewRefPolicyCheckModule(moduleOpts),
+		"snapshot_diff":    NewSnapshotDiffModule(moduleOpts),
+		"file_coupling":    NewFileCouplingModule(moduleOpts),
+		"reflog":           NewReflogModule(moduleOpts),
+		"notes":            NewNotesModule(moduleOpts),
+		"submodules":       NewSubmodulesModule(moduleOpts),
+		"git_config":       NewGitConfigModule(moduleOpts),
 	}
 
 	for name, mod := range modules {
-		if err = ext.CreateModule(name, mod); err != nil {
+		if err = ext.CreateModule(name, opt.Stats.Module(name, mod)); err != nil {
 			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q module", name)
 		}
+		opt.Modules.Add("git", name, mod)
 	}
 
 	var fns = map[string]sqlite.Function{
-		"commit_from_tag": &CommitFromTagFn{},
-		"clone":           NewCloneFn(moduleOpts),
+		"commit_from_tag":  &CommitFromTagFn{},
+		"clone":            NewCloneFn(moduleOpts),
+		"commit_graph":     NewCommitGraphFn(moduleOpts),
+		"commits_ahead":    NewCommitsAheadFn(moduleOpts),
+		"commit_depth":     NewCommitDepthFn(moduleOpts),
+		"branch_point":     NewBranchPointFn(moduleOpts),
+		"repo_fingerprint": NewRepoFingerprintFn(moduleOpts),
 	}
 
 	for name, fn := range fns {
@@ -0,0 +1,195 @@
+package git
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	format "github.com/go-git/go-git/v5/plumbing/format/config"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var gitConfigCols = []vtab.Column{
+	{Name: "key", Type: "TEXT"},
+	{Name: "value", Type: "TEXT"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "scope", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// gitConfigScopes are the scopes of the settings of git config, in the order they're read (and overridden) by git
+var gitConfigScopes = []string{"system", "global", "local"}
+
+// gitConfigEntry is a setting of git config, in the file of scope
+type gitConfigEntry struct {
+	key, value, scope string
+}
+
+type gitConfigIter struct {
+	entries []*gitConfigEntry
+	index   int
+}
+
+func (i *gitConfigIter) Column(ctx vtab.Context, c int) error {
+	current := i.entries[i.index]
+	switch gitConfigCols[c].Name {
+	case "key":
+		ctx.ResultText(current.key)
+	case "value":
+		ctx.ResultText(current.value)
+	case "scope":
+		ctx.ResultText(current.scope)
+	}
+	return nil
+}
+
+func (i *gitConfigIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.entries) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewGitConfigModule returns the implementation of a table-valued-function listing the settings of git config for a
+// repository, as in git config --list --show-scope: the settings of scope (system, global or local), or of every scope
+// if not supplied, in the order git reads them (so the last value of a key is the one in effect). Keys are named as in
+// git config, such as core.autocrlf or remote.origin.url, and a key with multiple values has a row for each. The scope
+// of a setting is in the hidden scope column. Include directives aren't followed.
+func NewGitConfigModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("git_config", gitConfigCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, scope string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 2:
+					repoPath = constraint.Value.Text()
+				case 3:
+					scope = strings.ToLower(constraint.Value.Text())
+				}
+			}
+		}
+
+		var scopes = gitConfigScopes
+		if scope != "" {
+			if indexOfScope(scope) < 0 {
+				return nil, errors.Errorf("unknown scope %q: expected one of %s", scope, strings.Join(gitConfigScopes, ", "))
+			}
+			scopes = []string{scope}
+		}
+
+		var iter = &gitConfigIter{index: -1}
+		for _, scope := range scopes {
+			var raw *format.Config
+			var err error
+			switch scope {
+			case "system":
+				raw, err = readGitConfigFile(config.SystemScope)
+			case "global":
+				raw, err = readGitConfigFile(config.GlobalScope)
+			case "local":
+				var repo *git.Repository
+				if _, repo, err = openFnRepo(options, repoPath); err != nil {
+					return nil, err
+				}
+				raw, err = readLocalGitConfig(repo)
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read %s git config", scope)
+			}
+			iter.entries = append(iter.entries, gitConfigEntries(raw, scope)...)
+		}
+		return iter, nil
+	})
+}
+
+// readGitConfigFile decodes the first config file of scope (system or global) that exists, as git does, or returns
+// an empty config if there's none. The files are decoded as they are, rather than as a config.Config, so that
+// settings go-git doesn't understand (such as unusual refspecs) don't make them unreadable.
+func readGitConfigFile(scope config.Scope) (*format.Config, error) {
+	paths, err := config.Paths(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		defer f.Close()
+		return decodeGitConfig(f)
+	}
+	return format.New(), nil
+}
+
+// readLocalGitConfig decodes the config file of repo, or returns the config of repo if it isn't on disk
+func readLocalGitConfig(repo *git.Repository) (*format.Config, error) {
+	fs, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		cfg, err := repo.Storer.Config()
+		if err != nil {
+			return nil, err
+		}
+		return cfg.Raw, nil
+	}
+
+	f, err := fs.Filesystem().Open("config")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return format.New(), nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return decodeGitConfig(f)
+}
+
+func decodeGitConfig(r io.Reader) (*format.Config, error) {
+	var raw = format.New()
+	if err := format.NewDecoder(r).Decode(raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+func indexOfScope(scope string) int {
+	for i, s := range gitConfigScopes {
+		if s == scope {
+			return i
+		}
+	}
+	return -1
+}
+
+// gitConfigEntries returns the settings of raw, in the order they're set. Section and variable names are lowercased
+// (they're case-insensitive) while subsection names are kept as they are, as in git config --list.
+func gitConfigEntries(raw *format.Config, scope string) []*gitConfigEntry {
+	if raw == nil {
+		return nil
+	}
+
+	var entries []*gitConfigEntry
+	var add = func(prefix string, opts format.Options) {
+		for _, opt := range opts {
+			entries = append(entries, &gitConfigEntry{key: prefix + strings.ToLower(opt.Key), value: opt.Value, scope: scope})
+		}
+	}
+	for _, section := range raw.Sections {
+		name := strings.ToLower(section.Name)
+		add(name+".", section.Options)
+		for _, subsection := range section.Subsections {
+			add(name+"."+subsection.Name+".", subsection.Options)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,87 @@
+package git_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestGitConfig(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	// read the global config from a home of the test's own
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if err := os.WriteFile(filepath.Join(home, ".gitconfig"), []byte("[user]\n\tname = Jane\n[core]\n\tautocrlf = input\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var git = func(args ...string) {
+		t.Helper()
+		args = append([]string{"-C", dir}, args...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	git("init", "--quiet")
+	git("config", "core.autoCRLF", "false")
+	git("config", "remote.Origin.url", "https://github.com/acme/app.git")
+	git("config", "--add", "remote.Origin.fetch", "+refs/heads/*:refs/remotes/origin/*")
+	git("config", "--add", "remote.Origin.fetch", "+refs/tags/*:refs/tags/*")
+
+	rows, err := db.Query("SELECT key, value, scope FROM git_config(?, 'local') WHERE key NOT LIKE 'core.%' OR key = 'core.autocrlf'", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{"core.autocrlf", "false", "local"},
+		{"remote.Origin.url", "https://github.com/acme/app.git", "local"},
+		{"remote.Origin.fetch", "+refs/heads/*:refs/remotes/origin/*", "local"},
+		{"remote.Origin.fetch", "+refs/tags/*:refs/tags/*", "local"},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d rows, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+
+	// the local setting comes last, overriding the global one
+	var scopes []string
+	rows, err = db.Query("SELECT scope FROM git_config(?) WHERE key = 'core.autocrlf'", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	for rows.Next() {
+		var scope string
+		if err = rows.Scan(&scope); err != nil {
+			t.Fatal(err)
+		}
+		scopes = append(scopes, scope)
+	}
+	if strings.Join(scopes, ",") != "global,local" {
+		t.Fatalf("expected core.autocrlf to be set globally then locally, got: %v", scopes)
+	}
+
+	if _, err = db.Query("SELECT * FROM git_config(?, 'worktree')", dir); err == nil {
+		t.Fatal("expected an unknown scope to fail")
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/mergestat/mergestat-lite/extensions"
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/mergestat/mergestat-lite/pkg/locator"
 	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
@@ -29,7 +30,7 @@ const Memory = "file:testing.db?mode=memory"
 
 // Connect opens a connection with the sqlite3 database using
 // the given data source address and pings it to check liveliness.
-func Connect(t *testing.T, dataSourceName string) *sql.DB {
+func Connect(t testing.TB, dataSourceName string) *sql.DB {
 	t.Helper()
 	db, err := sql.Open("sqlite3", dataSourceName)
 	if err != nil {
@@ -42,3 +43,11 @@ func Connect(t *testing.T, dataSourceName string) *sql.DB {
 
 	return db
 }
+
+// commitFiles commits files (paths to contents) to a new repository, returning its path
+func commitFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	repo := tools.NewRepo(t)
+	repo.Commit("add files", files)
+	return repo.Dir
+}
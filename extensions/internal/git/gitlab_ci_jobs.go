@@ -0,0 +1,280 @@
+package git
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/ghodss/yaml"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/extensions/internal/sqlutil"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// gitlabCIFile is the file GitLab CI/CD pipelines are configured in
+const gitlabCIFile = ".gitlab-ci.yml"
+
+// gitlabCIKeywords are the top-level keys of a pipeline configuration that aren't jobs,
+// as in https://docs.gitlab.com/ee/ci/yaml/
+var gitlabCIKeywords = map[string]bool{
+	"default": true, "include": true, "stages": true, "variables": true, "workflow": true,
+	"image": true, "services": true, "cache": true, "before_script": true, "after_script": true, "types": true,
+}
+
+var gitlabCIJobsCols = []vtab.Column{
+	{Name: "path", Type: "TEXT"},
+	{Name: "job", Type: "TEXT"},
+	{Name: "hidden", Type: "BOOLEAN"},
+	{Name: "stage", Type: "TEXT"},
+	{Name: "image", Type: "TEXT"},
+	{Name: "extends", Type: "JSON"},
+	{Name: "needs", Type: "JSON"},
+	{Name: "script", Type: "JSON"},
+	{Name: "trigger", Type: "TEXT"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// gitlabCIJob is a job of a pipeline configuration, as in https://docs.gitlab.com/ee/ci/yaml/#job-keywords
+type gitlabCIJob struct {
+	Name    string          `json:"-"`
+	Stage   string          `json:"stage"`
+	Image   json.RawMessage `json:"image"`   // the name of an image, or an object with its name
+	Extends json.RawMessage `json:"extends"` // a job name, or a list of them
+	Needs   json.RawMessage `json:"needs"`   // a list of job names, or of objects with a job name
+	Script  json.RawMessage `json:"script"`  // a command, or a list of commands (or of lists of them)
+	Trigger json.RawMessage `json:"trigger"` // a project, or an object describing the downstream pipeline
+}
+
+// hidden reports whether the job is hidden, as templates extended by other jobs are
+func (j *gitlabCIJob) hidden() bool { return strings.HasPrefix(j.Name, ".") }
+
+// image returns the name of the image the job runs in
+func (j *gitlabCIJob) image() string {
+	var name string
+	if err := json.Unmarshal(j.Image, &name); err == nil {
+		return name
+	}
+	var image struct {
+		Name string `json:"name"`
+	}
+	_ = json.Unmarshal(j.Image, &image)
+	return image.Name
+}
+
+// stringList returns the strings of a value that's either a string or a (nested) list of them. In lists of objects,
+// the key field of the objects is used (as for needs).
+func stringList(raw json.RawMessage, key string) []string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return []string{s}
+	}
+
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return []string{}
+	}
+
+	var strs = []string{}
+	for _, item := range list {
+		var object map[string]json.RawMessage
+		if key != "" && json.Unmarshal(item, &object) == nil {
+			strs = append(strs, stringList(object[key], "")...)
+			continue
+		}
+		strs = append(strs, stringList(item, key)...)
+	}
+	return strs
+}
+
+// parseGitLabCI parses the jobs of a pipeline configuration, in the order of their names. Jobs without
+// an image of their own run in the default one.
+func parseGitLabCI(contents string) ([]*gitlabCIJob, error) {
+	js, err := yaml.YAMLToJSON([]byte(contents))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse %s", gitlabCIFile)
+	}
+
+	var config map[string]json.RawMessage
+	if err = json.Unmarshal(js, &config); err != nil {
+		return nil, errors.Wrapf(err, "could not parse %s", gitlabCIFile)
+	}
+
+	var defaults struct {
+		Image json.RawMessage `json:"image"`
+	}
+	if len(config["default"]) > 0 {
+		_ = json.Unmarshal(config["default"], &defaults)
+	}
+	if len(defaults.Image) == 0 {
+		defaults.Image = config["image"]
+	}
+
+	var jobs = make(map[string]*gitlabCIJob)
+	for name, raw := range config {
+		if gitlabCIKeywords[name] || !strings.HasPrefix(strings.TrimSpace(string(raw)), "{") {
+			continue
+		}
+
+		var job = &gitlabCIJob{Name: name}
+		if err = json.Unmarshal(raw, job); err != nil {
+			return nil, errors.Wrapf(err, "could not parse job %s of %s", name, gitlabCIFile)
+		}
+		jobs[name] = job
+	}
+
+	var resolved = make([]*gitlabCIJob, 0, len(jobs))
+	for _, job := range jobs {
+		job = extend(job, jobs, 0)
+		if len(job.Image) == 0 {
+			job.Image = defaults.Image
+		}
+		if job.Stage == "" && !job.hidden() {
+			job.Stage = "test"
+		}
+		resolved = append(resolved, job)
+	}
+
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].Name < resolved[j].Name })
+	return resolved, nil
+}
+
+// maxExtendsDepth is the number of levels of inheritance GitLab supports
+const maxExtendsDepth = 11
+
+// extend returns job with the keywords it doesn't set inherited from the jobs it extends (in jobs),
+// the ones of the last job extended taking precedence, as in https://docs.gitlab.com/ee/ci/yaml/#extends
+func extend(job *gitlabCIJob, jobs map[string]*gitlabCIJob, depth int) *gitlabCIJob {
+	var extended = *job
+	if depth >= maxExtendsDepth {
+		return &extended
+	}
+
+	parents := stringList(job.Extends, "")
+	for p := len(parents) - 1; p >= 0; p-- {
+		parent, ok := jobs[parents[p]]
+		if !ok {
+			continue // extending jobs of included files, which aren't read
+		}
+		parent = extend(parent, jobs, depth+1)
+
+		if extended.Stage == "" {
+			extended.Stage = parent.Stage
+		}
+		for _, field := range []struct{ to, from *json.RawMessage }{
+			{&extended.Image, &parent.Image}, {&extended.Needs, &parent.Needs},
+			{&extended.Script, &parent.Script}, {&extended.Trigger, &parent.Trigger},
+		} {
+			if len(*field.to) == 0 {
+				*field.to = *field.from
+			}
+		}
+	}
+	return &extended
+}
+
+type gitlabCIJobsIter struct {
+	jobs  []*gitlabCIJob
+	index int
+}
+
+func (i *gitlabCIJobsIter) Column(ctx vtab.Context, c int) error {
+	current := i.jobs[i.index]
+	switch name := gitlabCIJobsCols[c].Name; name {
+	case "path":
+		ctx.ResultText(gitlabCIFile)
+	case "job":
+		ctx.ResultText(current.Name)
+	case "hidden":
+		ctx.ResultInt(sqlutil.BoolToInt(current.hidden()))
+	case "stage":
+		if current.Stage != "" {
+			ctx.ResultText(current.Stage)
+		}
+	case "image":
+		if image := current.image(); image != "" {
+			ctx.ResultText(image)
+		}
+	case "extends", "needs", "script":
+		var list []string
+		switch name {
+		case "extends":
+			list = stringList(current.Extends, "")
+		case "needs":
+			list = stringList(current.Needs, "job")
+		case "script":
+			list = stringList(current.Script, "")
+		}
+		js, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		ctx.ResultText(string(js))
+	case "trigger":
+		var project string
+		if err := json.Unmarshal(current.Trigger, &project); err == nil {
+			ctx.ResultText(project)
+		} else if len(current.Trigger) > 0 {
+			ctx.ResultText(string(current.Trigger))
+		}
+	}
+	return nil
+}
+
+func (i *gitlabCIJobsIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.jobs) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewGitLabCIJobsModule returns the implementation of a table-valued-function listing the jobs of the GitLab CI/CD pipeline
+// configuration (.gitlab-ci.yml) at ref (the default ref, or HEAD, if not supplied), with their stage, the image they run in,
+// and their script, including the ones inherited from the jobs they extend. Hidden jobs (templates, such as .build) are listed too.
+// Files included by the configuration aren't read.
+func NewGitLabCIJobsModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("gitlab_ci_jobs", gitlabCIJobsCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 9:
+					repoPath = constraint.Value.Text()
+				case 10:
+					ref = constraint.Value.Text()
+				}
+			}
+		}
+
+		tree, err := options.LookupTree(repoPath, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := tree.File(gitlabCIFile)
+		if err != nil {
+			if err == object.ErrFileNotFound {
+				return &gitlabCIJobsIter{index: -1}, nil
+			}
+			return nil, errors.Wrapf(err, "could not lookup %s", gitlabCIFile)
+		}
+
+		contents, err := f.Contents()
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not retrieve contents of %s", gitlabCIFile)
+		}
+
+		jobs, err := parseGitLabCI(contents)
+		if err != nil {
+			return nil, err
+		}
+
+		return &gitlabCIJobsIter{jobs: jobs, index: -1}, nil
+	})
+}
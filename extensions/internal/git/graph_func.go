@@ -0,0 +1,201 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// CommitGraphFn implements the COMMIT_GRAPH(repository, range [, format]) sql function, which renders
+// the commit DAG of a revision range (such as v1.0.0..HEAD, or simply HEAD) as a DOT digraph or as JSON nodes and edges.
+// Only edges between commits within the range are included.
+type CommitGraphFn struct {
+	Options *utils.ModuleOptions
+}
+
+// NewCommitGraphFn returns a new CommitGraphFn implementation
+func NewCommitGraphFn(opt *utils.ModuleOptions) *CommitGraphFn {
+	return &CommitGraphFn{Options: opt}
+}
+
+func (*CommitGraphFn) Deterministic() bool { return false }
+func (*CommitGraphFn) Args() int           { return -1 }
+func (fn *CommitGraphFn) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	if len(values) < 2 || len(values) > 3 {
+		c.ResultError(fmt.Errorf("commit_graph expects a repository, a revision range and optionally a format (dot or json)"))
+		return
+	}
+
+	var format = "dot"
+	if len(values) == 3 && values[2].Text() != "" {
+		format = values[2].Text()
+	}
+	if format != "dot" && format != "json" {
+		c.ResultError(fmt.Errorf("unknown graph format %q, expected dot or json", format))
+		return
+	}
+
+	path := values[0].Text()
+
+	var err error
+	if path == "" {
+		if path, err = fn.Options.GetRepoPath(); err != nil {
+			c.ResultError(err)
+			return
+		}
+	}
+
+	var repo *git.Repository
+	if repo, err = fn.Options.Locator.Open(context.Background(), path); err != nil {
+		c.ResultError(errors.Wrapf(err, "failed to open %q", path))
+		return
+	}
+
+	var commits []*object.Commit
+	if commits, err = commitRange(repo, values[1].Text(), utils.GetDefaultRefFromCtx(fn.Options.Context)); err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	if format == "json" {
+		var out []byte
+		if out, err = json.Marshal(newCommitGraph(commits)); err != nil {
+			c.ResultError(err)
+			return
+		}
+		c.ResultText(string(out))
+	} else {
+		c.ResultText(commitGraphDOT(commits))
+	}
+}
+
+// commitRange returns the commits in a revision range of the form from..to (commits reachable from `to`
+// but not from `from`), or simply `to` (all commits reachable from it). `to` defaults to defaultRef, or HEAD.
+func commitRange(repo *git.Repository, rng, defaultRef string) ([]*object.Commit, error) {
+	from, to, isRange := strings.Cut(rng, "..")
+	if !isRange {
+		from, to = "", rng
+	}
+
+	if to == "" {
+		if to = defaultRef; to == "" {
+			to = "HEAD"
+		}
+	}
+
+	var exclude = make(map[plumbing.Hash]struct{})
+	if from != "" {
+		hash, err := repo.ResolveRevision(plumbing.Revision(from))
+		if err != nil {
+			return nil, errors.Errorf("failed to resolve %q", from)
+		}
+
+		iter, err := repo.Log(&git.LogOptions{From: *hash})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create iterator")
+		}
+
+		err = iter.ForEach(func(c *object.Commit) error { exclude[c.Hash] = struct{}{}; return nil })
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(to))
+	if err != nil {
+		return nil, errors.Errorf("failed to resolve %q", to)
+	}
+
+	iter, err := repo.Log(&git.LogOptions{From: *hash, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create iterator")
+	}
+
+	var commits []*object.Commit
+	err = iter.ForEach(func(c *object.Commit) error {
+		if _, ok := exclude[c.Hash]; !ok {
+			commits = append(commits, c)
+		}
+		return nil
+	})
+	return commits, err
+}
+
+type commitGraphNode struct {
+	Hash        string `json:"hash"`
+	Summary     string `json:"summary"`
+	AuthorName  string `json:"author_name"`
+	AuthorEmail string `json:"author_email"`
+	AuthorWhen  string `json:"author_when"`
+}
+
+type commitGraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type commitGraph struct {
+	Nodes []*commitGraphNode `json:"nodes"`
+	Edges []*commitGraphEdge `json:"edges"`
+}
+
+// newCommitGraph builds the nodes and edges (from child to parent) of the DAG formed by commits
+func newCommitGraph(commits []*object.Commit) *commitGraph {
+	var graph = &commitGraph{Nodes: []*commitGraphNode{}, Edges: []*commitGraphEdge{}}
+
+	var included = make(map[plumbing.Hash]struct{}, len(commits))
+	for _, c := range commits {
+		included[c.Hash] = struct{}{}
+	}
+
+	for _, c := range commits {
+		graph.Nodes = append(graph.Nodes, &commitGraphNode{
+			Hash:        c.Hash.String(),
+			Summary:     summary(c.Message),
+			AuthorName:  c.Author.Name,
+			AuthorEmail: c.Author.Email,
+			AuthorWhen:  c.Author.When.Format(time.RFC3339),
+		})
+
+		for _, parent := range c.ParentHashes {
+			if _, ok := included[parent]; ok {
+				graph.Edges = append(graph.Edges, &commitGraphEdge{From: c.Hash.String(), To: parent.String()})
+			}
+		}
+	}
+
+	return graph
+}
+
+// commitGraphDOT renders the DAG formed by commits in Graphviz's DOT language
+func commitGraphDOT(commits []*object.Commit) string {
+	graph := newCommitGraph(commits)
+
+	var b strings.Builder
+	b.WriteString("digraph commits {\n")
+	b.WriteString("\tnode [shape=box];\n")
+	for _, node := range graph.Nodes {
+		fmt.Fprintf(&b, "\t%q [label=%q];\n", node.Hash, node.Hash[:7]+" "+node.Summary)
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&b, "\t%q -> %q;\n", edge.From, edge.To)
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// summary returns the first line of a commit message
+func summary(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return strings.TrimSpace(line)
+}
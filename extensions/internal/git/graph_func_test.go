@@ -0,0 +1,69 @@
+package git_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCommitGraphFnDOT(t *testing.T) {
+	db := Connect(t, Memory)
+	repo := "https://github.com/mergestat/mergestat-lite"
+
+	var graph string
+	if err := db.QueryRow("SELECT commit_graph(?, ?)", repo, "2359c9a9ba0ba8aa694601ff12538c4e74b82cd5~3..2359c9a9ba0ba8aa694601ff12538c4e74b82cd5").Scan(&graph); err != nil {
+		t.Fatalf("failed to execute query: %v", err)
+	}
+
+	if !strings.HasPrefix(graph, "digraph commits {") || !strings.HasSuffix(graph, "}\n") {
+		t.Fatalf("expected a DOT digraph, got: %s", graph)
+	}
+
+	if !strings.Contains(graph, `"2359c9a9ba0ba8aa694601ff12538c4e74b82cd5" [label=`) {
+		t.Fatalf("expected the tip of the range to be a node, got: %s", graph)
+	}
+}
+
+func TestCommitGraphFnJSON(t *testing.T) {
+	db := Connect(t, Memory)
+	repo := "https://github.com/mergestat/mergestat-lite"
+
+	var out string
+	if err := db.QueryRow("SELECT commit_graph(?, ?, 'json')", repo, "2359c9a9ba0ba8aa694601ff12538c4e74b82cd5~3..2359c9a9ba0ba8aa694601ff12538c4e74b82cd5").Scan(&out); err != nil {
+		t.Fatalf("failed to execute query: %v", err)
+	}
+
+	var graph struct {
+		Nodes []struct {
+			Hash string `json:"hash"`
+		} `json:"nodes"`
+		Edges []struct {
+			From string `json:"from"`
+			To   string `json:"to"`
+		} `json:"edges"`
+	}
+	if err := json.Unmarshal([]byte(out), &graph); err != nil {
+		t.Fatalf("failed to decode graph: %v", err)
+	}
+
+	var expected int
+	if err := db.QueryRow("SELECT count(*) FROM commits(?, '2359c9a9ba0ba8aa694601ff12538c4e74b82cd5') WHERE hash NOT IN (SELECT hash FROM commits(?, '2359c9a9ba0ba8aa694601ff12538c4e74b82cd5~3'))", repo, repo).Scan(&expected); err != nil {
+		t.Fatalf("failed to execute query: %v", err)
+	}
+
+	if len(graph.Nodes) != expected {
+		t.Fatalf("expected %d nodes, got %d", expected, len(graph.Nodes))
+	}
+
+	var nodes = make(map[string]bool)
+	for _, node := range graph.Nodes {
+		nodes[node.Hash] = true
+	}
+
+	// edges must only connect commits within the range
+	for _, edge := range graph.Edges {
+		if !nodes[edge.From] || !nodes[edge.To] {
+			t.Fatalf("edge %s -> %s leaves the range", edge.From, edge.To)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package git
+
+import (
+	"context"
+	"io"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var headCols = []vtab.Column{
+	{Name: "name", Type: "TEXT"},
+	{Name: "full_name", Type: "TEXT"},
+	{Name: "hash", Type: "TEXT"},
+	{Name: "detached", Type: "BOOLEAN"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// head describes where HEAD points in a repository
+type head struct {
+	// branch is the full name of the branch HEAD is attached to (empty if HEAD is detached)
+	branch plumbing.ReferenceName
+	// hash is the commit HEAD resolves to (zero if HEAD is attached to a branch without any commit yet)
+	hash plumbing.Hash
+}
+
+type headIter struct {
+	head *head
+	done bool
+}
+
+func (i *headIter) Column(ctx vtab.Context, c int) error {
+	switch headCols[c].Name {
+	case "name":
+		if i.head.branch != "" {
+			ctx.ResultText(i.head.branch.Short())
+		}
+	case "full_name":
+		if i.head.branch != "" {
+			ctx.ResultText(i.head.branch.String())
+		}
+	case "hash":
+		if !i.head.hash.IsZero() {
+			ctx.ResultText(i.head.hash.String())
+		}
+	case "detached":
+		if i.head.branch == "" {
+			ctx.ResultInt(1)
+		} else {
+			ctx.ResultInt(0)
+		}
+	}
+	return nil
+}
+
+func (i *headIter) Next() (vtab.Row, error) {
+	if i.done {
+		return nil, io.EOF
+	}
+	i.done = true
+	return i, nil
+}
+
+// NewHeadModule returns the implementation of a table-valued-function returning a single row describing where HEAD
+// points in a repository: the branch it's attached to (NULL if detached), the commit it resolves to
+// (NULL on a branch without any commit yet) and whether it is detached.
+func NewHeadModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("head", headCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 4 {
+				repoPath = constraint.Value.Text()
+			}
+		}
+
+		var err error
+		if repoPath == "" {
+			if repoPath, err = options.GetRepoPath(); err != nil {
+				return nil, err
+			}
+		}
+
+		var repo *git.Repository
+		if repo, err = options.Locator.Open(context.Background(), repoPath); err != nil {
+			return nil, errors.Wrapf(err, "failed to open %q", repoPath)
+		}
+
+		var h *head
+		if h, err = resolveHead(repo); err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve HEAD of %q", repoPath)
+		}
+
+		return &headIter{head: h}, nil
+	})
+}
+
+// resolveHead looks up where HEAD points in repo, without failing on branches that don't have any commit yet
+// (as in freshly initialized repositories)
+func resolveHead(repo *git.Repository) (*head, error) {
+	ref, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref.Type() == plumbing.HashReference {
+		return &head{hash: ref.Hash()}, nil
+	}
+
+	var h = &head{branch: ref.Target()}
+	if ref, err = repo.Reference(ref.Target(), true); err == nil {
+		h.hash = ref.Hash()
+	} else if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, err
+	}
+	return h, nil
+}
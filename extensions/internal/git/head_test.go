@@ -0,0 +1,68 @@
+package git_test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestHead(t *testing.T) {
+	db := Connect(t, Memory)
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to initialize repository: %v", err)
+	}
+
+	type result struct {
+		name, fullName, hash sql.NullString
+		detached             bool
+	}
+	head := func() (r result) {
+		t.Helper()
+		if err := db.QueryRow("SELECT name, full_name, hash, detached FROM head(?)", dir).Scan(&r.name, &r.fullName, &r.hash, &r.detached); err != nil {
+			t.Fatalf("failed to execute query: %v", err.Error())
+		}
+		return r
+	}
+
+	// a branch without any commit yet
+	if r := head(); r.name.String != "master" || r.fullName.String != "refs/heads/master" || r.hash.Valid || r.detached {
+		t.Fatalf("unexpected HEAD of an empty repository: %+v", r)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = worktree.Add("README.md"); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := worktree.Commit("initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	if r := head(); r.name.String != "master" || r.hash.String != hash.String() || r.detached {
+		t.Fatalf("unexpected HEAD attached to a branch: %+v", r)
+	}
+
+	if err = repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, hash)); err != nil {
+		t.Fatal(err)
+	}
+	if r := head(); r.name.Valid || r.fullName.Valid || r.hash.String != hash.String() || !r.detached {
+		t.Fatalf("unexpected detached HEAD: %+v", r)
+	}
+}
@@ -0,0 +1,80 @@
+package git_test
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRepositoryPushdownInJoins(t *testing.T) {
+	db := Connect(t, Memory)
+	_, bare := cloneFixtures(t)
+
+	// the working directory of the test is not the cloned repository, so the inner tables
+	// can only resolve the outer table's commits if the repository is propagated to them
+	var queries = map[string][2]string{
+		"stats": {
+			"SELECT count(*) FROM commits(?) c JOIN stats s ON s.rev = c.hash WHERE c.hash = '2359c9a9ba0ba8aa694601ff12538c4e74b82cd5'",
+			"SELECT count(*) FROM commits(?1) c JOIN stats s ON s.repository = ?1 AND s.rev = c.hash WHERE c.hash = '2359c9a9ba0ba8aa694601ff12538c4e74b82cd5'",
+		},
+		"files": {
+			"SELECT count(*) FROM commits(?) c JOIN files f ON f.rev = c.hash WHERE c.parents = 0",
+			"SELECT count(*) FROM commits(?1) c JOIN files f ON f.repository = ?1 AND f.rev = c.hash WHERE c.parents = 0",
+		},
+	}
+
+	for table, q := range queries {
+		var implicit, explicit int
+		if err := db.QueryRow(q[0], bare).Scan(&implicit); err != nil {
+			t.Fatalf("failed to join commits with %s: %v", table, err)
+		}
+
+		if err := db.QueryRow(q[1], bare).Scan(&explicit); err != nil {
+			t.Fatalf("failed to join commits with %s: %v", table, err)
+		}
+
+		if implicit == 0 || implicit != explicit {
+			t.Fatalf("expected %d rows from %s with the repository propagated, got %d", explicit, table, implicit)
+		}
+	}
+}
+
+func TestRepositoryPushdownIsPerConnection(t *testing.T) {
+	db := Connect(t, Memory)
+	_, bare := cloneFixtures(t)
+
+	// files without a repository reads the default one (the repository of the working directory)
+	var expected int
+	if err := db.QueryRow("SELECT count(*) FROM files()").Scan(&expected); err != nil {
+		t.Fatalf("failed to list the files of the default repository: %v", err)
+	}
+
+	outer, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer outer.Close()
+	other, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	// a cursor over the commits of the clone stays open on one connection...
+	rows, err := outer.QueryContext(context.Background(), "SELECT hash FROM commits(?)", bare)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatalf("expected commits in %s: %v", bare, rows.Err())
+	}
+
+	// ...while a statement on another connection doesn't pick its repository up
+	var count int
+	if err = other.QueryRowContext(context.Background(), "SELECT count(*) FROM files()").Scan(&count); err != nil {
+		t.Fatalf("failed to list the files of the default repository: %v", err)
+	}
+	if count != expected {
+		t.Fatalf("expected the %d files of the default repository, got %d", expected, count)
+	}
+}
@@ -2,13 +2,20 @@ package git
 
 import (
 	"context"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/native"
 	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/extensions/services"
 	"github.com/mergestat/mergestat-lite/pkg/mailmap"
 	"github.com/pkg/errors"
 	"go.riyazali.net/sqlite"
@@ -38,8 +45,16 @@ func (mod *logModule) Connect(_ *sqlite.Conn, _ []string, declare func(string) e
 
 			repository 	HIDDEN,
 			ref 		HIDDEN,
-			PRIMARY KEY ( hash )
-		) WITHOUT ROWID`
+			backend 	HIDDEN,
+			no_merges 	HIDDEN,
+			merges_only HIDDEN,
+			on_error 	HIDDEN,
+			hint 		HIDDEN,
+
+			author_tz_offset_minutes	INT,
+			author_local_hour 		INT,
+			error 						TEXT
+		)`
 
 	return &gitLogTable{ModuleOptions: mod.ModuleOptions}, declare(schema)
 }
@@ -80,8 +95,9 @@ func (tab *gitLogTable) Open() (sqlite.VirtualCursor, error) {
 //	and op code is an integer constant for the operation.
 //
 //	A potential issue with such framing is the small count of columns we can map,
-//	which comes to about 2^4 = 16 .. we have already got 11 columns in current implementation.
-//	And so, this contract must be revisited if we exceed the count of columns.
+//	which comes to about 2^4 = 16 .. we have already got 19 columns in current implementation.
+//	Only the columns that constraints are passed for need to fit, which is why columns that can't be filtered on
+//	are declared after the hidden ones. This contract must be revisited if we exceed the count of filterable columns.
 func (tab *gitLogTable) BestIndex(input *sqlite.IndexInfoInput) (*sqlite.IndexInfoOutput, error) {
 	var argv = 0
 	var bitmap []byte
@@ -98,8 +114,11 @@ func (tab *gitLogTable) BestIndex(input *sqlite.IndexInfoInput) (*sqlite.IndexIn
 			return nil, sqlite.SQLITE_CONSTRAINT
 		}
 
-		// if repository is provided, it must be usable
-		if idx == 9 && !constraint.Usable {
+		// if repository, ref, backend, no_merges, merges_only, on_error or hint is provided, it must be usable. These are the arguments
+		// of the table-valued function form, and might be supplied by an outer table in a join
+		// (as in `FROM repos_in('~/src') r, commits(r.path)`), in which case sqlite must pick a plan
+		// where the outer table is visited first.
+		if idx >= 9 && idx <= 15 && !constraint.Usable {
 			return nil, sqlite.SQLITE_CONSTRAINT
 		}
 
@@ -119,15 +138,16 @@ func (tab *gitLogTable) BestIndex(input *sqlite.IndexInfoInput) (*sqlite.IndexIn
 				out.IdxFlags |= sqlite.INDEX_SCAN_UNIQUE // we only visit at most one row or commit
 			}
 
-		// user has specified which repository and / or reference to use
-		case (idx == 9 || idx == 10) && constraint.Op == sqlite.INDEX_CONSTRAINT_EQ:
+		// user has specified which repository, reference and / or backend to use, whether to skip merges, how to handle errors,
+		// and hints on how to walk the history
+		case idx >= 9 && idx <= 15 && constraint.Op == sqlite.INDEX_CONSTRAINT_EQ:
 			{
 				set(1, idx)
 				out.ConstraintUsage[i] = &sqlite.ConstraintUsage{ArgvIndex: argv, Omit: true}
 			}
 
-		// user has specified < or  > constraint on committer_when column
-		case idx == 7 && (constraint.Op == sqlite.INDEX_CONSTRAINT_LT || constraint.Op == sqlite.INDEX_CONSTRAINT_GT):
+		// user has specified < or  > constraint on committer_when or author_when column
+		case (idx == 4 || idx == 7) && (constraint.Op == sqlite.INDEX_CONSTRAINT_LT || constraint.Op == sqlite.INDEX_CONSTRAINT_GT):
 			{
 				if constraint.Op == sqlite.INDEX_CONSTRAINT_LT {
 					set(2, idx)
@@ -137,6 +157,27 @@ func (tab *gitLogTable) BestIndex(input *sqlite.IndexInfoInput) (*sqlite.IndexIn
 				out.ConstraintUsage[i] = &sqlite.ConstraintUsage{ArgvIndex: argv}
 			}
 
+		// the query is limited to a number of rows (as in SELECT * FROM commits LIMIT 10), which the history is walked for,
+		// as the latest commits come first then. sqlite still applies the limit itself.
+		case constraint.Op == sqlite.INDEX_CONSTRAINT_LIMIT:
+			{
+				set(6, 0)
+				out.ConstraintUsage[i] = &sqlite.ConstraintUsage{ArgvIndex: argv}
+			}
+
+		// user has specified a LIKE or REGEXP constraint on the message column. Commits that don't match are skipped
+		// in the filter routine, but sqlite still checks the constraint itself (as LIKE may be made case-sensitive,
+		// and REGEXP may be backed by another function than ours)
+		case idx == 1 && (constraint.Op == sqlite.INDEX_CONSTRAINT_LIKE || constraint.Op == sqlite.INDEX_CONSTRAINT_REGEXP):
+			{
+				if constraint.Op == sqlite.INDEX_CONSTRAINT_LIKE {
+					set(4, idx)
+				} else {
+					set(5, idx)
+				}
+				out.ConstraintUsage[i] = &sqlite.ConstraintUsage{ArgvIndex: argv}
+			}
+
 		default:
 			argv -= 1 // constraint not used .. decrement back the argv
 		}
@@ -146,6 +187,15 @@ func (tab *gitLogTable) BestIndex(input *sqlite.IndexInfoInput) (*sqlite.IndexIn
 	// if the user specifies an ORDER BY committer_when DESC we can signal to sqlite3
 	// that the output would already be ordered and it doesn't have to program a separate sort routine
 	if len(input.OrderBy) == 1 && input.OrderBy[0].ColumnIndex == 7 && input.OrderBy[0].Desc {
+		out.IndexNumber = orderByCommitterWhen
+		out.OrderByConsumed = true
+	}
+
+	// rebased histories make the committer date a poor proxy for when the work was done,
+	// so we also support ordering by author date. As author dates aren't monotonic along the history
+	// this requires a full traversal and sort in the filter routine, signalled using the index number.
+	if len(input.OrderBy) == 1 && input.OrderBy[0].ColumnIndex == 4 && input.OrderBy[0].Desc {
+		out.IndexNumber = orderByAuthorWhen
 		out.OrderByConsumed = true
 	}
 
@@ -155,6 +205,14 @@ func (tab *gitLogTable) BestIndex(input *sqlite.IndexInfoInput) (*sqlite.IndexIn
 	return out, nil
 }
 
+const (
+	// orderByAuthorWhen is the index number used to request commits ordered by author date (descending)
+	orderByAuthorWhen = 1
+	// orderByCommitterWhen is the index number used when commits are to be returned in the order of the history
+	// (by descending committer date), as they are when walking it
+	orderByCommitterWhen = 2
+)
+
 type gitLogCursor struct {
 	*utils.ModuleOptions
 
@@ -163,19 +221,77 @@ type gitLogCursor struct {
 
 	commit  *object.Commit // the current commit
 	commits object.CommitIter
+	rowid   int64 // the rowid of the current commit, increasing from 1 with every commit returned by the cursor
 
 	mm mailmap.MailMap
+
+	noMerges   bool // skip commits with more than one parent (like git log --no-merges)
+	mergesOnly bool // skip commits with less than two parents (like git log --merges)
+
+	authorSince, authorUntil *time.Time // skip commits authored outside of this window
+
+	messagePatterns []*regexp.Regexp // skip commits with a message that doesn't match all of these
+
+	limit int64 // stop after returning this many commits, if set (see the limit hint)
+
+	onError string // how to handle errors reading the repository (see utils.OnErrorFail)
+	err     error  // the error reported by the current row, if any (in the null on_error mode)
+
+	releaseRepo func() // releases the repository pushed onto the statement context
 }
 
-func (cur *gitLogCursor) Filter(_ int, s string, values ...sqlite.Value) (err error) {
+func (cur *gitLogCursor) Filter(idxNum int, s string, values ...sqlite.Value) (err error) {
+	var onError string
+	var bitmap, _ = dec(s)
+	for i, val := range values {
+		if bitmap[i] == 0b00011110 {
+			onError = val.Text()
+		}
+	}
+
+	if onError == "" {
+		cur.onError, err = utils.GetOnErrorFromCtx(cur.Context)
+	} else {
+		cur.onError, err = utils.ParseOnError(onError)
+	}
+	if err != nil {
+		return err
+	}
+
+	cur.rowid, cur.err = 0, nil
+	return cur.handleError(cur.filter(idxNum, s, values...))
+}
+
+// handleError handles an error reading the repository, according to the on_error mode: the error either fails the query,
+// or ends the scan of the repository, with a last row reporting the error (in the null mode)
+func (cur *gitLogCursor) handleError(err error) error {
+	if err == nil || cur.onError == utils.OnErrorFail {
+		return err
+	}
+
+	cur.Logger.Warn().Err(err).Str("on-error", cur.onError).Msg("ending git log scan early")
+	cur.commit = nil
+	if cur.onError == utils.OnErrorNull {
+		cur.err = err
+		cur.rowid++
+	}
+	return nil
+}
+
+func (cur *gitLogCursor) filter(idxNum int, s string, values ...sqlite.Value) (err error) {
 	logger := cur.Logger.With().Str("module", "git-log").Logger()
 	defer func() {
 		logger.Debug().Msg("running git log filter")
 	}()
 
 	// values extracted from constraints
-	var hash, path, refName string
-	var start, end string
+	var hash, path, refName, backend, hint string
+	var start, end, authorStart, authorEnd string
+	var limited bool
+	cur.noMerges, cur.mergesOnly = false, false
+	cur.authorSince, cur.authorUntil = nil, nil
+	cur.messagePatterns = nil
+	cur.mm = nil
 
 	var bitmap, _ = dec(s)
 	for i, val := range values {
@@ -186,17 +302,45 @@ func (cur *gitLogCursor) Filter(_ int, s string, values ...sqlite.Value) (err er
 			path = val.Text()
 		case 0b00011010:
 			refName = val.Text()
+		case 0b00011011:
+			backend = val.Text()
+		case 0b00011100:
+			cur.noMerges = val.Int() != 0
+		case 0b00011101:
+			cur.mergesOnly = val.Int() != 0
+		case 0b00011111:
+			hint = val.Text()
 		case 0b0100111:
 			end = val.Text()
 		case 0b0110111:
 			start = val.Text()
+		case 0b00100100:
+			authorEnd = val.Text()
+		case 0b00110100:
+			authorStart = val.Text()
+		case 0b01000001:
+			cur.messagePatterns = append(cur.messagePatterns, likePattern(val.Text()))
+		case 0b01010001:
+			var re *regexp.Regexp
+			if re, err = regexp.Compile(val.Text()); err != nil {
+				return errors.Wrapf(err, "invalid message pattern")
+			}
+			cur.messagePatterns = append(cur.messagePatterns, re)
+		case 0b01100000:
+			limited = true
 		}
 	}
 
+	var hints *logHints
+	if hints, err = parseLogHints(hint); err != nil {
+		return err
+	}
+	cur.limit = hints.limit
+
 	var repo *git.Repository
 	{ // open the git repository
 		if path == "" {
-			path, err = utils.GetDefaultRepoFromCtx(cur.Context)
+			path, err = cur.GetRepoPath()
 			if err != nil {
 				return err
 			}
@@ -207,10 +351,18 @@ func (cur *gitLogCursor) Filter(_ int, s string, values ...sqlite.Value) (err er
 		}
 		cur.repo = repo
 		logger = logger.With().Str("repo-disk-path", path).Logger()
+
+		// make the repository available to inner tables in a join, which weren't given one explicitly
+		cur.release()
+		cur.releaseRepo = cur.Statement.PushRepo(path)
 	}
 
 	var opts = &git.LogOptions{Order: git.LogOrderDefault}
 
+	if refName == "" {
+		refName = utils.GetDefaultRefFromCtx(cur.Context)
+	}
+
 	rev := plumbing.Revision(refName)
 	cur.rev = &rev
 	if refName != "" {
@@ -229,46 +381,27 @@ func (cur *gitLogCursor) Filter(_ int, s string, values ...sqlite.Value) (err er
 
 	logger = logger.With().Str("revision", opts.From.String()).Logger()
 
-	if skipMailmap, _ := cur.Context.GetBool("skipMailmap"); !skipMailmap {
-		var c *object.Commit
-		if c, err = repo.CommitObject(opts.From); err != nil {
-			return errors.Wrapf(err, "could not lookup commit")
-		}
-		var t *object.Tree
-		if t, err = c.Tree(); err != nil {
-			return errors.Wrapf(err, "could not lookup tree")
-		}
-
-		var f *object.File
-		if f, err = t.File(".mailmap"); err != nil {
-			if err != object.ErrFileNotFound {
-				return errors.Wrapf(err, "could not lookup mailmap file")
-			} else {
-				goto skip_mailmap
-			}
-		}
-
-		var m string
-		if m, err = f.Contents(); err != nil {
-			if err != nil {
-				return errors.Wrapf(err, "could not retrieve contents of mailmap file")
-			}
-		}
-
-		var mm mailmap.MailMap
-		if mm, err = mailmap.Parse(m); err != nil {
-			return errors.Wrapf(err, "could not parse mailmap file")
+	skipMailmap := utils.GetSkipMailmapFromCtx(cur.Context)
+	if hints.skipMailmap != nil {
+		skipMailmap = *hints.skipMailmap
+	}
+	if !skipMailmap {
+		if cur.mm, err = readMailmap(cur.Mailmap, repo, opts.From); err != nil {
+			return err
 		}
-		logger.Info().Msg("found and parsed .mailmap file")
-		cur.mm = mm
 	}
 
-skip_mailmap:
+	// commits are read the way git reads them, honoring replace refs and grafts
+	noReplace := utils.GetNoReplaceObjectsFromCtx(cur.Context)
+	var replaced *git.Repository
+	if replaced, err = withReplaceObjects(repo, noReplace); err != nil {
+		return err
+	}
 
 	if hash != "" {
 		// we only need to get a single commit
-		cur.commits = object.NewCommitIter(repo.Storer, storer.NewEncodedObjectLookupIter(
-			repo.Storer, plumbing.CommitObject, []plumbing.Hash{plumbing.NewHash(hash)}))
+		cur.commits = object.NewCommitIter(replaced.Storer, storer.NewEncodedObjectLookupIter(
+			replaced.Storer, plumbing.CommitObject, []plumbing.Hash{plumbing.NewHash(hash)}))
 		logger = logger.With().Str("hash", hash).Logger()
 		return cur.Next()
 	}
@@ -289,14 +422,71 @@ skip_mailmap:
 		}
 	}
 
-	if cur.commits, err = repo.Log(opts); err != nil {
+	if authorStart != "" {
+		if t, err := time.Parse(time.RFC3339, authorStart); err == nil {
+			cur.authorSince = &t
+			logger = logger.With().Str("author-since", t.String()).Logger()
+		}
+	}
+
+	if authorEnd != "" {
+		if t, err := time.Parse(time.RFC3339, authorEnd); err == nil {
+			cur.authorUntil = &t
+			logger = logger.With().Str("author-until", t.String()).Logger()
+		}
+	}
+
+	if backend == "" {
+		if backend, err = utils.GetGitBackendFromCtx(cur.Context); err != nil {
+			return err
+		}
+	} else if backend, err = utils.ParseGitBackend(backend); err != nil {
+		return err
+	}
+	logger = logger.With().Str("backend", backend).Logger()
+
+	switch {
+	case hints.firstParent:
+		// all of the backends walk the whole history, so the first-parent history is walked here instead
+		cur.commits = newFirstParentIter(replaced, opts)
+		logger = logger.With().Bool("first-parent", true).Logger()
+	case backend == utils.BackendLibgit2 && replaced == repo:
+		// libgit2 doesn't honor replace refs and grafts, so histories altered by them are walked by go-git (below) instead
+		cur.commits, err = native.NewCommitIter(cur.ModuleOptions, path, opts)
+	case backend == utils.BackendCLI:
+		cur.commits, err = newCLICommitIter(repo, opts, noReplace)
+	case idxNum == 0 && !limited && cur.limit == 0 && opts.Since == nil && opts.Until == nil &&
+		cur.authorSince == nil && cur.authorUntil == nil && replaced == repo:
+		// whole-history scans, that aren't ordered, limited or bounded by date, read the commits straight out of the packfiles,
+		// rather than walking the history. Histories altered by replace refs or grafts are always walked.
+		cur.commits, err = newPackfileCommitIter(repo, opts.From)
+		logger = logger.With().Bool("packfile-scan", true).Logger()
+	default:
+		cur.commits, err = replaced.Log(opts)
+	}
+	if err != nil {
 		return errors.Wrap(err, "failed to create iterator")
 	}
 
+	if idxNum == orderByAuthorWhen {
+		if cur.commits, err = sortByAuthorWhen(cur.commits); err != nil {
+			return errors.Wrap(err, "failed to sort commits by author date")
+		}
+		logger = logger.With().Bool("order-by-author-when", true).Logger()
+	}
+
 	return cur.Next()
 }
 
 func (cur *gitLogCursor) Column(c *sqlite.VirtualTableContext, col int) error {
+	if cur.err != nil {
+		// all columns but the error are NULL in rows reporting errors
+		if col == 18 {
+			c.ResultText(cur.err.Error())
+		}
+		return nil
+	}
+
 	commit := cur.commit
 
 	properCommitterSig := cur.mm.Lookup(mailmap.NameAndEmail{Name: commit.Committer.Name, Email: commit.Committer.Email})
@@ -321,27 +511,265 @@ func (cur *gitLogCursor) Column(c *sqlite.VirtualTableContext, col int) error {
 		c.ResultText(commit.Committer.When.Format(time.RFC3339))
 	case 8:
 		c.ResultInt(commit.NumParents())
+	case 16:
+		// the author date keeps the offset of the original signature, rather than being converted to UTC
+		_, offset := commit.Author.When.Zone()
+		c.ResultInt(offset / 60)
+	case 17:
+		c.ResultInt(commit.Author.When.Hour())
 	}
 
 	return nil
 }
 
-func (cur *gitLogCursor) Next() (err error) {
-	if cur.commit, err = cur.commits.Next(); err != nil {
-		// check for ErrObjectNotFound to ensure we don't crash
-		// if the user provided hash did not point to a commit
-		if !eof(err) && err != plumbing.ErrObjectNotFound {
-			return err
+func (cur *gitLogCursor) Next() error {
+	// a row reporting an error is always the last one
+	if cur.err != nil {
+		cur.err = nil
+		return nil
+	}
+	return cur.handleError(cur.next())
+}
+
+func (cur *gitLogCursor) next() (err error) {
+	if cur.limit > 0 && cur.rowid >= cur.limit {
+		cur.commit = nil
+		return nil
+	}
+
+	for {
+		if cur.commit, err = cur.commits.Next(); err != nil {
+			// check for ErrObjectNotFound to ensure we don't crash
+			// if the user provided hash did not point to a commit
+			if !eof(err) && err != plumbing.ErrObjectNotFound {
+				return err
+			}
+			return nil
 		}
+		cur.Stats.Count(services.CommitsWalked, 1)
+
+		// skip over merge (or non-merge) commits here, rather than have sqlite filter on parents after reading every column
+		if (cur.noMerges && cur.commit.NumParents() > 1) || (cur.mergesOnly && cur.commit.NumParents() < 2) {
+			continue
+		}
+
+		// author dates aren't monotonic along the history, so the whole history is walked and filtered here
+		if (cur.authorSince != nil && cur.commit.Author.When.Before(*cur.authorSince)) ||
+			(cur.authorUntil != nil && cur.commit.Author.When.After(*cur.authorUntil)) {
+			continue
+		}
+
+		if !cur.matchMessage(cur.commit.Message) {
+			continue
+		}
+
+		cur.rowid++
+		return nil
 	}
-	return nil
 }
 
-func (cur *gitLogCursor) Eof() bool             { return cur.commit == nil }
-func (cur *gitLogCursor) Rowid() (int64, error) { return int64(0), nil }
+// matchMessage reports whether message matches all of the message patterns of the cursor
+func (cur *gitLogCursor) matchMessage(message string) bool {
+	for _, re := range cur.messagePatterns {
+		if !re.MatchString(message) {
+			return false
+		}
+	}
+	return true
+}
+
+// readMailmap returns the parsed .mailmap file in the tree of the given commit (empty if there's none), from cache if
+// the same file was read already
+func readMailmap(cache *utils.MailmapCache, repo *git.Repository, hash plumbing.Hash) (mailmap.MailMap, error) {
+	c, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not lookup commit")
+	}
+
+	var t *object.Tree
+	if t, err = c.Tree(); err != nil {
+		return nil, errors.Wrapf(err, "could not lookup tree")
+	}
+
+	var f *object.File
+	if f, err = t.File(".mailmap"); err != nil {
+		if err == object.ErrFileNotFound {
+			return cache.Get("", nil)
+		}
+		return nil, errors.Wrapf(err, "could not lookup mailmap file")
+	}
+
+	return cache.Get(f.Hash.String(), func() (string, error) {
+		m, err := f.Contents()
+		if err != nil {
+			return "", errors.Wrapf(err, "could not retrieve contents of mailmap file")
+		}
+		return m, nil
+	})
+}
+
+// logHints are the hints passed to the commits table (in its hint argument), controlling how the history is walked
+type logHints struct {
+	skipMailmap *bool // whether to skip mapping the authors and committers through .mailmap, if set by a (no-)mailmap hint
+	firstParent bool  // only follow the first parent of merge commits (like git log --first-parent)
+	limit       int64 // stop after returning this many commits, if set
+}
+
+// parseLogHints parses a comma separated list of hints, as in 'no-mailmap,first-parent,limit=1000'. The mailmap and no-mailmap
+// hints override the skipMailmap context value for the query, so that mapped and raw identities can be compared side by side.
+func parseLogHints(s string) (*logHints, error) {
+	var hints = &logHints{}
+	for _, hint := range strings.Split(s, ",") {
+		name, value, hasValue := strings.Cut(strings.TrimSpace(hint), "=")
+		name, value = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(value)
+
+		switch {
+		case name == "" && !hasValue:
+			continue
+		case (name == "mailmap" || name == "no-mailmap") && !hasValue:
+			var skip = name == "no-mailmap"
+			hints.skipMailmap = &skip
+		case name == "first-parent" && !hasValue:
+			hints.firstParent = true
+		case name == "limit" && hasValue:
+			limit, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || limit <= 0 {
+				return nil, errors.Errorf("invalid limit hint %q: expected a positive number of commits", value)
+			}
+			hints.limit = limit
+		default:
+			return nil, errors.Errorf("invalid hint %q: expected mailmap, no-mailmap, first-parent or limit=<commits>", strings.TrimSpace(hint))
+		}
+	}
+	return hints, nil
+}
+
+// likePattern converts a pattern of the LIKE operator into a regular expression. It is case-insensitive,
+// as LIKE is by default, with % matching any sequence of characters and _ any single character.
+func likePattern(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func (cur *gitLogCursor) Eof() bool             { return cur.commit == nil && cur.err == nil }
+func (cur *gitLogCursor) Rowid() (int64, error) { return cur.rowid, nil }
 func (cur *gitLogCursor) Close() error {
+	cur.release()
 	if cur.commits != nil {
 		cur.commits.Close()
 	}
 	return nil
 }
+
+func (cur *gitLogCursor) release() {
+	if cur.releaseRepo != nil {
+		cur.releaseRepo()
+		cur.releaseRepo = nil
+	}
+}
+
+// sortByAuthorWhen drains iter and returns an iterator over its commits, ordered by descending author date
+func sortByAuthorWhen(iter object.CommitIter) (object.CommitIter, error) {
+	var commits []*object.Commit
+	if err := iter.ForEach(func(c *object.Commit) error { commits = append(commits, c); return nil }); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(commits, func(i, j int) bool { return commits[i].Author.When.After(commits[j].Author.When) })
+	return &commitSliceIter{commits: commits}, nil
+}
+
+// commitSliceIter is an object.CommitIter over an in-memory slice of commits
+type commitSliceIter struct {
+	commits []*object.Commit
+	pos     int
+}
+
+func (iter *commitSliceIter) Next() (*object.Commit, error) {
+	if iter.pos >= len(iter.commits) {
+		return nil, io.EOF
+	}
+	iter.pos++
+	return iter.commits[iter.pos-1], nil
+}
+
+func (iter *commitSliceIter) ForEach(fn func(*object.Commit) error) error {
+	for {
+		c, err := iter.Next()
+		if eof(err) {
+			return nil
+		}
+
+		if err = fn(c); err == storer.ErrStop {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (iter *commitSliceIter) Close() { iter.commits = nil }
+
+// firstParentIter is an object.CommitIter walking the first-parent history of a commit (like git log --first-parent),
+// skipping the commits committed outside of a window
+type firstParentIter struct {
+	repo         *git.Repository
+	next         plumbing.Hash
+	since, until *time.Time
+}
+
+// newFirstParentIter returns an iterator over the first-parent history of opts.From, committed between opts.Since and opts.Until
+func newFirstParentIter(repo *git.Repository, opts *git.LogOptions) *firstParentIter {
+	return &firstParentIter{repo: repo, next: opts.From, since: opts.Since, until: opts.Until}
+}
+
+func (iter *firstParentIter) Next() (*object.Commit, error) {
+	for !iter.next.IsZero() {
+		commit, err := iter.repo.CommitObject(iter.next)
+		if err != nil {
+			return nil, err
+		}
+
+		iter.next = plumbing.ZeroHash
+		if len(commit.ParentHashes) > 0 {
+			iter.next = commit.ParentHashes[0]
+		}
+
+		if (iter.since != nil && commit.Committer.When.Before(*iter.since)) || (iter.until != nil && commit.Committer.When.After(*iter.until)) {
+			continue
+		}
+		return commit, nil
+	}
+	return nil, io.EOF
+}
+
+func (iter *firstParentIter) ForEach(fn func(*object.Commit) error) error {
+	for {
+		c, err := iter.Next()
+		if eof(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err = fn(c); err == storer.ErrStop {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (iter *firstParentIter) Close() { iter.next = plumbing.ZeroHash }
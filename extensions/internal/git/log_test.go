@@ -2,9 +2,17 @@ package git_test
 
 import (
 	"database/sql"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 func TestSelectAllCommits(t *testing.T) {
@@ -21,8 +29,9 @@ func TestSelectAllCommits(t *testing.T) {
 		var hash, message string
 		var authorName, authorEmail, authorWhen string
 		var committerName, committerEmail, committerWhen string
-		var parents int
-		err = rows.Scan(&hash, &message, &authorName, &authorEmail, &authorWhen, &committerName, &committerEmail, &committerWhen, &parents)
+		var parents, authorTZOffset, authorLocalHour int
+		var commitErr sql.NullString
+		err = rows.Scan(&hash, &message, &authorName, &authorEmail, &authorWhen, &committerName, &committerEmail, &committerWhen, &parents, &authorTZOffset, &authorLocalHour, &commitErr)
 		if err != nil {
 			t.Fatalf("failed to scan resultset: %v", err)
 		}
@@ -110,3 +119,515 @@ func TestDefaultCases(t *testing.T) {
 		}
 	})
 }
+
+func TestCommitBackends(t *testing.T) {
+	db := Connect(t, Memory)
+	repo := "https://github.com/mergestat/mergestat-lite"
+
+	var expected int
+	if err := db.QueryRow("SELECT count(*) FROM commits(?)", repo).Scan(&expected); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	for _, backend := range []string{"go-git", "libgit2", "cli"} {
+		var count int
+		if err := db.QueryRow("SELECT count(*) FROM commits(?, 'HEAD', ?)", repo, backend).Scan(&count); err != nil {
+			t.Fatalf("failed to execute query with %s backend: %v", backend, err.Error())
+		}
+
+		if count != expected {
+			t.Fatalf("expected %d commits using the %s backend, got %d", expected, backend, count)
+		}
+	}
+
+	if _, err := db.Exec("SELECT * FROM commits(?, 'HEAD', 'svn')", repo); err == nil {
+		t.Fatalf("expected an unknown backend to fail")
+	}
+}
+
+func TestCommitMergeFilters(t *testing.T) {
+	db := Connect(t, Memory)
+	repo := "https://github.com/mergestat/mergestat-lite"
+
+	var merges, nonMerges int
+	err := db.QueryRow("SELECT count(*) FILTER (WHERE parents > 1), count(*) FILTER (WHERE parents < 2) FROM commits(?)", repo).Scan(&merges, &nonMerges)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	var tests = map[string]int{
+		"SELECT count(*) FROM commits(?) WHERE no_merges = 1":                 nonMerges,
+		"SELECT count(*) FROM commits(?) WHERE merges_only = 1":               merges,
+		"SELECT count(*) FROM commits(?) WHERE no_merges = 0":                 merges + nonMerges,
+		"SELECT count(*) FROM commits(?, 'HEAD', 'cli', 1)":                   nonMerges,
+		"SELECT count(*) FROM commits(?, 'HEAD', 'libgit2', 0, 1)":            merges,
+		"SELECT count(*) FROM commits(?) WHERE no_merges = 1 AND parents > 1": 0,
+	}
+
+	for query, expected := range tests {
+		var count int
+		if err := db.QueryRow(query, repo).Scan(&count); err != nil {
+			t.Fatalf("failed to execute %q: %v", query, err.Error())
+		}
+
+		if count != expected {
+			t.Fatalf("expected %d commits from %q, got %d", expected, query, count)
+		}
+	}
+}
+
+func TestCommitsOrderByAuthorWhen(t *testing.T) {
+	db := Connect(t, Memory)
+	repo := "https://github.com/mergestat/mergestat-lite"
+
+	type commit struct {
+		hash string
+		when time.Time
+	}
+	var query = func(q string, args ...interface{}) []commit {
+		t.Helper()
+		rows, err := db.Query(q, args...)
+		if err != nil {
+			t.Fatalf("failed to execute query: %v", err.Error())
+		}
+		defer rows.Close()
+
+		var commits []commit
+		for rows.Next() {
+			var hash, when string
+			if err = rows.Scan(&hash, &when); err != nil {
+				t.Fatalf("failed to scan resultset: %v", err)
+			}
+			commits = append(commits, commit{hash, parseTime(t, when)})
+		}
+		if err = rows.Err(); err != nil {
+			t.Fatalf("failed to fetch results: %v", err.Error())
+		}
+		return commits
+	}
+
+	got := query("SELECT hash, author_when FROM commits(?) ORDER BY author_when DESC", repo)
+
+	// the same commits, sorted here instead
+	expected := query("SELECT hash, author_when FROM commits(?)", repo)
+	sort.SliceStable(expected, func(i, j int) bool { return expected[i].when.After(expected[j].when) })
+
+	if len(got) == 0 || len(got) != len(expected) {
+		t.Fatalf("expected %d commits, got %d", len(expected), len(got))
+	}
+	var hashes = make(map[string]bool)
+	for i := range got {
+		if !got[i].when.Equal(expected[i].when) {
+			t.Fatalf("expected commit %d to be authored at %s, got %s (%s)", i, expected[i].when, got[i].when, got[i].hash)
+		}
+		hashes[got[i].hash] = true
+	}
+	for _, c := range expected {
+		if !hashes[c.hash] {
+			t.Fatalf("expected commit %s to be returned", c.hash)
+		}
+	}
+
+	const since, until = "2021-01-01T00:00:00Z", "2021-06-01T00:00:00Z"
+	inRange := query("SELECT hash, author_when FROM commits(?) WHERE author_when > ? AND author_when < ?", repo, since, until)
+
+	// force sqlite to do the filtering on its own, by wrapping the column in an expression. sqlite compares the dates
+	// as text, which includes the offset of the author, so commits must be in range both as text and as dates.
+	var expectedInRange []commit
+	for _, c := range query("SELECT hash, author_when FROM commits(?) WHERE +author_when > ? AND +author_when < ?", repo, since, until) {
+		if !c.when.Before(parseTime(t, since)) && !c.when.After(parseTime(t, until)) {
+			expectedInRange = append(expectedInRange, c)
+		}
+	}
+
+	if len(inRange) == 0 || len(inRange) != len(expectedInRange) {
+		t.Fatalf("expected %d commits authored between %s and %s, got %d", len(expectedInRange), since, until, len(inRange))
+	}
+
+	// in a rebased history, author dates don't follow the order of the history
+	dir := t.TempDir()
+	local, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to initialize repository: %v", err)
+	}
+	worktree, err := local.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var order = make([]string, 3)
+	var committed = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, authored := range []int{2, 0, 1} { // hours after the first commit, at which each commit is authored
+		committed = committed.Add(time.Hour)
+		hash, err := worktree.Commit(fmt.Sprintf("commit %d", i), &git.CommitOptions{
+			AllowEmptyCommits: true,
+			Author:            &object.Signature{Name: "test", Email: "test@example.com", When: time.Date(2021, 1, 1, authored, 0, 0, 0, time.UTC)},
+			Committer:         &object.Signature{Name: "test", Email: "test@example.com", When: committed},
+		})
+		if err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+		order[2-authored] = hash.String()
+	}
+
+	var history []string
+	for _, c := range query("SELECT hash, author_when FROM commits(?) ORDER BY author_when DESC", dir) {
+		history = append(history, c.hash)
+	}
+	if strings.Join(history, ",") != strings.Join(order, ",") {
+		t.Fatalf("expected commits in the order %v, got %v", order, history)
+	}
+}
+
+func TestCommitsAuthorTimezone(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to initialize repository: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// authored at 23:30 in UTC-05:30, which is the next day in UTC
+	var when = time.Date(2022, 3, 1, 23, 30, 0, 0, time.FixedZone("", -(5*60+30)*60))
+	if _, err = worktree.Commit("late night commit", &git.CommitOptions{
+		AllowEmptyCommits: true,
+		Author:            &object.Signature{Name: "test", Email: "test@example.com", When: when},
+	}); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	for _, backend := range []string{"go-git", "libgit2", "cli"} {
+		var offset, hour int
+		if err = db.QueryRow("SELECT author_tz_offset_minutes, author_local_hour FROM commits(?, 'HEAD', ?)", dir, backend).Scan(&offset, &hour); err != nil {
+			t.Fatalf("failed to execute query: %v", err.Error())
+		}
+		if offset != -330 || hour != 23 {
+			t.Fatalf("expected an offset of -330 minutes at hour 23 with the %s backend, got %d at hour %d", backend, offset, hour)
+		}
+	}
+}
+
+func TestCommitsMessagePushdown(t *testing.T) {
+	db := Connect(t, Memory)
+	repo := "https://github.com/mergestat/mergestat-lite"
+
+	var tests = []struct{ constraint, pattern string }{
+		{"LIKE", "%fix%"},
+		{"LIKE", "%FIX_%"},
+		{"REGEXP", "^Merge pull request #[0-9]+"},
+		{"REGEXP", "(?i)readme"},
+	}
+
+	for _, test := range tests {
+		var count, expected int
+		if err := db.QueryRow("SELECT count(*) FROM commits(?) WHERE message "+test.constraint+" ?", repo, test.pattern).Scan(&count); err != nil {
+			t.Fatalf("failed to execute query: %v", err.Error())
+		}
+
+		// force sqlite to do the filtering on its own, by wrapping the column in an expression
+		if err := db.QueryRow("SELECT count(*) FROM commits(?) WHERE +message "+test.constraint+" ?", repo, test.pattern).Scan(&expected); err != nil {
+			t.Fatalf("failed to execute query: %v", err.Error())
+		}
+
+		if count == 0 || count != expected {
+			t.Fatalf("expected %d commits with a message %s %q, got %d", expected, test.constraint, test.pattern, count)
+		}
+	}
+
+	if _, err := db.Exec("SELECT * FROM commits(?) WHERE message REGEXP '('", repo); err == nil {
+		t.Fatal("expected an invalid pattern to fail")
+	}
+}
+
+func TestCommitsOnError(t *testing.T) {
+	db := Connect(t, Memory)
+	notARepo := t.TempDir()
+
+	if _, err := db.Exec("SELECT * FROM commits(?)", notARepo); err == nil {
+		t.Fatal("expected reading a directory that isn't a repository to fail")
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM commits(?) WHERE on_error = 'skip'", notARepo).Scan(&count); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if count != 0 {
+		t.Fatalf("expected no commit to be returned, got %d", count)
+	}
+
+	rows, err := db.Query("SELECT hash, error FROM commits(?) WHERE on_error = 'null'", notARepo)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	count = 0
+	for rows.Next() {
+		var hash, msg sql.NullString
+		if err = rows.Scan(&hash, &msg); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+		if hash.Valid || !msg.Valid || msg.String == "" {
+			t.Fatalf("expected a row with a NULL hash and an error, got hash=%v error=%v", hash, msg)
+		}
+		count++
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err.Error())
+	}
+	if count != 1 {
+		t.Fatalf("expected a single row reporting the error, got %d", count)
+	}
+
+	if _, err = db.Exec("SELECT * FROM commits(?) WHERE on_error = 'ignore'", notARepo); err == nil {
+		t.Fatal("expected an unknown on_error mode to fail")
+	}
+}
+
+func TestCommitsHints(t *testing.T) {
+	db := Connect(t, Memory)
+	repo := "https://github.com/mergestat/mergestat-lite"
+
+	var all, firstParent, firstParentArg int
+	if err := db.QueryRow("SELECT count(*) FROM commits(?)", repo).Scan(&all); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if err := db.QueryRow("SELECT count(*) FROM commits(?) WHERE hint = 'first-parent'", repo).Scan(&firstParent); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if err := db.QueryRow("SELECT count(*) FROM commits(?, 'HEAD', NULL, NULL, NULL, NULL, ' first-parent, no-mailmap ')", repo).Scan(&firstParentArg); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	// the history has merges, the commits of the merged branches of which aren't part of the first-parent history
+	if firstParent == 0 || firstParent >= all || firstParentArg != firstParent {
+		t.Fatalf("unexpected first-parent history of %d (and %d) commits, out of %d", firstParent, firstParentArg, all)
+	}
+
+	var limited int
+	if err := db.QueryRow("SELECT count(*) FROM commits(?) WHERE hint = 'limit=5,first-parent'", repo).Scan(&limited); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if limited != 5 {
+		t.Fatalf("expected the limit hint to stop after 5 commits, got %d", limited)
+	}
+
+	for _, hint := range []string{"limit=0", "limit", "fast", "no-mailmap=1"} {
+		if _, err := db.Exec("SELECT * FROM commits(?) WHERE hint = ?", repo, hint); err == nil {
+			t.Fatalf("expected the %q hint to fail", hint)
+		}
+	}
+}
+
+func TestCommitsReplaceRefsAndGrafts(t *testing.T) {
+	db := Connect(t, Memory)
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to initialize repository: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var when = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	var commits []plumbing.Hash
+	for _, content := range []string{"a", "b", "c"} {
+		if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Add("file.txt"); err != nil {
+			t.Fatal(err)
+		}
+		when = when.Add(time.Hour)
+		hash, err := worktree.Commit(content, &git.CommitOptions{
+			Author: &object.Signature{Name: "test", Email: "test@example.com", When: when},
+		})
+		if err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+		commits = append(commits, hash)
+	}
+
+	var walk = func(backend string) []string {
+		t.Helper()
+		rows, err := db.Query("SELECT hash, message FROM commits(?, 'HEAD', ?)", dir, backend)
+		if err != nil {
+			t.Fatalf("failed to execute query with %s backend: %v", backend, err.Error())
+		}
+		defer rows.Close()
+
+		var history []string
+		for rows.Next() {
+			var hash, message string
+			if err = rows.Scan(&hash, &message); err != nil {
+				t.Fatalf("failed to scan resultset: %v", err)
+			}
+			history = append(history, hash[:7]+" "+message)
+		}
+		if err = rows.Err(); err != nil {
+			t.Fatalf("failed to fetch results: %v", err.Error())
+		}
+		return history
+	}
+
+	// all of the backends walk the same history
+	var history = func() []string {
+		t.Helper()
+		expected := walk("go-git")
+		for _, backend := range []string{"libgit2", "cli"} {
+			if got := walk(backend); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+				t.Fatalf("expected the %s backend to walk %v, got %v", backend, expected, got)
+			}
+		}
+		return expected
+	}
+
+	// replace the last commit with a copy of it grafted onto the first one (as in git replace --graft)
+	last, err := repo.CommitObject(commits[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	last.ParentHashes = []plumbing.Hash{commits[0]}
+	last.Message = "c (replaced)"
+	obj := repo.Storer.NewEncodedObject()
+	if err = last.Encode(obj); err != nil {
+		t.Fatal(err)
+	}
+	replacement, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replaceRef := plumbing.ReferenceName("refs/replace/" + commits[2].String())
+	if err = repo.Storer.SetReference(plumbing.NewHashReference(replaceRef, replacement)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the replaced commit keeps its hash, with the contents of its replacement
+	if got := history(); len(got) != 2 || got[0] != commits[2].String()[:7]+" c (replaced)" || got[1] != commits[0].String()[:7]+" a" {
+		t.Fatalf("expected the replacement to be honored, got %v", got)
+	}
+
+	t.Setenv("GIT_NO_REPLACE_OBJECTS", "1")
+	if got := history(); len(got) != 3 {
+		t.Fatalf("expected the replacement to be ignored, got %v", got)
+	}
+
+	// commits at the boundary of a shallow clone have no parents
+	if err = repo.Storer.SetShallow([]plumbing.Hash{commits[1]}); err != nil {
+		t.Fatal(err)
+	}
+	if got := history(); len(got) != 2 || got[1] != commits[1].String()[:7]+" b" {
+		t.Fatalf("expected the history to stop at the shallow boundary, got %v", got)
+	}
+}
+
+func TestCommitsPackfileScan(t *testing.T) {
+	db := Connect(t, Memory)
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to initialize repository: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var when = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	commit := func(content string) plumbing.Hash {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Add("file.txt"); err != nil {
+			t.Fatal(err)
+		}
+		when = when.Add(time.Hour)
+		hash, err := worktree.Commit(content, &git.CommitOptions{
+			Author: &object.Signature{Name: "test", Email: "test@example.com", When: when},
+		})
+		if err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+		return hash
+	}
+
+	commit("a")
+	commit("b")
+	if err = worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("side"), Create: true}); err != nil {
+		t.Fatal(err)
+	}
+	side := commit("side")
+	if err = worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.Master}); err != nil {
+		t.Fatal(err)
+	}
+	commit("c")
+
+	// scans that aren't ordered or bounded read the commits straight out of the packfiles (and loose objects), while scans
+	// bounded by committer date walk the history
+	var query = "SELECT group_concat(hash) FROM (SELECT hash FROM commits(?, 'master') %s ORDER BY hash)"
+
+	var expected, got string
+	if err = db.QueryRow(fmt.Sprintf(query, "WHERE committer_when > '1970-01-01T00:00:00Z'"), dir).Scan(&expected); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if err = db.QueryRow(fmt.Sprintf(query, ""), dir).Scan(&got); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	// commits of other branches are stored too, but aren't reachable
+	if got != expected || strings.Count(got, ",") != 2 || strings.Contains(got, side.String()) {
+		t.Fatalf("expected the commits of master %s, got %s", expected, got)
+	}
+
+	// ordered and limited scans walk the history, returning the latest commit first
+	for _, q := range []string{
+		"SELECT message FROM commits(?, 'master') ORDER BY committer_when DESC LIMIT 1",
+		"SELECT message FROM commits(?, 'master') LIMIT 1",
+	} {
+		var latest string
+		if err = db.QueryRow(q, dir).Scan(&latest); err != nil {
+			t.Fatalf("failed to execute query: %v", err.Error())
+		}
+		if latest != "c" {
+			t.Fatalf("expected the latest commit to be c with %q, got %q", q, latest)
+		}
+	}
+}
+
+func BenchmarkCommits(b *testing.B) {
+	db := Connect(b, Memory)
+	repo := "https://github.com/mergestat/mergestat-lite"
+
+	for _, bench := range []struct{ name, query string }{
+		{"packfile-scan", "SELECT count(*) FROM commits(?)"},
+		{"walk", "SELECT count(*) FROM commits(?) WHERE committer_when > '1970-01-01T00:00:00Z'"},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var count int
+				if err := db.QueryRow(bench.query, repo).Scan(&count); err != nil {
+					b.Fatalf("failed to execute query: %v", err.Error())
+				}
+			}
+		})
+	}
+}
+
+func parseTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	when, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("failed to parse time %q: %v", value, err)
+	}
+	return when
+}
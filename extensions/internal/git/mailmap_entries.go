@@ -0,0 +1,131 @@
+package git
+
+import (
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/pkg/mailmap"
+	"go.riyazali.net/sqlite"
+)
+
+var mailmapEntriesCols = []vtab.Column{
+	{Name: "canonical_name", Type: "TEXT"},
+	{Name: "canonical_email", Type: "TEXT"},
+	{Name: "alias_name", Type: "TEXT"},
+	{Name: "alias_email", Type: "TEXT"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// mailmapEntry is a single mapping of an alias (as found in commits) to a canonical identity
+type mailmapEntry struct {
+	canonical, alias mailmap.NameAndEmail
+}
+
+type mailmapEntriesIter struct {
+	entries []*mailmapEntry
+	index   int
+}
+
+func (i *mailmapEntriesIter) Column(ctx vtab.Context, c int) error {
+	current := i.entries[i.index]
+	var value string
+	switch mailmapEntriesCols[c].Name {
+	case "canonical_name":
+		value = current.canonical.Name
+	case "canonical_email":
+		value = current.canonical.Email
+	case "alias_name":
+		value = current.alias.Name
+	case "alias_email":
+		value = current.alias.Email
+	}
+
+	// parts left out of a mailmap line are NULL (an alias without a name matches any name)
+	if value != "" {
+		ctx.ResultText(value)
+	}
+	return nil
+}
+
+func (i *mailmapEntriesIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.entries) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewMailmapEntriesModule returns the implementation of a table-valued-function listing the mappings of the .mailmap
+// file at ref (the default ref, or HEAD, if not supplied), as used to resolve the identities of the commits table.
+// Each row maps an alias to its canonical identity, ordered by canonical identity and alias.
+func NewMailmapEntriesModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("mailmap_entries", mailmapEntriesCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 4:
+					repoPath = constraint.Value.Text()
+				case 5:
+					ref = constraint.Value.Text()
+				}
+			}
+		}
+
+		if ref == "" {
+			if ref = utils.GetDefaultRefFromCtx(options.Context); ref == "" {
+				ref = "HEAD"
+			}
+		}
+
+		_, repo, err := openFnRepo(options, repoPath)
+		if err != nil {
+			return nil, err
+		}
+
+		commit, err := resolveCommitObject(repo, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		mm, err := readMailmap(options.Mailmap, repo, commit.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		return &mailmapEntriesIter{entries: mailmapEntries(mm), index: -1}, nil
+	})
+}
+
+// mailmapEntries flattens mm into its individual mappings, ordered by canonical identity and alias
+func mailmapEntries(mm mailmap.MailMap) []*mailmapEntry {
+	var entries []*mailmapEntry
+	for canonical, aliases := range mm {
+		for _, alias := range aliases {
+			entries = append(entries, &mailmapEntry{canonical: canonical, alias: alias})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.canonical != b.canonical {
+			return compareIdentities(a.canonical, b.canonical) < 0
+		}
+		return compareIdentities(a.alias, b.alias) < 0
+	})
+
+	return entries
+}
+
+// compareIdentities orders identities by name, then email
+func compareIdentities(a, b mailmap.NameAndEmail) int {
+	if c := strings.Compare(a.Name, b.Name); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Email, b.Email)
+}
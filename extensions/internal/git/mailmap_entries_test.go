@@ -0,0 +1,79 @@
+package git_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestMailmapEntries(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to initialize repository: %v", err)
+	}
+
+	var mailmap = "# comments are ignored\n" +
+		"Jane Doe <jane@example.com> <jane@old.example.com>\n" +
+		"Jane Doe <jane@example.com> Janey <jane@home.example.com>\n" +
+		"John Smith <john@old.example.com>\n"
+	if err := os.WriteFile(filepath.Join(dir, ".mailmap"), []byte(mailmap), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Add(".mailmap"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Commit("add mailmap", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	}); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	rows, err := db.Query("SELECT canonical_name, canonical_email, alias_name, alias_email FROM mailmap_entries(?)", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{"Jane Doe", "jane@example.com", "NULL", "jane@old.example.com"},
+		{"Jane Doe", "jane@example.com", "Janey", "jane@home.example.com"},
+		{"John Smith", "NULL", "NULL", "john@old.example.com"},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d entries, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+
+	// the ref can be supplied explicitly
+	var count int
+	if err = db.QueryRow("SELECT count(*) FROM mailmap_entries(?, 'HEAD')", dir).Scan(&count); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if count != 3 {
+		t.Fatalf("expected 3 entries at HEAD, got %d", count)
+	}
+}
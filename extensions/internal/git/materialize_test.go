@@ -0,0 +1,53 @@
+package git_test
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMaterialize(t *testing.T) {
+	db := Connect(t, Memory)
+	repo, hash := "https://github.com/mergestat/mergestat-lite", "2359c9a9ba0ba8aa694601ff12538c4e74b82cd5"
+
+	// the materialized table only exists on the connection that created it
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for name, table := range map[string]struct {
+		from string
+		args []interface{}
+	}{
+		"commits": {"commits(?, ?)", []interface{}{repo, hash}},
+		"refs":    {"refs(?)", []interface{}{repo}},
+	} {
+		from, args := table.from, table.args
+		t.Run(name, func(t *testing.T) {
+			// rowids are kept in the materialized table, so that rows can be told apart
+			if _, err := conn.ExecContext(context.Background(), "CREATE TABLE materialized_"+name+" AS SELECT rowid AS source_rowid, * FROM "+from, args...); err != nil {
+				t.Fatalf("failed to materialize %s: %v", name, err)
+			}
+			if _, err := conn.ExecContext(context.Background(), "INSERT INTO materialized_"+name+" SELECT rowid, * FROM "+from, args...); err != nil {
+				t.Fatalf("failed to insert into materialized %s: %v", name, err)
+			}
+
+			var expected, materialized, rowids int
+			if err := conn.QueryRowContext(context.Background(), "SELECT count(*) FROM "+from, args...).Scan(&expected); err != nil {
+				t.Fatal(err)
+			}
+			if err := conn.QueryRowContext(context.Background(), "SELECT count(*), count(DISTINCT source_rowid) FROM materialized_"+name).Scan(&materialized, &rowids); err != nil {
+				t.Fatal(err)
+			}
+
+			if expected == 0 || materialized != 2*expected {
+				t.Fatalf("expected %d materialized rows, got %d", 2*expected, materialized)
+			}
+			// each pass over the table numbers its rows in the same way
+			if rowids != expected {
+				t.Fatalf("expected %d distinct rowids, got %d", expected, rowids)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package native
+
+import (
+	"io"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var ancestryPathCols = []vtab.Column{
+	{Name: "hash", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "message", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "author_name", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "author_email", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "author_when", Type: "DATETIME", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "committer_name", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "committer_email", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "committer_when", Type: "DATETIME", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "parents", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "from_rev", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "to_rev", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// NewAncestryPathModule returns the implementation of a table-valued-function listing the commits
+// on the ancestry path between two revisions, equivalent to `git log --ancestry-path from..to`.
+// That is, commits reachable from `to` that are descendants of (and not reachable from) `from`.
+func NewAncestryPathModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("ancestry_path", ancestryPathCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, from, to string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch ancestryPathCols[constraint.ColIndex].Name {
+				case "repository":
+					repoPath = constraint.Value.Text()
+				case "from_rev":
+					from = constraint.Value.Text()
+				case "to_rev":
+					to = constraint.Value.Text()
+				}
+			}
+		}
+
+		if from == "" {
+			return nil, errors.New("ancestry_path table requires a from revision")
+		}
+
+		if repoPath == "" {
+			var err error
+			repoPath, err = options.GetRepoPath()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if to == "" {
+			to = utils.GetDefaultRefFromCtx(options.Context)
+		}
+
+		return newAncestryPathIter(options, repoPath, from, to)
+	})
+}
+
+func newAncestryPathIter(options *utils.ModuleOptions, repoPath, from, to string) (*ancestryPathIter, error) {
+	logger := options.Logger.With().
+		Str("module", "git-ancestry-path").
+		Str("repo-path", repoPath).
+		Str("from", from).
+		Str("to", to).
+		Logger()
+	defer func() {
+		logger.Debug().Msg("creating ancestry path iterator")
+	}()
+
+	repo, err := openRepo(options, repoPath, "ancestry_path")
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Free()
+
+	base, err := resolveCommit(repo, from)
+	if err != nil {
+		return nil, err
+	}
+	defer base.Free()
+
+	head, err := resolveCommit(repo, to)
+	if err != nil {
+		return nil, err
+	}
+	defer head.Free()
+
+	walk, err := repo.Walk()
+	if err != nil {
+		return nil, err
+	}
+	defer walk.Free()
+
+	// visit parents before their children, so that we know whether any of a commit's parents
+	// descend from `from` by the time we get to it
+	walk.Sorting(libgit2.SortTopological | libgit2.SortReverse)
+	if err = walk.Push(head.Id()); err != nil {
+		return nil, err
+	}
+	if err = walk.Hide(base.Id()); err != nil {
+		return nil, err
+	}
+
+	var descendants = map[libgit2.Oid]struct{}{*base.Id(): {}}
+	var commits []*object.Commit
+	err = walk.Iterate(func(c *libgit2.Commit) bool {
+		defer c.Free()
+		for i := uint(0); i < c.ParentCount(); i++ {
+			if _, ok := descendants[*c.ParentId(i)]; ok {
+				descendants[*c.Id()] = struct{}{}
+				commits = append(commits, toCommit(c))
+				break
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// return the commits newest first, as git log does
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+
+	return &ancestryPathIter{commits: commits, index: -1}, nil
+}
+
+type ancestryPathIter struct {
+	commits []*object.Commit
+	index   int
+}
+
+func (i *ancestryPathIter) Column(ctx vtab.Context, c int) error {
+	commit := i.commits[i.index]
+	switch c {
+	case 0:
+		ctx.ResultText(commit.Hash.String())
+	case 1:
+		ctx.ResultText(commit.Message)
+	case 2:
+		ctx.ResultText(commit.Author.Name)
+	case 3:
+		ctx.ResultText(commit.Author.Email)
+	case 4:
+		ctx.ResultText(commit.Author.When.Format(time.RFC3339))
+	case 5:
+		ctx.ResultText(commit.Committer.Name)
+	case 6:
+		ctx.ResultText(commit.Committer.Email)
+	case 7:
+		ctx.ResultText(commit.Committer.When.Format(time.RFC3339))
+	case 8:
+		ctx.ResultInt(len(commit.ParentHashes))
+	}
+	return nil
+}
+
+func (i *ancestryPathIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.commits) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
@@ -0,0 +1,55 @@
+package native_test
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestAncestryPathMatchesGitLog(t *testing.T) {
+	db := Connect(t, Memory)
+	dir, from := t.TempDir(), "2359c9a9ba0ba8aa694601ff12538c4e74b82cd5"
+
+	if out, err := exec.Command("git", "clone", "--quiet", "https://github.com/mergestat/mergestat-lite", dir).CombinedOutput(); err != nil {
+		t.Fatalf("failed to clone repository: %v: %s", err, out)
+	}
+
+	out, err := exec.Command("git", "-C", dir, "log", "--ancestry-path", "--format=%H", from+"..HEAD").Output()
+	if err != nil {
+		t.Fatalf("failed to run git log: %v", err)
+	}
+	expected := strings.Fields(string(out))
+
+	rows, err := db.Query("SELECT hash FROM ancestry_path(?, ?, 'HEAD')", dir, from)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	var got = make(map[string]bool)
+	for rows.Next() {
+		var hash string
+		if err = rows.Scan(&hash); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+		got[hash] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err.Error())
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d commits on the ancestry path, got %d", len(expected), len(got))
+	}
+
+	for _, hash := range expected {
+		if !got[hash] {
+			t.Fatalf("expected commit %s to be on the ancestry path", hash)
+		}
+	}
+
+	if _, err = db.Exec("SELECT * FROM ancestry_path(?)", dir); err == nil {
+		t.Fatalf("expected a missing from revision to fail")
+	}
+}
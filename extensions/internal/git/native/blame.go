@@ -1,13 +1,10 @@
 package native
 
 import (
-	"context"
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/augmentable-dev/vtab"
-	"github.com/go-git/go-git/v5/storage/filesystem"
 	libgit2 "github.com/libgit2/git2go/v34"
 	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
 	"github.com/pkg/errors"
@@ -46,12 +43,16 @@ func NewBlameModule(options *utils.ModuleOptions) sqlite.Module {
 
 		if repoPath == "" {
 			var err error
-			repoPath, err = utils.GetDefaultRepoFromCtx(options.Context)
+			repoPath, err = options.GetRepoPath()
 			if err != nil {
 				return nil, err
 			}
 		}
 
+		if rev == "" {
+			rev = utils.GetDefaultRefFromCtx(options.Context)
+		}
+
 		return newBlameIter(options, repoPath, rev, filePath)
 	})
 }
@@ -74,25 +75,7 @@ func newBlameIter(options *utils.ModuleOptions, repoPath, rev, filePath string)
 		index:    -1,
 	}
 
-	if repoPath == "" {
-		if wd, err := os.Getwd(); err != nil {
-			return nil, err
-		} else {
-			repoPath = wd
-		}
-	}
-
-	r, err := options.Locator.Open(context.Background(), repoPath)
-	if err != nil {
-		return nil, err
-	}
-
-	fsStorer, ok := r.Storer.(*filesystem.Storage)
-	if !ok {
-		return nil, fmt.Errorf("blame table only supported on filesystem backed git repos")
-	}
-
-	repo, err := libgit2.OpenRepository(fsStorer.Filesystem().Root())
+	repo, err := openRepo(options, repoPath, "blame")
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,337 @@
+package native
+
+import (
+	"bytes"
+	"io"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/augmentable-dev/vtab"
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/pkg/glob"
+	"github.com/mergestat/mergestat-lite/pkg/mailmap"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var blameSummaryCols = []vtab.Column{
+	{Name: "path", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "author_name", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "author_email", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "lines", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "percentage", Type: "REAL", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "rev", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "path_glob", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "skip_mailmap", Type: "BOOLEAN", NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// NewBlameSummaryModule returns the implementation of a table-valued-function summarizing git blame by author,
+// over all files matching a glob. Files are blamed in parallel, which makes it practical to build code ownership reports.
+// Authors are mapped through the .mailmap file at rev, unless skip_mailmap is true (it defaults to the skipMailmap context value).
+func NewBlameSummaryModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("blame_summary", blameSummaryCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, rev, glob string
+		var skipMailmap = utils.GetSkipMailmapFromCtx(options.Context)
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 5:
+					repoPath = constraint.Value.Text()
+				case 6:
+					rev = constraint.Value.Text()
+				case 7:
+					glob = constraint.Value.Text()
+				case 8:
+					if !constraint.Value.IsNil() {
+						skipMailmap = constraint.Value.Int() != 0
+					}
+				}
+			}
+		}
+
+		if repoPath == "" {
+			var err error
+			repoPath, err = options.GetRepoPath()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if rev == "" {
+			rev = utils.GetDefaultRefFromCtx(options.Context)
+		}
+
+		return newBlameSummaryIter(options, repoPath, rev, glob, skipMailmap)
+	})
+}
+
+// ownership is the number of lines of a single file last modified by a single author
+type ownership struct {
+	path       string
+	name       string
+	email      string
+	lines      int
+	percentage float64
+}
+
+func newBlameSummaryIter(options *utils.ModuleOptions, repoPath, rev, pathGlob string, skipMailmap bool) (*blameSummaryIter, error) {
+	logger := options.Logger.With().
+		Str("module", "git-blame-summary").
+		Str("repo-path", repoPath).
+		Str("path-glob", pathGlob).
+		Logger()
+
+	defer func() {
+		logger.Debug().Msg("creating blame summary iterator")
+	}()
+
+	match, err := matchPathGlob(pathGlob)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid path glob %q", pathGlob)
+	}
+
+	repo, err := openRepo(options, repoPath, "blame_summary")
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Free()
+
+	commit, err := resolveCommit(repo, rev)
+	if err != nil {
+		return nil, err
+	}
+	defer commit.Free()
+
+	var commitID = commit.Id()
+	logger = logger.With().Str("revision", commitID.String()).Logger()
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Free()
+
+	var paths []string
+	err = tree.Walk(func(root string, entry *libgit2.TreeEntry) error {
+		if entry.Type != libgit2.ObjectBlob || !match(root+entry.Name) {
+			return nil
+		}
+
+		blob, err := repo.LookupBlob(entry.Id)
+		if err != nil {
+			return err
+		}
+		defer blob.Free()
+
+		// skip over binary files, using the same heuristic as git (a NUL byte within the first 8000 bytes)
+		contents := blob.Contents()
+		if len(contents) > 8000 {
+			contents = contents[:8000]
+		}
+		if !bytes.Contains(contents, []byte{0}) {
+			paths = append(paths, root+entry.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// authors are reported by their canonical identity, as in the commits table
+	var mm mailmap.MailMap
+	if !skipMailmap {
+		if mm, err = readMailmap(options.Mailmap, repo, tree); err != nil {
+			return nil, err
+		}
+	}
+
+	summary, err := blameFiles(repo.Path(), commitID, paths, mm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &blameSummaryIter{rows: summary, index: -1}, nil
+}
+
+// matchPathGlob returns a func reporting whether the file at a path matches glob (see pkg/glob, where ** matches
+// across directories, as in src/**). As with .gitignore patterns, a glob without a slash is matched against
+// the file's name in any directory, otherwise against the full path. An empty glob matches every file.
+func matchPathGlob(pattern string) (func(p string) bool, error) {
+	if pattern == "" {
+		return func(string) bool { return true }, nil
+	}
+
+	re, err := glob.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.Contains(pattern, "/") {
+		return func(p string) bool { return re.MatchString(path.Base(p)) }, nil
+	}
+	return re.MatchString, nil
+}
+
+// blameFiles blames each of the given paths at commitID using a pool of workers, one per CPU,
+// returning the number of lines owned by each author (as mapped by mm) in each file ordered by path and descending line count.
+// Each worker opens the repository at gitDir itself, as libgit2 repository handles shouldn't be shared between threads.
+func blameFiles(gitDir string, commitID *libgit2.Oid, paths []string, mm mailmap.MailMap) ([]*ownership, error) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	var summary []*ownership
+
+	var queue = make(chan string)
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			repo, err := libgit2.OpenRepository(gitDir)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+
+				for range queue { // drain the queue so the producer doesn't block
+				}
+				return
+			}
+			defer repo.Free()
+
+			for p := range queue {
+				rows, err := blameFile(repo, commitID, p, mm)
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = errors.Wrapf(err, "failed to blame %q", p)
+				}
+				summary = append(summary, rows...)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, p := range paths {
+		queue <- p
+	}
+	close(queue)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	sort.Slice(summary, func(i, j int) bool {
+		if summary[i].path != summary[j].path {
+			return summary[i].path < summary[j].path
+		}
+		if summary[i].lines != summary[j].lines {
+			return summary[i].lines > summary[j].lines
+		}
+		return summary[i].email < summary[j].email
+	})
+
+	return summary, nil
+}
+
+// blameFile blames the file at p and aggregates the lines in each hunk by author
+func blameFile(repo *libgit2.Repository, commitID *libgit2.Oid, p string, mm mailmap.MailMap) ([]*ownership, error) {
+	opts, err := libgit2.DefaultBlameOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts.NewestCommit = commitID
+
+	blame, err := repo.BlameFile(p, &opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = blame.Free() }()
+
+	var total int
+	var byAuthor = make(map[[2]string]*ownership)
+	var rows []*ownership
+	for i := 0; i < blame.HunkCount(); i++ {
+		hunk, err := blame.HunkByIndex(i)
+		if err != nil {
+			return nil, err
+		}
+
+		var name, email string
+		if hunk.FinalSignature != nil {
+			proper := mm.Lookup(mailmap.NameAndEmail{Name: hunk.FinalSignature.Name, Email: hunk.FinalSignature.Email})
+			name, email = proper.Name, proper.Email
+		}
+
+		key := [2]string{name, email}
+		if _, ok := byAuthor[key]; !ok {
+			byAuthor[key] = &ownership{path: p, name: name, email: email}
+			rows = append(rows, byAuthor[key])
+		}
+		byAuthor[key].lines += int(hunk.LinesInHunk)
+		total += int(hunk.LinesInHunk)
+	}
+
+	for _, row := range rows {
+		row.percentage = float64(row.lines) * 100 / float64(total)
+	}
+
+	return rows, nil
+}
+
+// readMailmap returns the parsed .mailmap file in tree (empty if there's none), from cache if the same file was read already
+func readMailmap(cache *utils.MailmapCache, repo *libgit2.Repository, tree *libgit2.Tree) (mailmap.MailMap, error) {
+	entry := tree.EntryByName(".mailmap")
+	if entry == nil || entry.Type != libgit2.ObjectBlob {
+		return cache.Get("", nil)
+	}
+
+	return cache.Get(entry.Id.String(), func() (string, error) {
+		blob, err := repo.LookupBlob(entry.Id)
+		if err != nil {
+			return "", errors.Wrapf(err, "could not lookup mailmap file")
+		}
+		defer blob.Free()
+
+		return string(blob.Contents()), nil
+	})
+}
+
+type blameSummaryIter struct {
+	rows  []*ownership
+	index int
+}
+
+func (i *blameSummaryIter) Column(ctx vtab.Context, c int) error {
+	row := i.rows[i.index]
+	switch c {
+	case 0:
+		ctx.ResultText(row.path)
+	case 1:
+		ctx.ResultText(row.name)
+	case 2:
+		ctx.ResultText(row.email)
+	case 3:
+		ctx.ResultInt(row.lines)
+	case 4:
+		ctx.ResultFloat(row.percentage)
+	}
+	return nil
+}
+
+func (i *blameSummaryIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.rows) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
@@ -0,0 +1,132 @@
+package native_test
+
+import (
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlameSummary(t *testing.T) {
+	db := Connect(t, Memory)
+	repo, hash := "https://github.com/mergestat/mergestat-lite", "2359c9a9ba0ba8aa694601ff12538c4e74b82cd5"
+
+	rows, err := db.Query("SELECT path, lines, percentage FROM blame_summary(?, ?, '*.md')", repo, hash)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	var totals = make(map[string]float64)
+	for rows.Next() {
+		var path string
+		var lines int
+		var percentage float64
+		if err = rows.Scan(&path, &lines, &percentage); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+
+		if lines <= 0 {
+			t.Fatalf("expected %s to have lines attributed to an author", path)
+		}
+		totals[path] += percentage
+	}
+
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err.Error())
+	}
+
+	if _, ok := totals["README.md"]; !ok {
+		t.Fatalf("expected README.md to match the glob, got %v", totals)
+	}
+
+	for path, total := range totals {
+		if math.Abs(total-100) > 0.001 {
+			t.Fatalf("expected ownership of %s to add up to 100%%, got %f", path, total)
+		}
+	}
+
+	// the summary must agree with the per-line blame of the same file
+	var fromBlame, fromSummary int
+	if err = db.QueryRow("SELECT count(*) FROM blame(?, ?, 'README.md')", repo, hash).Scan(&fromBlame); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	if err = db.QueryRow("SELECT sum(lines) FROM blame_summary(?, ?, 'README.md')", repo, hash).Scan(&fromSummary); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	if fromBlame != fromSummary {
+		t.Fatalf("expected %d lines in README.md, got %d", fromBlame, fromSummary)
+	}
+}
+
+func TestBlameSummaryMailmap(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	var git = func(email string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=" + email}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to run git %v: %v: %s", args, err, out)
+		}
+	}
+
+	git("old@example.com", "init", "--quiet")
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("old@example.com", "add", ".")
+	git("old@example.com", "commit", "--quiet", "-m", "initial commit")
+
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".mailmap"), []byte("Test <new@example.com> <old@example.com>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("new@example.com", "add", ".")
+	git("new@example.com", "commit", "--quiet", "-m", "add mailmap")
+
+	// both lines of a.txt belong to the same (canonical) author
+	var email string
+	var lines int
+	if err := db.QueryRow("SELECT author_email, lines FROM blame_summary(?, 'HEAD', 'a.txt')", dir).Scan(&email, &lines); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if email != "new@example.com" || lines != 2 {
+		t.Fatalf("expected 2 lines owned by new@example.com, got %d lines owned by %s", lines, email)
+	}
+}
+
+func TestBlameSummaryAnnotatedTag(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	var git = func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to run git %v: %v: %s", args, err, out)
+		}
+	}
+
+	git("init", "--quiet")
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "--quiet", "-m", "initial commit")
+	git("tag", "-a", "v1.0", "-m", "v1.0")
+
+	// the annotated tag is peeled to the commit it points to
+	var lines int
+	if err := db.QueryRow("SELECT lines FROM blame_summary(?, 'v1.0', 'a.txt')", dir).Scan(&lines); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if lines != 2 {
+		t.Fatalf("expected 2 lines in a.txt, got %d", lines)
+	}
+}
@@ -0,0 +1,90 @@
+package native
+
+import (
+	"strings"
+
+	libgit2 "github.com/libgit2/git2go/v34"
+)
+
+// openDiff returns the diff between two revisions of repo, with renames detected (as in the stats table).
+// If to is empty, it is the diff of the changes introduced by the commit from resolves to (HEAD if empty)
+// against its first parent, or the empty tree for root commits (as in git show). Otherwise, it is the diff
+// from the tree of from to the tree of to (as in git diff from to).
+func openDiff(repo *libgit2.Repository, from, to string) (*libgit2.Diff, error) {
+	fromCommit, err := resolveCommit(repo, from)
+	if err != nil {
+		return nil, err
+	}
+	defer fromCommit.Free()
+
+	var oldCommit, newCommit *libgit2.Commit
+	if to == "" {
+		newCommit = fromCommit
+		if fromCommit.ParentCount() > 0 {
+			oldCommit = fromCommit.Parent(0)
+			defer oldCommit.Free()
+		}
+	} else {
+		oldCommit = fromCommit
+		if newCommit, err = resolveCommit(repo, to); err != nil {
+			return nil, err
+		}
+		defer newCommit.Free()
+	}
+
+	return diffCommits(repo, oldCommit, newCommit)
+}
+
+// diffCommits returns the diff from the tree of oldCommit (the empty tree if nil) to the tree of newCommit,
+// with renames detected
+func diffCommits(repo *libgit2.Repository, oldCommit, newCommit *libgit2.Commit) (*libgit2.Diff, error) {
+	var err error
+	var oldTree, newTree *libgit2.Tree
+	if oldCommit != nil {
+		if oldTree, err = oldCommit.Tree(); err != nil {
+			return nil, err
+		}
+		defer oldTree.Free()
+	}
+	if newTree, err = newCommit.Tree(); err != nil {
+		return nil, err
+	}
+	defer newTree.Free()
+
+	diffOpts, err := libgit2.DefaultDiffOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := repo.DiffTreeToTree(oldTree, newTree, &diffOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	diffFindOpts, err := libgit2.DefaultDiffFindOptions()
+	if err != nil {
+		_ = diff.Free()
+		return nil, err
+	}
+	// renames are detected regardless of the diff.renames setting of the repository
+	diffFindOpts.Flags |= libgit2.DiffFindRenames
+
+	if err = diff.FindSimilar(&diffFindOpts); err != nil {
+		_ = diff.Free()
+		return nil, err
+	}
+
+	return diff, nil
+}
+
+// hunkContext returns the function context of a hunk header (the text following the line ranges, which git
+// fills in with the enclosing function or section), as in "func main()" for "@@ -1,2 +1,3 @@ func main()"
+func hunkContext(header string) string {
+	header = strings.TrimRight(header, "\r\n")
+	if i := strings.Index(header, "@@ "); i >= 0 {
+		if j := strings.Index(header[i+3:], "@@"); j >= 0 {
+			return strings.TrimSpace(header[i+3+j+2:])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,156 @@
+package native
+
+import (
+	"io"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"go.riyazali.net/sqlite"
+)
+
+var diffHunksCols = []vtab.Column{
+	{Name: "file_path", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "old_file_path", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "old_start", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "old_lines", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "new_start", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "new_lines", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "additions", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "deletions", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "header", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "context", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "from_rev", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "to_rev", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// NewDiffHunksModule returns the implementation of a table-valued-function listing the hunks of a diff, with their line
+// ranges in the old and new versions of each file, and the function context git fills their header with.
+// As with stats, diff_hunks(repository, from_rev) lists the hunks of the changes introduced by from_rev (HEAD if not supplied),
+// while diff_hunks(repository, from_rev, to_rev) lists the hunks of the diff between from_rev and to_rev.
+func NewDiffHunksModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("diff_hunks", diffHunksCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, fromRev, toRev string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch diffHunksCols[constraint.ColIndex].Name {
+				case "repository":
+					repoPath = constraint.Value.Text()
+				case "from_rev":
+					fromRev = constraint.Value.Text()
+				case "to_rev":
+					toRev = constraint.Value.Text()
+				}
+			}
+		}
+
+		if repoPath == "" {
+			var err error
+			repoPath, err = options.GetRepoPath()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if fromRev == "" {
+			fromRev = utils.GetDefaultRefFromCtx(options.Context)
+		}
+
+		return newDiffHunksIter(options, repoPath, fromRev, toRev)
+	})
+}
+
+type diffHunk struct {
+	filePath, oldFilePath string
+	libgit2.DiffHunk
+	additions, deletions int
+}
+
+func newDiffHunksIter(options *utils.ModuleOptions, repoPath, fromRev, toRev string) (*diffHunksIter, error) {
+	logger := options.Logger.With().
+		Str("module", "git-diff-hunks").
+		Str("repo-path", repoPath).
+		Str("from-revision", fromRev).
+		Str("to-revision", toRev).
+		Logger()
+	defer func() {
+		logger.Debug().Msg("creating diff hunks iterator")
+	}()
+
+	repo, err := openRepo(options, repoPath, "diff_hunks")
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Free()
+
+	diff, err := openDiff(repo, fromRev, toRev)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = diff.Free() }()
+
+	var iter = &diffHunksIter{index: -1}
+	err = diff.ForEach(func(delta libgit2.DiffDelta, _ float64) (libgit2.DiffForEachHunkCallback, error) {
+		return func(hunk libgit2.DiffHunk) (libgit2.DiffForEachLineCallback, error) {
+			h := &diffHunk{filePath: delta.NewFile.Path, oldFilePath: delta.OldFile.Path, DiffHunk: hunk}
+			iter.hunks = append(iter.hunks, h)
+			return func(line libgit2.DiffLine) error {
+				switch line.Origin {
+				case libgit2.DiffLineAddition:
+					h.additions++
+				case libgit2.DiffLineDeletion:
+					h.deletions++
+				}
+				return nil
+			}, nil
+		}, nil
+	}, libgit2.DiffDetailLines)
+	if err != nil {
+		return nil, err
+	}
+
+	return iter, nil
+}
+
+type diffHunksIter struct {
+	hunks []*diffHunk
+	index int
+}
+
+func (i *diffHunksIter) Column(ctx vtab.Context, c int) error {
+	hunk := i.hunks[i.index]
+	switch diffHunksCols[c].Name {
+	case "file_path":
+		ctx.ResultText(hunk.filePath)
+	case "old_file_path":
+		ctx.ResultText(hunk.oldFilePath)
+	case "old_start":
+		ctx.ResultInt(hunk.OldStart)
+	case "old_lines":
+		ctx.ResultInt(hunk.OldLines)
+	case "new_start":
+		ctx.ResultInt(hunk.NewStart)
+	case "new_lines":
+		ctx.ResultInt(hunk.NewLines)
+	case "additions":
+		ctx.ResultInt(hunk.additions)
+	case "deletions":
+		ctx.ResultInt(hunk.deletions)
+	case "header":
+		ctx.ResultText(strings.TrimRight(hunk.Header, "\r\n"))
+	case "context":
+		ctx.ResultText(hunkContext(hunk.Header))
+	}
+	return nil
+}
+
+func (i *diffHunksIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.hunks) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
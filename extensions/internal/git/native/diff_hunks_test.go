@@ -0,0 +1,87 @@
+package native_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffHunks(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	var git = func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to run git %v: %v: %s", args, err, out)
+		}
+	}
+
+	var write = func(name string, lines ...string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	git("init", "--quiet")
+	write("main.go", "package main", "", "func main() {", "\ta := 1", "\tb := 2", "\tc := 3", "\td := 4", "\te := 5", "\tprintln(a, b, c, d, e)", "}")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "initial commit")
+
+	write("main.go", "package main", "", "func main() {", "\ta := 1", "\tb := 2", "\tc := 3", "\td := 4", "\te := 5", "\tprintln(e, d, c, b, a)", "}")
+	git("commit", "--quiet", "-am", "print in reverse")
+
+	var filePath, header, context string
+	var oldStart, oldLines, newStart, newLines, additions, deletions int
+	err := db.QueryRow("SELECT file_path, old_start, old_lines, new_start, new_lines, additions, deletions, header, context FROM diff_hunks(?)", dir).
+		Scan(&filePath, &oldStart, &oldLines, &newStart, &newLines, &additions, &deletions, &header, &context)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	if filePath != "main.go" || oldStart != 6 || oldLines != 5 || newStart != 6 || newLines != 5 || additions != 1 || deletions != 1 {
+		t.Fatalf("unexpected hunk: file=%s old=%d,%d new=%d,%d additions=%d deletions=%d", filePath, oldStart, oldLines, newStart, newLines, additions, deletions)
+	}
+
+	if header != "@@ -6,5 +6,5 @@ func main() {" || context != "func main() {" {
+		t.Fatalf("unexpected hunk header %q (context %q)", header, context)
+	}
+
+	// the initial commit is diffed against the empty tree, and both commits against each other
+	var count int
+	if err = db.QueryRow("SELECT count(*) FROM diff_hunks(?, 'HEAD~1') WHERE old_start = 0 AND new_lines = 10", dir).Scan(&count); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if count != 1 {
+		t.Fatalf("expected the initial commit to add the file, got %d hunks", count)
+	}
+
+	if err = db.QueryRow("SELECT additions FROM diff_hunks(?, 'HEAD', 'HEAD~1')", dir).Scan(&additions); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if additions != 1 {
+		t.Fatalf("expected a single addition from HEAD to HEAD~1, got %d", additions)
+	}
+}
+
+func TestDiffHunksMatchStats(t *testing.T) {
+	db := Connect(t, Memory)
+	repo, fromHash, toHash := "https://github.com/mergestat/mergestat-lite", "2359c9a9ba0ba8aa694601ff12538c4e74b82cd5", "d65736fd08fab5a64027f0c050ee148d88549406"
+
+	// stats(repository, rev, to_rev) is the diff from to_rev to rev
+	var additions, deletions, expectedAdditions, expectedDeletions int
+	if err := db.QueryRow("SELECT sum(additions), sum(deletions) FROM diff_hunks(?, ?, ?)", repo, toHash, fromHash).Scan(&additions, &deletions); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if err := db.QueryRow("SELECT sum(additions), sum(deletions) FROM stats(?, ?, ?)", repo, fromHash, toHash).Scan(&expectedAdditions, &expectedDeletions); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	if additions != expectedAdditions || deletions != expectedDeletions {
+		t.Fatalf("expected %d additions and %d deletions, got %d and %d", expectedAdditions, expectedDeletions, additions, deletions)
+	}
+}
@@ -0,0 +1,147 @@
+package native
+
+import (
+	"io"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"go.riyazali.net/sqlite"
+)
+
+var diffLinesCols = []vtab.Column{
+	{Name: "file_path", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "old_file_path", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "type", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "old_line_no", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "new_line_no", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "content", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "from_rev", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "to_rev", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// NewDiffLinesModule returns the implementation of a table-valued-function listing the lines added and removed by a diff,
+// with their content and line number (in the new version of the file for added lines, and in the old one for removed lines).
+// Revisions are interpreted as in diff_hunks, so that joining commits with diff_lines(repository, commits.hash) lists the lines
+// introduced and removed by each commit, such as to study how long lines survive along with the blame table.
+func NewDiffLinesModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("diff_lines", diffLinesCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, fromRev, toRev string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch diffLinesCols[constraint.ColIndex].Name {
+				case "repository":
+					repoPath = constraint.Value.Text()
+				case "from_rev":
+					fromRev = constraint.Value.Text()
+				case "to_rev":
+					toRev = constraint.Value.Text()
+				}
+			}
+		}
+
+		if repoPath == "" {
+			var err error
+			repoPath, err = options.GetRepoPath()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if fromRev == "" {
+			fromRev = utils.GetDefaultRefFromCtx(options.Context)
+		}
+
+		return newDiffLinesIter(options, repoPath, fromRev, toRev)
+	})
+}
+
+type diffLine struct {
+	filePath, oldFilePath string
+	libgit2.DiffLine
+}
+
+func newDiffLinesIter(options *utils.ModuleOptions, repoPath, fromRev, toRev string) (*diffLinesIter, error) {
+	logger := options.Logger.With().
+		Str("module", "git-diff-lines").
+		Str("repo-path", repoPath).
+		Str("from-revision", fromRev).
+		Str("to-revision", toRev).
+		Logger()
+	defer func() {
+		logger.Debug().Msg("creating diff lines iterator")
+	}()
+
+	repo, err := openRepo(options, repoPath, "diff_lines")
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Free()
+
+	diff, err := openDiff(repo, fromRev, toRev)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = diff.Free() }()
+
+	var iter = &diffLinesIter{index: -1}
+	err = diff.ForEach(func(delta libgit2.DiffDelta, _ float64) (libgit2.DiffForEachHunkCallback, error) {
+		return func(hunk libgit2.DiffHunk) (libgit2.DiffForEachLineCallback, error) {
+			return func(line libgit2.DiffLine) error {
+				// context lines are left out, as they're neither added nor removed
+				if line.Origin == libgit2.DiffLineAddition || line.Origin == libgit2.DiffLineDeletion {
+					iter.lines = append(iter.lines, &diffLine{filePath: delta.NewFile.Path, oldFilePath: delta.OldFile.Path, DiffLine: line})
+				}
+				return nil
+			}, nil
+		}, nil
+	}, libgit2.DiffDetailLines)
+	if err != nil {
+		return nil, err
+	}
+
+	return iter, nil
+}
+
+type diffLinesIter struct {
+	lines []*diffLine
+	index int
+}
+
+func (i *diffLinesIter) Column(ctx vtab.Context, c int) error {
+	line := i.lines[i.index]
+	switch diffLinesCols[c].Name {
+	case "file_path":
+		ctx.ResultText(line.filePath)
+	case "old_file_path":
+		ctx.ResultText(line.oldFilePath)
+	case "type":
+		if line.Origin == libgit2.DiffLineAddition {
+			ctx.ResultText("added")
+		} else {
+			ctx.ResultText("removed")
+		}
+	case "old_line_no":
+		if line.Origin == libgit2.DiffLineDeletion {
+			ctx.ResultInt(line.OldLineno)
+		}
+	case "new_line_no":
+		if line.Origin == libgit2.DiffLineAddition {
+			ctx.ResultInt(line.NewLineno)
+		}
+	case "content":
+		ctx.ResultText(strings.TrimSuffix(line.Content, "\n"))
+	}
+	return nil
+}
+
+func (i *diffLinesIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.lines) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
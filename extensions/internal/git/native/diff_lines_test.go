@@ -0,0 +1,88 @@
+package native_test
+
+import (
+	"database/sql"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	var git = func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to run git %v: %v: %s", args, err, out)
+		}
+	}
+
+	var write = func(name, contents string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	git("init", "--quiet")
+	write("list.txt", "one\ntwo\nthree\n")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "initial commit")
+
+	write("list.txt", "one\n2\nthree\nfour\n")
+	git("commit", "--quiet", "-am", "update list")
+
+	rows, err := db.Query("SELECT file_path, type, old_line_no, new_line_no, content FROM diff_lines(?) ORDER BY type DESC, coalesce(old_line_no, new_line_no)", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	type line struct {
+		typ              string
+		oldLine, newLine sql.NullInt64
+		content          string
+	}
+	var lines []line
+	for rows.Next() {
+		var path string
+		var l line
+		if err = rows.Scan(&path, &l.typ, &l.oldLine, &l.newLine, &l.content); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+		if path != "list.txt" {
+			t.Fatalf("unexpected file %q", path)
+		}
+		lines = append(lines, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err.Error())
+	}
+
+	expected := []line{
+		{typ: "removed", oldLine: sql.NullInt64{Int64: 2, Valid: true}, content: "two"},
+		{typ: "added", newLine: sql.NullInt64{Int64: 2, Valid: true}, content: "2"},
+		{typ: "added", newLine: sql.NullInt64{Int64: 4, Valid: true}, content: "four"},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got: %+v", len(expected), lines)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Fatalf("expected %+v, got: %+v", expected[i], lines[i])
+		}
+	}
+
+	// lines added by each commit, as when studying how long lines survive
+	var added int
+	if err = db.QueryRow("SELECT count(*) FROM commits(?) c, diff_lines(?, c.hash) l WHERE l.type = 'added'", dir, dir).Scan(&added); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if added != 5 {
+		t.Fatalf("expected 5 lines to be added over the history, got %d", added)
+	}
+}
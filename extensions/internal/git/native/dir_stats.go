@@ -0,0 +1,243 @@
+package native
+
+import (
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"go.riyazali.net/sqlite"
+)
+
+var dirStatsCols = []vtab.Column{
+	{Name: "directory", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "level", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "commits", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "additions", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "deletions", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "authors", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "last_modified", Type: "DATETIME", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "depth", Type: "INT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// defaultDirStatsDepth is the depth used by the dir_stats table when none is supplied
+const defaultDirStatsDepth = 1
+
+// NewDirStatsModule returns the implementation of a table-valued-function rolling up the changes made by the (non-merge)
+// commits reachable from ref to the directories they were made in, up to depth levels deep (all levels if negative).
+// Each directory accounts for all of the changes beneath it, with the root directory (".", at level 0) accounting for
+// all of them: the number of commits, lines added and removed, distinct authors (by email) and the date of the last change.
+func NewDirStatsModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("dir_stats", dirStatsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		var depth = defaultDirStatsDepth
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch dirStatsCols[constraint.ColIndex].Name {
+				case "repository":
+					repoPath = constraint.Value.Text()
+				case "ref":
+					ref = constraint.Value.Text()
+				case "depth":
+					depth = constraint.Value.Int()
+				}
+			}
+		}
+
+		if repoPath == "" {
+			var err error
+			repoPath, err = options.GetRepoPath()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if ref == "" {
+			ref = utils.GetDefaultRefFromCtx(options.Context)
+		}
+
+		return newDirStatsIter(options, repoPath, ref, depth)
+	})
+}
+
+type dirStat struct {
+	directory            string
+	level                int
+	commits              int
+	additions, deletions int
+	authors              map[string]struct{}
+	lastModified         time.Time
+
+	lastCommit libgit2.Oid // the last commit counted, so that commits touching several files are only counted once
+}
+
+func newDirStatsIter(options *utils.ModuleOptions, repoPath, ref string, depth int) (*dirStatsIter, error) {
+	logger := options.Logger.With().
+		Str("module", "git-dir-stats").
+		Str("repo-path", repoPath).
+		Int("depth", depth).
+		Logger()
+	defer func() {
+		logger.Debug().Msg("creating dir stats iterator")
+	}()
+
+	repo, err := openRepo(options, repoPath, "dir_stats")
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Free()
+
+	head, err := resolveCommit(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer head.Free()
+
+	walk, err := repo.Walk()
+	if err != nil {
+		return nil, err
+	}
+	defer walk.Free()
+
+	if err = walk.Push(head.Id()); err != nil {
+		return nil, err
+	}
+
+	var dirs = make(map[string]*dirStat)
+	var stat = func(directory string, level int) *dirStat {
+		d, ok := dirs[directory]
+		if !ok {
+			d = &dirStat{directory: directory, level: level, authors: make(map[string]struct{})}
+			dirs[directory] = d
+		}
+		return d
+	}
+
+	var walkErr error
+	err = walk.Iterate(func(c *libgit2.Commit) bool {
+		defer c.Free()
+		// merge commits are skipped, as their changes were already made by the commits being merged
+		if c.ParentCount() > 1 {
+			return true
+		}
+		walkErr = addDirStats(repo, c, depth, stat)
+		return walkErr == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if walkErr != nil {
+		return nil, walkErr
+	}
+
+	var iter = &dirStatsIter{index: -1}
+	for _, d := range dirs {
+		iter.dirs = append(iter.dirs, d)
+	}
+	sort.Slice(iter.dirs, func(i, j int) bool { return iter.dirs[i].directory < iter.dirs[j].directory })
+
+	return iter, nil
+}
+
+// addDirStats adds the changes made by commit c to the directories (returned by stat) of the files it changed
+func addDirStats(repo *libgit2.Repository, c *libgit2.Commit, depth int, stat func(string, int) *dirStat) error {
+	var parent *libgit2.Commit
+	if c.ParentCount() > 0 {
+		parent = c.Parent(0)
+		defer parent.Free()
+	}
+
+	diff, err := diffCommits(repo, parent, c)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = diff.Free() }()
+
+	author := c.Author()
+	return diff.ForEach(func(delta libgit2.DiffDelta, _ float64) (libgit2.DiffForEachHunkCallback, error) {
+		var dirs []*dirStat
+		for level, directory := range parentDirs(delta.NewFile.Path, depth) {
+			d := stat(directory, level)
+			if d.lastCommit != *c.Id() {
+				d.lastCommit = *c.Id()
+				d.commits++
+			}
+			d.authors[strings.ToLower(author.Email)] = struct{}{}
+			if author.When.After(d.lastModified) {
+				d.lastModified = author.When
+			}
+			dirs = append(dirs, d)
+		}
+
+		return func(hunk libgit2.DiffHunk) (libgit2.DiffForEachLineCallback, error) {
+			return func(line libgit2.DiffLine) error {
+				for _, d := range dirs {
+					switch line.Origin {
+					case libgit2.DiffLineAddition:
+						d.additions++
+					case libgit2.DiffLineDeletion:
+						d.deletions++
+					}
+				}
+				return nil
+			}, nil
+		}, nil
+	}, libgit2.DiffDetailLines)
+}
+
+// parentDirs returns the directories containing file, from the root (".") down to depth levels deep (all levels if negative),
+// such that the directory at index n is n levels deep
+func parentDirs(file string, depth int) []string {
+	var dirs = []string{"."}
+	dir := path.Dir(file)
+	if dir == "." {
+		return dirs // a file at the root
+	}
+
+	parts := strings.Split(dir, "/")
+	for i := 0; i < len(parts) && (depth < 0 || i < depth); i++ {
+		dirs = append(dirs, strings.Join(parts[:i+1], "/"))
+	}
+	return dirs
+}
+
+type dirStatsIter struct {
+	dirs  []*dirStat
+	index int
+}
+
+func (i *dirStatsIter) Column(ctx vtab.Context, c int) error {
+	d := i.dirs[i.index]
+	switch dirStatsCols[c].Name {
+	case "directory":
+		ctx.ResultText(d.directory)
+	case "level":
+		ctx.ResultInt(d.level)
+	case "commits":
+		ctx.ResultInt(d.commits)
+	case "additions":
+		ctx.ResultInt(d.additions)
+	case "deletions":
+		ctx.ResultInt(d.deletions)
+	case "authors":
+		ctx.ResultInt(len(d.authors))
+	case "last_modified":
+		ctx.ResultText(d.lastModified.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func (i *dirStatsIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.dirs) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
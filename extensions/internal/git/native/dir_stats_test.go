@@ -0,0 +1,62 @@
+package native_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestDirStats(t *testing.T) {
+	db := Connect(t, Memory)
+	repo := tools.NewRepo(t)
+	dir := repo.Dir
+
+	var commit = func(author, file, contents string) {
+		t.Helper()
+		repo.Author = author
+		repo.Commit("update "+file, map[string]string{file: contents})
+	}
+
+	commit("alice", "README.md", "hello\n")
+	commit("alice", "src/app/main.go", "package main\n\nfunc main() {}\n")
+	commit("bob", "src/app/main.go", "package main\n")
+	commit("bob", "src/lib/lib.go", "package lib\n")
+
+	var stats = func(depth int) map[string]string {
+		t.Helper()
+		rows, err := db.Query("SELECT directory, level, commits, additions, deletions, authors FROM dir_stats(?, '', ?)", dir, depth)
+		if err != nil {
+			t.Fatalf("failed to execute query: %v", err.Error())
+		}
+		defer rows.Close()
+
+		var got = make(map[string]string)
+		for rows.Next() {
+			var directory string
+			var level, commits, additions, deletions, authors int
+			if err = rows.Scan(&directory, &level, &commits, &additions, &deletions, &authors); err != nil {
+				t.Fatalf("failed to scan resultset: %v", err)
+			}
+			got[directory] = fmt.Sprintf("level=%d commits=%d +%d -%d authors=%d", level, commits, additions, deletions, authors)
+		}
+		if err = rows.Err(); err != nil {
+			t.Fatalf("failed to fetch results: %v", err.Error())
+		}
+		return got
+	}
+
+	expected := map[string]string{
+		".":   "level=0 commits=4 +5 -2 authors=2",
+		"src": "level=1 commits=3 +4 -2 authors=2",
+	}
+	if got := stats(1); fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+
+	expected["src/app"] = "level=2 commits=2 +3 -2 authors=2"
+	expected["src/lib"] = "level=2 commits=1 +1 -0 authors=1"
+	if got := stats(-1); fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
@@ -1,16 +1,14 @@
 package native
 
 import (
-	"context"
 	"fmt"
 	"io"
-	"os"
 	"path"
 
 	"github.com/augmentable-dev/vtab"
-	"github.com/go-git/go-git/v5/storage/filesystem"
 	libgit2 "github.com/libgit2/git2go/v34"
 	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/extensions/services"
 	"go.riyazali.net/sqlite"
 )
 
@@ -40,12 +38,16 @@ func NewFilesModule(options *utils.ModuleOptions) sqlite.Module {
 
 		if repoPath == "" {
 			var err error
-			repoPath, err = utils.GetDefaultRepoFromCtx(options.Context)
+			repoPath, err = options.GetRepoPath()
 			if err != nil {
 				return nil, err
 			}
 		}
 
+		if rev == "" {
+			rev = utils.GetDefaultRefFromCtx(options.Context)
+		}
+
 		return newFilesIter(options, repoPath, rev)
 	})
 }
@@ -62,28 +64,10 @@ func newFilesIter(options *utils.ModuleOptions, repoPath, rev string) (*filesIte
 	iter := &filesIter{
 		repoPath: repoPath,
 		rev:      rev,
-		index:    -1,
-	}
-
-	if repoPath == "" {
-		if wd, err := os.Getwd(); err != nil {
-			return nil, err
-		} else {
-			repoPath = wd
-		}
+		stats:    options.Stats,
 	}
 
-	r, err := options.Locator.Open(context.Background(), repoPath)
-	if err != nil {
-		return nil, err
-	}
-
-	fsStorer, ok := r.Storer.(*filesystem.Storage)
-	if !ok {
-		return nil, fmt.Errorf("file table only supported on filesystem backed git repos")
-	}
-
-	repo, err := libgit2.OpenRepository(fsStorer.Filesystem().Root())
+	repo, err := openRepo(options, repoPath, "file")
 	if err != nil {
 		return nil, err
 	}
@@ -124,21 +108,9 @@ func newFilesIter(options *utils.ModuleOptions, repoPath, rev string) (*filesIte
 		return nil, err
 	}
 
-	iter.files = make([]*file, 0, tree.EntryCount())
-	err = tree.Walk(func(p string, treeEntry *libgit2.TreeEntry) error {
-		if treeEntry.Type != libgit2.ObjectBlob {
-			return nil
-		}
-		iter.files = append(iter.files, &file{
-			id:         treeEntry.Id,
-			path:       path.Join(p, treeEntry.Name),
-			executable: treeEntry.Filemode == libgit2.FilemodeBlobExecutable,
-		})
-		return nil
-	})
-	if err != nil {
-		return nil, err
-	}
+	// the tree is walked lazily as the iterator advances, so that very large trees
+	// are streamed to sqlite rather than collected into memory up front
+	iter.stack = []*treeFrame{{tree: tree}}
 
 	return iter, nil
 }
@@ -149,16 +121,24 @@ type file struct {
 	executable bool
 }
 
+// treeFrame tracks the position of the walk within a single (sub)tree
+type treeFrame struct {
+	tree   *libgit2.Tree
+	prefix string
+	index  uint64
+}
+
 type filesIter struct {
 	repoPath string
 	rev      string
-	files    []*file
-	index    int
+	current  *file
+	stack    []*treeFrame
 	repo     *libgit2.Repository
+	stats    *services.QueryStats
 }
 
 func (i *filesIter) Column(ctx vtab.Context, c int) error {
-	currentFile := i.files[i.index]
+	currentFile := i.current
 	switch c {
 	case 0:
 		ctx.ResultText(currentFile.path)
@@ -174,18 +154,47 @@ func (i *filesIter) Column(ctx vtab.Context, c int) error {
 			return err
 		}
 		defer blob.Free()
+		i.stats.Count(services.ObjectsRead, 1)
 		ctx.ResultText(string(blob.Contents()))
 	}
+
 	return nil
 }
 
+// Next advances a depth-first walk of the tree (in the same order as libgit2's pre-order tree walk),
+// stopping at every blob along the way
 func (i *filesIter) Next() (vtab.Row, error) {
-	i.index++
-	if i.index >= len(i.files) {
-		if i.repo != nil {
-			i.repo.Free()
+	for len(i.stack) > 0 {
+		top := i.stack[len(i.stack)-1]
+		if top.index >= top.tree.EntryCount() {
+			top.tree.Free()
+			i.stack = i.stack[:len(i.stack)-1]
+			continue
 		}
-		return nil, io.EOF
+
+		entry := top.tree.EntryByIndex(top.index)
+		top.index++
+
+		switch entry.Type {
+		case libgit2.ObjectTree:
+			subtree, err := i.repo.LookupTree(entry.Id)
+			if err != nil {
+				return nil, err
+			}
+			i.stack = append(i.stack, &treeFrame{tree: subtree, prefix: path.Join(top.prefix, entry.Name)})
+		case libgit2.ObjectBlob:
+			i.current = &file{
+				id:         entry.Id,
+				path:       path.Join(top.prefix, entry.Name),
+				executable: entry.Filemode == libgit2.FilemodeBlobExecutable,
+			}
+			return i, nil
+		}
+	}
+
+	if i.repo != nil {
+		i.repo.Free()
+		i.repo = nil
 	}
-	return i, nil
+	return nil, io.EOF
 }
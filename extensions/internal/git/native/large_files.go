@@ -0,0 +1,169 @@
+package native
+
+import (
+	"io"
+	"sort"
+
+	"github.com/augmentable-dev/vtab"
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/extensions/internal/sqlutil"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var largeFilesCols = []vtab.Column{
+	{Name: "path", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "blob_hash", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "size", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "deleted", Type: "BOOLEAN", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "commit_hash", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "min_size", Type: "INT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// defaultLargeFileSize is the min_size used by the large_files table when none is supplied
+const defaultLargeFileSize = 1 << 20
+
+// NewLargeFilesModule returns the implementation of a table-valued-function listing the blobs reachable from ref
+// (in any commit, not only the tip) that are at least min_size bytes large, largest first. Blobs that are no longer
+// part of the tree at ref are flagged as deleted, as they only bloat the history. This is meant to help plan
+// repository slimming and migrations to LFS.
+func NewLargeFilesModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("large_files", largeFilesCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		var minSize int64 = defaultLargeFileSize
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch largeFilesCols[constraint.ColIndex].Name {
+				case "repository":
+					repoPath = constraint.Value.Text()
+				case "ref":
+					ref = constraint.Value.Text()
+				case "min_size":
+					minSize = constraint.Value.Int64()
+				}
+			}
+		}
+
+		if repoPath == "" {
+			var err error
+			repoPath, err = options.GetRepoPath()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if ref == "" {
+			ref = utils.GetDefaultRefFromCtx(options.Context)
+		}
+
+		return newLargeFilesIter(options, repoPath, ref, minSize)
+	})
+}
+
+type largeFile struct {
+	path    string
+	id      libgit2.Oid
+	size    uint64
+	deleted bool
+	commit  string
+}
+
+func newLargeFilesIter(options *utils.ModuleOptions, repoPath, ref string, minSize int64) (*largeFilesIter, error) {
+	logger := options.Logger.With().
+		Str("module", "git-large-files").
+		Str("repo-path", repoPath).
+		Int64("min-size", minSize).
+		Logger()
+	defer func() {
+		logger.Debug().Msg("creating large files iterator")
+	}()
+
+	repo, err := openRepo(options, repoPath, "large_files")
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Free()
+
+	head, err := resolveCommit(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer head.Free()
+
+	odb, err := repo.Odb()
+	if err != nil {
+		return nil, err
+	}
+	defer odb.Free()
+
+	var files []*largeFile
+	var byID = make(map[libgit2.Oid]*largeFile)
+	history := newBlobWalker(repo, func(commit, path string, entry *libgit2.TreeEntry) error {
+		// only read the object's header, so we don't need to inflate every blob in the history
+		size, _, err := odb.ReadHeader(entry.Id)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read header of %s", entry.Id)
+		}
+
+		if minSize <= 0 || size >= uint64(minSize) {
+			file := &largeFile{path: path, id: *entry.Id, size: size, deleted: true, commit: commit}
+			files = append(files, file)
+			byID[file.id] = file
+		}
+		return nil
+	})
+
+	if err = history.walkHistory(head); err != nil {
+		return nil, err
+	}
+
+	// any of the blobs still in the tree at ref haven't been deleted
+	current := newBlobWalker(repo, func(_, _ string, entry *libgit2.TreeEntry) error {
+		if file, ok := byID[*entry.Id]; ok {
+			file.deleted = false
+		}
+		return nil
+	})
+
+	if err = current.walkCommit(head); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(files, func(i, j int) bool { return files[i].size > files[j].size })
+
+	return &largeFilesIter{files: files, index: -1}, nil
+}
+
+type largeFilesIter struct {
+	files []*largeFile
+	index int
+}
+
+func (i *largeFilesIter) Column(ctx vtab.Context, c int) error {
+	file := i.files[i.index]
+	switch c {
+	case 0:
+		ctx.ResultText(file.path)
+	case 1:
+		ctx.ResultText(file.id.String())
+	case 2:
+		ctx.ResultInt64(int64(file.size))
+	case 3:
+		ctx.ResultInt(sqlutil.BoolToInt(file.deleted))
+	case 4:
+		ctx.ResultText(file.commit)
+	}
+	return nil
+}
+
+func (i *largeFilesIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.files) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
@@ -0,0 +1,70 @@
+package native_test
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestLargeFiles(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	var git = func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to run git %v: %v: %s", args, err, out)
+		}
+	}
+
+	var write = func(name string, size int) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), bytes.Repeat([]byte{'x'}, size), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	git("init", "--quiet")
+	write("small.txt", 10)
+	write("kept.bin", 4096)
+	write("removed.bin", 8192)
+	git("add", ".")
+	git("commit", "--quiet", "-m", "add files")
+	git("rm", "--quiet", "removed.bin")
+	git("commit", "--quiet", "-m", "remove large file")
+
+	rows, err := db.Query("SELECT path, size, deleted FROM large_files(?, 'HEAD', 1024)", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	type result struct {
+		path    string
+		size    int
+		deleted bool
+	}
+
+	var results []result
+	for rows.Next() {
+		var r result
+		if err = rows.Scan(&r.path, &r.size, &r.deleted); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+		results = append(results, r)
+	}
+
+	var expected = []result{{"removed.bin", 8192, true}, {"kept.bin", 4096, false}}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, results)
+	}
+
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected[i], results[i])
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package native
+
+import (
+	"io"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+)
+
+// NewCommitIter returns an iterator over the history reachable from opts.From, walked by libgit2
+// instead of go-git. On very large repositories this is several times faster than repo.Log(...).
+// Commits are converted to go-git's representation, so callers don't need to know which backend produced them.
+// Only the From, Since and Until fields of the supplied options are honoured.
+func NewCommitIter(options *utils.ModuleOptions, repoPath string, opts *git.LogOptions) (object.CommitIter, error) {
+	repo, err := openRepo(options, repoPath, "commits")
+	if err != nil {
+		return nil, err
+	}
+
+	walk, err := repo.Walk()
+	if err != nil {
+		repo.Free()
+		return nil, err
+	}
+	walk.Sorting(libgit2.SortTime)
+
+	from := libgit2.Oid(opts.From)
+	if err = walk.Push(&from); err != nil {
+		walk.Free()
+		repo.Free()
+		return nil, err
+	}
+
+	return &commitIter{repo: repo, walk: walk, opts: opts}, nil
+}
+
+type commitIter struct {
+	repo *libgit2.Repository
+	walk *libgit2.RevWalk
+	opts *git.LogOptions
+}
+
+func (iter *commitIter) Next() (*object.Commit, error) {
+	var id libgit2.Oid
+	for {
+		if err := iter.walk.Next(&id); err != nil {
+			if libgit2.IsErrorCode(err, libgit2.ErrorCodeIterOver) {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+
+		commit, err := iter.repo.LookupCommit(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		c := toCommit(commit)
+		commit.Free()
+
+		// mirror go-git's behaviour, which skips over (rather than stops at) commits outside of the window
+		if iter.opts.Since != nil && c.Committer.When.Before(*iter.opts.Since) {
+			continue
+		}
+		if iter.opts.Until != nil && c.Committer.When.After(*iter.opts.Until) {
+			continue
+		}
+
+		return c, nil
+	}
+}
+
+func (iter *commitIter) ForEach(fn func(*object.Commit) error) error {
+	defer iter.Close()
+	for {
+		c, err := iter.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err = fn(c); err == storer.ErrStop {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (iter *commitIter) Close() {
+	if iter.walk != nil {
+		iter.walk.Free()
+		iter.walk = nil
+	}
+	if iter.repo != nil {
+		iter.repo.Free()
+		iter.repo = nil
+	}
+}
+
+// toCommit converts a libgit2 commit to its go-git counterpart
+func toCommit(c *libgit2.Commit) *object.Commit {
+	parents := make([]plumbing.Hash, c.ParentCount())
+	for i := range parents {
+		parents[i] = plumbing.Hash(*c.ParentId(uint(i)))
+	}
+
+	author, committer := c.Author(), c.Committer()
+	return &object.Commit{
+		Hash:         plumbing.Hash(*c.Id()),
+		Author:       object.Signature{Name: author.Name, Email: author.Email, When: author.When},
+		Committer:    object.Signature{Name: committer.Name, Email: committer.Email, When: committer.When},
+		Message:      c.Message(),
+		TreeHash:     plumbing.Hash(*c.TreeId()),
+		ParentHashes: parents,
+	}
+}
@@ -0,0 +1,65 @@
+package native_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/native"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/pkg/locator"
+	"github.com/rs/zerolog"
+)
+
+func TestLibgit2CommitIterMatchesGoGit(t *testing.T) {
+	logger := zerolog.Nop()
+	opts := &utils.ModuleOptions{Locator: locator.CachedLocator(locator.MultiLocator(nil)), Logger: &logger}
+	repoPath := "https://github.com/mergestat/mergestat-lite"
+
+	repo, err := opts.Locator.Open(context.Background(), repoPath)
+	if err != nil {
+		t.Fatalf("failed to open repository: %v", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to resolve head: %v", err)
+	}
+
+	logOpts := &git.LogOptions{From: head.Hash(), Order: git.LogOrderCommitterTime}
+	expected, err := repo.Log(logOpts)
+	if err != nil {
+		t.Fatalf("failed to create go-git iterator: %v", err)
+	}
+	defer expected.Close()
+
+	actual, err := native.NewCommitIter(opts, repoPath, logOpts)
+	if err != nil {
+		t.Fatalf("failed to create libgit2 iterator: %v", err)
+	}
+	defer actual.Close()
+
+	// ordering of commits with identical timestamps may differ between backends, so compare the sets
+	seen := make(map[string]int)
+	err = expected.ForEach(func(c *object.Commit) error { seen[c.Hash.String()] = c.NumParents(); return nil })
+	if err != nil {
+		t.Fatalf("failed to walk go-git history: %v", err)
+	}
+
+	var count int
+	err = actual.ForEach(func(c *object.Commit) error {
+		if parents, ok := seen[c.Hash.String()]; !ok || parents != c.NumParents() {
+			t.Fatalf("unexpected commit from libgit2 iterator: %s", c.Hash)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to walk libgit2 history: %v", err)
+	}
+
+	if count != len(seen) {
+		t.Fatalf("expected %d commits, got %d", len(seen), count)
+	}
+}
@@ -0,0 +1,42 @@
+package native_test
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMaterialize(t *testing.T) {
+	db := Connect(t, Memory)
+	repo, hash := "https://github.com/mergestat/mergestat-lite", "2359c9a9ba0ba8aa694601ff12538c4e74b82cd5"
+
+	// the materialized table only exists on the connection that created it
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for name, from := range map[string]string{
+		"stats":          "stats(?, ?)",
+		"files":          "files(?, ?)",
+		"blame":          "blame(?, ?, 'README.md')",
+		"blame_summary":  "blame_summary(?, ?, '*.md')",
+		"reachable_from": "reachable_from(?, ?)",
+	} {
+		t.Run(name, func(t *testing.T) {
+			// rowids are kept in the materialized table, so that rows can be told apart
+			if _, err := conn.ExecContext(context.Background(), "CREATE TABLE materialized_"+name+" AS SELECT rowid AS source_rowid, * FROM "+from, repo, hash); err != nil {
+				t.Fatalf("failed to materialize %s: %v", name, err)
+			}
+
+			var count, rowids int
+			if err := conn.QueryRowContext(context.Background(), "SELECT count(*), count(DISTINCT source_rowid) FROM materialized_"+name).Scan(&count, &rowids); err != nil {
+				t.Fatal(err)
+			}
+
+			if count == 0 || rowids != count {
+				t.Fatalf("expected %d distinct rowids, got %d", count, rowids)
+			}
+		})
+	}
+}
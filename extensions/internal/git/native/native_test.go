@@ -17,6 +17,8 @@ func init() {
 	// register sqlite extension when this package is loaded
 	sqlite.Register(extensions.RegisterFn(
 		options.WithExtraFunctions(), options.WithRepoLocator(locator.CachedLocator(locator.MultiLocator(nil))),
+		// compute stats ahead of time, so that it's covered by the stats tests
+		options.WithContextValue("statsPrefetchMemory", "33554432"),
 	))
 }
 
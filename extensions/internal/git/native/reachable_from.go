@@ -0,0 +1,177 @@
+package native
+
+import (
+	"io"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var reachableFromCols = []vtab.Column{
+	{Name: "name", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "type", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "remote", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "full_name", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "ref_hash", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "hash", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// NewReachableFromModule returns the implementation of a table-valued-function listing the branches and tags
+// a commit is reachable from, equivalent to `git branch --all --contains` and `git tag --contains`.
+// ref_hash is the commit the branch or tag points at (annotated tags are peeled).
+func NewReachableFromModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("reachable_from", reachableFromCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, hash string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch reachableFromCols[constraint.ColIndex].Name {
+				case "repository":
+					repoPath = constraint.Value.Text()
+				case "hash":
+					hash = constraint.Value.Text()
+				}
+			}
+		}
+
+		if hash == "" {
+			return nil, errors.New("reachable_from table requires a commit")
+		}
+
+		if repoPath == "" {
+			var err error
+			repoPath, err = options.GetRepoPath()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return newReachableFromIter(options, repoPath, hash)
+	})
+}
+
+type containingRef struct {
+	name, typ, remote, fullName, hash string
+}
+
+func newReachableFromIter(options *utils.ModuleOptions, repoPath, hash string) (*reachableFromIter, error) {
+	logger := options.Logger.With().
+		Str("module", "git-reachable-from").
+		Str("repo-path", repoPath).
+		Str("hash", hash).
+		Logger()
+	defer func() {
+		logger.Debug().Msg("creating reachable from iterator")
+	}()
+
+	repo, err := openRepo(options, repoPath, "reachable_from")
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Free()
+
+	commit, err := resolveCommit(repo, hash)
+	if err != nil {
+		return nil, err
+	}
+	defer commit.Free()
+
+	refs, err := repo.NewReferenceIterator()
+	if err != nil {
+		return nil, err
+	}
+	defer refs.Free()
+
+	var containing []*containingRef
+	for {
+		ref, err := refs.Next()
+		if err != nil {
+			if libgit2.IsErrorCode(err, libgit2.ErrorCodeIterOver) {
+				break
+			}
+			return nil, err
+		}
+
+		r, err := refContaining(repo, ref, commit.Id())
+		ref.Free()
+		if err != nil {
+			return nil, err
+		} else if r != nil {
+			containing = append(containing, r)
+		}
+	}
+
+	return &reachableFromIter{refs: containing, index: -1}, nil
+}
+
+// refContaining returns a description of ref if it's a branch or tag from which id is reachable, or nil otherwise.
+// Symbolic references (such as refs/remotes/origin/HEAD) are skipped, as they'd duplicate the branch they point to.
+func refContaining(repo *libgit2.Repository, ref *libgit2.Reference, id *libgit2.Oid) (*containingRef, error) {
+	var typ, remote string
+	switch {
+	case ref.Type() == libgit2.ReferenceSymbolic:
+		return nil, nil
+	case ref.IsBranch():
+		typ = "branch"
+	case ref.IsRemote():
+		typ, remote = "branch", strings.SplitN(strings.TrimPrefix(ref.Name(), "refs/remotes/"), "/", 2)[0]
+	case ref.IsTag():
+		typ = "tag"
+	default:
+		return nil, nil
+	}
+
+	// tags may point at objects other than commits, which can't contain anything
+	obj, err := ref.Peel(libgit2.ObjectCommit)
+	if err != nil {
+		return nil, nil
+	}
+	defer obj.Free()
+
+	if !obj.Id().Equal(id) {
+		if descendant, err := repo.DescendantOf(obj.Id(), id); err != nil {
+			return nil, err
+		} else if !descendant {
+			return nil, nil
+		}
+	}
+
+	return &containingRef{name: ref.Shorthand(), typ: typ, remote: remote, fullName: ref.Name(), hash: obj.Id().String()}, nil
+}
+
+type reachableFromIter struct {
+	refs  []*containingRef
+	index int
+}
+
+func (i *reachableFromIter) Column(ctx vtab.Context, c int) error {
+	ref := i.refs[i.index]
+	switch c {
+	case 0:
+		ctx.ResultText(ref.name)
+	case 1:
+		ctx.ResultText(ref.typ)
+	case 2:
+		if ref.remote != "" {
+			ctx.ResultText(ref.remote)
+		}
+	case 3:
+		ctx.ResultText(ref.fullName)
+	case 4:
+		ctx.ResultText(ref.hash)
+	}
+	return nil
+}
+
+func (i *reachableFromIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.refs) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
@@ -0,0 +1,51 @@
+package native_test
+
+import (
+	"testing"
+)
+
+func TestReachableFrom(t *testing.T) {
+	db := Connect(t, Memory)
+	repo, hash := "https://github.com/mergestat/mergestat-lite", "2359c9a9ba0ba8aa694601ff12538c4e74b82cd5"
+
+	rows, err := db.Query("SELECT name, type, full_name, ref_hash FROM reachable_from(?, ?)", repo, hash)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	var count int
+	for rows.Next() {
+		var name, typ, fullName, refHash string
+		if err = rows.Scan(&name, &typ, &fullName, &refHash); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+
+		if typ != "branch" && typ != "tag" {
+			t.Fatalf("unexpected ref type %q for %s", typ, fullName)
+		}
+
+		// every returned ref must have the commit in its history (the unary + stops the hash lookup from being pushed down)
+		var found int
+		if err = db.QueryRow("SELECT count(*) FROM commits(?, ?) WHERE +hash = ?", repo, refHash, hash).Scan(&found); err != nil {
+			t.Fatalf("failed to execute query: %v", err.Error())
+		}
+
+		if found != 1 {
+			t.Fatalf("expected %s to be reachable from %s", hash, fullName)
+		}
+		count++
+	}
+
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err.Error())
+	}
+
+	if count == 0 {
+		t.Fatalf("expected %s to be reachable from at least one ref", hash)
+	}
+
+	if _, err = db.Exec("SELECT * FROM reachable_from(?)", repo); err == nil {
+		t.Fatalf("expected a missing commit to fail")
+	}
+}
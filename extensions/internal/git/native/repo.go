@@ -0,0 +1,47 @@
+package native
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+)
+
+// openRepo locates the repository at repoPath using the configured locator and opens it with libgit2.
+// libgit2 is always pointed at the git directory backing the located repository (rather than its worktree),
+// so that bare repositories, mirrors and paths referring to a .git directory directly all behave the same.
+func openRepo(options *utils.ModuleOptions, repoPath, table string) (*libgit2.Repository, error) {
+	r, err := options.Locator.Open(context.Background(), repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fsStorer, ok := r.Storer.(*filesystem.Storage)
+	if !ok {
+		return nil, fmt.Errorf("%s table only supported on filesystem backed git repos", table)
+	}
+
+	return libgit2.OpenRepository(fsStorer.Filesystem().Root())
+}
+
+// resolveCommit resolves rev (HEAD if empty) to a commit, peeling annotated tags along the way
+func resolveCommit(repo *libgit2.Repository, rev string) (*libgit2.Commit, error) {
+	if rev == "" {
+		rev = "HEAD"
+	}
+
+	obj, err := repo.RevparseSingle(rev)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Free()
+
+	peeled, err := obj.Peel(libgit2.ObjectCommit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid revision %q, could not resolve to a commit", rev)
+	}
+
+	return peeled.AsCommit()
+}
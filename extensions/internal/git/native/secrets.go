@@ -0,0 +1,235 @@
+package native
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"go.riyazali.net/sqlite"
+)
+
+var secretFindingsCols = []vtab.Column{
+	{Name: "commit_hash", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "path", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "line_no", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "rule", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "match", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "entropy", Type: "REAL", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "history", Type: "BOOLEAN", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// maxSecretScanSize is the size above which blobs are not scanned for secrets, as they're unlikely to be source files
+const maxSecretScanSize = 1 << 20
+
+// secretRule is a single high-signal pattern for a kind of credential. If minEntropy is set, the (first submatch of the)
+// match must also be sufficiently random, which weeds out placeholders such as password = "changeme".
+type secretRule struct {
+	name       string
+	pattern    *regexp.Regexp
+	minEntropy float64
+}
+
+var secretRules = []*secretRule{
+	{name: "aws_access_key_id", pattern: regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`)},
+	{name: "aws_secret_access_key", pattern: regexp.MustCompile(`(?i)aws.{0,20}secret.{0,20}['"]([A-Za-z0-9/+=]{40})['"]`), minEntropy: 4},
+	{name: "private_key", pattern: regexp.MustCompile(`(-----BEGIN (?:RSA |DSA |EC |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----)`)},
+	{name: "github_token", pattern: regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{82})\b`)},
+	{name: "slack_token", pattern: regexp.MustCompile(`\b(xox[baprs]-[A-Za-z0-9-]{10,72})\b`)},
+	{name: "google_api_key", pattern: regexp.MustCompile(`\b(AIza[0-9A-Za-z_\-]{35})`)},
+	{name: "stripe_secret_key", pattern: regexp.MustCompile(`\b((?:sk|rk)_live_[0-9a-zA-Z]{24,})\b`)},
+	{name: "generic_secret", pattern: regexp.MustCompile(`(?i)(?:secret|token|passwd|password|api_?key)["']?\s*[:=]\s*["']([A-Za-z0-9/+_\-=.]{16,})["']`), minEntropy: 4},
+}
+
+// NewSecretFindingsModule returns the implementation of a table-valued-function scanning the files of a repository
+// for leaked credentials, using a set of high-signal patterns and entropy checks. By default only the tree at ref is
+// scanned, if history is set every blob reachable from ref is scanned (once), and attributed to the oldest commit it appears in.
+// Matches are redacted, so the output of the table doesn't leak the credentials any further.
+func NewSecretFindingsModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("secret_findings", secretFindingsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		var history bool
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch secretFindingsCols[constraint.ColIndex].Name {
+				case "repository":
+					repoPath = constraint.Value.Text()
+				case "ref":
+					ref = constraint.Value.Text()
+				case "history":
+					history = constraint.Value.Int() != 0
+				}
+			}
+		}
+
+		if repoPath == "" {
+			var err error
+			repoPath, err = options.GetRepoPath()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if ref == "" {
+			ref = utils.GetDefaultRefFromCtx(options.Context)
+		}
+
+		return newSecretFindingsIter(options, repoPath, ref, history)
+	})
+}
+
+type secretFinding struct {
+	commit  string
+	path    string
+	lineNo  int
+	rule    string
+	match   string
+	entropy float64
+}
+
+func newSecretFindingsIter(options *utils.ModuleOptions, repoPath, ref string, history bool) (*secretFindingsIter, error) {
+	logger := options.Logger.With().
+		Str("module", "git-secret-findings").
+		Str("repo-path", repoPath).
+		Bool("history", history).
+		Logger()
+	defer func() {
+		logger.Debug().Msg("creating secret findings iterator")
+	}()
+
+	repo, err := openRepo(options, repoPath, "secret_findings")
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Free()
+
+	head, err := resolveCommit(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer head.Free()
+
+	var findings []*secretFinding
+	walker := newBlobWalker(repo, func(commit, path string, entry *libgit2.TreeEntry) error {
+		blob, err := repo.LookupBlob(entry.Id)
+		if err != nil {
+			return err
+		}
+		defer blob.Free()
+
+		if blob.Size() <= maxSecretScanSize {
+			for _, finding := range scanForSecrets(blob.Contents()) {
+				finding.commit, finding.path = commit, path
+				findings = append(findings, finding)
+			}
+		}
+		return nil
+	})
+
+	if history {
+		err = walker.walkHistory(head)
+	} else {
+		err = walker.walkCommit(head)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &secretFindingsIter{findings: findings, index: -1}, nil
+}
+
+// scanForSecrets matches contents against all secret rules, line by line. Binary contents are skipped.
+func scanForSecrets(contents []byte) []*secretFinding {
+	sample := contents
+	if len(sample) > 8000 {
+		sample = sample[:8000]
+	}
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return nil
+	}
+
+	var findings []*secretFinding
+	for n, line := range strings.Split(string(contents), "\n") {
+		for _, rule := range secretRules {
+			for _, match := range rule.pattern.FindAllStringSubmatch(line, -1) {
+				secret := match[1]
+
+				entropy := shannonEntropy(secret)
+				if entropy < rule.minEntropy {
+					continue
+				}
+
+				findings = append(findings, &secretFinding{lineNo: n + 1, rule: rule.name, match: redact(secret), entropy: entropy})
+			}
+		}
+	}
+	return findings
+}
+
+// shannonEntropy returns the Shannon entropy of s, in bits per character
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+
+	var counts = make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+
+	var entropy float64
+	var length = float64(len([]rune(s)))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// redact masks all but the first few characters of secret
+func redact(secret string) string {
+	const visible = 4
+	if len(secret) <= visible {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:visible] + strings.Repeat("*", len(secret)-visible)
+}
+
+type secretFindingsIter struct {
+	findings []*secretFinding
+	index    int
+}
+
+func (i *secretFindingsIter) Column(ctx vtab.Context, c int) error {
+	finding := i.findings[i.index]
+	switch c {
+	case 0:
+		ctx.ResultText(finding.commit)
+	case 1:
+		ctx.ResultText(finding.path)
+	case 2:
+		ctx.ResultInt(finding.lineNo)
+	case 3:
+		ctx.ResultText(finding.rule)
+	case 4:
+		ctx.ResultText(finding.match)
+	case 5:
+		ctx.ResultFloat(finding.entropy)
+	}
+	return nil
+}
+
+func (i *secretFindingsIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.findings) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
@@ -1,12 +1,10 @@
 package native
 
 import (
-	"context"
 	"fmt"
 	"io"
 
 	"github.com/augmentable-dev/vtab"
-	"github.com/go-git/go-git/v5/storage/filesystem"
 	libgit2 "github.com/libgit2/git2go/v34"
 	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
 	"go.riyazali.net/sqlite"
@@ -19,6 +17,12 @@ var statsCols = []vtab.Column{
 
 	{Name: "old_file_mode", Type: "TEXT", NotNull: true, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
 	{Name: "new_file_mode", Type: "TEXT", NotNull: true, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "old_mode", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "new_mode", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "executable_changed", Type: "BOOLEAN", NotNull: true, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "old_path", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "new_path", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "rename_similarity", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
 
 	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
 	{Name: "rev", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
@@ -52,8 +56,45 @@ func gitFileModeObjectTypeFromUint16(mode uint16) GitFileModeObjectType {
 	}
 }
 
-// NewStatsModule returns the implementation of a table-valued-function for git stats
+// gitFileModeString returns the octal representation of a git file mode, as in git diff (e.g. 100644 or 100755),
+// or an empty string for the mode of a file that doesn't exist on one side of a diff.
+func gitFileModeString(mode uint16) string {
+	if mode == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%06o", mode)
+}
+
+// executableChanged reports whether a regular file became executable, or stopped being executable,
+// going from oldMode to newMode. Files that are added, removed or change type aren't flips.
+func executableChanged(oldMode, newMode uint16) bool {
+	if gitFileModeObjectTypeFromUint16(oldMode) != GitFileModeObjectTypeRegularFile ||
+		gitFileModeObjectTypeFromUint16(newMode) != GitFileModeObjectTypeRegularFile {
+		return false
+	}
+	return (oldMode^newMode)&0111 != 0
+}
+
+// resultFileMode sets the result of ctx to the octal representation of mode, leaving it NULL if there's no file
+func resultFileMode(ctx vtab.Context, mode uint16) {
+	if s := gitFileModeString(mode); s != "" {
+		ctx.ResultText(s)
+	}
+}
+
+// resultBool sets the result of ctx to 1 or 0
+func resultBool(ctx vtab.Context, b bool) {
+	if b {
+		ctx.ResultInt(1)
+	} else {
+		ctx.ResultInt(0)
+	}
+}
+
+// NewStatsModule returns the implementation of a table-valued-function for git stats.
+// Renamed files are reported once, from old_path to new_path with their rename_similarity, rather than as a deletion and an addition.
 func NewStatsModule(options *utils.ModuleOptions) sqlite.Module {
+	var prefetcher = newStatsPrefetcher(options)
 	return vtab.NewTableFunc("stats", statsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
 		var repoPath, rev, toRev string
 		for _, constraint := range constraints {
@@ -71,17 +112,21 @@ func NewStatsModule(options *utils.ModuleOptions) sqlite.Module {
 
 		if repoPath == "" {
 			var err error
-			repoPath, err = utils.GetDefaultRepoFromCtx(options.Context)
+			repoPath, err = options.GetRepoPath()
 			if err != nil {
 				return nil, err
 			}
 		}
 
-		return newStatsIter(options, repoPath, rev, toRev)
+		if rev == "" {
+			rev = utils.GetDefaultRefFromCtx(options.Context)
+		}
+
+		return newStatsIter(options, prefetcher, repoPath, rev, toRev)
 	})
 }
 
-func newStatsIter(options *utils.ModuleOptions, repoPath, rev, toRev string) (*statsIter, error) {
+func newStatsIter(options *utils.ModuleOptions, prefetcher *statsPrefetcher, repoPath, rev, toRev string) (*statsIter, error) {
 	logger := options.Logger.With().
 		Str("module", "git-stats").
 		Str("repo-path", repoPath).
@@ -95,17 +140,7 @@ func newStatsIter(options *utils.ModuleOptions, repoPath, rev, toRev string) (*s
 		index:    -1,
 	}
 
-	r, err := options.Locator.Open(context.Background(), repoPath)
-	if err != nil {
-		return nil, err
-	}
-
-	fsStorer, ok := r.Storer.(*filesystem.Storage)
-	if !ok {
-		return nil, fmt.Errorf("stats table only supported on filesystem backed git repos")
-	}
-
-	repo, err := libgit2.OpenRepository(fsStorer.Filesystem().Root())
+	repo, err := openRepo(options, repoPath, "stats")
 	if err != nil {
 		return nil, err
 	}
@@ -141,15 +176,20 @@ func newStatsIter(options *utils.ModuleOptions, repoPath, rev, toRev string) (*s
 	defer fromCommit.Free()
 	logger = logger.With().Str("from-revision", fromCommit.Id().String()).Logger()
 
-	tree, err := fromCommit.Tree()
-	if err != nil {
-		return nil, err
-	}
-	defer tree.Free()
-
 	var toCommit *libgit2.Commit
 	if toRev == "" {
 		toCommit = fromCommit.Parent(0)
+
+		// the stats of commits against their parent are typically queried for a whole history,
+		// and may have been computed ahead of time along with the next commits to be queried
+		defer prefetcher.prefetch(repoPath, fromCommit)
+		if stats, ok := prefetcher.take(repoPath, fromCommit.Id().String()); ok {
+			if toCommit != nil {
+				toCommit.Free()
+			}
+			iter.stats = stats
+			return iter, nil
+		}
 	} else {
 		id, err := libgit2.NewOid(toRev)
 		if err != nil {
@@ -162,51 +202,35 @@ func newStatsIter(options *utils.ModuleOptions, repoPath, rev, toRev string) (*s
 		}
 	}
 
-	var toTree *libgit2.Tree
 	if toCommit == nil {
-		toTree = &libgit2.Tree{}
 		logger = logger.With().Str("to-revision", "").Logger()
 	} else {
-		toTree, err = toCommit.Tree()
-		if err != nil {
-			return nil, err
-		}
 		defer toCommit.Free()
 		logger = logger.With().Str("to-revision", toCommit.Id().String()).Logger()
 	}
-	defer toTree.Free()
 
-	diffOpts, err := libgit2.DefaultDiffOptions()
-	if err != nil {
+	if iter.stats, err = commitStats(repo, fromCommit, toCommit); err != nil {
 		return nil, err
 	}
 
-	diff, err := repo.DiffTreeToTree(toTree, tree, &diffOpts)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		err := diff.Free()
-		if err != nil {
-			// TODO what should we do here?
-			fmt.Println(err)
-		}
-	}()
-
-	diffFindOpts, err := libgit2.DefaultDiffFindOptions()
-	if err != nil {
-		return nil, err
-	}
+	return iter, nil
+}
 
-	err = diff.FindSimilar(&diffFindOpts)
+// commitStats returns the stats of the diff from the tree of toCommit (the empty tree if nil) to the tree of fromCommit
+func commitStats(repo *libgit2.Repository, fromCommit, toCommit *libgit2.Commit) ([]*stat, error) {
+	diff, err := diffCommits(repo, toCommit, fromCommit)
 	if err != nil {
 		return nil, err
 	}
+	defer func() { _ = diff.Free() }()
 
-	iter.stats = make([]*stat, 0)
+	var stats = make([]*stat, 0)
 	err = diff.ForEach(func(delta libgit2.DiffDelta, progress float64) (libgit2.DiffForEachHunkCallback, error) {
-		stat := &stat{filePath: delta.NewFile.Path, oldFileMode: gitFileModeObjectTypeFromUint16(delta.OldFile.Mode), newFileMode: gitFileModeObjectTypeFromUint16(delta.NewFile.Mode)}
-		iter.stats = append(iter.stats, stat)
+		stat := &stat{filePath: delta.NewFile.Path, oldMode: delta.OldFile.Mode, newMode: delta.NewFile.Mode, status: delta.Status}
+		if delta.Status == libgit2.DeltaRenamed {
+			stat.oldPath, stat.similarity = delta.OldFile.Path, int(delta.Similarity)
+		}
+		stats = append(stats, stat)
 		return func(hunk libgit2.DiffHunk) (libgit2.DiffForEachLineCallback, error) {
 			return func(line libgit2.DiffLine) error {
 				switch line.Origin {
@@ -223,15 +247,19 @@ func newStatsIter(options *utils.ModuleOptions, repoPath, rev, toRev string) (*s
 		return nil, err
 	}
 
-	return iter, nil
+	return stats, nil
 }
 
 type stat struct {
-	filePath    string
-	additions   int
-	deletions   int
-	oldFileMode GitFileModeObjectType
-	newFileMode GitFileModeObjectType
+	filePath  string
+	additions int
+	deletions int
+	oldMode   uint16
+	newMode   uint16
+
+	status     libgit2.Delta
+	oldPath    string // the path the file was renamed from, if it was
+	similarity int    // how similar a renamed file is to the file it was renamed from (0-100)
 }
 
 type statsIter struct {
@@ -250,9 +278,31 @@ func (i *statsIter) Column(ctx vtab.Context, c int) error {
 	case "deletions":
 		ctx.ResultInt(currentStat.deletions)
 	case "old_file_mode":
-		ctx.ResultText(string(currentStat.oldFileMode))
+		ctx.ResultText(string(gitFileModeObjectTypeFromUint16(currentStat.oldMode)))
 	case "new_file_mode":
-		ctx.ResultText(string(currentStat.newFileMode))
+		ctx.ResultText(string(gitFileModeObjectTypeFromUint16(currentStat.newMode)))
+	case "old_mode":
+		resultFileMode(ctx, currentStat.oldMode)
+	case "new_mode":
+		resultFileMode(ctx, currentStat.newMode)
+	case "executable_changed":
+		resultBool(ctx, executableChanged(currentStat.oldMode, currentStat.newMode))
+	case "old_path":
+		switch {
+		case currentStat.status == libgit2.DeltaAdded:
+		case currentStat.oldPath != "":
+			ctx.ResultText(currentStat.oldPath)
+		default:
+			ctx.ResultText(currentStat.filePath)
+		}
+	case "new_path":
+		if currentStat.status != libgit2.DeltaDeleted {
+			ctx.ResultText(currentStat.filePath)
+		}
+	case "rename_similarity":
+		if currentStat.status == libgit2.DeltaRenamed {
+			ctx.ResultInt(currentStat.similarity)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,214 @@
+package native
+
+import (
+	"sync"
+
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+)
+
+const (
+	// statsPrefetchWorkers is the number of commits the stats of which are computed concurrently, ahead of time
+	statsPrefetchWorkers = 4
+	// statsPrefetchBatch is the number of first-parent ancestors of a commit the stats of which are computed ahead of time
+	statsPrefetchBatch = 16
+)
+
+// statsPrefetcher computes the stats of commits (against their first parent) ahead of time, in the background. The stats
+// table is typically queried for every commit of a history, one commit at a time (as in commits, stats(commits.hash)),
+// in the order of the history. When the stats of a commit are queried, the stats of its next first-parent ancestors
+// are computed by a small pool of workers, so that reading trees and resolving deltas overlap with the rest of the query.
+//
+// The stats computed ahead of time are held in memory until queried, up to (roughly) the limit set in the context
+// (see utils.GetStatsPrefetchMemoryFromCtx), beyond which no more stats are computed ahead of time and the oldest ones are dropped.
+// It's opt-in: without a limit, nothing is computed ahead of time.
+type statsPrefetcher struct {
+	options   *utils.ModuleOptions
+	maxMemory int
+
+	mu      sync.Mutex
+	entries map[statsKey]*prefetchedStats
+	order   []statsKey         // keys of the entries, oldest first (possibly including keys of entries since taken)
+	pending []*prefetchedStats // entries that are yet to be computed, in the order they were requested
+	running int                // number of running workers
+	memory  int                // estimated size of the stats computed and not taken yet
+}
+
+type statsKey struct {
+	repoPath string
+	hash     string
+}
+
+type prefetchedStats struct {
+	key   statsKey
+	done  chan struct{} // closed once stats (or err) are set
+	stats []*stat
+	err   error
+	size  int
+}
+
+func newStatsPrefetcher(options *utils.ModuleOptions) *statsPrefetcher {
+	return &statsPrefetcher{
+		options:   options,
+		maxMemory: utils.GetStatsPrefetchMemoryFromCtx(options.Context),
+		entries:   make(map[statsKey]*prefetchedStats),
+	}
+}
+
+// take returns the stats of the commit of hash if they were computed ahead of time, waiting for them if they're being computed.
+// Stats that couldn't be computed aren't returned, so that they're computed (and the error reported) again.
+func (p *statsPrefetcher) take(repoPath, hash string) ([]*stat, bool) {
+	key := statsKey{repoPath: repoPath, hash: hash}
+
+	p.mu.Lock()
+	entry, ok := p.entries[key]
+	delete(p.entries, key)
+	p.mu.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	<-entry.done
+	p.mu.Lock()
+	p.memory -= entry.size
+	p.mu.Unlock()
+
+	return entry.stats, entry.err == nil
+}
+
+// prefetch starts computing the stats of the next first-parent ancestors of commit, in the background
+func (p *statsPrefetcher) prefetch(repoPath string, commit *libgit2.Commit) {
+	if p.maxMemory <= 0 {
+		return
+	}
+
+	var hashes []string
+	var c = commit.Parent(0)
+	for c != nil && len(hashes) < statsPrefetchBatch {
+		hashes = append(hashes, c.Id().String())
+		parent := c.Parent(0)
+		c.Free()
+		c = parent
+	}
+	if c != nil {
+		c.Free()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.evict()
+	for _, hash := range hashes {
+		key := statsKey{repoPath: repoPath, hash: hash}
+		if _, ok := p.entries[key]; ok || p.memory >= p.maxMemory {
+			continue
+		}
+
+		entry := &prefetchedStats{key: key, done: make(chan struct{})}
+		p.entries[key] = entry
+		p.order = append(p.order, key)
+		p.pending = append(p.pending, entry)
+	}
+
+	for ; p.running < statsPrefetchWorkers && p.running < len(p.pending); p.running++ {
+		go p.work()
+	}
+}
+
+// evict drops the oldest stats computed ahead of time (and not taken yet), until they fit in memory
+func (p *statsPrefetcher) evict() {
+	for p.memory >= p.maxMemory && len(p.order) > 0 {
+		key := p.order[0]
+		entry, ok := p.entries[key]
+		if ok {
+			select {
+			case <-entry.done:
+			default:
+				return // the oldest stats are still being computed
+			}
+			delete(p.entries, key)
+			p.memory -= entry.size
+		}
+		p.order = p.order[1:]
+	}
+
+	// drop the keys of entries that were taken
+	for len(p.order) > 0 {
+		if _, ok := p.entries[p.order[0]]; ok {
+			break
+		}
+		p.order = p.order[1:]
+	}
+}
+
+// work computes pending stats until there are none left. Each worker opens its own copy of the repositories it reads.
+func (p *statsPrefetcher) work() {
+	var repo *libgit2.Repository
+	var repoPath string
+	defer func() {
+		if repo != nil {
+			repo.Free()
+		}
+	}()
+
+	for {
+		p.mu.Lock()
+		if len(p.pending) == 0 {
+			p.running--
+			p.mu.Unlock()
+			return
+		}
+		entry := p.pending[0]
+		p.pending = p.pending[1:]
+		p.mu.Unlock()
+
+		if repo == nil || repoPath != entry.key.repoPath {
+			if repo != nil {
+				repo.Free()
+			}
+			repoPath = entry.key.repoPath
+			repo, entry.err = openRepo(p.options, repoPath, "stats")
+		}
+
+		if entry.err == nil {
+			entry.stats, entry.err = commitStatsOf(repo, entry.key.hash)
+		}
+
+		entry.size = statsSize(entry.stats)
+		p.mu.Lock()
+		p.memory += entry.size
+		p.mu.Unlock()
+		close(entry.done)
+	}
+}
+
+// commitStatsOf returns the stats of the commit of hash against its first parent
+func commitStatsOf(repo *libgit2.Repository, hash string) ([]*stat, error) {
+	id, err := libgit2.NewOid(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := repo.LookupCommit(id)
+	if err != nil {
+		return nil, err
+	}
+	defer commit.Free()
+
+	parent := commit.Parent(0)
+	if parent != nil {
+		defer parent.Free()
+	}
+
+	return commitStats(repo, commit, parent)
+}
+
+// statsSize estimates the memory held by stats
+func statsSize(stats []*stat) int {
+	var size = 24 // the slice header
+	for _, s := range stats {
+		size += 8 + 96 + len(s.filePath) + len(s.oldPath) // the pointer, the struct and the paths
+	}
+	return size
+}
@@ -1,6 +1,11 @@
 package native_test
 
 import (
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 )
 
@@ -84,3 +89,182 @@ func TestFromAndToStats(t *testing.T) {
 		t.Fatalf("expected %d deletions, got %d", expectedDeletions, deletions)
 	}
 }
+
+func TestStatsFileModes(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	var git = func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to run git %v: %v: %s", args, err, out)
+		}
+	}
+
+	git("init", "--quiet")
+	if err := os.WriteFile(filepath.Join(dir, "build.sh"), []byte("#!/bin/sh\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "--quiet", "-m", "initial commit")
+
+	// the executable bit is flipped in the index, so that it doesn't depend on the file system supporting it
+	git("update-index", "--chmod=+x", "build.sh")
+	git("commit", "--quiet", "-m", "make build.sh executable")
+
+	var filePath, oldMode, newMode string
+	var additions, executableChanged int
+	err := db.QueryRow("SELECT file_path, additions, old_mode, new_mode, executable_changed FROM stats(?)", dir).
+		Scan(&filePath, &additions, &oldMode, &newMode, &executableChanged)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	if filePath != "build.sh" || additions != 0 || oldMode != "100644" || newMode != "100755" || executableChanged != 1 {
+		t.Fatalf("unexpected stat: file=%s additions=%d modes=%s..%s executable_changed=%d", filePath, additions, oldMode, newMode, executableChanged)
+	}
+
+	// files that are added have no old mode, and aren't executable bit flips
+	var noOldMode bool
+	if err = db.QueryRow("SELECT old_mode IS NULL, executable_changed FROM stats(?, 'HEAD~1')", dir).Scan(&noOldMode, &executableChanged); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if !noOldMode || executableChanged != 0 {
+		t.Fatalf("unexpected stat for an added file: old_mode is null=%t executable_changed=%d", noOldMode, executableChanged)
+	}
+}
+
+func TestStatsPrefetch(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	var git = func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to run git %v: %v: %s", args, err, out)
+		}
+	}
+
+	// more commits than the stats of which are computed ahead of time at once
+	git("init", "--quiet")
+	var contents string
+	for i := 0; i < 40; i++ {
+		contents += fmt.Sprintf("line %d\n", i)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%d.txt", i%3)), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", ".")
+		git("commit", "--quiet", "-m", fmt.Sprintf("commit %d", i))
+	}
+
+	// the stats of every commit against its parent are computed ahead of time, as the history is walked,
+	// and must match the stats computed against the parent supplied explicitly
+	type summary struct{ additions, deletions, files int }
+	var prefetched = make(map[string]summary)
+	var history []string
+
+	// commits are only returned in the order of the history when asked to (otherwise they're returned as they're stored)
+	rows, err := db.Query("SELECT commits.hash, additions, deletions FROM commits(?), stats(?, commits.hash) ORDER BY commits.committer_when DESC", dir, dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash string
+		var additions, deletions int
+		if err = rows.Scan(&hash, &additions, &deletions); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+		s, ok := prefetched[hash]
+		if !ok {
+			history = append(history, hash) // the history is linear, each commit is followed by its parent
+		}
+		prefetched[hash] = summary{s.additions + additions, s.deletions + deletions, s.files + 1}
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err.Error())
+	}
+
+	if len(history) != 40 {
+		t.Fatalf("expected the stats of 40 commits, got %d", len(history))
+	}
+
+	for i, hash := range history[:len(history)-1] {
+		var expected summary
+		err = db.QueryRow("SELECT sum(additions), sum(deletions), count(*) FROM stats(?, ?, ?)", dir, hash, history[i+1]).
+			Scan(&expected.additions, &expected.deletions, &expected.files)
+		if err != nil {
+			t.Fatalf("failed to execute query: %v", err.Error())
+		}
+
+		if prefetched[hash] != expected {
+			t.Fatalf("unexpected stats for %s: got %+v, expected %+v", hash, prefetched[hash], expected)
+		}
+	}
+}
+
+func TestStatsRenames(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	var git = func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to run git %v: %v: %s", args, err, out)
+		}
+	}
+
+	var contents string
+	for i := 0; i < 20; i++ {
+		contents += fmt.Sprintf("line %d\n", i)
+	}
+
+	git("init", "--quiet")
+	if err := os.WriteFile(filepath.Join(dir, "old.txt"), []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "removed.txt"), []byte("removed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "--quiet", "-m", "initial commit")
+
+	// the file is renamed with a single line changed, rather than deleted and added
+	git("mv", "old.txt", "new.txt")
+	git("rm", "--quiet", "removed.txt")
+	if err := os.WriteFile(filepath.Join(dir, "new.txt"), []byte(contents+"line 20\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "--quiet", "-m", "rename old.txt")
+
+	rows, err := db.Query("SELECT file_path, additions, deletions, old_path, new_path, rename_similarity FROM stats(?) ORDER BY file_path", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	var results []string
+	for rows.Next() {
+		var filePath string
+		var additions, deletions int
+		var oldPath, newPath sql.NullString
+		var similarity sql.NullInt64
+		if err = rows.Scan(&filePath, &additions, &deletions, &oldPath, &newPath, &similarity); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+		results = append(results, fmt.Sprintf("%s +%d -%d %s..%s %v", filePath, additions, deletions, oldPath.String, newPath.String, similarity.Valid && similarity.Int64 > 50))
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err.Error())
+	}
+
+	expected := []string{"new.txt +1 -0 old.txt..new.txt true", "removed.txt +0 -1 removed.txt.. false"}
+	if len(results) != len(expected) || results[0] != expected[0] || results[1] != expected[1] {
+		t.Fatalf("unexpected stats: %q, expected %q", results, expected)
+	}
+}
@@ -0,0 +1,182 @@
+package native
+
+import (
+	"io"
+
+	"github.com/augmentable-dev/vtab"
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/extensions/internal/sqlutil"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var statusCols = []vtab.Column{
+	{Name: "path", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "old_path", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "staged", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "unstaged", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "untracked", Type: "BOOLEAN", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "conflicted", Type: "BOOLEAN", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// NewStatusModule returns the implementation of a table-valued-function reporting the status of the working tree
+// and index, similar to `git status --porcelain`. The staged and unstaged columns hold one of added, modified,
+// deleted, renamed or typechange (or NULL if there are no changes), while untracked files are flagged separately.
+func NewStatusModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("status", statusCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 6 {
+				repoPath = constraint.Value.Text()
+			}
+		}
+
+		if repoPath == "" {
+			var err error
+			repoPath, err = options.GetRepoPath()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return newStatusIter(options, repoPath)
+	})
+}
+
+type fileStatus struct {
+	path, oldPath       string
+	staged, unstaged    string
+	untracked, conflict bool
+}
+
+func newStatusIter(options *utils.ModuleOptions, repoPath string) (*statusIter, error) {
+	logger := options.Logger.With().
+		Str("module", "git-status").
+		Str("repo-path", repoPath).
+		Logger()
+	defer func() {
+		logger.Debug().Msg("creating status iterator")
+	}()
+
+	repo, err := openRepo(options, repoPath, "status")
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Free()
+
+	if repo.IsBare() {
+		return nil, errors.New("status table is not supported on bare repositories")
+	}
+
+	list, err := repo.StatusList(&libgit2.StatusOptions{
+		Show: libgit2.StatusShowIndexAndWorkdir,
+		Flags: libgit2.StatusOptIncludeUntracked | libgit2.StatusOptRecurseUntrackedDirs |
+			libgit2.StatusOptRenamesHeadToIndex | libgit2.StatusOptSortCaseSensitively,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer list.Free()
+
+	count, err := list.EntryCount()
+	if err != nil {
+		return nil, err
+	}
+
+	var files = make([]*fileStatus, 0, count)
+	for i := 0; i < count; i++ {
+		entry, err := list.ByIndex(i)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, toFileStatus(entry))
+	}
+
+	return &statusIter{files: files, index: -1}, nil
+}
+
+// toFileStatus converts the libgit2 status flags of a single entry into the table's representation
+func toFileStatus(entry libgit2.StatusEntry) *fileStatus {
+	var s = &fileStatus{
+		untracked: entry.Status&libgit2.StatusWtNew != 0,
+		conflict:  entry.Status&libgit2.StatusConflicted != 0,
+	}
+
+	switch {
+	case entry.Status&libgit2.StatusIndexNew != 0:
+		s.staged = "added"
+	case entry.Status&libgit2.StatusIndexModified != 0:
+		s.staged = "modified"
+	case entry.Status&libgit2.StatusIndexDeleted != 0:
+		s.staged = "deleted"
+	case entry.Status&libgit2.StatusIndexRenamed != 0:
+		s.staged = "renamed"
+	case entry.Status&libgit2.StatusIndexTypeChange != 0:
+		s.staged = "typechange"
+	}
+
+	switch {
+	case entry.Status&libgit2.StatusWtModified != 0:
+		s.unstaged = "modified"
+	case entry.Status&libgit2.StatusWtDeleted != 0:
+		s.unstaged = "deleted"
+	case entry.Status&libgit2.StatusWtRenamed != 0:
+		s.unstaged = "renamed"
+	case entry.Status&libgit2.StatusWtTypeChange != 0:
+		s.unstaged = "typechange"
+	}
+
+	// prefer the path in the working tree, falling back to the index for staged deletions
+	if entry.IndexToWorkdir.NewFile.Path != "" {
+		s.path = entry.IndexToWorkdir.NewFile.Path
+	} else {
+		s.path = entry.HeadToIndex.NewFile.Path
+	}
+
+	if entry.Status&libgit2.StatusIndexRenamed != 0 {
+		s.oldPath = entry.HeadToIndex.OldFile.Path
+	}
+
+	return s
+}
+
+type statusIter struct {
+	files []*fileStatus
+	index int
+}
+
+func (i *statusIter) Column(ctx vtab.Context, c int) error {
+	file := i.files[i.index]
+	switch c {
+	case 0:
+		ctx.ResultText(file.path)
+	case 1:
+		if file.oldPath != "" {
+			ctx.ResultText(file.oldPath)
+		}
+	case 2:
+		if file.staged != "" {
+			ctx.ResultText(file.staged)
+		}
+	case 3:
+		if file.unstaged != "" {
+			ctx.ResultText(file.unstaged)
+		}
+	case 4:
+		ctx.ResultInt(sqlutil.BoolToInt(file.untracked))
+	case 5:
+		ctx.ResultInt(sqlutil.BoolToInt(file.conflict))
+	}
+	return nil
+}
+
+func (i *statusIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.files) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
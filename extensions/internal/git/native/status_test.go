@@ -0,0 +1,80 @@
+package native_test
+
+import (
+	"database/sql"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	var git = func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to run git %v: %v: %s", args, err, out)
+		}
+	}
+
+	var write = func(name, contents string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	git("init", "--quiet")
+	write("modified.txt", "one")
+	write("deleted.txt", "two")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "initial commit")
+
+	write("modified.txt", "changed")
+	write("staged.txt", "three")
+	write("untracked.txt", "four")
+	git("add", "staged.txt")
+	git("rm", "--quiet", "deleted.txt")
+
+	rows, err := db.Query("SELECT path, staged, unstaged, untracked FROM status(?)", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	var got = make(map[string][3]string)
+	for rows.Next() {
+		var path string
+		var staged, unstaged sql.NullString
+		var untracked bool
+		if err = rows.Scan(&path, &staged, &unstaged, &untracked); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+
+		var u string
+		if untracked {
+			u = "untracked"
+		}
+		got[path] = [3]string{staged.String, unstaged.String, u}
+	}
+
+	var expected = map[string][3]string{
+		"modified.txt":  {"", "modified", ""},
+		"deleted.txt":   {"deleted", "", ""},
+		"staged.txt":    {"added", "", ""},
+		"untracked.txt": {"", "", "untracked"},
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d entries, got %v", len(expected), got)
+	}
+
+	for path, status := range expected {
+		if got[path] != status {
+			t.Fatalf("expected status of %s to be %v, got %v", path, status, got[path])
+		}
+	}
+}
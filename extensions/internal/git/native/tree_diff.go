@@ -0,0 +1,174 @@
+package native
+
+import (
+	"io"
+
+	"github.com/augmentable-dev/vtab"
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var treeDiffCols = []vtab.Column{
+	{Name: "path", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "old_path", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "status", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "old_blob_hash", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "new_blob_hash", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "old_mode", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "new_mode", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "executable_changed", Type: "BOOLEAN", NotNull: true, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "ref_a", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "ref_b", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// NewTreeDiffModule returns the implementation of a table-valued-function listing the files that differ between the trees
+// of ref_a and ref_b (HEAD if not supplied), with their status (added, removed, modified, renamed, copied or typechange)
+// going from ref_a to ref_b, and their modes (as in 100644 or 100755) on both sides, flagging regular files whose
+// executable bit flipped. Only the two trees are compared, without walking the commits in between, making it a quick
+// way to list what changed between two releases.
+func NewTreeDiffModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("tree_diff", treeDiffCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, refA, refB string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch treeDiffCols[constraint.ColIndex].Name {
+				case "repository":
+					repoPath = constraint.Value.Text()
+				case "ref_a":
+					refA = constraint.Value.Text()
+				case "ref_b":
+					refB = constraint.Value.Text()
+				}
+			}
+		}
+
+		if refA == "" {
+			return nil, errors.New("ref_a must be supplied")
+		}
+
+		if repoPath == "" {
+			var err error
+			repoPath, err = options.GetRepoPath()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if refB == "" {
+			refB = utils.GetDefaultRefFromCtx(options.Context)
+		}
+
+		return newTreeDiffIter(options, repoPath, refA, refB)
+	})
+}
+
+func newTreeDiffIter(options *utils.ModuleOptions, repoPath, refA, refB string) (*treeDiffIter, error) {
+	logger := options.Logger.With().
+		Str("module", "git-tree-diff").
+		Str("repo-path", repoPath).
+		Str("ref-a", refA).
+		Str("ref-b", refB).
+		Logger()
+	defer func() {
+		logger.Debug().Msg("creating tree diff iterator")
+	}()
+
+	repo, err := openRepo(options, repoPath, "tree_diff")
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Free()
+
+	a, err := resolveCommit(repo, refA)
+	if err != nil {
+		return nil, err
+	}
+	defer a.Free()
+
+	b, err := resolveCommit(repo, refB)
+	if err != nil {
+		return nil, err
+	}
+	defer b.Free()
+
+	diff, err := diffCommits(repo, a, b)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = diff.Free() }()
+
+	var iter = &treeDiffIter{index: -1}
+	err = diff.ForEach(func(delta libgit2.DiffDelta, _ float64) (libgit2.DiffForEachHunkCallback, error) {
+		iter.deltas = append(iter.deltas, delta)
+		return nil, nil
+	}, libgit2.DiffDetailFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	return iter, nil
+}
+
+// deltaStatus returns the name of the status of a file in a diff
+func deltaStatus(status libgit2.Delta) string {
+	switch status {
+	case libgit2.DeltaAdded:
+		return "added"
+	case libgit2.DeltaDeleted:
+		return "removed"
+	case libgit2.DeltaModified:
+		return "modified"
+	case libgit2.DeltaRenamed:
+		return "renamed"
+	case libgit2.DeltaCopied:
+		return "copied"
+	case libgit2.DeltaTypeChange:
+		return "typechange"
+	default:
+		return "unknown"
+	}
+}
+
+type treeDiffIter struct {
+	deltas []libgit2.DiffDelta
+	index  int
+}
+
+func (i *treeDiffIter) Column(ctx vtab.Context, c int) error {
+	delta := i.deltas[i.index]
+	switch treeDiffCols[c].Name {
+	case "path":
+		ctx.ResultText(delta.NewFile.Path)
+	case "old_path":
+		ctx.ResultText(delta.OldFile.Path)
+	case "status":
+		ctx.ResultText(deltaStatus(delta.Status))
+	case "old_blob_hash":
+		if delta.Status != libgit2.DeltaAdded {
+			ctx.ResultText(delta.OldFile.Oid.String())
+		}
+	case "new_blob_hash":
+		if delta.Status != libgit2.DeltaDeleted {
+			ctx.ResultText(delta.NewFile.Oid.String())
+		}
+	case "old_mode":
+		resultFileMode(ctx, delta.OldFile.Mode)
+	case "new_mode":
+		resultFileMode(ctx, delta.NewFile.Mode)
+	case "executable_changed":
+		resultBool(ctx, executableChanged(delta.OldFile.Mode, delta.NewFile.Mode))
+	}
+	return nil
+}
+
+func (i *treeDiffIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.deltas) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
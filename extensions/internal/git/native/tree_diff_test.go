@@ -0,0 +1,89 @@
+package native_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTreeDiff(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	var git = func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to run git %v: %v: %s", args, err, out)
+		}
+	}
+
+	var write = func(name string, lines ...string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	git("init", "--quiet")
+	write("kept.txt", "kept")
+	write("modified.txt", "before")
+	write("removed.txt", "removed")
+	write("renamed.txt", "the", "contents", "of", "a", "file", "that", "gets", "renamed")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "initial commit")
+	git("tag", "v1")
+
+	write("modified.txt", "after")
+	git("commit", "--quiet", "-am", "modify a file")
+
+	write("added.txt", "added")
+	git("rm", "--quiet", "removed.txt")
+	git("mv", "renamed.txt", "moved.txt")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "add, remove and rename files")
+	git("tag", "v2")
+
+	rows, err := db.Query("SELECT path, old_path, status, old_blob_hash IS NULL, new_blob_hash IS NULL FROM tree_diff(?, 'v1', 'v2') ORDER BY path", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	var got []string
+	for rows.Next() {
+		var path, oldPath, status string
+		var noOld, noNew bool
+		if err := rows.Scan(&path, &oldPath, &status, &noOld, &noNew); err != nil {
+			t.Fatal(err)
+		}
+		if noOld != (status == "added") || noNew != (status == "removed") {
+			t.Fatalf("unexpected blob hashes for %s file %s", status, path)
+		}
+		got = append(got, status+" "+oldPath+" -> "+path)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"added added.txt -> added.txt",
+		"modified modified.txt -> modified.txt",
+		"renamed renamed.txt -> moved.txt",
+		"removed removed.txt -> removed.txt",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected changes:\n%s", strings.Join(got, "\n"))
+	}
+
+	// ref_b defaults to HEAD
+	var count int
+	if err = db.QueryRow("SELECT count(*) FROM tree_diff(?, 'v2')", dir).Scan(&count); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if count != 0 {
+		t.Fatalf("expected no changes between v2 and HEAD, got %d", count)
+	}
+}
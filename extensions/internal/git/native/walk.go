@@ -0,0 +1,91 @@
+package native
+
+import (
+	"path"
+
+	libgit2 "github.com/libgit2/git2go/v34"
+)
+
+// blobVisitor is called for every blob visited by a blobWalker, with the commit and path it was first found at
+type blobVisitor func(commit, path string, entry *libgit2.TreeEntry) error
+
+// blobWalker visits the blobs in the trees of commits. Trees and blobs that have already been visited
+// (in a previous commit, or elsewhere in the same tree) are skipped, so that each blob is visited only once
+// even when walking the entire history of a repository.
+type blobWalker struct {
+	repo  *libgit2.Repository
+	seen  map[libgit2.Oid]struct{}
+	visit blobVisitor
+}
+
+func newBlobWalker(repo *libgit2.Repository, visit blobVisitor) *blobWalker {
+	return &blobWalker{repo: repo, seen: make(map[libgit2.Oid]struct{}), visit: visit}
+}
+
+// walkHistory visits the blobs in the trees of all commits reachable from head. The oldest commits are visited first,
+// so that blobs are attributed to the commit that introduced them.
+func (w *blobWalker) walkHistory(head *libgit2.Commit) error {
+	walk, err := w.repo.Walk()
+	if err != nil {
+		return err
+	}
+	defer walk.Free()
+
+	walk.Sorting(libgit2.SortTopological | libgit2.SortReverse)
+	if err = walk.Push(head.Id()); err != nil {
+		return err
+	}
+
+	var walkErr error
+	err = walk.Iterate(func(c *libgit2.Commit) bool {
+		defer c.Free()
+		walkErr = w.walkCommit(c)
+		return walkErr == nil
+	})
+	if err != nil {
+		return err
+	}
+	return walkErr
+}
+
+// walkCommit visits the blobs in the tree of commit c
+func (w *blobWalker) walkCommit(c *libgit2.Commit) error {
+	tree, err := c.Tree()
+	if err != nil {
+		return err
+	}
+	return w.walkTree(c.Id().String(), "", tree)
+}
+
+func (w *blobWalker) walkTree(commit, prefix string, tree *libgit2.Tree) error {
+	defer tree.Free()
+	if _, ok := w.seen[*tree.Id()]; ok {
+		return nil
+	}
+	w.seen[*tree.Id()] = struct{}{}
+
+	for i := uint64(0); i < tree.EntryCount(); i++ {
+		entry := tree.EntryByIndex(i)
+		if _, ok := w.seen[*entry.Id]; ok {
+			continue
+		}
+
+		switch entry.Type {
+		case libgit2.ObjectTree:
+			subtree, err := w.repo.LookupTree(entry.Id)
+			if err != nil {
+				return err
+			}
+			if err = w.walkTree(commit, path.Join(prefix, entry.Name), subtree); err != nil {
+				return err
+			}
+		case libgit2.ObjectBlob:
+			w.seen[*entry.Id] = struct{}{}
+			if err := w.visit(commit, path.Join(prefix, entry.Name), entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package git
+
+import (
+	"io"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var notesCols = []vtab.Column{
+	{Name: "hash", Type: "TEXT"},
+	{Name: "note", Type: "TEXT"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "notes_ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// defaultNotesRef is the notes ref read when none is supplied, as in git notes
+const defaultNotesRef = "refs/notes/commits"
+
+// note is the note attached to an object
+type note struct {
+	hash     string
+	contents string
+}
+
+type notesIter struct {
+	notes []*note
+	index int
+}
+
+func (i *notesIter) Column(ctx vtab.Context, c int) error {
+	current := i.notes[i.index]
+	switch notesCols[c].Name {
+	case "hash":
+		ctx.ResultText(current.hash)
+	case "note":
+		ctx.ResultText(current.contents)
+	}
+	return nil
+}
+
+func (i *notesIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.notes) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewNotesModule returns the implementation of a table-valued-function listing the notes of notes_ref (refs/notes/commits
+// if not supplied, and prefixed with refs/notes/ if it's a short name, as in git notes --ref), with the hash of the object
+// (typically a commit) each note is attached to. A notes ref that doesn't exist has no notes.
+func NewNotesModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("notes", notesCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, notesRef string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 2:
+					repoPath = constraint.Value.Text()
+				case 3:
+					notesRef = constraint.Value.Text()
+				}
+			}
+		}
+
+		switch {
+		case notesRef == "":
+			notesRef = defaultNotesRef
+		case !strings.HasPrefix(notesRef, "refs/"):
+			notesRef = "refs/notes/" + notesRef
+		}
+
+		_, repo, err := openFnRepo(options, repoPath)
+		if err != nil {
+			return nil, err
+		}
+
+		var iter = &notesIter{index: -1}
+		ref, err := repo.Reference(plumbing.ReferenceName(notesRef), true)
+		if err == plumbing.ErrReferenceNotFound {
+			return iter, nil
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve %q", notesRef)
+		}
+
+		commit, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not lookup commit of %s", notesRef)
+		}
+		tree, err := commit.Tree()
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not lookup tree of %s", notesRef)
+		}
+
+		// notes are named by the hash of the object they're attached to, split into directories (such as ab/cdef...)
+		// once there are many of them
+		err = tree.Files().ForEach(func(f *object.File) error {
+			hash := strings.ReplaceAll(f.Name, "/", "")
+			if !plumbing.IsHash(hash) {
+				return nil
+			}
+
+			contents, err := f.Contents()
+			if err != nil {
+				return errors.Wrapf(err, "could not retrieve the note of %s", hash)
+			}
+			iter.notes = append(iter.notes, &note{hash: hash, contents: contents})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return iter, nil
+	})
+}
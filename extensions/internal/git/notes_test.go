@@ -0,0 +1,58 @@
+package git_test
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestNotes(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	var git = func(args ...string) string {
+		t.Helper()
+		args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		out, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	git("init", "--quiet")
+	git("commit", "--quiet", "--allow-empty", "-m", "first")
+	first := git("rev-parse", "HEAD")
+	git("commit", "--quiet", "--allow-empty", "-m", "second")
+	git("notes", "add", "-m", "reviewed-by: jane", "HEAD~1")
+	git("notes", "--ref", "build", "add", "-m", "build: passed", "HEAD")
+
+	rows, err := db.Query("SELECT hash, note FROM notes(?)", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+	if len(contents) != 1 || contents[0][0] != first || contents[0][1] != "reviewed-by: jane\n" {
+		t.Fatalf("expected the note of the first commit, got: %v", contents)
+	}
+
+	var note string
+	if err = db.QueryRow("SELECT note FROM notes(?, 'build')", dir).Scan(&note); err != nil {
+		t.Fatal(err)
+	}
+	if note != "build: passed\n" {
+		t.Fatalf("unexpected build note: %q", note)
+	}
+
+	var count int
+	if err = db.QueryRow("SELECT count(*) FROM notes(?, 'refs/notes/missing')", dir).Scan(&count); err != nil || count != 0 {
+		t.Fatalf("expected a missing notes ref to have no notes, got: %d (%v)", count, err)
+	}
+}
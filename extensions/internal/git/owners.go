@@ -0,0 +1,251 @@
+package git
+
+import (
+	"io"
+	"path"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/pkg/owners"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// ownersAliasesFile is the file (at the root of a repository) defining the aliases OWNERS files may list
+const ownersAliasesFile = "OWNERS_ALIASES"
+
+var ownersCols = []vtab.Column{
+	{Name: "path", Type: "TEXT"},
+	{Name: "directory", Type: "TEXT"},
+	{Name: "owner", Type: "TEXT"},
+	{Name: "role", Type: "TEXT"},
+	{Name: "alias", Type: "TEXT"},
+	{Name: "filter", Type: "TEXT"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// owner is an owner listed in the OWNERS file at path
+type owner struct {
+	path string
+	owners.Owner
+}
+
+type ownersIter struct {
+	owners []*owner
+	index  int
+}
+
+func (i *ownersIter) Column(ctx vtab.Context, c int) error {
+	current := i.owners[i.index]
+
+	var value string
+	switch ownersCols[c].Name {
+	case "path":
+		value = current.path
+	case "directory":
+		value = path.Dir(current.path)
+	case "owner":
+		value = current.Login
+	case "role":
+		value = current.Role
+	case "alias":
+		value = current.Alias
+	case "filter":
+		value = current.Filter
+	}
+
+	if value != "" {
+		ctx.ResultText(value)
+	}
+	return nil
+}
+
+func (i *ownersIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.owners) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewOwnersModule returns the implementation of a table-valued-function listing the owners (approvers, reviewers and
+// emeritus approvers) of the directories of Kubernetes-style OWNERS files at ref (the default ref, or HEAD, if not supplied),
+// with the aliases of OWNERS_ALIASES expanded into their members. Owners of only some of the files of a directory
+// (listed under the filters of an OWNERS file) have the regular expression matching those files as filter.
+func NewOwnersModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("owners", ownersCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 6:
+					repoPath = constraint.Value.Text()
+				case 7:
+					ref = constraint.Value.Text()
+				}
+			}
+		}
+
+		tree, err := options.LookupTree(repoPath, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		var aliases map[string][]string
+		if f, err := tree.File(ownersAliasesFile); err == nil {
+			contents, err := f.Contents()
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not retrieve contents of %s", ownersAliasesFile)
+			}
+			if aliases, err = owners.ParseAliases(contents); err != nil {
+				return nil, err
+			}
+		} else if err != object.ErrFileNotFound {
+			return nil, errors.Wrapf(err, "could not lookup %s", ownersAliasesFile)
+		}
+
+		var listed []*owner
+		err = tree.Files().ForEach(func(f *object.File) error {
+			if path.Base(f.Name) != "OWNERS" {
+				return nil
+			}
+
+			contents, err := f.Contents()
+			if err != nil {
+				return errors.Wrapf(err, "could not retrieve contents of %s", f.Name)
+			}
+
+			parsed, err := owners.ParseOwners(contents, aliases)
+			if err != nil {
+				return errors.Wrapf(err, "could not parse %s", f.Name)
+			}
+
+			for _, o := range parsed {
+				listed = append(listed, &owner{path: f.Name, Owner: o})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &ownersIter{owners: listed, index: -1}, nil
+	})
+}
+
+var maintainersCols = []vtab.Column{
+	{Name: "path", Type: "TEXT"},
+	{Name: "line", Type: "INT"},
+	{Name: "section", Type: "TEXT"},
+	{Name: "pattern", Type: "TEXT"},
+	{Name: "name", Type: "TEXT"},
+	{Name: "owner", Type: "TEXT"},
+	{Name: "role", Type: "TEXT"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// maintainer is a maintainer listed in the MAINTAINERS file at path
+type maintainer struct {
+	path string
+	owners.Maintainer
+}
+
+type maintainersIter struct {
+	maintainers []*maintainer
+	index       int
+}
+
+func (i *maintainersIter) Column(ctx vtab.Context, c int) error {
+	current := i.maintainers[i.index]
+
+	var value string
+	switch maintainersCols[c].Name {
+	case "path":
+		value = current.path
+	case "line":
+		ctx.ResultInt(current.Line)
+		return nil
+	case "section":
+		value = current.Section
+	case "pattern":
+		value = current.Pattern
+	case "name":
+		value = current.Name
+	case "owner":
+		value = current.Owner
+	case "role":
+		value = current.Role
+	}
+
+	if value != "" {
+		ctx.ResultText(value)
+	}
+	return nil
+}
+
+func (i *maintainersIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.maintainers) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// isMaintainersFile reports whether name is the name of a MAINTAINERS file, as in MAINTAINERS, MAINTAINERS.md or maintainers.txt
+func isMaintainersFile(name string) bool {
+	ext := path.Ext(name)
+	return strings.EqualFold(strings.TrimSuffix(name, ext), "MAINTAINERS") && (ext == "" || ext == ".md" || ext == ".txt")
+}
+
+// NewMaintainersModule returns the implementation of a table-valued-function listing the people listed in the MAINTAINERS
+// files (MAINTAINERS, MAINTAINERS.md or MAINTAINERS.txt) at ref (the default ref, or HEAD, if not supplied), by their GitHub
+// login (as @login) or email address. Files in the format of the Linux kernel list a row for each of the file patterns
+// of the section a maintainer is listed in.
+func NewMaintainersModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("maintainers", maintainersCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 7:
+					repoPath = constraint.Value.Text()
+				case 8:
+					ref = constraint.Value.Text()
+				}
+			}
+		}
+
+		tree, err := options.LookupTree(repoPath, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		var listed []*maintainer
+		err = tree.Files().ForEach(func(f *object.File) error {
+			if !isMaintainersFile(path.Base(f.Name)) {
+				return nil
+			}
+
+			contents, err := f.Contents()
+			if err != nil {
+				return errors.Wrapf(err, "could not retrieve contents of %s", f.Name)
+			}
+
+			for _, m := range owners.ParseMaintainers(contents) {
+				listed = append(listed, &maintainer{path: f.Name, Maintainer: m})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &maintainersIter{maintainers: listed, index: -1}, nil
+	})
+}
@@ -0,0 +1,79 @@
+package git_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestOwners(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := commitFiles(t, map[string]string{
+		"OWNERS_ALIASES": "aliases:\n  api-approvers: [alice, bob]\n",
+		"OWNERS":         "approvers: [carol]\nreviewers: [dave]\n",
+		"pkg/api/OWNERS": "approvers: [api-approvers]\nfilters:\n  \"\\\\.proto$\":\n    reviewers: [erin]\n",
+	})
+
+	rows, err := db.Query("SELECT path, directory, owner, role, alias, filter FROM owners(?)", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{"OWNERS", ".", "carol", "approver", "NULL", "NULL"},
+		{"OWNERS", ".", "dave", "reviewer", "NULL", "NULL"},
+		{"pkg/api/OWNERS", "pkg/api", "alice", "approver", "api-approvers", "NULL"},
+		{"pkg/api/OWNERS", "pkg/api", "bob", "approver", "api-approvers", "NULL"},
+		{"pkg/api/OWNERS", "pkg/api", "erin", "reviewer", "NULL", `\.proto$`},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d owners, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+}
+
+func TestMaintainers(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := commitFiles(t, map[string]string{
+		"MAINTAINERS.md": "# Maintainers\n\n* Jane Doe (@jdoe)\n\n## Emeritus\n\n* Old Timer <old@example.com>\n",
+		"docs/README.md": "Contact @jdoe for questions.\n",
+	})
+
+	rows, err := db.Query("SELECT path, line, section, pattern, name, owner, role FROM maintainers(?)", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{"MAINTAINERS.md", "3", "Maintainers", "NULL", "Jane Doe", "@jdoe", "maintainer"},
+		{"MAINTAINERS.md", "7", "Emeritus", "NULL", "Old Timer", "old@example.com", "emeritus_maintainer"},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d maintainers, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+}
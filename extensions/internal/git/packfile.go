@@ -0,0 +1,145 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/pkg/errors"
+)
+
+// newPackfileCommitIter returns an iterator over the commits reachable from from, read straight out of the packfiles
+// (and loose objects) of repo in the order they're stored in, rather than by walking the history. This avoids seeking
+// back and forth across packfiles, which makes whole-history scans faster, but returns commits in no particular order.
+//
+// The objects are read twice: once to index the parents of every commit (reading only their headers), from which the
+// commits reachable from from are worked out, and once more to decode and return those commits, one at a time.
+func newPackfileCommitIter(repo *git.Repository, from plumbing.Hash) (object.CommitIter, error) {
+	parents, err := readCommitParents(repo.Storer)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := parents[from]; !ok {
+		return nil, errors.Errorf("could not lookup commit %s", from)
+	}
+
+	// commits of other branches, or that are unreachable, are stored too
+	var reachable = make(map[plumbing.Hash]struct{}, len(parents))
+	var stack = []plumbing.Hash{from}
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if _, ok := reachable[hash]; ok {
+			continue
+		}
+		// parents missing from the repository (as at the boundary of a shallow clone) end the history
+		p, ok := parents[hash]
+		if !ok {
+			continue
+		}
+		reachable[hash] = struct{}{}
+		stack = append(stack, p...)
+	}
+
+	iter, err := repo.Storer.IterEncodedObjects(plumbing.CommitObject)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to iterate over commits")
+	}
+	return &packfileCommitIter{s: repo.Storer, iter: iter, reachable: reachable}, nil
+}
+
+// readCommitParents reads the hashes of the parents of every commit stored in s. Only the headers of the commits are read,
+// as that's where their parents are listed.
+func readCommitParents(s storer.EncodedObjectStorer) (map[plumbing.Hash][]plumbing.Hash, error) {
+	iter, err := s.IterEncodedObjects(plumbing.CommitObject)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to iterate over commits")
+	}
+	defer iter.Close()
+
+	var parents = make(map[plumbing.Hash][]plumbing.Hash)
+	var br = bufio.NewReader(nil)
+	err = iter.ForEach(func(obj plumbing.EncodedObject) error {
+		// the same commit may be stored more than once (such as in a loose object and a packfile)
+		if _, ok := parents[obj.Hash()]; ok {
+			return nil
+		}
+
+		r, err := obj.Reader()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		var p []plumbing.Hash
+		br.Reset(r)
+		for {
+			// other headers may not fit in the buffer, but are skipped anyway
+			line, err := br.ReadSlice('\n')
+			if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+				return err
+			}
+			if bytes.HasPrefix(line, []byte("parent ")) {
+				p = append(p, plumbing.NewHash(string(bytes.TrimSpace(line[len("parent "):]))))
+			} else if !bytes.HasPrefix(line, []byte("tree ")) || err == io.EOF {
+				// parents are listed right after the tree, before the rest of the headers
+				break
+			}
+		}
+		parents[obj.Hash()] = p
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read commits")
+	}
+	return parents, nil
+}
+
+// packfileCommitIter is an object.CommitIter over the reachable commits of an iterator of stored objects
+type packfileCommitIter struct {
+	s         storer.EncodedObjectStorer
+	iter      storer.EncodedObjectIter
+	reachable map[plumbing.Hash]struct{} // commits yet to be returned
+}
+
+func (iter *packfileCommitIter) Next() (*object.Commit, error) {
+	for {
+		obj, err := iter.iter.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := iter.reachable[obj.Hash()]; !ok {
+			continue
+		}
+		// forgetting returned commits skips any other copy of them
+		delete(iter.reachable, obj.Hash())
+		return object.DecodeCommit(iter.s, obj)
+	}
+}
+
+func (iter *packfileCommitIter) ForEach(fn func(*object.Commit) error) error {
+	defer iter.Close()
+	for {
+		commit, err := iter.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err = fn(commit); err == storer.ErrStop {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (iter *packfileCommitIter) Close() { iter.iter.Close() }
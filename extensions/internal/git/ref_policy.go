@@ -0,0 +1,224 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/pkg/glob"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var refPolicyCols = []vtab.Column{
+	{Name: "ref", Type: "TEXT"},
+	{Name: "name", Type: "TEXT"},
+	{Name: "type", Type: "TEXT"},
+	{Name: "rule", Type: "TEXT"},
+	{Name: "message", Type: "TEXT"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "policy", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// refRules are the naming rules the names of a type of ref (branches or tags) are checked against
+type refRules struct {
+	Allow     []string `json:"allow"`      // globs names must match one of (if any)
+	Deny      []string `json:"deny"`       // globs names must not match
+	Regexp    string   `json:"regexp"`     // a regular expression names must match
+	MaxLength int      `json:"max_length"` // the maximum length (in characters) of names
+
+	allow, deny []*regexp.Regexp
+	re          *regexp.Regexp
+}
+
+// compile compiles the patterns of the rules
+func (r *refRules) compile() (err error) {
+	for _, list := range []struct {
+		globs    []string
+		compiled *[]*regexp.Regexp
+	}{{r.Allow, &r.allow}, {r.Deny, &r.deny}} {
+		for _, pattern := range list.globs {
+			re, err := glob.Compile(pattern)
+			if err != nil {
+				return err
+			}
+			*list.compiled = append(*list.compiled, re)
+		}
+	}
+
+	if r.Regexp != "" {
+		if r.re, err = regexp.Compile(r.Regexp); err != nil {
+			return errors.Wrapf(err, "invalid regexp %q", r.Regexp)
+		}
+	}
+	return nil
+}
+
+// refViolation is a rule the name of a ref violates
+type refViolation struct {
+	ref, name, refType string
+	rule, message      string
+}
+
+// check returns the rules name (the name of a ref of refType) violates
+func (r *refRules) check(ref, name, refType string) []*refViolation {
+	var violations []*refViolation
+	violation := func(rule, format string, args ...interface{}) {
+		violations = append(violations, &refViolation{ref: ref, name: name, refType: refType, rule: rule, message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(r.allow) > 0 {
+		var allowed bool
+		for _, re := range r.allow {
+			allowed = allowed || re.MatchString(name)
+		}
+		if !allowed {
+			violation("allow", "%s %q matches none of the allowed patterns", refType, name)
+		}
+	}
+
+	for d, re := range r.deny {
+		if re.MatchString(name) {
+			violation("deny", "%s %q matches the denied pattern %q", refType, name, r.Deny[d])
+		}
+	}
+
+	if r.re != nil && !r.re.MatchString(name) {
+		violation("regexp", "%s %q doesn't match %q", refType, name, r.Regexp)
+	}
+
+	if length := utf8.RuneCountInString(name); r.MaxLength > 0 && length > r.MaxLength {
+		violation("max_length", "%s %q is %d characters long, more than %d", refType, name, length, r.MaxLength)
+	}
+
+	return violations
+}
+
+// refPolicy holds the naming rules of branches and tags
+type refPolicy struct {
+	Branches *refRules `json:"branches"`
+	Tags     *refRules `json:"tags"`
+}
+
+// parseRefPolicy parses a policy, as in {"branches": {"allow": ["main", "feature/**"], "max_length": 50}, "tags": {"regexp": "^v[0-9]"}}
+func parseRefPolicy(policy string) (*refPolicy, error) {
+	var p refPolicy
+	decoder := json.NewDecoder(strings.NewReader(policy))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&p); err != nil {
+		return nil, errors.Wrap(err, "invalid ref policy")
+	}
+
+	for _, rules := range []*refRules{p.Branches, p.Tags} {
+		if rules == nil {
+			continue
+		}
+		if err := rules.compile(); err != nil {
+			return nil, errors.Wrap(err, "invalid ref policy")
+		}
+	}
+	return &p, nil
+}
+
+type refPolicyIter struct {
+	violations []*refViolation
+	index      int
+}
+
+func (i *refPolicyIter) Column(ctx vtab.Context, c int) error {
+	current := i.violations[i.index]
+	switch refPolicyCols[c].Name {
+	case "ref":
+		ctx.ResultText(current.ref)
+	case "name":
+		ctx.ResultText(current.name)
+	case "type":
+		ctx.ResultText(current.refType)
+	case "rule":
+		ctx.ResultText(current.rule)
+	case "message":
+		ctx.ResultText(current.message)
+	}
+	return nil
+}
+
+func (i *refPolicyIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.violations) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewRefPolicyCheckModule returns the implementation of a table-valued-function checking the names of the branches
+// (including remote-tracking ones, by the name of the branch on the remote) and tags of a repository against a policy
+// of naming rules (a JSON object), listing the violations. Rules are set for branches and tags independently, as in
+// {"branches": {"allow": ["main", "release/*", "feature/**"], "deny": ["tmp/*"], "regexp": "^[a-z0-9/._-]+$", "max_length": 50}},
+// with the patterns of allow and deny lists matched as in glob_match.
+func NewRefPolicyCheckModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("ref_policy_check", refPolicyCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, policyJSON string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 5:
+					repoPath = constraint.Value.Text()
+				case 6:
+					policyJSON = constraint.Value.Text()
+				}
+			}
+		}
+
+		if policyJSON == "" {
+			return nil, errors.New("a ref policy must be supplied")
+		}
+
+		policy, err := parseRefPolicy(policyJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		_, repo, err := openFnRepo(options, repoPath)
+		if err != nil {
+			return nil, err
+		}
+
+		refs, err := repo.References()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create iterator")
+		}
+
+		var violations []*refViolation
+		err = refs.ForEach(func(ref *plumbing.Reference) error {
+			var name = ref.Name()
+			switch {
+			case name.IsBranch() && policy.Branches != nil:
+				violations = append(violations, policy.Branches.check(name.String(), name.Short(), "branch")...)
+			case isRemoteBranch(name) && policy.Branches != nil && ref.Type() != plumbing.SymbolicReference:
+				// the name of the branch on the remote, without the name of the remote
+				if parts := strings.SplitN(strings.TrimPrefix(name.String(), "refs/remotes/"), "/", 2); len(parts) == 2 {
+					violations = append(violations, policy.Branches.check(name.String(), parts[1], "branch")...)
+				}
+			case name.IsTag() && policy.Tags != nil:
+				violations = append(violations, policy.Tags.check(name.String(), name.Short(), "tag")...)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// references are iterated in no particular order
+		sort.SliceStable(violations, func(i, j int) bool { return violations[i].ref < violations[j].ref })
+
+		return &refPolicyIter{violations: violations, index: -1}, nil
+	})
+}
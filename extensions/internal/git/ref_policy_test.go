@@ -0,0 +1,70 @@
+package git_test
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestRefPolicyCheck(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := commitFiles(t, map[string]string{"README.md": "# policy\n"})
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"refs/heads/feature/api/login", "refs/heads/tmp/experiment", "refs/heads/Fix_Parser", "refs/remotes/origin/release/v1"} {
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.ReferenceName(name), head.Hash())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tag := range []string{"v1.0.0", "latest"} {
+		if _, err := repo.CreateTag(tag, head.Hash(), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	policy := `{
+		"branches": {"allow": ["master", "main", "release/*", "feature/**", "tmp/*"], "deny": ["tmp/*"], "regexp": "^[a-z0-9/._-]+$"},
+		"tags": {"allow": ["v*"]}
+	}`
+	rows, err := db.Query("SELECT ref, name, type, rule FROM ref_policy_check(?, ?)", dir, policy)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{"refs/heads/Fix_Parser", "Fix_Parser", "branch", "allow"},
+		{"refs/heads/Fix_Parser", "Fix_Parser", "branch", "regexp"},
+		{"refs/heads/tmp/experiment", "tmp/experiment", "branch", "deny"},
+		{"refs/tags/latest", "latest", "tag", "allow"},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d violations, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+
+	var violations int
+	if err := db.QueryRow("SELECT count(*) FROM ref_policy_check(?, ?)", dir, `{"branch": {}}`).Scan(&violations); err == nil {
+		t.Fatal("expected an invalid policy to fail")
+	}
+}
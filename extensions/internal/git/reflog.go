@@ -0,0 +1,209 @@
+package git
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var reflogCols = []vtab.Column{
+	{Name: "ref_name", Type: "TEXT"},
+	{Name: "old_hash", Type: "TEXT"},
+	{Name: "new_hash", Type: "TEXT"},
+	{Name: "committer_name", Type: "TEXT"},
+	{Name: "committer_email", Type: "TEXT"},
+	{Name: "committer_when", Type: "DATETIME"},
+	{Name: "action", Type: "TEXT"},
+	{Name: "message", Type: "TEXT"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// reflogEntry is an update of a ref, as recorded in its reflog
+type reflogEntry struct {
+	ref       string
+	old, new  plumbing.Hash
+	committer object.Signature
+	message   string
+}
+
+type reflogIter struct {
+	entries []*reflogEntry
+	index   int
+}
+
+func (i *reflogIter) Column(ctx vtab.Context, c int) error {
+	current := i.entries[i.index]
+	switch reflogCols[c].Name {
+	case "ref_name":
+		ctx.ResultText(current.ref)
+	case "old_hash":
+		// the ref was created by the update
+		if !current.old.IsZero() {
+			ctx.ResultText(current.old.String())
+		}
+	case "new_hash":
+		// the ref was deleted by the update
+		if !current.new.IsZero() {
+			ctx.ResultText(current.new.String())
+		}
+	case "committer_name":
+		ctx.ResultText(current.committer.Name)
+	case "committer_email":
+		ctx.ResultText(current.committer.Email)
+	case "committer_when":
+		ctx.ResultText(current.committer.When.Format(time.RFC3339))
+	case "action":
+		if action, _, ok := strings.Cut(current.message, ":"); ok {
+			ctx.ResultText(action)
+		}
+	case "message":
+		ctx.ResultText(current.message)
+	}
+	return nil
+}
+
+func (i *reflogIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.entries) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewReflogModule returns the implementation of a table-valued-function listing the reflog of ref (such as HEAD or
+// refs/heads/main), or of every ref with one if not supplied, newest first. Each row is an update of a ref, from old_hash
+// to new_hash (NULL when the ref was created or deleted), with the action recorded in its message (such as commit,
+// reset, rebase (finish) or pull), e.g. to audit branch resets. Repositories that aren't on disk have no reflog.
+func NewReflogModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("reflog", reflogCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 8:
+					repoPath = constraint.Value.Text()
+				case 9:
+					ref = constraint.Value.Text()
+				}
+			}
+		}
+
+		_, repo, err := openFnRepo(options, repoPath)
+		if err != nil {
+			return nil, err
+		}
+
+		var iter = &reflogIter{index: -1}
+		if fs, ok := repo.Storer.(*filesystem.Storage); ok {
+			if iter.entries, err = readReflogs(fs.Filesystem(), ref); err != nil {
+				return nil, err
+			}
+		}
+		return iter, nil
+	})
+}
+
+// readReflogs reads the reflog of ref from the logs directory of fs, or the reflogs of every ref if ref is empty
+// (HEAD first, then the other refs by name), each newest first
+func readReflogs(fs billy.Filesystem, ref string) ([]*reflogEntry, error) {
+	var refs = []string{ref}
+	if ref == "" {
+		var err error
+		if refs, err = listReflogs(fs, "logs", ""); err != nil {
+			return nil, err
+		}
+		sort.Slice(refs, func(i, j int) bool {
+			if (refs[i] == "HEAD") != (refs[j] == "HEAD") {
+				return refs[i] == "HEAD"
+			}
+			return refs[i] < refs[j]
+		})
+	}
+
+	var entries []*reflogEntry
+	for _, name := range refs {
+		f, err := fs.Open(path.Join("logs", name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to read the reflog of %s", name)
+		}
+
+		var log []*reflogEntry
+		log, err = parseReflog(bufio.NewScanner(f), name)
+		_ = f.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the reflog of %s", name)
+		}
+		entries = append(entries, log...)
+	}
+	return entries, nil
+}
+
+// listReflogs returns the names of the refs with a reflog in dir (a directory under the logs directory of fs, for refs
+// starting with prefix)
+func listReflogs(fs billy.Filesystem, dir, prefix string) ([]string, error) {
+	files, err := fs.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to list reflogs")
+	}
+
+	var refs []string
+	for _, file := range files {
+		if file.IsDir() {
+			nested, err := listReflogs(fs, path.Join(dir, file.Name()), prefix+file.Name()+"/")
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, nested...)
+		} else {
+			refs = append(refs, prefix+file.Name())
+		}
+	}
+	return refs, nil
+}
+
+// parseReflog parses the lines of the reflog of ref, each holding the old and new hashes of the ref, the identity
+// of the committer and when the update was made, and a message, as in:
+//
+//	<old> <new> Name <email> 1651234567 +0200\tcommit: fix a bug
+//
+// and returns its entries, newest first
+func parseReflog(scanner *bufio.Scanner, ref string) ([]*reflogEntry, error) {
+	var entries []*reflogEntry
+	for scanner.Scan() {
+		line, message, _ := strings.Cut(scanner.Text(), "\t")
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 3 {
+			continue
+		}
+
+		var entry = &reflogEntry{ref: ref, old: plumbing.NewHash(fields[0]), new: plumbing.NewHash(fields[1]), message: message}
+		entry.committer.Decode([]byte(fields[2]))
+		entries = append(entries, entry)
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, scanner.Err()
+}
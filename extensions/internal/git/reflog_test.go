@@ -0,0 +1,65 @@
+package git_test
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestReflog(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	var git = func(args ...string) {
+		t.Helper()
+		args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	git("init", "--quiet", "-b", "main")
+	git("commit", "--quiet", "--allow-empty", "-m", "first")
+	git("commit", "--quiet", "--allow-empty", "-m", "second")
+	git("reset", "--quiet", "--hard", "HEAD~1")
+
+	rows, err := db.Query("SELECT ref_name, old_hash IS NULL, old_hash = new_hash, committer_email, action, message FROM reflog(?)", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{"HEAD", "0", "0", "test@example.com", "reset", "reset: moving to HEAD~1"},
+		{"HEAD", "0", "0", "test@example.com", "commit", "commit: second"},
+		{"HEAD", "1", "NULL", "test@example.com", "commit (initial)", "commit (initial): first"},
+		{"refs/heads/main", "0", "0", "test@example.com", "reset", "reset: moving to HEAD~1"},
+		{"refs/heads/main", "0", "0", "test@example.com", "commit", "commit: second"},
+		{"refs/heads/main", "1", "NULL", "test@example.com", "commit (initial)", "commit (initial): first"},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d reflog entries, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+
+	var count int
+	if err = db.QueryRow("SELECT count(*) FROM reflog(?, 'refs/heads/main') WHERE action = 'reset'", dir).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("expected a single reset of main, got: %d", count)
+	}
+}
@@ -32,11 +32,14 @@ func (mod *refModule) Connect(_ *sqlite.Conn, _ []string, declare func(string) e
 			full_name	TEXT,
 			hash		TEXT,
 			target		TEXT,
-			
+			symbolic	BOOLEAN,
+
 			repository	HIDDEN,
 			tag			HIDDEN,
-			PRIMARY KEY ( name )
-		) WITHOUT ROWID`
+			on_error	HIDDEN,
+
+			error		TEXT
+		)`
 
 	return &gitRefTable{ModuleOptions: mod.ModuleOptions}, declare(schema)
 }
@@ -52,13 +55,14 @@ func (tab *gitRefTable) Open() (sqlite.VirtualCursor, error) {
 }
 
 func (tab *gitRefTable) BestIndex(input *sqlite.IndexInfoInput) (*sqlite.IndexInfoOutput, error) {
+	var argv = 0
 	var bitmap []byte
 	var out = &sqlite.IndexInfoOutput{}
 	out.ConstraintUsage = make([]*sqlite.ConstraintUsage, len(input.Constraints))
 
 	for i, constraint := range input.Constraints {
-		// if repository is provided, it must be usable
-		if constraint.ColumnIndex == 6 && !constraint.Usable {
+		// if repository or on_error is provided, it must be usable
+		if (constraint.ColumnIndex == 7 || constraint.ColumnIndex == 9) && !constraint.Usable {
 			return nil, sqlite.SQLITE_CONSTRAINT
 		}
 
@@ -66,9 +70,10 @@ func (tab *gitRefTable) BestIndex(input *sqlite.IndexInfoInput) (*sqlite.IndexIn
 			continue // we do not support unusable constraint at all
 		}
 
-		if constraint.ColumnIndex == 6 && constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+		if (constraint.ColumnIndex == 7 || constraint.ColumnIndex == 9) && constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+			argv += 1
 			bitmap = append(bitmap, byte(1<<4|constraint.ColumnIndex))
-			out.ConstraintUsage[i] = &sqlite.ConstraintUsage{ArgvIndex: 1, Omit: true}
+			out.ConstraintUsage[i] = &sqlite.ConstraintUsage{ArgvIndex: argv, Omit: true}
 		}
 	}
 
@@ -82,31 +87,69 @@ type gitRefCursor struct {
 
 	repo *git.Repository
 
-	ref  *plumbing.Reference
-	refs storer.ReferenceIter
+	ref   *plumbing.Reference
+	refs  storer.ReferenceIter
+	rowid int64 // the rowid of the current ref, increasing from 1 with every ref returned by the cursor
+
+	onError string // how to handle errors reading the repository (see utils.OnErrorFail)
+	err     error  // the error reported by the current row, if any (in the null on_error mode)
+
+	releaseRepo func() // releases the repository pushed onto the statement context
 }
 
 func (cur *gitRefCursor) Filter(_ int, s string, values ...sqlite.Value) (err error) {
-	logger := cur.Logger.With().Str("module", "git-ref").Logger()
-	defer func() {
-		logger.Debug().Msg("running git refs filter")
-	}()
-
 	// values extracted from constraints
-	var path string
+	var path, onError string
 
 	var bitmap, _ = dec(s)
 	for i, val := range values {
 		switch b := bitmap[i]; b {
-		case 0b00010110:
+		case 0b00010111:
 			path = val.Text()
+		case 0b00011001:
+			onError = val.Text()
 		}
 	}
 
+	if onError == "" {
+		cur.onError, err = utils.GetOnErrorFromCtx(cur.Context)
+	} else {
+		cur.onError, err = utils.ParseOnError(onError)
+	}
+	if err != nil {
+		return err
+	}
+
+	cur.rowid, cur.err = 0, nil
+	return cur.handleError(cur.filter(path))
+}
+
+// handleError handles an error reading the repository, according to the on_error mode: the error either fails the query,
+// or ends the scan of the repository, with a last row reporting the error (in the null mode)
+func (cur *gitRefCursor) handleError(err error) error {
+	if err == nil || cur.onError == utils.OnErrorFail {
+		return err
+	}
+
+	cur.Logger.Warn().Err(err).Str("on-error", cur.onError).Msg("ending git refs scan early")
+	cur.ref = nil
+	if cur.onError == utils.OnErrorNull {
+		cur.err = err
+		cur.rowid++
+	}
+	return nil
+}
+
+func (cur *gitRefCursor) filter(path string) (err error) {
+	logger := cur.Logger.With().Str("module", "git-ref").Logger()
+	defer func() {
+		logger.Debug().Msg("running git refs filter")
+	}()
+
 	var repo *git.Repository
 	{ // open the git repository
 		if path == "" {
-			path, err = utils.GetDefaultRepoFromCtx(cur.Context)
+			path, err = cur.GetRepoPath()
 			if err != nil {
 				return err
 			}
@@ -117,6 +160,10 @@ func (cur *gitRefCursor) Filter(_ int, s string, values ...sqlite.Value) (err er
 		}
 		cur.repo = repo
 		logger = logger.With().Str("repo-disk-path", path).Logger()
+
+		// make the repository available to inner tables in a join, which weren't given one explicitly
+		cur.release()
+		cur.releaseRepo = cur.Statement.PushRepo(path)
 	}
 
 	if cur.refs, err = repo.References(); err != nil {
@@ -127,6 +174,14 @@ func (cur *gitRefCursor) Filter(_ int, s string, values ...sqlite.Value) (err er
 }
 
 func (cur *gitRefCursor) Column(c *sqlite.VirtualTableContext, col int) error {
+	if cur.err != nil {
+		// all columns but the error are NULL in rows reporting errors
+		if col == 10 {
+			c.ResultText(cur.err.Error())
+		}
+		return nil
+	}
+
 	ref := cur.ref
 	switch col {
 	case 0:
@@ -153,7 +208,14 @@ func (cur *gitRefCursor) Column(c *sqlite.VirtualTableContext, col int) error {
 		}
 	case 5:
 		c.ResultText(ref.Target().String())
-	case 7:
+	case 6:
+		// symbolic refs (such as HEAD, or refs/remotes/origin/HEAD) point to another ref, named in target
+		if ref.Type() == plumbing.SymbolicReference {
+			c.ResultInt(1)
+		} else {
+			c.ResultInt(0)
+		}
+	case 8:
 		if ref.Name().IsTag() {
 			if tag, err := cur.repo.TagObject(ref.Hash()); err != nil && err != plumbing.ErrObjectNotFound {
 				return errors.Wrap(err, "failed to fetch tag object")
@@ -166,20 +228,40 @@ func (cur *gitRefCursor) Column(c *sqlite.VirtualTableContext, col int) error {
 	return nil
 }
 
-func (cur *gitRefCursor) Next() (err error) {
+func (cur *gitRefCursor) Next() error {
+	// a row reporting an error is always the last one
+	if cur.err != nil {
+		cur.err = nil
+		return nil
+	}
+	return cur.handleError(cur.next())
+}
+
+func (cur *gitRefCursor) next() (err error) {
 	if cur.ref, err = cur.refs.Next(); err != nil {
 		if !eof(err) {
 			return err
 		}
+		return nil
 	}
+
+	cur.rowid++
 	return nil
 }
 
-func (cur *gitRefCursor) Eof() bool             { return cur.ref == nil }
-func (cur *gitRefCursor) Rowid() (int64, error) { return int64(0), nil }
+func (cur *gitRefCursor) Eof() bool             { return cur.ref == nil && cur.err == nil }
+func (cur *gitRefCursor) Rowid() (int64, error) { return cur.rowid, nil }
 func (cur *gitRefCursor) Close() error {
+	cur.release()
 	if cur.refs != nil {
 		cur.refs.Close()
 	}
 	return nil
 }
+
+func (cur *gitRefCursor) release() {
+	if cur.releaseRepo != nil {
+		cur.releaseRepo()
+		cur.releaseRepo = nil
+	}
+}
@@ -3,6 +3,8 @@ package git_test
 import (
 	"database/sql"
 	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
 )
 
 func TestSelectAllRefs(t *testing.T) {
@@ -17,15 +19,68 @@ func TestSelectAllRefs(t *testing.T) {
 
 	for rows.Next() {
 		var name, _type, remote sql.NullString
-		var fullName, hash, target sql.NullString
-		if err = rows.Scan(&name, &_type, &remote, &fullName, &hash, &target); err != nil {
+		var fullName, hash, target, refErr sql.NullString
+		var symbolic bool
+		if err = rows.Scan(&name, &_type, &remote, &fullName, &hash, &target, &symbolic, &refErr); err != nil {
 			t.Fatalf("failed to scan resultset: %v", err)
 		}
-		t.Logf("ref: name=%q type=%s fullName=%q hash=%q remote=%s target=%s",
-			name.String, _type.String, fullName.String, hash.String, remote.String, target.String)
+		t.Logf("ref: name=%q type=%s fullName=%q hash=%q remote=%s target=%s symbolic=%t",
+			name.String, _type.String, fullName.String, hash.String, remote.String, target.String, symbolic)
 	}
 
 	if err = rows.Err(); err != nil {
 		t.Fatalf("failed to fetch results: %v", err.Error())
 	}
 }
+
+func TestRefsOnError(t *testing.T) {
+	db := Connect(t, Memory)
+	notARepo := t.TempDir()
+
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM refs(?) WHERE on_error = 'skip'", notARepo).Scan(&count); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if count != 0 {
+		t.Fatalf("expected no ref to be returned, got %d", count)
+	}
+
+	var msg sql.NullString
+	if err := db.QueryRow("SELECT error FROM refs(?) WHERE on_error = 'null'", notARepo).Scan(&msg); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if !msg.Valid || msg.String == "" {
+		t.Fatal("expected a row reporting the error")
+	}
+}
+
+func TestRefsSymbolic(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := commitFiles(t, map[string]string{"README.md": "hello\n"})
+
+	rows, err := db.Query("SELECT full_name, hash IS NOT NULL, target, symbolic FROM refs(?) ORDER BY full_name", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{"HEAD", "0", "refs/heads/master", "1"},
+		{"refs/heads/master", "1", "", "0"},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d refs, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+}
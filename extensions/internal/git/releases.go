@@ -0,0 +1,246 @@
+package git
+
+import (
+	"context"
+	"io"
+	"math/bits"
+	"sort"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var releasesCols = []vtab.Column{
+	{Name: "name", Type: "TEXT"},
+	{Name: "full_name", Type: "TEXT"},
+	{Name: "hash", Type: "TEXT"},
+	{Name: "annotated", Type: "BOOLEAN"},
+	{Name: "tag_date", Type: "DATETIME"},
+	{Name: "previous", Type: "TEXT"},
+	{Name: "commits_since_previous", Type: "INT"},
+	{Name: "days_since_previous", Type: "REAL"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// release is a tag pointing (possibly through an annotated tag) at a commit
+type release struct {
+	ref       *plumbing.Reference
+	commit    plumbing.Hash
+	annotated bool
+	date      time.Time // date of the annotated tag, or of the commit of lightweight tags
+}
+
+type releasesIter struct {
+	releases []*release
+	commits  []int // number of commits in each release, that aren't in the previous one
+	index    int
+}
+
+func (i *releasesIter) Column(ctx vtab.Context, c int) error {
+	current := i.releases[i.index]
+	switch releasesCols[c].Name {
+	case "name":
+		ctx.ResultText(current.ref.Name().Short())
+	case "full_name":
+		ctx.ResultText(current.ref.Name().String())
+	case "hash":
+		ctx.ResultText(current.commit.String())
+	case "annotated":
+		if current.annotated {
+			ctx.ResultInt(1)
+		} else {
+			ctx.ResultInt(0)
+		}
+	case "tag_date":
+		ctx.ResultText(current.date.Format(time.RFC3339))
+	case "previous":
+		if i.index > 0 {
+			ctx.ResultText(i.releases[i.index-1].ref.Name().Short())
+		}
+	case "commits_since_previous":
+		ctx.ResultInt(i.commits[i.index])
+	case "days_since_previous":
+		if i.index > 0 {
+			ctx.ResultFloat(current.date.Sub(i.releases[i.index-1].date).Hours() / 24)
+		}
+	}
+	return nil
+}
+
+func (i *releasesIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.releases) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewReleasesModule returns the implementation of a table-valued-function listing the tags of a repository that point
+// at commits, ordered by tag date (the date of annotated tags, or of the commit of lightweight tags), along with
+// the number of commits and days since the previous tag. Commits since the previous tag are the ones reachable
+// from the tag but not from the previous one (as in git rev-list --count previous..tag).
+func NewReleasesModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("releases", releasesCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 8 {
+				repoPath = constraint.Value.Text()
+			}
+		}
+
+		var err error
+		if repoPath == "" {
+			if repoPath, err = options.GetRepoPath(); err != nil {
+				return nil, err
+			}
+		}
+
+		var repo *git.Repository
+		if repo, err = options.Locator.Open(context.Background(), repoPath); err != nil {
+			return nil, errors.Wrapf(err, "failed to open %q", repoPath)
+		}
+
+		var releases []*release
+		if releases, err = listReleases(repo); err != nil {
+			return nil, err
+		}
+
+		var commits []int
+		if commits, err = countReleaseCommits(repo, releases); err != nil {
+			return nil, err
+		}
+
+		return &releasesIter{releases: releases, commits: commits, index: -1}, nil
+	})
+}
+
+// listReleases returns the tags of repo pointing at commits, ordered by date (and name, for tags of the same date)
+func listReleases(repo *git.Repository) ([]*release, error) {
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list tags")
+	}
+	defer refs.Close()
+
+	var releases []*release
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		var r = &release{ref: ref}
+		if tag, err := repo.TagObject(ref.Hash()); err == nil {
+			var commit *object.Commit
+			if commit, err = tag.Commit(); err != nil {
+				return nil // tags of trees or blobs aren't releases
+			}
+			r.commit, r.annotated, r.date = commit.Hash, true, tag.Tagger.When
+		} else if errors.Is(err, plumbing.ErrObjectNotFound) {
+			var commit *object.Commit
+			if commit, err = repo.CommitObject(ref.Hash()); err != nil {
+				return nil // lightweight tags of trees or blobs
+			}
+			r.commit, r.date = commit.Hash, commit.Committer.When
+		} else {
+			return errors.Wrapf(err, "failed to lookup tag %s", ref.Name().Short())
+		}
+
+		releases = append(releases, r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		if !releases[i].date.Equal(releases[j].date) {
+			return releases[i].date.Before(releases[j].date)
+		}
+		return releases[i].ref.Name() < releases[j].ref.Name()
+	})
+
+	return releases, nil
+}
+
+// countReleaseCommits counts the commits of every release that aren't in the previous one (in the order of releases).
+// The history is walked once: the commits reachable from the releases are indexed, then visited in topological order
+// (children before parents), each one passing the set of releases containing it on to its parents.
+func countReleaseCommits(repo *git.Repository, releases []*release) ([]int, error) {
+	var parents = make(map[plumbing.Hash][]plumbing.Hash)
+	var children = make(map[plumbing.Hash]int) // number of children of each commit, yet to be visited
+	var queue []plumbing.Hash
+	for _, r := range releases {
+		queue = append(queue, r.commit)
+	}
+	for len(queue) > 0 {
+		hash := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+
+		if _, ok := parents[hash]; ok {
+			continue
+		}
+
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not lookup commit %s", hash)
+		}
+
+		parents[hash] = commit.ParentHashes
+		for _, parent := range commit.ParentHashes {
+			children[parent]++
+			queue = append(queue, parent)
+		}
+	}
+
+	// the releases containing a commit, as a bitset (of the indexes of releases)
+	var words = (len(releases) + 63) / 64
+	var contains = make(map[plumbing.Hash][]uint64)
+	var has = func(set []uint64, i int) bool { return set[i/64]&(1<<(i%64)) != 0 }
+	for i, r := range releases {
+		if contains[r.commit] == nil {
+			contains[r.commit] = make([]uint64, words)
+		}
+		contains[r.commit][i/64] |= 1 << (i % 64)
+	}
+
+	var counts = make([]int, len(releases))
+	var ready []plumbing.Hash
+	for hash := range parents {
+		if children[hash] == 0 {
+			ready = append(ready, hash)
+		}
+	}
+	for len(ready) > 0 {
+		hash := ready[len(ready)-1]
+		ready = ready[:len(ready)-1]
+
+		// the set of a commit is complete once all of its children were visited
+		set := contains[hash]
+		delete(contains, hash)
+
+		for w, word := range set {
+			for ; word != 0; word &= word - 1 {
+				if i := w*64 + bits.TrailingZeros64(word); i == 0 || !has(set, i-1) {
+					counts[i]++
+				}
+			}
+		}
+
+		for _, parent := range parents[hash] {
+			if contains[parent] == nil {
+				contains[parent] = make([]uint64, words)
+			}
+			for w := range set {
+				contains[parent][w] |= set[w]
+			}
+			if children[parent]--; children[parent] == 0 {
+				ready = append(ready, parent)
+			}
+		}
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,66 @@
+package git_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestReleases(t *testing.T) {
+	db := Connect(t, Memory)
+	repo := "https://github.com/mergestat/mergestat-lite"
+
+	rows, err := db.Query("SELECT name, hash, tag_date, previous, commits_since_previous, days_since_previous FROM releases(?)", repo)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	var count int
+	var last string
+	var previous time.Time
+	for rows.Next() {
+		var name, hash, date string
+		var prev sql.NullString
+		var commits int
+		var days sql.NullFloat64
+		if err = rows.Scan(&name, &hash, &date, &prev, &commits, &days); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+
+		when := parseTime(t, date)
+		if when.Before(previous) {
+			t.Fatalf("expected releases to be ordered by tag date, got %s after %s", when, previous)
+		}
+
+		if count == 0 {
+			if prev.Valid || days.Valid || commits == 0 {
+				t.Fatalf("unexpected first release %q: previous=%v days=%v commits=%d", name, prev, days, commits)
+			}
+		} else if prev.String != last || !days.Valid || days.Float64 < 0 {
+			t.Fatalf("unexpected release %q: previous=%v (expected %q) days=%v", name, prev, last, days)
+		}
+
+		previous, last = when, name
+		count++
+	}
+
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err.Error())
+	}
+
+	if count == 0 {
+		t.Fatal("expected the repository to have releases")
+	}
+
+	// commits since the previous release match counting the commits ahead of it
+	var since, ahead int
+	err = db.QueryRow(`SELECT commits_since_previous, commits_ahead(?, hash, previous)
+		FROM releases(?) WHERE previous IS NOT NULL ORDER BY tag_date DESC LIMIT 1`, repo, repo).Scan(&since, &ahead)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if since != ahead {
+		t.Fatalf("expected %d commits since the previous release, got %d", ahead, since)
+	}
+}
@@ -0,0 +1,144 @@
+package git
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/pkg/errors"
+)
+
+// replaceRefPrefix is the prefix of the refs replacing objects, as in refs/replace/<hash of the replaced object>
+const replaceRefPrefix = "refs/replace/"
+
+// maxReplaceDepth is how many replacements of replacements are followed (as in git)
+const maxReplaceDepth = 5
+
+// withReplaceObjects returns a repository reading the objects of repo the way git does: objects replaced by
+// a refs/replace/<hash> ref are swapped for their replacements (unless noReplace is set, as with git --no-replace-objects),
+// and commits that are grafted, either in info/grafts or at the boundary of a shallow clone, get the parents of the graft.
+// Replaced and grafted commits keep their original hash. If there is no replace ref or graft, repo is returned as is.
+func withReplaceObjects(repo *git.Repository, noReplace bool) (*git.Repository, error) {
+	var s = &replaceStorer{
+		Storer:       repo.Storer,
+		replacements: make(map[plumbing.Hash]plumbing.Hash),
+		grafts:       make(map[plumbing.Hash][]plumbing.Hash),
+	}
+
+	if !noReplace {
+		refs, err := repo.Storer.IterReferences()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list references")
+		}
+		err = refs.ForEach(func(ref *plumbing.Reference) error {
+			if name := ref.Name().String(); strings.HasPrefix(name, replaceRefPrefix) && ref.Type() == plumbing.HashReference {
+				s.replacements[plumbing.NewHash(strings.TrimPrefix(name, replaceRefPrefix))] = ref.Hash()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list replace references")
+		}
+	}
+
+	// commits at the boundary of a shallow clone have no parents (these aren't in the repository)
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read shallow commits")
+	}
+	for _, hash := range shallow {
+		s.grafts[hash] = nil
+	}
+
+	if fs, ok := repo.Storer.(*filesystem.Storage); ok {
+		f, err := fs.Filesystem().Open(fs.Filesystem().Join("info", "grafts"))
+		if err == nil {
+			defer f.Close()
+			if err = parseGrafts(bufio.NewScanner(f), s.grafts); err != nil {
+				return nil, errors.Wrap(err, "failed to read grafts")
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, errors.Wrap(err, "failed to read grafts")
+		}
+	}
+
+	if len(s.replacements) == 0 && len(s.grafts) == 0 {
+		return repo, nil
+	}
+	return git.Open(s, nil)
+}
+
+// parseGrafts parses the lines of an info/grafts file, each holding the hash of a commit followed by the hashes
+// of the parents it is grafted onto (if any), into grafts
+func parseGrafts(scanner *bufio.Scanner, grafts map[plumbing.Hash][]plumbing.Hash) error {
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		var parents = make([]plumbing.Hash, 0, len(fields)-1)
+		for _, field := range fields[1:] {
+			parents = append(parents, plumbing.NewHash(field))
+		}
+		grafts[plumbing.NewHash(fields[0])] = parents
+	}
+	return scanner.Err()
+}
+
+// replaceStorer is a storage.Storer swapping replaced objects for their replacements, and overriding
+// the parents of grafted commits
+type replaceStorer struct {
+	storage.Storer
+
+	replacements map[plumbing.Hash]plumbing.Hash
+	grafts       map[plumbing.Hash][]plumbing.Hash
+}
+
+func (s *replaceStorer) EncodedObject(t plumbing.ObjectType, h plumbing.Hash) (plumbing.EncodedObject, error) {
+	var target = h
+	for i := 0; i < maxReplaceDepth; i++ {
+		replacement, ok := s.replacements[target]
+		if !ok {
+			break
+		}
+		target = replacement
+	}
+
+	obj, err := s.Storer.EncodedObject(t, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if parents, ok := s.grafts[h]; ok && obj.Type() == plumbing.CommitObject {
+		var commit *object.Commit
+		if commit, err = object.DecodeCommit(s.Storer, obj); err != nil {
+			return nil, err
+		}
+
+		commit.ParentHashes = parents
+		var grafted = &plumbing.MemoryObject{}
+		if err = commit.Encode(grafted); err != nil {
+			return nil, err
+		}
+		obj = grafted
+	}
+
+	if obj.Hash() != h {
+		obj = &replacedObject{EncodedObject: obj, hash: h}
+	}
+	return obj, nil
+}
+
+// replacedObject is an object read in place of the object of hash
+type replacedObject struct {
+	plumbing.EncodedObject
+	hash plumbing.Hash
+}
+
+func (o *replacedObject) Hash() plumbing.Hash { return o.hash }
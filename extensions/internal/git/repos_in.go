@@ -0,0 +1,142 @@
+package git
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/extensions/internal/sqlutil"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var reposInCols = []vtab.Column{
+	{Name: "path", Type: "TEXT"},
+	{Name: "name", Type: "TEXT"},
+	{Name: "is_bare", Type: "BOOLEAN"},
+
+	{Name: "root", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "max_depth", Type: "INT", Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// NewReposInModule returns the implementation of a table-valued-function that discovers the git repositories
+// (both worktrees and bare repositories) found under a directory on disk. Its output is meant to be joined with
+// the other git tables, as in: SELECT * FROM repos_in('~/src') AS r, commits(r.path)
+func NewReposInModule(opt *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("repos_in", reposInCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var root string
+		var maxDepth = -1
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch reposInCols[constraint.ColIndex].Name {
+				case "root":
+					root = constraint.Value.Text()
+				case "max_depth":
+					maxDepth = constraint.Value.Int()
+				}
+			}
+		}
+
+		if root == "" {
+			return nil, errors.New("repos_in requires a root directory")
+		}
+
+		if strings.HasPrefix(root, "~") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to expand home directory")
+			}
+			root = filepath.Join(home, root[1:])
+		}
+
+		var err error
+		if root, err = filepath.Abs(root); err != nil {
+			return nil, err
+		}
+
+		opt.Logger.Debug().Str("module", "repos-in").Str("root", root).Int("max-depth", maxDepth).Msg("discovering repositories")
+
+		repos, err := findRepos(root, maxDepth)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to discover repositories in %q", root)
+		}
+
+		return &reposInIter{root: root, repos: repos, index: -1}, nil
+	})
+}
+
+type discoveredRepo struct {
+	path string
+	bare bool
+}
+
+// findRepos walks root looking for git repositories, up to maxDepth directories deep (unlimited if negative).
+// Repositories are not descended into, so nested repositories (such as submodules) are not reported.
+func findRepos(root string, maxDepth int) ([]*discoveredRepo, error) {
+	var repos []*discoveredRepo
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path != root && errors.Is(err, fs.ErrPermission) {
+				return fs.SkipDir // skip over what we cannot read, rather than failing the whole scan
+			}
+			return err
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+			repos = append(repos, &discoveredRepo{path: path})
+			return fs.SkipDir
+		}
+
+		if utils.IsGitDir(path) {
+			repos = append(repos, &discoveredRepo{path: path, bare: true})
+			return fs.SkipDir
+		}
+
+		if rel, _ := filepath.Rel(root, path); maxDepth >= 0 && rel != "." && len(strings.Split(rel, string(filepath.Separator))) >= maxDepth {
+			return fs.SkipDir
+		}
+
+		return nil
+	})
+
+	return repos, err
+}
+
+type reposInIter struct {
+	root  string
+	repos []*discoveredRepo
+	index int
+}
+
+func (i *reposInIter) Column(ctx vtab.Context, c int) error {
+	current := i.repos[i.index]
+	switch reposInCols[c].Name {
+	case "path":
+		ctx.ResultText(current.path)
+	case "name":
+		if rel, err := filepath.Rel(i.root, current.path); err != nil {
+			return err
+		} else {
+			ctx.ResultText(filepath.ToSlash(rel))
+		}
+	case "is_bare":
+		ctx.ResultInt(sqlutil.BoolToInt(current.bare))
+	}
+	return nil
+}
+
+func (i *reposInIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.repos) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
@@ -0,0 +1,60 @@
+package git_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestReposIn(t *testing.T) {
+	db := Connect(t, Memory)
+	root := t.TempDir()
+
+	if _, err := git.PlainClone(filepath.Join(root, "clones", "mergestat-lite"), false, &git.CloneOptions{URL: "https://github.com/mergestat/mergestat-lite"}); err != nil {
+		t.Fatalf("failed to clone repository: %v", err)
+	}
+
+	if _, err := git.PlainInit(filepath.Join(root, "mirrors", "empty.git"), true); err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+
+	rows, err := db.Query("SELECT name, is_bare FROM repos_in(?) ORDER BY name", root)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		var bare bool
+		if err = rows.Scan(&name, &bare); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+		names = append(names, name)
+
+		if expected := name == "mirrors/empty.git"; bare != expected {
+			t.Fatalf("expected is_bare for %q to be %v", name, expected)
+		}
+	}
+
+	if len(names) != 2 || names[0] != "clones/mergestat-lite" || names[1] != "mirrors/empty.git" {
+		t.Fatalf("unexpected repositories discovered: %v", names)
+	}
+
+	var count int
+	if err = db.QueryRow("SELECT count(*) FROM repos_in(?, 1)", root).Scan(&count); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	} else if count != 0 {
+		t.Fatalf("expected max_depth to limit discovery, got %d repositories", count)
+	}
+
+	// the repository argument of the git tables can be supplied by the outer table in a join
+	err = db.QueryRow("SELECT count(*) FROM repos_in(?) AS r, commits(r.path, 'HEAD') WHERE NOT r.is_bare", root).Scan(&count)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	} else if count == 0 {
+		t.Fatalf("expected commits to be returned for the discovered repository")
+	}
+}
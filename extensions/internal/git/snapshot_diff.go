@@ -0,0 +1,386 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-enry/go-enry/v2"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+	"golang.org/x/mod/modfile"
+)
+
+var snapshotDiffCols = []vtab.Column{
+	{Name: "metric", Type: "TEXT"},
+	{Name: "key", Type: "TEXT"},
+	{Name: "value_a", Type: "TEXT"},
+	{Name: "value_b", Type: "TEXT"},
+	{Name: "delta", Type: "INT"},
+	{Name: "change", Type: "TEXT"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref_a", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref_b", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "metric_filter", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// snapshotMetrics are the metrics compared by snapshot_diff, in the order they're listed
+var snapshotMetrics = []string{"files", "sloc", "dependencies"}
+
+// snapshot holds the metrics derived from the tree of a ref
+type snapshot struct {
+	files        int64
+	sloc         map[string]int64  // non-blank lines of code by language
+	dependencies map[string]string // versions by ecosystem:name, as in go:github.com/pkg/errors
+}
+
+// blobStats are the lines of code of a blob, which are cached by hash, as most blobs are the same at both refs
+type blobStats struct {
+	language string
+	sloc     int64
+}
+
+// takeSnapshot derives the metrics of tree. Vendored files (as detected by enry) don't count towards the lines of code
+// or the dependencies of a repository, and binary files have no language.
+func takeSnapshot(tree *object.Tree, metrics map[string]bool, cache map[plumbing.Hash]*blobStats) (*snapshot, error) {
+	var s = &snapshot{sloc: make(map[string]int64), dependencies: make(map[string]string)}
+	var versions = make(map[string][]string)
+
+	err := tree.Files().ForEach(func(f *object.File) error {
+		s.files++
+		if enry.IsVendor(f.Name) || (!metrics["sloc"] && !metrics["dependencies"]) {
+			return nil
+		}
+
+		if metrics["sloc"] {
+			stats, ok := cache[f.Hash]
+			if !ok {
+				contents, err := fileBytes(f)
+				if err != nil {
+					return err
+				}
+
+				stats = &blobStats{}
+				if !enry.IsBinary(contents) {
+					if stats.language = enry.GetLanguage(path.Base(f.Name), contents); stats.language != "" {
+						stats.sloc = countSLOC(contents)
+					}
+				}
+				cache[f.Hash] = stats
+			}
+			if stats.language != "" {
+				s.sloc[stats.language] += stats.sloc
+			}
+		}
+
+		if metrics["dependencies"] {
+			parse, ok := manifestParsers[path.Base(f.Name)]
+			if !ok {
+				return nil
+			}
+
+			contents, err := fileBytes(f)
+			if err != nil {
+				return err
+			}
+			for name, version := range parse(f.Name, contents) {
+				versions[name] = append(versions[name], version)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// a dependency of several manifests (as in a monorepo) may be required at different versions
+	for name, list := range versions {
+		sort.Strings(list)
+		var unique []string
+		for i, version := range list {
+			if i == 0 || version != list[i-1] {
+				unique = append(unique, version)
+			}
+		}
+		s.dependencies[name] = strings.Join(unique, ", ")
+	}
+	return s, nil
+}
+
+// fileBytes returns the contents of f
+func fileBytes(f *object.File) ([]byte, error) {
+	r, err := f.Reader()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read %s", f.Name)
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// countSLOC counts the non-blank lines of contents
+func countSLOC(contents []byte) int64 {
+	var sloc int64
+	for _, line := range bytes.Split(contents, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) > 0 {
+			sloc++
+		}
+	}
+	return sloc
+}
+
+// manifestParsers parse the dependencies (by ecosystem:name) and their versions out of package manifests, by file name.
+// Manifests that can't be parsed list no dependencies.
+var manifestParsers = map[string]func(name string, contents []byte) map[string]string{
+	"go.mod":           parseGoModDependencies,
+	"package.json":     parsePackageJSONDependencies,
+	"requirements.txt": parseRequirementsDependencies,
+}
+
+func parseGoModDependencies(name string, contents []byte) map[string]string {
+	f, err := modfile.ParseLax(name, contents, nil)
+	if err != nil {
+		return nil
+	}
+
+	var deps = make(map[string]string, len(f.Require))
+	for _, require := range f.Require {
+		deps["go:"+require.Mod.Path] = require.Mod.Version
+	}
+	return deps
+}
+
+func parsePackageJSONDependencies(_ string, contents []byte) map[string]string {
+	var manifest struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err := json.Unmarshal(contents, &manifest); err != nil {
+		return nil
+	}
+
+	var deps = make(map[string]string)
+	for _, list := range []map[string]string{manifest.DevDependencies, manifest.OptionalDependencies, manifest.Dependencies} {
+		for name, version := range list {
+			deps["npm:"+name] = version
+		}
+	}
+	return deps
+}
+
+// requirement matches a requirement of a requirements.txt file, as in requests[security]>=2.8.1,<3
+var requirement = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?\s*([<>=!~].*?)?\s*(?:;.*)?$`)
+
+func parseRequirementsDependencies(_ string, contents []byte) map[string]string {
+	var deps = make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), " #")
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		if m := requirement.FindStringSubmatch(line); m != nil {
+			// package names are case-insensitive, and treat -, _ and . alike
+			name := strings.NewReplacer("_", "-", ".", "-").Replace(strings.ToLower(m[1]))
+			deps["pypi:"+name] = strings.ReplaceAll(m[2], " ", "")
+		}
+	}
+	return deps
+}
+
+// snapshotChange is a change of a metric (of a key of it, for metrics by language or dependency) between two snapshots
+type snapshotChange struct {
+	metric, key    string
+	valueA, valueB interface{} // nil if the key is missing from a snapshot
+	delta          interface{} // the difference of numeric values
+	change         string      // added, removed, changed or unchanged
+}
+
+// diffSnapshots compares the metrics of snapshots a and b
+func diffSnapshots(a, b *snapshot, metrics map[string]bool) []*snapshotChange {
+	var changes []*snapshotChange
+	add := func(metric, key string, valueA, valueB interface{}, delta interface{}) {
+		c := &snapshotChange{metric: metric, key: key, valueA: valueA, valueB: valueB, delta: delta}
+		switch {
+		case valueA == nil:
+			c.change = "added"
+		case valueB == nil:
+			c.change = "removed"
+		case valueA != valueB:
+			c.change = "changed"
+		default:
+			c.change = "unchanged"
+		}
+		changes = append(changes, c)
+	}
+
+	if metrics["files"] {
+		add("files", "", a.files, b.files, b.files-a.files)
+	}
+
+	if metrics["sloc"] {
+		var languages = make(map[string]bool)
+		for _, sloc := range []map[string]int64{a.sloc, b.sloc} {
+			for language := range sloc {
+				languages[language] = true
+			}
+		}
+
+		for _, language := range sortedKeys(languages) {
+			var valueA, valueB interface{}
+			countA, inA := a.sloc[language]
+			countB, inB := b.sloc[language]
+			if inA {
+				valueA = countA
+			}
+			if inB {
+				valueB = countB
+			}
+			add("sloc", language, valueA, valueB, countB-countA)
+		}
+	}
+
+	if metrics["dependencies"] {
+		var names = make(map[string]bool)
+		for _, dependencies := range []map[string]string{a.dependencies, b.dependencies} {
+			for name := range dependencies {
+				names[name] = true
+			}
+		}
+
+		for _, name := range sortedKeys(names) {
+			var valueA, valueB interface{}
+			if version, ok := a.dependencies[name]; ok {
+				valueA = version
+			}
+			if version, ok := b.dependencies[name]; ok {
+				valueB = version
+			}
+			add("dependencies", name, valueA, valueB, nil)
+		}
+	}
+
+	return changes
+}
+
+// sortedKeys returns the keys of set, sorted
+func sortedKeys(set map[string]bool) []string {
+	var keys = make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type snapshotDiffIter struct {
+	changes []*snapshotChange
+	index   int
+}
+
+func (i *snapshotDiffIter) Column(ctx vtab.Context, c int) error {
+	current := i.changes[i.index]
+
+	var value interface{}
+	switch snapshotDiffCols[c].Name {
+	case "metric":
+		value = current.metric
+	case "key":
+		if current.key != "" {
+			value = current.key
+		}
+	case "value_a":
+		value = current.valueA
+	case "value_b":
+		value = current.valueB
+	case "delta":
+		value = current.delta
+	case "change":
+		value = current.change
+	}
+
+	switch v := value.(type) {
+	case string:
+		ctx.ResultText(v)
+	case int64:
+		ctx.ResultInt64(v)
+	default:
+		ctx.ResultNull()
+	}
+	return nil
+}
+
+func (i *snapshotDiffIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.changes) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewSnapshotDiffModule returns the implementation of a table-valued-function comparing metrics derived from the trees
+// of two refs of a repository (ref_b being the default ref, or HEAD, if not supplied), to report what changed between
+// releases beyond the files that differ: the number of files (files), the non-blank lines of code of each language
+// (sloc, as detected by enry) and the dependencies (and their versions) listed in go.mod, package.json and requirements.txt
+// files (dependencies, by ecosystem:name, as in npm:react). A metric may be supplied to only compare that one.
+// Vendored files are left out of the lines of code and dependencies. All keys are listed, with a change of added,
+// removed, changed or unchanged, and the difference of the values of numeric metrics as delta.
+func NewSnapshotDiffModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("snapshot_diff", snapshotDiffCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, refA, refB, metric string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 6:
+					repoPath = constraint.Value.Text()
+				case 7:
+					refA = constraint.Value.Text()
+				case 8:
+					refB = constraint.Value.Text()
+				case 9:
+					metric = constraint.Value.Text()
+				}
+			}
+		}
+
+		if refA == "" {
+			return nil, errors.New("a ref to compare against must be supplied")
+		}
+
+		var metrics = make(map[string]bool, len(snapshotMetrics))
+		for _, m := range snapshotMetrics {
+			metrics[m] = metric == "" || metric == m
+		}
+		if metric != "" && !metrics[metric] {
+			return nil, errors.Errorf("unknown metric %q, expected one of %s", metric, strings.Join(snapshotMetrics, ", "))
+		}
+
+		var cache = make(map[plumbing.Hash]*blobStats)
+		var snapshots []*snapshot
+		for _, ref := range []string{refA, refB} {
+			tree, err := options.LookupTree(repoPath, ref)
+			if err != nil {
+				return nil, err
+			}
+
+			s, err := takeSnapshot(tree, metrics, cache)
+			if err != nil {
+				return nil, err
+			}
+			snapshots = append(snapshots, s)
+		}
+
+		return &snapshotDiffIter{changes: diffSnapshots(snapshots[0], snapshots[1], metrics), index: -1}, nil
+	})
+}
@@ -0,0 +1,98 @@
+package git_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestSnapshotDiff(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := commitFiles(t, map[string]string{
+		"main.go":      "package main\n\nfunc main() {\n}\n",
+		"go.mod":       "module example.com/app\n\ngo 1.19\n\nrequire github.com/pkg/errors v0.9.1\n",
+		"package.json": `{"dependencies": {"react": "^17.0.0"}, "devDependencies": {"jest": "^29.0.0"}}`,
+	})
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range map[string]string{
+		"main.go":          "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println()\n}\n",
+		"tool.py":          "print('hello')\n",
+		"go.mod":           "module example.com/app\n\ngo 1.19\n\nrequire (\n\tgithub.com/pkg/errors v0.9.2\n\tgolang.org/x/mod v0.16.0\n)\n",
+		"package.json":     `{"devDependencies": {"jest": "^29.0.0"}}`,
+		"requirements.txt": "# tools\nRequests[security] >= 2.8.1\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := worktree.Commit("update files", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.Query("SELECT metric, key, value_a, value_b, delta, change FROM snapshot_diff(?, ?)", dir, head.Hash().String())
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{"files", "NULL", "3", "5", "2", "changed"},
+		{"sloc", "Go", "3", "5", "2", "changed"},
+		{"sloc", "Go Module", "3", "6", "3", "changed"},
+		{"sloc", "JSON", "1", "1", "0", "unchanged"},
+		{"sloc", "Python", "NULL", "1", "1", "added"},
+		{"sloc", "Text", "NULL", "2", "2", "added"},
+		{"dependencies", "go:github.com/pkg/errors", "v0.9.1", "v0.9.2", "NULL", "changed"},
+		{"dependencies", "go:golang.org/x/mod", "NULL", "v0.16.0", "NULL", "added"},
+		{"dependencies", "npm:jest", "^29.0.0", "^29.0.0", "NULL", "unchanged"},
+		{"dependencies", "npm:react", "^17.0.0", "NULL", "NULL", "removed"},
+		{"dependencies", "pypi:requests", "NULL", ">=2.8.1", "NULL", "added"},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d rows, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+
+	var files int
+	if err := db.QueryRow("SELECT count(*) FROM snapshot_diff(?, ?, 'HEAD', 'files')", dir, head.Hash().String()).Scan(&files); err != nil || files != 1 {
+		t.Fatalf("expected a single row comparing the number of files, got %d (%v)", files, err)
+	}
+
+	if err := db.QueryRow("SELECT count(*) FROM snapshot_diff(?, ?, 'HEAD', 'size')", dir, head.Hash().String()).Scan(&files); err == nil {
+		t.Fatal("expected an unknown metric to fail")
+	}
+}
@@ -0,0 +1,119 @@
+package git
+
+import (
+	"io"
+	"sort"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var submodulesCols = []vtab.Column{
+	{Name: "name", Type: "TEXT"},
+	{Name: "path", Type: "TEXT"},
+	{Name: "url", Type: "TEXT"},
+	{Name: "branch", Type: "TEXT"},
+	{Name: "hash", Type: "TEXT"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// submodule is a submodule declared in .gitmodules, and the commit it's pinned to (if any)
+type submodule struct {
+	*config.Submodule
+	hash string
+}
+
+type submodulesIter struct {
+	submodules []*submodule
+	index      int
+}
+
+func (i *submodulesIter) Column(ctx vtab.Context, c int) error {
+	current := i.submodules[i.index]
+	switch submodulesCols[c].Name {
+	case "name":
+		ctx.ResultText(current.Name)
+	case "path":
+		ctx.ResultText(current.Path)
+	case "url":
+		ctx.ResultText(current.URL)
+	case "branch":
+		if current.Branch != "" {
+			ctx.ResultText(current.Branch)
+		}
+	case "hash":
+		if current.hash != "" {
+			ctx.ResultText(current.hash)
+		}
+	}
+	return nil
+}
+
+func (i *submodulesIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.submodules) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewSubmodulesModule returns the implementation of a table-valued-function listing the submodules declared in the
+// .gitmodules file at ref (the default ref, or HEAD, if not supplied), ordered by path, with the commit each one is
+// pinned to at ref (NULL if the submodule isn't in the tree, such as when it was removed without updating .gitmodules).
+// The branch is NULL unless the submodule tracks one.
+func NewSubmodulesModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("submodules", submodulesCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 5:
+					repoPath = constraint.Value.Text()
+				case 6:
+					ref = constraint.Value.Text()
+				}
+			}
+		}
+
+		tree, err := options.LookupTree(repoPath, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		var iter = &submodulesIter{index: -1}
+		f, err := tree.File(".gitmodules")
+		if err == object.ErrFileNotFound {
+			return iter, nil
+		} else if err != nil {
+			return nil, errors.Wrap(err, "could not lookup .gitmodules")
+		}
+
+		contents, err := f.Contents()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not retrieve contents of .gitmodules")
+		}
+		var modules = config.NewModules()
+		if err = modules.Unmarshal([]byte(contents)); err != nil {
+			return nil, errors.Wrap(err, "could not parse .gitmodules")
+		}
+
+		for _, module := range modules.Submodules {
+			var current = &submodule{Submodule: module}
+			// a submodule is pinned by a gitlink, an entry of the tree holding the hash of a commit of the submodule
+			if entry, err := tree.FindEntry(module.Path); err == nil && entry.Mode == filemode.Submodule {
+				current.hash = entry.Hash.String()
+			}
+			iter.submodules = append(iter.submodules, current)
+		}
+		sort.Slice(iter.submodules, func(i, j int) bool { return iter.submodules[i].Path < iter.submodules[j].Path })
+
+		return iter, nil
+	})
+}
@@ -0,0 +1,77 @@
+package git_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestSubmodules(t *testing.T) {
+	db := Connect(t, Memory)
+	dir := t.TempDir()
+
+	var git = func(args ...string) string {
+		t.Helper()
+		args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		out, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	git("init", "--quiet")
+	git("commit", "--quiet", "--allow-empty", "-m", "first")
+	pinned := git("rev-parse", "HEAD")
+
+	var gitmodules = `[submodule "lib"]
+	path = vendor/lib
+	url = https://github.com/acme/lib.git
+	branch = stable
+[submodule "docs"]
+	path = docs
+	url = ../docs.git
+`
+	if err := os.WriteFile(filepath.Join(dir, ".gitmodules"), []byte(gitmodules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".gitmodules")
+	// pin vendor/lib to the first commit, without cloning it; docs is declared but never added
+	git("update-index", "--add", "--cacheinfo", "160000,"+pinned+",vendor/lib")
+	git("commit", "--quiet", "-m", "add submodules")
+
+	rows, err := db.Query("SELECT name, path, url, branch, hash FROM submodules(?)", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{"docs", "docs", "../docs.git", "NULL", "NULL"},
+		{"lib", "vendor/lib", "https://github.com/acme/lib.git", "stable", pinned},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d rows, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+
+	var count int
+	if err = db.QueryRow("SELECT count(*) FROM submodules(?, 'HEAD~1')", dir).Scan(&count); err != nil || count != 0 {
+		t.Fatalf("expected no submodules before .gitmodules was added, got: %d (%v)", count, err)
+	}
+}
@@ -0,0 +1,140 @@
+package git
+
+import (
+	"io"
+	"path"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/pkg/terraform"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var terraformCols = []vtab.Column{
+	{Name: "path", Type: "TEXT"},
+	{Name: "line", Type: "INT"},
+	{Name: "name", Type: "TEXT"},
+	{Name: "source", Type: "TEXT"},
+	{Name: "version", Type: "TEXT"},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// terraformDependency is a module or provider declared in a .tf file
+type terraformDependency struct {
+	path, name, source, version string
+	line                        int
+}
+
+type terraformIter struct {
+	dependencies []*terraformDependency
+	index        int
+}
+
+func (i *terraformIter) Column(ctx vtab.Context, c int) error {
+	current := i.dependencies[i.index]
+	switch terraformCols[c].Name {
+	case "path":
+		ctx.ResultText(current.path)
+	case "line":
+		ctx.ResultInt(current.line)
+	case "name":
+		ctx.ResultText(current.name)
+	case "source":
+		if current.source != "" {
+			ctx.ResultText(current.source)
+		}
+	case "version":
+		if current.version != "" {
+			ctx.ResultText(current.version)
+		}
+	}
+	return nil
+}
+
+func (i *terraformIter) Next() (vtab.Row, error) {
+	i.index += 1
+	if i.index >= len(i.dependencies) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewTerraformModulesModule returns the implementation of a table-valued-function listing the modules called by the
+// Terraform configurations (.tf files) at ref (the default ref, or HEAD, if not supplied), with their source and version
+// constraint (NULL if not literal strings, or not set)
+func NewTerraformModulesModule(options *utils.ModuleOptions) sqlite.Module {
+	return newTerraformModule(options, "tf_modules", func(path string, config *terraform.Config) []*terraformDependency {
+		var dependencies = make([]*terraformDependency, len(config.Modules))
+		for m, module := range config.Modules {
+			dependencies[m] = &terraformDependency{path: path, name: module.Name, source: module.Source, version: module.Version, line: module.Line}
+		}
+		return dependencies
+	})
+}
+
+// NewTerraformProvidersModule returns the implementation of a table-valued-function listing the providers required
+// (in the required_providers blocks) by the Terraform configurations (.tf files) at ref (the default ref, or HEAD,
+// if not supplied), with their source and version constraint (NULL if not literal strings, or not set)
+func NewTerraformProvidersModule(options *utils.ModuleOptions) sqlite.Module {
+	return newTerraformModule(options, "tf_providers", func(path string, config *terraform.Config) []*terraformDependency {
+		var dependencies = make([]*terraformDependency, len(config.Providers))
+		for p, provider := range config.Providers {
+			dependencies[p] = &terraformDependency{path: path, name: provider.Name, source: provider.Source, version: provider.Version, line: provider.Line}
+		}
+		return dependencies
+	})
+}
+
+// newTerraformModule returns the implementation of a table-valued-function listing the dependencies extracted
+// from each of the .tf files at ref
+func newTerraformModule(options *utils.ModuleOptions, table string, extract func(path string, config *terraform.Config) []*terraformDependency) sqlite.Module {
+	return vtab.NewTableFunc(table, terraformCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 5:
+					repoPath = constraint.Value.Text()
+				case 6:
+					ref = constraint.Value.Text()
+				}
+			}
+		}
+
+		tree, err := options.LookupTree(repoPath, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		var dependencies []*terraformDependency
+		err = tree.Files().ForEach(func(f *object.File) error {
+			// modules installed by terraform init aren't part of the configuration, even if committed
+			if path.Ext(f.Name) != ".tf" || strings.Contains("/"+f.Name, "/.terraform/") {
+				return nil
+			}
+
+			contents, err := f.Contents()
+			if err != nil {
+				return errors.Wrapf(err, "could not retrieve contents of %s", f.Name)
+			}
+
+			config, err := terraform.Parse(contents)
+			if err != nil {
+				return errors.Wrapf(err, "could not parse %s", f.Name)
+			}
+
+			dependencies = append(dependencies, extract(f.Name, config)...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &terraformIter{dependencies: dependencies, index: -1}, nil
+	})
+}
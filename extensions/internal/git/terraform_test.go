@@ -0,0 +1,65 @@
+package git_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestTerraform(t *testing.T) {
+	db := Connect(t, Memory)
+
+	var files = map[string]string{
+		"versions.tf": "terraform {\n" +
+			"  required_providers {\n" +
+			"    aws = { source = \"hashicorp/aws\", version = \"~> 5.0\" }\n" +
+			"  }\n" +
+			"}\n",
+		"modules/network/main.tf": "module \"vpc\" {\n" +
+			"  source  = \"terraform-aws-modules/vpc/aws\"\n" +
+			"  version = \"5.8.1\"\n" +
+			"  name    = var.name\n" +
+			"}\n",
+		"main.tf": "module \"network\" {\n" +
+			"  source = \"./modules/network\"\n" +
+			"}\n",
+		// modules installed by terraform init are left out
+		".terraform/modules/vpc/main.tf": "module \"installed\" {\n  source = \"./installed\"\n}\n",
+		"README.md":                      "module \"not_terraform\" {}\n",
+	}
+	dir := commitFiles(t, files)
+
+	rows, err := db.Query("SELECT path, line, name, source, version FROM tf_modules(?) ORDER BY path", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{"main.tf", "1", "network", "./modules/network", "NULL"},
+		{"modules/network/main.tf", "1", "vpc", "terraform-aws-modules/vpc/aws", "5.8.1"},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d modules, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+
+	var path, name, source, version string
+	if err = db.QueryRow("SELECT path, name, source, version FROM tf_providers(?, 'HEAD')", dir).Scan(&path, &name, &source, &version); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if path != "versions.tf" || name != "aws" || source != "hashicorp/aws" || version != "~> 5.0" {
+		t.Fatalf("unexpected provider: %s %s %s %s", path, name, source, version)
+	}
+}
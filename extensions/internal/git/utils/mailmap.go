@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"sync"
+
+	"github.com/mergestat/mergestat-lite/extensions/services"
+	"github.com/mergestat/mergestat-lite/pkg/mailmap"
+	"github.com/pkg/errors"
+)
+
+// mailmapCacheSize is the number of parsed .mailmap files held in a MailmapCache, past which the oldest ones are evicted
+const mailmapCacheSize = 64
+
+// MailmapCache holds the parsed .mailmap files read by the modules registered on a connection, keyed by the hash of their
+// blob, so that a .mailmap is read and parsed once, rather than on every query (or for every commit sharing it)
+type MailmapCache struct {
+	// Stats, if set, counts the lookups answered from the cache as cache hits
+	Stats *services.QueryStats
+
+	mu      sync.Mutex
+	entries map[string]mailmap.MailMap
+	order   []string // hashes of the cached files, oldest first
+}
+
+// Get returns the parsed .mailmap file stored in the blob with the given hash. On a cache miss, load is called to retrieve
+// its contents. An empty hash stands for a missing .mailmap, which maps no identities. Concurrent misses may both call load.
+func (c *MailmapCache) Get(blob string, load func() (string, error)) (mailmap.MailMap, error) {
+	if blob == "" {
+		return mailmap.MailMap{}, nil
+	}
+
+	c.mu.Lock()
+	mm, ok := c.entries[blob]
+	c.mu.Unlock()
+	if ok {
+		c.Stats.Count(services.CacheHits, 1)
+		return mm, nil
+	}
+
+	contents, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	if mm, err = mailmap.Parse(contents); err != nil {
+		return nil, errors.Wrapf(err, "could not parse mailmap file")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]mailmap.MailMap)
+	}
+	if _, ok := c.entries[blob]; !ok {
+		for len(c.order) >= mailmapCacheSize {
+			delete(c.entries, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, blob)
+	}
+	c.entries[blob] = mm
+	return mm, nil
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/pkg/mailmap"
+)
+
+func TestMailmapCache(t *testing.T) {
+	var c = &MailmapCache{}
+	var loads int
+	var load = func() (string, error) {
+		loads++
+		return "Proper Name <proper@example.com> <alias@example.com>", nil
+	}
+
+	for i := 0; i < 3; i++ {
+		mm, err := c.Get("abc", load)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proper := mm.Lookup(mailmap.NameAndEmail{Name: "Alias", Email: "alias@example.com"}); proper.Email != "proper@example.com" {
+			t.Fatalf("expected the alias to be mapped, got %v", proper)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("expected the mailmap to be loaded once, got %d loads", loads)
+	}
+
+	// a missing mailmap isn't loaded
+	if mm, err := c.Get("", load); err != nil || len(mm) != 0 || loads != 1 {
+		t.Fatalf("expected an empty mailmap without loading it, got %v (%d loads): %v", mm, loads, err)
+	}
+
+	// the oldest mailmaps are evicted once the cache is full
+	for i := 0; i < mailmapCacheSize; i++ {
+		if _, err := c.Get(fmt.Sprintf("blob%d", i), load); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(c.entries) != mailmapCacheSize || len(c.order) != mailmapCacheSize {
+		t.Fatalf("expected %d cached mailmaps, got %d", mailmapCacheSize, len(c.entries))
+	}
+	if _, err := c.Get("abc", load); err != nil {
+		t.Fatal(err)
+	}
+	if loads != mailmapCacheSize+2 {
+		t.Fatalf("expected the evicted mailmap to be loaded again, got %d loads", loads)
+	}
+}
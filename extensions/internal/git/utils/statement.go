@@ -0,0 +1,64 @@
+package utils
+
+import "sync"
+
+// StatementContext holds state shared between the git tables of a connection, for the statements being executed on it.
+// It is used to propagate the repository opened by an outer table in a join (such as commits in
+// `FROM commits('...') c JOIN stats s ON s.rev = c.hash`) to the inner tables, so that users don't have to repeat
+// the repository argument on every table in the query.
+//
+// Each connection must have its own (see NewStatementContext), so that statements running at the same time on other
+// connections never pick up the repository of an unrelated query. Repositories are pushed by cursors for as long as
+// they're open, so a statement only sees the repositories of the cursors still open on its connection.
+type StatementContext struct {
+	mu    sync.Mutex
+	repos []*statementRepo // stack of repositories opened by currently active cursors
+}
+
+type statementRepo struct{ path string }
+
+// NewStatementContext returns the statement context of a connection. It's meant to be called when the git tables are
+// registered on a connection, which happens for every connection SQLite opens.
+func NewStatementContext() *StatementContext { return &StatementContext{} }
+
+// PushRepo records path as the repository of the statement, until the returned release function is called.
+// Cursors call it once they have opened their repository, and release it when re-filtered or closed.
+// It is safe to call on a nil receiver, in which case it's a no-op.
+func (s *StatementContext) PushRepo(path string) (release func()) {
+	if s == nil {
+		return func() {}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entry = &statementRepo{path: path}
+	s.repos = append(s.repos, entry)
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for i := range s.repos {
+			if s.repos[i] == entry {
+				s.repos = append(s.repos[:i], s.repos[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Repo returns the repository most recently pushed by a still active cursor, or an empty string if there's none
+func (s *StatementContext) Repo() string {
+	if s == nil {
+		return ""
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.repos) == 0 {
+		return ""
+	}
+	return s.repos[len(s.repos)-1].path
+}
@@ -0,0 +1,34 @@
+package utils
+
+import "testing"
+
+func TestStatementContext(t *testing.T) {
+	var s = &StatementContext{}
+	if repo := s.Repo(); repo != "" {
+		t.Fatalf("expected no repository, got %q", repo)
+	}
+
+	releaseOuter := s.PushRepo("outer")
+	releaseInner := s.PushRepo("inner")
+	if repo := s.Repo(); repo != "inner" {
+		t.Fatalf("expected most recently pushed repository, got %q", repo)
+	}
+
+	// releasing out of order must not disturb the remaining entries
+	releaseOuter()
+	if repo := s.Repo(); repo != "inner" {
+		t.Fatalf("expected inner repository to remain, got %q", repo)
+	}
+
+	releaseInner()
+	releaseInner() // releasing twice is harmless
+	if repo := s.Repo(); repo != "" {
+		t.Fatalf("expected no repository after release, got %q", repo)
+	}
+
+	var nilCtx *StatementContext
+	nilCtx.PushRepo("ignored")()
+	if repo := nilCtx.Repo(); repo != "" {
+		t.Fatalf("expected nil context to have no repository, got %q", repo)
+	}
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/services"
+	"github.com/pkg/errors"
+)
+
+// LookupTree returns the tree of the commit ref resolves to (the default ref, or HEAD, if empty) in the repository
+// at path (the default repository, if empty), opened with locator. It's how tables parsing the files of a repository
+// (such as its workflows, or its CODEOWNERS) read them.
+func LookupTree(ctx context.Context, locator services.RepoLocator, defaults services.Context, path, ref string) (_ *object.Tree, err error) {
+	if locator == nil {
+		return nil, errors.New("no repository locator configured")
+	}
+
+	if path == "" {
+		if path, err = GetDefaultRepoFromCtx(defaults); err != nil {
+			return nil, err
+		}
+	}
+
+	if ref == "" {
+		if ref = GetDefaultRefFromCtx(defaults); ref == "" {
+			ref = "HEAD"
+		}
+	}
+
+	repo, err := locator.Open(ctx, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %q", path)
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve %q", ref)
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not lookup commit %s", hash)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not lookup tree")
+	}
+	return tree, nil
+}
+
+// LookupTree returns the tree of the commit ref resolves to in the repository at path, as LookupTree does, except that
+// an empty path is resolved as GetRepoPath does (to the repository of an outer table in the statement, if any)
+func (o *ModuleOptions) LookupTree(path, ref string) (_ *object.Tree, err error) {
+	if path == "" {
+		if path, err = o.GetRepoPath(); err != nil {
+			return nil, err
+		}
+	}
+	return LookupTree(context.Background(), o.Locator, o.Context, path, ref)
+}
@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/mergestat/mergestat-lite/extensions/services"
 	"github.com/rs/zerolog"
@@ -12,18 +14,179 @@ type ModuleOptions struct {
 	Locator services.RepoLocator
 	Context services.Context
 	Logger  *zerolog.Logger
+
+	// Statement is shared by all modules registered on a connection (see StatementContext)
+	Statement *StatementContext
+
+	// Mailmap caches the parsed .mailmap files shared by all modules registered on a connection
+	Mailmap *MailmapCache
+
+	// Stats collects the statistics of the tables taking part in the last query, such as the commits they walked
+	Stats *services.QueryStats
+}
+
+// GetRepoPath returns the repository to use when none is supplied to a module. If an outer table in
+// the statement being executed has opened a repository, that one is used, otherwise the default
+// repository is looked up from the context (see GetDefaultRepoFromCtx).
+func (o *ModuleOptions) GetRepoPath() (string, error) {
+	if repoPath := o.Statement.Repo(); repoPath != "" {
+		return repoPath, nil
+	}
+	return GetDefaultRepoFromCtx(o.Context)
 }
 
-// GetDefaultRepoFromCtx looks up the defaultRepoPath key in the supplied context and returns it if set,
-// otherwise it returns the current working directory
+// DefaultRepoEnv is the environment variable consulted for a default repository
+// when none is configured in the context and GIT_DIR is unset
+const DefaultRepoEnv = "MERGESTAT_DEFAULT_REPO"
+
+// GetDefaultRepoFromCtx returns the repository to use when none is supplied to a module.
+// The defaultRepoPath key in the supplied context takes precedence, followed by the GIT_DIR
+// and MERGESTAT_DEFAULT_REPO environment variables and finally the current working directory.
+// Local paths are then resolved to the enclosing repository (see DiscoverRepo).
 func GetDefaultRepoFromCtx(ctx services.Context) (repoPath string, err error) {
 	var ok bool
 	if repoPath, ok = ctx["defaultRepoPath"]; !ok || repoPath == "" {
-		if wd, err := os.Getwd(); err != nil {
+		if gitDir := os.Getenv("GIT_DIR"); gitDir != "" {
+			repoPath = gitDir
+		} else if env := os.Getenv(DefaultRepoEnv); env != "" {
+			repoPath = env
+		} else if wd, err := os.Getwd(); err != nil {
 			return "", err
 		} else {
 			repoPath = wd
 		}
 	}
-	return
+	return DiscoverRepo(repoPath)
+}
+
+// DiscoverRepo walks up from the supplied path looking for a git repository, the same way git itself does.
+// It returns the first directory that either contains a .git entry or is itself a git directory (such as a bare repository).
+// Paths that do not exist on disk (remote urls for instance) or that are not inside a repository are returned unchanged,
+// leaving it up to the locator to report a meaningful error.
+func DiscoverRepo(path string) (string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return path, nil
+	}
+
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil || IsGitDir(dir) {
+			return dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return path, nil
+		}
+		dir = parent
+	}
+}
+
+// IsGitDir reports whether dir looks like a git directory, i.e. a bare repository or the .git directory of a worktree
+func IsGitDir(dir string) bool {
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// GetDefaultRefFromCtx looks up the defaultRef key in the supplied context and returns it.
+// An empty string is returned if it's not set, in which case tables should fall back to HEAD.
+func GetDefaultRefFromCtx(ctx services.Context) string {
+	return ctx["defaultRef"]
+}
+
+const (
+	// BackendGoGit walks the commit history using go-git (the default). Whole-history scans, that aren't ordered, limited
+	// or bounded by date, read the commits straight out of the packfiles instead, in no particular order.
+	BackendGoGit = "go-git"
+	// BackendLibgit2 walks the commit history using libgit2, which is faster on very large repositories. Histories altered
+	// by replace refs or grafts, which libgit2 doesn't honor, are walked by go-git all the same.
+	BackendLibgit2 = "libgit2"
+	// BackendCLI walks the commit history by shelling out to the system's git executable
+	BackendCLI = "cli"
+)
+
+// ParseGitBackend validates the name of a backend, returning BackendGoGit if it is empty
+func ParseGitBackend(backend string) (string, error) {
+	switch backend {
+	case "", BackendGoGit:
+		return BackendGoGit, nil
+	case BackendLibgit2, BackendCLI:
+		return backend, nil
+	default:
+		return "", fmt.Errorf("unknown git backend %q", backend)
+	}
+}
+
+// GetGitBackendFromCtx looks up the gitBackend key in the supplied context and returns it if set,
+// otherwise it returns BackendGoGit. An error is returned if the value doesn't name a known backend.
+func GetGitBackendFromCtx(ctx services.Context) (string, error) {
+	return ParseGitBackend(ctx["gitBackend"])
+}
+
+// GetNoReplaceObjectsFromCtx reports whether replace refs (refs/replace/*) are to be ignored when walking the commit history,
+// as with git --no-replace-objects. The noReplaceObjects key in the supplied context takes precedence over
+// the GIT_NO_REPLACE_OBJECTS environment variable git itself honors.
+func GetNoReplaceObjectsFromCtx(ctx services.Context) bool {
+	if noReplace, ok := ctx.GetBool("noReplaceObjects"); ok {
+		return noReplace
+	}
+	return os.Getenv("GIT_NO_REPLACE_OBJECTS") != ""
+}
+
+// DefaultStatsPrefetchMemory is how much memory (in bytes) the stats table holds stats computed ahead of time in, by default.
+// It's 0, as computing stats ahead of time only pays off when querying the stats of a whole history, and the workers
+// computing them keep going for a while after a query stops early (such as with a LIMIT).
+const DefaultStatsPrefetchMemory = 0
+
+// GetSkipMailmapFromCtx reports whether identities are to be reported as recorded in commits, rather than mapped through
+// the .mailmap file, according to the skipMailmap key in the supplied context. Tables taking a skip_mailmap argument
+// (or a mailmap hint) override it for a single query.
+func GetSkipMailmapFromCtx(ctx services.Context) bool {
+	skip, _ := ctx.GetBool("skipMailmap")
+	return skip
+}
+
+// GetStatsPrefetchMemoryFromCtx looks up the statsPrefetchMemory key in the supplied context, the number of bytes the stats table
+// may hold stats computed ahead of time in, and returns it if set, otherwise it returns DefaultStatsPrefetchMemory.
+// A limit of 0 disables computing stats ahead of time, which is opt-in.
+func GetStatsPrefetchMemoryFromCtx(ctx services.Context) int {
+	if size, ok := ctx.GetInt("statsPrefetchMemory"); ok {
+		return size
+	}
+	return DefaultStatsPrefetchMemory
+}
+
+const (
+	// OnErrorFail fails the query when a repository can't be read (the default)
+	OnErrorFail = "fail"
+	// OnErrorSkip skips repositories that can't be read, returning no row for them
+	OnErrorSkip = "skip"
+	// OnErrorNull returns a single row for repositories that can't be read, with NULL columns and the error in the error column
+	OnErrorNull = "null"
+)
+
+// ParseOnError validates how errors are to be handled, returning OnErrorFail if it is empty
+func ParseOnError(onError string) (string, error) {
+	switch onError {
+	case "", OnErrorFail:
+		return OnErrorFail, nil
+	case OnErrorSkip, OnErrorNull:
+		return onError, nil
+	default:
+		return "", fmt.Errorf("unknown on_error mode %q (expected fail, skip or null)", onError)
+	}
+}
+
+// GetOnErrorFromCtx looks up the onError key in the supplied context and returns it if set, otherwise it returns OnErrorFail.
+// It lets multi-repository scans (such as over repos_in) go on past repositories that fail to open.
+func GetOnErrorFromCtx(ctx services.Context) (string, error) {
+	return ParseOnError(ctx["onError"])
 }
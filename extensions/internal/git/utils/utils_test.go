@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/mergestat/mergestat-lite/extensions/services"
+)
+
+func TestDiscoverRepo(t *testing.T) {
+	root := t.TempDir()
+	if _, err := git.PlainInit(root, false); err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatalf("failed to create nested directory: %v", err)
+	}
+
+	for _, path := range []string{root, nested, filepath.Join(root, ".git")} {
+		found, err := DiscoverRepo(path)
+		if err != nil {
+			t.Fatalf("failed to discover repo from %q: %v", path, err)
+		}
+
+		expected := root
+		if path == filepath.Join(root, ".git") {
+			expected = path
+		}
+		if found != expected {
+			t.Fatalf("expected %q to resolve to %q, got %q", path, expected, found)
+		}
+	}
+
+	const remote = "https://github.com/mergestat/mergestat-lite"
+	if found, _ := DiscoverRepo(remote); found != remote {
+		t.Fatalf("expected remote url to be returned unchanged, got %q", found)
+	}
+}
+
+func TestGetDefaultRepoFromCtx(t *testing.T) {
+	root := t.TempDir()
+	if _, err := git.PlainInit(root, true); err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+
+	t.Setenv("GIT_DIR", "")
+	t.Setenv(DefaultRepoEnv, root)
+
+	if path, err := GetDefaultRepoFromCtx(services.Context{}); err != nil {
+		t.Fatal(err)
+	} else if path != root {
+		t.Fatalf("expected default repo from environment %q, got %q", root, path)
+	}
+
+	other := t.TempDir()
+	if path, err := GetDefaultRepoFromCtx(services.Context{"defaultRepoPath": other}); err != nil {
+		t.Fatal(err)
+	} else if path != other {
+		t.Fatalf("expected context value to take precedence, got %q", path)
+	}
+}
+
+func TestGetDefaultRefFromCtx(t *testing.T) {
+	if ref := GetDefaultRefFromCtx(services.Context{}); ref != "" {
+		t.Fatalf("expected no default ref, got %q", ref)
+	}
+
+	if ref := GetDefaultRefFromCtx(services.Context{"defaultRef": "main"}); ref != "main" {
+		t.Fatalf("expected default ref to be main, got %q", ref)
+	}
+}
@@ -0,0 +1,130 @@
+package github
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+// actionsConfig is a secret or variable configured for the GitHub Actions of a repository (or of one of its environments).
+// Only names and metadata are exposed, never values.
+type actionsConfig struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type iterActionsConfig struct {
+	*Options
+	kind        string // "secrets" or "variables", the name of the REST API endpoint and of the field listing them
+	owner       string
+	name        string
+	environment string
+
+	pager   *restPager
+	configs []*actionsConfig
+	current int
+}
+
+func (i *iterActionsConfig) logger() *zerolog.Logger {
+	logger := i.Logger.With().Int("per-page", i.PerPage).Str("owner", i.owner).Str("name", i.name).Str("environment", i.environment).Logger()
+	return &logger
+}
+
+func (i *iterActionsConfig) Column(ctx vtab.Context, c int) error {
+	current := i.configs[i.current]
+	switch actionsConfigCols[c].Name {
+	case "name":
+		ctx.ResultText(current.Name)
+	case "created_at":
+		ctx.ResultText(current.CreatedAt.Format(time.RFC3339Nano))
+	case "updated_at":
+		ctx.ResultText(current.UpdatedAt.Format(time.RFC3339Nano))
+	case "days_since_update":
+		ctx.ResultFloat(time.Since(current.UpdatedAt).Hours() / 24)
+	}
+	return nil
+}
+
+func (i *iterActionsConfig) Next() (vtab.Row, error) {
+	i.current += 1
+
+	for i.current >= len(i.configs) {
+		if i.pager.done() {
+			return nil, io.EOF
+		}
+
+		i.logger().Info().Msgf("fetching page of actions %s for %s/%s", i.kind, i.owner, i.name)
+		var page map[string]json.RawMessage
+		if err := i.pager.fetch(&page); err != nil {
+			return nil, err
+		}
+
+		var configs []*actionsConfig
+		if err := json.Unmarshal(page[i.kind], &configs); err != nil {
+			return nil, err
+		}
+		i.configs, i.current = configs, 0
+	}
+
+	return i, nil
+}
+
+var actionsConfigCols = []vtab.Column{
+	{Name: "owner", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "environment", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "name", Type: "TEXT"},
+	{Name: "created_at", Type: "DATETIME"},
+	{Name: "updated_at", Type: "DATETIME"},
+	{Name: "days_since_update", Type: "REAL"},
+}
+
+// NewActionsSecretsModule returns the implementation of a table listing the names of the GitHub Actions secrets
+// of a repository (or of one of its environments, if supplied), along with when they were last updated
+func NewActionsSecretsModule(opts *Options) sqlite.Module {
+	return newActionsConfigModule(opts, "github_actions_secrets", "secrets")
+}
+
+// NewActionsVariablesModule returns the implementation of a table listing the names of the GitHub Actions variables
+// of a repository (or of one of its environments, if supplied), along with when they were last updated
+func NewActionsVariablesModule(opts *Options) sqlite.Module {
+	return newActionsConfigModule(opts, "github_actions_variables", "variables")
+}
+
+func newActionsConfigModule(opts *Options, table, kind string) sqlite.Module {
+	return vtab.NewTableFunc(table, actionsConfigCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name, environment string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				case 2:
+					environment = constraint.Value.Text()
+				}
+			}
+		}
+
+		owner, name, err := repoOwnerAndName(name, fullNameOrOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		var path = restPath("repos", owner, name, "actions", kind)
+		if environment != "" {
+			path = restPath("repos", owner, name, "environments", environment, kind)
+		}
+
+		iter := &iterActionsConfig{Options: opts, kind: kind, owner: owner, name: name, environment: environment, current: -1}
+		iter.pager = newRestPager(opts, path, nil)
+		iter.logger().Info().Msgf("starting GitHub actions %s iterator for %s/%s", kind, owner, name)
+		return iter, nil
+	})
+}
@@ -0,0 +1,67 @@
+package github_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestActionsSecrets(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT name, updated_at FROM github_actions_secrets('mergestat/mergestat')")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	if expected := 2; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	if name, updatedAt := content[1][0], content[1][1]; name != "NPM_TOKEN" || updatedAt != "2024-01-09T14:55:02Z" {
+		t.Fatalf("unexpected secret: %v", content[1])
+	}
+
+	// secrets of an environment
+	var name string
+	if err = db.QueryRow("SELECT name FROM github_actions_secrets('mergestat/mergestat') WHERE environment = 'production'").Scan(&name); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if name != "DEPLOY_KEY" {
+		t.Fatalf("expected the DEPLOY_KEY secret of the production environment, got: %s", name)
+	}
+}
+
+func TestActionsVariables(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT * FROM github_actions_variables('mergestat/mergestat')")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	colCount, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	// values are never exposed
+	if expected := 4; colCount != expected {
+		t.Fatalf("expected %d columns, got: %d", expected, colCount)
+	}
+
+	if expected := 1; len(content) != expected || content[0][0] != "REGISTRY" {
+		t.Fatalf("expected the REGISTRY variable, got: %v", content)
+	}
+}
@@ -105,7 +105,7 @@ func (i *iterBranches) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.Edges) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.QueryContext.Context())
 			if err != nil {
 				return nil, err
 			}
@@ -119,7 +119,7 @@ func (i *iterBranches) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of repo_branches for %s/%s", i.owner, i.name)
-			results, err := i.fetchBranches(context.Background(), cursor)
+			results, err := i.fetchBranches(i.QueryContext.Context(), cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -0,0 +1,175 @@
+package github
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/rs/zerolog"
+	"github.com/shurcooL/githubv4"
+	"go.riyazali.net/sqlite"
+)
+
+type collaborator struct {
+	Permission githubv4.RepositoryPermission
+	Node       struct {
+		Login string
+		Name  string
+	}
+}
+
+type fetchCollaboratorsResults struct {
+	RateLimit   *options.GitHubRateLimitResponse
+	Edges       []*collaborator
+	HasNextPage bool
+	EndCursor   *githubv4.String
+}
+
+func (i *iterCollaborators) fetchCollaborators(ctx context.Context, startCursor *githubv4.String) (*fetchCollaboratorsResults, error) {
+	var collaboratorsQuery struct {
+		RateLimit  *options.GitHubRateLimitResponse
+		Repository struct {
+			Collaborators struct {
+				Edges    []*collaborator
+				PageInfo struct {
+					EndCursor   githubv4.String
+					HasNextPage bool
+				}
+			} `graphql:"collaborators(first: $perpage, after: $collaboratorcursor, affiliation: $affiliation)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+	variables := map[string]interface{}{
+		"owner":              githubv4.String(i.owner),
+		"name":               githubv4.String(i.name),
+		"perpage":            githubv4.Int(i.PerPage),
+		"collaboratorcursor": startCursor,
+		"affiliation":        i.affiliation,
+	}
+
+	err := i.Client().Query(ctx, &collaboratorsQuery, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fetchCollaboratorsResults{
+		RateLimit:   collaboratorsQuery.RateLimit,
+		Edges:       collaboratorsQuery.Repository.Collaborators.Edges,
+		HasNextPage: collaboratorsQuery.Repository.Collaborators.PageInfo.HasNextPage,
+		EndCursor:   &collaboratorsQuery.Repository.Collaborators.PageInfo.EndCursor,
+	}, nil
+}
+
+type iterCollaborators struct {
+	*Options
+	owner       string
+	name        string
+	affiliation githubv4.CollaboratorAffiliation
+	current     int
+	results     *fetchCollaboratorsResults
+}
+
+func (i *iterCollaborators) logger() *zerolog.Logger {
+	logger := i.Logger.With().Int("per-page", i.PerPage).Str("owner", i.owner).Str("name", i.name).Str("affiliation", string(i.affiliation)).Logger()
+	return &logger
+}
+
+func (i *iterCollaborators) Column(ctx vtab.Context, c int) error {
+	current := i.results.Edges[i.current]
+	switch collaboratorCols[c].Name {
+	case "login":
+		ctx.ResultText(current.Node.Login)
+	case "name":
+		ctx.ResultText(current.Node.Name)
+	case "permission":
+		ctx.ResultText(string(current.Permission))
+	}
+	return nil
+}
+
+func (i *iterCollaborators) Next() (vtab.Row, error) {
+	i.current += 1
+
+	if i.results == nil || i.current >= len(i.results.Edges) {
+		if i.results == nil || i.results.HasNextPage {
+			err := i.RateLimiter.Wait(i.QueryContext.Context())
+			if err != nil {
+				return nil, err
+			}
+
+			var cursor *githubv4.String
+			if i.results != nil {
+				cursor = i.results.EndCursor
+			}
+
+			i.Options.GitHubPreRequestHook()
+
+			l := i.logger().With().Interface("cursor", cursor).Logger()
+			l.Info().Msgf("fetching page of collaborators for %s/%s", i.owner, i.name)
+			results, err := i.fetchCollaborators(i.QueryContext.Context(), cursor)
+
+			i.Options.GitHubPostRequestHook()
+
+			if err != nil {
+				return nil, err
+			}
+
+			i.Options.RateLimitHandler(results.RateLimit)
+
+			i.results = results
+			i.current = 0
+
+			if len(results.Edges) == 0 {
+				return nil, io.EOF
+			}
+		} else {
+			return nil, io.EOF
+		}
+	}
+
+	return i, nil
+}
+
+var collaboratorCols = []vtab.Column{
+	{Name: "owner", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "affiliation", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "login", Type: "TEXT"},
+	{Name: "name", Type: "TEXT"},
+	{Name: "permission", Type: "TEXT"},
+}
+
+// NewCollaboratorsModule returns the implementation of a table listing the collaborators of a GitHub repository along with
+// their permission level (such as ADMIN, WRITE or READ). All collaborators are listed unless an affiliation is supplied,
+// such as OUTSIDE for the outside collaborators of an organization-owned repository.
+func NewCollaboratorsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_collaborators", collaboratorCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name, affiliation string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				case 2:
+					affiliation = constraint.Value.Text()
+				}
+			}
+		}
+
+		owner, name, err := repoOwnerAndName(name, fullNameOrOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		if affiliation == "" {
+			affiliation = string(githubv4.CollaboratorAffiliationAll)
+		}
+
+		iter := &iterCollaborators{opts, owner, name, githubv4.CollaboratorAffiliation(strings.ToUpper(affiliation)), -1, nil}
+		iter.logger().Info().Msgf("starting GitHub collaborators iterator for %s/%s", owner, name)
+		return iter, nil
+	})
+}
@@ -0,0 +1,66 @@
+package github_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestCollaborators(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT * FROM github_collaborators('mergestat/mergestat')")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	colCount, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	if expected := 3; colCount != expected {
+		t.Fatalf("expected %d columns, got: %d", expected, colCount)
+	}
+
+	if expected := 3; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	if login, permission := content[0][0], content[0][2]; login != "patrickdevivo" || permission != "ADMIN" {
+		t.Fatalf("unexpected collaborator: %v", content[0])
+	}
+}
+
+func TestRepoInvitations(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT invitee_login, inviter_login, permission, expired FROM github_repo_invitations('mergestat/mergestat')")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	if expected := 2; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	if invitee, permission, expired := content[0][0], content[0][2], content[0][3]; invitee != "octocat" || permission != "write" || expired != "0" {
+		t.Fatalf("unexpected invitation: %v", content[0])
+	}
+
+	// invitations sent by email have no invitee login
+	if invitee, expired := content[1][0], content[1][3]; invitee != "NULL" || expired != "1" {
+		t.Fatalf("unexpected invitation: %v", content[1])
+	}
+}
@@ -0,0 +1,271 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/shurcooL/githubv4"
+	"go.riyazali.net/sqlite"
+)
+
+// commitPRsBatchSize is the number of commits looked up in a single GraphQL request
+const commitPRsBatchSize = 25
+
+type commitPR struct {
+	Number int
+	Title  string
+	State  githubv4.PullRequestState
+	Url    string
+	Author struct {
+		Login string
+	}
+	BaseRefName string
+	HeadRefName string
+	Merged      bool
+	MergedAt    githubv4.DateTime
+	MergeCommit struct {
+		Oid string
+	}
+}
+
+// associatedPRs is the lookup of the pull requests associated with a single commit
+type associatedPRs struct {
+	Commit struct {
+		Oid                    string
+		AssociatedPullRequests struct {
+			Nodes []*commitPR
+		} `graphql:"associatedPullRequests(first: $perpage)"`
+	} `graphql:"... on Commit"`
+}
+
+// commitPRsQueryType returns the type of a query looking up the pull requests associated with n commits at once.
+// Each commit is looked up through its own aliased field (c0, c1...) of the repository, and its own variable ($c0, $c1...),
+// so the type is built with reflection, as githubv4 builds queries out of struct tags.
+func commitPRsQueryType(n int) reflect.Type {
+	var fields = make([]reflect.StructField, n)
+	for i := range fields {
+		fields[i] = reflect.StructField{
+			Name: fmt.Sprintf("C%d", i),
+			Type: reflect.TypeOf(associatedPRs{}),
+			Tag:  reflect.StructTag(fmt.Sprintf(`graphql:"c%d: object(oid: $c%d)"`, i, i)),
+		}
+	}
+
+	return reflect.StructOf([]reflect.StructField{
+		{Name: "RateLimit", Type: reflect.TypeOf(&options.GitHubRateLimitResponse{})},
+		{Name: "Repository", Type: reflect.StructOf(fields), Tag: `graphql:"repository(owner: $owner, name: $name)"`},
+	})
+}
+
+type commitPRRow struct {
+	hash string
+	pr   *commitPR
+}
+
+type fetchCommitPRsResults struct {
+	RateLimit *options.GitHubRateLimitResponse
+	Edges     []*commitPRRow
+}
+
+func (i *iterCommitPRs) fetchCommitPRs(ctx context.Context, hashes []string) (*fetchCommitPRsResults, error) {
+	var query = reflect.New(commitPRsQueryType(len(hashes)))
+	variables := map[string]interface{}{
+		"owner":   githubv4.String(i.owner),
+		"name":    githubv4.String(i.name),
+		"perpage": githubv4.Int(i.PerPage),
+	}
+	for n, hash := range hashes {
+		variables[fmt.Sprintf("c%d", n)] = githubv4.GitObjectID(hash)
+	}
+
+	err := i.Client().Query(ctx, query.Interface(), variables)
+	if err != nil {
+		return nil, err
+	}
+
+	var results = &fetchCommitPRsResults{RateLimit: query.Elem().Field(0).Interface().(*options.GitHubRateLimitResponse)}
+	repository := query.Elem().Field(1)
+	for n, hash := range hashes {
+		commit := repository.Field(n).Interface().(associatedPRs).Commit
+		for _, pr := range commit.AssociatedPullRequests.Nodes {
+			results.Edges = append(results.Edges, &commitPRRow{hash: hash, pr: pr})
+		}
+	}
+
+	return results, nil
+}
+
+type iterCommitPRs struct {
+	*Options
+	owner   string
+	name    string
+	hashes  []string // commits still to look up
+	current int
+	results *fetchCommitPRsResults
+}
+
+func (i *iterCommitPRs) logger() *zerolog.Logger {
+	logger := i.Logger.With().Int("per-page", i.PerPage).Str("owner", i.owner).Str("name", i.name).Logger()
+	return &logger
+}
+
+func (i *iterCommitPRs) Column(ctx vtab.Context, c int) error {
+	current := i.results.Edges[i.current]
+	col := commitPRCols[c]
+
+	switch col.Name {
+	case "commit_hash":
+		ctx.ResultText(current.hash)
+	case "number":
+		ctx.ResultInt(current.pr.Number)
+	case "title":
+		ctx.ResultText(current.pr.Title)
+	case "state":
+		ctx.ResultText(string(current.pr.State))
+	case "url":
+		ctx.ResultText(current.pr.Url)
+	case "author_login":
+		ctx.ResultText(current.pr.Author.Login)
+	case "base_ref_name":
+		ctx.ResultText(current.pr.BaseRefName)
+	case "head_ref_name":
+		ctx.ResultText(current.pr.HeadRefName)
+	case "merged":
+		if current.pr.Merged {
+			ctx.ResultInt(1)
+		} else {
+			ctx.ResultInt(0)
+		}
+	case "merged_at":
+		t := current.pr.MergedAt
+		if t.IsZero() {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(t.Format(time.RFC3339Nano))
+		}
+	case "merge_commit_hash":
+		if current.pr.MergeCommit.Oid == "" {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(current.pr.MergeCommit.Oid)
+		}
+	}
+	return nil
+}
+
+func (i *iterCommitPRs) Next() (vtab.Row, error) {
+	i.current += 1
+
+	// keep on looking up batches of commits until one is associated with pull requests
+	for i.results == nil || i.current >= len(i.results.Edges) {
+		if len(i.hashes) == 0 {
+			return nil, io.EOF
+		}
+
+		batch := i.hashes
+		if len(batch) > commitPRsBatchSize {
+			batch = batch[:commitPRsBatchSize]
+		}
+		i.hashes = i.hashes[len(batch):]
+
+		err := i.RateLimiter.Wait(i.QueryContext.Context())
+		if err != nil {
+			return nil, err
+		}
+
+		i.Options.GitHubPreRequestHook()
+
+		l := i.logger().With().Int("commits", len(batch)).Logger()
+		l.Info().Msgf("fetching pull requests associated with commits of %s/%s", i.owner, i.name)
+		results, err := i.fetchCommitPRs(i.QueryContext.Context(), batch)
+
+		i.Options.GitHubPostRequestHook()
+
+		if err != nil {
+			return nil, err
+		}
+
+		i.Options.RateLimitHandler(results.RateLimit)
+
+		i.results = results
+		i.current = 0
+	}
+
+	return i, nil
+}
+
+var commitPRCols = []vtab.Column{
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "commits", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "commit_hash", Type: "TEXT"},
+	{Name: "number", Type: "INT"},
+	{Name: "title", Type: "TEXT"},
+	{Name: "state", Type: "TEXT"},
+	{Name: "url", Type: "TEXT"},
+	{Name: "author_login", Type: "TEXT"},
+	{Name: "base_ref_name", Type: "TEXT"},
+	{Name: "head_ref_name", Type: "TEXT"},
+	{Name: "merged", Type: "BOOLEAN"},
+	{Name: "merged_at", Type: "DATETIME"},
+	{Name: "merge_commit_hash", Type: "TEXT"},
+}
+
+// parseCommitHashes parses the commits argument of github_commit_prs, either a single hash or a JSON array of hashes
+func parseCommitHashes(commits string) ([]string, error) {
+	commits = strings.TrimSpace(commits)
+	if !strings.HasPrefix(commits, "[") {
+		if commits == "" {
+			return nil, nil
+		}
+		return []string{commits}, nil
+	}
+
+	var hashes []string
+	if err := json.Unmarshal([]byte(commits), &hashes); err != nil {
+		return nil, errors.Wrap(err, "commits must be a hash or a JSON array of hashes")
+	}
+	return hashes, nil
+}
+
+// NewCommitPRsModule returns the implementation of a table listing the pull requests associated with commits of a GitHub
+// repository (such as the pull request a commit was merged through), as in github_commit_prs('owner/name', hash).
+// Commits can also be passed as a JSON array of hashes (such as with json_group_array), in which case they're looked up
+// in batches, rather than with a request per commit.
+func NewCommitPRsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_commit_prs", commitPRCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullName, commits string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					fullName = constraint.Value.Text()
+				case 1:
+					commits = constraint.Value.Text()
+				}
+			}
+		}
+
+		owner, name, err := repoOwnerAndName("", fullName)
+		if err != nil {
+			return nil, err
+		}
+
+		hashes, err := parseCommitHashes(commits)
+		if err != nil {
+			return nil, err
+		}
+
+		iter := &iterCommitPRs{opts, owner, name, hashes, -1, nil}
+		iter.logger().Info().Msgf("starting GitHub commit_prs iterator for %s/%s", owner, name)
+		return iter, nil
+	}, vtab.EarlyOrderByConstraintExit(true))
+}
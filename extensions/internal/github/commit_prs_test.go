@@ -0,0 +1,37 @@
+package github_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestCommitPRs(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query(`SELECT commit_hash, number, state, merged FROM github_commit_prs('mergestat/mergestat',
+		json_array('0e6a4b2d5f5c8e1f3c0b9a7d6e5f4a3b2c1d0e9f', '7c3d9e1a2b4f6a8c0e2d4f6b8a1c3e5d7f9b0a2c'))`)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	if expected := 3; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	if hash, number := content[0][0], content[0][1]; hash != "0e6a4b2d5f5c8e1f3c0b9a7d6e5f4a3b2c1d0e9f" || number != "412" {
+		t.Fatalf("expected the first commit to be associated with #412, got: %s #%s", hash, number)
+	}
+
+	if state, merged := content[2][2], content[2][3]; state != "OPEN" || merged != "0" {
+		t.Fatalf("expected the last pull request to be open, got state: %s (merged: %s)", state, merged)
+	}
+}
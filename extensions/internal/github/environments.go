@@ -0,0 +1,158 @@
+package github
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+type restEnvironment struct {
+	ID              int       `json:"id"`
+	Name            string    `json:"name"`
+	HTMLURL         string    `json:"html_url"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	ProtectionRules []struct {
+		Type      string        `json:"type"`
+		WaitTimer *int          `json:"wait_timer"`
+		Reviewers []interface{} `json:"reviewers"`
+	} `json:"protection_rules"`
+	DeploymentBranchPolicy *struct {
+		ProtectedBranches    bool `json:"protected_branches"`
+		CustomBranchPolicies bool `json:"custom_branch_policies"`
+	} `json:"deployment_branch_policy"`
+}
+
+type iterEnvironments struct {
+	*Options
+	owner string
+	name  string
+
+	pager        *restPager
+	environments []*restEnvironment
+	current      int
+}
+
+func (i *iterEnvironments) logger() *zerolog.Logger {
+	logger := i.Logger.With().Int("per-page", i.PerPage).Str("owner", i.owner).Str("name", i.name).Logger()
+	return &logger
+}
+
+func (i *iterEnvironments) Column(ctx vtab.Context, c int) error {
+	current := i.environments[i.current]
+	switch environmentCols[c].Name {
+	case "id":
+		ctx.ResultInt(current.ID)
+	case "name":
+		ctx.ResultText(current.Name)
+	case "protection_rules":
+		var types = make([]string, len(current.ProtectionRules))
+		for r, rule := range current.ProtectionRules {
+			types[r] = rule.Type
+		}
+		js, err := json.Marshal(types)
+		if err != nil {
+			i.logger().Err(err).Msgf("could not marshal environment protection rules")
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(string(js))
+		}
+	case "wait_timer":
+		for _, rule := range current.ProtectionRules {
+			if rule.WaitTimer != nil {
+				ctx.ResultInt(*rule.WaitTimer)
+				break
+			}
+		}
+	case "reviewer_count":
+		var count int
+		for _, rule := range current.ProtectionRules {
+			count += len(rule.Reviewers)
+		}
+		ctx.ResultInt(count)
+	case "deployment_branch_policy":
+		switch policy := current.DeploymentBranchPolicy; {
+		case policy == nil:
+			ctx.ResultNull() // deployments from any branch
+		case policy.ProtectedBranches:
+			ctx.ResultText("protected_branches")
+		case policy.CustomBranchPolicies:
+			ctx.ResultText("custom_branch_policies")
+		}
+	case "html_url":
+		ctx.ResultText(current.HTMLURL)
+	case "created_at":
+		ctx.ResultText(current.CreatedAt.Format(time.RFC3339Nano))
+	case "updated_at":
+		ctx.ResultText(current.UpdatedAt.Format(time.RFC3339Nano))
+	}
+	return nil
+}
+
+func (i *iterEnvironments) Next() (vtab.Row, error) {
+	i.current += 1
+
+	for i.current >= len(i.environments) {
+		if i.pager.done() {
+			return nil, io.EOF
+		}
+
+		i.logger().Info().Msgf("fetching page of environments for %s/%s", i.owner, i.name)
+		var page struct {
+			Environments []*restEnvironment `json:"environments"`
+		}
+		if err := i.pager.fetch(&page); err != nil {
+			return nil, err
+		}
+		i.environments, i.current = page.Environments, 0
+	}
+
+	return i, nil
+}
+
+var environmentCols = []vtab.Column{
+	{Name: "owner", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: "INT"},
+	{Name: "name", Type: "TEXT"},
+	{Name: "protection_rules", Type: "JSON"},
+	{Name: "wait_timer", Type: "INT"},
+	{Name: "reviewer_count", Type: "INT"},
+	{Name: "deployment_branch_policy", Type: "TEXT"},
+	{Name: "html_url", Type: "TEXT"},
+	{Name: "created_at", Type: "DATETIME"},
+	{Name: "updated_at", Type: "DATETIME"},
+}
+
+// NewEnvironmentsModule returns the implementation of a table listing the deployment environments of a GitHub repository,
+// along with the types of their protection rules (such as required_reviewers or wait_timer) and the branches allowed
+// to deploy to them (NULL if any branch is)
+func NewEnvironmentsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_environments", environmentCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				}
+			}
+		}
+
+		owner, name, err := repoOwnerAndName(name, fullNameOrOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		iter := &iterEnvironments{Options: opts, owner: owner, name: name, current: -1}
+		iter.pager = newRestPager(opts, restPath("repos", owner, name, "environments"), nil)
+		iter.logger().Info().Msgf("starting GitHub environments iterator for %s/%s", owner, name)
+		return iter, nil
+	})
+}
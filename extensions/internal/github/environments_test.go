@@ -0,0 +1,44 @@
+package github_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestEnvironments(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT * FROM github_environments('mergestat/mergestat')")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	colCount, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	if expected := 9; colCount != expected {
+		t.Fatalf("expected %d columns, got: %d", expected, colCount)
+	}
+
+	if expected := 2; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	production := content[0]
+	if production[1] != "production" || production[2] != `["wait_timer","required_reviewers","branch_policy"]` ||
+		production[3] != "30" || production[4] != "2" || production[5] != "protected_branches" {
+		t.Fatalf("unexpected production environment: %v", production)
+	}
+
+	// environments without protection rules can be deployed to from any branch
+	staging := content[1]
+	if staging[1] != "staging" || staging[2] != "[]" || staging[3] != "NULL" || staging[4] != "0" || staging[5] != "NULL" {
+		t.Fatalf("unexpected staging environment: %v", staging)
+	}
+}
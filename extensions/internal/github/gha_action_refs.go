@@ -0,0 +1,212 @@
+package github
+
+import (
+	"io"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/pkg/ghactions"
+	"github.com/shurcooL/githubv4"
+	"go.riyazali.net/sqlite"
+)
+
+var actionRefsCols = []vtab.Column{
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "path", Type: "TEXT"},
+	{Name: "job", Type: "TEXT"},
+	{Name: "step", Type: "INT"},
+	{Name: "uses", Type: "TEXT"},
+	{Name: "action", Type: "TEXT"},
+	{Name: "version", Type: "TEXT"},
+	{Name: "pinned_to", Type: "TEXT"},
+	{Name: "latest_version", Type: "TEXT"},
+}
+
+// actionRef is what an action repository resolves the version an action is pinned to as
+type actionRef struct {
+	pinnedTo      string // sha, tag or branch, empty if the version isn't known to the repository
+	latestVersion string // the tag of the latest release, or the latest tag if nothing was released
+}
+
+type iterActionRefs struct {
+	*Options
+	steps   []*ghactions.Step
+	current int
+
+	// refs already looked up, keyed by repository and version (as in actions/checkout@v4)
+	refs map[string]*actionRef
+}
+
+// lookup resolves the version the action of step is pinned to in the action's repository, along with its latest version.
+// Actions whose repository can't be found (or accessed) resolve to an empty ref.
+func (i *iterActionRefs) lookup(step *ghactions.Step) (*actionRef, error) {
+	owner, name := step.Repository()
+	_, version := step.Action()
+
+	var key = owner + "/" + name + "@" + version
+	if ref, ok := i.refs[key]; ok {
+		return ref, nil
+	}
+
+	var actionQuery struct {
+		RateLimit  *options.GitHubRateLimitResponse
+		Repository *struct {
+			LatestRelease *struct {
+				TagName string
+			}
+			Tags struct {
+				Nodes []struct {
+					Name string
+				}
+			} `graphql:"tags: refs(refPrefix: \"refs/tags/\", first: 1, orderBy: {field: TAG_COMMIT_DATE, direction: DESC})"`
+			Tag *struct {
+				Name string
+			} `graphql:"tag: ref(qualifiedName: $tag)"`
+			Branch *struct {
+				Name string
+			} `graphql:"branch: ref(qualifiedName: $branch)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	if err := i.RateLimiter.Wait(i.QueryContext.Context()); err != nil {
+		return nil, err
+	}
+
+	i.GitHubPreRequestHook()
+
+	i.Logger.Info().Msgf("fetching versions of GitHub action %s/%s", owner, name)
+	variables := map[string]interface{}{
+		"owner":  githubv4.String(owner),
+		"name":   githubv4.String(name),
+		"tag":    githubv4.String(plumbing.NewTagReferenceName(version)),
+		"branch": githubv4.String(plumbing.NewBranchReferenceName(version)),
+	}
+	err := i.Client().Query(i.QueryContext.Context(), &actionQuery, variables)
+
+	i.GitHubPostRequestHook()
+
+	var ref = &actionRef{}
+	switch {
+	case err != nil && strings.Contains(err.Error(), "Could not resolve to a Repository"):
+		// a private or deleted action, which isn't an error for the rest of the report
+	case err != nil:
+		return nil, err
+	default:
+		i.RateLimitHandler(actionQuery.RateLimit)
+
+		repo := actionQuery.Repository
+		switch {
+		case repo == nil:
+		case ghactions.IsCommitSHA(version):
+			ref.pinnedTo = "sha"
+		case repo.Tag != nil:
+			ref.pinnedTo = "tag"
+		case repo.Branch != nil:
+			ref.pinnedTo = "branch"
+		}
+
+		if repo != nil && repo.LatestRelease != nil {
+			ref.latestVersion = repo.LatestRelease.TagName
+		} else if repo != nil && len(repo.Tags.Nodes) > 0 {
+			ref.latestVersion = repo.Tags.Nodes[0].Name
+		}
+	}
+
+	i.refs[key] = ref
+	return ref, nil
+}
+
+func (i *iterActionRefs) Column(ctx vtab.Context, c int) error {
+	current := i.steps[i.current]
+	action, version := current.Action()
+
+	var value string
+	switch actionRefsCols[c].Name {
+	case "path":
+		value = current.Path
+	case "job":
+		value = current.Job
+	case "step":
+		if current.Step >= 0 {
+			ctx.ResultInt(current.Step)
+		}
+		return nil
+	case "uses":
+		value = current.Uses
+	case "action":
+		value = action
+	case "version":
+		value = version
+	case "pinned_to", "latest_version":
+		// local actions and docker images aren't versioned on GitHub
+		if owner, _ := current.Repository(); owner == "" || version == "" {
+			return nil
+		}
+
+		ref, err := i.lookup(current)
+		if err != nil {
+			return err
+		}
+
+		if value = ref.latestVersion; actionRefsCols[c].Name == "pinned_to" {
+			value = ref.pinnedTo
+		}
+	}
+
+	if value != "" {
+		ctx.ResultText(value)
+	}
+	return nil
+}
+
+func (i *iterActionRefs) Next() (vtab.Row, error) {
+	i.current += 1
+	if i.current >= len(i.steps) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewActionRefsModule returns the implementation of a table-valued-function listing the actions (and reusable workflows)
+// used by the GitHub Actions workflows of a (local) repository at ref (the default ref, or HEAD, if not supplied), with what
+// the version they're used at is in the action's repository (a commit sha, a tag or a branch) and its latest version,
+// for reports of actions to pin or upgrade. Both are NULL for local actions, docker images and repositories which can't be found.
+func NewActionRefsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("gha_action_refs", actionRefsCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					repoPath = constraint.Value.Text()
+				case 1:
+					ref = constraint.Value.Text()
+				}
+			}
+		}
+
+		tree, err := utils.LookupTree(opts.QueryContext.Context(), opts.Locator, opts.Context, repoPath, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		steps, err := ghactions.Read(tree)
+		if err != nil {
+			return nil, err
+		}
+
+		// steps only running commands don't reference an action
+		var uses []*ghactions.Step
+		for _, step := range steps {
+			if step.Uses != "" {
+				uses = append(uses, step)
+			}
+		}
+
+		return &iterActionRefs{Options: opts, steps: uses, current: -1, refs: make(map[string]*actionRef)}, nil
+	})
+}
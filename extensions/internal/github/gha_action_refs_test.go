@@ -0,0 +1,96 @@
+package github_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+const workflow = `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11 # v4.1.1
+      - uses: actions/setup-go@v5
+      - uses: ./.github/actions/lint
+      - uses: example/private-action@v1
+      - run: go build ./...
+  release:
+    uses: mergestat/workflows/.github/workflows/release.yml@main
+`
+
+// commitWorkflow commits the workflow to a new repository, returning its path
+func commitWorkflow(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to initialize repository: %v", err)
+	}
+
+	name := ".github/workflows/ci.yml"
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(workflow), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Add(name); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Commit("add workflow", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	}); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	return dir
+}
+
+func TestActionRefs(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+	dir := commitWorkflow(t)
+
+	rows, err := db.Query("SELECT job, step, action, version, pinned_to, latest_version FROM gha_action_refs(?)", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{"build", "0", "actions/checkout", "b4ffde65f46336ab88eb53be808477a3936bae11", "sha", "v4.1.7"},
+		{"build", "1", "actions/setup-go", "v5", "tag", "v5.0.2"},
+		{"build", "2", "./.github/actions/lint", "NULL", "NULL", "NULL"},
+		{"build", "3", "example/private-action", "v1", "NULL", "NULL"},
+		{"release", "NULL", "mergestat/workflows/.github/workflows/release.yml", "main", "branch", "v0.3.0"},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d action references, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q in row %d, column %d, got: %v", value, r, c, contents[r])
+			}
+		}
+	}
+}
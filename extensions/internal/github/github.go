@@ -1,7 +1,7 @@
 package github
 
 import (
-	"context"
+	"net/http"
 	"time"
 
 	"github.com/mergestat/mergestat-lite/extensions/options"
@@ -25,6 +25,16 @@ func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCod
 		opt.Logger = &l
 	}
 
+	httpClient := func() *http.Client {
+		return &http.Client{Transport: &oauth2.Transport{
+			Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: GetGitHubTokenFromCtx(opt.Context)}),
+			Base:   opt.RetryTransport(opt.HTTPTransport),
+		}}
+	}
+	if opt.GitHubHTTPClientGetter != nil {
+		httpClient = opt.GitHubHTTPClientGetter
+	}
+
 	githubOpts := &Options{
 		RateLimiter: rateLimiter,
 		RateLimitHandler: func(rlr *options.GitHubRateLimitResponse) {
@@ -38,15 +48,16 @@ func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCod
 		},
 		GitHubPreRequestHook:  func() {},
 		GitHubPostRequestHook: func() {},
+		HTTPClient:            httpClient,
 		Client: func() *githubv4.Client {
-			httpClient := oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(
-				&oauth2.Token{AccessToken: GetGitHubTokenFromCtx(opt.Context)},
-			))
-			client := githubv4.NewClient(httpClient)
+			client := githubv4.NewClient(httpClient())
 			return client
 		},
-		PerPage: GetGitHubPerPageFromCtx(opt.Context),
-		Logger:  opt.Logger,
+		PerPage:      GetGitHubPerPageFromCtx(opt.Context),
+		Logger:       opt.Logger,
+		QueryContext: opt.QueryContext,
+		Locator:      opt.Locator,
+		Context:      opt.Context,
 	}
 
 	if opt.GitHubClientGetter != nil {
@@ -80,24 +91,51 @@ func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCod
 		"github_repo_commits":            NewRepoCommitsModule(githubOpts),
 		"github_repo_pr_reviews":         NewPRReviewsModule(githubOpts),
 		"github_org_audit_log":           NewOrgAuditModule(githubOpts),
+		"github_repo_deployments":        NewDeploymentsModule(githubOpts),
+		"github_commit_prs":              NewCommitPRsModule(githubOpts),
+		"github_user":                    NewUserProfileModule(githubOpts),
+		"github_project_items":           NewProjectItemsModule(githubOpts),
+		"github_packages":                NewPackagesModule(githubOpts),
+		"github_package_versions":        NewPackageVersionsModule(githubOpts),
+		"github_environments":            NewEnvironmentsModule(githubOpts),
+		"github_actions_secrets":         NewActionsSecretsModule(githubOpts),
+		"github_actions_variables":       NewActionsVariablesModule(githubOpts),
+		"github_webhooks":                NewWebhooksModule(githubOpts),
+		"github_workflow_runs":           NewWorkflowRunsModule(githubOpts),
+		"github_collaborators":           NewCollaboratorsModule(githubOpts),
+		"github_repo_invitations":        NewRepoInvitationsModule(githubOpts),
+		"gha_action_refs":                NewActionRefsModule(githubOpts),
+	}
+
+	// aliases of the tables above
+	var aliases = map[string]string{
+		"github_issue_comments":     "github_repo_issue_comments",
+		"github_pr_comments":        "github_repo_pr_comments",
+		"github_issues":             "github_repo_issues",
+		"github_pull_requests":      "github_repo_pull_requests",
+		"github_prs":                "github_repo_pull_requests",
+		"github_repo_prs":           "github_repo_pull_requests",
+		"github_branch_protections": "github_repo_branch_protections",
+		"github_pr_commits":         "github_repo_pr_commits",
+		"github_pr_reviews":         "github_repo_pr_reviews",
+		"github_audit_log":          "github_org_audit_log",
+		"github_deployments":        "github_repo_deployments",
 	}
 
-	modules["github_issue_comments"] = modules["github_repo_issue_comments"]
-	modules["github_pr_comments"] = modules["github_repo_pr_comments"]
-	modules["github_issues"] = modules["github_repo_issues"]
-	modules["github_pull_requests"] = modules["github_repo_pull_requests"]
-	modules["github_prs"] = modules["github_repo_pull_requests"]
-	modules["github_repo_prs"] = modules["github_repo_pull_requests"]
-	modules["github_branch_protections"] = modules["github_repo_branch_protections"]
-	modules["github_pr_commits"] = modules["github_repo_pr_commits"]
-	modules["github_pr_reviews"] = modules["github_repo_pr_reviews"]
-	modules["github_audit_log"] = modules["github_org_audit_log"]
+	for alias, name := range aliases {
+		modules[alias] = modules[name]
+	}
 
 	// register GitHub tables
 	for name, mod := range modules {
-		if err = ext.CreateModule(name, mod); err != nil {
+		if err = ext.CreateModule(name, opt.Stats.Module(name, mod)); err != nil {
 			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register GitHub %q module", name)
 		}
+		opt.Modules.Add("github", name, mod)
+	}
+
+	for alias, name := range aliases {
+		opt.Modules.Alias(alias, name)
 	}
 
 	var fns = map[string]sqlite.Function{
@@ -1,48 +1,21 @@
 package github_test
 
 import (
-	"context"
 	"database/sql"
 	"os"
-	"path"
 	"testing"
 
-	"github.com/dnaeon/go-vcr/v2/cassette"
-	"github.com/dnaeon/go-vcr/v2/recorder"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/mergestat/mergestat-lite/extensions"
 	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/pkg/locator"
 	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
-	"github.com/shurcooL/githubv4"
+	"github.com/mergestat/mergestat-lite/pkg/vcr"
 	"go.riyazali.net/sqlite"
-	"golang.org/x/oauth2"
 )
 
-var httpClient = oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(
-	&oauth2.Token{AccessToken: os.Getenv("GITHUB_TOKEN")},
-))
-
-func newRecorder(t *testing.T) func() {
-	r, err := recorder.New(path.Join("fixtures", t.Name()))
-	if err != nil {
-		t.Fatal(err)
-	}
-	r.SkipRequestLatency = true
-	r.SetTransport(httpClient.Transport)
-	httpClient.Transport = r
-
-	r.AddSaveFilter(func(i *cassette.Interaction) error {
-		delete(i.Request.Headers, "Authorization")
-		return nil
-	})
-
-	return func() {
-		err := r.Stop()
-		if err != nil {
-			t.Fatal(err)
-		}
-	}
-}
+// transport replays the interactions recorded in the fixtures directory
+var transport = &vcr.Transport{}
 
 // tests' entrypoint that registers the extension
 // automatically with all loaded database connections
@@ -51,9 +24,10 @@ func TestMain(m *testing.M) {
 	sqlite.Register(extensions.RegisterFn(
 		options.WithExtraFunctions(),
 		options.WithGitHub(),
-		options.WithGitHubClientGetter(func() *githubv4.Client {
-			return githubv4.NewClient(httpClient)
-		}),
+		options.WithRepoLocator(locator.CachedLocator(locator.MultiLocator(nil))),
+		options.WithHTTPTransport(transport),
+		options.WithContextValue("githubToken", os.Getenv("GITHUB_TOKEN")),
+		options.WithContextValue("httpRetries", "0"),
 	))
 	os.Exit(m.Run())
 }
@@ -76,3 +50,15 @@ func Connect(t *testing.T, dataSourceName string) *sql.DB {
 
 	return db
 }
+
+// columnIndex returns the index of the column named name in cols, failing the test if there's no such column
+func columnIndex(t *testing.T, cols []string, name string) int {
+	t.Helper()
+	for i, col := range cols {
+		if col == name {
+			return i
+		}
+	}
+	t.Fatalf("expected a %s column, got: %v", name, cols)
+	return -1
+}
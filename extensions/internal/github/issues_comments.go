@@ -31,11 +31,12 @@ type issueComment struct {
 		Login string
 		Url   string
 	}
-	CreatedAt  githubv4.DateTime
-	DatabaseId int
-	Id         githubv4.GitObjectID
-	UpdatedAt  githubv4.DateTime
-	Url        githubv4.URI
+	CreatedAt      githubv4.DateTime
+	DatabaseId     int
+	Id             githubv4.GitObjectID
+	ReactionGroups reactionGroups
+	UpdatedAt      githubv4.DateTime
+	Url            githubv4.URI
 }
 
 type fetchIssuesCommentsResults struct {
@@ -120,6 +121,16 @@ func (i *iterIssuesComments) Column(ctx vtab.Context, c int) error {
 		ctx.ResultInt(current.DatabaseId)
 	case "id":
 		ctx.ResultText(string(current.Id))
+	case "reaction_count":
+		ctx.ResultInt(current.ReactionGroups.total())
+	case "reactions":
+		js, err := current.ReactionGroups.json()
+		if err != nil {
+			i.logger().Err(err).Msgf("could not marshal issue comment reactions")
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(js)
+		}
 	case "updated_at":
 		t := current.UpdatedAt
 		if t.IsZero() {
@@ -142,7 +153,7 @@ func (i *iterIssuesComments) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.currentComment >= len(i.results.Comments.Comments.Nodes) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.QueryContext.Context())
 			if err != nil {
 				return nil, err
 			}
@@ -156,7 +167,7 @@ func (i *iterIssuesComments) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of issue_comments for %s/%s", i.owner, i.name)
-			results, err := i.fetchIssueComments(context.Background(), cursor)
+			results, err := i.fetchIssueComments(i.QueryContext.Context(), cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -191,6 +202,8 @@ var issuesCommentCols = []vtab.Column{
 	{Name: "created_at", Type: "TEXT"},
 	{Name: "database_id", Type: "INT"},
 	{Name: "id", Type: "TEXT"},
+	{Name: "reaction_count", Type: "INT"},
+	{Name: "reactions", Type: "JSON"},
 	{Name: "updated_at", Type: "TEXT", OrderBy: vtab.ASC | vtab.DESC},
 	{Name: "url", Type: "TEXT"},
 	{Name: "issue_id", Type: "TEXT"},
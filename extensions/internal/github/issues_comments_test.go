@@ -7,8 +7,7 @@ import (
 )
 
 func TestIssueComments(t *testing.T) {
-	cleanup := newRecorder(t)
-	defer cleanup()
+	defer transport.Start(t)()
 
 	db := Connect(t, Memory)
 
@@ -18,15 +17,46 @@ func TestIssueComments(t *testing.T) {
 	}
 	defer rows.Close()
 
-	colCount, _, err := tools.RowContent(rows)
+	cols, err := rows.Columns()
+	if err != nil {
+		t.Fatalf("failed to retrieve columns: %v", err.Error())
+	}
+
+	colCount, content, err := tools.RowContent(rows)
 	if err != nil {
 		t.Fatalf("failed to retrieve row contents: %v", err.Error())
 	}
 
-	if expected := 9; colCount != expected {
+	if expected := 11; colCount != expected {
 		t.Fatalf("expected %d columns, got: %d", expected, colCount)
 	}
 
+	var expected = map[string][2]string{
+		"654842143": {"0", `{"confused":0,"eyes":0,"heart":0,"hooray":0,"laugh":0,"rocket":0,"thumbs_down":0,"thumbs_up":0}`},
+		"655965188": {"2", `{"confused":0,"eyes":0,"heart":0,"hooray":0,"laugh":0,"rocket":0,"thumbs_down":0,"thumbs_up":2}`},
+		"686189137": {"1", `{"confused":0,"eyes":0,"heart":1,"hooray":0,"laugh":0,"rocket":0,"thumbs_down":0,"thumbs_up":0}`},
+	}
+
+	id, count, reactions := columnIndex(t, cols, "database_id"), columnIndex(t, cols, "reaction_count"), columnIndex(t, cols, "reactions")
+	for _, row := range content {
+		want, ok := expected[row[id]]
+		if !ok {
+			continue
+		}
+		delete(expected, row[id])
+
+		if row[count] != want[0] {
+			t.Fatalf("expected comment %s to have %s reactions, got: %s", row[id], want[0], row[count])
+		}
+		if row[reactions] != want[1] {
+			t.Fatalf("expected comment %s to have reactions %s, got: %s", row[id], want[1], row[reactions])
+		}
+	}
+
+	if len(expected) != 0 {
+		t.Fatalf("expected comments %v to be returned", expected)
+	}
+
 	rows, err = db.Query("SELECT * FROM github_repo_issue_comments('askgitdev', 'askgit', 10) LIMIT 10")
 	if err != nil {
 		t.Fatalf("failed to execute query: %v", err.Error())
@@ -38,7 +68,7 @@ func TestIssueComments(t *testing.T) {
 		t.Fatalf("failed to retrieve row contents: %v", err.Error())
 	}
 
-	if expected := 9; colCount != expected {
+	if expected := 11; colCount != expected {
 		t.Fatalf("expected %d columns, got: %d", expected, colCount)
 	}
 
@@ -141,7 +141,7 @@ func (i *iterOrgAuditLogs) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.AuditLogs) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.QueryContext.Context())
 			if err != nil {
 				return nil, err
 			}
@@ -153,7 +153,7 @@ func (i *iterOrgAuditLogs) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of org audit entries for %s", i.login)
-			results, err := i.fetchOrgAuditRepos(context.Background(), cursor)
+			results, err := i.fetchOrgAuditRepos(i.QueryContext.Context(), cursor)
 			if err != nil {
 				return nil, err
 			}
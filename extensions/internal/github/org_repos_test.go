@@ -7,8 +7,7 @@ import (
 )
 
 func TestOrgRepos(t *testing.T) {
-	cleanup := newRecorder(t)
-	defer cleanup()
+	defer transport.Start(t)()
 
 	db := Connect(t, Memory)
 
@@ -23,7 +22,7 @@ func TestOrgRepos(t *testing.T) {
 		t.Fatalf("failed to retrieve row contents: %v", err.Error())
 	}
 
-	if expected := 30; colCount != expected {
+	if expected := 35; colCount != expected {
 		t.Fatalf("expected %d columns, got: %d", expected, colCount)
 	}
 
@@ -31,3 +30,29 @@ func TestOrgRepos(t *testing.T) {
 		t.Fatalf("expected %d rows, got: %d", expected, len(content))
 	}
 }
+
+func TestOrgReposNamePrefix(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	// the fixture has a single page (with more to come), listing stops once past the repositories prefixed with mergestat
+	rows, err := db.Query("SELECT name FROM github_org_repos('mergestat') WHERE name LIKE 'mergestat%' AND is_archived = false")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	if expected := 2; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	if name := content[1][0]; name != "mergestat-lite" {
+		t.Fatalf("expected mergestat-lite, got: %s", name)
+	}
+}
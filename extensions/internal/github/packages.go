@@ -0,0 +1,268 @@
+package github
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+// packageTypes are the types of packages hosted by GitHub Packages, listed in turn when no type is supplied
+var packageTypes = []string{"container", "npm", "maven", "rubygems", "nuget", "docker"}
+
+type restPackage struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	PackageType  string    `json:"package_type"`
+	Visibility   string    `json:"visibility"`
+	VersionCount int       `json:"version_count"`
+	HTMLURL      string    `json:"html_url"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Repository   *struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+type iterPackages struct {
+	*Options
+	org   string
+	types []string // the package types left to list
+
+	pager    *restPager
+	packages []*restPackage
+	current  int
+}
+
+func (i *iterPackages) logger() *zerolog.Logger {
+	logger := i.Logger.With().Int("per-page", i.PerPage).Str("org", i.org).Logger()
+	return &logger
+}
+
+func (i *iterPackages) Column(ctx vtab.Context, c int) error {
+	current := i.packages[i.current]
+	switch packageCols[c].Name {
+	case "id":
+		ctx.ResultInt(current.ID)
+	case "name":
+		ctx.ResultText(current.Name)
+	case "type":
+		ctx.ResultText(current.PackageType)
+	case "visibility":
+		ctx.ResultText(current.Visibility)
+	case "version_count":
+		ctx.ResultInt(current.VersionCount)
+	case "repository":
+		if current.Repository != nil {
+			ctx.ResultText(current.Repository.FullName)
+		}
+	case "html_url":
+		ctx.ResultText(current.HTMLURL)
+	case "created_at":
+		ctx.ResultText(current.CreatedAt.Format(time.RFC3339Nano))
+	case "updated_at":
+		ctx.ResultText(current.UpdatedAt.Format(time.RFC3339Nano))
+	}
+	return nil
+}
+
+func (i *iterPackages) Next() (vtab.Row, error) {
+	i.current += 1
+
+	for i.current >= len(i.packages) {
+		// move on to the next package type once all of the pages of the current one were fetched
+		if i.pager == nil || i.pager.done() {
+			if len(i.types) == 0 {
+				return nil, io.EOF
+			}
+			i.pager = newRestPager(i.Options, restPath("orgs", i.org, "packages"), url.Values{"package_type": {i.types[0]}})
+			i.types = i.types[1:]
+		}
+
+		i.logger().Info().Msgf("fetching page of packages for %s", i.org)
+		var page []*restPackage
+		if err := i.pager.fetch(&page); err != nil {
+			return nil, err
+		}
+		i.packages, i.current = page, 0
+	}
+
+	return i, nil
+}
+
+var packageCols = []vtab.Column{
+	{Name: "org", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "package_type", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: "INT"},
+	{Name: "name", Type: "TEXT"},
+	{Name: "type", Type: "TEXT"},
+	{Name: "visibility", Type: "TEXT"},
+	{Name: "version_count", Type: "INT"},
+	{Name: "repository", Type: "TEXT"},
+	{Name: "html_url", Type: "TEXT"},
+	{Name: "created_at", Type: "DATETIME"},
+	{Name: "updated_at", Type: "DATETIME"},
+}
+
+// NewPackagesModule returns the implementation of a table listing the packages (such as container images, npm or maven
+// packages) an organization publishes on GitHub Packages. Packages of all types are listed, unless one is supplied.
+func NewPackagesModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_packages", packageCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var org, packageType string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					org = constraint.Value.Text()
+				case 1:
+					packageType = constraint.Value.Text()
+				}
+			}
+		}
+
+		if org == "" {
+			return nil, errors.New("an organization must be supplied")
+		}
+
+		var types = packageTypes
+		if packageType != "" {
+			types = []string{packageType}
+		}
+
+		iter := &iterPackages{Options: opts, org: org, types: types, current: -1}
+		iter.logger().Info().Msgf("starting GitHub packages iterator for %s", org)
+		return iter, nil
+	})
+}
+
+type restPackageVersion struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	HTMLURL   string    `json:"html_url"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Metadata  struct {
+		Container struct {
+			Tags []string `json:"tags"`
+		} `json:"container"`
+		Docker struct {
+			Tag []string `json:"tag"`
+		} `json:"docker"`
+	} `json:"metadata"`
+}
+
+// tags returns the tags of a container (or docker) image version
+func (v *restPackageVersion) tags() []string {
+	var tags = append([]string{}, v.Metadata.Container.Tags...)
+	return append(tags, v.Metadata.Docker.Tag...)
+}
+
+type iterPackageVersions struct {
+	*Options
+	org         string
+	packageType string
+	packageName string
+
+	pager    *restPager
+	versions []*restPackageVersion
+	current  int
+}
+
+func (i *iterPackageVersions) logger() *zerolog.Logger {
+	logger := i.Logger.With().Int("per-page", i.PerPage).Str("org", i.org).Str("package-type", i.packageType).Str("package-name", i.packageName).Logger()
+	return &logger
+}
+
+func (i *iterPackageVersions) Column(ctx vtab.Context, c int) error {
+	current := i.versions[i.current]
+	switch packageVersionCols[c].Name {
+	case "id":
+		ctx.ResultInt(current.ID)
+	case "name":
+		ctx.ResultText(current.Name)
+	case "tags":
+		js, err := json.Marshal(current.tags())
+		if err != nil {
+			i.logger().Err(err).Msgf("could not marshal package version tags")
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(string(js))
+		}
+	case "tag_count":
+		ctx.ResultInt(len(current.tags()))
+	case "html_url":
+		ctx.ResultText(current.HTMLURL)
+	case "created_at":
+		ctx.ResultText(current.CreatedAt.Format(time.RFC3339Nano))
+	case "updated_at":
+		ctx.ResultText(current.UpdatedAt.Format(time.RFC3339Nano))
+	}
+	return nil
+}
+
+func (i *iterPackageVersions) Next() (vtab.Row, error) {
+	i.current += 1
+
+	for i.current >= len(i.versions) {
+		if i.pager.done() {
+			return nil, io.EOF
+		}
+
+		i.logger().Info().Msgf("fetching page of package versions for %s/%s", i.org, i.packageName)
+		var page []*restPackageVersion
+		if err := i.pager.fetch(&page); err != nil {
+			return nil, err
+		}
+		i.versions, i.current = page, 0
+	}
+
+	return i, nil
+}
+
+var packageVersionCols = []vtab.Column{
+	{Name: "org", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "package_type", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "package_name", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: "INT"},
+	{Name: "name", Type: "TEXT"},
+	{Name: "tags", Type: "JSON"},
+	{Name: "tag_count", Type: "INT"},
+	{Name: "html_url", Type: "TEXT"},
+	{Name: "created_at", Type: "DATETIME"},
+	{Name: "updated_at", Type: "DATETIME"},
+}
+
+// NewPackageVersionsModule returns the implementation of a table listing the versions of a package an organization
+// publishes on GitHub Packages (most recent first), along with their tags (for container images). Versions without
+// any tag are usually the ones retention policies clean up.
+func NewPackageVersionsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_package_versions", packageVersionCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var org, packageType, packageName string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					org = constraint.Value.Text()
+				case 1:
+					packageType = constraint.Value.Text()
+				case 2:
+					packageName = constraint.Value.Text()
+				}
+			}
+		}
+
+		if org == "" || packageType == "" || packageName == "" {
+			return nil, errors.New("an organization, package type and package name must be supplied")
+		}
+
+		iter := &iterPackageVersions{Options: opts, org: org, packageType: packageType, packageName: packageName, current: -1}
+		iter.pager = newRestPager(opts, restPath("orgs", org, "packages", packageType, packageName, "versions"), nil)
+		iter.logger().Info().Msgf("starting GitHub package versions iterator for %s/%s", org, packageName)
+		return iter, nil
+	})
+}
@@ -0,0 +1,70 @@
+package github_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestPackages(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT name, type, version_count, repository FROM github_packages('mergestat', 'container')")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	// the packages are spread over two pages
+	if expected := 2; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	if name, repo := content[0][0], content[0][3]; name != "mergestat" || repo != "mergestat/mergestat" {
+		t.Fatalf("unexpected first package: %v", content[0])
+	}
+
+	if name, repo := content[1][0], content[1][3]; name != "mergestat-lite" || repo != "NULL" {
+		t.Fatalf("unexpected second package: %v", content[1])
+	}
+}
+
+func TestPackageVersions(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT * FROM github_package_versions('mergestat', 'container', 'mergestat')")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	colCount, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	if expected := 7; colCount != expected {
+		t.Fatalf("expected %d columns, got: %d", expected, colCount)
+	}
+
+	if expected := 2; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	if tags, count := content[0][2], content[0][3]; tags != `["latest","0.9.3"]` || count != "2" {
+		t.Fatalf("unexpected tags of the latest version: %s (%s)", tags, count)
+	}
+
+	if tags, count := content[1][2], content[1][3]; tags != "[]" || count != "0" {
+		t.Fatalf("expected the previous version to be untagged, got: %s (%s)", tags, count)
+	}
+}
@@ -151,7 +151,7 @@ func (i *iterPRCommits) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.currentCommit >= len(i.results.PR.Commits.Nodes) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.QueryContext.Context())
 			if err != nil {
 				return nil, err
 			}
@@ -165,7 +165,7 @@ func (i *iterPRCommits) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of pr_commits for %s/%s", i.owner, i.name)
-			results, err := i.fetchPRCommits(context.Background(), cursor)
+			results, err := i.fetchPRCommits(i.QueryContext.Context(), cursor)
 
 			i.Options.GitHubPostRequestHook()
 
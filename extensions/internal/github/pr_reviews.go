@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/internal/sqlutil"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/rs/zerolog"
 	"github.com/shurcooL/githubv4"
@@ -114,7 +115,7 @@ func (i *iterPRReviews) Column(ctx vtab.Context, c int) error {
 	case "author_association":
 		ctx.ResultText(current.AuthorAssociation)
 	case "author_can_push_to_repository":
-		ctx.ResultInt(t1f0(current.AuthorCanPushToRepository))
+		ctx.ResultInt(sqlutil.BoolToInt(current.AuthorCanPushToRepository))
 	case "body":
 		ctx.ResultText(current.Body)
 	case "comment_count":
@@ -127,7 +128,7 @@ func (i *iterPRReviews) Column(ctx vtab.Context, c int) error {
 			ctx.ResultText(t.Format(time.RFC3339Nano))
 		}
 	case "created_via_email":
-		ctx.ResultInt(t1f0(current.CreatedViaEmail))
+		ctx.ResultInt(sqlutil.BoolToInt(current.CreatedViaEmail))
 	case "editor_login":
 		ctx.ResultText(current.Editor.Login)
 	case "id":
@@ -173,7 +174,7 @@ func (i *iterPRReviews) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.currentReview >= len(i.results.PullRequest.Reviews.Nodes) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.QueryContext.Context())
 			if err != nil {
 				return nil, err
 			}
@@ -187,7 +188,7 @@ func (i *iterPRReviews) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of pr_reviews for %s/%s", i.owner, i.name)
-			results, err := i.fetchPRReviews(context.Background(), cursor)
+			results, err := i.fetchPRReviews(i.QueryContext.Context(), cursor)
 
 			i.Options.GitHubPostRequestHook()
 
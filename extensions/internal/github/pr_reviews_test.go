@@ -7,8 +7,7 @@ import (
 )
 
 func TestPRReviews(t *testing.T) {
-	cleanup := newRecorder(t)
-	defer cleanup()
+	defer transport.Start(t)()
 
 	db := Connect(t, Memory)
 
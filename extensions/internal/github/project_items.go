@@ -0,0 +1,378 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/internal/sqlutil"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/shurcooL/githubv4"
+	"go.riyazali.net/sqlite"
+)
+
+// projectItemContent is the issue, pull request or draft issue an item of a project is tracking
+type projectItemContent struct {
+	Issue struct {
+		Number     int
+		Title      string
+		Url        string
+		State      string
+		Repository struct {
+			NameWithOwner string
+		}
+	} `graphql:"... on Issue"`
+	PullRequest struct {
+		Number     int
+		Title      string
+		Url        string
+		State      string
+		Repository struct {
+			NameWithOwner string
+		}
+	} `graphql:"... on PullRequest"`
+	DraftIssue struct {
+		Title string
+	} `graphql:"... on DraftIssue"`
+}
+
+// projectField is the (custom) field of a project a value is set for
+type projectField struct {
+	Common struct {
+		Name string
+	} `graphql:"... on ProjectV2FieldCommon"`
+}
+
+// projectItemFieldValue is the value of a field of a project item. Only one of the fragments is set, depending on the type of field.
+type projectItemFieldValue struct {
+	Typename     string `graphql:"__typename"`
+	SingleSelect struct {
+		Name  string
+		Field projectField
+	} `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
+	Iteration struct {
+		Title     string
+		StartDate string
+		Duration  int
+		Field     projectField
+	} `graphql:"... on ProjectV2ItemFieldIterationValue"`
+	Text struct {
+		Text  string
+		Field projectField
+	} `graphql:"... on ProjectV2ItemFieldTextValue"`
+	Number struct {
+		Number float64
+		Field  projectField
+	} `graphql:"... on ProjectV2ItemFieldNumberValue"`
+	Date struct {
+		Date  string
+		Field projectField
+	} `graphql:"... on ProjectV2ItemFieldDateValue"`
+}
+
+// nameAndValue returns the name of the field the value is set for, and the value itself
+func (v *projectItemFieldValue) nameAndValue() (string, interface{}) {
+	switch v.Typename {
+	case "ProjectV2ItemFieldSingleSelectValue":
+		return v.SingleSelect.Field.Common.Name, v.SingleSelect.Name
+	case "ProjectV2ItemFieldIterationValue":
+		return v.Iteration.Field.Common.Name, v.Iteration.Title
+	case "ProjectV2ItemFieldTextValue":
+		return v.Text.Field.Common.Name, v.Text.Text
+	case "ProjectV2ItemFieldNumberValue":
+		return v.Number.Field.Common.Name, v.Number.Number
+	case "ProjectV2ItemFieldDateValue":
+		return v.Date.Field.Common.Name, v.Date.Date
+	}
+	return "", nil
+}
+
+type projectItem struct {
+	Id          string
+	Type        githubv4.ProjectV2ItemType
+	IsArchived  bool
+	CreatedAt   githubv4.DateTime
+	UpdatedAt   githubv4.DateTime
+	Content     projectItemContent
+	FieldValues struct {
+		Nodes []*projectItemFieldValue
+	} `graphql:"fieldValues(first: 25)"`
+}
+
+// status returns the value of the "Status" field of the item (the field projects are created with), if set
+func (item *projectItem) status() string {
+	for _, value := range item.FieldValues.Nodes {
+		if value.Typename == "ProjectV2ItemFieldSingleSelectValue" && value.SingleSelect.Field.Common.Name == "Status" {
+			return value.SingleSelect.Name
+		}
+	}
+	return ""
+}
+
+// iteration returns the (first) iteration the item is planned for, if any
+func (item *projectItem) iteration() *projectItemFieldValue {
+	for _, value := range item.FieldValues.Nodes {
+		if value.Typename == "ProjectV2ItemFieldIterationValue" {
+			return value
+		}
+	}
+	return nil
+}
+
+type fetchProjectItemsResults struct {
+	RateLimit   *options.GitHubRateLimitResponse
+	Edges       []*projectItem
+	HasNextPage bool
+	EndCursor   *githubv4.String
+}
+
+func (i *iterProjectItems) fetchProjectItems(ctx context.Context, startCursor *githubv4.String) (*fetchProjectItemsResults, error) {
+	var projectItemsQuery struct {
+		RateLimit    *options.GitHubRateLimitResponse
+		Organization struct {
+			ProjectV2 *struct {
+				Items struct {
+					Nodes    []*projectItem
+					PageInfo struct {
+						EndCursor   githubv4.String
+						HasNextPage bool
+					}
+				} `graphql:"items(first: $perpage, after: $itemcursor)"`
+			} `graphql:"projectV2(number: $number)"`
+		} `graphql:"organization(login: $login)"`
+	}
+	variables := map[string]interface{}{
+		"login":      githubv4.String(i.org),
+		"number":     githubv4.Int(i.number),
+		"perpage":    githubv4.Int(i.PerPage),
+		"itemcursor": startCursor,
+	}
+
+	err := i.Client().Query(ctx, &projectItemsQuery, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	project := projectItemsQuery.Organization.ProjectV2
+	if project == nil {
+		return nil, errors.Errorf("could not find project %d of %s", i.number, i.org)
+	}
+
+	return &fetchProjectItemsResults{
+		RateLimit:   projectItemsQuery.RateLimit,
+		Edges:       project.Items.Nodes,
+		HasNextPage: project.Items.PageInfo.HasNextPage,
+		EndCursor:   &project.Items.PageInfo.EndCursor,
+	}, nil
+}
+
+type iterProjectItems struct {
+	*Options
+	org     string
+	number  int
+	current int
+	results *fetchProjectItemsResults
+}
+
+func (i *iterProjectItems) logger() *zerolog.Logger {
+	logger := i.Logger.With().Int("per-page", i.PerPage).Str("org", i.org).Int("number", i.number).Logger()
+	return &logger
+}
+
+func (i *iterProjectItems) Column(ctx vtab.Context, c int) error {
+	current := i.results.Edges[i.current]
+	col := projectItemCols[c]
+
+	// the issue and pull request fragments share the same fields
+	content := current.Content.Issue
+	if current.Type == githubv4.ProjectV2ItemTypePullRequest {
+		content = current.Content.PullRequest
+	}
+
+	switch col.Name {
+	case "id":
+		ctx.ResultText(current.Id)
+	case "type":
+		ctx.ResultText(string(current.Type))
+	case "is_archived":
+		ctx.ResultInt(sqlutil.BoolToInt(current.IsArchived))
+	case "content_repository":
+		if content.Repository.NameWithOwner == "" {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(content.Repository.NameWithOwner)
+		}
+	case "content_number":
+		if content.Number == 0 {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultInt(content.Number)
+		}
+	case "content_title":
+		if current.Type == githubv4.ProjectV2ItemTypeDraftIssue {
+			ctx.ResultText(current.Content.DraftIssue.Title)
+		} else {
+			ctx.ResultText(content.Title)
+		}
+	case "content_url":
+		if content.Url == "" {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(content.Url)
+		}
+	case "content_state":
+		if content.State == "" {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(content.State)
+		}
+	case "status":
+		if status := current.status(); status == "" {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(status)
+		}
+	case "iteration":
+		if iteration := current.iteration(); iteration == nil {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(iteration.Iteration.Title)
+		}
+	case "iteration_start_date":
+		if iteration := current.iteration(); iteration == nil {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(iteration.Iteration.StartDate)
+		}
+	case "iteration_duration":
+		if iteration := current.iteration(); iteration == nil {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultInt(iteration.Iteration.Duration)
+		}
+	case "field_values":
+		var values = make(map[string]interface{})
+		for _, value := range current.FieldValues.Nodes {
+			if name, v := value.nameAndValue(); name != "" {
+				values[name] = v
+			}
+		}
+		js, err := json.Marshal(values)
+		if err != nil {
+			i.logger().Err(err).Msgf("could not marshal project item field values")
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(string(js))
+		}
+	case "created_at":
+		t := current.CreatedAt
+		if t.IsZero() {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(t.Format(time.RFC3339Nano))
+		}
+	case "updated_at":
+		t := current.UpdatedAt
+		if t.IsZero() {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(t.Format(time.RFC3339Nano))
+		}
+	}
+	return nil
+}
+
+func (i *iterProjectItems) Next() (vtab.Row, error) {
+	i.current += 1
+
+	if i.results == nil || i.current >= len(i.results.Edges) {
+		if i.results == nil || i.results.HasNextPage {
+			err := i.RateLimiter.Wait(i.QueryContext.Context())
+			if err != nil {
+				return nil, err
+			}
+
+			var cursor *githubv4.String
+			if i.results != nil {
+				cursor = i.results.EndCursor
+			}
+
+			i.Options.GitHubPreRequestHook()
+
+			l := i.logger().With().Interface("cursor", cursor).Logger()
+			l.Info().Msgf("fetching page of project_items for %s project %d", i.org, i.number)
+			results, err := i.fetchProjectItems(i.QueryContext.Context(), cursor)
+
+			i.Options.GitHubPostRequestHook()
+
+			if err != nil {
+				return nil, err
+			}
+
+			i.Options.RateLimitHandler(results.RateLimit)
+
+			i.results = results
+			i.current = 0
+
+			if len(results.Edges) == 0 {
+				return nil, io.EOF
+			}
+		} else {
+			return nil, io.EOF
+		}
+	}
+
+	return i, nil
+}
+
+var projectItemCols = []vtab.Column{
+	{Name: "org", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "project_number", Type: "INT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: "TEXT"},
+	{Name: "type", Type: "TEXT"},
+	{Name: "is_archived", Type: "BOOLEAN"},
+	{Name: "content_repository", Type: "TEXT"},
+	{Name: "content_number", Type: "INT"},
+	{Name: "content_title", Type: "TEXT"},
+	{Name: "content_url", Type: "TEXT"},
+	{Name: "content_state", Type: "TEXT"},
+	{Name: "status", Type: "TEXT"},
+	{Name: "iteration", Type: "TEXT"},
+	{Name: "iteration_start_date", Type: "DATE"},
+	{Name: "iteration_duration", Type: "INT"},
+	{Name: "field_values", Type: "JSON"},
+	{Name: "created_at", Type: "DATETIME"},
+	{Name: "updated_at", Type: "DATETIME"},
+}
+
+// NewProjectItemsModule returns the implementation of a table listing the items of a GitHub project (the "new" projects,
+// known as Projects V2) owned by an organization, as in github_project_items('org', 1). Items link to the issue or pull
+// request they track (if any), along with the values of the Status and iteration fields, and of all fields as JSON.
+func NewProjectItemsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_project_items", projectItemCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var org string
+		var number int
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					org = constraint.Value.Text()
+				case 1:
+					number = constraint.Value.Int()
+				}
+			}
+		}
+
+		if org == "" || number == 0 {
+			return nil, errors.New("need to supply an organization and project number")
+		}
+
+		iter := &iterProjectItems{opts, org, number, -1, nil}
+		iter.logger().Info().Msgf("starting GitHub project_items iterator for %s project %d", org, number)
+		return iter, nil
+	}, vtab.EarlyOrderByConstraintExit(true))
+}
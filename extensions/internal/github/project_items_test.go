@@ -0,0 +1,36 @@
+package github_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestProjectItems(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT type, content_number, status, iteration, json_extract(field_values, '$.Estimate') FROM github_project_items('mergestat', 3)")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	if expected := 3; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	if number, status, iteration, estimate := content[0][1], content[0][2], content[0][3], content[0][4]; number != "1032" || status != "Done" || iteration != "Sprint 14" || estimate != "3" {
+		t.Fatalf("unexpected first item: #%s, status: %s, iteration: %s, estimate: %s", number, status, iteration, estimate)
+	}
+
+	if typ, number := content[2][0], content[2][1]; typ != "DRAFT_ISSUE" || number != "NULL" {
+		t.Fatalf("expected the last item to be a draft issue, got: %s (#%s)", typ, number)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/internal/sqlutil"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/rs/zerolog"
 	"github.com/shurcooL/githubv4"
@@ -104,17 +105,17 @@ func (i *iterProtections) Column(ctx vtab.Context, c int) error {
 
 	switch col.Name {
 	case "allow_deletions":
-		ctx.ResultInt(t1f0(current.AllowsDeletions))
+		ctx.ResultInt(sqlutil.BoolToInt(current.AllowsDeletions))
 	case "allows_force_pushes":
-		ctx.ResultInt(t1f0(current.AllowsForcePushes))
+		ctx.ResultInt(sqlutil.BoolToInt(current.AllowsForcePushes))
 	case "creator_login":
 		ctx.ResultText(string(current.Creator.Login))
 	case "database_id":
 		ctx.ResultInt(current.DatabaseId)
 	case "dismisses_stale_reviews":
-		ctx.ResultInt(t1f0(current.DismissesStaleReviews))
+		ctx.ResultInt(sqlutil.BoolToInt(current.DismissesStaleReviews))
 	case "is_admin_enforced":
-		ctx.ResultInt(t1f0(current.IsAdminEnforced))
+		ctx.ResultInt(sqlutil.BoolToInt(current.IsAdminEnforced))
 	case "pattern":
 		ctx.ResultText(current.Pattern)
 	case "required_approving_review_count":
@@ -122,23 +123,23 @@ func (i *iterProtections) Column(ctx vtab.Context, c int) error {
 	case "required_status_check_contexts":
 		ctx.ResultText(strings.Join(current.RequiredStatusCheckContexts, ", "))
 	case "requires_approving_reviews":
-		ctx.ResultInt(t1f0(current.RequiresApprovingReviews))
+		ctx.ResultInt(sqlutil.BoolToInt(current.RequiresApprovingReviews))
 	case "requires_code_owners_reviews":
-		ctx.ResultInt(t1f0(current.RequiresCodeOwnerReviews))
+		ctx.ResultInt(sqlutil.BoolToInt(current.RequiresCodeOwnerReviews))
 	case "requires_commit_signature":
-		ctx.ResultInt(t1f0(current.RequiresCommitSignatures))
+		ctx.ResultInt(sqlutil.BoolToInt(current.RequiresCommitSignatures))
 	case "requires_conversation_resolution":
-		ctx.ResultInt(t1f0(current.RequiresConversationResolution))
+		ctx.ResultInt(sqlutil.BoolToInt(current.RequiresConversationResolution))
 	case "requires_linear_history":
-		ctx.ResultInt(t1f0(current.RequiresLinearHistory))
+		ctx.ResultInt(sqlutil.BoolToInt(current.RequiresLinearHistory))
 	case "requires_status_checks":
-		ctx.ResultInt(t1f0(current.RequiresStatusChecks))
+		ctx.ResultInt(sqlutil.BoolToInt(current.RequiresStatusChecks))
 	case "requires_strict_status_checks":
-		ctx.ResultInt(t1f0(current.RequiresStrictStatusChecks))
+		ctx.ResultInt(sqlutil.BoolToInt(current.RequiresStrictStatusChecks))
 	case "restricts_pushes":
-		ctx.ResultInt(t1f0(current.RestrictsPushes))
+		ctx.ResultInt(sqlutil.BoolToInt(current.RestrictsPushes))
 	case "restricts_review_dismissal":
-		ctx.ResultInt(t1f0(current.RestrictsReviewDismissals))
+		ctx.ResultInt(sqlutil.BoolToInt(current.RestrictsReviewDismissals))
 	}
 	return nil
 }
@@ -148,7 +149,7 @@ func (i *iterProtections) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.Edges) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.QueryContext.Context())
 			if err != nil {
 				return nil, err
 			}
@@ -162,7 +163,7 @@ func (i *iterProtections) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of repo_protections for %s/%s", i.owner, i.name)
-			results, err := i.fetchProtections(context.Background(), cursor)
+			results, err := i.fetchProtections(i.QueryContext.Context(), cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -207,7 +207,7 @@ func (i *iterRepositoryCommits) Next() (vtab.Row, error) {
 	}
 	if i.results == nil || i.currentCommit >= len(current) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.QueryContext.Context())
 			if err != nil {
 				return nil, err
 			}
@@ -221,7 +221,7 @@ func (i *iterRepositoryCommits) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of repository_commits for %s/%s", i.owner, i.name)
-			results, err := i.fetchRepositoryCommits(context.Background(), cursor)
+			results, err := i.fetchRepositoryCommits(i.QueryContext.Context(), cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -0,0 +1,254 @@
+package github
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/rs/zerolog"
+	"github.com/shurcooL/githubv4"
+	"go.riyazali.net/sqlite"
+)
+
+type deployment struct {
+	DatabaseId  int
+	Environment string
+	State       githubv4.DeploymentState
+	Task        string
+	Description string
+	CommitOid   string
+	Ref         struct {
+		Name string
+	}
+	Creator struct {
+		Login string
+	}
+	CreatedAt    githubv4.DateTime
+	UpdatedAt    githubv4.DateTime
+	LatestStatus struct {
+		State     githubv4.DeploymentStatusState
+		CreatedAt githubv4.DateTime
+	}
+	Statuses struct {
+		Nodes []struct {
+			State     githubv4.DeploymentStatusState
+			CreatedAt githubv4.DateTime
+		}
+	} `graphql:"statuses(first: 100)"`
+}
+
+// succeededAt returns when the deployment first succeeded (its earliest SUCCESS status), or a zero time if it never did.
+// Unlike its state, which turns INACTIVE once it's superseded, this tells successful deployments apart from the ones
+// superseded (or rolled back) before they succeeded.
+func (d *deployment) succeededAt() time.Time {
+	var at time.Time
+	for _, status := range d.Statuses.Nodes {
+		if status.State == githubv4.DeploymentStatusStateSuccess && (at.IsZero() || status.CreatedAt.Before(at)) {
+			at = status.CreatedAt.Time
+		}
+	}
+	return at
+}
+
+type fetchDeploymentsResults struct {
+	RateLimit   *options.GitHubRateLimitResponse
+	Edges       []*deployment
+	HasNextPage bool
+	EndCursor   *githubv4.String
+}
+
+func (i *iterDeployments) fetchDeployments(ctx context.Context, startCursor *githubv4.String) (*fetchDeploymentsResults, error) {
+	var DeploymentsQuery struct {
+		RateLimit  *options.GitHubRateLimitResponse
+		Repository struct {
+			Owner struct {
+				Login string
+			}
+			Name        string
+			Deployments struct {
+				Nodes    []*deployment
+				PageInfo struct {
+					EndCursor   githubv4.String
+					HasNextPage bool
+				}
+			} `graphql:"deployments(first: $perpage, after: $deploymentcursor, orderBy: {field: CREATED_AT, direction: ASC})"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+	variables := map[string]interface{}{
+		"owner":            githubv4.String(i.owner),
+		"name":             githubv4.String(i.name),
+		"perpage":          githubv4.Int(i.PerPage),
+		"deploymentcursor": startCursor,
+	}
+
+	err := i.Client().Query(ctx, &DeploymentsQuery, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fetchDeploymentsResults{
+		RateLimit:   DeploymentsQuery.RateLimit,
+		Edges:       DeploymentsQuery.Repository.Deployments.Nodes,
+		HasNextPage: DeploymentsQuery.Repository.Deployments.PageInfo.HasNextPage,
+		EndCursor:   &DeploymentsQuery.Repository.Deployments.PageInfo.EndCursor,
+	}, nil
+}
+
+type iterDeployments struct {
+	*Options
+	owner   string
+	name    string
+	current int
+	results *fetchDeploymentsResults
+}
+
+func (i *iterDeployments) logger() *zerolog.Logger {
+	logger := i.Logger.With().Int("per-page", i.PerPage).Str("owner", i.owner).Str("name", i.name).Logger()
+	return &logger
+}
+
+func (i *iterDeployments) Column(ctx vtab.Context, c int) error {
+	current := i.results.Edges[i.current]
+	col := deploymentCols[c]
+
+	switch col.Name {
+	case "id":
+		ctx.ResultInt(current.DatabaseId)
+	case "environment":
+		ctx.ResultText(current.Environment)
+	case "state":
+		ctx.ResultText(string(current.State))
+	case "task":
+		ctx.ResultText(current.Task)
+	case "description":
+		ctx.ResultText(current.Description)
+	case "commit_hash":
+		ctx.ResultText(current.CommitOid)
+	case "ref":
+		ctx.ResultText(current.Ref.Name)
+	case "creator_login":
+		ctx.ResultText(current.Creator.Login)
+	case "created_at":
+		t := current.CreatedAt
+		if t.IsZero() {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(t.Format(time.RFC3339Nano))
+		}
+	case "updated_at":
+		t := current.UpdatedAt
+		if t.IsZero() {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(t.Format(time.RFC3339Nano))
+		}
+	case "latest_status":
+		if current.LatestStatus.State == "" {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(string(current.LatestStatus.State))
+		}
+	case "latest_status_at":
+		t := current.LatestStatus.CreatedAt
+		if t.IsZero() {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(t.Format(time.RFC3339Nano))
+		}
+	case "succeeded_at":
+		if t := current.succeededAt(); !t.IsZero() {
+			ctx.ResultText(t.Format(time.RFC3339Nano))
+		}
+	}
+	return nil
+}
+
+func (i *iterDeployments) Next() (vtab.Row, error) {
+	i.current += 1
+
+	if i.results == nil || i.current >= len(i.results.Edges) {
+		if i.results == nil || i.results.HasNextPage {
+			err := i.RateLimiter.Wait(i.QueryContext.Context())
+			if err != nil {
+				return nil, err
+			}
+
+			var cursor *githubv4.String
+			if i.results != nil {
+				cursor = i.results.EndCursor
+			}
+
+			i.Options.GitHubPreRequestHook()
+
+			l := i.logger().With().Interface("cursor", cursor).Logger()
+			l.Info().Msgf("fetching page of repo_deployments for %s/%s", i.owner, i.name)
+			results, err := i.fetchDeployments(i.QueryContext.Context(), cursor)
+
+			i.Options.GitHubPostRequestHook()
+
+			if err != nil {
+				return nil, err
+			}
+
+			i.Options.RateLimitHandler(results.RateLimit)
+
+			i.results = results
+			i.current = 0
+
+			if len(results.Edges) == 0 {
+				return nil, io.EOF
+			}
+		} else {
+			return nil, io.EOF
+		}
+	}
+
+	return i, nil
+}
+
+var deploymentCols = []vtab.Column{
+	{Name: "owner", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: "INT"},
+	{Name: "environment", Type: "TEXT"},
+	{Name: "state", Type: "TEXT"},
+	{Name: "task", Type: "TEXT"},
+	{Name: "description", Type: "TEXT"},
+	{Name: "commit_hash", Type: "TEXT"},
+	{Name: "ref", Type: "TEXT"},
+	{Name: "creator_login", Type: "TEXT"},
+	{Name: "created_at", Type: "DATETIME"},
+	{Name: "updated_at", Type: "DATETIME"},
+	{Name: "latest_status", Type: "TEXT"},
+	{Name: "latest_status_at", Type: "DATETIME"},
+	{Name: "succeeded_at", Type: "DATETIME"},
+}
+
+// NewDeploymentsModule returns the implementation of a table listing the deployments of a GitHub repository
+// (oldest first), along with the state of their latest status (such as SUCCESS or FAILURE) and when they succeeded, if they did
+func NewDeploymentsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_repo_deployments", deploymentCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				}
+			}
+		}
+
+		owner, name, err := repoOwnerAndName(name, fullNameOrOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		iter := &iterDeployments{opts, owner, name, -1, nil}
+		iter.logger().Info().Msgf("starting GitHub repo_deployments iterator for %s/%s", owner, name)
+		return iter, nil
+	}, vtab.EarlyOrderByConstraintExit(true))
+}
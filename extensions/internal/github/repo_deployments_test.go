@@ -0,0 +1,43 @@
+package github_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestDeployments(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT * FROM github_repo_deployments('mergestat/mergestat')")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	colCount, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	if expected := 13; colCount != expected {
+		t.Fatalf("expected %d columns, got: %d", expected, colCount)
+	}
+
+	if expected := 3; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	if state := content[1][2]; state != "FAILURE" {
+		t.Fatalf("expected the second deployment to have failed, got state: %s", state)
+	}
+
+	// the first deployment was superseded (INACTIVE) after it succeeded, while the second one never succeeded
+	for d, expected := range []string{"2024-04-29T14:06:52Z", "NULL", "2024-04-30T09:12:40Z"} {
+		if succeededAt := content[d][11]; succeededAt != expected {
+			t.Fatalf("expected deployment %d to have succeeded at %s, got: %s", d, expected, succeededAt)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package github
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -18,7 +17,7 @@ type repoFileContent struct {
 func (f *repoFileContent) Args() int           { return -1 }
 func (f *repoFileContent) Deterministic() bool { return false }
 func (f *repoFileContent) Apply(ctx *sqlite.Context, values ...sqlite.Value) {
-	err := f.opts.RateLimiter.Wait(context.Background())
+	err := f.opts.RateLimiter.Wait(f.opts.QueryContext.Context())
 	if err != nil {
 		ctx.ResultError(err)
 		return
@@ -71,7 +70,7 @@ func (f *repoFileContent) Apply(ctx *sqlite.Context, values ...sqlite.Value) {
 		"expression": githubv4.String(expression),
 	}
 
-	err = f.opts.RateLimiter.Wait(context.Background())
+	err = f.opts.RateLimiter.Wait(f.opts.QueryContext.Context())
 	if err != nil {
 		ctx.ResultError(err)
 		return
@@ -79,7 +78,7 @@ func (f *repoFileContent) Apply(ctx *sqlite.Context, values ...sqlite.Value) {
 
 	f.opts.GitHubPreRequestHook()
 
-	err = f.opts.Client().Query(context.Background(), &fileContentsQuery, variables)
+	err = f.opts.Client().Query(f.opts.QueryContext.Context(), &fileContentsQuery, variables)
 
 	f.opts.GitHubPostRequestHook()
 
@@ -5,8 +5,7 @@ import (
 )
 
 func TestRepoFileContent(t *testing.T) {
-	cleanup := newRecorder(t)
-	defer cleanup()
+	defer transport.Start(t)()
 
 	db := Connect(t, Memory)
 
@@ -27,8 +26,7 @@ func TestRepoFileContent(t *testing.T) {
 }
 
 func TestRepoFileContentMultiArg(t *testing.T) {
-	cleanup := newRecorder(t)
-	defer cleanup()
+	defer transport.Start(t)()
 
 	db := Connect(t, Memory)
 
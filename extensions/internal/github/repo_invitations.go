@@ -0,0 +1,124 @@
+package github
+
+import (
+	"io"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/internal/sqlutil"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+type restInvitation struct {
+	ID      int `json:"id"`
+	Invitee *struct {
+		Login string `json:"login"`
+	} `json:"invitee"`
+	Inviter *struct {
+		Login string `json:"login"`
+	} `json:"inviter"`
+	Permissions string    `json:"permissions"`
+	Expired     bool      `json:"expired"`
+	HTMLURL     string    `json:"html_url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type iterInvitations struct {
+	*Options
+	owner string
+	name  string
+
+	pager       *restPager
+	invitations []*restInvitation
+	current     int
+}
+
+func (i *iterInvitations) logger() *zerolog.Logger {
+	logger := i.Logger.With().Int("per-page", i.PerPage).Str("owner", i.owner).Str("name", i.name).Logger()
+	return &logger
+}
+
+func (i *iterInvitations) Column(ctx vtab.Context, c int) error {
+	current := i.invitations[i.current]
+	switch invitationCols[c].Name {
+	case "id":
+		ctx.ResultInt(current.ID)
+	case "invitee_login":
+		if current.Invitee != nil {
+			ctx.ResultText(current.Invitee.Login)
+		}
+	case "inviter_login":
+		if current.Inviter != nil {
+			ctx.ResultText(current.Inviter.Login)
+		}
+	case "permission":
+		ctx.ResultText(current.Permissions)
+	case "expired":
+		ctx.ResultInt(sqlutil.BoolToInt(current.Expired))
+	case "html_url":
+		ctx.ResultText(current.HTMLURL)
+	case "created_at":
+		ctx.ResultText(current.CreatedAt.Format(time.RFC3339Nano))
+	}
+	return nil
+}
+
+func (i *iterInvitations) Next() (vtab.Row, error) {
+	i.current += 1
+
+	for i.current >= len(i.invitations) {
+		if i.pager.done() {
+			return nil, io.EOF
+		}
+
+		i.logger().Info().Msgf("fetching page of invitations for %s/%s", i.owner, i.name)
+		var page []*restInvitation
+		if err := i.pager.fetch(&page); err != nil {
+			return nil, err
+		}
+		i.invitations, i.current = page, 0
+	}
+
+	return i, nil
+}
+
+var invitationCols = []vtab.Column{
+	{Name: "owner", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: "INT"},
+	{Name: "invitee_login", Type: "TEXT"},
+	{Name: "inviter_login", Type: "TEXT"},
+	{Name: "permission", Type: "TEXT"},
+	{Name: "expired", Type: "BOOLEAN"},
+	{Name: "html_url", Type: "TEXT"},
+	{Name: "created_at", Type: "DATETIME"},
+}
+
+// NewRepoInvitationsModule returns the implementation of a table listing the pending invitations to collaborate
+// on a GitHub repository, along with the permission they grant (invitee_login is NULL for invitations by email)
+func NewRepoInvitationsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_repo_invitations", invitationCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				}
+			}
+		}
+
+		owner, name, err := repoOwnerAndName(name, fullNameOrOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		iter := &iterInvitations{Options: opts, owner: owner, name: name, current: -1}
+		iter.pager = newRestPager(opts, restPath("repos", owner, name, "invitations"), nil)
+		iter.logger().Info().Msgf("starting GitHub repo invitations iterator for %s/%s", owner, name)
+		return iter, nil
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/internal/sqlutil"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/rs/zerolog"
 	"github.com/shurcooL/githubv4"
@@ -47,14 +48,12 @@ type issue struct {
 	Participants struct {
 		TotalCount int
 	}
-	PublishedAt githubv4.DateTime
-	Reactions   struct {
-		TotalCount int
-	}
-	State     githubv4.IssueState
-	Title     string
-	UpdatedAt githubv4.DateTime
-	Url       githubv4.URI
+	PublishedAt    githubv4.DateTime
+	ReactionGroups reactionGroups
+	State          githubv4.IssueState
+	Title          string
+	UpdatedAt      githubv4.DateTime
+	Url            githubv4.URI
 }
 
 type fetchIssuesResults struct {
@@ -134,7 +133,7 @@ func (i *iterIssues) Column(ctx vtab.Context, c int) error {
 	case "body":
 		ctx.ResultText(current.Node.Body)
 	case "closed":
-		ctx.ResultInt(t1f0(current.Node.Closed))
+		ctx.ResultInt(sqlutil.BoolToInt(current.Node.Closed))
 	case "closed_at":
 		t := current.Node.ClosedAt
 		if t.IsZero() {
@@ -152,13 +151,13 @@ func (i *iterIssues) Column(ctx vtab.Context, c int) error {
 			ctx.ResultText(t.Format(time.RFC3339Nano))
 		}
 	case "created_via_email":
-		ctx.ResultInt(t1f0(current.Node.CreatedViaEmail))
+		ctx.ResultInt(sqlutil.BoolToInt(current.Node.CreatedViaEmail))
 	case "database_id":
 		ctx.ResultInt(current.Node.DatabaseId)
 	case "editor_login":
 		ctx.ResultText(current.Node.Editor.Login)
 	case "includes_created_edit":
-		ctx.ResultInt(t1f0(current.Node.IncludesCreatedEdit))
+		ctx.ResultInt(sqlutil.BoolToInt(current.Node.IncludesCreatedEdit))
 	case "label_count":
 		ctx.ResultInt(current.Node.Labels.TotalCount)
 	case "labels":
@@ -181,7 +180,7 @@ func (i *iterIssues) Column(ctx vtab.Context, c int) error {
 			ctx.ResultText(t.Format(time.RFC3339Nano))
 		}
 	case "locked":
-		ctx.ResultInt(t1f0(current.Node.Locked))
+		ctx.ResultInt(sqlutil.BoolToInt(current.Node.Locked))
 	case "milestone_number":
 		ctx.ResultInt(current.Node.Milestone.Number)
 	case "number":
@@ -196,7 +195,15 @@ func (i *iterIssues) Column(ctx vtab.Context, c int) error {
 			ctx.ResultText(t.Format(time.RFC3339Nano))
 		}
 	case "reaction_count":
-		ctx.ResultInt(current.Node.Reactions.TotalCount)
+		ctx.ResultInt(current.Node.ReactionGroups.total())
+	case "reactions":
+		js, err := current.Node.ReactionGroups.json()
+		if err != nil {
+			i.logger().Err(err).Msgf("could not marshal issue reactions")
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(js)
+		}
 	case "state":
 		ctx.ResultText(fmt.Sprint(current.Node.State))
 	case "title":
@@ -219,7 +226,7 @@ func (i *iterIssues) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.Edges) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.QueryContext.Context())
 			if err != nil {
 				return nil, err
 			}
@@ -233,7 +240,7 @@ func (i *iterIssues) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of repo_issues for %s/%s", i.owner, i.name)
-			results, err := i.fetchIssues(context.Background(), cursor)
+			results, err := i.fetchIssues(i.QueryContext.Context(), cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -279,6 +286,7 @@ var issuesCols = []vtab.Column{
 	{Name: "participant_count", Type: "INT"},
 	{Name: "published_at", Type: "DATETIME"},
 	{Name: "reaction_count", Type: "INT"},
+	{Name: "reactions", Type: "JSON"},
 	{Name: "state", Type: "TEXT"},
 	{Name: "title", Type: "TEXT"},
 	{Name: "updated_at", Type: "DATETIME", OrderBy: vtab.ASC | vtab.DESC},
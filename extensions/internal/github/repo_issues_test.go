@@ -7,8 +7,7 @@ import (
 )
 
 func TestRepoIssues(t *testing.T) {
-	cleanup := newRecorder(t)
-	defer cleanup()
+	defer transport.Start(t)()
 
 	db := Connect(t, Memory)
 
@@ -18,16 +17,50 @@ func TestRepoIssues(t *testing.T) {
 	}
 	defer rows.Close()
 
+	cols, err := rows.Columns()
+	if err != nil {
+		t.Fatalf("failed to retrieve columns: %v", err.Error())
+	}
+
 	colCount, content, err := tools.RowContent(rows)
 	if err != nil {
 		t.Fatalf("failed to retrieve row contents: %v", err.Error())
 	}
 
-	if colCount != 23 {
-		t.Fatalf("expected 23 columns, got: %d", colCount)
+	if colCount != 24 {
+		t.Fatalf("expected 24 columns, got: %d", colCount)
 	}
 
 	if len(content) != 10 {
 		t.Fatalf("expected 10 rows, got: %d", len(content))
 	}
+
+	// issues without reactions still list every emoji, with no reactors
+	var none = `{"confused":0,"eyes":0,"heart":0,"hooray":0,"laugh":0,"rocket":0,"thumbs_down":0,"thumbs_up":0}`
+	var expected = map[string][2]string{
+		"3":  {"1", `{"confused":0,"eyes":0,"heart":0,"hooray":0,"laugh":0,"rocket":0,"thumbs_down":0,"thumbs_up":1}`},
+		"9":  {"0", none},
+		"10": {"2", `{"confused":0,"eyes":0,"heart":1,"hooray":0,"laugh":0,"rocket":0,"thumbs_down":0,"thumbs_up":1}`},
+		"11": {"3", `{"confused":0,"eyes":0,"heart":0,"hooray":1,"laugh":0,"rocket":0,"thumbs_down":0,"thumbs_up":2}`},
+	}
+
+	number, count, reactions := columnIndex(t, cols, "number"), columnIndex(t, cols, "reaction_count"), columnIndex(t, cols, "reactions")
+	for _, row := range content {
+		want, ok := expected[row[number]]
+		if !ok {
+			continue
+		}
+		delete(expected, row[number])
+
+		if row[count] != want[0] {
+			t.Fatalf("expected issue %s to have %s reactions, got: %s", row[number], want[0], row[count])
+		}
+		if row[reactions] != want[1] {
+			t.Fatalf("expected issue %s to have reactions %s, got: %s", row[number], want[1], row[reactions])
+		}
+	}
+
+	if len(expected) != 0 {
+		t.Fatalf("expected issues %v to be returned", expected)
+	}
 }
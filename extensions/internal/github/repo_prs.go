@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/internal/sqlutil"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/rs/zerolog"
 	"github.com/shurcooL/githubv4"
@@ -73,6 +74,7 @@ type pullRequest struct {
 		TotalCount int
 	}
 	PublishedAt    githubv4.DateTime
+	ReactionGroups reactionGroups
 	ReviewDecision githubv4.PullRequestReviewDecision
 	State          githubv4.PullRequestState
 	Title          string
@@ -172,7 +174,7 @@ func (i *iterPRs) Column(ctx vtab.Context, c int) error {
 	case "changed_files":
 		ctx.ResultInt(current.ChangedFiles)
 	case "closed":
-		ctx.ResultInt(t1f0(current.Closed))
+		ctx.ResultInt(sqlutil.BoolToInt(current.Closed))
 	case "closed_at":
 		t := current.ClosedAt
 		if t.IsZero() {
@@ -192,7 +194,7 @@ func (i *iterPRs) Column(ctx vtab.Context, c int) error {
 			ctx.ResultText(t.Format(time.RFC3339Nano))
 		}
 	case "created_via_email":
-		ctx.ResultInt(t1f0(current.CreatedViaEmail))
+		ctx.ResultInt(sqlutil.BoolToInt(current.CreatedViaEmail))
 	case "database_id":
 		ctx.ResultInt(current.DatabaseID)
 	case "deletions":
@@ -206,7 +208,7 @@ func (i *iterPRs) Column(ctx vtab.Context, c int) error {
 	case "head_repository_name":
 		ctx.ResultText(string(current.HeadRepository.NameWithOwner))
 	case "is_draft":
-		ctx.ResultInt(t1f0(current.IsDraft))
+		ctx.ResultInt(sqlutil.BoolToInt(current.IsDraft))
 	case "label_count":
 		ctx.ResultInt(current.Labels.TotalCount)
 	case "labels":
@@ -229,13 +231,13 @@ func (i *iterPRs) Column(ctx vtab.Context, c int) error {
 			ctx.ResultText(t.Format(time.RFC3339Nano))
 		}
 	case "locked":
-		ctx.ResultInt(t1f0(current.Locked))
+		ctx.ResultInt(sqlutil.BoolToInt(current.Locked))
 	case "maintainer_can_modify":
-		ctx.ResultInt(t1f0(current.MaintainerCanModify))
+		ctx.ResultInt(sqlutil.BoolToInt(current.MaintainerCanModify))
 	case "mergeable":
 		ctx.ResultText(string(current.Mergeable))
 	case "merged":
-		ctx.ResultInt(t1f0(current.Merged))
+		ctx.ResultInt(sqlutil.BoolToInt(current.Merged))
 	case "merged_at":
 		t := current.MergedAt
 		if t.IsZero() {
@@ -256,6 +258,16 @@ func (i *iterPRs) Column(ctx vtab.Context, c int) error {
 		} else {
 			ctx.ResultText(t.Format(time.RFC3339Nano))
 		}
+	case "reaction_count":
+		ctx.ResultInt(current.ReactionGroups.total())
+	case "reactions":
+		js, err := current.ReactionGroups.json()
+		if err != nil {
+			i.logger().Err(err).Msgf("could not marshal PR reactions")
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(js)
+		}
 	case "review_decision":
 		ctx.ResultText(string(current.ReviewDecision))
 	case "state":
@@ -280,7 +292,7 @@ func (i *iterPRs) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.Edges) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.QueryContext.Context())
 			if err != nil {
 				return nil, err
 			}
@@ -294,7 +306,7 @@ func (i *iterPRs) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of repo_pull_requests for %s/%s", i.owner, i.name)
-			results, err := i.fetchPRs(context.Background(), cursor)
+			results, err := i.fetchPRs(i.QueryContext.Context(), cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -362,6 +374,8 @@ var prCols = []vtab.Column{
 	{Name: "number", Type: "INT"},
 	{Name: "participant_count", Type: "INT"},
 	{Name: "published_at", Type: "DATETIME"},
+	{Name: "reaction_count", Type: "INT"},
+	{Name: "reactions", Type: "JSON"},
 	{Name: "review_decision", Type: "TEXT"},
 	{Name: "state", Type: "TEXT"},
 	{Name: "title", Type: "TEXT"},
@@ -7,8 +7,7 @@ import (
 )
 
 func TestRepoPRs(t *testing.T) {
-	cleanup := newRecorder(t)
-	defer cleanup()
+	defer transport.Start(t)()
 
 	db := Connect(t, Memory)
 
@@ -18,16 +17,47 @@ func TestRepoPRs(t *testing.T) {
 	}
 	defer rows.Close()
 
+	cols, err := rows.Columns()
+	if err != nil {
+		t.Fatalf("failed to retrieve columns: %v", err.Error())
+	}
+
 	colCount, content, err := tools.RowContent(rows)
 	if err != nil {
 		t.Fatalf("failed to retrieve row contents: %v", err.Error())
 	}
 
-	if colCount != 40 {
-		t.Fatalf("expected 40 columns, got: %d", colCount)
+	if colCount != 42 {
+		t.Fatalf("expected 42 columns, got: %d", colCount)
 	}
 
 	if len(content) != 10 {
 		t.Fatalf("expected 10 rows, got: %d", len(content))
 	}
+
+	var expected = map[string][2]string{
+		"1": {"0", `{"confused":0,"eyes":0,"heart":0,"hooray":0,"laugh":0,"rocket":0,"thumbs_down":0,"thumbs_up":0}`},
+		"2": {"1", `{"confused":0,"eyes":0,"heart":0,"hooray":0,"laugh":0,"rocket":0,"thumbs_down":0,"thumbs_up":1}`},
+		"5": {"3", `{"confused":0,"eyes":0,"heart":2,"hooray":1,"laugh":0,"rocket":0,"thumbs_down":0,"thumbs_up":0}`},
+	}
+
+	number, count, reactions := columnIndex(t, cols, "number"), columnIndex(t, cols, "reaction_count"), columnIndex(t, cols, "reactions")
+	for _, row := range content {
+		want, ok := expected[row[number]]
+		if !ok {
+			continue
+		}
+		delete(expected, row[number])
+
+		if row[count] != want[0] {
+			t.Fatalf("expected PR %s to have %s reactions, got: %s", row[number], want[0], row[count])
+		}
+		if row[reactions] != want[1] {
+			t.Fatalf("expected PR %s to have reactions %s, got: %s", row[number], want[1], row[reactions])
+		}
+	}
+
+	if len(expected) != 0 {
+		t.Fatalf("expected PRs %v to be returned", expected)
+	}
 }
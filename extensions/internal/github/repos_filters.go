@@ -0,0 +1,158 @@
+package github
+
+import (
+	"strings"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/shurcooL/githubv4"
+	"go.riyazali.net/sqlite"
+)
+
+// repoFilters are the constraints on the columns of the repos tables (github_org_repos and github_user_repos)
+// that are pushed down to the GitHub API, or used to stop listing repositories early, as owners can have thousands of them.
+// They're never omitted, SQLite still checks them.
+type repoFilters struct {
+	privacy    *githubv4.RepositoryPrivacy
+	isArchived *githubv4.Boolean
+	isFork     *githubv4.Boolean
+
+	// namePrefix (lower cased) is set by constraints such as name LIKE 'prefix%'.
+	// When listing by name, repositories matching the prefix are listed one after the other.
+	namePrefix     string
+	seenNamePrefix bool
+
+	// since is set by constraints such as pushed_at > '2022-01-01', on sinceField.
+	// When listing by sinceField (most recent first), all repositories listed after one that's too old are too.
+	since      time.Time
+	sinceField githubv4.RepositoryOrderField
+}
+
+// repoFiltersCols are the filters of the columns of the repos tables that can be pushed down
+var repoFiltersCols = map[string][]*vtab.ColumnFilter{
+	"is_archived": {{Op: sqlite.INDEX_CONSTRAINT_EQ}},
+	"is_fork":     {{Op: sqlite.INDEX_CONSTRAINT_EQ}},
+	"is_private":  {{Op: sqlite.INDEX_CONSTRAINT_EQ}},
+	"visibility":  {{Op: sqlite.INDEX_CONSTRAINT_EQ}},
+	"name":        {{Op: sqlite.INDEX_CONSTRAINT_LIKE}, {Op: sqlite.INDEX_CONSTRAINT_GLOB}},
+	"created_at":  {{Op: sqlite.INDEX_CONSTRAINT_GT}, {Op: sqlite.INDEX_CONSTRAINT_GE}},
+	"updated_at":  {{Op: sqlite.INDEX_CONSTRAINT_GT}, {Op: sqlite.INDEX_CONSTRAINT_GE}},
+	"pushed_at":   {{Op: sqlite.INDEX_CONSTRAINT_GT}, {Op: sqlite.INDEX_CONSTRAINT_GE}},
+}
+
+// withRepoFilters sets the filters that can be pushed down on the repos table columns cols
+func withRepoFilters(cols []vtab.Column) []vtab.Column {
+	for c, col := range cols {
+		if filters, ok := repoFiltersCols[col.Name]; ok {
+			cols[c].Filters = filters
+		}
+	}
+	return cols
+}
+
+var repoSinceFields = map[string]githubv4.RepositoryOrderField{
+	"created_at": githubv4.RepositoryOrderFieldCreatedAt,
+	"updated_at": githubv4.RepositoryOrderFieldUpdatedAt,
+	"pushed_at":  githubv4.RepositoryOrderFieldPushedAt,
+}
+
+// newRepoFilters returns the filters set by the constraints on the repos table columns cols
+func newRepoFilters(cols []vtab.Column, constraints []*vtab.Constraint) *repoFilters {
+	var filters = &repoFilters{}
+	for _, constraint := range constraints {
+		name := cols[constraint.ColIndex].Name
+		switch constraint.Op {
+		case sqlite.INDEX_CONSTRAINT_EQ:
+			switch name {
+			case "is_archived":
+				b := githubv4.Boolean(constraint.Value.Int() != 0)
+				filters.isArchived = &b
+			case "is_fork":
+				b := githubv4.Boolean(constraint.Value.Int() != 0)
+				filters.isFork = &b
+			case "is_private":
+				// internal repositories are private too, but aren't listed with a PRIVATE privacy,
+				// so only listing public repositories is pushed down (SQLite checks the rest)
+				if constraint.Value.Int() == 0 {
+					privacy := githubv4.RepositoryPrivacyPublic
+					filters.privacy = &privacy
+				}
+			case "visibility":
+				// internal repositories are neither public nor private, as far as privacy goes
+				switch privacy := githubv4.RepositoryPrivacy(strings.ToUpper(constraint.Value.Text())); privacy {
+				case githubv4.RepositoryPrivacyPublic, githubv4.RepositoryPrivacyPrivate:
+					filters.privacy = &privacy
+				}
+			}
+		case sqlite.INDEX_CONSTRAINT_LIKE, sqlite.INDEX_CONSTRAINT_GLOB:
+			wildcards, wildcard := "%_", "%"
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_GLOB {
+				wildcards, wildcard = "*?[", "*"
+			}
+			pattern := constraint.Value.Text()
+			if prefix := strings.TrimSuffix(pattern, wildcard); prefix != pattern && !strings.ContainsAny(prefix, wildcards) {
+				filters.namePrefix = strings.ToLower(prefix)
+			}
+		case sqlite.INDEX_CONSTRAINT_GT, sqlite.INDEX_CONSTRAINT_GE:
+			if field, ok := repoSinceFields[name]; ok && filters.since.IsZero() {
+				if since, ok := parseSince(constraint.Value.Text()); ok {
+					filters.since, filters.sinceField = since, field
+				}
+			}
+		}
+	}
+	return filters
+}
+
+// parseSince parses the value of a constraint on a datetime column, in the formats SQLite datetimes are usually in
+func parseSince(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// order returns the order to list repositories in, to be able to stop early. An order set by the query is kept as is.
+func (f *repoFilters) order(order *githubv4.RepositoryOrder) *githubv4.RepositoryOrder {
+	switch {
+	case order != nil:
+		return order
+	case f.namePrefix != "":
+		return &githubv4.RepositoryOrder{Field: githubv4.RepositoryOrderFieldName, Direction: githubv4.OrderDirectionAsc}
+	case !f.since.IsZero():
+		return &githubv4.RepositoryOrder{Field: f.sinceField, Direction: githubv4.OrderDirectionDesc}
+	}
+	return nil
+}
+
+// past reports whether a repository listed in order (and so all of those listed after it)
+// is past the wanted ones, in which case listing can stop
+func (f *repoFilters) past(order *githubv4.RepositoryOrder, name string, createdAt, updatedAt, pushedAt time.Time) bool {
+	if order == nil {
+		return false
+	}
+
+	if f.namePrefix != "" && order.Field == githubv4.RepositoryOrderFieldName && order.Direction == githubv4.OrderDirectionAsc {
+		// GitHub orders names case-insensitively, in which case the ones matching the prefix (case-insensitively too) are contiguous
+		matches := strings.HasPrefix(strings.ToLower(name), f.namePrefix)
+		if f.seenNamePrefix && !matches {
+			return true
+		}
+		f.seenNamePrefix = f.seenNamePrefix || matches
+	}
+
+	if !f.since.IsZero() && order.Field == f.sinceField && order.Direction == githubv4.OrderDirectionDesc {
+		var t = map[githubv4.RepositoryOrderField]time.Time{
+			githubv4.RepositoryOrderFieldCreatedAt: createdAt,
+			githubv4.RepositoryOrderFieldUpdatedAt: updatedAt,
+			githubv4.RepositoryOrderFieldPushedAt:  pushedAt,
+		}[f.sinceField]
+		if !t.IsZero() && t.Before(f.since) {
+			return true
+		}
+	}
+
+	return false
+}
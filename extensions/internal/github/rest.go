@@ -0,0 +1,132 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/pkg/errors"
+	"github.com/shurcooL/githubv4"
+)
+
+// restBaseURL is the root of the GitHub REST API, used for the resources the GraphQL API doesn't expose
+// (such as container packages, Actions secrets or webhooks)
+const restBaseURL = "https://api.github.com"
+
+// restMaxPerPage is the largest page size supported by the GitHub REST API
+const restMaxPerPage = 100
+
+// restPager pages through the results of a GitHub REST API list endpoint, following the links to the next pages
+type restPager struct {
+	*Options
+
+	next    string // url of the next page to fetch
+	fetched bool   // whether a page was fetched already
+}
+
+// newRestPager returns a pager over the results of the endpoint at path (relative to restBaseURL),
+// called with the supplied query parameters (which may be nil)
+func newRestPager(opts *Options, path string, query url.Values) *restPager {
+	var params = url.Values{}
+	for key, values := range query {
+		params[key] = values
+	}
+
+	perPage := opts.PerPage
+	if perPage > restMaxPerPage {
+		perPage = restMaxPerPage
+	}
+	params.Set("per_page", strconv.Itoa(perPage))
+
+	return &restPager{Options: opts, next: restBaseURL + path + "?" + params.Encode()}
+}
+
+// done reports whether all of the pages were fetched
+func (p *restPager) done() bool { return p.fetched && p.next == "" }
+
+// fetch requests the next page of results, decoding the JSON response into out
+func (p *restPager) fetch(out interface{}) error {
+	if err := p.RateLimiter.Wait(p.QueryContext.Context()); err != nil {
+		return err
+	}
+
+	p.Options.GitHubPreRequestHook()
+	defer p.Options.GitHubPostRequestHook()
+
+	req, err := http.NewRequestWithContext(p.QueryContext.Context(), http.MethodGet, p.next, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	res, err := p.HTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		var body struct{ Message string }
+		_ = json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&body)
+		return errors.Errorf("GitHub API request to %s failed: %s: %s", req.URL.Path, res.Status, body.Message)
+	}
+
+	if err = json.NewDecoder(res.Body).Decode(out); err != nil {
+		return errors.Wrapf(err, "failed to decode GitHub API response of %s", req.URL.Path)
+	}
+
+	p.Options.RateLimitHandler(restRateLimit(res.Header))
+	p.next, p.fetched = nextPageURL(res.Header.Get("Link")), true
+	return nil
+}
+
+// restRateLimit returns the status of the rate limit reported in the headers of a REST API response
+func restRateLimit(header http.Header) *options.GitHubRateLimitResponse {
+	var atoi = func(key string) int {
+		n, _ := strconv.Atoi(header.Get(key))
+		return n
+	}
+
+	var rl = &options.GitHubRateLimitResponse{
+		Cost:      1,
+		Limit:     atoi("X-Ratelimit-Limit"),
+		Remaining: atoi("X-Ratelimit-Remaining"),
+		Used:      atoi("X-Ratelimit-Used"),
+	}
+	if reset := atoi("X-Ratelimit-Reset"); reset != 0 {
+		rl.ResetAt = githubv4.DateTime{Time: time.Unix(int64(reset), 0)}
+	}
+	return rl
+}
+
+// nextPageURL returns the url of the next page of results from a Link header (empty on the last page),
+// such as: <https://api.github.com/...?page=2>; rel="next", <https://api.github.com/...?page=5>; rel="last"
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if !ok {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(target), "<>")
+			}
+		}
+	}
+	return ""
+}
+
+// restPath joins the (escaped) segments into the path of a REST API endpoint
+func restPath(segments ...string) string {
+	var b strings.Builder
+	for _, segment := range segments {
+		fmt.Fprintf(&b, "/%s", url.PathEscape(segment))
+	}
+	return b.String()
+}
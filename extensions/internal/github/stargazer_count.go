@@ -1,7 +1,6 @@
 package github
 
 import (
-	"context"
 	"errors"
 	"strings"
 
@@ -17,7 +16,7 @@ type starCount struct {
 func (s *starCount) Args() int           { return -1 }
 func (s *starCount) Deterministic() bool { return false }
 func (s *starCount) Apply(ctx *sqlite.Context, values ...sqlite.Value) {
-	err := s.opts.RateLimiter.Wait(context.Background())
+	err := s.opts.RateLimiter.Wait(s.opts.QueryContext.Context())
 	if err != nil {
 		ctx.ResultError(err)
 		return
@@ -57,7 +56,7 @@ func (s *starCount) Apply(ctx *sqlite.Context, values ...sqlite.Value) {
 		"owner": githubv4.String(owner),
 		"name":  githubv4.String(name),
 	}
-	err = s.opts.Client().Query(context.Background(), &starsCountQuery, variables)
+	err = s.opts.Client().Query(s.opts.QueryContext.Context(), &starsCountQuery, variables)
 
 	s.opts.GitHubPostRequestHook()
 
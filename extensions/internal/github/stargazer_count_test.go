@@ -5,8 +5,7 @@ import (
 )
 
 func TestStargazersCount(t *testing.T) {
-	cleanup := newRecorder(t)
-	defer cleanup()
+	defer transport.Start(t)()
 
 	db := Connect(t, Memory)
 
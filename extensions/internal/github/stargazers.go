@@ -139,7 +139,7 @@ func (i *iterStargazers) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.Edges) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.QueryContext.Context())
 			if err != nil {
 				return nil, err
 			}
@@ -153,7 +153,7 @@ func (i *iterStargazers) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of stargazers for %s/%s", i.owner, i.name)
-			results, err := i.fetchStars(context.Background(), cursor)
+			results, err := i.fetchStars(i.QueryContext.Context(), cursor)
 
 			i.Options.GitHubPostRequestHook()
 
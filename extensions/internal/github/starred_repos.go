@@ -134,7 +134,7 @@ func (i *iterStarredRepos) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.Edges) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.QueryContext.Context())
 			if err != nil {
 				return nil, err
 			}
@@ -148,7 +148,7 @@ func (i *iterStarredRepos) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of starred_repos for %s", i.login)
-			results, err := i.fetchStarredRepos(context.Background(), cursor)
+			results, err := i.fetchStarredRepos(i.QueryContext.Context(), cursor)
 
 			i.Options.GitHubPostRequestHook()
 
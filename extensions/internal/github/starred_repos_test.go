@@ -7,8 +7,7 @@ import (
 )
 
 func TestStarredRepos(t *testing.T) {
-	cleanup := newRecorder(t)
-	defer cleanup()
+	defer transport.Start(t)()
 
 	db := Connect(t, Memory)
 
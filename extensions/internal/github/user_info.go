@@ -1,7 +1,6 @@
 package github
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/mergestat/mergestat-lite/extensions/options"
@@ -19,7 +18,7 @@ func (s *userInfo) Args() int           { return 1 }
 func (s *userInfo) Deterministic() bool { return false }
 
 func (s *userInfo) Apply(ctx *sqlite.Context, value ...sqlite.Value) {
-	err := s.opts.RateLimiter.Wait(context.Background())
+	err := s.opts.RateLimiter.Wait(s.opts.QueryContext.Context())
 	if err != nil {
 		ctx.ResultError(err)
 		return
@@ -49,7 +48,7 @@ func (s *userInfo) Apply(ctx *sqlite.Context, value ...sqlite.Value) {
 	l := s.opts.Logger.With().Str("login", login).Logger()
 	l.Info().Msgf("fetching user information for: %s", login)
 
-	err = s.opts.Client().Query(context.TODO(), &query, variables)
+	err = s.opts.Client().Query(s.opts.QueryContext.Context(), &query, variables)
 
 	s.opts.GitHubPostRequestHook()
 
@@ -6,8 +6,7 @@ import (
 )
 
 func TestUserInfo(t *testing.T) {
-	cleanup := newRecorder(t)
-	defer cleanup()
+	defer transport.Start(t)()
 
 	db := Connect(t, Memory)
 
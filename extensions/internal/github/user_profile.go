@@ -0,0 +1,172 @@
+package github
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/rs/zerolog"
+	"github.com/shurcooL/githubv4"
+	"go.riyazali.net/sqlite"
+)
+
+type userProfile struct {
+	Login        string
+	Name         string
+	Company      string
+	Location     string
+	Email        string
+	Bio          string
+	CreatedAt    githubv4.DateTime
+	Repositories struct {
+		TotalCount int
+	} `graphql:"repositories(privacy: PUBLIC)"`
+	Followers struct {
+		TotalCount int
+	}
+	Following struct {
+		TotalCount int
+	}
+	TwitterUsername string
+	Url             githubv4.URI
+}
+
+func (i *iterUserProfile) fetchUserProfile(ctx context.Context) (*options.GitHubRateLimitResponse, *userProfile, error) {
+	// repositoryOwner is looked up (rather than user), as it resolves to null (rather than an error)
+	// for logins that don't exist, or that aren't users (such as bots)
+	var userQuery struct {
+		RateLimit       *options.GitHubRateLimitResponse
+		RepositoryOwner *struct {
+			User userProfile `graphql:"... on User"`
+		} `graphql:"repositoryOwner(login: $login)"`
+	}
+	variables := map[string]interface{}{
+		"login": githubv4.String(i.login),
+	}
+
+	err := i.Client().Query(ctx, &userQuery, variables)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if userQuery.RepositoryOwner == nil || userQuery.RepositoryOwner.User.Login == "" {
+		return userQuery.RateLimit, nil, nil
+	}
+	return userQuery.RateLimit, &userQuery.RepositoryOwner.User, nil
+}
+
+type iterUserProfile struct {
+	*Options
+	login   string
+	fetched bool
+	user    *userProfile
+}
+
+func (i *iterUserProfile) logger() *zerolog.Logger {
+	logger := i.Logger.With().Str("login", i.login).Logger()
+	return &logger
+}
+
+func (i *iterUserProfile) Column(ctx vtab.Context, c int) error {
+	current := i.user
+	col := userProfileCols[c]
+
+	switch col.Name {
+	case "login":
+		ctx.ResultText(current.Login)
+	case "name":
+		ctx.ResultText(current.Name)
+	case "company":
+		ctx.ResultText(current.Company)
+	case "location":
+		ctx.ResultText(current.Location)
+	case "email":
+		ctx.ResultText(current.Email)
+	case "bio":
+		ctx.ResultText(current.Bio)
+	case "created_at":
+		t := current.CreatedAt
+		if t.IsZero() {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(t.Format(time.RFC3339Nano))
+		}
+	case "public_repo_count":
+		ctx.ResultInt(current.Repositories.TotalCount)
+	case "follower_count":
+		ctx.ResultInt(current.Followers.TotalCount)
+	case "following_count":
+		ctx.ResultInt(current.Following.TotalCount)
+	case "twitter_username":
+		ctx.ResultText(current.TwitterUsername)
+	case "url":
+		ctx.ResultText(current.Url.String())
+	}
+	return nil
+}
+
+func (i *iterUserProfile) Next() (vtab.Row, error) {
+	if i.fetched || i.login == "" {
+		return nil, io.EOF
+	}
+	i.fetched = true
+
+	err := i.RateLimiter.Wait(i.QueryContext.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	i.Options.GitHubPreRequestHook()
+
+	i.logger().Info().Msgf("fetching user profile of %s", i.login)
+	rateLimit, user, err := i.fetchUserProfile(i.QueryContext.Context())
+
+	i.Options.GitHubPostRequestHook()
+
+	if err != nil {
+		return nil, err
+	}
+
+	i.Options.RateLimitHandler(rateLimit)
+
+	if i.user = user; user == nil {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+var userProfileCols = []vtab.Column{
+	{Name: "login", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "name", Type: "TEXT"},
+	{Name: "company", Type: "TEXT"},
+	{Name: "location", Type: "TEXT"},
+	{Name: "email", Type: "TEXT"},
+	{Name: "bio", Type: "TEXT"},
+	{Name: "created_at", Type: "DATETIME"},
+	{Name: "public_repo_count", Type: "INT"},
+	{Name: "follower_count", Type: "INT"},
+	{Name: "following_count", Type: "INT"},
+	{Name: "twitter_username", Type: "TEXT"},
+	{Name: "url", Type: "TEXT"},
+}
+
+// NewUserProfileModule returns the implementation of a table with the profile of a GitHub user, as in github_user('login').
+// It's meant to enrich the logins found in other tables (such as to map authors to companies), so logins
+// that don't resolve to a user return no row, rather than an error. It shares its name with the github_user function
+// (which returns the same profile as JSON), as SQLite keeps table-valued functions apart from scalar ones.
+func NewUserProfileModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_user", userProfileCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var login string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 0 {
+				login = constraint.Value.Text()
+			}
+		}
+
+		iter := &iterUserProfile{Options: opts, login: login}
+		iter.logger().Info().Msgf("starting GitHub user iterator for %s", login)
+		return iter, nil
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/internal/sqlutil"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/rs/zerolog"
 	"github.com/shurcooL/githubv4"
@@ -28,16 +29,18 @@ type userRepo struct {
 		Name   string
 		Prefix string
 	}
-	Description string
-	DiskUsage   int
-	ForkCount   int
-	HomepageUrl string
-	IsArchived  bool
-	IsDisabled  bool
-	IsFork      bool
-	IsMirror    bool
-	IsPrivate   bool
-	Issues      struct {
+	Description                   string
+	DiskUsage                     int
+	ForkCount                     int
+	HasVulnerabilityAlertsEnabled bool
+	HomepageUrl                   string
+	IsArchived                    bool
+	IsDisabled                    bool
+	IsFork                        bool
+	IsMirror                      bool
+	IsPrivate                     bool
+	IsSecurityPolicyEnabled       bool
+	Issues                        struct {
 		TotalCount int
 	}
 	LatestRelease struct {
@@ -52,8 +55,12 @@ type userRepo struct {
 		Key      string
 		Name     string
 		Nickname string
+		SpdxId   string
 	}
-	Name              string
+	Name       string
+	OpenIssues struct {
+		TotalCount int
+	} `graphql:"openIssues: issues(states: OPEN)"`
 	OpenGraphImageUrl githubv4.URI
 	PrimaryLanguage   struct {
 		Name string
@@ -73,8 +80,9 @@ type userRepo struct {
 			}
 		}
 	} `graphql:"repositoryTopics(first: 10)"`
-	UpdatedAt time.Time
-	Watchers  struct {
+	UpdatedAt  time.Time
+	Visibility githubv4.RepositoryVisibility
+	Watchers   struct {
 		TotalCount int
 	}
 }
@@ -90,7 +98,7 @@ func (i *iterUserRepos) fetchUserRepos(ctx context.Context, startCursor *githubv
 					EndCursor   githubv4.String
 					HasNextPage bool
 				}
-			} `graphql:"repositories(first: $perPage, after: $userReposCursor, orderBy: $repositoryOrder, affiliations: $affiliations)"`
+			} `graphql:"repositories(first: $perPage, after: $userReposCursor, orderBy: $repositoryOrder, affiliations: $affiliations, privacy: $privacy, isArchived: $isArchived, isFork: $isFork)"`
 		} `graphql:"user(login: $login)"`
 	}
 
@@ -100,6 +108,9 @@ func (i *iterUserRepos) fetchUserRepos(ctx context.Context, startCursor *githubv
 		"perPage":         githubv4.Int(i.PerPage),
 		"userReposCursor": startCursor,
 		"repositoryOrder": i.repoOrder,
+		"privacy":         i.filters.privacy,
+		"isArchived":      i.filters.isArchived,
+		"isFork":          i.filters.isFork,
 	}
 
 	err := i.Client().Query(ctx, &reposQuery, variables)
@@ -122,6 +133,7 @@ type iterUserRepos struct {
 	current      int
 	results      *fetchUserReposResults
 	repoOrder    *githubv4.RepositoryOrder
+	filters      *repoFilters
 }
 
 func (i *iterUserRepos) logger() *zerolog.Logger {
@@ -156,18 +168,22 @@ func (i *iterUserRepos) Column(ctx vtab.Context, c int) error {
 		ctx.ResultInt(current.DiskUsage)
 	case "fork_count":
 		ctx.ResultInt(current.ForkCount)
+	case "has_vulnerability_alerts_enabled":
+		ctx.ResultInt(sqlutil.BoolToInt(current.HasVulnerabilityAlertsEnabled))
 	case "homepage_url":
 		ctx.ResultText(current.HomepageUrl)
 	case "is_archived":
-		ctx.ResultInt(t1f0(current.IsArchived))
+		ctx.ResultInt(sqlutil.BoolToInt(current.IsArchived))
 	case "is_disabled":
-		ctx.ResultInt(t1f0(current.IsDisabled))
+		ctx.ResultInt(sqlutil.BoolToInt(current.IsDisabled))
 	case "is_fork":
-		ctx.ResultInt(t1f0(current.IsFork))
+		ctx.ResultInt(sqlutil.BoolToInt(current.IsFork))
 	case "is_mirror":
-		ctx.ResultInt(t1f0(current.IsMirror))
+		ctx.ResultInt(sqlutil.BoolToInt(current.IsMirror))
 	case "is_private":
-		ctx.ResultInt(t1f0(current.IsPrivate))
+		ctx.ResultInt(sqlutil.BoolToInt(current.IsPrivate))
+	case "is_security_policy_enabled":
+		ctx.ResultInt(sqlutil.BoolToInt(current.IsSecurityPolicyEnabled))
 	case "issue_count":
 		ctx.ResultInt(current.Issues.TotalCount)
 	case "latest_release_author":
@@ -192,8 +208,12 @@ func (i *iterUserRepos) Column(ctx vtab.Context, c int) error {
 		ctx.ResultText(current.LicenseInfo.Key)
 	case "license_name":
 		ctx.ResultText(current.LicenseInfo.Name)
+	case "license_spdx_id":
+		ctx.ResultText(current.LicenseInfo.SpdxId)
 	case "name":
 		ctx.ResultText(current.Name)
+	case "open_issue_count":
+		ctx.ResultInt(current.OpenIssues.TotalCount)
 	case "open_graph_image_url":
 		ctx.ResultText(current.OpenGraphImageUrl.String())
 	case "primary_language":
@@ -228,6 +248,8 @@ func (i *iterUserRepos) Column(ctx vtab.Context, c int) error {
 		} else {
 			ctx.ResultText(t.Format(time.RFC3339Nano))
 		}
+	case "visibility":
+		ctx.ResultText(string(current.Visibility))
 	case "watcher_count":
 		ctx.ResultInt(current.Watchers.TotalCount)
 	}
@@ -239,7 +261,7 @@ func (i *iterUserRepos) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.UserRepos) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.QueryContext.Context())
 			if err != nil {
 				return nil, err
 			}
@@ -253,7 +275,7 @@ func (i *iterUserRepos) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of user_repos for %s", i.login)
-			results, err := i.fetchUserRepos(context.Background(), cursor)
+			results, err := i.fetchUserRepos(i.QueryContext.Context(), cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -272,10 +294,15 @@ func (i *iterUserRepos) Next() (vtab.Row, error) {
 		}
 	}
 
+	current := i.results.UserRepos[i.current]
+	if i.filters.past(i.repoOrder, current.Name, current.CreatedAt, current.UpdatedAt, current.PushedAt) {
+		return nil, io.EOF
+	}
+
 	return i, nil
 }
 
-var userReposCols = []vtab.Column{
+var userReposCols = withRepoFilters([]vtab.Column{
 	{Name: "login", Type: "TEXT", Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
 	{Name: "affiliations", Type: "TEXT", Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
 	{Name: "created_at", Type: "DATETIME", OrderBy: vtab.ASC | vtab.DESC},
@@ -285,12 +312,14 @@ var userReposCols = []vtab.Column{
 	{Name: "description", Type: "TEXT"},
 	{Name: "disk_usage", Type: "INT"},
 	{Name: "fork_count", Type: "INT"},
+	{Name: "has_vulnerability_alerts_enabled", Type: "BOOLEAN"},
 	{Name: "homepage_url", Type: "TEXT"},
 	{Name: "is_archived", Type: "BOOLEAN"},
 	{Name: "is_disabled", Type: "BOOLEAN"},
 	{Name: "is_fork", Type: "BOOLEAN"},
 	{Name: "is_mirror", Type: "BOOLEAN"},
 	{Name: "is_private", Type: "BOOLEAN"},
+	{Name: "is_security_policy_enabled", Type: "BOOLEAN"},
 	{Name: "issue_count", Type: "INT"},
 	{Name: "latest_release_author", Type: "TEXT"},
 	{Name: "latest_release_created_at", Type: "DATETIME"},
@@ -298,8 +327,10 @@ var userReposCols = []vtab.Column{
 	{Name: "latest_release_published_at", Type: "DATETIME"},
 	{Name: "license_key", Type: "TEXT"},
 	{Name: "license_name", Type: "TEXT"},
+	{Name: "license_spdx_id", Type: "TEXT"},
 	{Name: "name", Type: "TEXT", OrderBy: vtab.ASC | vtab.DESC},
 	{Name: "open_graph_image_url", Type: "TEXT"},
+	{Name: "open_issue_count", Type: "INT"},
 	{Name: "primary_language", Type: "TEXT"},
 	{Name: "pull_request_count", Type: "INT"},
 	{Name: "pushed_at", Type: "DATETIME", OrderBy: vtab.ASC | vtab.DESC},
@@ -307,8 +338,9 @@ var userReposCols = []vtab.Column{
 	{Name: "stargazer_count", Type: "INT", OrderBy: vtab.ASC | vtab.DESC},
 	{Name: "topics", Type: "JSON"},
 	{Name: "updated_at", Type: "DATETIME", OrderBy: vtab.ASC | vtab.DESC},
+	{Name: "visibility", Type: "TEXT"},
 	{Name: "watcher_count", Type: "INT"},
-}
+})
 
 func NewUserReposModule(opts *Options) sqlite.Module {
 	return vtab.NewTableFunc("github_user_repos", userReposCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
@@ -324,6 +356,8 @@ func NewUserReposModule(opts *Options) sqlite.Module {
 			}
 		}
 
+		filters := newRepoFilters(userReposCols, constraints)
+
 		var repoOrder *githubv4.RepositoryOrder
 		// for now we can only support single field order bys
 		if len(orders) == 1 {
@@ -344,7 +378,7 @@ func NewUserReposModule(opts *Options) sqlite.Module {
 			repoOrder.Direction = orderByToGitHubOrder(order.Desc)
 		}
 
-		iter := &iterUserRepos{opts, login, affiliations, -1, nil, repoOrder}
+		iter := &iterUserRepos{opts, login, affiliations, -1, nil, filters.order(repoOrder), filters}
 		iter.logger().Info().Msgf("starting GitHub user_repos iterator for %s", login)
 		return iter, nil
 	}, vtab.EarlyOrderByConstraintExit(true))
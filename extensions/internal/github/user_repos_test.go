@@ -7,8 +7,7 @@ import (
 )
 
 func TestUserRepos(t *testing.T) {
-	cleanup := newRecorder(t)
-	defer cleanup()
+	defer transport.Start(t)()
 
 	db := Connect(t, Memory)
 
@@ -23,7 +22,7 @@ func TestUserRepos(t *testing.T) {
 		t.Fatalf("failed to retrieve row contents: %v", err.Error())
 	}
 
-	if expected := 30; colCount != expected {
+	if expected := 35; colCount != expected {
 		t.Fatalf("expected %d columns, got: %d", expected, colCount)
 	}
 
@@ -1,7 +1,9 @@
 package github
 
 import (
+	"encoding/json"
 	"errors"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -19,9 +21,17 @@ type Options struct {
 	RateLimitHandler      func(*options.GitHubRateLimitResponse)
 	GitHubPreRequestHook  func()
 	GitHubPostRequestHook func()
+	// HTTPClient returns the (authenticated) client to make requests to the REST API with
+	HTTPClient func() *http.Client
 	// PerPage is the default number of items per page to use when making a paginated GitHub API request
 	PerPage int
 	Logger  *zerolog.Logger
+	// QueryContext returns the context of the query being run, which cancels requests (and pagination) when done
+	QueryContext options.QueryContextFunc
+	// Locator opens the (local) repositories read by the tables looking into their files (such as gha_action_refs)
+	Locator services.RepoLocator
+	// Context holds the default repository and ref of those tables
+	Context services.Context
 }
 
 // GetGitHubTokenFromCtx looks up the githubToken key in the supplied context and returns it if set
@@ -77,12 +87,32 @@ func GetGitHubPerPageFromCtx(ctx services.Context) int {
 	}
 }
 
-// t1f0 converts a bool to an int
-func t1f0(b bool) int {
-	if b {
-		return 1
+// reactionGroups are the reactions to a piece of content (such as an issue, pull request or comment), grouped by emoji
+type reactionGroups []struct {
+	Content  githubv4.ReactionContent
+	Reactors struct {
+		TotalCount int
 	}
-	return 0
+}
+
+// total returns the number of reactions, across all emojis
+func (r reactionGroups) total() int {
+	var total int
+	for _, group := range r {
+		total += group.Reactors.TotalCount
+	}
+	return total
+}
+
+// json returns the number of reactions per emoji as a JSON object, keyed by the lower cased
+// name of the emoji (such as {"thumbs_up": 2, "heart": 1, ...})
+func (r reactionGroups) json() (string, error) {
+	var counts = make(map[string]int, len(r))
+	for _, group := range r {
+		counts[strings.ToLower(string(group.Content))] = group.Reactors.TotalCount
+	}
+	js, err := json.Marshal(counts)
+	return string(js), err
 }
 
 // orderByToGitHubOrder is a helper that takes a boolean indicating whether DESC or ASC and returns
@@ -0,0 +1,153 @@
+package github
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/internal/sqlutil"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+type restWebhook struct {
+	ID     int      `json:"id"`
+	Name   string   `json:"name"`
+	Active bool     `json:"active"`
+	Events []string `json:"events"`
+	Config struct {
+		URL         string          `json:"url"`
+		ContentType string          `json:"content_type"`
+		InsecureSSL json.RawMessage `json:"insecure_ssl"` // either "0" or "1", sometimes sent as a number
+	} `json:"config"`
+	LastResponse struct {
+		Code    *int   `json:"code"`
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	} `json:"last_response"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type iterWebhooks struct {
+	*Options
+	owner string
+	name  string
+
+	pager    *restPager
+	webhooks []*restWebhook
+	current  int
+}
+
+func (i *iterWebhooks) logger() *zerolog.Logger {
+	logger := i.Logger.With().Int("per-page", i.PerPage).Str("owner", i.owner).Str("name", i.name).Logger()
+	return &logger
+}
+
+func (i *iterWebhooks) Column(ctx vtab.Context, c int) error {
+	current := i.webhooks[i.current]
+	switch webhookCols[c].Name {
+	case "id":
+		ctx.ResultInt(current.ID)
+	case "name":
+		ctx.ResultText(current.Name)
+	case "url":
+		ctx.ResultText(current.Config.URL)
+	case "content_type":
+		ctx.ResultText(current.Config.ContentType)
+	case "insecure_ssl":
+		ctx.ResultInt(sqlutil.BoolToInt(strings.Trim(string(current.Config.InsecureSSL), `"`) == "1"))
+	case "events":
+		js, err := json.Marshal(current.Events)
+		if err != nil {
+			i.logger().Err(err).Msgf("could not marshal webhook events")
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(string(js))
+		}
+	case "active":
+		ctx.ResultInt(sqlutil.BoolToInt(current.Active))
+	case "last_response_code":
+		if current.LastResponse.Code != nil {
+			ctx.ResultInt(*current.LastResponse.Code)
+		}
+	case "last_response_status":
+		ctx.ResultText(current.LastResponse.Status)
+	case "last_response_message":
+		if current.LastResponse.Message != "" {
+			ctx.ResultText(current.LastResponse.Message)
+		}
+	case "created_at":
+		ctx.ResultText(current.CreatedAt.Format(time.RFC3339Nano))
+	case "updated_at":
+		ctx.ResultText(current.UpdatedAt.Format(time.RFC3339Nano))
+	}
+	return nil
+}
+
+func (i *iterWebhooks) Next() (vtab.Row, error) {
+	i.current += 1
+
+	for i.current >= len(i.webhooks) {
+		if i.pager.done() {
+			return nil, io.EOF
+		}
+
+		i.logger().Info().Msgf("fetching page of webhooks for %s/%s", i.owner, i.name)
+		var page []*restWebhook
+		if err := i.pager.fetch(&page); err != nil {
+			return nil, err
+		}
+		i.webhooks, i.current = page, 0
+	}
+
+	return i, nil
+}
+
+var webhookCols = []vtab.Column{
+	{Name: "owner", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: "INT"},
+	{Name: "name", Type: "TEXT"},
+	{Name: "url", Type: "TEXT"},
+	{Name: "content_type", Type: "TEXT"},
+	{Name: "insecure_ssl", Type: "BOOLEAN"},
+	{Name: "events", Type: "JSON"},
+	{Name: "active", Type: "BOOLEAN"},
+	{Name: "last_response_code", Type: "INT"},
+	{Name: "last_response_status", Type: "TEXT"},
+	{Name: "last_response_message", Type: "TEXT"},
+	{Name: "created_at", Type: "DATETIME"},
+	{Name: "updated_at", Type: "DATETIME"},
+}
+
+// NewWebhooksModule returns the implementation of a table listing the webhooks of a GitHub repository: where they deliver
+// to, the events they're subscribed to, and the outcome of their last delivery (last_response_status is "unused"
+// for webhooks that never delivered anything). Listing webhooks requires admin access to the repository.
+func NewWebhooksModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_webhooks", webhookCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				}
+			}
+		}
+
+		owner, name, err := repoOwnerAndName(name, fullNameOrOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		iter := &iterWebhooks{Options: opts, owner: owner, name: name, current: -1}
+		iter.pager = newRestPager(opts, restPath("repos", owner, name, "hooks"), nil)
+		iter.logger().Info().Msgf("starting GitHub webhooks iterator for %s/%s", owner, name)
+		return iter, nil
+	})
+}
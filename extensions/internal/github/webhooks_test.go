@@ -0,0 +1,42 @@
+package github_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestWebhooks(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT * FROM github_webhooks('mergestat/mergestat')")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	colCount, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	if expected := 12; colCount != expected {
+		t.Fatalf("expected %d columns, got: %d", expected, colCount)
+	}
+
+	if expected := 2; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	if url, events, active, code := content[0][2], content[0][5], content[0][6], content[0][7]; url != "https://ci.example.com/github/webhook" ||
+		events != `["push","pull_request"]` || active != "1" || code != "200" {
+		t.Fatalf("unexpected webhook: %v", content[0])
+	}
+
+	// the second webhook skips TLS verification, and never delivered anything
+	if insecure, code, status := content[1][4], content[1][7], content[1][8]; insecure != "1" || code != "NULL" || status != "unused" {
+		t.Fatalf("unexpected webhook: %v", content[1])
+	}
+}
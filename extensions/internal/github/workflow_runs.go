@@ -0,0 +1,159 @@
+package github
+
+import (
+	"io"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+type restWorkflowRun struct {
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	Path         string     `json:"path"`
+	RunNumber    int        `json:"run_number"`
+	RunAttempt   int        `json:"run_attempt"`
+	Event        string     `json:"event"`
+	Status       string     `json:"status"`
+	Conclusion   *string    `json:"conclusion"`
+	HeadBranch   string     `json:"head_branch"`
+	HeadSHA      string     `json:"head_sha"`
+	HTMLURL      string     `json:"html_url"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	RunStartedAt *time.Time `json:"run_started_at"`
+}
+
+type iterWorkflowRuns struct {
+	*Options
+	owner    string
+	name     string
+	workflow string
+
+	pager   *restPager
+	runs    []*restWorkflowRun
+	current int
+}
+
+func (i *iterWorkflowRuns) logger() *zerolog.Logger {
+	logger := i.Logger.With().Int("per-page", i.PerPage).Str("owner", i.owner).Str("name", i.name).Str("workflow", i.workflow).Logger()
+	return &logger
+}
+
+func (i *iterWorkflowRuns) Column(ctx vtab.Context, c int) error {
+	current := i.runs[i.current]
+	switch workflowRunCols[c].Name {
+	case "id":
+		ctx.ResultInt(current.ID)
+	case "workflow_name":
+		ctx.ResultText(current.Name)
+	case "workflow_path":
+		ctx.ResultText(current.Path)
+	case "run_number":
+		ctx.ResultInt(current.RunNumber)
+	case "run_attempt":
+		ctx.ResultInt(current.RunAttempt)
+	case "event":
+		ctx.ResultText(current.Event)
+	case "status":
+		ctx.ResultText(current.Status)
+	case "conclusion":
+		if current.Conclusion != nil {
+			ctx.ResultText(*current.Conclusion)
+		}
+	case "head_branch":
+		ctx.ResultText(current.HeadBranch)
+	case "head_sha":
+		ctx.ResultText(current.HeadSHA)
+	case "html_url":
+		ctx.ResultText(current.HTMLURL)
+	case "created_at":
+		ctx.ResultText(current.CreatedAt.Format(time.RFC3339Nano))
+	case "updated_at":
+		ctx.ResultText(current.UpdatedAt.Format(time.RFC3339Nano))
+	case "run_started_at":
+		if current.RunStartedAt != nil {
+			ctx.ResultText(current.RunStartedAt.Format(time.RFC3339Nano))
+		}
+	}
+	return nil
+}
+
+func (i *iterWorkflowRuns) Next() (vtab.Row, error) {
+	i.current += 1
+
+	for i.current >= len(i.runs) {
+		if i.pager.done() {
+			return nil, io.EOF
+		}
+
+		i.logger().Info().Msgf("fetching page of workflow runs for %s/%s", i.owner, i.name)
+		var page struct {
+			WorkflowRuns []*restWorkflowRun `json:"workflow_runs"`
+		}
+		if err := i.pager.fetch(&page); err != nil {
+			return nil, err
+		}
+		i.runs, i.current = page.WorkflowRuns, 0
+	}
+
+	return i, nil
+}
+
+var workflowRunCols = []vtab.Column{
+	{Name: "owner", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "workflow", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: "INT"},
+	{Name: "workflow_name", Type: "TEXT"},
+	{Name: "workflow_path", Type: "TEXT"},
+	{Name: "run_number", Type: "INT"},
+	{Name: "run_attempt", Type: "INT"},
+	{Name: "event", Type: "TEXT"},
+	{Name: "status", Type: "TEXT"},
+	{Name: "conclusion", Type: "TEXT"},
+	{Name: "head_branch", Type: "TEXT"},
+	{Name: "head_sha", Type: "TEXT"},
+	{Name: "html_url", Type: "TEXT"},
+	{Name: "created_at", Type: "DATETIME"},
+	{Name: "updated_at", Type: "DATETIME"},
+	{Name: "run_started_at", Type: "DATETIME"},
+}
+
+// NewWorkflowRunsModule returns the implementation of a table listing the GitHub Actions workflow runs of a repository
+// (or of one of its workflows, by id or file name such as deploy.yml, if supplied), most recent first, along with
+// the commit and branch they ran on and how they concluded (NULL until they're completed)
+func NewWorkflowRunsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_workflow_runs", workflowRunCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name, workflow string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				case 2:
+					workflow = constraint.Value.Text()
+				}
+			}
+		}
+
+		owner, name, err := repoOwnerAndName(name, fullNameOrOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		var path = restPath("repos", owner, name, "actions", "runs")
+		if workflow != "" {
+			path = restPath("repos", owner, name, "actions", "workflows", workflow, "runs")
+		}
+
+		iter := &iterWorkflowRuns{Options: opts, owner: owner, name: name, workflow: workflow, current: -1}
+		iter.pager = newRestPager(opts, path, nil)
+		iter.logger().Info().Msgf("starting GitHub workflow runs iterator for %s/%s", owner, name)
+		return iter, nil
+	})
+}
@@ -0,0 +1,39 @@
+package github_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestWorkflowRuns(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT id, run_number, run_attempt, status, conclusion, head_branch, head_sha, run_started_at FROM github_workflow_runs('mergestat/mergestat') WHERE workflow = 'deploy.yml'")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	expected := [][]string{
+		{"8912345002", "142", "2", "completed", "failure", "main", "7f3d2c1b9a8e7d6c5b4a39281706f5e4d3c2b1a0", "2024-04-30T14:05:30Z"},
+		{"8912345001", "141", "1", "in_progress", "NULL", "main", "1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d", "2024-04-29T09:12:03Z"},
+	}
+	if len(content) != len(expected) {
+		t.Fatalf("expected %d rows, got: %v", len(expected), content)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if content[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, content[r][c])
+			}
+		}
+	}
+}
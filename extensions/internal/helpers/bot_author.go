@@ -0,0 +1,112 @@
+package helpers
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// botNames are the names of well-known bots and automation, matched against author names and the local part of their emails
+var botNames = map[string]struct{}{
+	"dependabot": {}, "dependabot-preview": {}, "renovate": {}, "renovate-bot": {}, "renovatebot": {},
+	"github-actions": {}, "github action": {}, "github actions": {}, "actions-user": {}, "greenkeeper": {},
+	"greenkeeperio-bot": {}, "snyk-bot": {}, "mergify": {}, "imgbot": {}, "imgbotapp": {}, "codecov": {},
+	"pre-commit-ci": {}, "allcontributors": {}, "semantic-release-bot": {}, "pyup-bot": {}, "deepsource-autofix": {},
+	"whitesource-bolt-for-github": {}, "k8s-ci-robot": {}, "copybara-service": {}, "weblate": {}, "transifex": {},
+}
+
+// botNamePatterns match the (lowercased) names of bots not in botNames: GitHub apps (such as dependabot[bot]),
+// and names ending in bot or robot (such as release-bot)
+var botNamePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\[bot\]`),
+	regexp.MustCompile(`(^|[^a-z])(ro)?bot$`),
+}
+
+// botEmailPatterns match the (lowercased) emails of bots not in botNames: GitHub apps, no-reply or automation mailboxes
+// (such as noreply@github.com, but not the no-reply addresses of users, such as octocat@users.noreply.github.com),
+// and mailboxes ending in bot or robot (such as release-bot@example.com)
+var botEmailPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\[bot\]`),
+	regexp.MustCompile(`^(no-?reply|do-?not-?reply|bot|actions?|automation)@`),
+	regexp.MustCompile(`[-_.+](ro)?bot@`),
+}
+
+// botAuthorPatterns returns the patterns of additional bots, set as a comma separated list of regular expressions
+// in the botAuthors key of the context of opt, matched case-insensitively against author names and emails
+func botAuthorPatterns(opt *options.Options) ([]*regexp.Regexp, error) {
+	if opt == nil || opt.Context["botAuthors"] == "" {
+		return nil, nil
+	}
+
+	var patterns []*regexp.Regexp
+	for _, pattern := range strings.Split(opt.Context["botAuthors"], ",") {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid bot author pattern %q", pattern)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// IsBotAuthor implements the IS_BOT_AUTHOR(name, email) sql function, which reports whether an author (or committer)
+// is a bot or automation rather than a person, from a list of well-known bots and patterns (such as dependabot[bot],
+// renovate or noreply@github.com), extended with the patterns of the botAuthors key of the context.
+type IsBotAuthor struct {
+	extra []*regexp.Regexp
+}
+
+func (*IsBotAuthor) Args() int           { return 2 }
+func (*IsBotAuthor) Deterministic() bool { return true }
+func (fn *IsBotAuthor) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	if values[0].IsNil() && values[1].IsNil() {
+		c.ResultNull()
+		return
+	}
+
+	if fn.isBot(values[0].Text(), values[1].Text()) {
+		c.ResultInt(1)
+	} else {
+		c.ResultInt(0)
+	}
+}
+
+func (fn *IsBotAuthor) isBot(name, email string) bool {
+	for _, re := range fn.extra {
+		if (name != "" && re.MatchString(name)) || (email != "" && re.MatchString(email)) {
+			return true
+		}
+	}
+
+	name, email = strings.ToLower(strings.TrimSpace(name)), strings.ToLower(strings.TrimSpace(email))
+	if _, ok := botNames[name]; ok {
+		return true
+	}
+	if local, _, ok := strings.Cut(email, "@"); ok {
+		// the no-reply addresses of GitHub are prefixed with the id of the account, as in 49699333+dependabot[bot]@
+		if _, id, _ := strings.Cut(local, "+"); id != "" {
+			local = id
+		}
+		if _, ok := botNames[local]; ok {
+			return true
+		}
+	}
+
+	for _, re := range botNamePatterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	for _, re := range botEmailPatterns {
+		if re.MatchString(email) {
+			return true
+		}
+	}
+	return false
+}
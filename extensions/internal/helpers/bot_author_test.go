@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/extensions/services"
+)
+
+func TestIsBotAuthor(t *testing.T) {
+	type test struct {
+		query    string
+		expected string
+	}
+	tests := []test{
+		{query: `SELECT is_bot_author('dependabot[bot]', '49699333+dependabot[bot]@users.noreply.github.com')`, expected: "1"},
+		{query: `SELECT is_bot_author('Renovate Bot', 'bot@renovateapp.com')`, expected: "1"},
+		{query: `SELECT is_bot_author('github-actions', '41898282+github-actions@users.noreply.github.com')`, expected: "1"},
+		{query: `SELECT is_bot_author('GitHub', 'noreply@github.com')`, expected: "1"},
+		{query: `SELECT is_bot_author('release', 'release-bot@example.com')`, expected: "1"},
+		{query: `SELECT is_bot_author('The Octocat', '583231+octocat@users.noreply.github.com')`, expected: "0"},
+		{query: `SELECT is_bot_author('Jane Talbot', 'jane@example.com')`, expected: "0"},
+		{query: `SELECT is_bot_author(NULL, NULL)`, expected: "NULL"},
+	}
+
+	for _, testCase := range tests {
+		rows, err := FixtureDatabase.Query(testCase.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rowNum, contents, err := tools.RowContent(rows)
+		if err != nil {
+			t.Fatalf("err %d at row Number %d", err, rowNum)
+		}
+		if contents[0][0] != testCase.expected {
+			t.Fatalf("expected string: %s, got %s for %s", testCase.expected, contents[0][0], testCase.query)
+		}
+	}
+}
+
+func TestBotAuthorPatterns(t *testing.T) {
+	patterns, err := botAuthorPatterns(&options.Options{Context: services.Context{"botAuthors": "^deploy ,@ci\\.example\\.com$"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fn = &IsBotAuthor{extra: patterns}
+	if !fn.isBot("Deploy Pipeline", "pipeline@example.com") || !fn.isBot("builder", "builder@CI.example.com") || fn.isBot("Jane", "jane@example.com") {
+		t.Fatal("expected the patterns of the context to match bots")
+	}
+
+	if _, err = botAuthorPatterns(&options.Options{Context: services.Context{"botAuthors": "deploy("}}); err == nil {
+		t.Fatal("expected an invalid pattern to fail")
+	}
+}
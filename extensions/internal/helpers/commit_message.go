@@ -0,0 +1,189 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// splitMessage splits a commit message into its subject and body, as git does (for the %s and %b placeholders
+// of git log --format): the subject is the first paragraph, with its lines joined by a space, and the body is the rest.
+func splitMessage(message string) (subject, body string) {
+	message = strings.TrimLeft(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+
+	var paragraph = message
+	if end := strings.Index(message, "\n\n"); end >= 0 {
+		paragraph, body = message[:end], strings.Trim(message[end:], "\n")
+	}
+
+	lines := strings.Split(strings.TrimRight(paragraph, "\n"), "\n")
+	for l := range lines {
+		lines[l] = strings.TrimSpace(lines[l])
+	}
+	return strings.Join(lines, " "), body
+}
+
+// MsgSubject implements the MSG_SUBJECT(message) sql function, which returns the subject of a commit message
+type MsgSubject struct{}
+
+func (*MsgSubject) Args() int           { return 1 }
+func (*MsgSubject) Deterministic() bool { return true }
+func (*MsgSubject) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	if values[0].IsNil() {
+		c.ResultNull()
+		return
+	}
+	subject, _ := splitMessage(values[0].Text())
+	c.ResultText(subject)
+}
+
+// MsgBody implements the MSG_BODY(message) sql function, which returns the body of a commit message
+// (an empty string if the message has none)
+type MsgBody struct{}
+
+func (*MsgBody) Args() int           { return 1 }
+func (*MsgBody) Deterministic() bool { return true }
+func (*MsgBody) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	if values[0].IsNil() {
+		c.ResultNull()
+		return
+	}
+	_, body := splitMessage(values[0].Text())
+	c.ResultText(body)
+}
+
+// MsgSubjectLength implements the MSG_SUBJECT_LENGTH(message) sql function, which returns the length
+// (in characters) of the subject of a commit message
+type MsgSubjectLength struct{}
+
+func (*MsgSubjectLength) Args() int           { return 1 }
+func (*MsgSubjectLength) Deterministic() bool { return true }
+func (*MsgSubjectLength) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	if values[0].IsNil() {
+		c.ResultNull()
+		return
+	}
+	subject, _ := splitMessage(values[0].Text())
+	c.ResultInt(utf8.RuneCountInString(subject))
+}
+
+// lintRuleset holds the rules commit messages are linted against. Rules are disabled with false (or 0, for lengths).
+type lintRuleset struct {
+	SubjectEmpty          bool `json:"subject_empty"`
+	SubjectMaxLength      int  `json:"subject_max_length"`
+	SubjectImperative     bool `json:"subject_imperative"`
+	SubjectTrailingPeriod bool `json:"subject_trailing_period"`
+}
+
+// defaultLintRuleset is the ruleset used when none is supplied, and the one a supplied ruleset overrides rules of
+var defaultLintRuleset = lintRuleset{SubjectEmpty: true, SubjectMaxLength: 72, SubjectImperative: true, SubjectTrailingPeriod: true}
+
+// lintViolation is a rule a commit message violates
+type lintViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+var (
+	// conventionalPrefix matches the type (and scope) prefixing the subject of conventional commits, as in "fix(parser)!: "
+	conventionalPrefix = regexp.MustCompile(`^[A-Za-z]+(\([^)]*\))?!?:\s*`)
+
+	// imperativeVerbs are the verbs commonly starting the subject of commit messages, whose other forms
+	// (as in "Added", "Adds" or "Adding") aren't in the imperative mood
+	imperativeVerbs = strings.Fields(`add allow avoid build bump change clean correct create delete deprecate disable document drop
+		enable ensure extract fix handle implement improve introduce merge move prevent refactor release remove rename replace
+		restore return revert run set show simplify support switch test update upgrade use`)
+
+	nonImperative = func() map[string]string {
+		var forms = make(map[string]string)
+		for _, verb := range imperativeVerbs {
+			stem := strings.TrimSuffix(verb, "e")
+			for _, form := range []string{verb + "s", verb + "es", verb + "d", verb + "ed", stem + "ing", verb + "ing"} {
+				forms[form] = verb
+			}
+			// verbs doubling their last consonant, as in "dropped" or "setting"
+			last := verb[len(verb)-1:]
+			forms[verb+last+"ed"], forms[verb+last+"ing"] = verb, verb
+		}
+		for _, verb := range imperativeVerbs {
+			delete(forms, verb) // forms of a verb which are another verb
+		}
+		forms["ran"], forms["built"], forms["made"], forms["making"], forms["makes"] = "run", "build", "make", "make", "make"
+		return forms
+	}()
+)
+
+// lintMessage returns the rules of ruleset the commit message violates
+func lintMessage(message string, ruleset lintRuleset) []lintViolation {
+	var violations = []lintViolation{}
+	subject, _ := splitMessage(message)
+
+	if subject == "" {
+		if ruleset.SubjectEmpty {
+			violations = append(violations, lintViolation{"subject_empty", "subject is empty"})
+		}
+		return violations
+	}
+
+	if length := utf8.RuneCountInString(subject); ruleset.SubjectMaxLength > 0 && length > ruleset.SubjectMaxLength {
+		violations = append(violations, lintViolation{"subject_max_length",
+			fmt.Sprintf("subject is %d characters long, more than %d", length, ruleset.SubjectMaxLength)})
+	}
+
+	if ruleset.SubjectImperative {
+		words := strings.Fields(conventionalPrefix.ReplaceAllString(subject, ""))
+		if len(words) > 0 {
+			word := strings.ToLower(strings.Trim(words[0], `"'`+"`"))
+			if verb, ok := nonImperative[word]; ok {
+				violations = append(violations, lintViolation{"subject_imperative",
+					fmt.Sprintf("subject should use the imperative mood (%q rather than %q)", verb, words[0])})
+			}
+		}
+	}
+
+	if ruleset.SubjectTrailingPeriod && strings.HasSuffix(subject, ".") && !strings.HasSuffix(subject, "...") {
+		violations = append(violations, lintViolation{"subject_trailing_period", "subject ends with a period"})
+	}
+
+	return violations
+}
+
+// MsgLint implements the MSG_LINT(message, [ruleset]) sql function, which returns the violations (as a JSON array of
+// objects with the rule violated and a message) of a commit message against ruleset, a JSON object overriding the rules
+// of the default ruleset, such as {"subject_max_length": 50, "subject_imperative": false}.
+type MsgLint struct{}
+
+func (*MsgLint) Args() int           { return -1 }
+func (*MsgLint) Deterministic() bool { return true }
+func (*MsgLint) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	if len(values) < 1 || len(values) > 2 {
+		c.ResultError(errors.New("msg_lint: expected a message and an (optional) ruleset"))
+		return
+	}
+	if values[0].IsNil() {
+		c.ResultNull()
+		return
+	}
+
+	var ruleset = defaultLintRuleset
+	if len(values) > 1 && !values[1].IsNil() {
+		decoder := json.NewDecoder(strings.NewReader(values[1].Text()))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&ruleset); err != nil {
+			c.ResultError(errors.Wrap(err, "msg_lint: invalid ruleset"))
+			return
+		}
+	}
+
+	js, err := json.Marshal(lintMessage(values[0].Text(), ruleset))
+	if err != nil {
+		c.ResultError(err)
+		return
+	}
+	c.ResultText(string(js))
+}
@@ -0,0 +1,49 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestCommitMessage(t *testing.T) {
+	type test struct {
+		query    string
+		expected string
+	}
+	tests := []test{
+		{query: `SELECT msg_subject('Fix the parser' || char(10) || char(10) || 'It failed on empty files.')`, expected: "Fix the parser"},
+		{query: `SELECT msg_subject('Fix the parser' || char(10) || 'of sql files')`, expected: "Fix the parser of sql files"},
+		{query: `SELECT msg_body('Fix the parser' || char(10) || char(10) || 'It failed on empty files.' || char(10))`, expected: "It failed on empty files."},
+		{query: `SELECT msg_body('Fix the parser')`, expected: ""},
+		{query: `SELECT msg_subject_length('Fix the parsér' || char(10) || char(10) || 'Body')`, expected: "14"},
+		{query: `SELECT msg_subject(NULL)`, expected: "NULL"},
+
+		{query: `SELECT msg_lint('Fix the parser')`, expected: "[]"},
+		{query: `SELECT msg_lint('fix(parser)!: handle empty files')`, expected: "[]"},
+		{query: `SELECT msg_lint('Added a parser.')`, expected: `[{"rule":"subject_imperative","message":"subject should use the imperative mood (\"add\" rather than \"Added\")"},{"rule":"subject_trailing_period","message":"subject ends with a period"}]`},
+		{query: `SELECT msg_lint('feat: adding a parser')`, expected: `[{"rule":"subject_imperative","message":"subject should use the imperative mood (\"add\" rather than \"adding\")"}]`},
+		{query: `SELECT msg_lint('Fix the parser of sql files', '{"subject_max_length": 20}')`, expected: `[{"rule":"subject_max_length","message":"subject is 27 characters long, more than 20"}]`},
+		{query: `SELECT msg_lint('Fixed the parser.', '{"subject_imperative": false, "subject_trailing_period": false}')`, expected: "[]"},
+		{query: `SELECT msg_lint(char(10))`, expected: `[{"rule":"subject_empty","message":"subject is empty"}]`},
+	}
+
+	for _, testCase := range tests {
+		rows, err := FixtureDatabase.Query(testCase.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rowNum, contents, err := tools.RowContent(rows)
+		if err != nil {
+			t.Fatalf("err %d at row Number %d", err, rowNum)
+		}
+		if contents[0][0] != testCase.expected {
+			t.Fatalf("expected string: %s, got %s", testCase.expected, contents[0][0])
+		}
+	}
+
+	var violations string
+	if err := FixtureDatabase.QueryRow(`SELECT msg_lint('Fix the parser', '{"max_length": 50}')`).Scan(&violations); err == nil {
+		t.Fatal("expected an unknown rule to fail")
+	}
+}
@@ -0,0 +1,163 @@
+package helpers
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+var extractRefsCols = []vtab.Column{
+	{Name: "ref", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "type", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "owner", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "repo", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "number", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "key", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "closes", Type: "BOOLEAN", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+
+	{Name: "message", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "jira_projects", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+var (
+	// references are matched in a single pass (so they're returned in order), the alternatives being
+	// owner/repo#123, GH-123, #123 and Jira keys (such as PROJ-123), in that order of precedence
+	refPattern = regexp.MustCompile(`([A-Za-z0-9][A-Za-z0-9-]*)/([A-Za-z0-9._-]+)#(\d+)|GH-(\d+)|#(\d+)|([A-Z][A-Z0-9_]+)-(\d+)`)
+
+	// closingKeyword matches a GitHub closing keyword (https://docs.github.com/en/issues/tracking-your-work-with-issues/linking-a-pull-request-to-an-issue)
+	// right before a reference
+	closingKeyword = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+$`)
+
+	// notJiraProjects are common upper case prefixes that look like Jira keys but aren't, such as UTF-8 or SHA-256.
+	// They're only excluded when the Jira projects aren't supplied.
+	notJiraProjects = map[string]bool{"UTF": true, "SHA": true, "ISO": true, "RFC": true, "CVE": true, "GHSA": true, "HTTP": true, "TLS": true, "SSL": true}
+)
+
+// NewExtractRefsModule returns the implementation of a table-valued-function extracting the references to issues and pull requests
+// (#123, GH-123 and owner/repo#123) and Jira issues (such as PROJ-123) found in a message, such as a commit message.
+// Jira matches can be restricted to a comma-separated list of project keys, to avoid false positives.
+func NewExtractRefsModule() sqlite.Module {
+	return vtab.NewTableFunc("extract_refs", extractRefsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var message, projects string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 7:
+					message = constraint.Value.Text()
+				case 8:
+					projects = constraint.Value.Text()
+				}
+			}
+		}
+
+		var jiraProjects map[string]bool
+		if projects != "" {
+			jiraProjects = make(map[string]bool)
+			for _, p := range strings.Split(projects, ",") {
+				jiraProjects[strings.TrimSpace(p)] = true
+			}
+		}
+
+		return &extractRefsIter{refs: extractRefs(message, jiraProjects), index: -1}, nil
+	})
+}
+
+type reference struct {
+	ref, typ    string
+	owner, repo string
+	number      int
+	key         string
+	closes      bool
+}
+
+// extractRefs returns the references found in message. If jiraProjects is set, only Jira keys of those projects are matched.
+func extractRefs(message string, jiraProjects map[string]bool) []*reference {
+	var refs []*reference
+	for _, m := range refPattern.FindAllStringSubmatchIndex(message, -1) {
+		start, end := m[0], m[1]
+
+		// references must stand on their own, and not be part of a longer word (or url, or html entity)
+		before, _ := utf8.DecodeLastRuneInString(message[:start])
+		after, _ := utf8.DecodeRuneInString(message[end:])
+		if isWordRune(before) || before == '/' || before == '&' || isWordRune(after) {
+			continue
+		}
+
+		var ref = &reference{ref: message[start:end], typ: "issue"}
+		group := func(n int) string { return message[m[2*n]:m[2*n+1]] }
+		switch {
+		case m[2] >= 0: // owner/repo#123
+			ref.owner, ref.repo = group(1), group(2)
+			ref.number, _ = strconv.Atoi(group(3))
+		case m[8] >= 0: // GH-123
+			ref.number, _ = strconv.Atoi(group(4))
+		case m[10] >= 0: // #123
+			ref.number, _ = strconv.Atoi(group(5))
+		default: // PROJ-123
+			project := group(6)
+			if (jiraProjects != nil && !jiraProjects[project]) || (jiraProjects == nil && notJiraProjects[project]) {
+				continue
+			}
+			ref.typ, ref.key = "jira", ref.ref
+			ref.number, _ = strconv.Atoi(group(7))
+		}
+
+		ref.closes = ref.typ == "issue" && closingKeyword.MatchString(message[:start])
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+type extractRefsIter struct {
+	refs  []*reference
+	index int
+}
+
+func (i *extractRefsIter) Column(ctx vtab.Context, c int) error {
+	ref := i.refs[i.index]
+	switch c {
+	case 0:
+		ctx.ResultText(ref.ref)
+	case 1:
+		ctx.ResultText(ref.typ)
+	case 2:
+		if ref.owner != "" {
+			ctx.ResultText(ref.owner)
+		}
+	case 3:
+		if ref.repo != "" {
+			ctx.ResultText(ref.repo)
+		}
+	case 4:
+		ctx.ResultInt(ref.number)
+	case 5:
+		if ref.key != "" {
+			ctx.ResultText(ref.key)
+		}
+	case 6:
+		if ref.closes {
+			ctx.ResultInt(1)
+		} else {
+			ctx.ResultInt(0)
+		}
+	}
+	return nil
+}
+
+func (i *extractRefsIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.refs) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
@@ -0,0 +1,55 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestExtractRefs(t *testing.T) {
+	message := "fix: handle empty repos (#42)\n\nFixes mergestat/mergestat-lite#7, see GH-8 and PROJ-123.\nUTF-8 and &#39; aren't references, nor is http://example.com/#9"
+
+	rows, err := FixtureDatabase.Query("SELECT ref, type, owner, repo, number, key, closes FROM extract_refs(?)", message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var refs []string
+	for _, row := range contents {
+		refs = append(refs, strings.Join(row, "|"))
+	}
+
+	expected := []string{
+		"#42|issue|NULL|NULL|42|NULL|0",
+		"mergestat/mergestat-lite#7|issue|mergestat|mergestat-lite|7|NULL|1",
+		"GH-8|issue|NULL|NULL|8|NULL|0",
+		"PROJ-123|jira|NULL|NULL|123|PROJ-123|0",
+	}
+
+	if strings.Join(refs, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(refs, "\n"))
+	}
+}
+
+func TestExtractRefsJiraProjects(t *testing.T) {
+	rows, err := FixtureDatabase.Query("SELECT ref FROM extract_refs('PROJ-1 OTHER-2 UTF-8', 'OTHER,UTF')")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// when projects are supplied, only their keys are matched
+	if len(contents) != 2 || contents[0][0] != "OTHER-2" || contents[1][0] != "UTF-8" {
+		t.Fatalf("expected OTHER-2 and UTF-8, got %v", contents)
+	}
+}
@@ -0,0 +1,54 @@
+package helpers
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/mergestat/mergestat-lite/pkg/glob"
+	"go.riyazali.net/sqlite"
+)
+
+// GlobMatch implements the GLOB_MATCH(pattern, text) sql function, which reports whether text matches the glob pattern,
+// where * doesn't match a /, but ** does (as in feature/** matching feature/api/login), and {a,b} matches either alternative.
+// Unlike sqlite's GLOB operator, it's suited to match git refs and paths. As the function is typically called with
+// the same pattern for every row, the last compiled pattern is kept.
+type GlobMatch struct {
+	mu      sync.Mutex
+	pattern string
+	re      *regexp.Regexp
+}
+
+func (*GlobMatch) Args() int           { return 2 }
+func (*GlobMatch) Deterministic() bool { return true }
+func (fn *GlobMatch) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	if values[0].IsNil() || values[1].IsNil() {
+		c.ResultNull()
+		return
+	}
+
+	re, err := fn.compile(values[0].Text())
+	if err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	if re.MatchString(values[1].Text()) {
+		c.ResultInt(1)
+	} else {
+		c.ResultInt(0)
+	}
+}
+
+func (fn *GlobMatch) compile(pattern string) (*regexp.Regexp, error) {
+	fn.mu.Lock()
+	defer fn.mu.Unlock()
+
+	if fn.re == nil || fn.pattern != pattern {
+		re, err := glob.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		fn.pattern, fn.re = pattern, re
+	}
+	return fn.re, nil
+}
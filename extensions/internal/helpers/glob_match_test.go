@@ -0,0 +1,39 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestGlobMatch(t *testing.T) {
+	type test struct {
+		query    string
+		expected string
+	}
+	tests := []test{
+		{query: `SELECT glob_match('release/*', 'release/v1.2')`, expected: "1"},
+		{query: `SELECT glob_match('release/*', 'release/v1/hotfix')`, expected: "0"},
+		{query: `SELECT glob_match('{feature,bugfix}/**', 'feature/api/login')`, expected: "1"},
+		{query: `SELECT glob_match('release/*', NULL)`, expected: "NULL"},
+	}
+
+	for _, testCase := range tests {
+		rows, err := FixtureDatabase.Query(testCase.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rowNum, contents, err := tools.RowContent(rows)
+		if err != nil {
+			t.Fatalf("err %d at row Number %d", err, rowNum)
+		}
+		if contents[0][0] != testCase.expected {
+			t.Fatalf("expected string: %s, got %s", testCase.expected, contents[0][0])
+		}
+	}
+
+	var match int
+	if err := FixtureDatabase.QueryRow(`SELECT glob_match('release/[0-9', 'release/1')`).Scan(&match); err == nil {
+		t.Fatal("expected an invalid pattern to fail")
+	}
+}
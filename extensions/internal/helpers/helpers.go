@@ -7,7 +7,17 @@ import (
 )
 
 // Register registers helpers as a SQLite extension
-func Register(ext *sqlite.ExtensionApi, _ *options.Options) (_ sqlite.ErrorCode, err error) {
+func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
+	botAuthors, err := botAuthorPatterns(opt)
+	if err != nil {
+		return sqlite.SQLITE_ERROR, err
+	}
+
+	orgMapping, err := readOrgMapping(opt)
+	if err != nil {
+		return sqlite.SQLITE_ERROR, err
+	}
+
 	var fns = map[string]sqlite.Function{
 		"str_split":    &StringSplit{},
 		"toml_to_json": &TomlToJson{},
@@ -15,6 +25,15 @@ func Register(ext *sqlite.ExtensionApi, _ *options.Options) (_ sqlite.ErrorCode,
 		"xml_to_json":  &XmlToJson{},
 		"time_diff":    &TimeDiff{},
 		"approx_dur":   &ApproxDuration{},
+		"regexp":       &Regexp{},
+		"glob_match":   &GlobMatch{},
+
+		"msg_subject":        &MsgSubject{},
+		"msg_body":           &MsgBody{},
+		"msg_subject_length": &MsgSubjectLength{},
+		"msg_lint":           &MsgLint{},
+		"is_bot_author":      &IsBotAuthor{extra: botAuthors},
+		"org_of":             &OrgOf{mapping: orgMapping},
 	}
 
 	// alias yaml_to_json => yml_to_json
@@ -27,14 +46,24 @@ func Register(ext *sqlite.ExtensionApi, _ *options.Options) (_ sqlite.ErrorCode,
 	}
 
 	var modules = map[string]sqlite.Module{
-		"grep":      NewGrepModule(),
-		"str_split": NewStrSplitModule(),
+		"grep":         NewGrepModule(),
+		"str_split":    NewStrSplitModule(),
+		"extract_refs": NewExtractRefsModule(),
+		"read_csv":     NewReadCSVModule(opt),
+		"read_json":    NewReadJSONModule(opt),
 	}
 
 	for name, mod := range modules {
-		if err = ext.CreateModule(name, mod); err != nil {
+		var registered = mod
+		if opt != nil {
+			registered = opt.Stats.Module(name, mod)
+		}
+		if err = ext.CreateModule(name, registered); err != nil {
 			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q module", name)
 		}
+		if opt != nil {
+			opt.Modules.Add("helpers", name, mod)
+		}
 	}
 
 	return sqlite.SQLITE_OK, nil
@@ -0,0 +1,102 @@
+package helpers
+
+import (
+	"os"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// OrgMapping maps emails to the organizations they belong to, by domain (covering its subdomains too),
+// with overrides for single emails (such as employees contributing with a personal email). For instance:
+//
+//	domains:
+//	  example.com: Example
+//	overrides:
+//	  jane@gmail.com: Example
+type OrgMapping struct {
+	Domains   map[string]string `json:"domains"`
+	Overrides map[string]string `json:"overrides"`
+}
+
+// readOrgMapping reads the mapping file (YAML or JSON) set in the orgMapping key of the context of opt, if any
+func readOrgMapping(opt *options.Options) (*OrgMapping, error) {
+	if opt == nil || opt.Context["orgMapping"] == "" {
+		return nil, nil
+	}
+
+	var path = opt.Context["orgMapping"]
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read organization mapping %q", path)
+	}
+
+	var mapping OrgMapping
+	if err = yaml.Unmarshal(contents, &mapping); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse organization mapping %q", path)
+	}
+	return mapping.normalize(), nil
+}
+
+// normalize lowercases the emails and domains of m, for them to be matched case-insensitively
+func (m *OrgMapping) normalize() *OrgMapping {
+	var normalized = &OrgMapping{Domains: make(map[string]string), Overrides: make(map[string]string)}
+	for domain, org := range m.Domains {
+		normalized.Domains[strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "@")] = org
+	}
+	for email, org := range m.Overrides {
+		normalized.Overrides[strings.ToLower(strings.TrimSpace(email))] = org
+	}
+	return normalized
+}
+
+// org returns the organization email belongs to, if any: the one of its override, or of its domain, or of the closest
+// parent domain (so that example.com covers eng.example.com)
+func (m *OrgMapping) org(email string) (string, bool) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if org, ok := m.Overrides[email]; ok {
+		return org, true
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return "", false
+	}
+	for domain := email[at+1:]; domain != ""; {
+		if org, ok := m.Domains[domain]; ok {
+			return org, true
+		}
+		_, domain, _ = strings.Cut(domain, ".")
+	}
+	return "", false
+}
+
+// OrgOf implements the ORG_OF(email) sql function, which returns the organization an email belongs to, according to
+// the mapping file set in the orgMapping key of the context (see OrgMapping), or NULL if it belongs to none
+// (such as for community contributions)
+type OrgOf struct {
+	mapping *OrgMapping
+}
+
+func (*OrgOf) Args() int           { return 1 }
+func (*OrgOf) Deterministic() bool { return true }
+func (fn *OrgOf) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	if fn.mapping == nil {
+		c.ResultError(errors.New("no organization mapping is configured"))
+		return
+	}
+
+	if values[0].IsNil() {
+		c.ResultNull()
+		return
+	}
+
+	if org, ok := fn.mapping.org(values[0].Text()); ok {
+		c.ResultText(org)
+	} else {
+		c.ResultNull()
+	}
+}
@@ -0,0 +1,48 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/extensions/services"
+)
+
+func TestOrgOf(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orgs.yaml")
+	mapping := "domains:\n  Example.com: Example\n  acme.io: Acme\noverrides:\n  jane@gmail.com: Example\n"
+	if err := os.WriteFile(path, []byte(mapping), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := readOrgMapping(&options.Options{Context: services.Context{"orgMapping": path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"bob@example.com":      "Example",
+		"bob@eng.EXAMPLE.com":  "Example",
+		"Jane@gmail.com":       "Example",
+		"wile@acme.io":         "Acme",
+		"john@gmail.com":       "",
+		"bob@notexample.com":   "",
+		"not an email address": "",
+	}
+	for email, expected := range tests {
+		if org, _ := m.org(email); org != expected {
+			t.Fatalf("expected %q for %s, got: %q", expected, email, org)
+		}
+	}
+
+	if _, err = readOrgMapping(&options.Options{Context: services.Context{"orgMapping": filepath.Join(t.TempDir(), "missing.yaml")}}); err == nil {
+		t.Fatal("expected a missing mapping file to fail")
+	}
+
+	// without a mapping, the function fails rather than report every email as belonging to no organization
+	var org string
+	if err = FixtureDatabase.QueryRow("SELECT org_of('bob@example.com')").Scan(&org); err == nil {
+		t.Fatal("expected org_of to fail without a mapping")
+	}
+}
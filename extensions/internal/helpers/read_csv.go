@@ -0,0 +1,155 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var readCSVCols = []vtab.Column{
+	{Name: "line_no", Type: "INT", OrderBy: vtab.NONE},
+	{Name: "record", Type: "JSON", OrderBy: vtab.NONE},
+
+	{Name: "source", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "header", Type: "BOOLEAN", Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "delimiter", Type: "TEXT", Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "infer_types", Type: "BOOLEAN", Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// NewReadCSVModule returns the implementation of a table-valued-function reading a CSV file (or URL), with a row per record,
+// as a JSON object keyed by the names in the header (or c1, c2... if header is 0, or for fields past the header). Values
+// that look like numbers or booleans are typed as such (unless infer_types is 0), and empty ones are null, so that
+// json_extract(record, '$.name') returns values that compare the way they would in a table.
+func NewReadCSVModule(opt *options.Options) sqlite.Module {
+	src := newFileSource(opt)
+	return vtab.NewTableFunc("read_csv", readCSVCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var source string
+		var header, inferTypes = true, true
+		var delimiter = ','
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 2:
+					source = constraint.Value.Text()
+				case 3:
+					header = constraint.Value.Int() != 0
+				case 4:
+					d := constraint.Value.Text()
+					if d == `\t` {
+						d = "\t"
+					}
+					if utf8.RuneCountInString(d) != 1 {
+						return nil, errors.Errorf("invalid delimiter %q, expected a single character", d)
+					}
+					delimiter, _ = utf8.DecodeRuneInString(d)
+				case 5:
+					inferTypes = constraint.Value.Int() != 0
+				}
+			}
+		}
+
+		if source == "" {
+			return nil, errors.New("read_csv requires a path or URL")
+		}
+
+		contents, err := src.read(source)
+		if err != nil {
+			return nil, err
+		}
+
+		r := csv.NewReader(bytes.NewReader(contents))
+		r.Comma = delimiter
+		r.FieldsPerRecord = -1
+		r.ReuseRecord = true
+
+		iter := &readCSVIter{reader: r, inferTypes: inferTypes}
+		if header {
+			names, err := r.Read()
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			iter.names = append([]string(nil), names...)
+		}
+		return iter, nil
+	})
+}
+
+type readCSVIter struct {
+	reader     *csv.Reader
+	names      []string // names of the fields, from the header
+	inferTypes bool
+
+	lineNo int
+	record []string
+}
+
+func (i *readCSVIter) Column(ctx vtab.Context, c int) error {
+	switch c {
+	case 0:
+		ctx.ResultInt(i.lineNo)
+	case 1:
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+		for f, value := range i.record {
+			if f > 0 {
+				buf.WriteByte(',')
+			}
+			name, _ := json.Marshal(i.fieldName(f))
+			buf.Write(name)
+			buf.WriteByte(':')
+			buf.WriteString(i.jsonValue(value))
+		}
+		buf.WriteByte('}')
+		ctx.ResultText(buf.String())
+	}
+	return nil
+}
+
+func (i *readCSVIter) Next() (vtab.Row, error) {
+	record, err := i.reader.Read()
+	if err != nil {
+		return nil, err // including io.EOF
+	}
+	i.lineNo++
+	i.record = record
+	return i, nil
+}
+
+// fieldName returns the name of the field at index f, from the header if there is one
+func (i *readCSVIter) fieldName(f int) string {
+	if f < len(i.names) && strings.TrimSpace(i.names[f]) != "" {
+		return i.names[f]
+	}
+	return "c" + strconv.Itoa(f+1)
+}
+
+// jsonNumber matches the numbers that are written as is in JSON (excluding ones with leading zeros, such as zip codes)
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// jsonValue returns value encoded as JSON, typed as a number, a boolean or null if it looks like one (and types are inferred)
+func (i *readCSVIter) jsonValue(value string) string {
+	if i.inferTypes {
+		switch {
+		case value == "":
+			return "null"
+		case jsonNumber.MatchString(value):
+			return value
+		case strings.EqualFold(value, "true"):
+			return "true"
+		case strings.EqualFold(value, "false"):
+			return "false"
+		}
+	}
+	js, _ := json.Marshal(value)
+	return string(js)
+}
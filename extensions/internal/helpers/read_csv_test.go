@@ -0,0 +1,68 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestReadCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "owners.csv")
+	contents := "team,zip,headcount,ratio,active,notes\nplatform,02139,12,0.5,true,\n\"data, infra\",94107,3,1e2,FALSE,\"says \"\"hi\"\"\"\n"
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := FixtureDatabase.Query("SELECT line_no, record FROM read_csv(?)", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := 2; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	if lineNo, record := content[0][0], content[0][1]; lineNo != "1" ||
+		record != `{"team":"platform","zip":"02139","headcount":12,"ratio":0.5,"active":true,"notes":null}` {
+		t.Fatalf("unexpected record: %v", content[0])
+	}
+
+	if record := content[1][1]; record != `{"team":"data, infra","zip":94107,"headcount":3,"ratio":1e2,"active":false,"notes":"says \"hi\""}` {
+		t.Fatalf("unexpected record: %v", content[1])
+	}
+
+	// values are typed, so they compare as numbers
+	var total int
+	if err := FixtureDatabase.QueryRow("SELECT sum(json_extract(record, '$.headcount')) FROM read_csv(?) WHERE json_extract(record, '$.headcount') > 5", path).Scan(&total); err != nil {
+		t.Fatal(err)
+	}
+	if total != 12 {
+		t.Fatalf("expected a headcount of 12, got: %d", total)
+	}
+}
+
+func TestReadCSVOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "owners.tsv")
+	if err := os.WriteFile(path, []byte("platform\t12\textra\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := FixtureDatabase.Query(`SELECT record FROM read_csv(?, 0, '\t', 0)`, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(content) != 1 || content[0][0] != `{"c1":"platform","c2":"12","c3":"extra"}` {
+		t.Fatalf("unexpected records: %v", content)
+	}
+}
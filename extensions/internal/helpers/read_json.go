@@ -0,0 +1,86 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var readJSONCols = []vtab.Column{
+	{Name: "key", Type: "INT", OrderBy: vtab.NONE},
+	{Name: "value", Type: "JSON", OrderBy: vtab.NONE},
+
+	{Name: "source", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// NewReadJSONModule returns the implementation of a table-valued-function reading a JSON file (or URL), with a row per element
+// if it holds an array, per value if it holds a sequence of them (such as newline delimited JSON), or a single row otherwise.
+// Like with json_each, key is the index of the element (starting at 0), and value the element itself.
+func NewReadJSONModule(opt *options.Options) sqlite.Module {
+	src := newFileSource(opt)
+	return vtab.NewTableFunc("read_json", readJSONCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var source string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 2 {
+				source = constraint.Value.Text()
+			}
+		}
+
+		if source == "" {
+			return nil, errors.New("read_json requires a path or URL")
+		}
+
+		contents, err := src.read(source)
+		if err != nil {
+			return nil, err
+		}
+
+		iter := &readJSONIter{decoder: json.NewDecoder(bytes.NewReader(contents)), key: -1}
+
+		// step into a top-level array, to list its elements rather than the array itself
+		if trimmed := bytes.TrimSpace(contents); len(trimmed) > 0 && trimmed[0] == '[' {
+			if _, err := iter.decoder.Token(); err != nil {
+				return nil, err
+			}
+		}
+
+		return iter, nil
+	})
+}
+
+type readJSONIter struct {
+	decoder *json.Decoder
+
+	key   int
+	value json.RawMessage
+}
+
+func (i *readJSONIter) Column(ctx vtab.Context, c int) error {
+	switch c {
+	case 0:
+		ctx.ResultInt(i.key)
+	case 1:
+		ctx.ResultText(string(i.value))
+	}
+	return nil
+}
+
+func (i *readJSONIter) Next() (vtab.Row, error) {
+	if !i.decoder.More() {
+		return nil, io.EOF
+	}
+
+	var value json.RawMessage
+	if err := i.decoder.Decode(&value); err != nil {
+		return nil, errors.Wrapf(err, "could not decode JSON value %d", i.key+1)
+	}
+
+	i.key++
+	i.value = value
+	return i, nil
+}
@@ -0,0 +1,40 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestReadJSON(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"array.json":  `[{"service": "checkout", "tier": 1}, {"service": "search", "tier": 2}]`,
+		"lines.jsonl": "{\"service\": \"checkout\", \"tier\": 1}\n{\"service\": \"search\", \"tier\": 2}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"array.json", "lines.jsonl"} {
+		rows, err := FixtureDatabase.Query("SELECT key, json_extract(value, '$.service'), json_extract(value, '$.tier') FROM read_json(?)", filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, content, err := tools.RowContent(rows)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := 2; len(content) != expected {
+			t.Fatalf("%s: expected %d rows, got: %d", name, expected, len(content))
+		}
+
+		if key, service, tier := content[1][0], content[1][1], content[1][2]; key != "1" || service != "search" || tier != "2" {
+			t.Fatalf("%s: unexpected row: %v", name, content[1])
+		}
+	}
+}
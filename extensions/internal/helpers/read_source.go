@@ -0,0 +1,50 @@
+package helpers
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/pkg/errors"
+)
+
+// fileSource reads the files (or URLs) the read_csv and read_json tables are supplied with
+type fileSource struct {
+	client *http.Client
+	ctx    options.QueryContextFunc
+}
+
+// newFileSource returns a fileSource making requests the way the API backed modules do (through the HTTPTransport, with
+// retries), and with the context of the query being run, if opt is set
+func newFileSource(opt *options.Options) *fileSource {
+	if opt == nil {
+		return &fileSource{client: http.DefaultClient}
+	}
+	return &fileSource{client: &http.Client{Transport: opt.RetryTransport(opt.HTTPTransport)}, ctx: opt.QueryContext}
+}
+
+// read returns the contents of pathOrURL, requested with GET if it's an http(s) URL, read from disk otherwise
+func (src *fileSource) read(pathOrURL string) ([]byte, error) {
+	if !strings.HasPrefix(pathOrURL, "http://") && !strings.HasPrefix(pathOrURL, "https://") {
+		return os.ReadFile(pathOrURL)
+	}
+
+	req, err := http.NewRequestWithContext(src.ctx.Context(), http.MethodGet, pathOrURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := src.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("could not fetch %s: %s", pathOrURL, res.Status)
+	}
+
+	return io.ReadAll(res.Body)
+}
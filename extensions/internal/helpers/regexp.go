@@ -0,0 +1,52 @@
+package helpers
+
+import (
+	"regexp"
+	"sync"
+
+	"go.riyazali.net/sqlite"
+)
+
+// Regexp implements the REGEXP(pattern, value) sql function, which backs the X REGEXP Y operator of sqlite
+// (matching X against the pattern Y, using the syntax of Go's regexp package). As the function is typically called
+// with the same pattern for every row, the last compiled pattern is kept.
+type Regexp struct {
+	mu      sync.Mutex
+	pattern string
+	re      *regexp.Regexp
+}
+
+func (*Regexp) Args() int           { return 2 }
+func (*Regexp) Deterministic() bool { return true }
+func (fn *Regexp) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	if values[0].IsNil() || values[1].IsNil() {
+		c.ResultNull()
+		return
+	}
+
+	re, err := fn.compile(values[0].Text())
+	if err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	if re.MatchString(values[1].Text()) {
+		c.ResultInt(1)
+	} else {
+		c.ResultInt(0)
+	}
+}
+
+func (fn *Regexp) compile(pattern string) (*regexp.Regexp, error) {
+	fn.mu.Lock()
+	defer fn.mu.Unlock()
+
+	if fn.re == nil || fn.pattern != pattern {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		fn.pattern, fn.re = pattern, re
+	}
+	return fn.re, nil
+}
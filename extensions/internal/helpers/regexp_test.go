@@ -0,0 +1,39 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestRegexp(t *testing.T) {
+	type test struct {
+		query    string
+		expected string
+	}
+	tests := []test{
+		{query: `SELECT 'fix: a bug' REGEXP '^fix'`, expected: "1"},
+		{query: `SELECT 'feat: a feature' REGEXP '^fix'`, expected: "0"},
+		{query: `SELECT regexp('(?i)BUG', 'fix: a bug')`, expected: "1"},
+		{query: `SELECT NULL REGEXP 'bug'`, expected: "NULL"},
+	}
+
+	for _, testCase := range tests {
+		rows, err := FixtureDatabase.Query(testCase.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rowNum, contents, err := tools.RowContent(rows)
+		if err != nil {
+			t.Fatalf("err %d at row Number %d", err, rowNum)
+		}
+		if contents[0][0] != testCase.expected {
+			t.Fatalf("expected string: %s, got %s", testCase.expected, contents[0][0])
+		}
+	}
+
+	var match int
+	if err := FixtureDatabase.QueryRow(`SELECT 'bug' REGEXP '('`).Scan(&match); err == nil {
+		t.Fatal("expected an invalid pattern to fail")
+	}
+}
@@ -0,0 +1,34 @@
+package httpget
+
+import (
+	"go.riyazali.net/sqlite"
+)
+
+// Get implements the http_get(url) function, returning the body of the response to a GET request to url,
+// or NULL if the request didn't succeed (such as with a 404)
+type Get struct {
+	*Fetcher
+}
+
+func (f *Get) Args() int           { return 1 }
+func (f *Get) Deterministic() bool { return false }
+func (f *Get) Apply(ctx *sqlite.Context, values ...sqlite.Value) {
+	if values[0].IsNil() {
+		ctx.ResultNull()
+		return
+	}
+
+	res, err := f.get(values[0].Text())
+	if err != nil {
+		ctx.ResultError(err)
+		return
+	}
+
+	if !res.ok() {
+		f.logger.Warn().Int("status", res.status).Msgf("GET request to %s failed", values[0].Text())
+		ctx.ResultNull()
+		return
+	}
+
+	ctx.ResultText(string(res.body))
+}
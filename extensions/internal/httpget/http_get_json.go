@@ -0,0 +1,231 @@
+package httpget
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var getJSONCols = []vtab.Column{
+	{Name: "key", Type: "TEXT", OrderBy: vtab.NONE},
+	{Name: "value", Type: "JSON", OrderBy: vtab.NONE},
+
+	{Name: "url", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "json_path", Type: "TEXT", Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// NewGetJSONModule returns the implementation of a table-valued-function listing the JSON response to a GET request to url,
+// or the value at json_path in it (such as $.items or $.data[0].members), like json_each does: a row per element of an
+// array (keyed by index), per member of an object (keyed by name), or a single row (with a NULL key) for any other value.
+// No rows are listed if the request doesn't succeed, or if there's nothing at json_path.
+func NewGetJSONModule(fetcher *Fetcher) sqlite.Module {
+	return vtab.NewTableFunc("http_get_json", getJSONCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var url, path string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 2:
+					url = constraint.Value.Text()
+				case 3:
+					path = constraint.Value.Text()
+				}
+			}
+		}
+
+		if url == "" {
+			return nil, errors.New("http_get_json requires a url")
+		}
+
+		segments, err := parseJSONPath(path)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := fetcher.get(url)
+		if err != nil {
+			return nil, err
+		}
+
+		iter := &getJSONIter{current: -1}
+		if !res.ok() {
+			fetcher.logger.Warn().Int("status", res.status).Msgf("GET request to %s failed", url)
+			return iter, nil
+		}
+
+		value, err := lookup(json.RawMessage(res.body), segments)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read the JSON response from %s", url)
+		}
+		if value != nil {
+			iter.keys, iter.values, err = entries(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not read the JSON response from %s", url)
+			}
+		}
+
+		return iter, nil
+	})
+}
+
+type getJSONIter struct {
+	keys    []interface{} // int indexes or string names, or a single nil key for a value that isn't an array or object
+	values  []json.RawMessage
+	current int
+}
+
+func (i *getJSONIter) Column(ctx vtab.Context, c int) error {
+	switch c {
+	case 0:
+		switch key := i.keys[i.current].(type) {
+		case int:
+			ctx.ResultInt(key)
+		case string:
+			ctx.ResultText(key)
+		}
+	case 1:
+		ctx.ResultText(string(i.values[i.current]))
+	}
+	return nil
+}
+
+func (i *getJSONIter) Next() (vtab.Row, error) {
+	i.current++
+	if i.current >= len(i.values) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// parseJSONPath parses a path in the syntax of the SQLite JSON functions, such as $.items[0]."display name",
+// into the names of the object members (strings) and indexes of the array elements (ints) it is made of
+func parseJSONPath(path string) ([]interface{}, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "$") {
+		return nil, errors.Errorf("invalid JSON path %q, expected it to start with $", path)
+	}
+
+	var segments []interface{}
+	for rest := path[1:]; rest != ""; {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			var name string
+			if strings.HasPrefix(rest, `"`) {
+				end := strings.IndexByte(rest[1:], '"')
+				if end < 0 {
+					return nil, errors.Errorf("invalid JSON path %q, unterminated quoted name", path)
+				}
+				name, rest = rest[1:end+1], rest[end+2:]
+			} else {
+				end := strings.IndexAny(rest, ".[")
+				if end < 0 {
+					end = len(rest)
+				}
+				name, rest = rest[:end], rest[end:]
+			}
+			if name == "" {
+				return nil, errors.Errorf("invalid JSON path %q, expected a name after .", path)
+			}
+			segments = append(segments, name)
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, errors.Errorf("invalid JSON path %q, unterminated index", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, errors.Errorf("invalid JSON path %q, expected an index in brackets", path)
+			}
+			segments, rest = append(segments, index), rest[end+1:]
+		default:
+			return nil, errors.Errorf("invalid JSON path %q, expected . or [ at %q", path, rest)
+		}
+	}
+	return segments, nil
+}
+
+// lookup returns the value at the path made of segments in value, or nil if there's none
+func lookup(value json.RawMessage, segments []interface{}) (json.RawMessage, error) {
+	for _, segment := range segments {
+		value = bytes.TrimSpace(value)
+		switch segment := segment.(type) {
+		case string:
+			if len(value) == 0 || value[0] != '{' {
+				return nil, nil
+			}
+			var members map[string]json.RawMessage
+			if err := json.Unmarshal(value, &members); err != nil {
+				return nil, err
+			}
+			value = members[segment]
+		case int:
+			if len(value) == 0 || value[0] != '[' {
+				return nil, nil
+			}
+			var elements []json.RawMessage
+			if err := json.Unmarshal(value, &elements); err != nil {
+				return nil, err
+			}
+			if segment >= len(elements) {
+				return nil, nil
+			}
+			value = elements[segment]
+		}
+		if value == nil {
+			return nil, nil
+		}
+	}
+	return value, nil
+}
+
+// entries returns the keys and values of the elements of value if it's an array, of its members (in order) if it's
+// an object, or value itself with a nil key otherwise
+func entries(value json.RawMessage) ([]interface{}, []json.RawMessage, error) {
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 {
+		return nil, nil, nil
+	}
+
+	switch value[0] {
+	case '[':
+		var elements []json.RawMessage
+		if err := json.Unmarshal(value, &elements); err != nil {
+			return nil, nil, err
+		}
+		var keys = make([]interface{}, len(elements))
+		for e := range elements {
+			keys[e] = e
+		}
+		return keys, elements, nil
+	case '{':
+		// members are decoded one by one, as unmarshalling into a map would lose their order
+		dec := json.NewDecoder(bytes.NewReader(value))
+		if _, err := dec.Token(); err != nil {
+			return nil, nil, err
+		}
+		var keys []interface{}
+		var values []json.RawMessage
+		for dec.More() {
+			name, err := dec.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+			var member json.RawMessage
+			if err := dec.Decode(&member); err != nil {
+				return nil, nil, err
+			}
+			keys, values = append(keys, name), append(values, member)
+		}
+		return keys, values, nil
+	default:
+		return []interface{}{nil}, []json.RawMessage{value}, nil
+	}
+}
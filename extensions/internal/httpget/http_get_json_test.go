@@ -0,0 +1,50 @@
+package httpget_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestGetJSON(t *testing.T) {
+	for _, tc := range []struct {
+		path     string
+		expected [][]string
+	}{
+		{"$.members", [][]string{{"0", `{"login": "alice"}`}, {"1", `{"login": "bob"}`}}},
+		{"$.members[1].login", [][]string{{"NULL", `"bob"`}}},
+		{"$.lead", [][]string{{"login", `"alice"`}}},
+		{"$.missing", nil},
+	} {
+		rows, err := FixtureDatabase.Query("SELECT key, value FROM http_get_json(?, ?)", server.URL+"/team", tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, content, err := tools.RowContent(rows)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(content) != len(tc.expected) {
+			t.Fatalf("%s: expected %d rows, got: %v", tc.path, len(tc.expected), content)
+		}
+		for r, row := range tc.expected {
+			if content[r][0] != row[0] || content[r][1] != row[1] {
+				t.Fatalf("%s: expected %v, got: %v", tc.path, row, content[r])
+			}
+		}
+	}
+
+	// the whole response is listed without a path
+	rows, err := FixtureDatabase.Query("SELECT key FROM http_get_json(?)", server.URL+"/team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) != 3 || content[0][0] != "name" {
+		t.Fatalf("unexpected rows: %v", content)
+	}
+}
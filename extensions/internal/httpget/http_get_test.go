@@ -0,0 +1,37 @@
+package httpget_test
+
+import (
+	"database/sql"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	before := atomic.LoadInt32(&requests)
+
+	// the same url is only requested once
+	var body, again string
+	if err := FixtureDatabase.QueryRow("SELECT http_get(?), http_get(?)", server.URL+"/team", server.URL+"/team").Scan(&body, &again); err != nil {
+		t.Fatal(err)
+	}
+	if body != again || body == "" {
+		t.Fatalf("unexpected bodies: %q and %q", body, again)
+	}
+	if requests := atomic.LoadInt32(&requests) - before; requests != 1 {
+		t.Fatalf("expected 1 request, got: %d", requests)
+	}
+
+	// failed requests are NULL
+	var missing sql.NullString
+	if err := FixtureDatabase.QueryRow("SELECT http_get(?)", server.URL+"/missing").Scan(&missing); err != nil {
+		t.Fatal(err)
+	}
+	if missing.Valid {
+		t.Fatalf("expected NULL, got: %q", missing.String)
+	}
+
+	// responses larger than httpGetMaxBytes fail
+	if err := FixtureDatabase.QueryRow("SELECT http_get(?)", server.URL+"/large").Scan(&body); err == nil {
+		t.Fatal("expected an error for a response larger than the limit")
+	}
+}
@@ -0,0 +1,144 @@
+// Package httpget implements functions making HTTP requests from within queries, such as to enrich results
+// with the responses of internal APIs. They are only registered when explicitly allowed (see options.WithAllowHTTP).
+package httpget
+
+import (
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+// defaultMaxBytes is the size responses are limited to, unless the httpGetMaxBytes context value is set
+const defaultMaxBytes = 10 << 20
+
+// cacheMaxBytes is the size of all of the responses held in the cache, past which the oldest ones are evicted
+const cacheMaxBytes = 64 << 20
+
+// Fetcher makes GET requests, caching responses so that urls requested over and over (such as for every row of a query)
+// are only requested once
+type Fetcher struct {
+	client   *http.Client
+	maxBytes int
+	logger   *zerolog.Logger
+	// QueryContext returns the context of the query being run, which cancels requests when done
+	QueryContext options.QueryContextFunc
+
+	mu    sync.Mutex
+	cache map[string]*response
+	order []string // urls of the cached responses, oldest first
+	size  int      // size of all of the cached responses
+}
+
+type response struct {
+	status int
+	body   []byte
+}
+
+// ok reports whether the request succeeded
+func (r *response) ok() bool { return r.status >= 200 && r.status < 300 }
+
+// NewFetcher returns a Fetcher making requests with client, and failing on responses larger than maxBytes
+func NewFetcher(client *http.Client, maxBytes int, logger *zerolog.Logger) *Fetcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBytes
+	}
+	if logger == nil {
+		l := zerolog.Nop()
+		logger = &l
+	}
+	return &Fetcher{client: client, maxBytes: maxBytes, logger: logger, cache: make(map[string]*response)}
+}
+
+// get requests url, returning the cached response if it was requested already
+func (f *Fetcher) get(url string) (*response, error) {
+	f.mu.Lock()
+	res, ok := f.cache[url]
+	f.mu.Unlock()
+	if ok {
+		return res, nil
+	}
+
+	req, err := http.NewRequestWithContext(f.QueryContext.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	f.logger.Info().Msgf("making GET request: %s", url)
+
+	r, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, int64(f.maxBytes)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > f.maxBytes {
+		return nil, errors.Errorf("response from %s is larger than %d bytes", url, f.maxBytes)
+	}
+
+	res = &response{status: r.StatusCode, body: body}
+	f.store(url, res)
+	return res, nil
+}
+
+// store caches the response to url, evicting the oldest responses if the cache is full
+func (f *Fetcher) store(url string, res *response) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.cache[url]; ok || len(res.body) > cacheMaxBytes {
+		return
+	}
+
+	for f.size+len(res.body) > cacheMaxBytes && len(f.order) > 0 {
+		oldest := f.order[0]
+		f.size -= len(f.cache[oldest].body)
+		delete(f.cache, oldest)
+		f.order = f.order[1:]
+	}
+
+	f.cache[url] = res
+	f.order = append(f.order, url)
+	f.size += len(res.body)
+}
+
+// Register registers the http_get functions as a SQLite extension
+func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
+	maxBytes, _ := opt.Context.GetInt("httpGetMaxBytes")
+	fetcher := NewFetcher(&http.Client{Transport: opt.RetryTransport(opt.HTTPTransport)}, maxBytes, opt.Logger)
+	fetcher.QueryContext = opt.QueryContext
+
+	var fns = map[string]sqlite.Function{
+		"http_get": &Get{fetcher},
+	}
+
+	for name, fn := range fns {
+		if err = ext.CreateFunction(name, fn); err != nil {
+			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q function", name)
+		}
+	}
+
+	var modules = map[string]sqlite.Module{
+		"http_get_json": NewGetJSONModule(fetcher),
+	}
+
+	for name, mod := range modules {
+		if err = ext.CreateModule(name, opt.Stats.Module(name, mod)); err != nil {
+			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q module", name)
+		}
+		opt.Modules.Add("httpget", name, mod)
+	}
+
+	return sqlite.SQLITE_OK, nil
+}
@@ -0,0 +1,58 @@
+package httpget_test
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/mergestat/mergestat-lite/extensions"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
+	"go.riyazali.net/sqlite"
+)
+
+// FixtureDatabase represents the database connection to run the test against
+var FixtureDatabase *sql.DB
+
+// server serves the responses the tests request, counting requests
+var server *httptest.Server
+
+// requests is the number of requests served
+var requests int32
+
+func TestMain(m *testing.M) {
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/team":
+			fmt.Fprint(w, `{"name": "platform", "members": [{"login": "alice"}, {"login": "bob"}], "lead": {"login": "alice"}}`)
+		case "/large":
+			fmt.Fprint(w, strings.Repeat("x", 1024))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	// register sqlite extension when this package is loaded
+	sqlite.Register(extensions.RegisterFn(
+		options.WithAllowHTTP(true),
+		options.WithContextValue("httpGetMaxBytes", "512"),
+		options.WithContextValue("httpRetries", "0"),
+	))
+
+	var err error
+	if FixtureDatabase, err = sql.Open("sqlite3", "file:testing.db?mode=memory"); err != nil {
+		log.Fatalf("failed to open database connection: %v", err)
+	}
+
+	code := m.Run()
+	server.Close()
+	os.Exit(code)
+}
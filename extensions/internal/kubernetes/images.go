@@ -0,0 +1,282 @@
+package kubernetes
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/internal/sqlutil"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+// podsPerPage is the number of pods requested per page
+const podsPerPage = "500"
+
+type container struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+type containerStatus struct {
+	Name    string `json:"name"`
+	ImageID string `json:"imageID"`
+	Ready   bool   `json:"ready"`
+	State   struct {
+		Running *struct {
+			StartedAt time.Time `json:"startedAt"`
+		} `json:"running"`
+		Terminated *struct {
+			StartedAt time.Time `json:"startedAt"`
+		} `json:"terminated"`
+	} `json:"state"`
+}
+
+type pod struct {
+	Metadata struct {
+		Name            string            `json:"name"`
+		Namespace       string            `json:"namespace"`
+		Labels          map[string]string `json:"labels"`
+		OwnerReferences []struct {
+			Kind       string `json:"kind"`
+			Name       string `json:"name"`
+			Controller bool   `json:"controller"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+	Spec struct {
+		NodeName       string      `json:"nodeName"`
+		Containers     []container `json:"containers"`
+		InitContainers []container `json:"initContainers"`
+	} `json:"spec"`
+	Status struct {
+		ContainerStatuses     []containerStatus `json:"containerStatuses"`
+		InitContainerStatuses []containerStatus `json:"initContainerStatuses"`
+	} `json:"status"`
+}
+
+// workload returns the kind and name of the workload managing the pod (such as a Deployment, through its ReplicaSet),
+// or empty strings if it has no controller
+func (p *pod) workload() (string, string) {
+	for _, owner := range p.Metadata.OwnerReferences {
+		if !owner.Controller {
+			continue
+		}
+		if hash := p.Metadata.Labels["pod-template-hash"]; owner.Kind == "ReplicaSet" && strings.HasSuffix(owner.Name, "-"+hash) {
+			return "Deployment", strings.TrimSuffix(owner.Name, "-"+hash)
+		}
+		return owner.Kind, owner.Name
+	}
+	return "", ""
+}
+
+// image is a container of a pod, and the image it runs
+type image struct {
+	pod       *pod
+	container container
+	status    *containerStatus // nil if the container has no status yet
+	init      bool
+}
+
+// reference splits the image of the container into its repository, tag and digest (the one it was pulled by,
+// as reported by the container runtime, if not part of the image reference)
+func (i *image) reference() (repository, tag, digest string) {
+	repository = i.container.Image
+	if at := strings.Index(repository, "@"); at >= 0 {
+		repository, digest = repository[:at], repository[at+1:]
+	}
+	if colon := strings.LastIndex(repository, ":"); colon > strings.LastIndex(repository, "/") {
+		repository, tag = repository[:colon], repository[colon+1:]
+	} else if digest == "" {
+		tag = "latest"
+	}
+
+	// image ids are either repository digests (as in docker.io/library/nginx@sha256:...) or, for some runtimes,
+	// the digest of the image configuration, which isn't one images can be pulled by
+	if digest == "" && i.status != nil {
+		if at := strings.LastIndex(i.status.ImageID, "@"); at >= 0 {
+			digest = i.status.ImageID[at+1:]
+		}
+	}
+
+	return repository, tag, digest
+}
+
+type iterImages struct {
+	*Options
+	client    *apiClient
+	logger    *zerolog.Logger
+	namespace string
+
+	token   string // continue token of the next page of pods
+	fetched bool
+	images  []*image
+	current int
+}
+
+func (i *iterImages) Column(ctx vtab.Context, c int) error {
+	current := i.images[i.current]
+	repository, tag, digest := current.reference()
+	kind, name := current.pod.workload()
+
+	switch imageCols[c].Name {
+	case "pod_namespace":
+		ctx.ResultText(current.pod.Metadata.Namespace)
+	case "pod":
+		ctx.ResultText(current.pod.Metadata.Name)
+	case "workload_kind":
+		if kind != "" {
+			ctx.ResultText(kind)
+		}
+	case "workload_name":
+		if name != "" {
+			ctx.ResultText(name)
+		}
+	case "node":
+		ctx.ResultText(current.pod.Spec.NodeName)
+	case "container":
+		ctx.ResultText(current.container.Name)
+	case "init_container":
+		ctx.ResultInt(sqlutil.BoolToInt(current.init))
+	case "image":
+		ctx.ResultText(current.container.Image)
+	case "image_repository":
+		ctx.ResultText(repository)
+	case "image_tag":
+		if tag != "" {
+			ctx.ResultText(tag)
+		}
+	case "image_digest":
+		if digest != "" {
+			ctx.ResultText(digest)
+		}
+	case "ready":
+		ctx.ResultInt(sqlutil.BoolToInt(current.status != nil && current.status.Ready))
+	case "started_at":
+		if status := current.status; status != nil {
+			switch {
+			case status.State.Running != nil:
+				ctx.ResultText(status.State.Running.StartedAt.Format(time.RFC3339))
+			case status.State.Terminated != nil:
+				ctx.ResultText(status.State.Terminated.StartedAt.Format(time.RFC3339))
+			}
+		}
+	}
+	return nil
+}
+
+func (i *iterImages) Next() (vtab.Row, error) {
+	i.current += 1
+
+	for i.current >= len(i.images) {
+		if i.fetched && i.token == "" {
+			return nil, io.EOF
+		}
+
+		i.logger.Info().Msgf("fetching page of pods")
+		images, err := i.list()
+		if err != nil {
+			return nil, err
+		}
+		i.images, i.current = images, 0
+	}
+
+	return i, nil
+}
+
+// list returns the images of the containers of the next page of running pods
+func (i *iterImages) list() ([]*image, error) {
+	var params = url.Values{}
+	params.Set("fieldSelector", "status.phase=Running")
+	params.Set("limit", podsPerPage)
+	if i.token != "" {
+		params.Set("continue", i.token)
+	}
+
+	path := "/api/v1/pods"
+	if i.namespace != "" {
+		path = "/api/v1/namespaces/" + url.PathEscape(i.namespace) + "/pods"
+	}
+
+	var list struct {
+		Metadata struct {
+			Continue string `json:"continue"`
+		} `json:"metadata"`
+		Items []*pod `json:"items"`
+	}
+	if err := i.client.get(path, params, &list); err != nil {
+		return nil, err
+	}
+
+	i.fetched, i.token = true, list.Metadata.Continue
+
+	var images []*image
+	for _, p := range list.Items {
+		images = append(images, podImages(p, p.Spec.InitContainers, p.Status.InitContainerStatuses, true)...)
+		images = append(images, podImages(p, p.Spec.Containers, p.Status.ContainerStatuses, false)...)
+	}
+	return images, nil
+}
+
+// podImages returns the images of the containers of p, along with their statuses
+func podImages(p *pod, containers []container, statuses []containerStatus, init bool) []*image {
+	var images = make([]*image, len(containers))
+	for c, cont := range containers {
+		images[c] = &image{pod: p, container: cont, init: init}
+		for s := range statuses {
+			if statuses[s].Name == cont.Name {
+				images[c].status = &statuses[s]
+			}
+		}
+	}
+	return images
+}
+
+var imageCols = []vtab.Column{
+	{Name: "context", Type: "TEXT", Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "namespace", Type: "TEXT", Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "pod_namespace", Type: "TEXT"},
+	{Name: "pod", Type: "TEXT"},
+	{Name: "workload_kind", Type: "TEXT"},
+	{Name: "workload_name", Type: "TEXT"},
+	{Name: "node", Type: "TEXT"},
+	{Name: "container", Type: "TEXT"},
+	{Name: "init_container", Type: "BOOLEAN"},
+	{Name: "image", Type: "TEXT"},
+	{Name: "image_repository", Type: "TEXT"},
+	{Name: "image_tag", Type: "TEXT"},
+	{Name: "image_digest", Type: "TEXT"},
+	{Name: "ready", Type: "BOOLEAN"},
+	{Name: "started_at", Type: "DATETIME"},
+}
+
+// NewImagesModule returns the implementation of a table listing the images of the containers of the pods running in a cluster
+// (in the namespace, if supplied, else in all namespaces), along with the workload running them, and the tag and digest of
+// the image. The cluster is the one of the context of kubeconfig (the current one if not supplied), connected to with the
+// credentials kubectl would use (including exec credential plugins).
+func NewImagesModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("k8s_images", imageCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var context, namespace string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					context = constraint.Value.Text()
+				case 1:
+					namespace = constraint.Value.Text()
+				}
+			}
+		}
+
+		client, err := opts.connect(context)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not connect to Kubernetes cluster")
+		}
+
+		logger := opts.Logger.With().Str("context", context).Str("namespace", namespace).Logger()
+		logger.Info().Msgf("starting Kubernetes images iterator")
+		return &iterImages{Options: opts, client: client, logger: &logger, namespace: namespace, current: -1}, nil
+	})
+}
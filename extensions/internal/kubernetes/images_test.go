@@ -0,0 +1,67 @@
+package kubernetes_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestImages(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query(`SELECT pod_namespace, pod, workload_kind, workload_name, container, init_container, image_repository,
+		image_tag, image_digest, ready, started_at FROM k8s_images()`)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	// the containers (init ones included) of the pods of both pages
+	if expected := 5; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	// pods of a deployment are listed as such, rather than as the ones of its replica set
+	if api := content[1]; strings.Join(api, " ") != "web api-7d9f8c6b5-x2k4q Deployment api api 0 ghcr.io/mergestat/api 0.6.0 "+
+		"sha256:884c660c942cebd94de9aac6416f8de24380cf542fa5879ac5d8a9b6a64b9d73 1 2024-05-14T08:05:12Z" {
+		t.Fatalf("unexpected image: %v", api)
+	}
+
+	// images without a tag are of the latest one
+	if postgres := content[3]; postgres[6] != "postgres" || postgres[7] != "latest" ||
+		postgres[8] != "sha256:4aea012537edfad80f98d870a36e6b90b4c09b27be7f4b4759d72db863baeebb" {
+		t.Fatalf("unexpected image: %v", postgres)
+	}
+
+	// images referenced by digest have no tag
+	if debug := content[4]; debug[2] != "NULL" || debug[6] != "busybox" || debug[7] != "NULL" ||
+		debug[8] != "sha256:c3839dd800b9eb7603340509769c43e146a74c63dca3045a8e7dc8ee07e53966" {
+		t.Fatalf("unexpected image: %v", debug)
+	}
+}
+
+func TestImagesForbidden(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT * FROM k8s_images('staging', 'data')")
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+		}
+		err = rows.Err()
+	}
+
+	if err == nil || !strings.Contains(err.Error(), "403 Forbidden") {
+		t.Fatalf("expected the request to be forbidden, got: %v", err)
+	}
+}
@@ -0,0 +1,331 @@
+package kubernetes
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// kubeconfig holds the fields of kubeconfig files that clusters are connected to with,
+// as in https://kubernetes.io/docs/concepts/configuration/organize-cluster-access-kubeconfig/
+type kubeconfig struct {
+	CurrentContext string `json:"current-context"`
+	Clusters       []struct {
+		Name    string  `json:"name"`
+		Cluster cluster `json:"cluster"`
+	} `json:"clusters"`
+	Contexts []struct {
+		Name    string `json:"name"`
+		Context struct {
+			Cluster string `json:"cluster"`
+			User    string `json:"user"`
+		} `json:"context"`
+	} `json:"contexts"`
+	Users []struct {
+		Name string `json:"name"`
+		User user   `json:"user"`
+	} `json:"users"`
+}
+
+type cluster struct {
+	Server                   string `json:"server"`
+	TLSServerName            string `json:"tls-server-name"`
+	InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+	CertificateAuthority     string `json:"certificate-authority"`
+	CertificateAuthorityData []byte `json:"certificate-authority-data"`
+}
+
+type user struct {
+	ClientCertificate     string `json:"client-certificate"`
+	ClientCertificateData []byte `json:"client-certificate-data"`
+	ClientKey             string `json:"client-key"`
+	ClientKeyData         []byte `json:"client-key-data"`
+	Token                 string `json:"token"`
+	TokenFile             string `json:"tokenFile"`
+	Username              string `json:"username"`
+	Password              string `json:"password"`
+	Exec                  *struct {
+		APIVersion string   `json:"apiVersion"`
+		Command    string   `json:"command"`
+		Args       []string `json:"args"`
+		Env        []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"env"`
+	} `json:"exec"`
+	AuthProvider *struct {
+		Name string `json:"name"`
+	} `json:"auth-provider"`
+}
+
+// loadKubeconfig loads the kubeconfig files kubectl would, the ones listed in KUBECONFIG or ~/.kube/config,
+// merged as kubectl does (the first file to set a value wins). Relative paths are resolved against the file they're in.
+func loadKubeconfig() (*kubeconfig, error) {
+	paths := filepath.SplitList(os.Getenv("KUBECONFIG"))
+	if len(paths) == 0 {
+		home, _ := os.UserHomeDir()
+		paths = []string{filepath.Join(home, ".kube", "config")}
+	}
+
+	var merged = &kubeconfig{}
+	var found bool
+	for _, path := range paths {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		found = true
+
+		var config kubeconfig
+		if err = yaml.Unmarshal(contents, &config); err != nil {
+			return nil, errors.Wrapf(err, "could not read kubeconfig %s", path)
+		}
+
+		resolve := func(p *string) {
+			if *p != "" && !filepath.IsAbs(*p) {
+				*p = filepath.Join(filepath.Dir(path), *p)
+			}
+		}
+		for c := range config.Clusters {
+			resolve(&config.Clusters[c].Cluster.CertificateAuthority)
+		}
+		for u := range config.Users {
+			resolve(&config.Users[u].User.ClientCertificate)
+			resolve(&config.Users[u].User.ClientKey)
+			resolve(&config.Users[u].User.TokenFile)
+		}
+
+		if merged.CurrentContext == "" {
+			merged.CurrentContext = config.CurrentContext
+		}
+		merged.Clusters = append(merged.Clusters, config.Clusters...)
+		merged.Contexts = append(merged.Contexts, config.Contexts...)
+		merged.Users = append(merged.Users, config.Users...)
+	}
+
+	if !found {
+		return nil, errors.Errorf("no kubeconfig found in %s", strings.Join(paths, string(os.PathListSeparator)))
+	}
+	return merged, nil
+}
+
+// apiClient makes requests to the API of a cluster, with the credentials of the user of a context
+type apiClient struct {
+	*Options
+	server string
+	client *http.Client
+
+	authorization string // value of the Authorization header requests are made with, if any
+}
+
+// connect returns a client for the cluster of the named context of kubeconfig (the current one if empty)
+func (o *Options) connect(name string) (*apiClient, error) {
+	config, err := loadKubeconfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		if name = config.CurrentContext; name == "" {
+			return nil, errors.New("no context supplied, and no current context set in kubeconfig")
+		}
+	}
+
+	var clusterName, userName string
+	var contextFound bool
+	for _, c := range config.Contexts {
+		if c.Name == name {
+			clusterName, userName, contextFound = c.Context.Cluster, c.Context.User, true
+			break
+		}
+	}
+	if !contextFound {
+		return nil, errors.Errorf("no context %q in kubeconfig", name)
+	}
+
+	var cl *cluster
+	for c := range config.Clusters {
+		if config.Clusters[c].Name == clusterName {
+			cl = &config.Clusters[c].Cluster
+			break
+		}
+	}
+	if cl == nil || cl.Server == "" {
+		return nil, errors.Errorf("no cluster %q in kubeconfig (of context %q)", clusterName, name)
+	}
+
+	var u = &user{}
+	for c := range config.Users {
+		if config.Users[c].Name == userName {
+			u = &config.Users[c].User
+			break
+		}
+	}
+
+	tlsConfig := &tls.Config{ServerName: cl.TLSServerName, InsecureSkipVerify: cl.InsecureSkipTLSVerify}
+	if ca, err := readData(cl.CertificateAuthorityData, cl.CertificateAuthority); err != nil {
+		return nil, errors.Wrapf(err, "could not read the certificate authority of cluster %q", clusterName)
+	} else if len(ca) > 0 {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, errors.Errorf("invalid certificate authority of cluster %q", clusterName)
+		}
+	}
+
+	client := &apiClient{Options: o, server: strings.TrimSuffix(cl.Server, "/")}
+	if err = client.authenticate(u, tlsConfig); err != nil {
+		return nil, errors.Wrapf(err, "could not authenticate as user %q (of context %q)", userName, name)
+	}
+	client.client = o.Client(tlsConfig)
+
+	return client, nil
+}
+
+// authenticate sets up the credentials of u, as a client certificate (in tlsConfig) or in the Authorization header.
+// Credentials of exec plugins (such as the ones of EKS and GKE) are requested from the plugin.
+func (c *apiClient) authenticate(u *user, tlsConfig *tls.Config) error {
+	certificate, key := u.ClientCertificateData, u.ClientKeyData
+	token := u.Token
+
+	switch {
+	case u.Exec != nil:
+		status, err := execCredential(u)
+		if err != nil {
+			return err
+		}
+		token = status.Token
+		if status.ClientCertificateData != "" {
+			certificate, key = []byte(status.ClientCertificateData), []byte(status.ClientKeyData)
+		}
+	case u.AuthProvider != nil:
+		return errors.Errorf("unsupported auth provider %q, use an exec credential plugin instead", u.AuthProvider.Name)
+	case token == "" && u.TokenFile != "":
+		contents, err := os.ReadFile(u.TokenFile)
+		if err != nil {
+			return err
+		}
+		token = strings.TrimSpace(string(contents))
+	}
+
+	var err error
+	if certificate, err = readData(certificate, u.ClientCertificate); err != nil {
+		return err
+	}
+	if key, err = readData(key, u.ClientKey); err != nil {
+		return err
+	}
+	if len(certificate) > 0 {
+		pair, err := tls.X509KeyPair(certificate, key)
+		if err != nil {
+			return errors.Wrap(err, "invalid client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+
+	switch {
+	case token != "":
+		c.authorization = "Bearer " + token
+	case u.Username != "":
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(u.Username, u.Password)
+		c.authorization = req.Header.Get("Authorization")
+	}
+
+	return nil
+}
+
+// execStatus is the status of the ExecCredential an exec plugin outputs, as in
+// https://kubernetes.io/docs/reference/access-authn-authz/authentication/#client-go-credential-plugins
+type execStatus struct {
+	Token                 string `json:"token"`
+	ClientCertificateData string `json:"clientCertificateData"`
+	ClientKeyData         string `json:"clientKeyData"`
+}
+
+// execCredential runs the exec plugin of u, and returns the credentials it outputs
+func execCredential(u *user) (*execStatus, error) {
+	apiVersion := u.Exec.APIVersion
+	if apiVersion == "" {
+		apiVersion = "client.authentication.k8s.io/v1"
+	}
+	info, _ := json.Marshal(map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       "ExecCredential",
+		"spec":       map[string]interface{}{"interactive": false},
+	})
+
+	cmd := exec.Command(u.Exec.Command, u.Exec.Args...)
+	cmd.Env = append(os.Environ(), "KUBERNETES_EXEC_INFO="+string(info))
+	for _, env := range u.Exec.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "exec credential plugin %s failed: %s", u.Exec.Command, strings.TrimSpace(stderr.String()))
+	}
+
+	var credential struct {
+		Status execStatus `json:"status"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &credential); err != nil {
+		return nil, errors.Wrapf(err, "could not decode the output of exec credential plugin %s", u.Exec.Command)
+	}
+	return &credential.Status, nil
+}
+
+// readData returns data if set, else the contents of the file at path, if any
+func readData(data []byte, path string) ([]byte, error) {
+	if len(data) > 0 || path == "" {
+		return data, nil
+	}
+	return os.ReadFile(path)
+}
+
+// get requests the resource at path (such as /api/v1/pods) with the supplied query parameters,
+// decoding the JSON response into out
+func (c *apiClient) get(path string, params url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(c.QueryContext.Context(), http.MethodGet, c.server+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.authorization != "" {
+		req.Header.Set("Authorization", c.authorization)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		var status struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&status)
+		return errors.Errorf("Kubernetes API request to %s failed: %s: %s", path, res.Status, status.Message)
+	}
+
+	if err = json.NewDecoder(res.Body).Decode(out); err != nil {
+		return errors.Wrapf(err, "could not decode Kubernetes API response to %s", path)
+	}
+
+	return nil
+}
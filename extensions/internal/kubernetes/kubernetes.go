@@ -0,0 +1,59 @@
+// Package kubernetes implements tables querying the API of Kubernetes clusters (with the contexts of kubeconfig),
+// such as to take an inventory of the images running, and correlate them with the tags of a repository
+package kubernetes
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+type Options struct {
+	// Client returns the http client requests to a cluster are made with, with its TLS configuration
+	// (credentials are handled by the modules)
+	Client func(config *tls.Config) *http.Client
+	Logger *zerolog.Logger
+	// QueryContext returns the context of the query being run, which cancels requests when done
+	QueryContext options.QueryContextFunc
+}
+
+// Register registers the Kubernetes tables as a SQLite extension
+func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
+	k8sOpts := &Options{
+		// clusters are connected to with their own TLS configuration, unless the transport is overridden (as in tests)
+		Client: func(config *tls.Config) *http.Client {
+			transport := opt.HTTPTransport
+			if transport == nil {
+				t := http.DefaultTransport.(*http.Transport).Clone()
+				t.TLSClientConfig = config
+				transport = t
+			}
+			return &http.Client{Transport: opt.RetryTransport(transport)}
+		},
+		Logger:       opt.Logger,
+		QueryContext: opt.QueryContext,
+	}
+
+	if k8sOpts.Logger == nil {
+		l := zerolog.Nop()
+		k8sOpts.Logger = &l
+	}
+
+	var modules = map[string]sqlite.Module{
+		"k8s_images": NewImagesModule(k8sOpts),
+	}
+
+	// register Kubernetes tables
+	for name, mod := range modules {
+		if err = ext.CreateModule(name, opt.Stats.Module(name, mod)); err != nil {
+			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register Kubernetes %q module", name)
+		}
+		opt.Modules.Add("kubernetes", name, mod)
+	}
+
+	return sqlite.SQLITE_OK, nil
+}
@@ -1,13 +1,17 @@
 package npm
 
 import (
-	"context"
 	"fmt"
 
+	"github.com/mergestat/mergestat-lite/extensions/options"
 	"go.riyazali.net/sqlite"
 )
 
-type GetPackage struct{ *Client }
+type GetPackage struct {
+	*Client
+	// QueryContext returns the context of the query being run, which cancels requests when done
+	QueryContext options.QueryContextFunc
+}
 
 func (f *GetPackage) Args() int           { return -1 }
 func (f *GetPackage) Deterministic() bool { return false }
@@ -17,14 +21,14 @@ func (f *GetPackage) Apply(ctx *sqlite.Context, values ...sqlite.Value) {
 		ctx.ResultError(fmt.Errorf("expected a package name"))
 		return
 	case len(values) == 1:
-		if res, err := f.GetPackage(context.Background(), values[0].Text()); err != nil {
+		if res, err := f.GetPackage(f.QueryContext.Context(), values[0].Text()); err != nil {
 			ctx.ResultError(err)
 			return
 		} else {
 			ctx.ResultText(string(res))
 		}
 	default:
-		if res, err := f.GetPackageVersion(context.Background(), values[0].Text(), values[1].Text()); err != nil {
+		if res, err := f.GetPackageVersion(f.QueryContext.Context(), values[0].Text(), values[1].Text()); err != nil {
 			ctx.ResultError(err)
 			return
 		} else {
@@ -7,8 +7,7 @@ import (
 )
 
 func TestGetPackage(t *testing.T) {
-	cleanup := newRecorder(t)
-	defer cleanup()
+	defer transport.Start(t)()
 
 	rows, err := FixtureDatabase.Query("SELECT npm_get_package(?)", "jquery")
 	if err != nil {
@@ -26,8 +25,7 @@ func TestGetPackage(t *testing.T) {
 }
 
 func TestGetPackageVersion(t *testing.T) {
-	cleanup := newRecorder(t)
-	defer cleanup()
+	defer transport.Start(t)()
 
 	rows, err := FixtureDatabase.Query("SELECT npm_get_package(?, ?)", "jquery", "latest")
 	if err != nil {
@@ -82,8 +82,14 @@ func (c *Client) GetPackageVersion(ctx context.Context, packageName, version str
 
 // Register registers npm API related functionality as a SQLite extension
 func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
+	// a client set by the embedder is used as is, otherwise requests are retried according to the options
+	httpClient := opt.NPMHttpClient
+	if httpClient == nil {
+		httpClient = &http.Client{Transport: opt.RetryTransport(opt.HTTPTransport)}
+	}
+
 	var fns = map[string]sqlite.Function{
-		"npm_get_package": &GetPackage{NewClient(opt.NPMHttpClient, opt.Logger)},
+		"npm_get_package": &GetPackage{NewClient(httpClient, opt.Logger), opt.QueryContext},
 	}
 
 	for name, fn := range fns {
@@ -3,28 +3,29 @@ package npm_test
 import (
 	"database/sql"
 	"log"
-	"net/http"
 	"os"
-	"path"
 	"testing"
 
-	"github.com/dnaeon/go-vcr/v2/recorder"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/mergestat/mergestat-lite/extensions"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
+	"github.com/mergestat/mergestat-lite/pkg/vcr"
 	"go.riyazali.net/sqlite"
 )
 
 // FixtureDatabase represents the database connection to run the test against
 var FixtureDatabase *sql.DB
-var httpClient = http.DefaultClient
+
+// transport replays the interactions recorded in the fixtures directory
+var transport = &vcr.Transport{}
 
 func TestMain(m *testing.M) {
 	// register sqlite extension when this package is loaded
 	sqlite.Register(extensions.RegisterFn(
 		options.WithNPM(),
-		options.WithNPMHttpClient(httpClient),
+		options.WithHTTPTransport(transport),
+		options.WithContextValue("httpRetries", "0"),
 	))
 
 	var err error
@@ -34,21 +35,3 @@ func TestMain(m *testing.M) {
 
 	os.Exit(m.Run())
 }
-
-func newRecorder(t *testing.T) func() {
-	r, err := recorder.New(path.Join("fixtures", t.Name()))
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	r.SkipRequestLatency = true
-	r.SetTransport(http.DefaultTransport)
-	httpClient.Transport = r
-
-	return func() {
-		err := r.Stop()
-		if err != nil {
-			t.Fatal(err)
-		}
-	}
-}
@@ -0,0 +1,203 @@
+package objectstore
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// emptySHA256 is the hex encoded SHA-256 hash of an empty payload, such as the one of GET requests
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// awsConfig is the configuration requests to S3 are made with, looked up the way the AWS CLI and SDKs do
+type awsConfig struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	Endpoint        string // root url of the S3 API, such as for S3 compatible stores (https://s3.<region>.amazonaws.com by default)
+}
+
+// anonymous reports whether there are no credentials, in which case requests aren't signed (such as for public buckets)
+func (c *awsConfig) anonymous() bool { return c.AccessKeyID == "" || c.SecretAccessKey == "" }
+
+// loadAWSConfig looks up the credentials and region in the AWS_* environment variables, falling back to the profile
+// named by AWS_PROFILE (or the default one) in the shared credentials and config files (~/.aws/credentials and ~/.aws/config)
+func loadAWSConfig() *awsConfig {
+	cfg := &awsConfig{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Region:          firstNonEmpty(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION")),
+		Endpoint:        firstNonEmpty(os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL")),
+	}
+
+	profile := firstNonEmpty(os.Getenv("AWS_PROFILE"), "default")
+	home, _ := os.UserHomeDir()
+
+	if cfg.anonymous() {
+		path := firstNonEmpty(os.Getenv("AWS_SHARED_CREDENTIALS_FILE"), filepath.Join(home, ".aws", "credentials"))
+		if section := readINISection(path, profile); section != nil {
+			cfg.AccessKeyID = section["aws_access_key_id"]
+			cfg.SecretAccessKey = section["aws_secret_access_key"]
+			cfg.SessionToken = section["aws_session_token"]
+		}
+	}
+
+	if cfg.Region == "" {
+		// profiles other than the default one are in sections named "profile <name>" in the config file
+		name := profile
+		if name != "default" {
+			name = "profile " + name
+		}
+		path := firstNonEmpty(os.Getenv("AWS_CONFIG_FILE"), filepath.Join(home, ".aws", "config"))
+		if section := readINISection(path, name); section != nil {
+			cfg.Region = section["region"]
+		}
+	}
+
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	return cfg
+}
+
+// readINISection returns the keys and values of the section named name in the INI file at path,
+// or nil if there's no such section (or file)
+func readINISection(path, name string) map[string]string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+
+	var section map[string]string
+	var current string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			current = strings.TrimSpace(line[1 : len(line)-1])
+			if current == name && section == nil {
+				section = make(map[string]string)
+			}
+		case current == name:
+			if key, value, ok := strings.Cut(line, "="); ok {
+				section[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+	}
+
+	return section
+}
+
+// signV4 signs req (which must have an empty body) with AWS Signature Version 4, for the service in region,
+// as described in https://docs.aws.amazon.com/IAM/latest/UserGuide/create-signed-request.html
+func signV4(req *http.Request, cfg *awsConfig, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptySHA256)
+	if cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", cfg.SessionToken)
+	}
+
+	// the query is encoded the way it is signed, as url.Values encodes spaces as +
+	req.URL.RawQuery = canonicalQuery(req.URL.Query())
+
+	var headers = map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if name := strings.ToLower(name); strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	var names = make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, req.URL.RawQuery, canonicalHeaders.String(), signedHeaders, emptySHA256,
+	}, "\n")
+
+	scope := date + "/" + cfg.Region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, cfg.Region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+cfg.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// canonicalQuery encodes query sorted by key, with keys and values escaped as AWS expects (spaces as %20)
+func canonicalQuery(query url.Values) string {
+	var keys = make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, awsEscape(key)+"="+awsEscape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape percent-encodes s, leaving only the unreserved characters of RFC 3986 as is
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hexSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,227 @@
+package objectstore
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+// gcsBaseURL is the root of the Cloud Storage JSON API
+const gcsBaseURL = "https://storage.googleapis.com"
+
+// gcsReadOnlyScope is the OAuth scope listing objects requires
+const gcsReadOnlyScope = "https://www.googleapis.com/auth/devstorage.read_only"
+
+// googleTokenURL is where access tokens are requested for user credentials
+const googleTokenURL = "https://oauth2.googleapis.com/token"
+
+// googleCredentials are the fields of the service account keys and user credentials (as written by
+// gcloud auth application-default login) that tokens are requested with
+type googleCredentials struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// gcsAuth authenticates requests to the Cloud Storage JSON API, with the token source made from
+// the credentials found the first time it's needed
+type gcsAuth struct {
+	once   sync.Once
+	source oauth2.TokenSource // nil for anonymous requests
+	err    error
+}
+
+// tokenSource returns the source of the tokens requests are authenticated with (or nil if there are no credentials),
+// looked up as the Google Cloud SDKs do: an access token in GOOGLE_OAUTH_ACCESS_TOKEN, the credentials file at
+// GOOGLE_APPLICATION_CREDENTIALS, or the application default credentials of gcloud. Tokens are requested with client.
+func (a *gcsAuth) tokenSource(client *http.Client) (oauth2.TokenSource, error) {
+	a.once.Do(func() {
+		if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+			a.source = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+			return
+		}
+
+		path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+		if path == "" {
+			path = filepath.Join(gcloudConfigDir(), "application_default_credentials.json")
+			if _, err := os.Stat(path); err != nil {
+				return // no credentials, requests are anonymous
+			}
+		}
+
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			a.err = err
+			return
+		}
+
+		var creds googleCredentials
+		if err = json.Unmarshal(contents, &creds); err != nil {
+			a.err = errors.Wrapf(err, "could not read Google credentials in %s", path)
+			return
+		}
+
+		// the token source keeps the context, to request tokens with the client as they expire
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+		switch creds.Type {
+		case "service_account":
+			cfg := &jwt.Config{
+				Email:        creds.ClientEmail,
+				PrivateKey:   []byte(creds.PrivateKey),
+				PrivateKeyID: creds.PrivateKeyID,
+				Scopes:       []string{gcsReadOnlyScope},
+				TokenURL:     firstNonEmpty(creds.TokenURI, googleTokenURL),
+			}
+			a.source = cfg.TokenSource(ctx)
+		case "authorized_user":
+			cfg := &oauth2.Config{
+				ClientID:     creds.ClientID,
+				ClientSecret: creds.ClientSecret,
+				Endpoint:     oauth2.Endpoint{TokenURL: googleTokenURL},
+				Scopes:       []string{gcsReadOnlyScope},
+			}
+			a.source = cfg.TokenSource(ctx, &oauth2.Token{RefreshToken: creds.RefreshToken})
+		default:
+			a.err = errors.Errorf("unsupported type of Google credentials %q in %s", creds.Type, path)
+		}
+	})
+	return a.source, a.err
+}
+
+// gcloudConfigDir returns the directory gcloud keeps its configuration in
+func gcloudConfigDir() string {
+	if dir := os.Getenv("CLOUDSDK_CONFIG"); dir != "" {
+		return dir
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "gcloud")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "gcloud")
+}
+
+// gcsEndpoint returns the root url of the Cloud Storage JSON API, the one of the emulator at STORAGE_EMULATOR_HOST if set
+func gcsEndpoint() string {
+	host := os.Getenv("STORAGE_EMULATOR_HOST")
+	switch {
+	case host == "":
+		return gcsBaseURL
+	case strings.Contains(host, "://"):
+		return strings.TrimSuffix(host, "/")
+	default:
+		return "http://" + strings.TrimSuffix(host, "/")
+	}
+}
+
+type gcsLister struct {
+	*Options
+	auth   *gcsAuth
+	bucket string
+	prefix string
+
+	token   string // token of the next page
+	fetched bool
+}
+
+func (l *gcsLister) done() bool { return l.fetched && l.token == "" }
+
+func (l *gcsLister) list() ([]*object, error) {
+	var params = url.Values{}
+	if l.prefix != "" {
+		params.Set("prefix", l.prefix)
+	}
+	if l.token != "" {
+		params.Set("pageToken", l.token)
+	}
+
+	req, err := http.NewRequestWithContext(l.QueryContext.Context(), http.MethodGet, gcsEndpoint()+"/storage/v1/b/"+url.PathEscape(l.bucket)+"/o?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := l.auth.tokenSource(l.Client())
+	if err != nil {
+		return nil, err
+	}
+	if source != nil {
+		token, err := source.Token()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get a Google access token")
+		}
+		token.SetAuthHeader(req)
+	}
+
+	res, err := l.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		var body struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&body)
+		return nil, errors.Errorf("Cloud Storage request to list the objects of %s failed: %s: %s", l.bucket, res.Status, body.Error.Message)
+	}
+
+	var result struct {
+		NextPageToken string `json:"nextPageToken"`
+		Items         []struct {
+			Name         string    `json:"name"`
+			Size         string    `json:"size"` // an int64, encoded as a string
+			Updated      time.Time `json:"updated"`
+			ETag         string    `json:"etag"`
+			StorageClass string    `json:"storageClass"`
+		} `json:"items"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, errors.Wrapf(err, "could not decode the objects of %s", l.bucket)
+	}
+
+	l.fetched, l.token = true, result.NextPageToken
+
+	var objects = make([]*object, len(result.Items))
+	for o, item := range result.Items {
+		size, _ := strconv.ParseInt(item.Size, 10, 64)
+		objects[o] = &object{
+			Key:          item.Name,
+			Size:         size,
+			LastModified: item.Updated,
+			ETag:         item.ETag,
+			StorageClass: item.StorageClass,
+		}
+	}
+	return objects, nil
+}
+
+// NewGCSObjectsModule returns the implementation of a table listing the objects of a Google Cloud Storage bucket whose names
+// start with prefix, if supplied. Credentials are looked up as the Google Cloud SDKs do (GOOGLE_OAUTH_ACCESS_TOKEN,
+// GOOGLE_APPLICATION_CREDENTIALS, or the application default credentials of gcloud), and requests are anonymous if there are none.
+func NewGCSObjectsModule(opts *Options) sqlite.Module {
+	var auth = &gcsAuth{}
+	return newObjectsModule(opts, "gcs_objects", func(bucket, prefix string) (lister, error) {
+		return &gcsLister{Options: opts, auth: auth, bucket: bucket, prefix: prefix}, nil
+	})
+}
@@ -0,0 +1,34 @@
+package objectstore_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestGCSObjects(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT key, size, last_modified, storage_class FROM gcs_objects('mergestat-artifacts', 'releases/')")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	// objects are listed across both pages
+	if expected := 3; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	if key, size, updated, class := content[1][0], content[1][1], content[1][2], content[1][3]; key != "releases/v0.5.0/mergestat-linux-arm64.tar.gz" ||
+		size != "17825792" || updated != "2024-04-02T10:15:40.118Z" || class != "NEARLINE" {
+		t.Fatalf("unexpected object: %v", content[1])
+	}
+}
@@ -0,0 +1,158 @@
+// Package objectstore implements tables listing the objects stored in Amazon S3 and Google Cloud Storage buckets,
+// such as to take an inventory of build artifacts. Credentials are looked up the way the official CLIs and SDKs do.
+package objectstore
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+type Options struct {
+	// Client returns the http client requests are made with (credentials are handled by the modules)
+	Client func() *http.Client
+	Logger *zerolog.Logger
+	// QueryContext returns the context of the query being run, which cancels requests when done
+	QueryContext options.QueryContextFunc
+}
+
+// Register registers the object storage tables as a SQLite extension
+func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
+	httpClient := &http.Client{Transport: opt.RetryTransport(opt.HTTPTransport)}
+
+	objectstoreOpts := &Options{
+		Client:       func() *http.Client { return httpClient },
+		Logger:       opt.Logger,
+		QueryContext: opt.QueryContext,
+	}
+
+	if objectstoreOpts.Logger == nil {
+		l := zerolog.Nop()
+		objectstoreOpts.Logger = &l
+	}
+
+	var modules = map[string]sqlite.Module{
+		"s3_objects":  NewS3ObjectsModule(objectstoreOpts),
+		"gcs_objects": NewGCSObjectsModule(objectstoreOpts),
+	}
+
+	// register object storage tables
+	for name, mod := range modules {
+		if err = ext.CreateModule(name, opt.Stats.Module(name, mod)); err != nil {
+			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register object storage %q module", name)
+		}
+		opt.Modules.Add("objectstore", name, mod)
+	}
+
+	return sqlite.SQLITE_OK, nil
+}
+
+// object is an object stored in a bucket
+type object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+	ETag         string
+	StorageClass string
+}
+
+// lister pages through the objects of a bucket
+type lister interface {
+	// done reports whether all of the pages were listed
+	done() bool
+	// list returns the next page of objects
+	list() ([]*object, error)
+}
+
+type iterObjects struct {
+	lister
+	logger  *zerolog.Logger
+	objects []*object
+	current int
+}
+
+func (i *iterObjects) Column(ctx vtab.Context, c int) error {
+	current := i.objects[i.current]
+	switch objectCols[c].Name {
+	case "key":
+		ctx.ResultText(current.Key)
+	case "size":
+		ctx.ResultInt64(current.Size)
+	case "last_modified":
+		ctx.ResultText(current.LastModified.Format(time.RFC3339Nano))
+	case "etag":
+		ctx.ResultText(current.ETag)
+	case "storage_class":
+		if current.StorageClass != "" {
+			ctx.ResultText(current.StorageClass)
+		}
+	}
+	return nil
+}
+
+func (i *iterObjects) Next() (vtab.Row, error) {
+	i.current += 1
+
+	for i.current >= len(i.objects) {
+		if i.done() {
+			return nil, io.EOF
+		}
+
+		i.logger.Info().Msgf("fetching page of objects")
+		objects, err := i.list()
+		if err != nil {
+			return nil, err
+		}
+		i.objects, i.current = objects, 0
+	}
+
+	return i, nil
+}
+
+// objectCols are the columns of the tables listing objects, the same for all stores so that they can be queried alike
+var objectCols = []vtab.Column{
+	{Name: "bucket", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "prefix", Type: "TEXT", Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "key", Type: "TEXT"},
+	{Name: "size", Type: "INT"},
+	{Name: "last_modified", Type: "DATETIME"},
+	{Name: "etag", Type: "TEXT"},
+	{Name: "storage_class", Type: "TEXT"},
+}
+
+// newObjectsModule returns the implementation of a table listing the objects of a bucket (whose keys start with prefix, if supplied)
+// with the lister returned by newLister
+func newObjectsModule(opts *Options, table string, newLister func(bucket, prefix string) (lister, error)) sqlite.Module {
+	return vtab.NewTableFunc(table, objectCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var bucket, prefix string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					bucket = constraint.Value.Text()
+				case 1:
+					prefix = constraint.Value.Text()
+				}
+			}
+		}
+
+		if bucket == "" {
+			return nil, errors.Errorf("%s requires a bucket", table)
+		}
+
+		l, err := newLister(bucket, prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		logger := opts.Logger.With().Str("table", table).Str("bucket", bucket).Str("prefix", prefix).Logger()
+		logger.Info().Msgf("starting objects iterator for %s", bucket)
+		return &iterObjects{lister: l, logger: &logger, current: -1}, nil
+	})
+}
@@ -0,0 +1,125 @@
+package objectstore
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		ETag         string    `xml:"ETag"`
+		Size         int64     `xml:"Size"`
+		StorageClass string    `xml:"StorageClass"`
+	} `xml:"Contents"`
+}
+
+type s3Lister struct {
+	*Options
+	cfg    *awsConfig
+	bucket string
+	prefix string
+
+	token     string // continuation token of the next page
+	fetched   bool
+	truncated bool
+}
+
+func (l *s3Lister) done() bool { return l.fetched && !l.truncated }
+
+// endpoint returns the root url of the S3 API
+func (l *s3Lister) endpoint() string {
+	if l.cfg.Endpoint != "" {
+		return strings.TrimSuffix(l.cfg.Endpoint, "/")
+	}
+	return "https://s3." + l.cfg.Region + ".amazonaws.com"
+}
+
+func (l *s3Lister) list() ([]*object, error) {
+	var params = url.Values{}
+	params.Set("list-type", "2")
+	if l.prefix != "" {
+		params.Set("prefix", l.prefix)
+	}
+	if l.token != "" {
+		params.Set("continuation-token", l.token)
+	}
+
+	res, err := l.get(params)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	// buckets in another region than the configured one are listed again from the region they're in
+	if region := res.Header.Get("X-Amz-Bucket-Region"); res.StatusCode == http.StatusMovedPermanently && region != "" &&
+		region != l.cfg.Region && l.cfg.Endpoint == "" {
+		cfg := *l.cfg
+		cfg.Region = region
+		l.cfg = &cfg
+		return l.list()
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var body struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		_ = xml.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&body)
+		return nil, errors.Errorf("S3 request to list the objects of %s failed: %s: %s %s", l.bucket, res.Status, body.Code, body.Message)
+	}
+
+	var result listBucketResult
+	if err = xml.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, errors.Wrapf(err, "could not decode the objects of %s", l.bucket)
+	}
+
+	l.fetched = true
+	l.token, l.truncated = result.NextContinuationToken, result.IsTruncated && result.NextContinuationToken != ""
+
+	var objects = make([]*object, len(result.Contents))
+	for o, content := range result.Contents {
+		objects[o] = &object{
+			Key:          content.Key,
+			Size:         content.Size,
+			LastModified: content.LastModified,
+			ETag:         strings.Trim(content.ETag, `"`),
+			StorageClass: content.StorageClass,
+		}
+	}
+	return objects, nil
+}
+
+// get makes the request listing the objects of the bucket, signed if there are credentials
+func (l *s3Lister) get(params url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(l.QueryContext.Context(), http.MethodGet, l.endpoint()+"/"+url.PathEscape(l.bucket)+"?"+canonicalQuery(params), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if !l.cfg.anonymous() {
+		signV4(req, l.cfg, "s3", time.Now())
+	}
+
+	return l.Client().Do(req)
+}
+
+// NewS3ObjectsModule returns the implementation of a table listing the objects of an Amazon S3 bucket (or of an S3 compatible
+// store, at AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL) whose keys start with prefix, if supplied. Credentials and region are
+// looked up as the AWS CLI does (in AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN and AWS_REGION, or in the
+// AWS_PROFILE profile of ~/.aws/credentials and ~/.aws/config), and requests are anonymous if there are none.
+func NewS3ObjectsModule(opts *Options) sqlite.Module {
+	return newObjectsModule(opts, "s3_objects", func(bucket, prefix string) (lister, error) {
+		return &s3Lister{Options: opts, cfg: loadAWSConfig(), bucket: bucket, prefix: prefix}, nil
+	})
+}
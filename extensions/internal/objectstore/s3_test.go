@@ -0,0 +1,42 @@
+package objectstore_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestS3Objects(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT * FROM s3_objects('mergestat-artifacts', 'releases/')")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	colCount, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	if expected := 5; colCount != expected {
+		t.Fatalf("expected %d columns, got: %d", expected, colCount)
+	}
+
+	// objects are listed across both pages
+	if expected := 3; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	if key, size, lastModified, etag, class := content[0][0], content[0][1], content[0][2], content[0][3], content[0][4]; key != "releases/v0.5.0/mergestat-linux-amd64.tar.gz" ||
+		size != "18874368" || lastModified != "2024-04-02T10:15:31Z" || etag != "9b2cf535f27731c974343645a3985328" || class != "STANDARD" {
+		t.Fatalf("unexpected object: %v", content[0])
+	}
+
+	if key := content[2][0]; key != "releases/v0.6.0/mergestat-linux-amd64.tar.gz" {
+		t.Fatalf("unexpected object: %v", content[2])
+	}
+}
@@ -0,0 +1,50 @@
+// Package oci implements tables querying container registries (such as Docker Hub, GHCR or ECR) with the
+// OCI distribution API, such as to correlate the images pushed with the tags of a repository
+package oci
+
+import (
+	"net/http"
+
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+type Options struct {
+	// Client returns the http client requests are made with (credentials are handled by the modules)
+	Client func() *http.Client
+	Logger *zerolog.Logger
+	// QueryContext returns the context of the query being run, which cancels requests when done
+	QueryContext options.QueryContextFunc
+}
+
+// Register registers the container registry tables as a SQLite extension
+func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
+	httpClient := &http.Client{Transport: opt.RetryTransport(opt.HTTPTransport)}
+
+	ociOpts := &Options{
+		Client:       func() *http.Client { return httpClient },
+		Logger:       opt.Logger,
+		QueryContext: opt.QueryContext,
+	}
+
+	if ociOpts.Logger == nil {
+		l := zerolog.Nop()
+		ociOpts.Logger = &l
+	}
+
+	var modules = map[string]sqlite.Module{
+		"oci_tags": NewTagsModule(ociOpts),
+	}
+
+	// register container registry tables
+	for name, mod := range modules {
+		if err = ext.CreateModule(name, opt.Stats.Module(name, mod)); err != nil {
+			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register OCI %q module", name)
+		}
+		opt.Modules.Add("oci", name, mod)
+	}
+
+	return sqlite.SQLITE_OK, nil
+}
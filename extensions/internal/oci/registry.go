@@ -0,0 +1,322 @@
+package oci
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// dockerHub is the registry images are pulled from when none is named, and dockerHubIndex the name
+// its credentials are stored under in the Docker config
+const (
+	dockerHub      = "registry-1.docker.io"
+	dockerHubIndex = "https://index.docker.io/v1/"
+)
+
+// reference is a repository in a registry, such as ghcr.io/mergestat/mergestat
+type reference struct {
+	Registry   string // host (and port) of the registry, such as ghcr.io
+	Repository string // such as mergestat/mergestat, or library/alpine for official images on Docker Hub
+}
+
+// parseReference parses a repository reference the way docker does, as in alpine, mergestat/mergestat or
+// ghcr.io/mergestat/mergestat. Any tag or digest (as in alpine:3.19) is ignored.
+func parseReference(ref string) (*reference, error) {
+	ref = strings.TrimPrefix(strings.TrimPrefix(ref, "https://"), "http://")
+	if at := strings.Index(ref, "@"); at >= 0 {
+		ref = ref[:at]
+	}
+	if colon := strings.LastIndex(ref, ":"); colon > strings.LastIndex(ref, "/") {
+		ref = ref[:colon]
+	}
+	if ref == "" {
+		return nil, errors.New("expected a repository reference, such as ghcr.io/owner/image")
+	}
+
+	var registry, repository = dockerHub, ref
+	if first, rest, ok := strings.Cut(ref, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		registry, repository = first, rest
+	}
+
+	switch registry {
+	case "docker.io", "index.docker.io":
+		registry = dockerHub
+	}
+	if registry == dockerHub && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+
+	return &reference{Registry: registry, Repository: repository}, nil
+}
+
+// credentials are the username and password (or token) to authenticate with a registry
+type credentials struct {
+	Username string
+	Secret   string
+}
+
+// lookupCredentials returns the credentials docker would use for the registry, from the auths, credsStore and credHelpers of
+// its config file (in $DOCKER_CONFIG, or ~/.docker), or nil if there are none
+func lookupCredentials(registry string) (*credentials, error) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".docker")
+	}
+
+	contents, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return nil, nil // no config, requests are anonymous
+	}
+
+	var config struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+		CredsStore  string            `json:"credsStore"`
+		CredHelpers map[string]string `json:"credHelpers"`
+	}
+	if err = json.Unmarshal(contents, &config); err != nil {
+		return nil, errors.Wrap(err, "could not read docker config")
+	}
+
+	server := registry
+	if registry == dockerHub {
+		server = dockerHubIndex
+	}
+
+	if helper := config.CredHelpers[server]; helper != "" {
+		return helperCredentials(helper, server)
+	}
+
+	for key, auth := range config.Auths {
+		if (key != server && strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://") != server) || auth.Auth == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not decode docker credentials of %s", server)
+		}
+		username, secret, _ := strings.Cut(string(decoded), ":")
+		return &credentials{Username: username, Secret: secret}, nil
+	}
+
+	if config.CredsStore != "" {
+		return helperCredentials(config.CredsStore, server)
+	}
+
+	return nil, nil
+}
+
+// helperCredentials returns the credentials of server kept by the docker-credential-<helper> program
+func helperCredentials(helper, server string) (*credentials, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		// helpers fail for servers they have no credentials for, in which case requests are anonymous
+		return nil, nil
+	}
+
+	var creds credentials
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return nil, errors.Wrapf(err, "could not read the credentials of docker-credential-%s", helper)
+	}
+	return &creds, nil
+}
+
+// registryClient makes requests to the API of a registry, authenticating as the registry asks
+// (with a bearer token requested from its token service, or with basic auth) once it's challenged to
+type registryClient struct {
+	*Options
+	ref   *reference
+	creds *credentials
+
+	authorization string // value of the Authorization header requests are made with, once challenged
+}
+
+func newRegistryClient(opts *Options, ref *reference) (*registryClient, error) {
+	creds, err := lookupCredentials(ref.Registry)
+	if err != nil {
+		return nil, err
+	}
+	return &registryClient{Options: opts, ref: ref, creds: creds}, nil
+}
+
+// baseURL returns the root of the API of the registry
+func (c *registryClient) baseURL() string {
+	if host, _, _ := strings.Cut(c.ref.Registry, ":"); host == "localhost" || host == "127.0.0.1" {
+		return "http://" + c.ref.Registry + "/v2/"
+	}
+	return "https://" + c.ref.Registry + "/v2/"
+}
+
+// get requests the resource at path (relative to the repository, as in tags/list), or at an absolute url,
+// accepting the supplied media types, and returns the response if it succeeded
+func (c *registryClient) get(path string, accept ...string) (*http.Response, error) {
+	target := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		target = c.baseURL() + c.ref.Repository + "/" + path
+	}
+
+	res, err := c.do(target, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	// authorize once challenged (again if the token expired), and retry
+	if res.StatusCode == http.StatusUnauthorized {
+		challenge := res.Header.Get("Www-Authenticate")
+		_ = res.Body.Close()
+
+		if c.authorization, err = c.authorize(challenge); err != nil {
+			return nil, err
+		}
+		if res, err = c.do(target, accept); err != nil {
+			return nil, err
+		}
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer func() { _ = res.Body.Close() }()
+		var body struct {
+			Errors []struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		_ = json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&body)
+		var messages []string
+		for _, e := range body.Errors {
+			messages = append(messages, e.Code+": "+e.Message)
+		}
+		return nil, errors.Errorf("request to %s failed: %s %s", target, res.Status, strings.Join(messages, "; "))
+	}
+
+	return res, nil
+}
+
+func (c *registryClient) do(target string, accept []string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(c.QueryContext.Context(), http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(accept) > 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+	if c.authorization != "" {
+		req.Header.Set("Authorization", c.authorization)
+	}
+	return c.Client().Do(req)
+}
+
+// authorize returns the Authorization header to make requests with, answering the WWW-Authenticate challenge of the registry
+func (c *registryClient) authorize(challenge string) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if c.creds == nil {
+			return "", errors.Errorf("%s requires credentials (docker login %s)", c.ref.Registry, c.ref.Registry)
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(c.creds.Username+":"+c.creds.Secret)), nil
+	case "bearer":
+		return c.token(params)
+	default:
+		return "", errors.Errorf("unsupported authentication challenge from %s: %q", c.ref.Registry, challenge)
+	}
+}
+
+// token requests a token to pull from the repository from the token service of the registry
+// (with the credentials, if there are any), as in https://distribution.github.io/distribution/spec/auth/token/
+func (c *registryClient) token(params map[string]string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", errors.Errorf("invalid token realm from %s: %q", c.ref.Registry, params["realm"])
+	}
+
+	var query = realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + c.ref.Repository + ":pull"
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(c.QueryContext.Context(), http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if c.creds != nil {
+		req.SetBasicAuth(c.creds.Username, c.creds.Secret)
+	}
+
+	res, err := c.Client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.Errorf("could not get a token to pull %s/%s: %s", c.ref.Registry, c.ref.Repository, res.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", errors.Wrapf(err, "could not decode the token to pull %s/%s", c.ref.Registry, c.ref.Repository)
+	}
+
+	if body.Token == "" {
+		body.Token = body.AccessToken
+	}
+	return "Bearer " + body.Token, nil
+}
+
+// parseChallenge parses a WWW-Authenticate header, such as Bearer realm="https://ghcr.io/token",service="ghcr.io",
+// into its scheme and parameters
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+
+	var params = make(map[string]string)
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+			params[key], rest = value[1:end+1], value[end+2:]
+		} else {
+			end := strings.Index(value, ",")
+			if end < 0 {
+				end = len(value)
+			}
+			params[key], rest = value[:end], value[end:]
+		}
+		rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), ","))
+	}
+
+	return scheme, params
+}
@@ -0,0 +1,29 @@
+package oci
+
+import "testing"
+
+func TestParseReference(t *testing.T) {
+	for ref, expected := range map[string]reference{
+		"alpine":                              {Registry: dockerHub, Repository: "library/alpine"},
+		"alpine:3.19":                         {Registry: dockerHub, Repository: "library/alpine"},
+		"docker.io/grafana/grafana@sha256:ab": {Registry: dockerHub, Repository: "grafana/grafana"},
+		"ghcr.io/mergestat/mergestat:latest":  {Registry: "ghcr.io", Repository: "mergestat/mergestat"},
+		"localhost:5000/tools/builder":        {Registry: "localhost:5000", Repository: "tools/builder"},
+	} {
+		parsed, err := parseReference(ref)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", ref, err)
+		}
+		if *parsed != expected {
+			t.Fatalf("expected %s to be parsed as %v, got: %v", ref, expected, *parsed)
+		}
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/alpine:pull"`)
+	if scheme != "Bearer" || params["realm"] != "https://auth.docker.io/token" || params["service"] != "registry.docker.io" ||
+		params["scope"] != "repository:library/alpine:pull" {
+		t.Fatalf("unexpected challenge: %s %v", scheme, params)
+	}
+}
@@ -0,0 +1,306 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+// tagsPerPage is the number of tags requested per page (registries may return fewer)
+const tagsPerPage = 100
+
+// media types of the image indexes (multi-platform images) and manifests, in the OCI and Docker formats
+const (
+	mediaTypeOCIIndex       = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// maxManifestSize bounds the size of the manifests and image configurations read
+const maxManifestSize = 4 << 20
+
+// unknownPlatform is the platform of the attestations (such as provenance) indexes list alongside the images
+const unknownPlatform = "unknown/unknown"
+
+var manifestMediaTypes = []string{mediaTypeOCIIndex, mediaTypeDockerList, mediaTypeOCIManifest, mediaTypeDockerManifest}
+
+// manifest holds the fields of both image indexes and image manifests
+type manifest struct {
+	MediaType string `json:"mediaType"`
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform *struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+			Variant      string `json:"variant"`
+		} `json:"platform"`
+	} `json:"manifests"`
+	Config struct {
+		Digest string `json:"digest"`
+		Size   int64  `json:"size"`
+	} `json:"config"`
+	Layers []struct {
+		Size int64 `json:"size"`
+	} `json:"layers"`
+}
+
+// isIndex reports whether the manifest is an index of the manifests of a multi-platform image
+func (m *manifest) isIndex() bool {
+	return m.MediaType == mediaTypeOCIIndex || m.MediaType == mediaTypeDockerList || len(m.Manifests) > 0
+}
+
+// tagDetails are the details of a tag, looked up from its manifest (and image configuration)
+type tagDetails struct {
+	digest    string
+	mediaType string
+	created   *time.Time
+	size      int64
+	platforms []string
+}
+
+type iterTags struct {
+	*Options
+	client *registryClient
+
+	next    string // url of the next page of tags, if any
+	fetched bool
+	tags    []string
+	current int
+	details *tagDetails // of the current tag, looked up the first time they're needed
+}
+
+func (i *iterTags) logger() *zerolog.Logger {
+	logger := i.Logger.With().Str("registry", i.client.ref.Registry).Str("repository", i.client.ref.Repository).Logger()
+	return &logger
+}
+
+func (i *iterTags) Column(ctx vtab.Context, c int) error {
+	name := tagCols[c].Name
+	if name == "tag" {
+		ctx.ResultText(i.tags[i.current])
+		return nil
+	}
+
+	if i.details == nil {
+		details, err := i.lookup(i.tags[i.current])
+		if err != nil {
+			return err
+		}
+		i.details = details
+	}
+
+	switch name {
+	case "digest":
+		ctx.ResultText(i.details.digest)
+	case "media_type":
+		ctx.ResultText(i.details.mediaType)
+	case "created_at":
+		if i.details.created != nil {
+			ctx.ResultText(i.details.created.Format(time.RFC3339Nano))
+		}
+	case "size":
+		ctx.ResultInt64(i.details.size)
+	case "platforms":
+		js, err := json.Marshal(i.details.platforms)
+		if err != nil {
+			return err
+		}
+		ctx.ResultText(string(js))
+	}
+	return nil
+}
+
+func (i *iterTags) Next() (vtab.Row, error) {
+	i.current += 1
+	i.details = nil
+
+	for i.current >= len(i.tags) {
+		if i.fetched && i.next == "" {
+			return nil, io.EOF
+		}
+
+		i.logger().Info().Msgf("fetching page of tags")
+		res, err := i.client.get(i.next)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&page)
+		_ = res.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decode tags")
+		}
+
+		i.tags, i.current, i.fetched = page.Tags, 0, true
+		i.next = nextPage(res)
+	}
+
+	return i, nil
+}
+
+// lookup looks up the details of tag: the digest and media type of its manifest, and the platforms of its image, along with when
+// it was created and its (compressed) size. For multi-platform images, those are of the linux/amd64 image (or the first one).
+func (i *iterTags) lookup(tag string) (*tagDetails, error) {
+	m, digest, err := i.manifest(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &tagDetails{digest: digest, mediaType: m.MediaType, platforms: []string{}}
+
+	if m.isIndex() {
+		var chosen string
+		for _, child := range m.Manifests {
+			if child.Platform == nil {
+				continue
+			}
+			platform := child.Platform.OS + "/" + child.Platform.Architecture
+			if child.Platform.Variant != "" {
+				platform += "/" + child.Platform.Variant
+			}
+			if platform == unknownPlatform {
+				continue
+			}
+			details.platforms = append(details.platforms, platform)
+			if chosen == "" || platform == "linux/amd64" {
+				chosen = child.Digest
+			}
+		}
+		if chosen == "" {
+			return details, nil
+		}
+		if m, _, err = i.manifest(chosen); err != nil {
+			return nil, err
+		}
+	}
+
+	details.size = m.Config.Size
+	for _, layer := range m.Layers {
+		details.size += layer.Size
+	}
+
+	if m.Config.Digest != "" {
+		res, err := i.client.get("blobs/" + m.Config.Digest)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = res.Body.Close() }()
+
+		var config struct {
+			Created      *time.Time `json:"created"`
+			OS           string     `json:"os"`
+			Architecture string     `json:"architecture"`
+		}
+		if err = json.NewDecoder(io.LimitReader(res.Body, maxManifestSize)).Decode(&config); err == nil {
+			details.created = config.Created
+			if len(details.platforms) == 0 && config.OS != "" {
+				details.platforms = append(details.platforms, config.OS+"/"+config.Architecture)
+			}
+		}
+	}
+
+	return details, nil
+}
+
+// manifest returns the manifest of reference (a tag or digest) and its digest
+func (i *iterTags) manifest(reference string) (*manifest, string, error) {
+	res, err := i.client.get("manifests/"+url.PathEscape(reference), manifestMediaTypes...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxManifestSize))
+	if err != nil {
+		return nil, "", err
+	}
+
+	var m manifest
+	if err = json.Unmarshal(body, &m); err != nil {
+		return nil, "", errors.Wrapf(err, "could not decode the manifest of %s", reference)
+	}
+	if m.MediaType == "" {
+		m.MediaType = res.Header.Get("Content-Type")
+	}
+
+	digest := res.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		sum := sha256.Sum256(body)
+		digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	return &m, digest, nil
+}
+
+// linkNext matches the url of the next page in a Link header, as in </v2/alpine/tags/list?last=3.19&n=100>; rel="next"
+var linkNext = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+
+// nextPage returns the url of the page after the one of res, if any
+func nextPage(res *http.Response) string {
+	match := linkNext.FindStringSubmatch(res.Header.Get("Link"))
+	if match == nil {
+		return ""
+	}
+	next, err := res.Request.URL.Parse(match[1])
+	if err != nil {
+		return ""
+	}
+	return next.String()
+}
+
+var tagCols = []vtab.Column{
+	{Name: "repository_ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "tag", Type: "TEXT"},
+	{Name: "digest", Type: "TEXT"},
+	{Name: "media_type", Type: "TEXT"},
+	{Name: "created_at", Type: "DATETIME"},
+	{Name: "size", Type: "INT"},
+	{Name: "platforms", Type: "JSON"},
+}
+
+// NewTagsModule returns the implementation of a table listing the tags of a repository in a container registry
+// (such as alpine on Docker Hub, ghcr.io/owner/image or <account>.dkr.ecr.<region>.amazonaws.com/image), along with the digest
+// of their manifest, when their image was created, its (compressed) size and its platforms. For multi-platform images,
+// created_at and size are the ones of the linux/amd64 image (or the first one). Credentials are the ones docker uses
+// (after docker login, or through credential helpers such as the ECR one), and requests are anonymous if there are none.
+// All but the tag column take a request or more per tag.
+func NewTagsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("oci_tags", tagCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var ref string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 0 {
+				ref = constraint.Value.Text()
+			}
+		}
+
+		parsed, err := parseReference(ref)
+		if err != nil {
+			return nil, err
+		}
+
+		client, err := newRegistryClient(opts, parsed)
+		if err != nil {
+			return nil, err
+		}
+
+		iter := &iterTags{Options: opts, client: client, current: -1}
+		iter.next = client.baseURL() + parsed.Repository + "/tags/list?n=" + strconv.Itoa(tagsPerPage)
+		iter.logger().Info().Msgf("starting OCI tags iterator for %s/%s", parsed.Registry, parsed.Repository)
+		return iter, nil
+	})
+}
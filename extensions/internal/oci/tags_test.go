@@ -0,0 +1,54 @@
+package oci_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestTags(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT tag FROM oci_tags('ghcr.io/mergestat/mergestat')")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	_, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	// tags are listed across both pages
+	if expected := 3; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	if tag := content[2][0]; tag != "latest" {
+		t.Fatalf("unexpected tag: %s", tag)
+	}
+
+	// the details of the multi-platform image are the ones of its linux/amd64 image
+	row := db.QueryRow("SELECT digest, media_type, created_at, size, platforms FROM oci_tags('ghcr.io/mergestat/mergestat') WHERE tag = '0.6.0'")
+
+	var digest, mediaType, created, platforms string
+	var size int64
+	if err = row.Scan(&digest, &mediaType, &created, &size, &platforms); err != nil {
+		t.Fatalf("failed to retrieve tag details: %v", err.Error())
+	}
+
+	if digest != "sha256:884c660c942cebd94de9aac6416f8de24380cf542fa5879ac5d8a9b6a64b9d73" || mediaType != "application/vnd.oci.image.index.v1+json" {
+		t.Fatalf("unexpected manifest: %s %s", digest, mediaType)
+	}
+
+	if created != "2024-05-14T08:03:27.118Z" || size != 24886999 {
+		t.Fatalf("unexpected image: created at %s, %d bytes", created, size)
+	}
+
+	if platforms != `["linux/amd64","linux/arm64/v8"]` {
+		t.Fatalf("unexpected platforms: %s", platforms)
+	}
+}
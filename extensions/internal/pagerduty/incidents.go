@@ -0,0 +1,179 @@
+package pagerduty
+
+import (
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+// incidentsPerPage is the number of incidents requested per page, the most PagerDuty allows
+const incidentsPerPage = 100
+
+type incident struct {
+	ID             string     `json:"id"`
+	IncidentNumber int        `json:"incident_number"`
+	Title          string     `json:"title"`
+	Status         string     `json:"status"`
+	Urgency        string     `json:"urgency"`
+	HTMLURL        string     `json:"html_url"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+	Service        struct {
+		ID      string `json:"id"`
+		Summary string `json:"summary"`
+	} `json:"service"`
+}
+
+type iterIncidents struct {
+	*Options
+	service string
+	since   time.Time
+
+	offset    int
+	fetched   bool
+	more      bool
+	incidents []*incident
+	current   int
+}
+
+func (i *iterIncidents) logger() *zerolog.Logger {
+	logger := i.Logger.With().Str("service", i.service).Time("since", i.since).Logger()
+	return &logger
+}
+
+func (i *iterIncidents) Column(ctx vtab.Context, c int) error {
+	current := i.incidents[i.current]
+	switch incidentCols[c].Name {
+	case "id":
+		ctx.ResultText(current.ID)
+	case "incident_number":
+		ctx.ResultInt(current.IncidentNumber)
+	case "title":
+		ctx.ResultText(current.Title)
+	case "status":
+		ctx.ResultText(current.Status)
+	case "urgency":
+		ctx.ResultText(current.Urgency)
+	case "service_id":
+		ctx.ResultText(current.Service.ID)
+	case "service_name":
+		ctx.ResultText(current.Service.Summary)
+	case "created_at":
+		ctx.ResultText(current.CreatedAt.Format(time.RFC3339Nano))
+	case "resolved_at":
+		if current.ResolvedAt != nil {
+			ctx.ResultText(current.ResolvedAt.Format(time.RFC3339Nano))
+		}
+	case "minutes_to_resolve":
+		if current.ResolvedAt != nil {
+			ctx.ResultFloat(current.ResolvedAt.Sub(current.CreatedAt).Minutes())
+		}
+	case "html_url":
+		ctx.ResultText(current.HTMLURL)
+	}
+	return nil
+}
+
+func (i *iterIncidents) Next() (vtab.Row, error) {
+	i.current += 1
+
+	for i.current >= len(i.incidents) {
+		if i.fetched && !i.more {
+			return nil, io.EOF
+		}
+
+		var params = url.Values{}
+		params.Set("limit", strconv.Itoa(incidentsPerPage))
+		params.Set("offset", strconv.Itoa(i.offset))
+		params.Set("sort_by", "created_at:desc")
+		if i.service != "" {
+			params.Set("service_ids[]", i.service)
+		}
+		if i.since.IsZero() {
+			params.Set("date_range", "all")
+		} else {
+			params.Set("since", i.since.Format(time.RFC3339))
+		}
+
+		i.logger().Info().Int("offset", i.offset).Msgf("fetching page of incidents")
+
+		var page struct {
+			Incidents []*incident `json:"incidents"`
+			More      bool        `json:"more"`
+		}
+		if err := i.get("/incidents", params, &page); err != nil {
+			return nil, err
+		}
+
+		i.incidents, i.current, i.fetched = page.Incidents, 0, true
+		i.offset += len(page.Incidents)
+		i.more = page.More && len(page.Incidents) > 0
+	}
+
+	return i, nil
+}
+
+var incidentCols = []vtab.Column{
+	{Name: "service", Type: "TEXT", Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "since", Type: "TEXT", Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: "TEXT"},
+	{Name: "incident_number", Type: "INT"},
+	{Name: "title", Type: "TEXT"},
+	{Name: "status", Type: "TEXT"},
+	{Name: "urgency", Type: "TEXT"},
+	{Name: "service_id", Type: "TEXT"},
+	{Name: "service_name", Type: "TEXT"},
+	{Name: "created_at", Type: "DATETIME"},
+	{Name: "resolved_at", Type: "DATETIME"},
+	{Name: "minutes_to_resolve", Type: "REAL"},
+	{Name: "html_url", Type: "TEXT"},
+}
+
+// NewIncidentsModule returns the implementation of a table listing PagerDuty incidents, newest first, optionally only the ones
+// of a service (supplied by ID, such as PIJ90N7) and the ones created since a time (RFC3339 or YYYY-MM-DD). PagerDuty
+// only searches up to 6 months from since. resolved_at and minutes_to_resolve are NULL for incidents that aren't resolved.
+func NewIncidentsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("pagerduty_incidents", incidentCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var service, since string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					service = constraint.Value.Text()
+				case 1:
+					since = constraint.Value.Text()
+				}
+			}
+		}
+
+		iter := &iterIncidents{Options: opts, service: service, current: -1}
+		if since != "" {
+			t, err := parseSince(since)
+			if err != nil {
+				return nil, err
+			}
+			iter.since = t
+		}
+
+		iter.logger().Info().Msgf("starting PagerDuty incidents iterator")
+		return iter, nil
+	})
+}
+
+// parseSince parses the time incidents are listed since, either an RFC3339 timestamp or a date
+func parseSince(since string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", since)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid since %q, expected an RFC3339 timestamp or a YYYY-MM-DD date", since)
+	}
+	return t, nil
+}
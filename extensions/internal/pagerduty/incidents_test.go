@@ -0,0 +1,44 @@
+package pagerduty_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestIncidents(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT * FROM pagerduty_incidents('PIJ90N7', '2024-05-01')")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	colCount, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	if expected := 11; colCount != expected {
+		t.Fatalf("expected %d columns, got: %d", expected, colCount)
+	}
+
+	// incidents are listed across both pages
+	if expected := 3; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	// the newest incident is still open
+	if status, urgency, resolvedAt, mttr := content[0][3], content[0][4], content[0][8], content[0][9]; status != "triggered" ||
+		urgency != "high" || resolvedAt != "NULL" || mttr != "NULL" {
+		t.Fatalf("unexpected incident: %v", content[0])
+	}
+
+	if service, resolvedAt, mttr := content[1][6], content[1][8], content[1][9]; service != "Checkout API" ||
+		resolvedAt != "2024-05-02T15:12:00Z" || mttr != "47" {
+		t.Fatalf("unexpected incident: %v", content[1])
+	}
+}
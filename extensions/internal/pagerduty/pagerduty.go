@@ -0,0 +1,110 @@
+// Package pagerduty implements tables for querying the PagerDuty REST API, such as to correlate incidents
+// with the history of a repository
+package pagerduty
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/extensions/services"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+// BaseURL is the root of the PagerDuty REST API
+const BaseURL = "https://api.pagerduty.com"
+
+type Options struct {
+	// Client returns the http client requests to the PagerDuty REST API are made with (authenticated with the pagerdutyToken)
+	Client func() *http.Client
+	Logger *zerolog.Logger
+	// QueryContext returns the context of the query being run, which cancels requests when done
+	QueryContext options.QueryContextFunc
+}
+
+// GetPagerDutyTokenFromCtx looks up the pagerdutyToken key in the supplied context and returns it if set
+func GetPagerDutyTokenFromCtx(ctx services.Context) string {
+	return ctx["pagerdutyToken"]
+}
+
+// tokenTransport authenticates requests with a PagerDuty REST API key, which (unlike OAuth tokens) isn't a bearer token
+type tokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Token token="+t.token)
+	return t.base.RoundTrip(req)
+}
+
+// Register registers PagerDuty related functionality as a SQLite extension
+func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
+	httpClient := &http.Client{Transport: &tokenTransport{
+		token: GetPagerDutyTokenFromCtx(opt.Context),
+		base:  opt.RetryTransport(opt.HTTPTransport),
+	}}
+
+	pagerdutyOpts := &Options{
+		Client:       func() *http.Client { return httpClient },
+		Logger:       opt.Logger,
+		QueryContext: opt.QueryContext,
+	}
+
+	if pagerdutyOpts.Logger == nil {
+		l := zerolog.Nop()
+		pagerdutyOpts.Logger = &l
+	}
+
+	var modules = map[string]sqlite.Module{
+		"pagerduty_incidents": NewIncidentsModule(pagerdutyOpts),
+	}
+
+	// register PagerDuty tables
+	for name, mod := range modules {
+		if err = ext.CreateModule(name, opt.Stats.Module(name, mod)); err != nil {
+			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register PagerDuty %q module", name)
+		}
+		opt.Modules.Add("pagerduty", name, mod)
+	}
+
+	return sqlite.SQLITE_OK, nil
+}
+
+// get requests the resource at path (relative to BaseURL) with the supplied query parameters,
+// decoding the JSON response into out
+func (o *Options) get(path string, params url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(o.QueryContext.Context(), http.MethodGet, BaseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+
+	res, err := o.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		var body struct {
+			Error struct {
+				Message string   `json:"message"`
+				Errors  []string `json:"errors"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&body)
+		return errors.Errorf("PagerDuty API request to %s failed: %s: %s %v", path, res.Status, body.Error.Message, body.Error.Errors)
+	}
+
+	if err = json.NewDecoder(res.Body).Decode(out); err != nil {
+		return errors.Wrapf(err, "could not decode PagerDuty API response to %s", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package pagerduty_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/mergestat/mergestat-lite/extensions"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
+	"github.com/mergestat/mergestat-lite/pkg/vcr"
+	"go.riyazali.net/sqlite"
+)
+
+// transport replays the interactions recorded in the fixtures directory
+var transport = &vcr.Transport{}
+
+// tests' entrypoint that registers the extension
+// automatically with all loaded database connections
+func TestMain(m *testing.M) {
+	// register sqlite extension when this package is loaded
+	sqlite.Register(extensions.RegisterFn(
+		options.WithPagerDuty(),
+		options.WithHTTPTransport(transport),
+		options.WithContextValue("pagerdutyToken", os.Getenv("PAGERDUTY_TOKEN")),
+		options.WithContextValue("httpRetries", "0"),
+	))
+	os.Exit(m.Run())
+}
+
+// Memory represents a uri to an in-memory database
+const Memory = "file:testing.db?mode=memory"
+
+// Connect opens a connection with the sqlite3 database using
+// the given data source address and pings it to check liveliness.
+func Connect(t *testing.T, dataSourceName string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", dataSourceName)
+	if err != nil {
+		t.Fatalf("failed to open connection: %v", err.Error())
+	}
+
+	if err = db.Ping(); err != nil {
+		t.Fatalf("failed to open connection: %v", err.Error())
+	}
+
+	return db
+}
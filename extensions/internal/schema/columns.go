@@ -0,0 +1,83 @@
+package schema
+
+import (
+	"io"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/services"
+	"go.riyazali.net/sqlite"
+)
+
+var columnsCols = []vtab.Column{
+	{Name: "table_name", Type: "TEXT", Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "position", Type: "INT"},
+	{Name: "name", Type: "TEXT"},
+	{Name: "type", Type: "TEXT"},
+	{Name: "hidden", Type: "BOOLEAN"},
+}
+
+type columnsIter struct {
+	tables  []*Table
+	table   int
+	current int
+}
+
+func (i *columnsIter) Column(ctx vtab.Context, c int) error {
+	table := i.tables[i.table]
+	current := table.Columns[i.current]
+	switch columnsCols[c].Name {
+	case "table_name":
+		ctx.ResultText(table.Name)
+	case "position":
+		ctx.ResultInt(current.Position)
+	case "name":
+		ctx.ResultText(current.Name)
+	case "type":
+		ctx.ResultText(current.Type)
+	case "hidden":
+		if current.Hidden {
+			ctx.ResultInt(1)
+		} else {
+			ctx.ResultInt(0)
+		}
+	}
+	return nil
+}
+
+func (i *columnsIter) Next() (vtab.Row, error) {
+	i.current += 1
+	for i.table < len(i.tables) && i.current >= len(i.tables[i.table].Columns) {
+		i.table, i.current = i.table+1, 0
+	}
+	if i.table >= len(i.tables) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewColumnsModule returns the implementation of a table listing the columns of a registered virtual table,
+// as in askgit_columns('commits'), or of all of them when no table is given. Hidden columns are the arguments
+// of table-valued functions (passed in the order of their position), and are only returned when selected explicitly.
+func NewColumnsModule(registry *services.ModuleRegistry) sqlite.Module {
+	return vtab.NewTableFunc("askgit_columns", columnsCols, func(constraints []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var name string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 0 {
+				name = constraint.Value.Text()
+			}
+		}
+
+		var modules = registry.Modules()
+		if name != "" {
+			modules = nil
+			for _, mod := range registry.Modules() {
+				if strings.EqualFold(mod.Name, name) {
+					modules = append(modules, mod)
+				}
+			}
+		}
+
+		return &columnsIter{tables: describeAll(modules), current: -1}, nil
+	})
+}
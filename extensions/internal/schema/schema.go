@@ -0,0 +1,161 @@
+// Package schema implements tables describing the virtual tables registered by the extension, and their columns,
+// as a machine-readable source of the schema (such as for autocompletion, or generating documentation),
+// along with a function describing the build of the extension itself.
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/extensions/services"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// Table is the description of a registered virtual table
+type Table struct {
+	Name      string
+	Extension string
+
+	// AliasOf is the name of the table this one is an alias of, if any
+	AliasOf string
+
+	Columns []*Column
+}
+
+// Column is the description of a column of a virtual table
+type Column struct {
+	Position int
+	Name     string
+	Type     string
+
+	// Hidden columns are the ones the arguments of table-valued functions are passed to (in order),
+	// and that are only returned when selected explicitly
+	Hidden bool
+}
+
+// Arguments returns the names of the hidden columns of the table, in the order they're passed as arguments
+func (t *Table) Arguments() []string {
+	var args []string
+	for _, col := range t.Columns {
+		if col.Hidden {
+			args = append(args, col.Name)
+		}
+	}
+	return args
+}
+
+// Usage returns an example of how to query the table, with its arguments (if any)
+func (t *Table) Usage() string {
+	args := t.Arguments()
+	if len(args) == 0 {
+		return fmt.Sprintf("SELECT * FROM %s", t.Name)
+	}
+	return fmt.Sprintf("SELECT * FROM %s(%s)", t.Name, strings.Join(args, ", "))
+}
+
+// Describe returns the description of a registered module, out of the schema it declares
+func Describe(mod *services.RegisteredModule) (*Table, error) {
+	var declared string
+	vt, err := mod.Module.Connect(nil, nil, func(sql string) error {
+		declared = sql
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to describe %q module", mod.Name)
+	}
+	_ = vt.Disconnect()
+
+	cols, err := parseSchema(declared)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to describe %q module", mod.Name)
+	}
+
+	return &Table{Name: mod.Name, Extension: mod.Extension, AliasOf: mod.AliasOf, Columns: cols}, nil
+}
+
+// constraintKeywords are the keywords starting the table constraints of a CREATE TABLE statement (rather than a column)
+var constraintKeywords = []string{"PRIMARY", "UNIQUE", "CHECK", "FOREIGN", "CONSTRAINT"}
+
+// parseSchema parses the CREATE TABLE statement virtual tables declare, returning the columns of the table
+func parseSchema(sql string) ([]*Column, error) {
+	start, end := strings.Index(sql, "("), strings.LastIndex(sql, ")")
+	if start < 0 || end < start {
+		return nil, errors.Errorf("invalid schema: %s", sql)
+	}
+
+	// split the definitions on the commas that aren't nested in parentheses (such as in PRIMARY KEY (a, b))
+	var defs []string
+	var depth, from int
+	body := sql[start+1 : end]
+	for i, r := range body {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				defs = append(defs, body[from:i])
+				from = i + 1
+			}
+		}
+	}
+	defs = append(defs, body[from:])
+
+	var cols []*Column
+defs:
+	for _, def := range defs {
+		fields := strings.Fields(def)
+		if len(fields) == 0 {
+			continue
+		}
+		for _, keyword := range constraintKeywords {
+			if strings.EqualFold(fields[0], keyword) {
+				continue defs
+			}
+		}
+
+		var col = &Column{Position: len(cols), Name: unquote(fields[0])}
+		var typ []string
+		for _, field := range fields[1:] {
+			if strings.EqualFold(field, "HIDDEN") {
+				col.Hidden = true
+			} else {
+				typ = append(typ, field)
+			}
+		}
+		col.Type = strings.Join(typ, " ")
+		cols = append(cols, col)
+	}
+
+	return cols, nil
+}
+
+func unquote(name string) string {
+	return strings.Trim(name, "\"`[]")
+}
+
+// Register registers the askgit_tables and askgit_columns tables, describing the modules recorded in opt.Modules,
+// the askgit_last_query_stats table, reporting the statistics collected in opt.Stats, and the askgit_version function
+func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
+	var modules = map[string]sqlite.Module{
+		"askgit_tables":           NewTablesModule(opt.Modules),
+		"askgit_columns":          NewColumnsModule(opt.Modules),
+		"askgit_last_query_stats": NewLastQueryStatsModule(opt.Stats),
+	}
+
+	for name, mod := range modules {
+		if err = ext.CreateModule(name, mod); err != nil {
+			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q module", name)
+		}
+		opt.Modules.Add("schema", name, mod)
+	}
+
+	if err = ext.CreateFunction("askgit_version", NewVersionFn(opt)); err != nil {
+		return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q function", "askgit_version")
+	}
+
+	return sqlite.SQLITE_OK, nil
+}
@@ -0,0 +1,230 @@
+package schema_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/mergestat/mergestat-lite/extensions"
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
+	"go.riyazali.net/sqlite"
+)
+
+func init() {
+	// register sqlite extension when this package is loaded
+	sqlite.Register(extensions.RegisterFn(options.WithExtraFunctions(), options.WithGitHub()))
+}
+
+func TestMain(m *testing.M) { os.Exit(m.Run()) }
+
+func connect(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", "file:testing.db?mode=memory")
+	if err != nil {
+		t.Fatalf("failed to open connection: %v", err.Error())
+	}
+	return db
+}
+
+func TestTables(t *testing.T) {
+	db := connect(t)
+
+	rows, err := db.Query("SELECT name, extension, alias_of, arguments, usage FROM askgit_tables WHERE name IN ('commits', 'github_prs', 'askgit_columns') ORDER BY name")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{
+		{"askgit_columns", "schema", "NULL", "table_name", "SELECT * FROM askgit_columns(table_name)"},
+		{"commits", "git", "NULL", "repository, ref, backend, no_merges, merges_only, on_error, hint", "SELECT * FROM commits(repository, ref, backend, no_merges, merges_only, on_error, hint)"},
+		{"github_prs", "github", "github_repo_pull_requests", "owner, reponame", "SELECT * FROM github_prs(owner, reponame)"},
+	}
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d rows, got: %v", len(expected), contents)
+	}
+	for r, row := range expected {
+		for c, value := range row {
+			if contents[r][c] != value {
+				t.Fatalf("expected %q at row %d column %d, got: %q", value, r, c, contents[r][c])
+			}
+		}
+	}
+}
+
+func TestColumns(t *testing.T) {
+	db := connect(t)
+
+	rows, err := db.Query("SELECT table_name, position, name, type, hidden FROM askgit_columns('refs')")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(contents) != 11 {
+		t.Fatalf("expected 11 columns, got: %v", contents)
+	}
+	if first := contents[0]; first[0] != "refs" || first[1] != "0" || first[2] != "name" || first[3] != "TEXT" || first[4] != "0" {
+		t.Fatalf("unexpected first column: %v", first)
+	}
+	if last := contents[10]; last[2] != "error" || last[3] != "TEXT" || last[4] != "0" {
+		t.Fatalf("expected the last column to be the error column, got: %v", last)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM askgit_columns WHERE table_name = 'github_repo_issues'").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count == 0 {
+		t.Fatal("expected columns for github_repo_issues")
+	}
+}
+
+func TestVersion(t *testing.T) {
+	db := connect(t)
+
+	var js string
+	if err := db.QueryRow("SELECT askgit_version()").Scan(&js); err != nil {
+		t.Fatal(err)
+	}
+
+	var version struct {
+		GoVersion  string   `json:"go_version"`
+		Backends   []string `json:"backends"`
+		Extensions []string `json:"extensions"`
+		Modules    []string `json:"modules"`
+	}
+	if err := json.Unmarshal([]byte(js), &version); err != nil {
+		t.Fatal(err)
+	}
+
+	if version.GoVersion == "" || len(version.Backends) != 3 {
+		t.Fatalf("unexpected version: %s", js)
+	}
+	if !contains(version.Extensions, "github") || contains(version.Extensions, "npm") {
+		t.Fatalf("expected only the enabled extensions, got: %v", version.Extensions)
+	}
+	if !contains(version.Modules, "commits") || !contains(version.Modules, "askgit_tables") {
+		t.Fatalf("expected the registered modules, got: %v", version.Modules)
+	}
+}
+
+func TestLastQueryStats(t *testing.T) {
+	db := connect(t)
+
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM (SELECT hash FROM commits LIMIT 3)").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.Query("SELECT table_name, rows, commits_walked >= rows, api_requests FROM askgit_last_query_stats()")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(contents) != 1 {
+		t.Fatalf("expected the statistics of a single table, got: %v", contents)
+	}
+	if stats := contents[0]; stats[0] != "commits" || stats[1] != "3" || stats[2] != "1" || stats[3] != "0" {
+		t.Fatalf("unexpected statistics: %v", stats)
+	}
+
+	// querying the statistics doesn't reset them
+	if err := db.QueryRow("SELECT count(*) FROM askgit_last_query_stats()").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("expected the statistics of the last query to be kept, got %d tables", count)
+	}
+}
+
+func TestLastQueryStatsPerConnection(t *testing.T) {
+	db := connect(t)
+
+	// each connection reports the statistics of its own last query, even as the other one runs queries at the same time
+	var queries = []struct{ table, query string }{
+		{"commits", "SELECT count(*) FROM (SELECT hash FROM commits LIMIT 3)"},
+		{"refs", "SELECT count(*) FROM refs"},
+	}
+
+	var wg sync.WaitGroup
+	var errs = make(chan error, len(queries))
+	for _, q := range queries {
+		conn, err := db.Conn(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		wg.Add(1)
+		go func(conn *sql.Conn, table, query string) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				var count int
+				if err := conn.QueryRowContext(context.Background(), query).Scan(&count); err != nil {
+					errs <- err
+					return
+				}
+
+				var tables []string
+				rows, err := conn.QueryContext(context.Background(), "SELECT table_name FROM askgit_last_query_stats()")
+				if err != nil {
+					errs <- err
+					return
+				}
+				for rows.Next() {
+					var name string
+					if err := rows.Scan(&name); err != nil {
+						errs <- err
+						return
+					}
+					tables = append(tables, name)
+				}
+				if err := rows.Close(); err != nil {
+					errs <- err
+					return
+				}
+
+				if len(tables) != 1 || tables[0] != table {
+					errs <- fmt.Errorf("expected the statistics of %s alone, got: %v", table, tables)
+					return
+				}
+			}
+		}(conn, q.table, q.query)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
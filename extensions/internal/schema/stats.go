@@ -0,0 +1,63 @@
+package schema
+
+import (
+	"io"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/services"
+	"go.riyazali.net/sqlite"
+)
+
+var lastQueryStatsCols = []vtab.Column{
+	{Name: "table_name", Type: "TEXT"},
+	{Name: "rows", Type: "INT"},
+	{Name: "commits_walked", Type: "INT"},
+	{Name: "objects_read", Type: "INT"},
+	{Name: "api_requests", Type: "INT"},
+	{Name: "cache_hits", Type: "INT"},
+	{Name: "wall_time_ms", Type: "REAL"},
+}
+
+type lastQueryStatsIter struct {
+	tables  []services.TableStats
+	current int
+}
+
+func (i *lastQueryStatsIter) Column(ctx vtab.Context, c int) error {
+	current := i.tables[i.current]
+	switch lastQueryStatsCols[c].Name {
+	case "table_name":
+		ctx.ResultText(current.Table)
+	case "rows":
+		ctx.ResultInt64(current.Rows)
+	case "commits_walked":
+		ctx.ResultInt64(current.CommitsWalked)
+	case "objects_read":
+		ctx.ResultInt64(current.ObjectsRead)
+	case "api_requests":
+		ctx.ResultInt64(current.APIRequests)
+	case "cache_hits":
+		ctx.ResultInt64(current.CacheHits)
+	case "wall_time_ms":
+		ctx.ResultFloat(float64(current.Time.Microseconds()) / 1000)
+	}
+	return nil
+}
+
+func (i *lastQueryStatsIter) Next() (vtab.Row, error) {
+	i.current += 1
+	if i.current >= len(i.tables) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// NewLastQueryStatsModule returns the implementation of a table listing the statistics of the tables taking part in the
+// last query, in the order they were first opened: the rows they emitted, the commits they walked, the objects they read,
+// the API requests they made, the lookups they answered from a cache, and the time spent in them. Run it on its own,
+// after the query, as a query opening other tables starts a new set of statistics.
+func NewLastQueryStatsModule(stats *services.QueryStats) sqlite.Module {
+	return vtab.NewTableFunc("askgit_last_query_stats", lastQueryStatsCols, func(_ []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		return &lastQueryStatsIter{tables: stats.Tables(), current: -1}, nil
+	})
+}
@@ -0,0 +1,74 @@
+package schema
+
+import (
+	"io"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/services"
+	"go.riyazali.net/sqlite"
+)
+
+var tablesCols = []vtab.Column{
+	{Name: "name", Type: "TEXT"},
+	{Name: "extension", Type: "TEXT"},
+	{Name: "alias_of", Type: "TEXT"},
+	{Name: "arguments", Type: "TEXT"},
+	{Name: "column_count", Type: "INT"},
+	{Name: "usage", Type: "TEXT"},
+}
+
+type tablesIter struct {
+	tables  []*Table
+	current int
+}
+
+func (i *tablesIter) Column(ctx vtab.Context, c int) error {
+	current := i.tables[i.current]
+	switch tablesCols[c].Name {
+	case "name":
+		ctx.ResultText(current.Name)
+	case "extension":
+		ctx.ResultText(current.Extension)
+	case "alias_of":
+		if current.AliasOf == "" {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(current.AliasOf)
+		}
+	case "arguments":
+		ctx.ResultText(strings.Join(current.Arguments(), ", "))
+	case "column_count":
+		ctx.ResultInt(len(current.Columns))
+	case "usage":
+		ctx.ResultText(current.Usage())
+	}
+	return nil
+}
+
+func (i *tablesIter) Next() (vtab.Row, error) {
+	i.current += 1
+	if i.current >= len(i.tables) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+// describeAll describes the registered modules, skipping those that can't be described
+func describeAll(modules []*services.RegisteredModule) []*Table {
+	var tables []*Table
+	for _, mod := range modules {
+		if table, err := Describe(mod); err == nil {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// NewTablesModule returns the implementation of a table listing the virtual tables registered by the extension,
+// along with the arguments they take (as table-valued functions) and whether they're an alias of another table.
+func NewTablesModule(registry *services.ModuleRegistry) sqlite.Module {
+	return vtab.NewTableFunc("askgit_tables", tablesCols, func(_ []*vtab.Constraint, _ []*sqlite.OrderBy) (vtab.Iterator, error) {
+		return &tablesIter{tables: describeAll(registry.Modules()), current: -1}, nil
+	})
+}
@@ -0,0 +1,107 @@
+package schema
+
+import (
+	"encoding/json"
+	"runtime"
+	"runtime/debug"
+	"sort"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"go.riyazali.net/sqlite"
+)
+
+// modulePath is the path of the Go module the extension is part of, to look up its version in the build info
+const modulePath = "github.com/mergestat/mergestat-lite"
+
+// version, commit and buildDate are set at build time, with -ldflags "-X github.com/mergestat/mergestat-lite/extensions/internal/schema.version=..."
+// (see the Makefile). When unset, they're looked up in the build info Go embeds in binaries.
+var version, commit, buildDate string
+
+// Version is the description of the build of the extension returned by askgit_version()
+type Version struct {
+	Version        string   `json:"version"`
+	Commit         string   `json:"commit"`
+	BuildDate      string   `json:"build_date"`
+	GoVersion      string   `json:"go_version"`
+	Backends       []string `json:"backends"`
+	DefaultBackend string   `json:"default_backend"`
+	Extensions     []string `json:"extensions"`
+	Modules        []string `json:"modules"`
+}
+
+// buildVersion returns the version, commit and build date of the build, from the build info if not set at build time
+func buildVersion() (string, string, string) {
+	v, c, d := version, commit, buildDate
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v == "" {
+			if info.Main.Path == modulePath {
+				v = info.Main.Version
+			}
+			for _, dep := range info.Deps {
+				if dep.Path == modulePath {
+					v = dep.Version
+				}
+			}
+		}
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && c == "":
+				c = setting.Value
+			case setting.Key == "vcs.time" && d == "":
+				d = setting.Value
+			}
+		}
+	}
+
+	return v, c, d
+}
+
+// VersionFn implements askgit_version(), returning the description of the build of the extension as JSON
+type VersionFn struct {
+	Options *options.Options
+}
+
+// NewVersionFn returns a new VersionFn implementation
+func NewVersionFn(opt *options.Options) *VersionFn {
+	return &VersionFn{Options: opt}
+}
+
+func (*VersionFn) Deterministic() bool { return false }
+func (*VersionFn) Args() int           { return 0 }
+func (fn *VersionFn) Apply(c *sqlite.Context, _ ...sqlite.Value) {
+	var v = &Version{
+		GoVersion: runtime.Version(),
+		Backends:  []string{utils.BackendGoGit, utils.BackendLibgit2, utils.BackendCLI},
+	}
+	v.Version, v.Commit, v.BuildDate = buildVersion()
+
+	if backend, err := utils.GetGitBackendFromCtx(fn.Options.Context); err == nil {
+		v.DefaultBackend = backend
+	}
+
+	for extension, enabled := range map[string]bool{
+		"git":         !fn.Options.ExcludeGit,
+		"helpers":     fn.Options.ExtraFunctions,
+		"github":      fn.Options.GitHub,
+		"sourcegraph": fn.Options.Sourcegraph,
+		"npm":         fn.Options.NPM,
+	} {
+		if enabled {
+			v.Extensions = append(v.Extensions, extension)
+		}
+	}
+	sort.Strings(v.Extensions)
+
+	for _, mod := range fn.Options.Modules.Modules() {
+		v.Modules = append(v.Modules, mod.Name)
+	}
+
+	js, err := json.Marshal(v)
+	if err != nil {
+		c.ResultError(err)
+		return
+	}
+	c.ResultText(string(js))
+}
@@ -0,0 +1,202 @@
+package slack
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+// messagesPerPage is the number of messages requested per page, the most Slack recommends
+const messagesPerPage = 200
+
+type message struct {
+	Type       string `json:"type"`
+	Subtype    string `json:"subtype"`
+	TS         string `json:"ts"`
+	User       string `json:"user"`
+	BotID      string `json:"bot_id"`
+	Text       string `json:"text"`
+	ThreadTS   string `json:"thread_ts"`
+	ReplyCount int    `json:"reply_count"`
+}
+
+type historyResponse struct {
+	response
+	Messages []*message `json:"messages"`
+	HasMore  bool       `json:"has_more"`
+}
+
+type iterMessages struct {
+	*Options
+	channel string
+	oldest  string
+
+	cursor   string
+	fetched  bool
+	hasMore  bool
+	messages []*message
+	current  int
+}
+
+func (i *iterMessages) logger() *zerolog.Logger {
+	logger := i.Logger.With().Str("channel", i.channel).Str("oldest", i.oldest).Logger()
+	return &logger
+}
+
+func (i *iterMessages) Column(ctx vtab.Context, c int) error {
+	current := i.messages[i.current]
+	switch messageCols[c].Name {
+	case "ts":
+		ctx.ResultText(current.TS)
+	case "created_at":
+		if t, err := parseTS(current.TS); err == nil {
+			ctx.ResultText(t.Format(time.RFC3339Nano))
+		}
+	case "user":
+		if current.User != "" {
+			ctx.ResultText(current.User)
+		}
+	case "bot_id":
+		if current.BotID != "" {
+			ctx.ResultText(current.BotID)
+		}
+	case "subtype":
+		if current.Subtype != "" {
+			ctx.ResultText(current.Subtype)
+		}
+	case "text":
+		ctx.ResultText(current.Text)
+	case "thread_ts":
+		if current.ThreadTS != "" {
+			ctx.ResultText(current.ThreadTS)
+		}
+	case "reply_count":
+		ctx.ResultInt(current.ReplyCount)
+	}
+	return nil
+}
+
+func (i *iterMessages) Next() (vtab.Row, error) {
+	i.current += 1
+
+	for i.current >= len(i.messages) {
+		if i.fetched && !i.hasMore {
+			return nil, io.EOF
+		}
+
+		var params = url.Values{}
+		params.Set("channel", i.channel)
+		params.Set("limit", strconv.Itoa(messagesPerPage))
+		if i.oldest != "" {
+			params.Set("oldest", i.oldest)
+		}
+		if i.cursor != "" {
+			params.Set("cursor", i.cursor)
+		}
+
+		l := i.logger().With().Str("cursor", i.cursor).Logger()
+		l.Info().Msgf("fetching page of messages in %s", i.channel)
+
+		var page historyResponse
+		if err := i.call("conversations.history", params, &page); err != nil {
+			return nil, err
+		}
+
+		i.messages, i.current, i.fetched = page.Messages, 0, true
+		i.cursor = page.ResponseMetadata.NextCursor
+		i.hasMore = page.HasMore && i.cursor != ""
+	}
+
+	return i, nil
+}
+
+var messageCols = []vtab.Column{
+	{Name: "channel", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "since", Type: "TEXT", Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ts", Type: "TEXT"},
+	{Name: "created_at", Type: "DATETIME"},
+	{Name: "user", Type: "TEXT"},
+	{Name: "bot_id", Type: "TEXT"},
+	{Name: "subtype", Type: "TEXT"},
+	{Name: "text", Type: "TEXT"},
+	{Name: "thread_ts", Type: "TEXT"},
+	{Name: "reply_count", Type: "INT"},
+}
+
+// NewMessagesModule returns the implementation of a table listing the messages posted to a Slack channel (supplied by ID,
+// such as C024BE91L), newest first, optionally only the ones posted since a time (RFC3339 or YYYY-MM-DD). Thread replies
+// aren't listed, only their parent messages (along with their reply_count). The token needs the channels:history scope
+// (or groups:history for private channels), and the app needs to be a member of the channel.
+func NewMessagesModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("slack_messages", messageCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var channel, since string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					channel = constraint.Value.Text()
+				case 1:
+					since = constraint.Value.Text()
+				}
+			}
+		}
+
+		if channel == "" {
+			return nil, errors.New("slack_messages requires a channel ID")
+		}
+
+		iter := &iterMessages{Options: opts, channel: strings.TrimPrefix(channel, "#"), current: -1}
+		if since != "" {
+			t, err := parseSince(since)
+			if err != nil {
+				return nil, err
+			}
+			iter.oldest = formatTS(t)
+		}
+
+		iter.logger().Info().Msgf("starting Slack messages iterator for %s", iter.channel)
+		return iter, nil
+	})
+}
+
+// parseSince parses the time messages are listed since, either an RFC3339 timestamp or a date
+func parseSince(since string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", since)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid since %q, expected an RFC3339 timestamp or a YYYY-MM-DD date", since)
+	}
+	return t, nil
+}
+
+// parseTS parses the timestamp of a Slack message (which also identifies it in its channel), such as 1512085950.000216,
+// as seconds and microseconds since the epoch
+func parseTS(ts string) (time.Time, error) {
+	secs, micros, _ := strings.Cut(ts, ".")
+	s, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var us int64
+	if micros != "" {
+		if us, err = strconv.ParseInt(micros, 10, 64); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Unix(s, us*int64(time.Microsecond)).UTC(), nil
+}
+
+// formatTS formats t as a Slack message timestamp
+func formatTS(t time.Time) string {
+	return fmt.Sprintf("%d.%06d", t.Unix(), t.Nanosecond()/int(time.Microsecond))
+}
@@ -0,0 +1,61 @@
+package slack_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestMessages(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT * FROM slack_messages('C0123ABCD', '2024-05-01')")
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	colCount, content, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("failed to retrieve row contents: %v", err.Error())
+	}
+
+	if expected := 8; colCount != expected {
+		t.Fatalf("expected %d columns, got: %d", expected, colCount)
+	}
+
+	// messages are listed across both pages
+	if expected := 3; len(content) != expected {
+		t.Fatalf("expected %d rows, got: %d", expected, len(content))
+	}
+
+	if ts, createdAt, user, replies := content[0][0], content[0][1], content[0][2], content[0][7]; ts != "1714660200.001500" ||
+		createdAt != "2024-05-02T14:30:00.0015Z" || user != "U02ALICE" || replies != "4" {
+		t.Fatalf("unexpected message: %v", content[0])
+	}
+
+	// the last message was posted by a bot
+	if user, bot, subtype := content[2][2], content[2][3], content[2][4]; user != "NULL" || bot != "B01DEPLOY" || subtype != "bot_message" {
+		t.Fatalf("unexpected message: %v", content[2])
+	}
+}
+
+func TestMessagesChannelNotFound(t *testing.T) {
+	defer transport.Start(t)()
+
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT * FROM slack_messages('C0MISSING')")
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+		}
+		err = rows.Err()
+	}
+
+	if err == nil {
+		t.Fatalf("expected an error for a channel that doesn't exist")
+	}
+}
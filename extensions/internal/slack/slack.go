@@ -0,0 +1,114 @@
+// Package slack implements tables for querying the Slack Web API, such as to correlate the messages
+// of a channel with the history of a repository
+package slack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/extensions/services"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+	"golang.org/x/oauth2"
+)
+
+// BaseURL is the root of the Slack Web API
+const BaseURL = "https://slack.com/api"
+
+type Options struct {
+	// Client returns the http client requests to the Slack Web API are made with (authenticated with the slackToken)
+	Client func() *http.Client
+	Logger *zerolog.Logger
+	// QueryContext returns the context of the query being run, which cancels requests when done
+	QueryContext options.QueryContextFunc
+}
+
+// GetSlackTokenFromCtx looks up the slackToken key in the supplied context and returns it if set
+func GetSlackTokenFromCtx(ctx services.Context) string {
+	return ctx["slackToken"]
+}
+
+// Register registers Slack related functionality as a SQLite extension
+func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
+	httpClient := &http.Client{Transport: &oauth2.Transport{
+		Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: GetSlackTokenFromCtx(opt.Context)}),
+		Base:   opt.RetryTransport(opt.HTTPTransport),
+	}}
+
+	slackOpts := &Options{
+		Client:       func() *http.Client { return httpClient },
+		Logger:       opt.Logger,
+		QueryContext: opt.QueryContext,
+	}
+
+	if slackOpts.Logger == nil {
+		l := zerolog.Nop()
+		slackOpts.Logger = &l
+	}
+
+	var modules = map[string]sqlite.Module{
+		"slack_messages": NewMessagesModule(slackOpts),
+	}
+
+	// register Slack tables
+	for name, mod := range modules {
+		if err = ext.CreateModule(name, opt.Stats.Module(name, mod)); err != nil {
+			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register Slack %q module", name)
+		}
+		opt.Modules.Add("slack", name, mod)
+	}
+
+	return sqlite.SQLITE_OK, nil
+}
+
+// response holds the fields common to all of the Slack Web API responses
+type response struct {
+	OK               bool   `json:"ok"`
+	Error            string `json:"error"`
+	ResponseMetadata struct {
+		NextCursor string `json:"next_cursor"`
+	} `json:"response_metadata"`
+}
+
+// call calls the Slack Web API method with the supplied parameters, decoding the JSON response into out,
+// which is expected to embed response. Slack reports most errors with an ok field set to false, rather than a status.
+func (o *Options) call(method string, params url.Values, out interface{ failure() string }) error {
+	req, err := http.NewRequestWithContext(o.QueryContext.Context(), http.MethodGet, BaseURL+"/"+method+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := o.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("Slack API request to %s failed: %s", method, res.Status)
+	}
+
+	if err = json.NewDecoder(res.Body).Decode(out); err != nil {
+		return errors.Wrapf(err, "could not decode Slack API response to %s", method)
+	}
+
+	if failure := out.failure(); failure != "" {
+		return errors.Errorf("Slack API request to %s failed: %s", method, failure)
+	}
+
+	return nil
+}
+
+// failure returns the error reported by the response, if it isn't ok
+func (r *response) failure() string {
+	if r.OK {
+		return ""
+	}
+	if r.Error == "" {
+		return "unknown_error"
+	}
+	return r.Error
+}
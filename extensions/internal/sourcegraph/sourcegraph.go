@@ -1,7 +1,7 @@
 package sourcegraph
 
 import (
-	"context"
+	"net/http"
 
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/pkg/errors"
@@ -17,13 +17,15 @@ var sourcegraphUrl string = "https://sourcegraph.com/.api/graphql"
 func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
 	sourcegraphOpts := &Options{
 		Client: func() *graphql.Client {
-			httpClient := oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(
-				&oauth2.Token{AccessToken: GetSourcegraphTokenFromCtx(opt.Context)},
-			))
+			httpClient := &http.Client{Transport: &oauth2.Transport{
+				Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: GetSourcegraphTokenFromCtx(opt.Context)}),
+				Base:   opt.RetryTransport(opt.HTTPTransport),
+			}}
 			client := graphql.NewClient(sourcegraphUrl, httpClient)
 			return client
 		},
-		Logger: opt.Logger,
+		Logger:       opt.Logger,
+		QueryContext: opt.QueryContext,
 	}
 
 	if opt.SourcegraphClientGetter != nil {
@@ -41,9 +43,10 @@ func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCod
 
 	// register Sourcegraph tables
 	for name, mod := range modules {
-		if err = ext.CreateModule(name, mod); err != nil {
+		if err = ext.CreateModule(name, opt.Stats.Module(name, mod)); err != nil {
 			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register Sourcegraph %q module", name)
 		}
+		opt.Modules.Add("sourcegraph", name, mod)
 	}
 
 	return sqlite.SQLITE_OK, nil
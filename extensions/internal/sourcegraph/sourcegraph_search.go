@@ -222,7 +222,7 @@ func (i *iterResults) Column(ctx vtab.Context, c int) error {
 func (i *iterResults) Next() (vtab.Row, error) {
 	var err error
 	if i.current == -1 {
-		i.results, err = fetchSearch(context.Background(), &fetchSourcegraphOptions{i.Client(), i.query})
+		i.results, err = fetchSearch(i.QueryContext.Context(), &fetchSourcegraphOptions{i.Client(), i.query})
 		if err != nil {
 			return nil, err
 		}
@@ -1,48 +1,20 @@
 package sourcegraph_test
 
 import (
-	"context"
 	"database/sql"
 	"os"
-	"path"
 	"testing"
 
-	"github.com/dnaeon/go-vcr/v2/cassette"
-	"github.com/dnaeon/go-vcr/v2/recorder"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/mergestat/mergestat-lite/extensions"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
-	"github.com/shurcooL/graphql"
+	"github.com/mergestat/mergestat-lite/pkg/vcr"
 	"go.riyazali.net/sqlite"
-	"golang.org/x/oauth2"
 )
 
-var httpClient = oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(
-	&oauth2.Token{AccessToken: os.Getenv("SOURCEGRAPH_TOKEN")},
-))
-
-func newRecorder(t *testing.T) func() {
-	r, err := recorder.New(path.Join("fixtures", t.Name()))
-	if err != nil {
-		t.Fatal(err)
-	}
-	r.SkipRequestLatency = true
-	r.SetTransport(httpClient.Transport)
-	httpClient.Transport = r
-
-	r.AddSaveFilter(func(i *cassette.Interaction) error {
-		delete(i.Request.Headers, "Authorization")
-		return nil
-	})
-
-	return func() {
-		err := r.Stop()
-		if err != nil {
-			t.Fatal(err)
-		}
-	}
-}
+// transport replays the interactions recorded in the fixtures directory
+var transport = &vcr.Transport{}
 
 // tests' entrypoint that registers the extension
 // automatically with all loaded database connections
@@ -51,9 +23,9 @@ func TestMain(m *testing.M) {
 	sqlite.Register(extensions.RegisterFn(
 		options.WithExtraFunctions(),
 		options.WithSourcegraph(),
-		options.WithSourcegraphClientGetter(func() *graphql.Client {
-			return graphql.NewClient("https://sourcegraph.com/.api/graphql", httpClient)
-		}),
+		options.WithHTTPTransport(transport),
+		options.WithContextValue("sourcegraphToken", os.Getenv("SOURCEGRAPH_TOKEN")),
+		options.WithContextValue("httpRetries", "0"),
 	))
 	os.Exit(m.Run())
 }
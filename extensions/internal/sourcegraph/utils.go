@@ -1,6 +1,7 @@
 package sourcegraph
 
 import (
+	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/mergestat/mergestat-lite/extensions/services"
 	"github.com/rs/zerolog"
 	"github.com/shurcooL/graphql"
@@ -12,6 +13,8 @@ type Options struct {
 	RateLimiter *rate.Limiter
 	PerPage     int
 	Logger      *zerolog.Logger
+	// QueryContext returns the context of the query being run, which cancels requests when done
+	QueryContext options.QueryContextFunc
 }
 
 // GetSourcegraphTokenFromCtx looks up the sourcegraphToken key in the supplied context and returns it if set
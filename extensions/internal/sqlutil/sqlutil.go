@@ -0,0 +1,10 @@
+// Package sqlutil provides helpers shared by the modules to report values to SQLite.
+package sqlutil
+
+// BoolToInt converts a bool to the int SQLite stores booleans as (1 for true, 0 for false)
+func BoolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
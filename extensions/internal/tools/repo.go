@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Repo is a repository in a temporary directory, to make commits in for the tests of a table
+type Repo struct {
+	*git.Repository
+	Worktree *git.Worktree
+	Dir      string
+
+	// Author is the name of the author (and committer) of the next commits, with an email at example.com
+	Author string
+
+	// When is the date of the next commit. Each commit moves it on by an hour, so that commits are ordered.
+	When time.Time
+
+	t *testing.T
+}
+
+// NewRepo initializes a repository in a temporary directory, removed at the end of the test
+func NewRepo(t *testing.T) *Repo {
+	t.Helper()
+	dir := t.TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to initialize repository: %v", err)
+	}
+	return newRepo(t, repo, dir)
+}
+
+// Clone clones r into another temporary directory, such as to make a fork of it
+func (r *Repo) Clone() *Repo {
+	r.t.Helper()
+	dir := r.t.TempDir()
+
+	repo, err := git.PlainClone(dir, false, &git.CloneOptions{URL: r.Dir})
+	if err != nil {
+		r.t.Fatalf("failed to clone repository: %v", err)
+	}
+
+	clone := newRepo(r.t, repo, dir)
+	clone.Author, clone.When = r.Author, r.When
+	return clone
+}
+
+func newRepo(t *testing.T, repo *git.Repository, dir string) *Repo {
+	t.Helper()
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Repo{
+		Repository: repo,
+		Worktree:   worktree,
+		Dir:        dir,
+		Author:     "test",
+		When:       time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		t:          t,
+	}
+}
+
+// Commit writes files (paths to contents) to the worktree and commits them with message, on top of HEAD,
+// or of parents if supplied (such as to make a merge). It returns the hash of the commit.
+func (r *Repo) Commit(message string, files map[string]string, parents ...plumbing.Hash) plumbing.Hash {
+	r.t.Helper()
+	for name, contents := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(r.Dir, name)), 0755); err != nil {
+			r.t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(r.Dir, name), []byte(contents), 0644); err != nil {
+			r.t.Fatal(err)
+		}
+		if _, err := r.Worktree.Add(name); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+
+	var signature = &object.Signature{Name: r.Author, Email: r.Author + "@example.com", When: r.When}
+	hash, err := r.Worktree.Commit(message, &git.CommitOptions{Author: signature, Committer: signature, Parents: parents})
+	if err != nil {
+		r.t.Fatalf("failed to commit: %v", err)
+	}
+	r.When = r.When.Add(time.Hour)
+	return hash
+}
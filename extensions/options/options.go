@@ -31,6 +31,10 @@ type Options struct {
 	// GitHubClientGetter overrides the default GitHub v4 client
 	GitHubClientGetter func() *githubv4.Client
 
+	// GitHubHTTPClientGetter overrides the (authenticated) HTTP client of both the GitHub v4 client and the requests
+	// to the REST API, such as to cache responses. GitHubClientGetter still takes precedence for the v4 client.
+	GitHubHTTPClientGetter func() *http.Client
+
 	// GitHubRateLimitHandler overrides the default GitHub API rate limit response handler
 	GitHubRateLimitHandler func(*GitHubRateLimitResponse)
 
@@ -52,6 +56,50 @@ type Options struct {
 	// NPMHttpClient
 	NPMHttpClient *http.Client
 
+	// Slack set to true to register the Slack tables/funcs
+	Slack bool
+
+	// PagerDuty set to true to register the PagerDuty tables/funcs
+	PagerDuty bool
+
+	// ObjectStorage set to true to register the tables listing the objects of S3 and GCS buckets
+	ObjectStorage bool
+
+	// OCI set to true to register the tables querying container registries
+	OCI bool
+
+	// Kubernetes set to true to register the tables querying the clusters of kubeconfig
+	Kubernetes bool
+
+	// AllowHTTP set to true to register the http_get funcs, which make requests to arbitrary urls
+	AllowHTTP bool
+
+	// HTTPTransport overrides the transport the default clients of the API backed modules (GitHub, Sourcegraph, NPM, Slack, PagerDuty, object storage, container registries and Kubernetes)
+	// make requests with, such as to go through a proxy, authenticate with client certificates, or record and replay
+	// responses in tests. Credentials (and retries) are still handled by the modules, but for Kubernetes, the TLS configuration
+	// of the clusters in kubeconfig is then up to the transport.
+	HTTPTransport http.RoundTripper
+
+	// QueryContext returns the context of the query being run (such as the one passed to sql.DB.QueryContext, which
+	// interrupts SQLite when done). API backed modules make their requests with it, so that cancelling a query
+	// cancels the requests in flight, and stops paging through results, rather than waiting for the current table scan to end.
+	QueryContext QueryContextFunc
+
+	// HTTPRetryHook runs a function every time a request to an API is retried, such as to count retries
+	HTTPRetryHook func(req *http.Request, retry int, err error)
+
+	// Classifiers are registered as SQL functions of the same name (such as classify_commit(hash, message)), so that
+	// domain-specific labeling runs inline, as the rows of a query are scanned. They override the functions of the extension
+	// with the same name.
+	Classifiers map[string]Classifier
+
+	// Modules records the virtual table modules registered by the extension (set by RegisterFn, if nil)
+	Modules *services.ModuleRegistry
+
+	// Stats collects the statistics of the tables taking part in the last query run on a connection, such as the rows
+	// they emitted (set by RegisterFn, for each connection)
+	Stats *services.QueryStats
+
 	// Context is a key-value store to pass along values to the underlying extensions
 	Context services.Context
 
@@ -59,6 +107,10 @@ type Options struct {
 	Logger *zerolog.Logger
 }
 
+// Classifier labels something (such as a commit) from the arguments its SQL function is called with (as text,
+// NULL being an empty string). An empty label leaves it unlabeled (the function returns NULL), and an error fails the query.
+type Classifier func(args ...string) (string, error)
+
 // OptionFn represents any function capable of customising or providing options
 type OptionFn func(*Options)
 
@@ -83,6 +135,11 @@ func WithGitHubClientGetter(getter func() *githubv4.Client) OptionFn {
 	return func(o *Options) { o.GitHubClientGetter = getter }
 }
 
+// WithGitHubHTTPClientGetter configures a way to use a custom HTTP client for all GitHub API requests
+func WithGitHubHTTPClientGetter(getter func() *http.Client) OptionFn {
+	return func(o *Options) { o.GitHubHTTPClientGetter = getter }
+}
+
 // WithGitHubRateLimitHandler configures a way to use a custom GitHub API rate limit handler
 func WithGitHubRateLimitHandler(handler func(*GitHubRateLimitResponse)) OptionFn {
 	return func(o *Options) { o.GitHubRateLimitHandler = handler }
@@ -118,6 +175,47 @@ func WithNPMHttpClient(client *http.Client) OptionFn {
 	return func(o *Options) { o.NPMHttpClient = client }
 }
 
+// WithSlack configures the extension to also register the Slack related tables and funcs
+func WithSlack() OptionFn {
+	return func(o *Options) { o.Slack = true }
+}
+
+// WithPagerDuty configures the extension to also register the PagerDuty related tables and funcs
+func WithPagerDuty() OptionFn {
+	return func(o *Options) { o.PagerDuty = true }
+}
+
+// WithObjectStorage configures the extension to also register the tables listing the objects of S3 and GCS buckets
+func WithObjectStorage() OptionFn {
+	return func(o *Options) { o.ObjectStorage = true }
+}
+
+// WithOCI configures the extension to also register the tables querying container registries
+func WithOCI() OptionFn {
+	return func(o *Options) { o.OCI = true }
+}
+
+// WithKubernetes configures the extension to also register the tables querying the clusters of kubeconfig
+func WithKubernetes() OptionFn {
+	return func(o *Options) { o.Kubernetes = true }
+}
+
+// WithAllowHTTP sets whether or not to register the http_get funcs, which make requests to arbitrary urls
+func WithAllowHTTP(allow bool) OptionFn {
+	return func(o *Options) { o.AllowHTTP = allow }
+}
+
+// WithClassifier registers classifier as the SQL function name, such as WithClassifier("classify_commit", fn)
+// to call fn with the hash and message of commits with classify_commit(hash, message)
+func WithClassifier(name string, classifier Classifier) OptionFn {
+	return func(o *Options) {
+		if o.Classifiers == nil {
+			o.Classifiers = make(map[string]Classifier)
+		}
+		o.Classifiers[name] = classifier
+	}
+}
+
 // RepoLocatorFn is an adapter type that adapts any function with compatible
 // signature to a RepoLocator instance.
 type RepoLocatorFn func(ctx context.Context, path string) (*git.Repository, error)
@@ -132,6 +230,32 @@ func WithRepoLocator(loc services.RepoLocator) OptionFn {
 	return func(o *Options) { o.Locator = loc }
 }
 
+// WithHTTPTransport configures the transport the API backed modules make requests with
+func WithHTTPTransport(transport http.RoundTripper) OptionFn {
+	return func(o *Options) { o.HTTPTransport = transport }
+}
+
+// WithQueryContext configures a way to get the context of the query being run, to cancel API requests with
+func WithQueryContext(f func() context.Context) OptionFn {
+	return func(o *Options) { o.QueryContext = f }
+}
+
+// QueryContextFunc returns the context of the query being run (see Options.QueryContext)
+type QueryContextFunc func() context.Context
+
+// Context returns the context to make requests with: the one of the query being run if known, context.Background() otherwise
+func (f QueryContextFunc) Context() context.Context {
+	if f == nil {
+		return context.Background()
+	}
+	return f()
+}
+
+// WithHTTPRetryHook configures a function to run every time a request to an API is retried
+func WithHTTPRetryHook(f func(req *http.Request, retry int, err error)) OptionFn {
+	return func(o *Options) { o.HTTPRetryHook = f }
+}
+
 // WithContextValue sets a value on the options context.
 // It will override any existing value set with the same key
 func WithContextValue(key, value string) OptionFn {
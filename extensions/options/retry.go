@@ -0,0 +1,68 @@
+package options
+
+import (
+	"net/http"
+
+	"github.com/mergestat/mergestat-lite/extensions/services"
+	"github.com/mergestat/mergestat-lite/pkg/httpretry"
+)
+
+// defaultHTTPRetries is the number of times requests to APIs are retried, unless set with the httpRetries context value
+const defaultHTTPRetries = 3
+
+// RetryTransport wraps base (or http.DefaultTransport if nil, which goes through the proxy set by the environment) to retry requests to APIs failing transiently,
+// according to the httpRetries (count), httpRetryBackoff (exponential or constant, optionally with a base duration,
+// as in constant:5s) and httpRetryStatuses (such as 502,503,504) context values. Retries are logged, and passed to the HTTPRetryHook.
+// Invalid values are logged and ignored, in favor of the defaults. Requests (retries included) are counted in the Stats of the query.
+func (o *Options) RetryTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if o.Stats != nil {
+		base = &statsTransport{stats: o.Stats, transport: base}
+	}
+
+	retries, ok := o.Context.GetInt("httpRetries")
+	if !ok {
+		retries = defaultHTTPRetries
+	}
+	if retries <= 0 {
+		return base
+	}
+
+	backoff, err := httpretry.ParseBackoff(o.Context["httpRetryBackoff"])
+	if err != nil && o.Logger != nil {
+		o.Logger.Warn().Err(err).Msgf("ignoring HTTP retry backoff")
+	}
+
+	statuses, err := httpretry.ParseStatuses(o.Context["httpRetryStatuses"])
+	if err != nil && o.Logger != nil {
+		o.Logger.Warn().Err(err).Msgf("ignoring HTTP retry statuses")
+	}
+
+	return &httpretry.Transport{
+		Retries:  retries,
+		Backoff:  backoff,
+		Statuses: statuses,
+		OnRetry: func(req *http.Request, retry int, err error) {
+			if o.Logger != nil {
+				o.Logger.Warn().Err(err).Int("retry", retry).Int("max-retries", retries).Msgf("retrying %s %s", req.Method, req.URL)
+			}
+			if o.HTTPRetryHook != nil {
+				o.HTTPRetryHook(req, retry, err)
+			}
+		},
+		Transport: base,
+	}
+}
+
+// statsTransport counts the requests made through transport as API requests of the table making them
+type statsTransport struct {
+	stats     *services.QueryStats
+	transport http.RoundTripper
+}
+
+func (t *statsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.stats.Count(services.APIRequests, 1)
+	return t.transport.RoundTrip(req)
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"sort"
+	"sync"
+
+	"go.riyazali.net/sqlite"
+)
+
+// RegisteredModule is a virtual table module registered by the extension
+type RegisteredModule struct {
+	// Name is the name the module is registered under (aliases of a module are registered under their own name)
+	Name string
+
+	// Extension is the name of the set of modules the module is part of (such as git or github)
+	Extension string
+
+	// AliasOf is the name of the module this one is an alias of, if any
+	AliasOf string
+
+	Module sqlite.Module
+}
+
+// ModuleRegistry records the virtual table modules registered by the extension, so that they can be described,
+// such as by the askgit_tables and askgit_columns tables. Its methods are safe to call on a nil registry.
+type ModuleRegistry struct {
+	mu      sync.Mutex
+	modules map[string]*RegisteredModule
+}
+
+// Add records a module registered under name, replacing any module previously registered under the same name
+func (r *ModuleRegistry) Add(extension, name string, mod sqlite.Module) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.modules == nil {
+		r.modules = make(map[string]*RegisteredModule)
+	}
+	r.modules[name] = &RegisteredModule{Name: name, Extension: extension, Module: mod}
+}
+
+// Alias records that the module registered under alias is an alias of the one registered under name
+func (r *ModuleRegistry) Alias(alias, name string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// registered modules are never modified in place, as they may be in use
+	if mod, ok := r.modules[alias]; ok {
+		aliased := *mod
+		aliased.AliasOf = name
+		r.modules[alias] = &aliased
+	}
+}
+
+// Modules returns the registered modules, sorted by name
+func (r *ModuleRegistry) Modules() []*RegisteredModule {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var modules = make([]*RegisteredModule, 0, len(r.modules))
+	for _, mod := range r.modules {
+		modules = append(modules, mod)
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Name < modules[j].Name })
+	return modules
+}
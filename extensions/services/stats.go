@@ -0,0 +1,208 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"go.riyazali.net/sqlite"
+)
+
+// Counter is a statistic the tables taking part in a query count, on top of the rows they emit and the time they take
+type Counter int
+
+const (
+	// CommitsWalked counts the commits read while walking the history of a repository
+	CommitsWalked Counter = iota
+
+	// ObjectsRead counts the git objects (such as blobs) read from a repository
+	ObjectsRead
+
+	// APIRequests counts the requests made to APIs (including retries)
+	APIRequests
+
+	// CacheHits counts the lookups answered from a cache (such as of parsed .mailmap files)
+	CacheHits
+)
+
+// TableStats are the statistics of a table taking part in a query
+type TableStats struct {
+	Table         string
+	Rows          int64
+	CommitsWalked int64
+	ObjectsRead   int64
+	APIRequests   int64
+	CacheHits     int64
+
+	// Time is the time spent in the table itself (filtering, advancing and reading columns), as opposed to in SQLite
+	Time time.Duration
+}
+
+// QueryStats collects the statistics of the tables taking part in the last query run on a connection, such as to report
+// the cost breakdown of a query with askgit_last_query_stats(). Each connection must have its own, as queries run on
+// other connections at the same time aren't part of it. A query starts when a table is opened while no other one is,
+// resetting the statistics of the previous query, and ends when they're all closed. Its methods are safe to call
+// on a nil collector, and from other goroutines (such as those making the requests of a table).
+type QueryStats struct {
+	mu      sync.Mutex
+	open    int                    // cursors currently open
+	current *TableStats            // the table whose cursor is currently being called into, if any
+	tables  map[string]*TableStats // statistics of the tables of the query, by name
+	order   []string               // names of the tables of the query, in the order they were first opened
+}
+
+// Tables returns the statistics of the tables taking part in the last query, in the order they were first opened
+func (s *QueryStats) Tables() []TableStats {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var tables = make([]TableStats, 0, len(s.order))
+	for _, name := range s.order {
+		tables = append(tables, *s.tables[name])
+	}
+	return tables
+}
+
+// Count adds n to counter of the table currently being called into. Counts made outside of tables (such as by
+// scalar functions) aren't part of any table's statistics, and are dropped.
+func (s *QueryStats) Count(counter Counter, n int64) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil {
+		return
+	}
+
+	switch counter {
+	case CommitsWalked:
+		s.current.CommitsWalked += n
+	case ObjectsRead:
+		s.current.ObjectsRead += n
+	case APIRequests:
+		s.current.APIRequests += n
+	case CacheHits:
+		s.current.CacheHits += n
+	}
+}
+
+// Module wraps mod, registered under name, to collect its statistics. It returns mod itself if s is nil.
+func (s *QueryStats) Module(name string, mod sqlite.Module) sqlite.Module {
+	if s == nil {
+		return mod
+	}
+	return &statsModule{Module: mod, stats: s, name: name}
+}
+
+// opened records that a cursor of the table named name was opened, starting a new query if it's the only one open
+func (s *QueryStats) opened(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.open == 0 {
+		s.tables, s.order = make(map[string]*TableStats), nil
+	}
+	s.open++
+
+	if _, ok := s.tables[name]; !ok {
+		s.tables[name] = &TableStats{Table: name}
+		s.order = append(s.order, name)
+	}
+}
+
+func (s *QueryStats) closed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.open--
+}
+
+// enter records that the table named name is being called into, until the returned function is called with
+// whether the cursor of the table is now positioned on a row
+func (s *QueryStats) enter(name string) (exit func(row bool)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var table, previous, start = s.tables[name], s.current, time.Now()
+	s.current = table
+
+	return func(row bool) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.current = previous
+		if table == nil {
+			return
+		}
+		table.Time += time.Since(start)
+		if row {
+			table.Rows++
+		}
+	}
+}
+
+type statsModule struct {
+	sqlite.Module
+	stats *QueryStats
+	name  string
+}
+
+func (m *statsModule) Connect(conn *sqlite.Conn, args []string, declare func(string) error) (sqlite.VirtualTable, error) {
+	table, err := m.Module.Connect(conn, args, declare)
+	if err != nil {
+		return nil, err
+	}
+	return &statsTable{VirtualTable: table, module: m}, nil
+}
+
+type statsTable struct {
+	sqlite.VirtualTable
+	module *statsModule
+}
+
+func (t *statsTable) Open() (sqlite.VirtualCursor, error) {
+	cursor, err := t.VirtualTable.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	t.module.stats.opened(t.module.name)
+	return &statsCursor{VirtualCursor: cursor, module: t.module}, nil
+}
+
+type statsCursor struct {
+	sqlite.VirtualCursor
+	module *statsModule
+	closed bool
+}
+
+func (c *statsCursor) Filter(idxNum int, idxStr string, values ...sqlite.Value) error {
+	exit := c.module.stats.enter(c.module.name)
+	err := c.VirtualCursor.Filter(idxNum, idxStr, values...)
+	exit(err == nil && !c.VirtualCursor.Eof())
+	return err
+}
+
+func (c *statsCursor) Next() error {
+	exit := c.module.stats.enter(c.module.name)
+	err := c.VirtualCursor.Next()
+	exit(err == nil && !c.VirtualCursor.Eof())
+	return err
+}
+
+func (c *statsCursor) Column(ctx *sqlite.VirtualTableContext, col int) error {
+	exit := c.module.stats.enter(c.module.name)
+	defer exit(false)
+	return c.VirtualCursor.Column(ctx, col)
+}
+
+func (c *statsCursor) Close() error {
+	if !c.closed {
+		c.closed = true
+		c.module.stats.closed()
+	}
+	return c.VirtualCursor.Close()
+}
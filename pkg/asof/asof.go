@@ -0,0 +1,139 @@
+// Package asof implements the AS OF clause of queries, as in `SELECT count(*) FROM files AS OF 'v1.0.0'`,
+// which sets the ref the git tables of a statement default to (instead of HEAD), so that historical queries
+// joining several tables don't have to repeat the ref constraint on each of them.
+package asof
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Rewrite removes the AS OF '<ref>' clauses of query, returning the query without them along with the ref they set
+// (or an empty string if there's none). A clause may follow any table of the statement, or end it, and applies to
+// all of its git tables (but the ones supplied a ref explicitly). Clauses setting different refs are an error.
+func Rewrite(query string) (string, string, error) {
+	var out strings.Builder
+	var ref string
+	var found bool
+
+	for i := 0; i < len(query); {
+		start := i
+		switch c := query[i]; {
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			i, _ = skipQuoted(query, i)
+		case strings.HasPrefix(query[i:], "--"):
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(query)
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(query)
+			}
+		case isWordChar(c):
+			for i < len(query) && isWordChar(query[i]) {
+				i++
+			}
+
+			if !strings.EqualFold(query[start:i], "AS") {
+				break
+			}
+
+			// AS followed by OF and a string literal is a clause, anything else an alias
+			clauseRef, end, ok := parseClause(query, i)
+			if !ok {
+				break
+			}
+			if found && clauseRef != ref {
+				return "", "", errors.Errorf("conflicting AS OF clauses: %q and %q", ref, clauseRef)
+			}
+			ref, found = clauseRef, true
+
+			out.WriteString(" ")
+			i = end
+			continue
+		default:
+			i++
+		}
+		out.WriteString(query[start:i])
+	}
+
+	if found && ref == "" {
+		return "", "", errors.New("invalid AS OF clause: the ref is empty")
+	}
+	return out.String(), ref, nil
+}
+
+// parseClause parses the OF '<ref>' following an AS at i, returning the ref and the position right after the clause
+func parseClause(query string, i int) (ref string, end int, ok bool) {
+	i = skipSpace(query, i)
+	if i+2 > len(query) || !strings.EqualFold(query[i:i+2], "OF") || (i+2 < len(query) && isWordChar(query[i+2])) {
+		return "", 0, false
+	}
+
+	i = skipSpace(query, i+2)
+	if i >= len(query) || query[i] != '\'' {
+		return "", 0, false
+	}
+
+	end, terminated := skipQuoted(query, i)
+	if !terminated {
+		return "", 0, false
+	}
+	return strings.ReplaceAll(query[i+1:end-1], "''", "'"), end, true
+}
+
+// skipQuoted returns the position right after the string literal or quoted identifier starting at i,
+// or the end of query if it's unterminated (leaving sqlite to report the error)
+func skipQuoted(query string, i int) (int, bool) {
+	closing := query[i]
+	if closing == '[' {
+		closing = ']'
+	}
+
+	for j := i + 1; j < len(query); j++ {
+		if query[j] != closing {
+			continue
+		}
+		// quotes are escaped by doubling them, as in 'it''s'
+		if closing != ']' && j+1 < len(query) && query[j+1] == closing {
+			j++
+			continue
+		}
+		return j + 1, true
+	}
+	return len(query), false
+}
+
+// skipSpace returns the position of the first character at or after i that isn't whitespace or part of a comment
+func skipSpace(query string, i int) int {
+	for i < len(query) {
+		switch {
+		case query[i] == ' ' || query[i] == '\t' || query[i] == '\n' || query[i] == '\r':
+			i++
+		case strings.HasPrefix(query[i:], "--"):
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(query)
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(query)
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
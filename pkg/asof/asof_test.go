@@ -0,0 +1,35 @@
+package asof_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/pkg/asof"
+)
+
+func TestRewrite(t *testing.T) {
+	for _, test := range []struct {
+		query, expected, ref string
+	}{
+		{"SELECT count(*) FROM files AS OF 'v1.0.0'", "SELECT count(*) FROM files  ", "v1.0.0"},
+		{"SELECT * FROM files f as of 'v1' JOIN blame_summary b AS OF 'v1' USING (path)", "SELECT * FROM files f   JOIN blame_summary b   USING (path)", "v1"},
+		{"SELECT * FROM commits AS OF\n  'it''s'", "SELECT * FROM commits  ", "it's"},
+		{"SELECT hash AS of_hash FROM commits", "SELECT hash AS of_hash FROM commits", ""},
+		{"SELECT 'AS OF ''v1''' AS \"AS OF 'v2'\" -- AS OF 'v3'\nFROM commits", "SELECT 'AS OF ''v1''' AS \"AS OF 'v2'\" -- AS OF 'v3'\nFROM commits", ""},
+		{"SELECT * FROM files /* AS OF 'v1' */ AS OF /* the release */ 'v2'", "SELECT * FROM files /* AS OF 'v1' */  ", "v2"},
+		{"SELECT * FROM files WHERE path = 'unterminated", "SELECT * FROM files WHERE path = 'unterminated", ""},
+	} {
+		query, ref, err := asof.Rewrite(test.query)
+		if err != nil {
+			t.Fatalf("failed to rewrite %q: %v", test.query, err)
+		}
+		if query != test.expected || ref != test.ref {
+			t.Fatalf("expected %q rewritten into %q (ref %q), got %q (ref %q)", test.query, test.expected, test.ref, query, ref)
+		}
+	}
+
+	for _, invalid := range []string{"SELECT * FROM files AS OF 'v1' JOIN commits AS OF 'v2'", "SELECT * FROM files AS OF ''"} {
+		if _, _, err := asof.Rewrite(invalid); err == nil {
+			t.Fatalf("expected an error rewriting %q", invalid)
+		}
+	}
+}
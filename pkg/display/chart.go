@@ -0,0 +1,328 @@
+package display
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	// ChartBar is a horizontal bar chart, with a bar for each row
+	ChartBar = "bar"
+	// ChartLine is a line chart, plotting the rows in order (such as a time series)
+	ChartLine = "line"
+)
+
+// ChartOptions configures how a resultset is charted
+type ChartOptions struct {
+	Type string // ChartBar (the default) or ChartLine
+	X    string // the column labelling the points (the first column, if empty)
+	Y    string // the column holding the values of the points (the second column, if empty)
+
+	// SVG renders the chart as an SVG image, rather than as text for the terminal
+	SVG bool
+	// Width is the width of the chart, in characters (the width of the terminal if 0) or pixels (800 if 0) for SVG
+	Width int
+}
+
+// chartHeight is the height of text line charts, in lines
+const chartHeight = 12
+
+// chartPoint is a labelled value of a chart
+type chartPoint struct {
+	label string
+	value float64
+}
+
+// WriteChart renders rows as a chart of the values of a column, labelled by another column, to w.
+// Rows with a NULL or non-numeric value are left out.
+func WriteChart(rows *sql.Rows, w io.Writer, opts ChartOptions) error {
+	points, err := readChartPoints(rows, opts.X, opts.Y)
+	if err != nil {
+		return err
+	}
+
+	switch opts.Type {
+	case "", ChartBar:
+		if opts.SVG {
+			return svgBarChart(w, points, opts.Width)
+		}
+		return textBarChart(w, points, opts.Width)
+	case ChartLine:
+		if opts.SVG {
+			return svgLineChart(w, points, opts.Width)
+		}
+		return textLineChart(w, points, opts.Width)
+	default:
+		return fmt.Errorf("unknown chart type %q: expected %s or %s", opts.Type, ChartBar, ChartLine)
+	}
+}
+
+// readChartPoints reads the points of a chart out of rows, from the columns named x and y
+func readChartPoints(rows *sql.Rows, x, y string) ([]chartPoint, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var xi, yi = 0, 1
+	if x != "" {
+		if xi = indexOf(columns, x); xi < 0 {
+			return nil, fmt.Errorf("unknown x column %q", x)
+		}
+	}
+	if y != "" {
+		if yi = indexOf(columns, y); yi < 0 {
+			return nil, fmt.Errorf("unknown y column %q", y)
+		}
+	} else if len(columns) < 2 {
+		return nil, fmt.Errorf("expected a column of labels and a column of values to chart")
+	}
+
+	pointers := make([]interface{}, len(columns))
+	container := make([]sql.NullString, len(columns))
+	for i := range pointers {
+		pointers[i] = &container[i]
+	}
+
+	var points []chartPoint
+	for rows.Next() {
+		if err = rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		if !container[yi].Valid {
+			continue
+		}
+		value, err := strconv.ParseFloat(container[yi].String, 64)
+		if err != nil {
+			continue
+		}
+		points = append(points, chartPoint{label: container[xi].String, value: value})
+	}
+	return points, rows.Err()
+}
+
+func indexOf(columns []string, name string) int {
+	for i, column := range columns {
+		if strings.EqualFold(column, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// chartWidth returns width, or the width of the terminal if 0
+func chartWidth(width int) int {
+	if width > 0 {
+		return width
+	}
+	if width, _, err := term.GetSize(0); err == nil && width > 0 {
+		return width
+	}
+	return 80
+}
+
+// formatValue formats a value of a chart, without trailing zeros
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// valueRange returns the smallest and largest values of points, including 0 so that bars and lines start at the axis
+func valueRange(points []chartPoint) (min, max float64) {
+	for _, p := range points {
+		min, max = math.Min(min, p.value), math.Max(max, p.value)
+	}
+	return min, max
+}
+
+// bar returns a bar of the given length in characters, using eighths of blocks for the fractional part
+func bar(length float64) string {
+	var eighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+	full := int(length)
+	return strings.Repeat("█", full) + eighths[int((length-float64(full))*8)]
+}
+
+// textBarChart renders points as a horizontal bar for each, labelled with its label and value. Negative values
+// have an empty bar.
+func textBarChart(w io.Writer, points []chartPoint, width int) error {
+	var labelWidth, valueWidth int
+	for _, p := range points {
+		if n := utf8.RuneCountInString(p.label); n > labelWidth {
+			labelWidth = n
+		}
+		if n := len(formatValue(p.value)); n > valueWidth {
+			valueWidth = n
+		}
+	}
+
+	_, max := valueRange(points)
+	var barWidth = float64(chartWidth(width) - labelWidth - valueWidth - 3)
+	if barWidth < 1 {
+		barWidth = 1
+	}
+
+	for _, p := range points {
+		var length float64
+		if max > 0 && p.value > 0 {
+			length = p.value / max * barWidth
+		}
+		padding := strings.Repeat(" ", labelWidth-utf8.RuneCountInString(p.label))
+		if _, err := fmt.Fprintf(w, "%s%s │%s %s\n", padding, p.label, bar(length), formatValue(p.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// textLineChart renders points as a line chart plotting them in order, with the range of values on the y axis
+// and the labels of the first and last points on the x axis. Points are averaged together when there are more
+// of them than columns of the chart.
+func textLineChart(w io.Writer, points []chartPoint, width int) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	min, max := valueRange(points)
+	var axisWidth = len(formatValue(max))
+	if n := len(formatValue(min)); n > axisWidth {
+		axisWidth = n
+	}
+
+	var columns = chartWidth(width) - axisWidth - 2
+	if columns < 1 {
+		columns = 1
+	}
+	values := bucketValues(points, columns)
+
+	var grid = make([][]rune, chartHeight)
+	for r := range grid {
+		grid[r] = []rune(strings.Repeat(" ", len(values)))
+	}
+	for c, v := range values {
+		var r = chartHeight - 1
+		if max > min {
+			r = int(math.Round((max - v) / (max - min) * float64(chartHeight-1)))
+		}
+		grid[r][c] = '•'
+	}
+
+	for r, line := range grid {
+		var axis string
+		switch r {
+		case 0:
+			axis = formatValue(max)
+		case chartHeight - 1:
+			axis = formatValue(min)
+		}
+		if _, err := fmt.Fprintf(w, "%*s │%s\n", axisWidth, axis, strings.TrimRight(string(line), " ")); err != nil {
+			return err
+		}
+	}
+
+	first, last := points[0].label, points[len(points)-1].label
+	gap := len(values) - utf8.RuneCountInString(first) - utf8.RuneCountInString(last)
+	if gap < 1 {
+		gap = 1
+	}
+	_, err := fmt.Fprintf(w, "%*s └%s\n%*s  %s%s%s\n", axisWidth, "", strings.Repeat("─", len(values)),
+		axisWidth, "", first, strings.Repeat(" ", gap), last)
+	return err
+}
+
+// bucketValues returns the values of points, averaged into at most n buckets of consecutive points
+func bucketValues(points []chartPoint, n int) []float64 {
+	if len(points) <= n {
+		var values = make([]float64, len(points))
+		for i, p := range points {
+			values[i] = p.value
+		}
+		return values
+	}
+
+	var values = make([]float64, n)
+	for b := range values {
+		start, end := b*len(points)/n, (b+1)*len(points)/n
+		var sum float64
+		for _, p := range points[start:end] {
+			sum += p.value
+		}
+		values[b] = sum / float64(end-start)
+	}
+	return values
+}
+
+// svgWidth returns width, or the default width of SVG charts if 0
+func svgWidth(width int) int {
+	if width > 0 {
+		return width
+	}
+	return 800
+}
+
+// svgBarChart renders points as an SVG image of a horizontal bar for each, labelled with its label and value
+func svgBarChart(w io.Writer, points []chartPoint, width int) error {
+	const barHeight, gap, labelWidth, valueWidth = 20, 6, 160, 60
+	var chartWidth = float64(svgWidth(width) - labelWidth - valueWidth)
+	var height = len(points)*(barHeight+gap) + gap
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", svgWidth(width), height)
+
+	_, max := valueRange(points)
+	for i, p := range points {
+		var length float64
+		if max > 0 && p.value > 0 {
+			length = p.value / max * chartWidth
+		}
+		y := gap + i*(barHeight+gap)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", labelWidth-6, y+barHeight-5, escapeXML(p.label))
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="#4e79a7"/>`+"\n", labelWidth, y, length, barHeight)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d">%s</text>`+"\n", float64(labelWidth)+length+6, y+barHeight-5, formatValue(p.value))
+	}
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// svgLineChart renders points as an SVG image of a line chart plotting them in order, with the range of values
+// on the y axis and the labels of the first and last points on the x axis
+func svgLineChart(w io.Writer, points []chartPoint, width int) error {
+	const height, margin = 300, 50
+	var plotWidth, plotHeight = float64(svgWidth(width) - 2*margin), float64(height - 2*margin)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", svgWidth(width), height)
+	fmt.Fprintf(&b, `<path d="M%d %d V%d H%d" fill="none" stroke="#888"/>`+"\n", margin, margin, height-margin, svgWidth(width)-margin)
+
+	if len(points) > 0 {
+		min, max := valueRange(points)
+		var coordinates = make([]string, len(points))
+		for i, p := range points {
+			var x, y = float64(margin), float64(height - margin)
+			if len(points) > 1 {
+				x += float64(i) / float64(len(points)-1) * plotWidth
+			}
+			if max > min {
+				y -= (p.value - min) / (max - min) * plotHeight
+			}
+			coordinates[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="#4e79a7" stroke-width="2"/>`+"\n", strings.Join(coordinates, " "))
+
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", margin-6, margin+4, formatValue(max))
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", margin-6, height-margin+4, formatValue(min))
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`+"\n", margin, height-margin+18, escapeXML(points[0].label))
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", svgWidth(width)-margin, height-margin+18, escapeXML(points[len(points)-1].label))
+	}
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
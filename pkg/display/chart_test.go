@@ -0,0 +1,106 @@
+package display
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestChartBar(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+
+	mockRows := sqlmock.NewRows([]string{"author", "commits"}).
+		AddRow("jane", int64(10)).
+		AddRow("bob", int64(5)).
+		AddRow("eve", nil)
+
+	mock.ExpectQuery("select").WillReturnRows(mockRows)
+
+	rows, _ := db.Query("select")
+
+	var b bytes.Buffer
+	if err := WriteChart(rows, &b, ChartOptions{Width: 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the bars are 30 - 4 (label) - 2 (value) - 3 characters wide at most
+	expected := "jane │" + strings.Repeat("█", 21) + " 10\n" +
+		" bob │" + strings.Repeat("█", 10) + "▌ 5\n"
+	if b.String() != expected {
+		t.Fatalf("unexpected chart:\n%s\nexpected:\n%s", b.String(), expected)
+	}
+}
+
+func TestChartLine(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+
+	mockRows := sqlmock.NewRows([]string{"month", "commits", "additions"}).
+		AddRow("2022-01", int64(1), int64(10)).
+		AddRow("2022-02", int64(4), int64(20)).
+		AddRow("2022-03", int64(2), int64(30))
+
+	mock.ExpectQuery("select").WillReturnRows(mockRows)
+
+	rows, _ := db.Query("select")
+
+	var b bytes.Buffer
+	if err := WriteChart(rows, &b, ChartOptions{Type: ChartLine, Y: "additions", Width: 40}); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != chartHeight+2 {
+		t.Fatalf("expected %d lines, got: %d\n%s", chartHeight+2, len(lines), b.String())
+	}
+	if lines[0] != "30 │  •" || lines[chartHeight-1] != " 0 │" {
+		t.Fatalf("unexpected y axis:\n%s", b.String())
+	}
+	if lines[chartHeight+1] != "    2022-01 2022-03" {
+		t.Fatalf("unexpected x axis: %q", lines[chartHeight+1])
+	}
+}
+
+func TestChartSVG(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery("select").WillReturnRows(sqlmock.NewRows([]string{"label", "value"}).
+			AddRow("<a>", 1.5).
+			AddRow("b", 3.0))
+	}
+
+	var b bytes.Buffer
+	rows, _ := db.Query("select")
+	if err := WriteChart(rows, &b, ChartOptions{Type: ChartBar, SVG: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{`<svg xmlns="http://www.w3.org/2000/svg" width="800"`, `<rect `, `&lt;a&gt;`, `>1.5</text>`} {
+		if !strings.Contains(b.String(), expected) {
+			t.Fatalf("expected the bar chart to contain %s, got: %s", expected, b.String())
+		}
+	}
+
+	b.Reset()
+	rows, _ = db.Query("select")
+	if err := WriteChart(rows, &b, ChartOptions{Type: ChartLine, SVG: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `<polyline points="50.0,150.0 750.0,50.0"`) {
+		t.Fatalf("unexpected line chart: %s", b.String())
+	}
+}
+
+func TestChartErrors(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	for i := 0; i < 3; i++ {
+		mock.ExpectQuery("select").WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(int64(1)))
+	}
+
+	for _, opts := range []ChartOptions{{}, {Y: "missing"}, {Type: "pie", Y: "value"}} {
+		rows, _ := db.Query("select")
+		if err := WriteChart(rows, &bytes.Buffer{}, opts); err == nil {
+			t.Fatalf("expected charting with %+v to fail", opts)
+		}
+	}
+}
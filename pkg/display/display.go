@@ -47,6 +47,18 @@ func WriteTo(rows *sql.Rows, w io.Writer, format string, interactive bool) error
 		if err != nil {
 			return err
 		}
+	case "chart":
+		if err := WriteChart(rows, w, ChartOptions{}); err != nil {
+			return err
+		}
+	case "xlsx":
+		x := NewXLSXWriter(w)
+		if err := x.AddSheet("", rows); err != nil {
+			return err
+		}
+		if err := x.Close(); err != nil {
+			return err
+		}
 	default:
 		err := tableDisplay(rows, w, interactive)
 		if err != nil {
@@ -165,9 +177,9 @@ func ndjsonDisplay(rows *sql.Rows, writer io.Writer) error {
 	return nil
 }
 
+// jsonDisplay writes the rows as a JSON array, encoding and writing each row as it's read
+// so that memory use stays bounded regardless of the size of the resultset
 func jsonDisplay(rows *sql.Rows, writer io.Writer) error {
-	buffer := make([]interface{}, 0)
-
 	columns, err := rows.Columns()
 	if err != nil {
 		return err
@@ -178,7 +190,11 @@ func jsonDisplay(rows *sql.Rows, writer io.Writer) error {
 		values[i] = new(interface{})
 	}
 
-	for rows.Next() {
+	if _, err = writer.Write([]byte("[")); err != nil {
+		return err
+	}
+
+	for first := true; rows.Next(); first = false {
 		err = rows.Scan(values...)
 		if err != nil {
 			return err
@@ -190,20 +206,31 @@ func jsonDisplay(rows *sql.Rows, writer io.Writer) error {
 			dest[column] = *(values[i].(*interface{}))
 		}
 
-		buffer = append(buffer, dest)
-	}
+		out, err := json.Marshal(dest)
+		if err != nil {
+			return err
+		}
+
+		if !first {
+			out = append([]byte(","), out...)
+		}
 
-	if out, err := json.Marshal(buffer); err != nil {
-		return err
-	} else {
 		if _, err := writer.Write(out); err != nil {
 			return err
 		}
 	}
 
+	if _, err = writer.Write([]byte("]")); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// tablePageSize is the number of rows rendered in a table at a time. Aligning the columns of a table takes all of its rows,
+// so large results are rendered as a series of tables (each with a header) rather than held in memory all at once.
+var tablePageSize = 1000
+
 func tableDisplay(rows *sql.Rows, write io.Writer, overflow bool) error {
 	columns, err := rows.Columns()
 	if err != nil {
@@ -219,20 +246,27 @@ func tableDisplay(rows *sql.Rows, write io.Writer, overflow bool) error {
 		pointers[i] = &container[i]
 	}
 
-	t := table.NewWriter()
-	t.Style().Options.SeparateRows = true
+	width := 0
 	if !overflow {
-		width, _, err := term.GetSize(0)
-		if err != nil {
+		if width, _, err = term.GetSize(0); err != nil {
 			//  TODO - getting terminal size seems to fail with `operation not supported by device` in tests
 			//  as a workaround for now, set a default width instead of returning an error, if one is encountered
 			width = 500
 		}
-		t.SetAllowedRowLength(width)
 	}
-	t.AppendHeader(cols)
-	t.SetOutputMirror(write)
 
+	newTable := func() table.Writer {
+		t := table.NewWriter()
+		t.Style().Options.SeparateRows = true
+		if !overflow {
+			t.SetAllowedRowLength(width)
+		}
+		t.AppendHeader(cols)
+		t.SetOutputMirror(write)
+		return t
+	}
+
+	t, pageRows := newTable(), 0
 	for rows.Next() {
 		err := rows.Scan(pointers...)
 		if err != nil {
@@ -248,10 +282,12 @@ func tableDisplay(rows *sql.Rows, write io.Writer, overflow bool) error {
 			}
 		}
 
-		t.AppendRow(r)
-		if err != nil {
-			return err
+		if pageRows == tablePageSize {
+			t.Render()
+			t, pageRows = newTable(), 0
 		}
+		t.AppendRow(r)
+		pageRows++
 	}
 
 	t.Render()
@@ -281,3 +281,34 @@ func TestDisplaySingle(t *testing.T) {
 		t.Fatalf("expected output to be the only the first column of the first row: %s, got: %s", "1", b.String())
 	}
 }
+
+func TestDisplayTablePages(t *testing.T) {
+	defer func(size int) { tablePageSize = size }(tablePageSize)
+	tablePageSize = 2
+
+	db, mock, _ := sqlmock.New()
+
+	mockRows := sqlmock.NewRows([]string{"id", "name"}).
+		AddRow("1", "name 1").
+		AddRow("2", "name 2").
+		AddRow("3", "name 3")
+
+	mock.ExpectQuery("select").WillReturnRows(mockRows)
+
+	rows, _ := db.Query("select")
+
+	var b bytes.Buffer
+	if err := WriteTo(rows, &b, "table", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// the rows are rendered in two tables, each with its own header
+	if headers := strings.Count(b.String(), "| ID | NAME"); headers != 2 {
+		t.Fatalf("expected 2 tables, got %d in:\n%s", headers, b.String())
+	}
+	for _, row := range []string{"name 1", "name 2", "name 3"} {
+		if !strings.Contains(b.String(), row) {
+			t.Fatalf("expected %q in:\n%s", row, b.String())
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package display
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSheetName is the most characters a sheet name may have
+const maxSheetName = 31
+
+// XLSXWriter writes resultsets as the sheets of an Excel workbook (.xlsx), streaming the rows of each one
+// as they're read. The workbook is complete once Close is called.
+type XLSXWriter struct {
+	zip    *zip.Writer
+	sheets []string // the names of the sheets written so far
+}
+
+// NewXLSXWriter returns a writer of a workbook to w
+func NewXLSXWriter(w io.Writer) *XLSXWriter {
+	return &XLSXWriter{zip: zip.NewWriter(w)}
+}
+
+// AddSheet writes rows as the next sheet of the workbook, with a header row holding the names of the columns.
+// Numbers are written as numeric cells, NULLs as empty cells and anything else as text. name is made a valid,
+// unique sheet name, defaulting to SheetN (where N is the position of the sheet) if empty.
+func (x *XLSXWriter) AddSheet(name string, rows *sql.Rows) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	x.sheets = append(x.sheets, x.sheetName(name))
+	w, err := x.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(x.sheets)))
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.WriteString(w, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return err
+	}
+
+	var header = make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = column
+	}
+	if err = writeXLSXRow(w, 1, header); err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for r := 2; rows.Next(); r++ {
+		if err = rows.Scan(pointers...); err != nil {
+			return err
+		}
+		if err = writeXLSXRow(w, r, values); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, `</sheetData></worksheet>`)
+	return err
+}
+
+// Close writes the parts of the workbook listing its sheets, and completes it
+func (x *XLSXWriter) Close() error {
+	// a workbook has at least one sheet
+	if len(x.sheets) == 0 {
+		x.sheets = append(x.sheets, "Sheet1")
+		w, err := x.zip.Create("xl/worksheets/sheet1.xml")
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(w, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData/></worksheet>`); err != nil {
+			return err
+		}
+	}
+
+	var contentTypes, workbook, workbookRels strings.Builder
+	contentTypes.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	for i, name := range x.sheets {
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeXML(name), i+1, i+1)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRels.WriteString(`</Relationships>`)
+
+	var parts = []struct{ name, contents string }{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+	}
+	for _, part := range parts {
+		w, err := x.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(w, part.contents); err != nil {
+			return err
+		}
+	}
+
+	return x.zip.Close()
+}
+
+// sheetName returns name, stripped of the characters sheet names can't have, truncated to the length they
+// can have, and made unique among the sheets written so far
+func (x *XLSXWriter) sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = fmt.Sprintf("Sheet%d", len(x.sheets)+1)
+	}
+
+	var unique = truncateRunes(name, maxSheetName)
+	for n := 2; x.hasSheet(unique); n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		unique = truncateRunes(name, maxSheetName-len(suffix)) + suffix
+	}
+	return unique
+}
+
+func (x *XLSXWriter) hasSheet(name string) bool {
+	for _, sheet := range x.sheets {
+		if strings.EqualFold(sheet, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncateRunes(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
+
+// writeXLSXRow writes values as the row numbered r (from 1) of a sheet
+func writeXLSXRow(w io.Writer, r int, values []interface{}) error {
+	var row strings.Builder
+	fmt.Fprintf(&row, `<row r="%d">`, r)
+	for c, value := range values {
+		ref := xlsxColumn(c) + strconv.Itoa(r)
+		switch v := value.(type) {
+		case nil:
+			continue
+		case int64:
+			fmt.Fprintf(&row, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case float64:
+			fmt.Fprintf(&row, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'g', -1, 64))
+		case bool:
+			var b = 0
+			if v {
+				b = 1
+			}
+			fmt.Fprintf(&row, `<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+		case time.Time:
+			fmt.Fprintf(&row, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, v.Format(time.RFC3339))
+		case []byte:
+			fmt.Fprintf(&row, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escapeXML(string(v)))
+		default:
+			fmt.Fprintf(&row, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escapeXML(fmt.Sprint(v)))
+		}
+	}
+	row.WriteString(`</row>`)
+
+	_, err := io.WriteString(w, row.String())
+	return err
+}
+
+// xlsxColumn returns the name of the column numbered c (from 0), as in A, B, ..., Z, AA, AB, ...
+func xlsxColumn(c int) string {
+	var name string
+	for c++; c > 0; c = (c - 1) / 26 {
+		name = string(rune('A'+(c-1)%26)) + name
+	}
+	return name
+}
+
+// escapeXML escapes s to be used as XML text, leaving out the characters XML can't hold
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s)))
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package display
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// readZipFile returns the contents of the file named name in the zip archive b
+func readZipFile(t *testing.T, b []byte, name string) string {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := r.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	contents, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(contents)
+}
+
+func TestDisplayXLSX(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+
+	mockRows := sqlmock.NewRows([]string{"author", "commits", "ratio"}).
+		AddRow("Jane <jane@example.com>", int64(12), 0.5).
+		AddRow(nil, int64(3), nil)
+
+	mock.ExpectQuery("select").WillReturnRows(mockRows)
+
+	rows, _ := db.Query("select")
+
+	var b bytes.Buffer
+	if err := WriteTo(rows, &b, "xlsx", false); err != nil {
+		t.Fatal(err)
+	}
+
+	sheet := readZipFile(t, b.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, expected := range []string{
+		`<c r="A1" t="inlineStr"><is><t xml:space="preserve">author</t></is></c>`,
+		`<c r="A2" t="inlineStr"><is><t xml:space="preserve">Jane &lt;jane@example.com&gt;</t></is></c>`,
+		`<c r="B2"><v>12</v></c><c r="C2"><v>0.5</v></c>`,
+		`<row r="3"><c r="B3"><v>3</v></c></row>`,
+	} {
+		if !strings.Contains(sheet, expected) {
+			t.Fatalf("expected the sheet to contain %s, got: %s", expected, sheet)
+		}
+	}
+
+	if workbook := readZipFile(t, b.Bytes(), "xl/workbook.xml"); !strings.Contains(workbook, `<sheet name="Sheet1" sheetId="1" r:id="rId1"/>`) {
+		t.Fatalf("expected the workbook to list the sheet, got: %s", workbook)
+	}
+}
+
+func TestXLSXSheets(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	for i := 0; i < 3; i++ {
+		mock.ExpectQuery("select").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(int64(i)))
+	}
+
+	var b bytes.Buffer
+	x := NewXLSXWriter(&b)
+	for _, name := range []string{"commits/week", "commits/week", ""} {
+		rows, _ := db.Query("select")
+		if err := x.AddSheet(name, rows); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	workbook := readZipFile(t, b.Bytes(), "xl/workbook.xml")
+	for _, expected := range []string{`name="commitsweek"`, `name="commitsweek (2)"`, `name="Sheet3"`} {
+		if !strings.Contains(workbook, expected) {
+			t.Fatalf("expected the workbook to contain a sheet %s, got: %s", expected, workbook)
+		}
+	}
+
+	if sheet := readZipFile(t, b.Bytes(), "xl/worksheets/sheet3.xml"); !strings.Contains(sheet, `<c r="A2"><v>2</v></c>`) {
+		t.Fatalf("unexpected third sheet: %s", sheet)
+	}
+
+	if xlsxColumn(0) != "A" || xlsxColumn(25) != "Z" || xlsxColumn(26) != "AA" || xlsxColumn(701) != "ZZ" || xlsxColumn(702) != "AAA" {
+		t.Fatal("unexpected column names")
+	}
+}
@@ -0,0 +1,189 @@
+// Package ghactions parses GitHub Actions workflows, as in
+// https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions
+package ghactions
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+)
+
+// WorkflowsDir is the directory of a repository workflows are read from
+const WorkflowsDir = ".github/workflows"
+
+// workflow holds the fields of a workflow file
+type workflow struct {
+	Name string          `json:"name"`
+	On   json.RawMessage `json:"on"`
+	// True is the on key of workflows, which YAML 1.1 parses as a boolean (and so as the "true" key)
+	True json.RawMessage `json:"true"`
+	Jobs map[string]struct {
+		Name   string          `json:"name"`
+		RunsOn json.RawMessage `json:"runs-on"`
+		Uses   string          `json:"uses"` // reusable workflow called by the job, if any
+		Steps  []struct {
+			Name string `json:"name"`
+			Uses string `json:"uses"`
+			Run  string `json:"run"`
+		} `json:"steps"`
+	} `json:"jobs"`
+}
+
+// triggers returns the events triggering the workflow
+func (w *workflow) triggers() []string {
+	on := w.On
+	if len(on) == 0 {
+		on = w.True
+	}
+
+	var event string
+	if err := json.Unmarshal(on, &event); err == nil {
+		return []string{event}
+	}
+
+	var events []string
+	if err := json.Unmarshal(on, &events); err == nil {
+		return events
+	}
+
+	var configured map[string]json.RawMessage
+	if err := json.Unmarshal(on, &configured); err == nil {
+		for event := range configured {
+			events = append(events, event)
+		}
+		sort.Strings(events)
+	}
+	return events
+}
+
+// Step is a step of a job of a workflow (or a job calling a reusable workflow, which has no steps)
+type Step struct {
+	Path, Workflow string
+	Triggers       []string
+	Job, JobName   string
+	RunsOn         string // a label, or a list of labels or a group (as JSON)
+	Step           int    // index of the step in the job, -1 for jobs calling a reusable workflow
+	StepName       string
+	Uses, Run      string
+}
+
+// Action splits the uses of the step into the action (or reusable workflow) and the version (a tag, branch or commit)
+// it's pinned to, as in actions/checkout@v4. Local actions and docker images have no version.
+func (s *Step) Action() (string, string) {
+	if s.IsLocal() || s.IsDocker() {
+		return s.Uses, ""
+	}
+	if at := strings.LastIndex(s.Uses, "@"); at >= 0 {
+		return s.Uses[:at], s.Uses[at+1:]
+	}
+	return s.Uses, ""
+}
+
+// IsLocal reports whether the step uses an action (or workflow) of the repository itself
+func (s *Step) IsLocal() bool { return strings.HasPrefix(s.Uses, "./") }
+
+// IsDocker reports whether the step runs a docker image
+func (s *Step) IsDocker() bool { return strings.HasPrefix(s.Uses, "docker://") }
+
+// Repository returns the owner and name of the GitHub repository the action (or reusable workflow) of the step
+// is published in, as in actions/aws for actions/aws/ec2@v1. Both are empty for steps not using one.
+func (s *Step) Repository() (owner, name string) {
+	if s.Uses == "" || s.IsLocal() || s.IsDocker() {
+		return "", ""
+	}
+	action, _ := s.Action()
+	parts := strings.SplitN(action, "/", 3)
+	if len(parts) < 2 {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+var commitSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// IsCommitSHA reports whether version is the (full) SHA of a commit, the only way of pinning an action immutably
+func IsCommitSHA(version string) bool { return commitSHA.MatchString(version) }
+
+// Parse parses the workflow file at path into its steps, in the order of the jobs' ids
+func Parse(path, contents string) ([]*Step, error) {
+	js, err := yaml.YAMLToJSON([]byte(contents))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse %s", path)
+	}
+
+	var w workflow
+	if err = json.Unmarshal(js, &w); err != nil {
+		return nil, errors.Wrapf(err, "could not parse %s", path)
+	}
+
+	var ids = make([]string, 0, len(w.Jobs))
+	for id := range w.Jobs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var triggers = w.triggers()
+	var steps []*Step
+	for _, id := range ids {
+		job := w.Jobs[id]
+
+		var runsOn string
+		if err := json.Unmarshal(job.RunsOn, &runsOn); err != nil && len(job.RunsOn) > 0 {
+			runsOn = string(job.RunsOn)
+		}
+
+		step := Step{Path: path, Workflow: w.Name, Triggers: triggers, Job: id, JobName: job.Name, RunsOn: runsOn}
+		if job.Uses != "" {
+			s := step
+			s.Step, s.Uses = -1, job.Uses
+			steps = append(steps, &s)
+		}
+		for n, st := range job.Steps {
+			s := step
+			s.Step, s.StepName, s.Uses, s.Run = n, st.Name, st.Uses, st.Run
+			steps = append(steps, &s)
+		}
+	}
+	return steps, nil
+}
+
+// Read returns the steps of the workflows (the .yml and .yaml files of WorkflowsDir) of tree, in the order of their path
+func Read(tree *object.Tree) ([]*Step, error) {
+	dir, err := tree.Tree(WorkflowsDir)
+	if err != nil {
+		if err == object.ErrDirectoryNotFound {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "could not lookup %s", WorkflowsDir)
+	}
+
+	var steps []*Step
+	for _, entry := range dir.Entries {
+		if !entry.Mode.IsFile() || (path.Ext(entry.Name) != ".yml" && path.Ext(entry.Name) != ".yaml") {
+			continue
+		}
+
+		f, err := dir.TreeEntryFile(&entry)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not lookup %s", entry.Name)
+		}
+
+		contents, err := f.Contents()
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not retrieve contents of %s", entry.Name)
+		}
+
+		parsed, err := Parse(path.Join(WorkflowsDir, entry.Name), contents)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, parsed...)
+	}
+	return steps, nil
+}
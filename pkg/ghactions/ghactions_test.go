@@ -0,0 +1,67 @@
+package ghactions_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/pkg/ghactions"
+)
+
+func TestParse(t *testing.T) {
+	steps, err := ghactions.Parse(".github/workflows/ci.yml", `name: CI
+on: [push, pull_request]
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11
+      - uses: aws-actions/configure-aws-credentials/assume-role@v4
+      - uses: docker://alpine:3.19
+      - run: make test
+  release:
+    uses: mergestat/workflows/.github/workflows/release.yml@main
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		job, uses, action, version, owner, name string
+		step                                    int
+	}
+	var results []result
+	for _, step := range steps {
+		action, version := step.Action()
+		owner, name := step.Repository()
+		results = append(results, result{step.Job, step.Uses, action, version, owner, name, step.Step})
+	}
+
+	expected := []result{
+		{"release", "mergestat/workflows/.github/workflows/release.yml@main", "mergestat/workflows/.github/workflows/release.yml", "main", "mergestat", "workflows", -1},
+		{"test", "actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11", "actions/checkout", "b4ffde65f46336ab88eb53be808477a3936bae11", "actions", "checkout", 0},
+		{"test", "aws-actions/configure-aws-credentials/assume-role@v4", "aws-actions/configure-aws-credentials/assume-role", "v4", "aws-actions", "configure-aws-credentials", 1},
+		{"test", "docker://alpine:3.19", "docker://alpine:3.19", "", "", "", 2},
+		{"test", "", "", "", "", "", 3},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("unexpected steps: %+v", results)
+	}
+
+	if !reflect.DeepEqual(steps[0].Triggers, []string{"push", "pull_request"}) {
+		t.Fatalf("unexpected triggers: %v", steps[0].Triggers)
+	}
+}
+
+func TestIsCommitSHA(t *testing.T) {
+	for version, expected := range map[string]bool{
+		"b4ffde65f46336ab88eb53be808477a3936bae11": true,
+		"b4ffde6": false,
+		"v4.1.1":  false,
+		"main":    false,
+		"B4FFDE65F46336AB88EB53BE808477A3936BAE11": false,
+	} {
+		if ghactions.IsCommitSHA(version) != expected {
+			t.Fatalf("expected IsCommitSHA(%q) to be %v", version, expected)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Package glob matches names (such as paths or git refs) against glob patterns, as used by branch protection
+// rules: * matches any sequence of characters but /, ** any sequence of characters (including /), ? any single
+// character but /, [...] a character class (negated by a leading ! or ^) and {a,b} either of the alternatives.
+// Special characters are escaped with a backslash.
+package glob
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Compile translates pattern into an (anchored) regular expression matching the same names
+func Compile(pattern string) (*regexp.Regexp, error) {
+	var re strings.Builder
+	re.WriteString("^")
+
+	var braces int // depth of the {...} groups the pattern is in
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				// **/ also matches no directory at all, as in a/**/b matching a/b
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					re.WriteString("(?:.*/)?")
+				} else {
+					re.WriteString(".*")
+				}
+			} else {
+				re.WriteString("[^/]*")
+			}
+		case '?':
+			re.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end == 0 && len(pattern) > i+2 {
+				// a ] right after the [ is part of the class
+				end = strings.IndexByte(pattern[i+2:], ']') + 1
+			}
+			if end <= 0 {
+				return nil, errors.Errorf("invalid glob %q: unterminated character class", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '{':
+			braces++
+			re.WriteString("(?:")
+		case '}':
+			if braces == 0 {
+				re.WriteString(`\}`)
+				continue
+			}
+			braces--
+			re.WriteString(")")
+		case ',':
+			if braces > 0 {
+				re.WriteString("|")
+			} else {
+				re.WriteString(",")
+			}
+		case '\\':
+			if i+1 == len(pattern) {
+				return nil, errors.Errorf("invalid glob %q: trailing backslash", pattern)
+			}
+			i++
+			re.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if braces > 0 {
+		return nil, errors.Errorf("invalid glob %q: unterminated {", pattern)
+	}
+
+	re.WriteString("$")
+	compiled, err := regexp.Compile(re.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid glob %q", pattern)
+	}
+	return compiled, nil
+}
+
+// Match reports whether name matches the glob pattern
+func Match(pattern, name string) (bool, error) {
+	re, err := Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(name), nil
+}
@@ -0,0 +1,50 @@
+package glob_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/pkg/glob"
+)
+
+func TestMatch(t *testing.T) {
+	for _, test := range []struct {
+		pattern, name string
+		expected      bool
+	}{
+		{"main", "main", true},
+		{"main", "main2", false},
+		{"release/*", "release/v1.2", true},
+		{"release/*", "release/v1/hotfix", false},
+		{"feature/**", "feature/api/login", true},
+		{"**/fix-*", "users/jane/fix-parser", true},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"v?.*", "v1.2", true},
+		{"v?.*", "v10.2", false},
+		{"v[0-9]*", "v1.2.3", true},
+		{"v[!0-9]*", "v1.2.3", false},
+		{"{feature,bugfix}/*", "bugfix/crash", true},
+		{"{feature,bugfix}/*", "hotfix/crash", false},
+		{"release-1.{0,1}", "release-1.1", true},
+		{"v1.0", "v1x0", false},
+		{`literal\*`, "literal*", true},
+		{`literal\*`, "literally", false},
+		{"a,b", "a,b", true},
+	} {
+		matched, err := glob.Match(test.pattern, test.name)
+		if err != nil {
+			t.Fatalf("failed to match %q: %v", test.pattern, err)
+		}
+		if matched != test.expected {
+			t.Fatalf("expected %q matching %q to be %v", test.pattern, test.name, test.expected)
+		}
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+	for _, pattern := range []string{"release/[0-9", "{feature,bugfix/*", `trailing\`} {
+		if _, err := glob.Compile(pattern); err == nil {
+			t.Fatalf("expected an error compiling %q", pattern)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Package httpcache implements an http.RoundTripper keeping API responses on disk, so that queries
+// can be answered again later without network access (or credentials), such as when running offline.
+package httpcache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+)
+
+// ErrNotCached is returned by offline transports for requests that were never cached
+var ErrNotCached = errors.New("response not cached")
+
+// Transport is an http.RoundTripper storing successful responses in Dir. Responses are keyed by the method, URL and body
+// of requests (but not their headers, so that credentials don't matter), as GraphQL requests are all POSTed to the same URL.
+type Transport struct {
+	// Dir is the directory responses are stored in
+	Dir string
+
+	// Offline, if set, answers requests exclusively from the cache, failing with ErrNotCached on misses
+	Offline bool
+
+	// Transport makes the actual requests when online. If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+}
+
+// DefaultDir returns the default directory to cache responses of the API named name in, under the user's cache directory
+func DefaultDir(name string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "mergestat", name), nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	path := filepath.Join(t.Dir, key(req, body))
+	if t.Offline {
+		return t.load(path, req)
+	}
+
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	res, err := transport.RoundTrip(req)
+	if err != nil || res.StatusCode != http.StatusOK {
+		return res, err
+	}
+
+	err = t.store(path, res)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	return t.load(path, req)
+}
+
+// key returns the name of the file the response to req (with the given body) is stored in
+func key(req *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", req.Method, req.URL.String())
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (t *Transport) store(path string, res *http.Response) error {
+	dump, err := httputil.DumpResponse(res, true)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+
+	if err = os.MkdirAll(t.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %v", err)
+	}
+
+	// write to a temporary file first, so that concurrent readers never see a partial response
+	tmp, err := os.CreateTemp(t.Dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to cache response: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(dump); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to cache response: %v", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to cache response: %v", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (t *Transport) load(path string, req *http.Request) (*http.Response, error) {
+	dump, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s %s (run once without offline mode to cache it)", ErrNotCached, req.Method, req.URL)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+}
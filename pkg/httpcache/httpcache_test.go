@@ -0,0 +1,53 @@
+package httpcache_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/pkg/httpcache"
+)
+
+func TestOffline(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"echo":` + string(body) + `}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	post := func(client *http.Client, body string) (string, error) {
+		res, err := client.Post(srv.URL+"/graphql", "application/json", strings.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		b, err := io.ReadAll(res.Body)
+		return string(b), err
+	}
+
+	online := &http.Client{Transport: &httpcache.Transport{Dir: dir}}
+	if out, err := post(online, `"first"`); err != nil || out != `{"echo":"first"}` {
+		t.Fatalf("unexpected response: %s (%v)", out, err)
+	}
+
+	offline := &http.Client{Transport: &httpcache.Transport{Dir: dir, Offline: true}}
+	if out, err := post(offline, `"first"`); err != nil || out != `{"echo":"first"}` {
+		t.Fatalf("unexpected cached response: %s (%v)", out, err)
+	}
+
+	if requests != 1 {
+		t.Fatalf("expected a single request to the server, got: %d", requests)
+	}
+
+	// requests with a different body are different requests
+	if _, err := post(offline, `"second"`); !errors.Is(err, httpcache.ErrNotCached) {
+		t.Fatalf("expected a cache miss, got: %v", err)
+	}
+}
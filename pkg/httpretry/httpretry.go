@@ -0,0 +1,173 @@
+// Package httpretry implements an http.RoundTripper retrying requests that failed transiently (such as with a 502 from
+// an API gateway), so that a single failed request doesn't fail an entire (possibly long) scan of an API.
+package httpretry
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultStatuses are the HTTP statuses retried when none are set
+var DefaultStatuses = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// Backoff returns how long to wait before the given retry (starting at 1)
+type Backoff func(retry int) time.Duration
+
+// Constant waits d before every retry
+func Constant(d time.Duration) Backoff {
+	return func(int) time.Duration { return d }
+}
+
+// Exponential waits base before the first retry, doubling the wait on every retry after that, up to max
+func Exponential(base, max time.Duration) Backoff {
+	return func(retry int) time.Duration {
+		d := base
+		for n := 1; n < retry && d < max; n++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d
+	}
+}
+
+// ParseBackoff parses the name of a backoff strategy, either "exponential" (the default, when empty) or "constant",
+// optionally followed by the base duration to wait, as in "constant:5s"
+func ParseBackoff(s string) (Backoff, error) {
+	var base = time.Second
+	name, duration, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if ok {
+		var err error
+		if base, err = time.ParseDuration(duration); err != nil {
+			return nil, fmt.Errorf("invalid backoff duration %q: %v", duration, err)
+		}
+	}
+
+	switch strings.ToLower(name) {
+	case "", "exponential":
+		return Exponential(base, time.Minute), nil
+	case "constant":
+		return Constant(base), nil
+	}
+	return nil, fmt.Errorf("unknown backoff strategy %q (expected exponential or constant)", name)
+}
+
+// ParseStatuses parses a comma separated list of HTTP statuses, as in "502,503,504"
+func ParseStatuses(s string) ([]int, error) {
+	var statuses []int
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		status, err := strconv.Atoi(field)
+		if err != nil || status < 100 || status > 599 {
+			return nil, fmt.Errorf("invalid HTTP status %q", field)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Transport is an http.RoundTripper retrying requests failing with an error, or with one of Statuses
+type Transport struct {
+	// Retries is the maximum number of times a request is retried
+	Retries int
+
+	// Backoff is how long to wait between attempts. If nil, waits grow exponentially from a second.
+	Backoff Backoff
+
+	// Statuses are the HTTP statuses to retry requests on. If nil, DefaultStatuses are retried.
+	Statuses []int
+
+	// OnRetry, if set, is called before every retry, with the error (or status) the previous attempt failed with
+	OnRetry func(req *http.Request, retry int, err error)
+
+	// Transport makes the actual requests. If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	// the body is read once and for all, to be sent again on every attempt
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+	}
+
+	for retry := 0; ; retry++ {
+		if body != nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		res, err := transport.RoundTrip(req)
+		if retry >= t.Retries || req.Context().Err() != nil || (err == nil && !t.retryable(res.StatusCode)) {
+			return res, err
+		}
+
+		wait := t.backoff(retry + 1)
+		if err == nil {
+			if after := retryAfter(res); after > wait {
+				wait = after
+			}
+			err = fmt.Errorf("unexpected status: %s", res.Status)
+			_, _ = io.Copy(io.Discard, res.Body)
+			_ = res.Body.Close()
+		}
+
+		if t.OnRetry != nil {
+			t.OnRetry(req, retry+1, err)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *Transport) retryable(status int) bool {
+	statuses := t.Statuses
+	if statuses == nil {
+		statuses = DefaultStatuses
+	}
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Transport) backoff(retry int) time.Duration {
+	if t.Backoff == nil {
+		return Exponential(time.Second, time.Minute)(retry)
+	}
+	return t.Backoff(retry)
+}
+
+// retryAfter returns the wait the server asked for through the Retry-After header (in seconds), if any
+func retryAfter(res *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
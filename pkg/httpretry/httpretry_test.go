@@ -0,0 +1,109 @@
+package httpretry_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mergestat/mergestat-lite/pkg/httpretry"
+)
+
+func TestRetry(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		if requests < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	var retries []int
+	client := &http.Client{Transport: &httpretry.Transport{
+		Retries: 3,
+		Backoff: httpretry.Constant(time.Millisecond),
+		OnRetry: func(_ *http.Request, retry int, _ error) { retries = append(retries, retry) },
+	}}
+
+	res, err := client.Post(srv.URL, "application/json", strings.NewReader(`{"query":"{}"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK || string(body) != `{"query":"{}"}` {
+		t.Fatalf("unexpected response: %d %s", res.StatusCode, body)
+	}
+	if requests != 3 || len(retries) != 2 || retries[1] != 2 {
+		t.Fatalf("expected 3 requests and 2 retries, got %d requests and retries %v", requests, retries)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &httpretry.Transport{Retries: 2, Backoff: httpretry.Constant(time.Millisecond)}}
+	res, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusServiceUnavailable || requests != 3 {
+		t.Fatalf("expected the last response after 3 requests, got %d after %d requests", res.StatusCode, requests)
+	}
+}
+
+func TestRetryStatuses(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &httpretry.Transport{Retries: 2, Statuses: []int{http.StatusTooManyRequests}}}
+	res, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if requests != 1 {
+		t.Fatalf("expected statuses not listed to not be retried, got %d requests", requests)
+	}
+}
+
+func TestParse(t *testing.T) {
+	statuses, err := httpretry.ParseStatuses("502, 503,504")
+	if err != nil || len(statuses) != 3 || statuses[2] != 504 {
+		t.Fatalf("unexpected statuses: %v (%v)", statuses, err)
+	}
+	if _, err := httpretry.ParseStatuses("bad"); err == nil {
+		t.Fatal("expected an error for an invalid status")
+	}
+
+	backoff, err := httpretry.ParseBackoff("constant:5s")
+	if err != nil || backoff(3) != 5*time.Second {
+		t.Fatalf("unexpected constant backoff (%v)", err)
+	}
+	backoff, err = httpretry.ParseBackoff("")
+	if err != nil || backoff(1) != time.Second || backoff(3) != 4*time.Second || backoff(20) != time.Minute {
+		t.Fatalf("unexpected exponential backoff (%v)", err)
+	}
+	if _, err := httpretry.ParseBackoff("linear"); err == nil {
+		t.Fatalf("expected an error for an unknown strategy, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package locator
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/pkg/errors"
+)
+
+// alternatesPath is the path (in a git directory) of the file listing the object directories a repository borrows objects from
+const alternatesPath = "objects/info/alternates"
+
+// withAlternates returns repo reading objects from the object directories listed in its objects/info/alternates file,
+// as found in forks sharing the objects of their upstream on forge servers, or in clones made with --reference or --shared.
+// go-git looks alternates up within the git directory of the repository only, which the alternates of such layouts
+// lie outside of, so the storage of repo is rebuilt to look them up anywhere on disk. Repositories without
+// alternates are returned as is.
+func withAlternates(repo *git.Repository) (*git.Repository, error) {
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return repo, nil
+	}
+
+	fs := storage.Filesystem()
+	if _, err := fs.Stat(alternatesPath); os.IsNotExist(err) {
+		return repo, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to read alternates")
+	}
+
+	// relative alternates are relative to the objects directory (which, in linked worktrees, is in the common git directory)
+	objects, err := fs.Chroot("objects")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read alternates")
+	}
+
+	var root = filepath.VolumeName(objects.Root()) + string(filepath.Separator)
+	var alternates = &alternatesFS{Filesystem: fs, objectsDir: objects.Root()}
+	storage = filesystem.NewStorageWithOptions(alternates, cache.NewObjectLRUDefault(), filesystem.Options{AlternatesFS: osfs.New(root)})
+
+	var worktree billy.Filesystem
+	if w, err := repo.Worktree(); err == nil {
+		worktree = w.Filesystem
+	} else if !errors.Is(err, git.ErrIsBareRepository) {
+		return nil, err
+	}
+
+	return git.Open(storage, worktree)
+}
+
+// alternatesFS is a git directory whose objects/info/alternates file lists absolute paths only, so that go-git
+// can look them up from the root of the file system (see withAlternates)
+type alternatesFS struct {
+	billy.Filesystem
+	objectsDir string
+}
+
+func (fs *alternatesFS) Open(name string) (billy.File, error) {
+	f, err := fs.Filesystem.Open(name)
+	if err != nil || filepath.ToSlash(filepath.Clean(name)) != alternatesPath {
+		return f, err
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		path := strings.TrimSpace(scanner.Text())
+		if path == "" || strings.HasPrefix(path, "#") {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(fs.objectsDir, path)
+		}
+		buf.WriteString(filepath.Clean(path) + "\n")
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &alternatesFile{name: name, Reader: bytes.NewReader(buf.Bytes())}, nil
+}
+
+// alternatesFile is a read-only, in-memory copy of an alternates file
+type alternatesFile struct {
+	name string
+	*bytes.Reader
+}
+
+func (f *alternatesFile) Name() string              { return f.name }
+func (f *alternatesFile) Write([]byte) (int, error) { return 0, os.ErrPermission }
+func (f *alternatesFile) Close() error              { return nil }
+func (f *alternatesFile) Lock() error               { return nil }
+func (f *alternatesFile) Unlock() error             { return nil }
+func (f *alternatesFile) Truncate(size int64) error { return os.ErrPermission }
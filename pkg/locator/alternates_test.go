@@ -0,0 +1,83 @@
+package locator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestAlternates(t *testing.T) {
+	// an upstream repository, and forks borrowing its objects (as on forge servers), next to it
+	base := t.TempDir()
+	upstreamDir := filepath.Join(base, "upstream")
+
+	upstream, err := git.PlainInit(upstreamDir, false)
+	if err != nil {
+		t.Fatalf("failed to initialize repository: %v", err)
+	}
+	worktree, err := upstream.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(upstreamDir, "README.md"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = worktree.Add("README.md"); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := worktree.Commit("initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	tests := map[string]string{
+		"absolute": filepath.Join(upstreamDir, ".git", "objects"),
+		"relative": filepath.Join("..", "..", "upstream", ".git", "objects"),
+	}
+
+	for name, alternate := range tests {
+		t.Run(name, func(t *testing.T) {
+			forkDir := filepath.Join(base, name+".git")
+			fork, err := git.PlainInit(forkDir, true)
+			if err != nil {
+				t.Fatalf("failed to initialize repository: %v", err)
+			}
+			if err = fork.Storer.SetReference(plumbing.NewHashReference(plumbing.Master, hash)); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = os.MkdirAll(filepath.Join(forkDir, "objects", "info"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err = os.WriteFile(filepath.Join(forkDir, "objects", "info", "alternates"), []byte(alternate+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			repo, err := DiskLocator().Open(context.Background(), forkDir)
+			if err != nil {
+				t.Fatalf("failed to open fork: %v", err)
+			}
+
+			head, err := repo.Head()
+			if err != nil {
+				t.Fatalf("failed to resolve HEAD: %v", err)
+			}
+
+			commit, err := repo.CommitObject(head.Hash())
+			if err != nil {
+				t.Fatalf("failed to read a commit of the alternate: %v", err)
+			}
+			if _, err = commit.File("README.md"); err != nil {
+				t.Fatalf("failed to read a file of the alternate: %v", err)
+			}
+		})
+	}
+}
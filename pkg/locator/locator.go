@@ -25,9 +25,24 @@ import (
 )
 
 // DiskLocator is a repo locator implementation that opens on-disk repository at the specified path.
+// The path may point at a worktree, a bare repository, a .git directory or a .git file (as used by
+// linked worktrees and submodules), and may be given as a file:// url (see LocalPath). Objects are also read from
+// the alternate object directories the repository borrows from, if any (see withAlternates).
 func DiskLocator() services.RepoLocator {
-	return options.RepoLocatorFn(func(_ context.Context, path string) (*git.Repository, error) {
-		return git.PlainOpen(path)
+	return options.RepoLocatorFn(func(_ context.Context, path string) (_ *git.Repository, err error) {
+		if path, err = LocalPath(path); err != nil {
+			return nil, err
+		}
+
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			path = filepath.Dir(path) // a .git file, let go-git follow the gitdir it points to
+		}
+
+		var repo *git.Repository
+		if repo, err = git.PlainOpenWithOptions(path, &git.PlainOpenOptions{EnableDotGitCommonDir: true}); err != nil {
+			return nil, err
+		}
+		return withAlternates(repo)
 	})
 }
 
@@ -200,6 +215,9 @@ func MultiLocator(o *MultiLocatorOptions) services.RepoLocator {
 
 	return options.RepoLocatorFn(func(ctx context.Context, path string) (*git.Repository, error) {
 		var fn = locators["file"] // file is the default locator
+		if IsFileURL(path) {
+			return fn().Open(ctx, path)
+		}
 		if strings.HasPrefix(path, "http") || strings.HasPrefix(path, "https") {
 			fn = locators["http"]
 			if o.HTTPAuth != nil {
@@ -0,0 +1,72 @@
+package locator
+
+import (
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// isWindows is whether paths are to be handled the Windows way (drive letters, UNC paths and backslashes)
+var isWindows = runtime.GOOS == "windows"
+
+// IsFileURL reports whether path is a file:// url rather than a plain path
+func IsFileURL(path string) bool {
+	return len(path) >= 7 && strings.EqualFold(path[:7], "file://")
+}
+
+// LocalPath returns the absolute path on disk of a local repository, given either a path or a file:// url.
+// Paths are made absolute as, on Windows, Go only transparently supports paths longer than MAX_PATH
+// (260 characters) when they're absolute.
+func LocalPath(path string) (string, error) {
+	if IsFileURL(path) {
+		var err error
+		if path, err = fromFileURL(path, isWindows); err != nil {
+			return "", err
+		}
+	}
+
+	abs, err := filepath.Abs(filepath.FromSlash(path))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to retrieve absolute path for %q", path)
+	}
+	return abs, nil
+}
+
+// fromFileURL returns the (slash-separated) path a file:// url refers to. On Windows, the url may hold a drive letter,
+// as in file:///C:/repo, or the host of a UNC path, as in file://server/share/repo (for \\server\share\repo).
+// Elsewhere, only urls of the local host are supported.
+func fromFileURL(raw string, windows bool) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid file url")
+	}
+
+	path := u.Path
+	switch host := u.Host; {
+	case host == "" || strings.EqualFold(host, "localhost"):
+		// strip the slash preceding drive letters, as in /C:/repo
+		if windows && len(path) >= 3 && path[0] == '/' && isDriveLetter(path[1]) && path[2] == ':' {
+			path = path[1:]
+		}
+	case windows && len(host) == 2 && isDriveLetter(host[0]) && host[1] == ':':
+		path = host + path // a drive letter with a missing slash, as in file://C:/repo
+	case host == "." || host == "..":
+		path = host + path // relative paths, as in file://./repo
+	case windows:
+		path = "//" + host + path // a UNC path
+	default:
+		return "", errors.Errorf("unsupported file url %q: only urls of the local host are supported", raw)
+	}
+
+	if path == "" {
+		return "", errors.Errorf("invalid file url %q: missing path", raw)
+	}
+	return path, nil
+}
+
+func isDriveLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
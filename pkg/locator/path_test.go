@@ -0,0 +1,69 @@
+package locator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFromFileURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		windows bool
+		path    string
+		err     bool
+	}{
+		{url: "file:///home/user/repo", path: "/home/user/repo"},
+		{url: "file://localhost/home/user/repo", path: "/home/user/repo"},
+		{url: "file:///home/user/my%20repo", path: "/home/user/my repo"},
+		{url: "file://./repo", path: "./repo"},
+		{url: "file://server/share/repo", err: true},
+		{url: "file://", err: true},
+		{url: "file:///C:/Users/user/repo", windows: true, path: "C:/Users/user/repo"},
+		{url: "FILE:///c:/repo", windows: true, path: "c:/repo"},
+		{url: "file://C:/repo", windows: true, path: "C:/repo"},
+		{url: "file://localhost/C:/repo", windows: true, path: "C:/repo"},
+		{url: "file://server/share/repo", windows: true, path: "//server/share/repo"},
+		{url: "file:///C:/repo", path: "/C:/repo"},
+	}
+
+	for _, test := range tests {
+		path, err := fromFileURL(test.url, test.windows)
+		if test.err {
+			if err == nil {
+				t.Errorf("expected %q (windows=%v) to fail, got: %q", test.url, test.windows, path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q (windows=%v): %v", test.url, test.windows, err)
+		} else if path != test.path {
+			t.Errorf("expected %q (windows=%v) to be %q, got: %q", test.url, test.windows, test.path, path)
+		}
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := LocalPath("file://" + filepath.ToSlash(dir))
+	if isWindows {
+		path, err = LocalPath("file:///" + filepath.ToSlash(dir))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != dir {
+		t.Fatalf("expected %q, got: %q", dir, path)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path, err = LocalPath("testdata/repo"); err != nil {
+		t.Fatal(err)
+	} else if expected := filepath.Join(wd, "testdata", "repo"); path != expected {
+		t.Fatalf("expected relative paths to be made absolute, as %q, got: %q", expected, path)
+	}
+}
@@ -0,0 +1,91 @@
+package owners
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mergestat/mergestat-lite/pkg/glob"
+	"github.com/pkg/errors"
+)
+
+// CodeownersFiles are the paths a CODEOWNERS file is looked up at, in the order GitHub looks them up
+var CodeownersFiles = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+// CodeownersRule is a rule of a CODEOWNERS file, setting the owners of the files matching a pattern
+type CodeownersRule struct {
+	Line    int
+	Pattern string
+	Owners  []string // @ GitHub logins, @org/team names or email addresses, or none if the files are left without owners
+
+	re *regexp.Regexp
+}
+
+// Match reports whether the file at path matches the pattern of the rule
+func (r *CodeownersRule) Match(path string) bool { return r.re.MatchString(path) }
+
+// ParseCodeowners parses a CODEOWNERS file into its rules, in the order they're listed. Patterns follow the rules
+// of .gitignore files: a pattern without a slash (other than a trailing one) matches in any directory, and a pattern
+// matching a directory matches all the files in it, except for patterns ending in /*, which only match the files
+// directly in a directory. The section headers of GitLab (as in [Documentation]) are skipped.
+func ParseCodeowners(contents string) ([]*CodeownersRule, error) {
+	var rules []*CodeownersRule
+	for n, line := range strings.Split(strings.ReplaceAll(contents, "\r\n", "\n"), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(strings.TrimPrefix(fields[0], "^"), "[") {
+			continue
+		}
+
+		rule := &CodeownersRule{Line: n + 1, Pattern: fields[0]}
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "#") {
+				break
+			}
+			rule.Owners = append(rule.Owners, owner)
+		}
+
+		var err error
+		if rule.re, err = compileCodeownersPattern(rule.Pattern); err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern on line %d", rule.Line)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// compileCodeownersPattern compiles a CODEOWNERS pattern into a regular expression matching the paths of the files it owns
+func compileCodeownersPattern(pattern string) (*regexp.Regexp, error) {
+	// CODEOWNERS patterns have no {a,b} alternatives
+	p := strings.NewReplacer("{", `\{`, "}", `\}`, ",", `\,`).Replace(pattern)
+
+	p, dir := strings.TrimSuffix(p, "/"), strings.HasSuffix(p, "/")
+	if strings.HasPrefix(p, "/") {
+		p = strings.TrimPrefix(p, "/")
+	} else if !strings.Contains(p, "/") {
+		p = "**/" + p
+	}
+
+	re, err := glob.Compile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// all the files within a matching directory are matched as well, unless the pattern ends in /*
+	switch {
+	case dir:
+		return regexp.Compile(strings.TrimSuffix(re.String(), "$") + "/.*$")
+	case p == "*" || strings.HasSuffix(p, "/*"):
+		return re, nil
+	default:
+		return regexp.Compile(strings.TrimSuffix(re.String(), "$") + "(?:/.*)?$")
+	}
+}
+
+// MatchCodeowners returns the rule setting the owners of the file at path: the last rule matching it, or nil if none does
+func MatchCodeowners(rules []*CodeownersRule, path string) *CodeownersRule {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].Match(path) {
+			return rules[i]
+		}
+	}
+	return nil
+}
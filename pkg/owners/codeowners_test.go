@@ -0,0 +1,74 @@
+package owners_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/pkg/owners"
+)
+
+func TestParseCodeowners(t *testing.T) {
+	rules, err := owners.ParseCodeowners(`# default owners
+*       @acme/core
+
+*.js    @alice # frontend
+/docs/  docs@example.com
+apps/   @bob @acme/apps
+/scripts/*  @carol
+services/billing @dave
+/vendor/
+
+[Documentation]
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed [][]string
+	for _, rule := range rules {
+		parsed = append(parsed, append([]string{rule.Pattern}, rule.Owners...))
+	}
+	expected := [][]string{
+		{"*", "@acme/core"},
+		{"*.js", "@alice"},
+		{"/docs/", "docs@example.com"},
+		{"apps/", "@bob", "@acme/apps"},
+		{"/scripts/*", "@carol"},
+		{"services/billing", "@dave"},
+		{"/vendor/"},
+	}
+	if !reflect.DeepEqual(parsed, expected) {
+		t.Fatalf("unexpected rules: %v", parsed)
+	}
+	if rules[1].Line != 4 {
+		t.Fatalf("expected the rule of *.js on line 4, got %d", rules[1].Line)
+	}
+
+	for path, pattern := range map[string]string{
+		"README.md":                     "*",
+		"web/src/app.js":                "*.js",
+		"docs/guide/intro.md":           "/docs/",
+		"api/docs/intro.md":             "*",
+		"apps/web/main.go":              "apps/",
+		"services/apps/worker/main.go":  "apps/",
+		"scripts/build.sh":              "/scripts/*",
+		"scripts/ci/lint.sh":            "*",
+		"services/billing/invoice.go":   "services/billing",
+		"services/billing-v2/main.go":   "*",
+		"vendor/github.com/pkg/x/x.go":  "/vendor/",
+		"lib/services/billing/fee.go":   "*",
+		"services/billing/web/index.js": "services/billing",
+	} {
+		if rule := owners.MatchCodeowners(rules, path); rule == nil || rule.Pattern != pattern {
+			t.Fatalf("expected %s to be owned by the rule of %q, got %+v", path, pattern, rule)
+		}
+	}
+
+	if rules, _ := owners.ParseCodeowners("/docs/ @alice"); owners.MatchCodeowners(rules, "README.md") != nil {
+		t.Fatal("expected no rule to match README.md")
+	}
+
+	if _, err := owners.ParseCodeowners("[invalid @alice\nsrc/[a-z @bob"); err == nil {
+		t.Fatal("expected an error parsing an invalid pattern")
+	}
+}
@@ -0,0 +1,231 @@
+// Package owners parses the files projects record code ownership in: CODEOWNERS files, the OWNERS files
+// of Kubernetes-style projects (https://www.kubernetes.dev/docs/guide/owners/) and MAINTAINERS files.
+package owners
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// Owner is an owner of the directory of an OWNERS file
+type Owner struct {
+	Login  string
+	Role   string // approver, reviewer or emeritus_approver
+	Alias  string // the alias (of OWNERS_ALIASES) the owner is a member of, if listed as one
+	Filter string // the regular expression of the files owned, if only some are
+}
+
+// ownersConfig holds the lists of owners set in an OWNERS file, or for a filter of one
+type ownersConfig struct {
+	Approvers         []string `json:"approvers"`
+	Reviewers         []string `json:"reviewers"`
+	EmeritusApprovers []string `json:"emeritus_approvers"`
+}
+
+// ParseAliases parses an OWNERS_ALIASES file into the members of each alias
+func ParseAliases(contents string) (map[string][]string, error) {
+	var aliases struct {
+		Aliases map[string][]string `json:"aliases"`
+	}
+	if err := yaml.Unmarshal([]byte(contents), &aliases); err != nil {
+		return nil, errors.Wrap(err, "could not parse OWNERS_ALIASES")
+	}
+
+	var normalized = make(map[string][]string, len(aliases.Aliases))
+	for alias, members := range aliases.Aliases {
+		normalized[strings.ToLower(alias)] = members
+	}
+	return normalized, nil
+}
+
+// ParseOwners parses an OWNERS file into its owners, the ones of the whole directory first, followed by the ones
+// of each filter (in the order of their expression). Aliases are expanded into their members, and logins (which
+// GitHub treats as case-insensitive) are lower cased.
+func ParseOwners(contents string, aliases map[string][]string) ([]Owner, error) {
+	var config struct {
+		ownersConfig
+		Filters map[string]ownersConfig `json:"filters"`
+	}
+	if err := yaml.Unmarshal([]byte(contents), &config); err != nil {
+		return nil, errors.Wrap(err, "could not parse OWNERS")
+	}
+
+	var owners = expand(config.ownersConfig, "", aliases)
+
+	var filters = make([]string, 0, len(config.Filters))
+	for filter := range config.Filters {
+		filters = append(filters, filter)
+	}
+	sort.Strings(filters)
+
+	for _, filter := range filters {
+		owners = append(owners, expand(config.Filters[filter], filter, aliases)...)
+	}
+	return owners, nil
+}
+
+// expand returns the owners listed in config, with aliases expanded
+func expand(config ownersConfig, filter string, aliases map[string][]string) []Owner {
+	var owners []Owner
+	for _, list := range []struct {
+		role   string
+		logins []string
+	}{
+		{"approver", config.Approvers}, {"reviewer", config.Reviewers}, {"emeritus_approver", config.EmeritusApprovers},
+	} {
+		for _, login := range list.logins {
+			login = strings.ToLower(strings.TrimSpace(login))
+			if members, ok := aliases[login]; ok {
+				for _, member := range members {
+					owners = append(owners, Owner{Login: strings.ToLower(member), Role: list.role, Alias: login, Filter: filter})
+				}
+				continue
+			}
+			owners = append(owners, Owner{Login: login, Role: list.role, Filter: filter})
+		}
+	}
+	return owners
+}
+
+// Maintainer is a person listed in a MAINTAINERS file
+type Maintainer struct {
+	Line    int
+	Section string // the section (or heading) of the file the maintainer is listed under, if any
+	Pattern string // the files maintained, as listed in the section (F: entries), if any
+	Name    string
+	Owner   string // the @ GitHub login of the maintainer, or their email address
+	Role    string // maintainer, reviewer or emeritus_maintainer
+}
+
+var (
+	// kernelEntry matches the tagged entries of the sections of a Linux kernel style MAINTAINERS file, as in "M:	Jane Doe <jane@example.com>"
+	kernelEntry = regexp.MustCompile(`^([A-Z]):\s*(.*)$`)
+
+	email       = regexp.MustCompile(`[\w.+-]+@[\w-]+(?:\.[\w-]+)+`)
+	login       = regexp.MustCompile(`(?:^|[\s(\[|,:])@([A-Za-z0-9][A-Za-z0-9-]*)`)
+	profileLink = regexp.MustCompile(`github\.com/([A-Za-z0-9][A-Za-z0-9-]*)/?(?:[\s)\]|>]|$)`)
+)
+
+// ParseMaintainers parses a MAINTAINERS file into the maintainers it lists. Files in the format of the Linux kernel
+// (sections of M:, R: and F: entries) list a maintainer for each of the files patterns of their section. Files in any
+// other format (plain text, markdown lists or tables) are read line by line, listing a maintainer for each line naming
+// a GitHub user or an email address; the role of those is guessed from the heading they're listed under.
+func ParseMaintainers(contents string) []Maintainer {
+	lines := strings.Split(strings.ReplaceAll(contents, "\r\n", "\n"), "\n")
+	for _, line := range lines {
+		if m := kernelEntry.FindStringSubmatch(line); m != nil && (m[1] == "M" || m[1] == "F") {
+			return parseKernelMaintainers(lines)
+		}
+	}
+	return parseListedMaintainers(lines)
+}
+
+// parseKernelMaintainers parses the lines of a Linux kernel style MAINTAINERS file
+func parseKernelMaintainers(lines []string) []Maintainer {
+	var maintainers []Maintainer
+
+	var section string
+	var people []Maintainer
+	var patterns []string
+	flush := func() {
+		if len(patterns) == 0 {
+			patterns = []string{""}
+		}
+		for _, person := range people {
+			for _, pattern := range patterns {
+				person.Pattern = pattern
+				maintainers = append(maintainers, person)
+			}
+		}
+		people, patterns = nil, nil
+	}
+
+	for n, line := range lines {
+		m := kernelEntry.FindStringSubmatch(line)
+		switch {
+		case m == nil && strings.TrimSpace(line) == "":
+			continue
+		case m == nil:
+			// a line that isn't an entry starts a new section, titled by it
+			flush()
+			section = strings.TrimSpace(line)
+		case m[1] == "M" || m[1] == "R":
+			role := "maintainer"
+			if m[1] == "R" {
+				role = "reviewer"
+			}
+			name, owner := person(m[2])
+			if owner != "" {
+				people = append(people, Maintainer{Line: n + 1, Section: section, Name: name, Owner: owner, Role: role})
+			}
+		case m[1] == "F":
+			patterns = append(patterns, strings.TrimSpace(m[2]))
+		}
+	}
+	flush()
+	return maintainers
+}
+
+// parseListedMaintainers parses the lines of a MAINTAINERS file listing people one per line
+func parseListedMaintainers(lines []string) []Maintainer {
+	var maintainers []Maintainer
+
+	var section, role = "", "maintainer"
+	for n, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			section = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			switch heading := strings.ToLower(section); {
+			case strings.Contains(heading, "emeritus"), strings.Contains(heading, "alumni"), strings.Contains(heading, "former"):
+				role = "emeritus_maintainer"
+			case strings.Contains(heading, "reviewer"):
+				role = "reviewer"
+			default:
+				role = "maintainer"
+			}
+			continue
+		}
+
+		name, owner := person(trimmed)
+		if owner != "" {
+			maintainers = append(maintainers, Maintainer{Line: n + 1, Section: section, Name: name, Owner: owner, Role: role})
+		}
+	}
+	return maintainers
+}
+
+// person returns the name and the GitHub login (as @login) or email address of the person an entry refers to,
+// as in "Jane Doe <jane@example.com>", "* Jane Doe (@jdoe)" or "| Jane Doe | [@jdoe](https://github.com/jdoe) |"
+func person(entry string) (name, owner string) {
+	switch {
+	case login.MatchString(entry):
+		owner = "@" + login.FindStringSubmatch(entry)[1]
+	case profileLink.MatchString(entry):
+		owner = "@" + profileLink.FindStringSubmatch(entry)[1]
+	case email.MatchString(entry):
+		owner = email.FindString(entry)
+	default:
+		return "", ""
+	}
+
+	// the name is the first cell of a table row, or what comes before the login or email address otherwise
+	if strings.HasPrefix(entry, "|") {
+		name = strings.Split(strings.Trim(entry, "|"), "|")[0]
+	} else {
+		name = strings.TrimLeft(entry, "*-+ \t")
+		if end := strings.IndexAny(name, "<(@[|,"); end >= 0 {
+			name = name[:end]
+		}
+	}
+	name = strings.Trim(strings.TrimSpace(name), "*_`")
+
+	// names that are an email address or link themselves aren't names
+	if email.MatchString(name) || strings.Contains(name, "://") {
+		name = ""
+	}
+	return name, owner
+}
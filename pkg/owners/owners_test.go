@@ -0,0 +1,115 @@
+package owners_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/pkg/owners"
+)
+
+func TestParseOwners(t *testing.T) {
+	aliases, err := owners.ParseAliases(`
+aliases:
+  sig-storage-leads:
+    - alice
+    - Bob
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := owners.ParseOwners(`# See the OWNERS docs at https://go.k8s.io/owners
+approvers:
+  - sig-storage-leads
+  - Carol
+reviewers:
+  - dave
+emeritus_approvers:
+  - erin
+filters:
+  "\\.proto$":
+    approvers:
+      - frank
+options:
+  no_parent_owners: true
+`, aliases)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []owners.Owner{
+		{Login: "alice", Role: "approver", Alias: "sig-storage-leads"},
+		{Login: "bob", Role: "approver", Alias: "sig-storage-leads"},
+		{Login: "carol", Role: "approver"},
+		{Login: "dave", Role: "reviewer"},
+		{Login: "erin", Role: "emeritus_approver"},
+		{Login: "frank", Role: "approver", Filter: `\.proto$`},
+	}
+	if !reflect.DeepEqual(parsed, expected) {
+		t.Fatalf("unexpected owners: %+v", parsed)
+	}
+
+	if _, err := owners.ParseOwners("approvers: [", nil); err == nil {
+		t.Fatal("expected an error parsing an invalid OWNERS file")
+	}
+}
+
+func TestParseKernelMaintainers(t *testing.T) {
+	parsed := owners.ParseMaintainers(`List of maintainers
+===================
+
+EXT4 FILE SYSTEM
+M:	"Theodore Ts'o" <tytso@mit.edu>
+R:	Andreas Dilger <adilger.kernel@dilger.ca>
+L:	linux-ext4@vger.kernel.org
+S:	Maintained
+F:	Documentation/filesystems/ext4/
+F:	fs/ext4/
+
+GIT TOOLING
+M:	Jane Doe <jane@example.com>
+S:	Odd Fixes
+`)
+
+	expected := []owners.Maintainer{
+		{Line: 5, Section: "EXT4 FILE SYSTEM", Pattern: "Documentation/filesystems/ext4/", Name: `"Theodore Ts'o"`, Owner: "tytso@mit.edu", Role: "maintainer"},
+		{Line: 5, Section: "EXT4 FILE SYSTEM", Pattern: "fs/ext4/", Name: `"Theodore Ts'o"`, Owner: "tytso@mit.edu", Role: "maintainer"},
+		{Line: 6, Section: "EXT4 FILE SYSTEM", Pattern: "Documentation/filesystems/ext4/", Name: "Andreas Dilger", Owner: "adilger.kernel@dilger.ca", Role: "reviewer"},
+		{Line: 6, Section: "EXT4 FILE SYSTEM", Pattern: "fs/ext4/", Name: "Andreas Dilger", Owner: "adilger.kernel@dilger.ca", Role: "reviewer"},
+		{Line: 13, Section: "GIT TOOLING", Name: "Jane Doe", Owner: "jane@example.com", Role: "maintainer"},
+	}
+	if !reflect.DeepEqual(parsed, expected) {
+		t.Fatalf("unexpected maintainers: %+v", parsed)
+	}
+}
+
+func TestParseListedMaintainers(t *testing.T) {
+	parsed := owners.ParseMaintainers(`# Maintainers
+
+| Name | GitHub | Affiliation |
+|------|--------|-------------|
+| Jane Doe | [@jdoe](https://github.com/jdoe) | Example Corp |
+| John Roe | https://github.com/jroe | |
+
+## Reviewers
+
+* Ann Smith (@asmith)
+- Bo Chen <bo@example.com>
+
+## Emeritus Maintainers
+
+- Old Timer, https://github.com/oldtimer
+See https://github.com/example/project/blob/main/GOVERNANCE.md for how to become one.
+`)
+
+	expected := []owners.Maintainer{
+		{Line: 5, Section: "Maintainers", Name: "Jane Doe", Owner: "@jdoe", Role: "maintainer"},
+		{Line: 6, Section: "Maintainers", Name: "John Roe", Owner: "@jroe", Role: "maintainer"},
+		{Line: 10, Section: "Reviewers", Name: "Ann Smith", Owner: "@asmith", Role: "reviewer"},
+		{Line: 11, Section: "Reviewers", Name: "Bo Chen", Owner: "bo@example.com", Role: "reviewer"},
+		{Line: 15, Section: "Emeritus Maintainers", Name: "Old Timer", Owner: "@oldtimer", Role: "emeritus_maintainer"},
+	}
+	if !reflect.DeepEqual(parsed, expected) {
+		t.Fatalf("unexpected maintainers: %+v", parsed)
+	}
+}
@@ -0,0 +1,56 @@
+package query
+
+import "strings"
+
+// Split splits sql into its statements, on the semicolons outside of string literals, quoted identifiers and comments,
+// leaving out empty statements. Statements holding semicolons of their own (such as CREATE TRIGGER) aren't supported.
+func Split(sql string) []string {
+	var statements []string
+	var start int
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; c {
+		case '\'', '"', '`':
+			if end := strings.IndexByte(sql[i+1:], c); end >= 0 {
+				i += end + 1 // doubled quotes are read as two literals in a row
+			} else {
+				i = len(sql)
+			}
+		case '[':
+			if end := strings.IndexByte(sql[i+1:], ']'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(sql)
+			}
+		case '-':
+			if strings.HasPrefix(sql[i:], "--") {
+				if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+					i += end
+				} else {
+					i = len(sql)
+				}
+			}
+		case '/':
+			if strings.HasPrefix(sql[i:], "/*") {
+				if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+					i += end + 3
+				} else {
+					i = len(sql)
+				}
+			}
+		case ';':
+			statements = appendStatement(statements, sql[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(sql) {
+		statements = appendStatement(statements, sql[start:])
+	}
+	return statements
+}
+
+func appendStatement(statements []string, statement string) []string {
+	if statement = strings.TrimSpace(statement); statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements
+}
@@ -0,0 +1,24 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := map[string][]string{
+		"SELECT 1":                      {"SELECT 1"},
+		"SELECT 1; SELECT 2;\n":         {"SELECT 1", "SELECT 2"},
+		"SELECT ';', \"a;b\"; SELECT 2": {"SELECT ';', \"a;b\"", "SELECT 2"},
+		"SELECT 'it''s; fine'":          {"SELECT 'it''s; fine'"},
+		"SELECT 1 -- one; two\n; SELECT [a;b] FROM t /* ; */": {"SELECT 1 -- one; two", "SELECT [a;b] FROM t /* ; */"},
+		" ; ;":                          nil,
+		"SELECT 'unterminated; literal": {"SELECT 'unterminated; literal"},
+	}
+
+	for sql, expected := range tests {
+		if statements := Split(sql); !reflect.DeepEqual(statements, expected) {
+			t.Fatalf("expected %q to be split into %q, got: %q", sql, expected, statements)
+		}
+	}
+}
@@ -0,0 +1,472 @@
+// Package terraform implements a parser of the dependencies declared by Terraform configurations (.tf files, written
+// in HCL): the modules they call, and the providers they require. See https://developer.hashicorp.com/terraform/language.
+// Only literal strings are evaluated, other expressions (such as references to variables) are skipped.
+package terraform
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Module is a call of a module, as in module "vpc" { source = "terraform-aws-modules/vpc/aws", version = "~> 5.0" }
+type Module struct {
+	Name    string // label of the module block
+	Source  string
+	Version string // version constraint, empty if none (as for local or git sources)
+	Line    int
+}
+
+// Provider is a provider requirement, as in terraform { required_providers { aws = { source = "hashicorp/aws", version = "~> 5.0" } } },
+// or in the legacy form aws = "~> 2.0"
+type Provider struct {
+	Name    string // local name of the provider
+	Source  string // empty if not set (as in the legacy form)
+	Version string // version constraint, empty if none
+	Line    int
+}
+
+// Config holds the dependencies declared by a Terraform configuration file
+type Config struct {
+	Modules   []Module
+	Providers []Provider
+}
+
+// Parse parses the contents of a .tf file, returning the modules and providers it declares
+func Parse(input string) (*Config, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.body(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var config = &Config{}
+	for _, b := range root.blocks {
+		switch b.typ {
+		case "module":
+			if len(b.labels) == 0 {
+				continue
+			}
+			config.Modules = append(config.Modules, Module{
+				Name:    b.labels[0],
+				Source:  b.attrs["source"].string(),
+				Version: b.attrs["version"].string(),
+				Line:    b.line,
+			})
+		case "terraform":
+			for _, required := range b.blocks {
+				if required.typ != "required_providers" {
+					continue
+				}
+				for _, name := range required.order {
+					value := required.attrs[name]
+					provider := Provider{Name: name, Line: value.line}
+					if value.object != nil {
+						provider.Source, provider.Version = value.object["source"].string(), value.object["version"].string()
+					} else {
+						provider.Version = value.string()
+					}
+					config.Providers = append(config.Providers, provider)
+				}
+			}
+		}
+	}
+
+	return config, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNewline
+	tokenIdent
+	tokenString // quoted strings and heredocs, text holding their contents
+	tokenPunct  // single characters such as { = ,
+	tokenOther  // numbers
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	line int
+}
+
+// tokenize splits input into tokens, leaving out comments and whitespace other than newlines (which end attributes)
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	var line = 1
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == '\n':
+			tokens = append(tokens, token{kind: tokenNewline, line: line})
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#' || strings.HasPrefix(input[i:], "//"):
+			for i < len(input) && input[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(input[i:], "/*"):
+			end := strings.Index(input[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at line %d", line)
+			}
+			line += strings.Count(input[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"':
+			end, err := scanTemplate(input, i+1)
+			if err != nil {
+				return nil, fmt.Errorf("%v at line %d", err, line)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: unquote(input[i+1 : end]), line: line})
+			line += strings.Count(input[i:end], "\n")
+			i = end + 1
+		case strings.HasPrefix(input[i:], "<<"):
+			text, end, err := scanHeredoc(input, i)
+			if err != nil {
+				return nil, fmt.Errorf("%v at line %d", err, line)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, line: line})
+			line += strings.Count(input[i:end], "\n")
+			i = end
+		case isIdentStart(c):
+			start := i
+			for i < len(input) && (isIdentStart(input[i]) || isDigit(input[i]) || input[i] == '-') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: input[start:i], line: line})
+		case isDigit(c):
+			start := i
+			for i < len(input) && (isDigit(input[i]) || input[i] == '.' || input[i] == 'e' || input[i] == 'E') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenOther, text: input[start:i], line: line})
+		default:
+			tokens = append(tokens, token{kind: tokenPunct, text: string(c), line: line})
+			i++
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, line: line}), nil
+}
+
+// scanTemplate returns the index of the quote ending the quoted template starting at start (after its opening quote),
+// skipping over the interpolations and directives it contains (which may themselves contain quoted templates)
+func scanTemplate(input string, start int) (int, error) {
+	for i := start; i < len(input); i++ {
+		switch {
+		case input[i] == '\\':
+			i++
+		case input[i] == '"':
+			return i, nil
+		case input[i] == '\n':
+			return 0, fmt.Errorf("unterminated string")
+		case strings.HasPrefix(input[i:], "$${") || strings.HasPrefix(input[i:], "%%{"):
+			i += 2
+		case strings.HasPrefix(input[i:], "${") || strings.HasPrefix(input[i:], "%{"):
+			depth := 0
+			for i += 1; i < len(input); i++ {
+				if input[i] == '{' {
+					depth++
+				} else if input[i] == '}' {
+					if depth--; depth == 0 {
+						break
+					}
+				} else if input[i] == '"' {
+					end, err := scanTemplate(input, i+1)
+					if err != nil {
+						return 0, err
+					}
+					i = end
+				}
+			}
+		}
+	}
+	return 0, fmt.Errorf("unterminated string")
+}
+
+// unquote replaces the escape sequences of a quoted template with the characters they stand for
+func unquote(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u', 'U':
+			size := 4
+			if s[i] == 'U' {
+				size = 8
+			}
+			if i+1+size > len(s) {
+				b.WriteByte(s[i])
+			} else if r, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32); err == nil {
+				b.WriteRune(rune(r))
+				i += size
+			} else {
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// scanHeredoc returns the contents of the heredoc starting at start (as in <<EOF or <<-EOF) and the index following it
+func scanHeredoc(input string, start int) (string, int, error) {
+	i := start + 2
+	indented := strings.HasPrefix(input[i:], "-")
+	if indented {
+		i++
+	}
+
+	newline := strings.IndexByte(input[i:], '\n')
+	if newline < 0 {
+		return "", 0, fmt.Errorf("unterminated heredoc")
+	}
+	marker := strings.TrimSpace(input[i : i+newline])
+	if marker == "" {
+		return "", 0, fmt.Errorf("invalid heredoc")
+	}
+
+	var lines []string
+	for i += newline + 1; i <= len(input); {
+		end := strings.IndexByte(input[i:], '\n')
+		if end < 0 {
+			end = len(input) - i
+		}
+		line := input[i : i+end]
+		if strings.TrimSpace(line) == marker {
+			if indented {
+				lines = trimIndentation(lines)
+			}
+			return strings.Join(lines, "\n"), i + end, nil
+		}
+		lines = append(lines, line)
+		i += end + 1
+	}
+	return "", 0, fmt.Errorf("unterminated heredoc")
+}
+
+// trimIndentation removes the leading whitespace lines have in common, as for indented heredocs
+func trimIndentation(lines []string) []string {
+	var indent = -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if n := len(line) - len(strings.TrimLeft(line, " \t")); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for l, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			lines[l] = line[indent:]
+		}
+	}
+	return lines
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// value is the value of an attribute, if it's a literal string or an object
+type value struct {
+	str    *string
+	object map[string]*value
+	line   int
+}
+
+// string returns the value if it's a literal string, else an empty string
+func (v *value) string() string {
+	if v == nil || v.str == nil {
+		return ""
+	}
+	return *v.str
+}
+
+// block is a block (or the whole file) with its attributes and nested blocks
+type block struct {
+	typ    string
+	labels []string
+	attrs  map[string]*value
+	order  []string // names of the attributes, in the order they're declared
+	blocks []*block
+	line   int
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) skipNewlines() {
+	for p.peek().kind == tokenNewline {
+		p.next()
+	}
+}
+
+// body parses the attributes and blocks of a body, up to its closing brace if nested
+func (p *parser) body(nested bool) (*block, error) {
+	var b = &block{attrs: make(map[string]*value)}
+	for {
+		p.skipNewlines()
+		tok := p.next()
+		switch {
+		case tok.kind == tokenEOF:
+			if nested {
+				return nil, fmt.Errorf("unexpected end of file, expected }")
+			}
+			return b, nil
+		case tok.kind == tokenPunct && tok.text == "}" && nested:
+			return b, nil
+		case tok.kind != tokenIdent:
+			return nil, fmt.Errorf("unexpected %q at line %d, expected an attribute or block", tok.text, tok.line)
+		}
+
+		if next := p.peek(); next.kind == tokenPunct && next.text == "=" {
+			p.next()
+			v := p.expression()
+			v.line = tok.line
+			if _, ok := b.attrs[tok.text]; !ok {
+				b.order = append(b.order, tok.text)
+			}
+			b.attrs[tok.text] = v
+			continue
+		}
+
+		var labels []string
+		for p.peek().kind == tokenString || p.peek().kind == tokenIdent {
+			labels = append(labels, p.next().text)
+		}
+		if open := p.next(); open.kind != tokenPunct || open.text != "{" {
+			return nil, fmt.Errorf("unexpected %q at line %d, expected {", open.text, open.line)
+		}
+
+		nestedBlock, err := p.body(true)
+		if err != nil {
+			return nil, err
+		}
+		nestedBlock.typ, nestedBlock.labels, nestedBlock.line = tok.text, labels, tok.line
+		b.blocks = append(b.blocks, nestedBlock)
+	}
+}
+
+// expression parses an expression up to its end (a newline, comma or closing bracket that isn't nested in it),
+// evaluating it if it's a literal string or an object
+func (p *parser) expression() *value {
+	var v = &value{}
+	switch tok := p.peek(); {
+	case tok.kind == tokenString:
+		p.next()
+		v.str = &tok.text
+	case tok.kind == tokenPunct && tok.text == "{":
+		p.next()
+		v.object = p.object()
+	}
+
+	// anything following is part of an expression that isn't a literal (such as "a" + "b"), which isn't evaluated
+	var depth int
+	for {
+		tok := p.peek()
+		if tok.kind == tokenEOF {
+			return v
+		}
+		if tok.kind == tokenPunct {
+			switch tok.text {
+			case "{", "[", "(":
+				depth++
+			case "}", "]", ")":
+				if depth == 0 {
+					return v
+				}
+				depth--
+			case ",":
+				if depth == 0 {
+					return v
+				}
+			}
+		} else if tok.kind == tokenNewline && depth == 0 {
+			return v
+		}
+		v = &value{}
+		p.next()
+	}
+}
+
+// object parses the attributes of an object constructor (after its opening brace) up to its closing brace.
+// Other expressions in braces (such as for expressions) are skipped, and nil returned.
+func (p *parser) object() map[string]*value {
+	var object = make(map[string]*value)
+	for {
+		for tok := p.peek(); tok.kind == tokenNewline || (tok.kind == tokenPunct && tok.text == ","); tok = p.peek() {
+			p.next()
+		}
+
+		key := p.next()
+		switch {
+		case key.kind == tokenEOF:
+			return object
+		case key.kind == tokenPunct && key.text == "}":
+			return object
+		case key.kind == tokenIdent || key.kind == tokenString:
+			if sep := p.peek(); sep.kind == tokenPunct && (sep.text == "=" || sep.text == ":") {
+				p.next()
+				v := p.expression()
+				v.line = key.line
+				object[key.text] = v
+				continue
+			}
+		}
+
+		p.skipBraces()
+		return nil
+	}
+}
+
+// skipBraces skips the tokens up to the closing brace of the braces being parsed
+func (p *parser) skipBraces() {
+	for depth := 1; depth > 0; {
+		tok := p.next()
+		switch {
+		case tok.kind == tokenEOF:
+			return
+		case tok.kind == tokenPunct && tok.text == "{":
+			depth++
+		case tok.kind == tokenPunct && tok.text == "}":
+			depth--
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package terraform_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/pkg/terraform"
+)
+
+func TestParse(t *testing.T) {
+	tf := `
+terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+    random = { source = "hashicorp/random" }
+    null   = "~> 2.1" # legacy form
+  }
+}
+
+/* the network,
+   shared by all environments */
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.8.1"
+
+  name = "main-${var.environment}"
+  azs  = [for az in data.aws_availability_zones.available.names : az if az != "us-east-1e"]
+  tags = merge(local.tags, {
+    Name = "main"
+  })
+}
+
+// modules without a version, from git or local paths
+module "dns" {
+  source = "git::https://github.com/example/terraform-dns.git?ref=v1.2.0"
+}
+
+module "app" { source = "./modules/app" }
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs-${var.environment == "prod" ? "p" : "np"}"
+  policy = <<-EOT
+    {
+      "Version": "2012-10-17"
+    }
+  EOT
+}
+`
+
+	config, err := terraform.Parse(tf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedModules := []terraform.Module{
+		{Name: "vpc", Source: "terraform-aws-modules/vpc/aws", Version: "5.8.1", Line: 17},
+		{Name: "dns", Source: "git::https://github.com/example/terraform-dns.git?ref=v1.2.0", Line: 29},
+		{Name: "app", Source: "./modules/app", Line: 33},
+	}
+	if !reflect.DeepEqual(config.Modules, expectedModules) {
+		t.Fatalf("unexpected modules: %+v", config.Modules)
+	}
+
+	expectedProviders := []terraform.Provider{
+		{Name: "aws", Source: "hashicorp/aws", Version: "~> 5.0", Line: 6},
+		{Name: "random", Source: "hashicorp/random", Line: 10},
+		{Name: "null", Version: "~> 2.1", Line: 11},
+	}
+	if !reflect.DeepEqual(config.Providers, expectedProviders) {
+		t.Fatalf("unexpected providers: %+v", config.Providers)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, tf := range []string{
+		`module "vpc" {`,
+		`module "vpc" { source = "terraform-aws-modules/vpc/aws }`,
+		`= "value"`,
+	} {
+		if _, err := terraform.Parse(tf); err == nil {
+			t.Fatalf("expected an error parsing %q", tf)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Package vcr records the HTTP interactions of tests with APIs (such as GitHub's) in "cassettes", and replays them
+// on later runs, so that tests querying API backed tables run deterministically, and without credentials.
+//
+// A Transport is set once for all tests (such as with options.WithHTTPTransport when registering the extension),
+// and every test then starts its own cassette, named after the test:
+//
+//	var transport = &vcr.Transport{}
+//
+//	func TestMain(m *testing.M) {
+//		sqlite.Register(extensions.RegisterFn(options.WithGitHub(), options.WithHTTPTransport(transport)))
+//		os.Exit(m.Run())
+//	}
+//
+//	func TestStargazers(t *testing.T) {
+//		defer transport.Start(t)()
+//		...
+//	}
+//
+// Cassettes that don't exist yet are recorded, making actual requests (with whatever credentials are set).
+// Setting the VCR_RECORD environment variable records all cassettes again.
+package vcr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/dnaeon/go-vcr/v2/cassette"
+	"github.com/dnaeon/go-vcr/v2/recorder"
+)
+
+// Transport is an http.RoundTripper replaying (or recording) the interactions of the cassette of the current test
+type Transport struct {
+	// Dir is the directory cassettes are stored in, "fixtures" if empty
+	Dir string
+
+	// Real makes the requests being recorded. If nil, http.DefaultTransport is used.
+	Real http.RoundTripper
+
+	mu       sync.Mutex
+	recorder *recorder.Recorder
+}
+
+// Start starts replaying the cassette named after the test (recording it if it doesn't exist, or if VCR_RECORD is set).
+// The returned function stops it, saving the interactions that were recorded, if any.
+func (t *Transport) Start(tb testing.TB) (stop func()) {
+	tb.Helper()
+
+	dir := t.Dir
+	if dir == "" {
+		dir = "fixtures"
+	}
+
+	mode := recorder.ModeReplaying
+	if os.Getenv("VCR_RECORD") != "" {
+		mode = recorder.ModeRecording
+	}
+
+	r, err := recorder.NewAsMode(filepath.Join(dir, tb.Name()), mode, t.Real)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	r.SkipRequestLatency = true
+
+	// credentials are never saved in cassettes
+	r.AddSaveFilter(func(i *cassette.Interaction) error {
+		delete(i.Request.Headers, "Authorization")
+		return nil
+	})
+
+	t.mu.Lock()
+	t.recorder = r
+	t.mu.Unlock()
+
+	return func() {
+		t.mu.Lock()
+		t.recorder = nil
+		t.mu.Unlock()
+
+		if err := r.Stop(); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	r := t.recorder
+	t.mu.Unlock()
+
+	if r == nil {
+		return nil, errors.New("vcr: no cassette started (call Start at the beginning of the test)")
+	}
+
+	res, err := r.RoundTrip(req)
+	if errors.Is(err, cassette.ErrInteractionNotFound) {
+		return nil, fmt.Errorf("vcr: %s %s is not in the cassette (set VCR_RECORD to record it again): %w", req.Method, req.URL, err)
+	}
+	return res, err
+}
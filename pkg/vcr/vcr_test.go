@@ -0,0 +1,65 @@
+package vcr_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/pkg/vcr"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":"recorded"}`))
+	}))
+
+	transport := &vcr.Transport{Dir: t.TempDir()}
+	client := &http.Client{Transport: transport}
+	get := func() (string, error) {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		req.Header.Set("Authorization", "bearer secret")
+		res, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		b, err := io.ReadAll(res.Body)
+		return string(b), err
+	}
+
+	// the cassette doesn't exist yet, so the interaction is recorded
+	stop := transport.Start(t)
+	if out, err := get(); err != nil || out != `{"data":"recorded"}` {
+		t.Fatalf("unexpected response: %s (%v)", out, err)
+	}
+	stop()
+
+	cassette, err := os.ReadFile(filepath.Join(transport.Dir, t.Name()+".yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(cassette), "secret") {
+		t.Fatal("expected credentials to not be saved in the cassette")
+	}
+
+	// the server is gone, so the interaction can only be replayed
+	srv.Close()
+	defer transport.Start(t)()
+	if out, err := get(); err != nil || out != `{"data":"recorded"}` {
+		t.Fatalf("unexpected replayed response: %s (%v)", out, err)
+	}
+	if _, err := get(); err == nil {
+		t.Fatal("expected an error for an interaction not in the cassette")
+	}
+}
+
+func TestNotStarted(t *testing.T) {
+	client := &http.Client{Transport: &vcr.Transport{}}
+	if _, err := client.Get("http://localhost"); err == nil {
+		t.Fatal("expected an error without a cassette")
+	}
+}
@@ -35,6 +35,15 @@ func init() {
 		options.WithSourcegraph(),
 		options.WithContextValue("sourcegraphToken", os.Getenv("SOURCEGRAPH_TOKEN")),
 		options.WithNPM(),
+		options.WithSlack(),
+		options.WithContextValue("slackToken", os.Getenv("SLACK_TOKEN")),
+		options.WithPagerDuty(),
+		options.WithContextValue("pagerdutyToken", os.Getenv("PAGERDUTY_TOKEN")),
+		options.WithObjectStorage(),
+		options.WithOCI(),
+		options.WithKubernetes(),
+		options.WithAllowHTTP(os.Getenv("MERGESTAT_ALLOW_HTTP") != ""),
+		options.WithContextValue("httpGetMaxBytes", os.Getenv("HTTP_GET_MAX_BYTES")),
 	))
 }
 